@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// runDiff 实现 diff 子命令: 对比两次扫描的 JSON 结果
+//
+//	diff [-html] [-o output/] [-lang zh] old.json new.json
+//
+// 存在新增发现时以 1 退出，可用于 CI 卡点
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	html := fs.Bool("html", false, "Also write an HTML diff report (new findings highlighted, fixed ones struck through).")
+	out := fs.String("o", report.OutputDir, "Output directory for the HTML diff report.")
+	lang := fs.String("lang", "", "Language of the HTML diff report: 'en' or 'zh' (default: as recorded in the new JSON, otherwise from LC_ALL / LANG).")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: diff [-html] [-o output/] [-lang zh] old.json new.json")
+		return 2
+	}
+
+	oldChains, oldMeta, err := report.LoadJSON(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to load %s: %v\n", fs.Arg(0), err)
+		return 2
+	}
+	newChains, newMeta, err := report.LoadJSON(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to load %s: %v\n", fs.Arg(1), err)
+		return 2
+	}
+
+	// 两次扫描可能在不同的环境 (容器 / 宿主机) 中进行: 都按各自记录的 -path-map 换成宿主机路径再比较
+	oldChains, oldMeta = report.HostView(oldChains, oldMeta)
+	newChains, newMeta = report.HostView(newChains, newMeta)
+
+	d := report.DiffChains(oldChains, oldMeta.ProjectRoot, newChains, newMeta.ProjectRoot)
+	sum := d.Summary()
+	fmt.Printf("%s new, %s fixed, %d unchanged\n",
+		color.RedString("%d", sum.New), color.GreenString("%d", sum.Fixed), sum.Unchanged)
+
+	for i, stack := range d.New {
+		printDiffFinding(i+1, stack, newMeta.ProjectRoot)
+	}
+
+	if *html {
+		if *lang != "" || newMeta.Locale == "" {
+			if newMeta.Locale, err = reportLocale(*lang); err != nil {
+				fmt.Fprintf(os.Stderr, "[-] Invalid -lang: %v\n", err)
+				return 2
+			}
+		}
+		report.OutputDir = *out
+		report.GenerateDiffHTML(d, oldMeta.ProjectRoot, newMeta.ProjectRoot, newMeta)
+	}
+
+	if sum.New > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printDiffFinding 在控制台输出一条新增发现 (Sink 和 Source 位置)
+func printDiffFinding(n int, stack []model.ChainStep, root string) {
+	if len(stack) == 0 {
+		return
+	}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(root, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+
+	sink, source := stack[0], stack[len(stack)-1]
+	name := "Uncategorized"
+	if sink.Rule != nil {
+		name = sink.Rule.Name
+	}
+	color.Red("\n[NEW %d] %s", n, name)
+	fmt.Printf("    Sink:   %s (%s:%d)\n", sink.QualifiedFunc(), rel(sink.File), sink.Line+1)
+	if len(stack) > 1 {
+		fmt.Printf("    Source: %s (%s:%d)\n", source.QualifiedFunc(), rel(source.File), source.Line+1)
+	}
+	if sink.Code != "" {
+		fmt.Printf("    Code:   %s\n", sink.Code)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/analysis"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+	"LSPTracer/internal/textutil"
+
+	"github.com/fatih/color"
+)
+
+// dryRunReport -dry-run 的 JSON 输出 (路径相对工作区根目录，行号从 1 开始)
+type dryRunReport struct {
+	WorkspaceRoot string         `json:"workspace_root"`
+	JavaLevel     string         `json:"java_level"`
+	SourceRoots   []string       `json:"source_roots"`
+	Modules       []dryRunModule `json:"modules,omitempty"` // 多模块项目中每个模块一个 Eclipse 项目
+	Candidates    int            `json:"candidates"`
+	Rules         []dryRunRule   `json:"rules"` // 候选点多的规则在前
+}
+
+type dryRunModule struct {
+	Name        string   `json:"name"`
+	Dir         string   `json:"dir"`
+	SourceRoots []string `json:"source_roots"`
+	Deps        []string `json:"depends_on,omitempty"`
+}
+
+type dryRunRule struct {
+	Rule       string            `json:"rule"`
+	Count      int               `json:"count"`
+	Candidates []dryRunCandidate `json:"candidates,omitempty"`
+}
+
+type dryRunCandidate struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Code string `json:"code"`
+}
+
+// applyWalkOptions 把影响文件遍历的参数 (排除、符号链接、生成源码、范围) 设置到追踪器上
+func applyWalkOptions(t *analysis.Tracer, opts Options, scope analysis.Scope) {
+	t.Exclude = opts.Exclude
+	t.FollowSymlinks = opts.FollowSymlinks
+	t.IncludeGenerated = opts.IncludeGenerated
+	t.TreatEnvAsTaint = opts.TreatEnvAsTaint
+	t.Scope = scope
+}
+
+// runDryRun 输出将要配置的源码目录和模块、按规则分组的候选点；formats 包含 json 时同时写入 OutputDir
+func runDryRun(t *analysis.Tracer, rules []model.SinkRule, level analysis.JavaLevel, formats string) {
+	root := t.ProjectRoot
+	color.Cyan("[*] Dry run: JDT.LS is not started and no project files are written.")
+
+	out := dryRunReport{WorkspaceRoot: root, JavaLevel: level.Level}
+	srcDirs, modules, err := analysis.PlanEclipseConfig(root, t.FollowSymlinks)
+	if err != nil {
+		color.Yellow("[!] Source root detection failed: %v", err)
+	}
+	for _, dir := range srcDirs {
+		out.SourceRoots = append(out.SourceRoots, relPath(root, dir))
+	}
+	if len(modules) > 1 {
+		for _, m := range modules {
+			module := dryRunModule{Name: m.Name, Dir: relPath(root, m.Dir), Deps: m.Deps}
+			for _, dir := range m.SrcDirs {
+				module.SourceRoots = append(module.SourceRoots, relPath(root, dir))
+			}
+			out.Modules = append(out.Modules, module)
+		}
+	}
+
+	start := time.Now()
+	var zeroHit []string
+	for _, g := range t.PreviewCandidates(rules) {
+		rule := dryRunRule{Rule: g.Rule, Count: len(g.Candidates)}
+		for _, c := range g.Candidates {
+			rule.Candidates = append(rule.Candidates, dryRunCandidate{File: relPath(root, c.File), Line: c.Line, Code: c.Code})
+		}
+		if rule.Count == 0 {
+			zeroHit = append(zeroHit, rule.Rule)
+		}
+		out.Candidates += rule.Count
+		out.Rules = append(out.Rules, rule)
+	}
+
+	fmt.Printf("\nSource roots (%d):\n", len(out.SourceRoots))
+	for _, dir := range out.SourceRoots {
+		fmt.Printf("    %s\n", dir)
+	}
+	if len(out.Modules) > 0 {
+		fmt.Printf("\nModules (%d):\n", len(out.Modules))
+		for _, m := range out.Modules {
+			deps := ""
+			if len(m.Deps) > 0 {
+				deps = " -> " + strings.Join(m.Deps, ", ")
+			}
+			fmt.Printf("    %-24s %s%s\n", m.Name, m.Dir, deps)
+		}
+	}
+	fmt.Printf("\nCandidates by rule (%d in %s):\n", out.Candidates, time.Since(start).Round(time.Millisecond))
+	for _, r := range out.Rules {
+		if r.Count == 0 {
+			continue
+		}
+		color.New(color.Bold).Printf("  %4d  %s\n", r.Count, r.Rule)
+		for _, c := range r.Candidates {
+			fmt.Printf("        %s:%d  %s\n", c.File, c.Line, textutil.Truncate(c.Code, 80))
+		}
+	}
+	if len(zeroHit) > 0 {
+		color.Yellow("\nZero-hit rules: %s", strings.Join(zeroHit, ", "))
+	}
+	fmt.Println("\nComposite rules are matched per method after indexing and are not part of the preview.")
+
+	for _, format := range strings.Split(strings.ToLower(formats), ",") {
+		if strings.TrimSpace(format) != "json" {
+			continue
+		}
+		path, err := writeDryRunJSON(out)
+		if err != nil {
+			color.Red("[-] Failed to write dry-run JSON: %v", err)
+			return
+		}
+		color.Green("[+] Dry-run preview written: %s", path)
+	}
+}
+
+func writeDryRunJSON(out dryRunReport) (string, error) {
+	if err := os.MkdirAll(report.OutputDir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(report.OutputDir, fmt.Sprintf("dryrun_%d.json", time.Now().Unix()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	abs, _ := filepath.Abs(path)
+	return abs, nil
+}
+
+// relPath 相对 root 的展示路径 (根目录本身为 ".")
+func relPath(root, path string) string {
+	if path == "" {
+		return "."
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,854 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/env"
+	"LSPTracer/internal/lang"
+	"LSPTracer/internal/report"
+)
+
+// e2eDir 集成测试的固定数据: 一个 Spring Maven 项目 (project/) 和回放给它的 JDT.LS 交互 (lsp_script.json)
+const e2eDir = "testdata/e2e"
+
+// copyFixture 把固定的项目复制到临时目录 (light 模式会在项目中生成 .project/.classpath)
+func copyFixture(t *testing.T, src string) string {
+	t.Helper()
+	dst := t.TempDir()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+// fakeDeps 已经 "安装" 好 JDT.LS 和 Lombok 的依赖目录，prepareEnv 不会下载
+func fakeDeps(t *testing.T) string {
+	t.Helper()
+	deps := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(deps, "jdtls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(deps, "lombok.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return deps
+}
+
+// parseTestArgs 像 main() 一样解析命令行参数并生成 Options，测试结束后恢复参数和 parseOptions 设置的全局状态
+func parseTestArgs(t *testing.T, args ...string) Options {
+	t.Helper()
+	outputDir, depsDir := report.OutputDir, env.DepsDir
+	t.Cleanup(func() {
+		for _, arg := range args {
+			if name, ok := strings.CutPrefix(arg, "-"); ok {
+				f := flag.Lookup(name)
+				f.Value.Set(f.DefValue)
+			}
+		}
+		// 可重复的参数 Set 是追加，直接清空
+		argFiles, argPathMap = nil, nil
+		report.OutputDir, env.DepsDir = outputDir, depsDir
+	})
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := parseOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Args = args
+	return opts
+}
+
+// e2eReport JSON 报告中测试检查的部分
+type e2eReport struct {
+	Metadata struct {
+		TotalChains int `json:"total_chains"`
+		Stats       struct {
+			FanOut []struct {
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			} `json:"fan_out_limited"`
+		} `json:"stats"`
+		Provenance *report.Provenance `json:"provenance"`
+		Summary    struct {
+			ExcludedCode []struct {
+				Name  string `json:"name"`
+				Count int    `json:"count"`
+			} `json:"excluded_code"`
+		} `json:"summary"`
+	} `json:"metadata"`
+	Findings []struct {
+		ID           int      `json:"id"`
+		Fingerprint  string   `json:"fingerprint"`
+		VulnType     string   `json:"vuln_type"`
+		Title        string   `json:"title"`
+		Verification string   `json:"verification"`
+		SourceKind   string   `json:"source_kind"`
+		Routes       []string `json:"routes"`
+		Termination  string   `json:"termination"`
+		Strict       string   `json:"strict_excluded"`
+		Severity     string   `json:"severity"`
+		Effective    string   `json:"effective_severity"`
+		CodeKind     string   `json:"code_kind"`
+		TestOnly     bool     `json:"test_only"`
+		Signals      *struct {
+			VerifiedBy string `json:"verified_by"`
+			Evidence   string `json:"verification_evidence"`
+			DataFlow   bool   `json:"dataflow"`
+		} `json:"confidence_signals"`
+		Steps []struct {
+			Type     string   `json:"type"`
+			File     string   `json:"file"`
+			Line     int      `json:"line"`
+			Func     string   `json:"func"`
+			Analysis []string `json:"analysis"`
+			CodeKind string   `json:"code_kind"`
+		} `json:"steps"`
+	} `json:"findings"`
+	CallTrees []e2eCallee `json:"call_trees"`
+}
+
+// e2eCallee JSON 报告中 -direction down 的调用树
+type e2eCallee struct {
+	Func     string      `json:"func"`
+	File     string      `json:"file"`
+	Line     int         `json:"line"`
+	Rule     string      `json:"rule"`
+	Note     string      `json:"note"`
+	Children []e2eCallee `json:"children"`
+}
+
+// runE2E 用假 JDT.LS 扫描固定项目的副本 (追加 args)，返回 JSON 报告和假服务器收到的消息
+func runE2E(t *testing.T, args ...string) (e2eReport, []string) {
+	t.Helper()
+	return runFixture(t, e2eDir, args...)
+}
+
+// runFixture 与 runE2E 相同，使用 dir 中的项目和录制的交互
+func runFixture(t *testing.T, dir string, args ...string) (e2eReport, []string) {
+	t.Helper()
+	return runProject(t, copyFixture(t, filepath.Join(dir, "project")), filepath.Join(dir, "lsp_script.json"), args...)
+}
+
+// runProject 扫描已经复制好的项目 project，script 是录制的交互；args 可以覆盖默认的 -output 和 -deps-dir
+func runProject(t *testing.T, project, script string, args ...string) (e2eReport, []string) {
+	t.Helper()
+	args = append([]string{"-project", project, "-format", "json", "-output", t.TempDir(), "-deps-dir", fakeDeps(t)}, args...)
+	opts := parseTestArgs(t, args...)
+	script, err := filepath.Abs(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverLog := filepath.Join(t.TempDir(), "server.log")
+	opts.ServerCmd = fakeJdtlsCmd(script, serverLog)
+
+	if err := Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	received, _ := os.ReadFile(serverLog)
+	return readE2EReport(t, report.OutputDir), strings.Split(strings.TrimSpace(string(received)), "\n")
+}
+
+// readE2EReport 读取 dir 中唯一的 JSON 报告
+func readE2EReport(t *testing.T, dir string) e2eReport {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, "report_*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("want one JSON report, got %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep e2eReport
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+// 完整的一次扫描: Spring 接口的参数经过 CommandService.run 到达 Runtime.exec
+// 报告中的链路、标题和指纹与拆分成阶段之前相同 (指纹变化会让已有的 -baseline 失效)
+func TestRunEndToEnd(t *testing.T) {
+	rep, received := runE2E(t)
+
+	if rep.Metadata.TotalChains != 1 || len(rep.Findings) != 1 {
+		t.Fatalf("want exactly one finding, got total_chains=%d findings=%d", rep.Metadata.TotalChains, len(rep.Findings))
+	}
+	f := rep.Findings[0]
+	if f.VulnType != "RCE" || f.Title != "CommandService.run(String)" || f.Verification != "verified" {
+		t.Errorf("finding = %s %q (%s), want RCE \"CommandService.run(String)\" (verified)", f.VulnType, f.Title, f.Verification)
+	}
+	if f.Fingerprint != "4f8f099bb45c9cab6156e32cb4d6dc7b" {
+		t.Errorf("fingerprint = %s, want 4f8f099bb45c9cab6156e32cb4d6dc7b", f.Fingerprint)
+	}
+	if f.SourceKind != "HTTP" || strings.Join(f.Routes, ",") != "GET /ping" || f.Termination != "REACHED_ENTRY" {
+		t.Errorf("source = %s %v (%s), want HTTP [GET /ping] (REACHED_ENTRY)", f.SourceKind, f.Routes, f.Termination)
+	}
+	var steps []string
+	for _, s := range f.Steps {
+		steps = append(steps, fmt.Sprintf("%s %s:%d %s", s.Type, path.Base(s.File), s.Line, s.Func))
+	}
+	wantSteps := []string{
+		"SOURCE PingController.java:14 ping(String)",
+		"SINK CommandService.java:8 run(String)",
+	}
+	if !slices.Equal(steps, wantSteps) {
+		t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(steps, "\n"), strings.Join(wantSteps, "\n"))
+	}
+
+	// 与语言服务器的交互: 初始化、打开锚点、验证 Sink (definition)、查找调用者 (references)
+	wantTraffic := []string{
+		"initialize :-1",
+		"initialized :-1",
+		"workspace/didChangeConfiguration :-1",
+		"textDocument/didOpen src/main/java/com/example/demo/PingController.java:-1",
+		"textDocument/definition src/main/java/com/example/demo/CommandService.java:7",
+		"textDocument/references src/main/java/com/example/demo/CommandService.java:6",
+	}
+	if !isSubsequence(wantTraffic, received) {
+		t.Errorf("server received:\n%s\nwant in order:\n%s", strings.Join(received, "\n"), strings.Join(wantTraffic, "\n"))
+	}
+}
+
+// isSubsequence want 中的元素是否按顺序出现在 got 中
+func isSubsequence(want, got []string) bool {
+	for _, g := range got {
+		if len(want) > 0 && g == want[0] {
+			want = want[1:]
+		}
+	}
+	return len(want) == 0
+}
+
+// -dry-run 只做文本初筛，不启动语言服务器
+func TestRunDryRunSkipsServer(t *testing.T) {
+	project := copyFixture(t, filepath.Join(e2eDir, "project"))
+	opts := parseTestArgs(t, "-project", project, "-dry-run", "-output", t.TempDir())
+	opts.ServerCmd = func(lang.JavaConfig) (*exec.Cmd, error) {
+		t.Error("dry run started the language server")
+		return nil, errors.New("unexpected")
+	}
+	if err := Run(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".classpath")); err == nil {
+		t.Error("dry run generated .classpath")
+	}
+}
+
+// 语言服务器无法启动时 Run 返回错误 (而不是退出进程)，并写出只包含配置检查结果的部分报告
+func TestRunServerStartFailure(t *testing.T) {
+	project := copyFixture(t, filepath.Join(e2eDir, "project"))
+	resources := filepath.Join(project, "src", "main", "resources")
+	if err := os.MkdirAll(resources, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(resources, "application.properties"), []byte("spring.h2.console.enabled=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := t.TempDir()
+	opts := parseTestArgs(t, "-project", project, "-format", "json", "-output", output, "-deps-dir", fakeDeps(t))
+	opts.ServerCmd = func(lang.JavaConfig) (*exec.Cmd, error) {
+		return nil, errors.New("no java")
+	}
+	err := Run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "no java") {
+		t.Fatalf("Run error = %v, want the server start failure", err)
+	}
+	rep := readE2EReport(t, output)
+	if len(rep.Findings) != 1 || rep.Findings[0].VulnType != "CONFIG" {
+		t.Errorf("partial report findings = %+v, want the H2 console configuration finding", rep.Findings)
+	}
+}
+
+// 规则文件无法加载时 Run 返回错误
+func TestRunBadRules(t *testing.T) {
+	project := copyFixture(t, filepath.Join(e2eDir, "project"))
+	opts := parseTestArgs(t, "-project", project, "-rules", filepath.Join(project, "missing.yaml"), "-output", t.TempDir())
+	if err := Run(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "Failed to load rules") {
+		t.Fatalf("Run error = %v, want a rules load error", err)
+	}
+}
+
+// 追踪是并发的，同样的项目扫描两次得到的编号和顺序必须相同: 按 Sink 文件、Sink 行号排序后分配编号
+func TestRunDeterministicOrder(t *testing.T) {
+	order := func(rep e2eReport) []string {
+		var keys []string
+		for _, f := range rep.Findings {
+			sink := f.Steps[len(f.Steps)-1]
+			keys = append(keys, fmt.Sprintf("#%d %s %s:%d", f.ID, f.Fingerprint, path.Base(sink.File), sink.Line))
+		}
+		return keys
+	}
+	first, _ := runFixture(t, "testdata/order")
+	second, _ := runFixture(t, "testdata/order")
+
+	got := order(first)
+	if len(got) != 3 {
+		t.Fatalf("want three findings, got:\n%s", strings.Join(got, "\n"))
+	}
+	if again := order(second); !slices.Equal(got, again) {
+		t.Errorf("second scan:\n%s\nfirst scan:\n%s", strings.Join(again, "\n"), strings.Join(got, "\n"))
+	}
+	var sinks []string
+	for _, key := range got {
+		fields := strings.Fields(key)
+		sinks = append(sinks, fields[0]+" "+fields[2])
+	}
+	want := []string{"#1 AdminController.java:12", "#2 ToolsController.java:12", "#3 ToolsController.java:18"}
+	if !slices.Equal(sinks, want) {
+		t.Errorf("order = %v, want %v", sinks, want)
+	}
+}
+
+// 多模块项目: 每个模块生成独立的 Eclipse 项目，web 模块中的接口经过 common 模块的 CommandService 到达 Runtime.exec
+func TestRunCrossModuleChain(t *testing.T) {
+	rep, received := runFixture(t, "testdata/modules")
+
+	if len(rep.Findings) != 1 {
+		t.Fatalf("want exactly one finding, got %d", len(rep.Findings))
+	}
+	var steps []string
+	for _, s := range rep.Findings[0].Steps {
+		steps = append(steps, fmt.Sprintf("%s %s:%d %s", s.Type, s.File, s.Line, s.Func))
+	}
+	wantSteps := []string{
+		"SOURCE web/src/main/java/com/example/web/PingController.java:15 ping(String)",
+		"SINK common/src/main/java/com/example/common/CommandService.java:8 run(String)",
+	}
+	if !slices.Equal(steps, wantSteps) {
+		t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(steps, "\n"), strings.Join(wantSteps, "\n"))
+	}
+
+	wantTraffic := []string{
+		"textDocument/definition common/src/main/java/com/example/common/CommandService.java:7",
+		"textDocument/references common/src/main/java/com/example/common/CommandService.java:6",
+	}
+	if !isSubsequence(wantTraffic, received) {
+		t.Errorf("server received:\n%s\nwant in order:\n%s", strings.Join(received, "\n"), strings.Join(wantTraffic, "\n"))
+	}
+}
+
+// -direction down: 列出目标行调用的项目方法并逐层展开，递归调用、已展开的方法和深度上限都不再展开，到达的 Sink 作为叶子
+func TestRunDirectionDown(t *testing.T) {
+	const target = "src/main/java/com/example/demo/Service.java"
+	flatten := func(root e2eCallee) []string {
+		var rows []string
+		var walk func(n e2eCallee, depth int)
+		walk = func(n e2eCallee, depth int) {
+			row := fmt.Sprintf("%s%s :%d", strings.Repeat("  ", depth), n.Func, n.Line)
+			if n.Rule != "" {
+				row += " [" + n.Rule + "]"
+			}
+			if n.Note != "" {
+				row += " (" + n.Note + ")"
+			}
+			rows = append(rows, row)
+			for _, c := range n.Children {
+				walk(c, depth+1)
+			}
+		}
+		walk(root, 0)
+		return rows
+	}
+
+	tests := []struct {
+		depth string
+		want  []string
+	}{
+		{"3", []string{
+			"handle(String) :6",
+			"  dispatch(String, int) :17",
+			"    dispatch(String, int) :17 (recursive call)",
+			"    execute(String) :24",
+			"      RCE (Runtime.exec) :26 [RCE (Runtime.exec)]",
+			"  audit(String) :9",
+			"    normalize(String) :13",
+			"  normalize(String) :13 (expanded above)",
+		}},
+		{"1", []string{
+			"handle(String) :6",
+			"  dispatch(String, int) :17 (depth limit)",
+			"  audit(String) :9 (depth limit)",
+			"  normalize(String) :13 (depth limit)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run("depth"+tt.depth, func(t *testing.T) {
+			rep, received := runFixture(t, "testdata/callees", "-file", target+":6", "-direction", "down", "-callee-depth", tt.depth)
+			if len(rep.CallTrees) != 1 {
+				t.Fatalf("want one call tree, got %d", len(rep.CallTrees))
+			}
+			if got := flatten(rep.CallTrees[0]); !slices.Equal(got, tt.want) {
+				t.Errorf("call tree:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			for _, msg := range received {
+				if strings.HasPrefix(msg, "textDocument/references") {
+					t.Errorf("-direction down looked up callers: %s", msg)
+				}
+			}
+		})
+	}
+}
+
+// static 块中的 Sink: 从加载类的代码继续追踪，new CommandRunner() 和静态方法调用都到达接口，字段类型声明不是调用点
+func TestRunClassInitializer(t *testing.T) {
+	rep, received := runFixture(t, "testdata/classinit")
+
+	var chains []string
+	for _, f := range rep.Findings {
+		var steps []string
+		for _, s := range f.Steps {
+			steps = append(steps, fmt.Sprintf("%s %s:%d %s", s.Type, path.Base(s.File), s.Line, s.Func))
+		}
+		chains = append(chains, strings.Join(steps, " <- "))
+		if sink := f.Steps[len(f.Steps)-1]; !slices.ContainsFunc(sink.Analysis, func(a string) bool {
+			return strings.Contains(a, "Runs during class initialization (`CommandRunner.<clinit>`), tracing code that loads `CommandRunner`")
+		}) {
+			t.Errorf("sink step has no class initialization note: %q", sink.Analysis)
+		}
+	}
+	slices.Sort(chains)
+	want := []string{
+		"SOURCE RunController.java:13 run() <- SINK CommandRunner.java:15 CommandRunner.<clinit>",
+		"SOURCE RunController.java:19 version() <- SINK CommandRunner.java:15 CommandRunner.<clinit>",
+	}
+	if !slices.Equal(chains, want) {
+		t.Errorf("chains:\n%s\nwant:\n%s", strings.Join(chains, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 从类名查找引用 (而不是从 static 块)
+	wantTraffic := []string{
+		"textDocument/definition src/main/java/com/example/demo/CommandRunner.java:14",
+		"textDocument/references src/main/java/com/example/demo/CommandRunner.java:4",
+	}
+	if !isSubsequence(wantTraffic, received) {
+		t.Errorf("server received:\n%s\nwant in order:\n%s", strings.Join(received, "\n"), strings.Join(wantTraffic, "\n"))
+	}
+}
+
+// scriptFixture 以 e2e 的项目和录制为基础生成新的固定数据 (供 runFixture 使用)，edit 修改录制的交互
+func scriptFixture(t *testing.T, edit func(*jdtlsScript)) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(e2eDir, "lsp_script.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script jdtlsScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		t.Fatal(err)
+	}
+	edit(&script)
+	dir := t.TempDir()
+	if err := os.Rename(copyFixture(t, filepath.Join(e2eDir, "project")), filepath.Join(dir, "project")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = json.Marshal(script); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lsp_script.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// 服务器没有声明 definition 和 hover 时不发送这两种请求，Sink 改用 import / 全限定名验证，链路不变
+func TestRunCapabilityFallback(t *testing.T) {
+	dir := scriptFixture(t, func(script *jdtlsScript) {
+		script.Initialize = json.RawMessage(`{"capabilities":{"textDocumentSync":2,"hoverProvider":false,
+			"referencesProvider":true,"documentSymbolProvider":true,"workspaceSymbolProvider":true}}`)
+	})
+
+	rep, received := runFixture(t, dir)
+	if len(rep.Findings) != 1 || rep.Findings[0].Title != "CommandService.run(String)" {
+		t.Fatalf("findings = %+v, want the CommandService.run(String) chain", rep.Findings)
+	}
+	for _, msg := range received {
+		if strings.HasPrefix(msg, "textDocument/definition ") || strings.HasPrefix(msg, "textDocument/hover ") {
+			t.Errorf("sent %s although the server does not support it", msg)
+		}
+	}
+	wantTraffic := []string{
+		"initialize :-1",
+		"initialized :-1",
+		"textDocument/references src/main/java/com/example/demo/CommandService.java:6",
+	}
+	if !isSubsequence(wantTraffic, received) {
+		t.Errorf("server received:\n%s\nwant in order:\n%s", strings.Join(received, "\n"), strings.Join(wantTraffic, "\n"))
+	}
+}
+
+// 只被测试调用的生产代码: 链路在测试调用者处结束 (TEST_ONLY) 并降低一级；测试代码中的 Sink 默认只在概览中计数
+func TestRunTestCode(t *testing.T) {
+	rep, received := runFixture(t, "testdata/testcode")
+	if len(rep.Findings) != 1 {
+		t.Fatalf("want one finding, got %d", len(rep.Findings))
+	}
+	f := rep.Findings[0]
+	if f.Title != "CommandService.run(String)" || f.Termination != "TEST_ONLY" || !f.TestOnly || f.CodeKind != "MAIN" {
+		t.Errorf("finding = %q termination %s test_only %v code_kind %s, want CommandService.run(String) TEST_ONLY true MAIN", f.Title, f.Termination, f.TestOnly, f.CodeKind)
+	}
+	if f.Effective == f.Severity {
+		t.Errorf("effective severity %s not downgraded from %s", f.Effective, f.Severity)
+	}
+	excluded := rep.Metadata.Summary.ExcludedCode
+	if len(excluded) != 1 || excluded[0].Name != "TEST" || excluded[0].Count != 1 {
+		t.Errorf("excluded_code = %+v, want TEST: 1", excluded)
+	}
+	// 测试中的调用者 (runsPing) 不继续追踪
+	if slices.Contains(received, "textDocument/references src/test/java/com/example/demo/CommandServiceTest.java:4") {
+		t.Error("traced the callers of the test method runsPing")
+	}
+
+	rep, _ = runFixture(t, "testdata/testcode", "-include-test-code")
+	var kinds []string
+	for _, f := range rep.Findings {
+		kinds = append(kinds, f.Title+" "+f.CodeKind+" "+f.Steps[len(f.Steps)-1].CodeKind)
+	}
+	slices.Sort(kinds)
+	want := []string{
+		"CommandService.run(String) MAIN MAIN",
+		"CommandServiceTest.shell(String) TEST TEST",
+	}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("findings with -include-test-code:\n%s\nwant:\n%s", strings.Join(kinds, "\n"), strings.Join(want, "\n"))
+	}
+	if n := len(rep.Metadata.Summary.ExcludedCode); n != 0 {
+		t.Errorf("excluded_code listed with -include-test-code: %+v", rep.Metadata.Summary.ExcludedCode)
+	}
+}
+
+// 报告中记录产生环境: 版本、实际解析的命令行参数、规则文件的哈希、平台，JDT.LS 的版本优先取 serverInfo
+func TestRunProvenance(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := captureStdout(t, func() { runRules([]string{"export"}) })
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(rules))
+
+	rep, _ := runE2E(t, "-rules", rulesFile)
+	p := rep.Metadata.Provenance
+	if p == nil {
+		t.Fatal("report has no provenance")
+	}
+	if p.Version != version || p.GoVersion != runtime.Version() || p.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("provenance = %+v", p)
+	}
+	if p.JdtlsVersion != "Fake JDT.LS 1.0.0-test" {
+		t.Errorf("jdtls_version = %q, want the serverInfo of the initialize result", p.JdtlsVersion)
+	}
+	if p.RulesSHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("rules_sha256 = %s, want %x", p.RulesSHA256, sum)
+	}
+	if i := slices.Index(p.Args, "-rules"); i < 0 || i+1 >= len(p.Args) || p.Args[i+1] != rulesFile || !slices.Contains(p.Args, "-project") {
+		t.Errorf("args = %q, want the parsed command line", p.Args)
+	}
+
+	// serverInfo 没有版本时取 plugins 中 jdt.ls.core 的版本
+	dir := scriptFixture(t, func(script *jdtlsScript) {
+		script.Initialize = json.RawMessage(`{"capabilities":{"textDocumentSync":2,"hoverProvider":true,"definitionProvider":true,
+			"referencesProvider":true,"documentSymbolProvider":true,"workspaceSymbolProvider":true},"serverInfo":{"name":"Fake JDT.LS"}}`)
+	})
+	deps := fakeDeps(t)
+	plugins := filepath.Join(deps, "jdtls", "plugins")
+	if err := os.MkdirAll(plugins, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(plugins, "org.eclipse.jdt.ls.core_1.40.0.202409261450.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rep, _ = runFixture(t, dir, "-deps-dir", deps)
+	if p := rep.Metadata.Provenance; p == nil || p.JdtlsVersion != "1.40.0.202409261450" {
+		t.Errorf("provenance without serverInfo version = %+v", p)
+	}
+}
+
+// stepNotes 发现中 Func 所在步骤的说明 (没有这个步骤时为 nil)
+func stepNotes(t *testing.T, rep e2eReport, route, fn string) []string {
+	t.Helper()
+	for _, f := range rep.Findings {
+		if !slices.Contains(f.Routes, route) {
+			continue
+		}
+		for _, s := range f.Steps {
+			if s.Func == fn {
+				return s.Analysis
+			}
+		}
+		t.Errorf("finding for %s has no step %s", route, fn)
+		return nil
+	}
+	t.Errorf("no finding for %s", route)
+	return nil
+}
+
+// hasNote notes 中是否有以 prefix 开头的说明
+func hasNote(notes []string, prefix string) bool {
+	return slices.ContainsFunc(notes, func(n string) bool { return strings.HasPrefix(n, prefix) })
+}
+
+// @EventListener 方法没有直接的调用者: 事件的发布点作为调用者继续追踪
+// publishEvent(new OrderCreated(...)) 由文本搜索找到，先赋值给变量再发布的由事件类的 references 找到
+func TestRunEventBridge(t *testing.T) {
+	rep, received := runFixture(t, "testdata/event")
+	var routes []string
+	for _, f := range rep.Findings {
+		if f.Title != "OrderListener.onOrder(OrderCreated)" || f.Termination != "REACHED_ENTRY" || len(f.Steps) != 2 {
+			t.Errorf("finding %q termination %s with %d steps", f.Title, f.Termination, len(f.Steps))
+		}
+		routes = append(routes, f.Routes...)
+	}
+	slices.Sort(routes)
+	if want := []string{"GET /orders", "GET /orders/retry"}; !slices.Equal(routes, want) {
+		t.Fatalf("routes = %v, want %v", routes, want)
+	}
+	const bridge = "⏩ Async boundary: event — `OrderCreated` published here is delivered to `@EventListener` method `onOrder(OrderCreated)`"
+	for route, fn := range map[string]string{"GET /orders": "create(String)", "GET /orders/retry": "retry(String)"} {
+		if notes := stepNotes(t, rep, route, fn); !slices.Contains(notes, bridge) {
+			t.Errorf("%s: publisher step notes %v", route, notes)
+		}
+	}
+	if !slices.Contains(received, "textDocument/references src/main/java/com/example/demo/OrderCreated.java:2") {
+		t.Error("did not look up the constructions of the event class")
+	}
+}
+
+// @Async 方法的调用者照常追踪，调用者步骤上标注边界
+func TestRunAsyncBoundary(t *testing.T) {
+	rep, _ := runFixture(t, "testdata/async")
+	if len(rep.Findings) != 1 || rep.Findings[0].Title != "ReportService.generate(String)" || rep.Findings[0].Termination != "REACHED_ENTRY" {
+		t.Fatalf("findings = %+v", rep.Findings)
+	}
+	notes := stepNotes(t, rep, "GET /reports", "export(String)")
+	if !hasNote(notes, "⏩ Async boundary: `@Async` method `generate(String)` runs on a task executor") {
+		t.Errorf("caller step notes %v", notes)
+	}
+	if notes := stepNotes(t, rep, "GET /reports", "generate(String)"); hasNote(notes, "⏩") {
+		t.Errorf("sink step is annotated as a boundary: %v", notes)
+	}
+}
+
+// 提交给线程池的 lambda 回退到提交它的方法，说明线程池的边界
+func TestRunExecutorBoundary(t *testing.T) {
+	rep, _ := runFixture(t, "testdata/executor")
+	if len(rep.Findings) != 1 || rep.Findings[0].Title != "JobService.run(String)" || rep.Findings[0].Termination != "REACHED_ENTRY" {
+		t.Fatalf("findings = %+v", rep.Findings)
+	}
+	const handoff = "⏩ Async boundary: executor — lambda submitted via `executor.submit`, tracing submitting method `start(String)`"
+	if notes := stepNotes(t, rep, "GET /jobs", "start(String)"); !slices.Contains(notes, handoff) {
+		t.Errorf("submitting step notes %v", notes)
+	}
+}
+
+// 在项目目录下运行: 依赖目录 (JDT.LS 插件中带有源码) 和之前的报告输出目录位于项目中，
+// 它们不产生候选点、不打开文件，也不出现在生成的 .classpath 中
+func TestRunToolDirsInsideProject(t *testing.T) {
+	project := copyFixture(t, filepath.Join(e2eDir, "project"))
+	deps := filepath.Join(project, "lsptracer-deps")
+	output := filepath.Join(project, "reports")
+	leaked := map[string]string{
+		"lsptracer-deps/jdtls/plugins/src/org/eclipse/jdt/ls/Launcher.java": "package org.eclipse.jdt.ls;\n\npublic class Launcher {\n    void launch(String cmd) throws Exception {\n        Runtime.getRuntime().exec(cmd);\n    }\n}\n",
+		"lsptracer-deps/lombok.jar":                                         "",
+		"reports/snippets/com/example/demo/CommandService.java":             "package com.example.demo;\n\npublic class CommandService {\n    void run(String cmd) throws Exception {\n        Runtime.getRuntime().exec(cmd);\n    }\n}\n",
+	}
+	for rel, content := range leaked {
+		path := filepath.Join(project, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rep, received := runProject(t, project, filepath.Join(e2eDir, "lsp_script.json"), "-deps-dir", deps, "-output", output)
+	if len(rep.Findings) != 1 || rep.Findings[0].Title != "CommandService.run(String)" {
+		t.Errorf("findings = %+v, want only CommandService.run(String)", rep.Findings)
+	}
+	for _, f := range rep.Findings {
+		for _, s := range f.Steps {
+			if strings.Contains(s.File, "lsptracer-deps") || strings.Contains(s.File, "reports") {
+				t.Errorf("step in %s", s.File)
+			}
+		}
+	}
+	for _, line := range received {
+		if strings.Contains(line, "lsptracer-deps/") || strings.Contains(line, "reports/") {
+			t.Errorf("server received %s", line)
+		}
+	}
+	classpath, err := os.ReadFile(filepath.Join(project, ".classpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(classpath), "lsptracer-deps") || strings.Contains(string(classpath), "reports") {
+		t.Errorf(".classpath contains the tool directories:\n%s", classpath)
+	}
+}
+
+// scope: project 的规则: 项目自己的类没有 import 可查，由 workspace/symbol 找到类的声明文件，
+// 调用的 definition 落在该文件中时确认；同名方法定义在其它类中的调用不确认
+func TestRunWorkspaceSymbolRule(t *testing.T) {
+	rep, received := runFixture(t, "testdata/workspace", "-rules", filepath.Join("testdata", "workspace", "rules.yaml"))
+	if len(rep.Findings) != 1 {
+		t.Fatalf("findings = %+v, want only the LegacySqlRunner call", rep.Findings)
+	}
+	f := rep.Findings[0]
+	if f.Title != "QueryController.query(String)" || f.Verification != "verified" || !slices.Equal(f.Routes, []string{"GET /query"}) {
+		t.Errorf("finding %q verification %s routes %v", f.Title, f.Verification, f.Routes)
+	}
+	const evidence = "definition resolved to com.example.demo.LegacySqlRunner declared in src/main/java/com/example/demo/LegacySqlRunner.java (workspace/symbol)"
+	if f.Signals == nil || f.Signals.VerifiedBy != "workspace" || f.Signals.Evidence != evidence {
+		t.Errorf("confidence signals = %+v, want workspace verification", f.Signals)
+	}
+	// 规则的类只查询一次 (按类缓存)
+	lookups := 0
+	for _, msg := range received {
+		if strings.HasPrefix(msg, "workspace/symbol ") {
+			lookups++
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("workspace/symbol sent %d times, want 1", lookups)
+	}
+}
+
+// @RequestBody 绑定的 DTO 传到 Sink: req.getPath() 的值来自方法参数 req，数据流确认并追踪到接口
+func TestRunRequestBodyGetter(t *testing.T) {
+	rep, _ := runFixture(t, "testdata/dto")
+	if len(rep.Findings) != 1 {
+		t.Fatalf("findings = %+v", rep.Findings)
+	}
+	f := rep.Findings[0]
+	if f.Title != "ExportService.save(ExportRequest)" || f.Termination != "REACHED_ENTRY" || !slices.Equal(f.Routes, []string{"POST /exports"}) {
+		t.Errorf("finding %q termination %s routes %v", f.Title, f.Termination, f.Routes)
+	}
+	if f.Signals == nil || !f.Signals.DataFlow {
+		t.Errorf("confidence signals = %+v, want confirmed dataflow", f.Signals)
+	}
+	notes := stepNotes(t, rep, "POST /exports", "save(ExportRequest)")
+	for _, want := range []string{
+		"⚠️ Variable Definition: Method Parameter `req` via getter `req.getPath()`",
+		"📦 Getter on bound request object `ExportRequest` (property `path`)",
+	} {
+		if !slices.Contains(notes, want) {
+			t.Errorf("sink step notes %q, want %q", notes, want)
+		}
+	}
+}
+
+// Sink 在 stream 的 lambda 和匿名类中: 从外层的命名方法 (注册点) 继续追踪到 Controller，
+// 匿名类自己的方法没有外部引用，不查询它的 references
+func TestRunLambdaRegistration(t *testing.T) {
+	rep, received := runFixture(t, "testdata/lambda")
+	type step struct {
+		Func string
+		Line int
+	}
+	want := map[string][]step{
+		"ArchiveService.writeAll(List<String>)": {{"archive(List<String>)", 15}, {"writeAll(List<String>)", 20}},
+		"ArchiveService$1.accept(String)":       {{"archiveEach(List<String>)", 21}, {"ArchiveService$1.accept(String)", 32}},
+	}
+	routes := map[string]string{
+		"ArchiveService.writeAll(List<String>)": "POST /archive",
+		"ArchiveService$1.accept(String)":       "POST /archive/each",
+	}
+	notes := map[string]string{
+		"ArchiveService.writeAll(List<String>)": "🔸 Inside lambda/anonymous class, tracing enclosing method `writeAll(List<String>)`",
+		"ArchiveService$1.accept(String)":       "🔸 Inside lambda/anonymous class, tracing enclosing method `writeEach(List<String>)`",
+	}
+	if len(rep.Findings) != len(want) {
+		t.Fatalf("findings = %+v", rep.Findings)
+	}
+	for _, f := range rep.Findings {
+		var got []step
+		for _, s := range f.Steps {
+			got = append(got, step{s.Func, s.Line})
+		}
+		if !slices.Equal(got, want[f.Title]) {
+			t.Errorf("%s: steps %v, want %v", f.Title, got, want[f.Title])
+		}
+		if f.Termination != "REACHED_ENTRY" || !slices.Equal(f.Routes, []string{routes[f.Title]}) {
+			t.Errorf("%s: termination %s routes %v", f.Title, f.Termination, f.Routes)
+		}
+		if n := len(f.Steps); n > 0 && !slices.Contains(f.Steps[n-1].Analysis, notes[f.Title]) {
+			t.Errorf("%s: sink step notes %q", f.Title, f.Steps[n-1].Analysis)
+		}
+	}
+	if slices.Contains(received, "textDocument/references src/main/java/com/example/demo/ArchiveService.java:29") {
+		t.Error("looked up references of the anonymous class method")
+	}
+}
+
+// JNDI / LDAP / XPath 规则: 严格模式下链路追踪到 Controller；检查的参数 (JNDI 名称、LDAP 过滤器、XPath 表达式) 是常量的调用点跳过
+func TestRunInjectionRuleFamilies(t *testing.T) {
+	tests := []struct {
+		dir      string
+		vulnType string
+		title    string
+		route    string
+		lines    []int // Sink 所在行 (1-based)
+	}{
+		{"testdata/jndi", "JNDI_INJECTION", "ResourceLocator.locate(String)", "GET /resources", []int{22}},
+		{"testdata/ldap", "LDAP_INJECTION", "DirectoryService.find(String)", "GET /directory", []int{19, 21}},
+		{"testdata/xpath", "XPATH_INJECTION", "CatalogService.search(String)", "GET /catalog", []int{17, 18}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vulnType, func(t *testing.T) {
+			rep, _ := runFixture(t, tt.dir, "-strict", "true")
+			if rep.Metadata.TotalChains != len(tt.lines) {
+				t.Errorf("total_chains = %d, want %d", rep.Metadata.TotalChains, len(tt.lines))
+			}
+			var lines []int
+			for _, f := range rep.Findings {
+				if f.VulnType != tt.vulnType || f.Title != tt.title || f.Verification != "verified" {
+					t.Errorf("finding %s %q verification %s", f.VulnType, f.Title, f.Verification)
+				}
+				if f.Termination != "REACHED_ENTRY" || f.Strict != "" || !slices.Equal(f.Routes, []string{tt.route}) {
+					t.Errorf("finding at %v: termination %s strict_excluded %q routes %v", f.Steps, f.Termination, f.Strict, f.Routes)
+				}
+				if n := len(f.Steps); n != 2 || f.Steps[0].Func != "handle(String)" {
+					t.Errorf("steps %+v, want the controller handler as source", f.Steps)
+				} else {
+					lines = append(lines, f.Steps[n-1].Line)
+				}
+			}
+			slices.Sort(lines)
+			if !slices.Equal(lines, tt.lines) {
+				t.Errorf("sink lines %v, want %v (constant arguments skipped)", lines, tt.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"LSPTracer/internal/env"
+
+	"github.com/fatih/color"
+)
+
+// runEnv 实现 env 子命令: 查看并升级依赖目录中的 JDT.LS
+//
+//	env check   [-channel snapshot|milestone] [-deps-dir dir]
+//	env upgrade [-channel snapshot|milestone] [-deps-dir dir] [-y]
+func runEnv(args []string) int {
+	if len(args) == 0 || (args[0] != "check" && args[0] != "upgrade") {
+		fmt.Fprintln(os.Stderr, "usage: lsptracer env check|upgrade [-channel snapshot|milestone] [-deps-dir dir] [-y]")
+		return 2
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("env "+action, flag.ExitOnError)
+	channel := fs.String("channel", "", "JDT.LS download channel: snapshot or milestone (default: the recorded channel, else snapshot).")
+	yes := fs.Bool("y", false, "Upgrade without asking for confirmation.")
+	fs.StringVar(&env.DepsDir, "deps-dir", "", "Dependency directory used by scans with -deps-dir (default: .lsptracer_deps in the current directory).")
+	fs.Parse(args[1:])
+
+	depsRoot, err := env.DepsRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] %v\n", err)
+		return 1
+	}
+	installed, recorded, err := env.InstalledJdtls(depsRoot)
+	if err != nil {
+		color.Yellow("[!] %v", err)
+	}
+	if *channel == "" {
+		*channel = env.ChannelSnapshot
+		if recorded && installed.Channel != "" {
+			*channel = installed.Channel
+		}
+	}
+	if err := env.ValidChannel(*channel); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] %v\n", err)
+		return 2
+	}
+
+	current := installed.Version
+	switch {
+	case current == "":
+		current = "not installed"
+	case !recorded:
+		current += " (from plugin jar, not recorded in " + env.LockFileName + ")"
+	case installed.Channel != "":
+		current += " (" + installed.Channel + ")"
+	}
+	fmt.Printf("JDT.LS installed: %s\n", current)
+
+	latest, err := env.LatestJdtls(*channel)
+	if err != nil {
+		if action == "check" {
+			color.Yellow("[!] Could not check the %s channel: %v", *channel, err)
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "[-] Could not check the %s channel: %v\n", *channel, err)
+		return 1
+	}
+	fmt.Printf("JDT.LS available: %s (%s)\n", latest.Version, latest.Channel)
+
+	if installed.Version != "" && env.CompareVersions(installed.Version, latest.Version) >= 0 {
+		color.Green("[+] JDT.LS is up to date")
+		return 0
+	}
+	if action == "check" {
+		color.Cyan("[*] Run `lsptracer env upgrade -channel %s` to install %s", *channel, latest.Version)
+		return 0
+	}
+
+	if !*yes && !confirm(fmt.Sprintf("Install JDT.LS %s to %s?", latest.Version, depsRoot)) {
+		fmt.Println("Aborted.")
+		return 1
+	}
+	if err := os.MkdirAll(depsRoot, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] %v\n", err)
+		return 1
+	}
+	entry, err := env.InstallJdtls(depsRoot, latest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Upgrade failed, the previous installation was kept: %v\n", err)
+		return 1
+	}
+	color.Green("[+] JDT.LS upgraded to %s", entry.Version)
+	return 0
+}
+
+// confirm 在终端询问 [y/N]，只有 y/yes 视为同意
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
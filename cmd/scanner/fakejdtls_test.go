@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lang"
+	"LSPTracer/internal/lsp"
+)
+
+// fakeJdtlsEnv 设置后测试二进制作为脚本化的假 JDT.LS 运行 (值为脚本文件)
+// fakeJdtlsLogEnv 假服务器把收到的每条消息追加到这个文件 ("方法 相对路径:行")
+const (
+	fakeJdtlsEnv    = "LSPTRACER_FAKE_JDTLS"
+	fakeJdtlsLogEnv = "LSPTRACER_FAKE_JDTLS_LOG"
+)
+
+func TestMain(m *testing.M) {
+	if script := os.Getenv(fakeJdtlsEnv); script != "" {
+		if err := runFakeJdtls(script, os.Getenv(fakeJdtlsLogEnv), os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeJdtlsCmd 替换 Options.ServerCmd: 启动测试二进制自身作为按 script 回放的假 JDT.LS
+func fakeJdtlsCmd(script, logFile string) func(lang.JavaConfig) (*exec.Cmd, error) {
+	return func(lang.JavaConfig) (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		cmd.Env = append(os.Environ(), fakeJdtlsEnv+"="+script, fakeJdtlsLogEnv+"="+logFile)
+		return cmd, nil
+	}
+}
+
+// jdtlsScript 录制的 JDT.LS 交互:
+// initialize 的结果、第一次 didOpen 之后服务器发出的通知，以及按方法/文件/行匹配的请求结果
+// 结果中的 ${ROOT} 替换为 initialize 中的 rootUri
+type jdtlsScript struct {
+	Initialize  json.RawMessage `json:"initialize"`
+	OnFirstOpen []struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	} `json:"onFirstOpen"`
+	Responses []scriptedResponse `json:"responses"`
+}
+
+// scriptedResponse 一条录制的结果，File (相对项目根目录)、Line 和 Character 为空时匹配任意值
+type scriptedResponse struct {
+	Method    string          `json:"method"`
+	File      string          `json:"file"`
+	Line      *int            `json:"line"`
+	Character *int            `json:"character"`
+	Result    json.RawMessage `json:"result"`
+}
+
+// jdtlsFrame 假服务器收发的 JSON-RPC 消息
+type jdtlsFrame struct {
+	JsonRpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+}
+
+// documentParams 文档请求中用于匹配脚本的部分
+type documentParams struct {
+	RootUri      string `json:"rootUri"`
+	TextDocument struct {
+		Uri string `json:"uri"`
+	} `json:"textDocument"`
+	Position *lsp.Position `json:"position"`
+}
+
+// runFakeJdtls 假 JDT.LS 的主循环: 请求按脚本回答，没有录制的请求返回 null，客户端的回复和其它通知只记录
+func runFakeJdtls(scriptPath, logPath string, in io.Reader, out io.Writer) error {
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	var script jdtlsScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return fmt.Errorf("%s: %v", scriptPath, err)
+	}
+	var log io.Writer = io.Discard
+	if logPath != "" {
+		f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		log = f
+	}
+
+	r := bufio.NewReader(in)
+	root, opened := "", false
+	for {
+		body, err := readJdtlsFrame(r)
+		if err != nil {
+			return nil
+		}
+		var msg jdtlsFrame
+		if json.Unmarshal(body, &msg) != nil || msg.Method == "" {
+			continue
+		}
+		var params documentParams
+		json.Unmarshal(msg.Params, &params)
+		file := ""
+		if uri := params.TextDocument.Uri; uri != "" {
+			file = filepath.ToSlash(strings.TrimPrefix(lsp.FromUri(uri), lsp.FromUri(root)+string(filepath.Separator)))
+		}
+		line, char := -1, -1
+		if params.Position != nil {
+			line, char = params.Position.Line, params.Position.Character
+		}
+		fmt.Fprintf(log, "%s %s:%d\n", msg.Method, file, line)
+
+		switch msg.Method {
+		case "exit":
+			return nil
+		case "textDocument/didOpen":
+			if !opened {
+				opened = true
+				for _, n := range script.OnFirstOpen {
+					writeJdtlsFrame(out, jdtlsFrame{JsonRpc: "2.0", Method: n.Method, Params: n.Params})
+				}
+			}
+		}
+		if msg.Id == nil {
+			continue
+		}
+
+		result := json.RawMessage("null")
+		if msg.Method == "initialize" {
+			root = params.RootUri
+			result = script.Initialize
+		} else {
+			for _, resp := range script.Responses {
+				if resp.Method == msg.Method && (resp.File == "" || resp.File == file) && (resp.Line == nil || *resp.Line == line) && (resp.Character == nil || *resp.Character == char) {
+					result = json.RawMessage(strings.ReplaceAll(string(resp.Result), "${ROOT}", strings.TrimSuffix(root, "/")))
+					break
+				}
+			}
+		}
+		writeJdtlsFrame(out, jdtlsFrame{JsonRpc: "2.0", Id: msg.Id, Result: result})
+	}
+}
+
+func readJdtlsFrame(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			length, _ = strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+func writeJdtlsFrame(w io.Writer, msg jdtlsFrame) {
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+)
+
+const fanOutPkg = "src/main/java/com/example/demo/"
+
+// writeFanOutFixture 生成一个 CommandService.run 有 callers 个生产代码调用者的项目，以及回放给它的 JDT.LS 交互:
+// references 另外返回 10 个测试代码中的调用者和 5 个 generated/ 目录中的调用者 (-exclude generated)
+func writeFanOutFixture(t *testing.T, callers int) string {
+	t.Helper()
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	write := func(rel, content string) {
+		path := filepath.Join(project, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rel := range []string{"pom.xml", fanOutPkg + "CommandService.java"} {
+		data, err := os.ReadFile(filepath.Join(e2eDir, "project", filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(rel, string(data))
+	}
+
+	// Callers.java: 每个方法一行 (第 4+i 行)，都调用 commands.run(s)
+	var src strings.Builder
+	src.WriteString("package com.example.demo;\n\npublic class Callers {\n    private final CommandService commands = new CommandService();\n")
+	var methods []lsp.DocumentSymbol
+	var refs []lsp.Location
+	for i := 0; i < callers; i++ {
+		line := fmt.Sprintf("    public void c%03d(String s) throws Exception { commands.run(s); }", i)
+		src.WriteString(line + "\n")
+		n := 4 + i
+		methods = append(methods, lsp.DocumentSymbol{
+			Name: fmt.Sprintf("c%03d(String)", i), Kind: 6,
+			Range:          lsp.Range{Start: lsp.Position{Line: n, Character: 4}, End: lsp.Position{Line: n, Character: len(line)}},
+			SelectionRange: lsp.Range{Start: lsp.Position{Line: n, Character: 16}, End: lsp.Position{Line: n, Character: 20}},
+		})
+		col := strings.Index(line, "run(")
+		refs = append(refs, lsp.Location{
+			Uri:   "${ROOT}/" + fanOutPkg + "Callers.java",
+			Range: lsp.Range{Start: lsp.Position{Line: n, Character: col}, End: lsp.Position{Line: n, Character: col + 3}},
+		})
+	}
+	src.WriteString("}\n")
+	write(fanOutPkg+"Callers.java", src.String())
+	for i := 0; i < 10; i++ {
+		refs = append(refs, lsp.Location{Uri: "${ROOT}/src/test/java/com/example/demo/CallersTest.java", Range: lsp.Range{Start: lsp.Position{Line: 5 + i}}})
+	}
+	for i := 0; i < 5; i++ {
+		refs = append(refs, lsp.Location{Uri: "${ROOT}/generated/com/example/demo/Gen.java", Range: lsp.Range{Start: lsp.Position{Line: 5 + i}}})
+	}
+
+	// 录制的交互: 沿用 e2e 的初始化和 CommandService 的结果，替换 run(String) 的引用
+	data, err := os.ReadFile(filepath.Join(e2eDir, "lsp_script.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script jdtlsScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		t.Fatal(err)
+	}
+	raw := func(v any) json.RawMessage {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	classEnd := 4 + callers
+	responses := []scriptedResponse{{
+		Method: "textDocument/documentSymbol", File: fanOutPkg + "Callers.java",
+		Result: raw([]lsp.DocumentSymbol{{
+			Name: "Callers", Kind: 5, Children: methods,
+			Range:          lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: classEnd, Character: 1}},
+			SelectionRange: lsp.Range{Start: lsp.Position{Line: 2, Character: 13}, End: lsp.Position{Line: 2, Character: 20}},
+		}}),
+	}}
+	for _, resp := range script.Responses {
+		switch {
+		case resp.Method == "textDocument/references" && strings.HasSuffix(resp.File, "CommandService.java"):
+			resp.Result = raw(refs)
+		case strings.HasSuffix(resp.File, "PingController.java"):
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	script.Responses = responses
+	if err := os.WriteFile(filepath.Join(dir, "lsp_script.json"), raw(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// 200 个调用者只追踪 -max-callers 个: 记录一条带有说明的部分链路，并在统计中列出该方法；
+// 测试代码和 -exclude 排除的调用者不计入上限
+func TestRunFanOutLimit(t *testing.T) {
+	rep, received := runFixture(t, writeFanOutFixture(t, 200), "-max-callers", "20", "-exclude", "generated")
+
+	var limited []string
+	for _, f := range rep.Findings {
+		if f.Termination != "FANOUT_LIMIT" {
+			continue
+		}
+		for _, s := range f.Steps {
+			limited = append(limited, fmt.Sprintf("%s:%d %s", filepath.Base(s.File), s.Line, strings.Join(s.Analysis, " | ")))
+		}
+	}
+	if len(limited) != 1 || !strings.HasPrefix(limited[0], "CommandService.java:8 ") || !strings.Contains(limited[0], "Fan-out limit: 180 callers not explored") {
+		t.Errorf("fan-out limited chain steps = %q, want the sink with a note about 180 unexplored callers", limited)
+	}
+
+	fanOut := rep.Metadata.Stats.FanOut
+	if len(fanOut) != 1 || !strings.Contains(fanOut[0].Name, "run(String) (CommandService.java:8)") || fanOut[0].Count != 200 {
+		t.Errorf("fan_out_limited = %+v, want run(String) with 200 callers", fanOut)
+	}
+
+	// 只有排在前面的 20 个调用者被展开 (各自查询一次 references)
+	var traced []string
+	for _, msg := range received {
+		if strings.HasPrefix(msg, "textDocument/references "+fanOutPkg+"Callers.java:") {
+			traced = append(traced, msg)
+		}
+		if strings.Contains(msg, "CallersTest.java") || strings.Contains(msg, "Gen.java") {
+			t.Errorf("excluded caller was traced: %s", msg)
+		}
+	}
+	if len(traced) != 20 {
+		t.Errorf("traced %d callers, want 20", len(traced))
+	}
+	for _, msg := range traced {
+		var line int
+		fmt.Sscanf(msg[strings.LastIndex(msg, ":")+1:], "%d", &line)
+		if line < 4 || line >= 24 {
+			t.Errorf("caller outside the first 20 was traced: %s", msg)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProbeScript 以 e2e 的录制为基础写出新的脚本: 去掉所有 documentSymbol 结果，workspace/symbol 返回 symbols
+func writeProbeScript(t *testing.T, symbols string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(e2eDir, "lsp_script.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script jdtlsScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		t.Fatal(err)
+	}
+	var responses []scriptedResponse
+	for _, resp := range script.Responses {
+		switch resp.Method {
+		case "textDocument/documentSymbol":
+			continue
+		case "workspace/symbol":
+			resp.Result = json.RawMessage(symbols)
+		}
+		responses = append(responses, resp)
+	}
+	script.Responses = responses
+	if data, err = json.Marshal(script); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "lsp_script.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runProbeScan 用 script 扫描 e2e 项目，返回 Run 的错误和服务器收到的消息
+func runProbeScan(t *testing.T, script string, args ...string) (error, []string) {
+	t.Helper()
+	project := copyFixture(t, filepath.Join(e2eDir, "project"))
+	args = append([]string{"-project", project, "-format", "json", "-output", t.TempDir(), "-deps-dir", fakeDeps(t)}, args...)
+	opts := parseTestArgs(t, args...)
+	serverLog := filepath.Join(t.TempDir(), "server.log")
+	opts.ServerCmd = fakeJdtlsCmd(script, serverLog)
+	err := Run(context.Background(), opts)
+	received, _ := os.ReadFile(serverLog)
+	return err, strings.Split(strings.TrimSpace(string(received)), "\n")
+}
+
+// 索引为空 (锚点没有符号，锚点中的类也查不到) 时中止扫描，错误中列出检测到的源码目录
+func TestRunIndexProbeEmptyIndex(t *testing.T) {
+	err, received := runProbeScan(t, writeProbeScript(t, "[]"))
+	if err == nil {
+		t.Fatal("Run succeeded against an empty index")
+	}
+	for _, want := range []string{
+		"Index check failed",
+		"empty index",
+		"Controller.java: no results",
+		"declared there: no results",
+		"Detected source roots:\n    src/main/java",
+		"-no-index-probe",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error is missing %q:\n%v", want, err)
+		}
+	}
+	for _, msg := range received {
+		if strings.HasPrefix(msg, "textDocument/references") {
+			t.Errorf("scan continued after the failed index check: %s", msg)
+		}
+	}
+}
+
+// 锚点的 documentSymbol 为空但 workspace/symbol 能找到锚点中的类时，索引视为正常
+func TestRunIndexProbeWorkspaceSymbol(t *testing.T) {
+	symbols := `[{"name": "PingController", "kind": 5, "location": {"uri": "${ROOT}/src/main/java/com/example/demo/PingController.java",
+		"range": {"start": {"line": 7, "character": 13}, "end": {"line": 7, "character": 27}}}}]`
+	err, received := runProbeScan(t, writeProbeScript(t, symbols))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !isSubsequence([]string{"workspace/symbol :-1"}, received) {
+		t.Errorf("index probe did not query workspace/symbol: %v", received)
+	}
+}
+
+// -no-index-probe 跳过检查，空索引也继续扫描
+func TestRunIndexProbeOptOut(t *testing.T) {
+	err, received := runProbeScan(t, writeProbeScript(t, "[]"), "-no-index-probe")
+	if err != nil {
+		t.Fatalf("Run with -no-index-probe: %v", err)
+	}
+	for _, msg := range received {
+		if strings.HasPrefix(msg, "workspace/symbol") {
+			t.Errorf("index probe ran despite -no-index-probe: %s", msg)
+		}
+	}
+}
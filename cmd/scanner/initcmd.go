@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"LSPTracer/internal/config"
+
+	"github.com/fatih/color"
+)
+
+// runInit 实现 init 子命令: 在当前目录写出带注释的配置模板
+//
+//	init [-force]
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "Overwrite an existing "+config.DefaultFile+".")
+	fs.Parse(args)
+
+	if _, err := os.Stat(config.DefaultFile); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "[-] %s already exists (use -force to overwrite)\n", config.DefaultFile)
+		return 1
+	}
+	if err := os.WriteFile(config.DefaultFile, []byte(config.Template), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to write %s: %v\n", config.DefaultFile, err)
+		return 1
+	}
+	color.Green("[+] Wrote %s", config.DefaultFile)
+	return 0
+}
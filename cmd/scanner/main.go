@@ -2,17 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
-	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"LSPTracer/internal/analysis"
 	"LSPTracer/internal/env"
-	"LSPTracer/internal/lang"
 	"LSPTracer/internal/lsp"
 	"LSPTracer/internal/model"
 	"LSPTracer/internal/report"
@@ -20,13 +19,81 @@ import (
 	"github.com/fatih/color"
 )
 
+// 单点模式的目标 (可重复: -file A.java:10 -file B.java:20)
+var argFiles fileList
+
+// 报告中的路径映射 (可重复: -path-map /src=/home/dev/app)
+var argPathMap pathMapList
+
+func init() {
+	flag.Var(&argFiles, "file", "(Optional) Target file path with line number (e.g., src/Main.java:42). Repeatable. If empty (and no -targets), auto-scan mode is enabled.")
+	flag.Var(&argPathMap, "path-map", "(Optional) Rewrite paths in reports from the scan environment to the host, e.g. /src=/home/dev/app (for scans inside Docker). Repeatable; the longest matching prefix wins.")
+}
+
 // 定义命令行参数
 var (
-	argProject   = flag.String("project", "", "Path to the project root directory")
-	argFile      = flag.String("file", "", "(Optional) Target file path with line number (e.g., src/Main.java:42). If empty, auto-scan mode is enabled.")
+	argProject   = flag.String("project", "", "Path to the project root directory, or a source archive (.zip, .jar, .tar.gz) that is extracted to a temporary directory")
+	argMethod    = flag.String("method", "", "(Optional) Trace every caller of a method, e.g. 'com.acme.dao.LegacyDao#runSql' or 'LegacyDao#runSql(String)' for one overload.")
+	argDirection = flag.String("direction", directionUp, "Single-shot direction: 'up' (who calls the target line), 'down' (what the target line calls, flagging reachable sinks) or 'both'.")
+	argCallDepth = flag.Int("callee-depth", analysis.DefaultCalleeDepth, "Levels of project methods expanded by -direction down.")
+	argTargets   = flag.String("targets", "", "(Optional) File with one 'path:line' target per line ('#' starts a comment). Traced like -file in one session.")
 	argJdtlsHome = flag.String("jdtls", "", "Path to JDT.LS directory. If empty, it will be auto-downloaded.")
+	argDepsDir   = flag.String("deps-dir", "", "(Optional) Directory for the auto-downloaded JDT.LS and Lombok (default: .lsptracer_deps in the current directory). Keep it outside the project when running LSPTracer from inside the project.")
+	argNoProbe   = flag.Bool("no-index-probe", false, "Skip the index sanity check after JDT.LS is ready (by default the scan aborts when neither the anchor file nor its class can be found in the index).")
+	argNoLombok  = flag.Bool("no-lombok", false, "Never attach the Lombok agent to JDT.LS (by default it is attached only when the project uses Lombok).")
+	argLombokJar = flag.String("lombok-jar", "", "(Optional) Lombok jar used as the JDT.LS agent instead of the auto-downloaded one; always attached unless -no-lombok.")
 	argRules     = flag.String("rules", "", "(Optional) Path to external rules.yaml file.")
 	argMode      = flag.String("mode", "light", "Scan mode: 'light' (fast, heuristic) or 'precise' (slow, full build). Default: light")
+	argFormat    = flag.String("format", "html", "Report formats, comma separated: html, json, sarif")
+	argLspLog    = flag.String("lsp-log", "", "(Optional) Append all LSP traffic to this file as JSON lines (for debugging).")
+	argLspLogMax = flag.Int("lsp-log-max", lsp.DefaultLogPayloadSize, "Maximum payload size in bytes per message in the LSP log (larger payloads are truncated).")
+	argSecrets   = flag.Bool("secrets", false, "(Optional) Also scan source and config files for hardcoded credentials and keys (reported as SECRET findings).")
+	argTemplates = flag.Bool("templates", true, "Scan JSP and Thymeleaf templates (webapp / templates dirs) for unescaped output of request input and model attributes (reported as XSS_TEMPLATE findings).")
+	argCfgScan   = flag.Bool("config-scan", true, "Check web.xml, Spring XML and application properties/yml against the config_rules (reported as CONFIG findings). Runs before JDT.LS starts.")
+	argEndpoints = flag.String("emit-endpoints", "", "(Optional) Write the inventory of HTTP endpoints (path, method, handler, parameters) to this JSON file.")
+	argAnalyzer  = flag.String("analyzer", "", "(Optional) Extra analyzers, comma separated: 'authz' (controller endpoints without any authorization, reported as INFO findings).")
+	argExclude   = flag.String("exclude", "", "(Optional) Comma separated globs of paths to skip during candidate discovery, matched against the project-relative path or file/directory name (e.g. 'src/test/*,generated').")
+	argScope     = flag.String("scope", "", "(Optional) Comma separated package prefixes; only files in these packages are searched for sinks (callers are still traced across the whole workspace).")
+	argScopeDir  = flag.String("scope-dir", "", "(Optional) Comma separated directories (relative to -project); only files under them are searched for sinks.")
+	argFollow    = flag.Bool("follow-symlinks", false, "Follow symlinked directories while walking the project (cycles and duplicate physical directories are skipped).")
+	argNoGitign  = flag.Bool("no-gitignore", false, "Do not skip paths listed in .gitignore files (.lsptracerignore is still honored).")
+	argGenerated = flag.Bool("include-generated", false, "Also search generated sources (target/generated-sources, build/generated/sources) for sinks. They are always indexed for resolution.")
+	argTestCode  = flag.Bool("include-test-code", false, "Report findings whose sink is in test code (src/test, src/integrationTest, ...) or sample code (samples/, demo/, examples/). By default they are only counted in the overview.")
+	argTestPaths = flag.String("test-paths", "", "(Optional) Comma separated globs (relative to the project root, ** spans directories) of additional test code, e.g. 'qa/**'.")
+	argSamples   = flag.String("sample-paths", "", "(Optional) Comma separated globs of additional sample/demo code, e.g. 'tutorials/**'.")
+	argEnvTaint  = flag.Bool("treat-env-as-taint", false, "Report sinks whose argument comes from System.getenv/System.getProperty (by default they are skipped like constants).")
+	argKeepExt   = flag.Bool("keep-extracted", false, "Keep the temporary directory when -project is a source archive (.zip, .jar, .tar.gz).")
+	argDryRun    = flag.Bool("dry-run", false, "Only detect the workspace, source roots and text candidates (grouped by rule), without downloading JDT.LS, starting it or writing .project files. With -format json the preview is also written as JSON.")
+	argReuseCfg  = flag.Bool("reuse-config", false, "Keep a valid existing .project/.classpath (adding missing source roots) instead of regenerating them.")
+	argWarmup    = flag.Int("warmup", analysis.DefaultWarmupFiles, "Files containing candidates (and Controllers next to them) opened before verification so JDT.LS has parsed them (0 = only the anchor file).")
+	argMaxCall   = flag.Int("max-callers", analysis.DefaultMaxCallers, "Maximum callers traced per method; methods with more references are traced partially and listed in the summary (0 = unlimited).")
+	argMaxDepth  = flag.Int("max-depth", analysis.DefaultMaxDepth, "Maximum callers traced above a sink; deeper chains are recorded as partial chains ending with DEPTH_LIMIT (0 = unlimited).")
+	argConSteps  = flag.Int("console-steps", analysis.DefaultConsoleSteps, "Chains longer than this are collapsed in the console to the source and the steps at both ends (0 = never collapse). The report always has every step.")
+	argVerbose   = flag.Bool("verbose", false, "Also print every caller and field write found while tracing (through the same queue as the chains, so they never interleave).")
+	argConChains = flag.Int("console-chains", analysis.DefaultConsoleChains, "Number of chains printed in full on the console; later chains are printed as one line each (0 = unlimited).")
+	argSpill     = flag.Bool("spill", false, "Stream findings to a temporary NDJSON file during the scan and keep only per-sink counts in memory (for very large projects). The report stage reads them back in batches and the report writers stream them from disk.")
+	argSinkTime  = flag.Duration("per-sink-timeout", analysis.DefaultSinkTimeout, "Wall-clock budget for tracing a single sink; longer traces are recorded as truncated partial chains (0 = unlimited).")
+	argMinHealth = flag.Float64("min-health", 0, "(Optional) Minimum scan health (0-1, share of files without compile errors). The run fails if indexing health is lower.")
+	argOrphans   = flag.String("orphan-sinks", analysis.OrphanDowngrade, "How to handle sinks with no enclosing function: 'report', 'suppress' or 'downgrade' (reported as unverified with a lower effective severity).")
+	argSources   = flag.String("sources", model.DefaultSources, "Source kinds accepted in strict mode, comma separated: http, mq, scheduled, cli.")
+	argConfig    = flag.String("config", "", "(Optional) Path to lsptracer.yaml. If empty, lsptracer.yaml in the project root or current directory is used when present. Command line flags override file values.")
+	argStrict    = flag.String("strict", "auto", "Strict mode: 'auto' (on for auto-scan, off for single sink), 'true' or 'false'. Only chains that reached an accepted framework entry (REACHED_ENTRY) with input are kept in the HTML/SARIF reports.")
+	argShowUnv   = flag.Bool("show-unverified", false, "List chains excluded by strict mode in a separate report section instead of dropping them from HTML/SARIF (JSON always keeps them).")
+	argOutput    = flag.String("output", report.OutputDir, "Directory for generated reports.")
+	argCtxBudget = flag.Int("html-context-budget", report.DefaultContextBudget>>20, "Total size (MB) of full-context code blocks in one HTML report; later steps only show their summary line (0 = unlimited).")
+	argTemplate  = flag.String("template", "", "(Optional) Custom HTML report template (Go html/template). It is parsed after the built-in one, so it may only redefine \"vuln-card\" or provide a whole page; see README for the data fields.")
+	argRepTitle  = flag.String("report-title", "", "(Optional) Title of the HTML report.")
+	argLogo      = flag.String("logo", "", "(Optional) Image shown in the HTML report sidebar (embedded as base64).")
+	argLang      = flag.String("lang", "", "(Optional) Report language for rule descriptions, vulnerability type names and the HTML report: 'en' or 'zh'. Defaults to LC_ALL / LC_MESSAGES / LANG (English otherwise).")
+	argPageSize  = flag.Int("html-page-threshold", report.DefaultPageThreshold, "Split the HTML report into an index page and one page per vulnerability type when it has more findings than this (0 = never split).")
+	argMinSev    = flag.String("min-severity", "", "(Optional) Drop findings below this severity: info, low, medium, high, critical.")
+	argMinConf   = flag.String("min-confidence", "", "(Optional) Drop findings below this confidence: low, medium, high.")
+	argBaseline  = flag.String("baseline", "", "(Optional) JSON result of a previous scan; findings already present in it are not reported.")
+	argOwners    = flag.String("owners", "", "(Optional) YAML file mapping path globs to teams (CODEOWNERS style); each finding records the team owning its sink file.")
+	argProgress  = flag.String("progress", "text", "Progress output: 'text' (console only) or 'json' (also write NDJSON events to stderr or -progress-file).")
+	argProgFile  = flag.String("progress-file", "", "(Optional) File for -progress json events instead of stderr.")
+	argJvmOpts   = flag.String("jvm-opts", "", "(Optional) Extra JVM options for JDT.LS, space separated (e.g. '-Xmx8G').")
+	argVersion   = flag.Bool("version", false, "Print the LSPTracer version, build revision and the JDT.LS / Java runtime that scans would use, then exit.")
 )
 
 // 自动读取文件指定行的代码
@@ -77,6 +144,40 @@ func SmartWorkspaceFinder(targetFileAbs string) string {
 	return filepath.Dir(targetFileAbs)
 }
 
+// splitList 解析逗号分隔的参数，去掉空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// findAnchorFile 自动寻找第一个 .java 文件作为 LSP 启动锚点
+func findAnchorFile(root string, followSymlinks bool) string {
+	var anchorFile string
+	analysis.WalkProject(root, followSymlinks, func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			// Skip hidden dirs (like .mvn, .git, .idea), build/target dirs and ignored dirs
+			if analysis.ScanWalk.SkipDir(root, path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), ".java") && !analysis.ScanWalk.SkipFile(path) {
+			anchorFile = path
+			return filepath.SkipDir // 找到一个就行
+		}
+		return nil
+	})
+	return anchorFile
+}
+
 func hasBuildFile(dir string) bool {
 	if _, err := os.Stat(filepath.Join(dir, "pom.xml")); err == nil {
 		return true
@@ -89,9 +190,9 @@ func hasBuildFile(dir string) bool {
 
 // 强力清理：清除 JDT.LS 缓存以及项目下的 IDE 配置文件
 // 确保每次启动都是干净的环境，强制 JDT.LS 读取我们新生成的配置
-func ForceClean(root string) {
+func ForceClean(root string, followSymlinks bool) {
 	// 1. 清理 JDT.LS 的数据缓存
-	cacheDir := ".jdtls_data_cache"
+	cacheDir := env.DataCacheDirName
 	if _, err := os.Stat(cacheDir); err == nil {
 		os.RemoveAll(cacheDir)
 	}
@@ -110,213 +211,74 @@ func ForceClean(root string) {
 	if _, err := os.Stat(settingsDir); err == nil {
 		os.RemoveAll(settingsDir)
 	}
+
+	// 4. 清理之前为各个子模块生成的配置 (用户自己的配置不会被删除)
+	analysis.RemoveGeneratedModuleConfigs(root, followSymlinks)
 }
 
 func main() {
+	// 子命令 (不需要启动语言服务器)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "test-rules":
+			os.Exit(runTestRules(os.Args[2:]))
+		case "rules":
+			os.Exit(runRules(os.Args[2:]))
+		case "render":
+			os.Exit(runRender(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "init":
+			os.Exit(runInit(os.Args[2:]))
+		case "env":
+			os.Exit(runEnv(os.Args[2:]))
+		}
+	}
+
 	// 1. 强制清理 JDT.LS 缓存 (启动前先清理一次，防止读取旧索引)
-	if _, err := os.Stat(".jdtls_data_cache"); err == nil {
-		os.RemoveAll(".jdtls_data_cache")
+	if _, err := os.Stat(env.DataCacheDirName); err == nil {
+		os.RemoveAll(env.DataCacheDirName)
 	}
 
 	// 2. 解析命令行
 	flag.Parse()
-
-	if *argProject == "" {
-		log.Fatal("Please provide -project argument.\nExample: -project ./mall")
+	if *argVersion {
+		env.DepsDir = *argDepsDir
+		printVersion(*argJdtlsHome)
+		return
 	}
-
-	// 判断模式：是否为全自动扫描
-	autoScanMode := false
-	if *argFile == "" {
-		autoScanMode = true
-	}
-
-	// 3. 环境自动准备
-	var lombokPath string
-	autoJdtls, autoLombok, err := env.EnsureEnv()
+	opts, err := parseOptions()
 	if err != nil {
-		log.Printf("[!] Environment setup warning: %v", err)
-	}
-
-	finalJdtlsHome := *argJdtlsHome
-	if finalJdtlsHome == "" {
-		finalJdtlsHome = autoJdtls
-		if finalJdtlsHome != "" {
-			color.Green("[*] Using auto-installed JDT.LS: %s", finalJdtlsHome)
-		}
-	}
-
-	lombokPath = autoLombok
-
-	if finalJdtlsHome == "" {
-		log.Fatal("❌ JDT.LS not found. Please specify -jdtls or check network for auto-download.")
-	}
-
-	// 4. 处理路径 (Project Root)
-	absProjectRoot, _ := filepath.Abs(*argProject)
-
-	// 确定启动锚点文件 (Anchor File) 和 目标文件/行号
-	var anchorFile string
-	var targetLine int
-
-	if autoScanMode {
-		color.Cyan("[*] Auto-Scan Mode Enabled. Searching for anchor file...")
-		// 自动寻找第一个 .java 文件作为 LSP 启动锚点
-		filepath.Walk(absProjectRoot, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
-			if info.IsDir() {
-				// Skip hidden dirs (like .mvn, .git, .idea) and build/target dirs
-				if strings.HasPrefix(info.Name(), ".") || info.Name() == "target" || info.Name() == "build" {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			if strings.HasSuffix(info.Name(), ".java") {
-				anchorFile = path
-				return filepath.SkipDir // 找到一个就行
-			}
-			return nil
-		})
-		if anchorFile == "" {
-			log.Fatal("[-] No .java files found in the project. Cannot start analysis.")
-		}
-	} else {
-		// 解析 file:line 格式
-		lastColon := strings.LastIndex(*argFile, ":")
-		if lastColon == -1 {
-			log.Fatal("Invalid file format. Please use 'path/to/file:line' (e.g., Main.java:42)")
-		}
-
-		rawFilePath := (*argFile)[:lastColon]
-		lineStr := (*argFile)[lastColon+1:]
-
-		var err error
-		targetLine, err = strconv.Atoi(lineStr)
-		if err != nil || targetLine <= 0 {
-			log.Fatalf("Invalid line number: %s", lineStr)
-		}
-
-		if filepath.IsAbs(rawFilePath) {
-			anchorFile = rawFilePath
-		} else {
-			anchorFile = filepath.Join(absProjectRoot, rawFilePath)
-		}
-	}
-
-	// 5. 探测工作区根目录并清理配置
-	realWorkspaceRoot := SmartWorkspaceFinder(anchorFile)
-	color.Blue("[*] Smart Workspace Detected: %s", realWorkspaceRoot)
-
-	// Mode handling
-	currentMode := strings.ToLower(*argMode)
-	if currentMode != "light" && currentMode != "precise" {
-		log.Fatal("Invalid mode. Use 'light' or 'precise'.")
-	}
-	color.Blue("[*] Running in %s mode", strings.ToUpper(currentMode))
-
-	if currentMode == "light" {
-		ForceClean(realWorkspaceRoot)
-		// ✨✨✨ 生成欺骗性 Eclipse 配置 (Light Mode Only) ✨✨✨
-		if err := analysis.GenerateEclipseConfig(realWorkspaceRoot); err != nil {
-			color.Red("[-] Failed to generate Eclipse config: %v", err)
-		}
-	} else {
-		// Precise Mode: Clean only cache, keep/let JDT.LS manage project files?
-		// Actually, to trigger a clean import, we might still want to clean old .project files
-		// if they were generated by Light mode previously.
-		ForceClean(realWorkspaceRoot)
-		// But DO NOT generate new ones.
-	}
-
-	// 6. 构建命令 & 启动
-	javaLang := lang.JavaConfig{
-		JdtlsHome:  finalJdtlsHome,
-		JavaExec:   "java",
-		LombokPath: lombokPath,
+		log.Fatal(err)
 	}
-	cmd, err := javaLang.BuildCmd()
-	if err != nil {
+	opts.Args = os.Args[1:]
+	if err := Run(context.Background(), opts); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	client, err := lsp.NewClient(cmd)
+// writeReports 按逗号分隔的格式列表生成报告 (扫描和 render 子命令共用)
+// 链路先排成稳定顺序，同样的结果在每次扫描中得到同样的编号；报告中的路径按 meta.PathMap 换成宿主机路径
+// 链路从 src 逐条读回，内存中只保留排序键和 HTML 卡片的摘要
+func writeReports(formats string, src report.ChainSource, projectRoot string, meta report.Metadata) {
+	projectRoot = meta.PathMap.ToHost(projectRoot)
+	chains, meta, err := report.NewChains(src, projectRoot, meta)
 	if err != nil {
-		log.Fatalf("Failed to start LSP: %v", err)
+		color.Red("[-] Failed to read back findings: %v", err)
+		return
 	}
-	defer client.Close()
-
-	// 7. 启动追踪器
-	tracer := analysis.NewTracer(client, realWorkspaceRoot, currentMode)
-	tracer.StrictMode = autoScanMode // Auto-Scan = Strict Mode; Single File = Loose Mode
-	tracer.Start(anchorFile)         // 发送 didOpen 信号激活 LSP
-
-	// 8. 根据模式执行扫描
-	if autoScanMode {
-		// ✨✨✨ 全自动扫描模式 ✨✨✨
-
-		// 加载规则优先级: 1. 命令行参数 2. 当前目录 rules.yaml 3. 内置默认
-		var rules []model.SinkRule
-		var err error
-
-		rulePath := *argRules
-		if rulePath == "" {
-			// 尝试默认文件名
-			if _, err := os.Stat("rules.yaml"); err == nil {
-				rulePath = "rules.yaml"
-			}
-		}
-
-		if rulePath != "" {
-			color.Cyan("[*] Loading rules from: %s", rulePath)
-			rules, err = model.LoadRulesFromFile(rulePath)
-			if err != nil {
-				log.Fatalf("[-] Failed to load rules from %s: %v", rulePath, err)
-			}
-		} else {
-			color.Cyan("[*] Using built-in default rules.")
-			rules = model.GetBuiltinRules()
+	for _, format := range strings.Split(strings.ToLower(formats), ",") {
+		switch strings.TrimSpace(format) {
+		case "html":
+			report.GenerateHTML(chains, projectRoot, meta)
+		case "json":
+			// JSON 即使没有结果也生成，方便 CI 读取
+			report.GenerateJSON(chains, projectRoot, meta)
+		case "sarif":
+			report.GenerateSARIF(chains, projectRoot, meta)
+		case "":
+		default:
+			color.Red("[-] Unknown report format: %s", format)
 		}
-
-		color.Blue("[*] Loaded %d rules.", len(rules))
-
-		tracer.ScanAndTrace(rules)
-	} else {
-		// ✨✨✨ 单点狙击模式 ✨✨✨
-		color.Cyan("[*] Analyzing Sink at Line %d", targetLine)
-
-		targetLineIndex := targetLine - 1
-		funcName, funcLine, _, funcCol := tracer.GetEnclosingFunction(lsp.ToUri(anchorFile), targetLineIndex)
-
-		if funcName != "" {
-			color.Green("[+] Hit Initial Function: %s (Line:%d)", funcName, funcLine+1)
-
-			realCode := GetLineContent(anchorFile, targetLine)
-
-			firstStep := model.ChainStep{
-				File: anchorFile,
-				Line: targetLineIndex,
-				Func: funcName,
-				Code: realCode,
-			}
-
-			tracer.TraceChain(anchorFile, funcLine, funcCol, []model.ChainStep{firstStep}, make(map[string]bool))
-
-			// Wait for async trace tasks to complete
-			color.Cyan("[*] Waiting for trace chains to complete...")
-			tracer.Wg.Wait()
-		} else {
-			color.Red("[-] Could not find function context. Is the line number correct?")
-		}
-	}
-
-	// 9. 生成报告
-	if len(tracer.Results) > 0 {
-		// ✨✨✨ 传入 realWorkspaceRoot (项目根目录) ✨✨✨
-		report.GenerateHTML(tracer.Results, realWorkspaceRoot)
-	} else {
-		fmt.Println()
-		color.Yellow("[*] No vulnerability chains found.")
 	}
 }
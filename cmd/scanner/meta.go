@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"LSPTracer/internal/env"
+	"LSPTracer/internal/lang"
+	"LSPTracer/internal/report"
+)
+
+// version 通过 -ldflags "-X main.version=v1.2.3" 在构建时设置
+var version = "dev"
+
+// revision 通过 -ldflags "-X main.revision=<commit>" 在构建时设置；为空时使用 go build 记录的 vcs.revision
+var revision = ""
+
+// buildRevision LSPTracer 构建时的 git revision，工作区有未提交的修改时加上 "-dirty"
+func buildRevision() string {
+	if revision != "" {
+		return revision
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var rev, dirty string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				dirty = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return ""
+	}
+	return rev + dirty
+}
+
+// newProvenance 记录结果的产生环境 (args 是原始的命令行参数)；JDT.LS 的版本在语言服务器启动后用 serverInfo 更新
+func newProvenance(args []string, rulesFile, jdtlsHome, javaExec string) *report.Provenance {
+	p := &report.Provenance{
+		Version:      version,
+		Revision:     buildRevision(),
+		GoVersion:    runtime.Version(),
+		JdtlsVersion: lang.JdtlsVersion(jdtlsHome),
+		JavaRuntime:  lang.JavaVersion(javaExec),
+		Args:         append([]string{}, args...),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if rulesFile != "" {
+		if data, err := os.ReadFile(rulesFile); err == nil {
+			sum := sha256.Sum256(data)
+			p.RulesSHA256 = hex.EncodeToString(sum[:])
+		}
+	}
+	return p
+}
+
+// printVersion -version: 输出 LSPTracer 的版本以及会写入报告的运行环境 (不下载 JDT.LS)
+func printVersion(jdtlsHome string) {
+	if jdtlsHome == "" {
+		if depsRoot, err := env.DepsRoot(); err == nil {
+			jdtlsHome = filepath.Join(depsRoot, "jdtls")
+		}
+	}
+	p := newProvenance(os.Args[1:], "", jdtlsHome, "java")
+	fmt.Printf("LSPTracer %s\n", p.Version)
+	row := func(name, value string) {
+		if value == "" {
+			value = "unknown"
+		}
+		fmt.Printf("  %-9s %s\n", name+":", value)
+	}
+	row("Revision", p.Revision)
+	row("Go", p.GoVersion)
+	row("Platform", p.Platform)
+	jdtls := p.JdtlsVersion
+	if jdtls != "" {
+		jdtls += " (" + jdtlsHome + ")"
+	} else {
+		jdtls = "not installed (" + jdtlsHome + ")"
+	}
+	row("JDT.LS", jdtls)
+	row("Java", p.JavaRuntime)
+}
+
+// gitRevision 返回项目当前的 commit 和分支 (best-effort，不是 git 仓库或没有安装 git 时返回空)
+func gitRevision(dir string) (commit, branch string) {
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit = run("rev-parse", "HEAD")
+	if commit == "" {
+		return "", ""
+	}
+	branch = run("rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "HEAD" { // detached HEAD
+		branch = ""
+	}
+	return commit, branch
+}
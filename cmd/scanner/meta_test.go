@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// -version 输出与报告中相同的产生环境，JDT.LS 的版本取自 plugins 中 jdt.ls.core 的文件名
+func TestPrintVersion(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "plugins", "org.eclipse.jdt.ls.core_1.40.0.202409261450.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() { printVersion(home) })
+	for _, want := range []string{
+		"LSPTracer " + version + "\n",
+		"  Go:       " + runtime.Version() + "\n",
+		"  Platform: " + runtime.GOOS + "/" + runtime.GOARCH + "\n",
+		"  JDT.LS:   1.40.0.202409261450 (" + home + ")\n",
+		"  Java:     ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "jdtls")
+	if out := captureStdout(t, func() { printVersion(missing) }); !strings.Contains(out, "  JDT.LS:   not installed ("+missing+")\n") {
+		t.Errorf("output without JDT.LS:\n%s", out)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"LSPTracer/internal/config"
+	"LSPTracer/internal/i18n"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// loadRules 加载 Sink 规则，优先级: 1. 命令行参数 2. 当前目录 rules.yaml 3. 内置默认
+// 返回实际使用的规则文件 (内置规则时为空)
+func loadRules(rulePath string) ([]model.SinkRule, string, error) {
+	if rulePath == "" {
+		// 尝试默认文件名
+		if _, err := os.Stat("rules.yaml"); err == nil {
+			rulePath = "rules.yaml"
+		}
+	}
+
+	if rulePath == "" {
+		color.Cyan("[*] Using built-in default rules.")
+		return model.GetBuiltinRules(), "", nil
+	}
+	color.Cyan("[*] Loading rules from: %s", rulePath)
+	rules, err := model.LoadRulesFromFile(rulePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("[-] Failed to load rules from %s: %v", rulePath, err)
+	}
+	return rules, rulePath, nil
+}
+
+// configureHTML 设置 HTML 报告的标题、Logo 和自定义模板 (模板有错误时返回包含行号的解析错误)
+func configureHTML(templatePath, title, logoPath string) error {
+	report.Title = title
+	if logoPath != "" {
+		logo, err := report.LoadLogo(logoPath)
+		if err != nil {
+			return fmt.Errorf("failed to load logo: %w", err)
+		}
+		report.Logo = logo
+	}
+	if templatePath != "" {
+		if err := report.LoadTemplate(templatePath); err != nil {
+			return fmt.Errorf("invalid report template: %w", err)
+		}
+		color.Cyan("[*] Using report template: %s", templatePath)
+	}
+	return nil
+}
+
+// reportLocale 报告语言: -lang 指定的值 (接受 zh_CN.UTF-8 形式)，为空时按 LC_ALL / LC_MESSAGES / LANG 推断
+func reportLocale(value string) (string, error) {
+	if value == "" {
+		return i18n.Detect(), nil
+	}
+	return i18n.Normalize(value)
+}
+
+// applyConfig 加载配置文件并把其中的值填入 fs 中没有在命令行显式指定的参数
+// path 为空时自动探测 (projectRoot / 当前目录下的 lsptracer.yaml)，返回实际使用的文件
+func applyConfig(fs *flag.FlagSet, path, projectRoot string) (string, error) {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if path == "" {
+		path = config.Find(projectRoot)
+		if path == "" {
+			return "", nil
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	values := cfg.FlagValues()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, values[name]); err != nil {
+			return "", fmt.Errorf("%s: invalid value %q for %s: %v", path, values[name], name, err)
+		}
+	}
+	return path, nil
+}
+
+// applyStrict 按严格模式标记链路: 开启时在 Sink 步骤记录被排除的原因，关闭时清除 (render 可以重新切换)
+// 返回被排除的链路数量
+func applyStrict(chains [][]model.ChainStep, strict bool, accepted map[string]bool) int {
+	excluded := 0
+	for _, stack := range chains {
+		if len(stack) == 0 {
+			continue
+		}
+		stack[0].StrictExcluded = ""
+		if strict {
+			stack[0].StrictExcluded = model.StrictExclusion(stack, accepted)
+		}
+		if stack[0].StrictExcluded != "" {
+			excluded++
+		}
+	}
+	return excluded
+}
+
+// filterBySeverity 去掉有效等级 (model.EffectiveSeverity) 低于 min 的链路；没有规则或等级无法识别的链路保留
+func filterBySeverity(chains [][]model.ChainStep, min string) [][]model.ChainStep {
+	minRank := model.SeverityRank(min)
+	var kept [][]model.ChainStep
+	for _, stack := range chains {
+		if len(stack) > 0 && stack[0].Rule != nil && model.SeverityRank(stack[0].Rule.Severity) >= 0 {
+			if model.SeverityRank(model.EffectiveSeverity(stack[0])) < minRank {
+				continue
+			}
+		}
+		kept = append(kept, stack)
+	}
+	return kept
+}
+
+// filterByConfidence 去掉可信度低于 min 的链路；没有可信度数据的链路 (例如旧版 JSON 结果) 保留
+func filterByConfidence(chains [][]model.ChainStep, min string) [][]model.ChainStep {
+	minRank := model.ConfidenceRank(min)
+	var kept [][]model.ChainStep
+	for _, stack := range chains {
+		if len(stack) > 0 && stack[0].Confidence != nil && model.ConfidenceRank(stack[0].Confidence.Level()) < minRank {
+			continue
+		}
+		kept = append(kept, stack)
+	}
+	return kept
+}
+
+// loadBaseline 读取 -baseline 指定的 JSON 报告
+func loadBaseline(path string) (*report.Baseline, error) {
+	old, oldMeta, err := report.LoadJSON(path)
+	if err != nil {
+		return nil, fmt.Errorf("[-] Failed to load baseline %s: %v", path, err)
+	}
+	return report.NewBaseline(old, oldMeta.ProjectRoot), nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/config"
+	"LSPTracer/internal/model"
+)
+
+// testFlags 与主程序同名的一组参数，用于检查配置文件的优先级
+func testFlags() (*flag.FlagSet, map[string]interface{}) {
+	fs := flag.NewFlagSet("lsptracer", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	values := map[string]interface{}{
+		"strict":    fs.String("strict", "auto", ""),
+		"output":    fs.String("output", "reports", ""),
+		"format":    fs.String("format", "html", ""),
+		"max-depth": fs.Int("max-depth", 12, ""),
+		"secrets":   fs.Bool("secrets", false, ""),
+		"rules":     fs.String("rules", "", ""),
+	}
+	return fs, values
+}
+
+func writeTestConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, config.DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestConfig(t, dir, strings.Join([]string{
+		"strict: \"true\"",
+		"max_depth: 0",
+		"secrets: true",
+		"rules: custom-rules.yaml",
+		"output:",
+		"  dir: /tmp/from-config",
+	}, "\n"))
+
+	fs, v := testFlags()
+	// 命令行显式指定的值 (包括与默认值相同的值) 优先于配置文件
+	if err := fs.Parse([]string{"-strict", "false", "-output", "reports"}); err != nil {
+		t.Fatal(err)
+	}
+	used, err := applyConfig(fs, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != path {
+		t.Errorf("applyConfig returned %q, want %q", used, path)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"strict (flag)", *v["strict"].(*string), "false"},
+		{"output (flag equal to default)", *v["output"].(*string), "reports"},
+		{"max-depth (config zero)", *v["max-depth"].(*int), 0},
+		{"secrets (config)", *v["secrets"].(*bool), true},
+		{"rules (config, resolved)", *v["rules"].(*string), filepath.Join(dir, "custom-rules.yaml")},
+		{"format (default)", *v["format"].(*string), "html"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfigAutoDetect(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(t.TempDir())
+
+	fs, v := testFlags()
+	fs.Parse(nil)
+	if used, err := applyConfig(fs, "", project); err != nil || used != "" {
+		t.Fatalf("no config: used %q, err %v", used, err)
+	}
+
+	path := writeTestConfig(t, project, "output:\n  formats: [json, sarif]\n")
+	used, err := applyConfig(fs, "", project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != path || *v["format"].(*string) != "json,sarif" {
+		t.Errorf("used %q, format %q", used, *v["format"].(*string))
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	fs, _ := testFlags()
+	fs.Parse(nil)
+	unknown := writeTestConfig(t, dir, "strikt: true\n")
+	if _, err := applyConfig(fs, unknown, ""); err == nil || !strings.Contains(err.Error(), "strikt") {
+		t.Errorf("unknown key: err = %v", err)
+	}
+
+	// 值的校验交给参数自己的解析: 错误信息包含文件、参数名和值
+	invalid := writeTestConfig(t, dir, "secrets: true\nmin_health: 0.5\n")
+	fs2 := flag.NewFlagSet("lsptracer", flag.ContinueOnError)
+	fs2.SetOutput(io.Discard)
+	fs2.Bool("secrets", false, "")
+	fs2.Int("min-health", 0, "")
+	fs2.Parse(nil)
+	_, err := applyConfig(fs2, invalid, "")
+	if err == nil || !strings.Contains(err.Error(), "min-health") || !strings.Contains(err.Error(), invalid) {
+		t.Errorf("invalid value: err = %v", err)
+	}
+}
+
+// -min-confidence 去掉低于阈值的链路，没有可信度数据的链路保留
+func TestFilterByConfidence(t *testing.T) {
+	chain := func(name string, c *model.Confidence) []model.ChainStep {
+		return []model.ChainStep{{Func: name, Confidence: c}}
+	}
+	high := &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByDefinition}, FrameworkEntry: true, TaintedInput: true}
+	medium := &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByImport}, DataFlow: true}
+	low := &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByImport}}
+	chains := [][]model.ChainStep{chain("high", high), chain("medium", medium), chain("low", low), chain("legacy", nil)}
+
+	for min, want := range map[string]string{
+		"low":    "high,medium,low,legacy",
+		"medium": "high,medium,legacy",
+		"High":   "high,legacy",
+	} {
+		var kept []string
+		for _, c := range filterByConfidence(chains, min) {
+			kept = append(kept, c[0].Func)
+		}
+		if got := strings.Join(kept, ","); got != want {
+			t.Errorf("-min-confidence %s kept %s, want %s", min, got, want)
+		}
+	}
+}
@@ -0,0 +1,952 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/analysis"
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/env"
+	"LSPTracer/internal/events"
+	"LSPTracer/internal/lang"
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// Options 校验之后的扫描选项 (parseOptions 由命令行和配置文件生成)，各阶段只读取这里的设置
+// 只影响其它包全局状态的参数 (-output / -deps-dir / -template 等) 在 parseOptions 中直接设置
+type Options struct {
+	// 目标
+	Project       string   // -project (源码压缩包解压后替换为检测到的项目根目录)
+	Files         []string // -file 单点模式的目标 (path:line)
+	TargetsFile   string   // -targets
+	Method        string   // -method
+	Direction     string   // -direction: up / down / both
+	CalleeDepth   int      // -callee-depth
+	DryRun        bool     // -dry-run
+	KeepExtracted bool     // -keep-extracted
+
+	// 文件遍历和扫描范围
+	Scope            []string // -scope 包名前缀
+	ScopeDirs        []string // -scope-dir (相对 -project)
+	Exclude          []string // -exclude
+	FollowSymlinks   bool     // -follow-symlinks
+	NoGitignore      bool     // -no-gitignore
+	IncludeGenerated bool     // -include-generated
+	TreatEnvAsTaint  bool     // -treat-env-as-taint
+
+	// 规则和追踪
+	Mode          string        // -mode: light / precise
+	RulesPath     string        // -rules
+	ConfigScan    bool          // -config-scan
+	Secrets       bool          // -secrets
+	Templates     bool          // -templates
+	Analyzers     []string      // -analyzer
+	Warmup        int           // -warmup
+	MaxCallers    int           // -max-callers
+	MaxDepth      int           // -max-depth
+	SinkTimeout   time.Duration // -per-sink-timeout
+	OrphanSinks   string        // -orphan-sinks
+	Spill         bool          // -spill
+	ConsoleSteps  int           // -console-steps
+	ConsoleChains int           // -console-chains
+	Verbose       bool          // -verbose
+
+	// 语言服务器
+	JdtlsHome    string   // -jdtls，为空时使用自动下载的 JDT.LS
+	LombokJar    string   // -lombok-jar
+	NoLombok     bool     // -no-lombok
+	JvmOptions   []string // -jvm-opts
+	ReuseConfig  bool     // -reuse-config
+	NoIndexProbe bool     // -no-index-probe
+	MinHealth    float64  // -min-health
+	LspLog       string   // -lsp-log
+	LspLogMax    int      // -lsp-log-max
+	// ServerCmd 生成启动语言服务器的命令，nil 时按 JDT.LS 的配置生成 (集成测试换成脚本化的假语言服务器)
+	ServerCmd func(lang.JavaConfig) (*exec.Cmd, error)
+
+	// 报告
+	Formats         string          // -format
+	Strict          string          // -strict: auto / true / false
+	Sources         string          // -sources 原始值 (记录在报告中)
+	SourceKinds     map[string]bool // -sources 接受的入口类别
+	ShowUnverified  bool            // -show-unverified
+	IncludeTestCode bool            // -include-test-code
+	MinSeverity     string          // -min-severity
+	MinConfidence   string          // -min-confidence
+	Baseline        string          // -baseline
+	PathMap         report.PathMap  // -path-map
+	EndpointsFile   string          // -emit-endpoints
+	Owners          model.Owners    // -owners 中模块到团队的映射
+	CodePaths       model.CodePaths // -test-paths / -sample-paths
+	Locale          string          // -lang 报告语言 (未指定时按环境变量推断)
+	Args            []string        // 原始的命令行参数 (记录在报告的 provenance 中)
+
+	// 进度
+	Progress     string // -progress: text / json
+	ProgressFile string // -progress-file
+}
+
+// parseOptions 加载配置文件并校验参数；错误信息和拆分之前 main() 中的一致
+func parseOptions() (Options, error) {
+	var opts Options
+	// 配置文件: 命令行参数 > 配置文件 > 默认值
+	configFile, err := applyConfig(flag.CommandLine, *argConfig, *argProject)
+	if err != nil {
+		return opts, fmt.Errorf("[-] Failed to load config: %v", err)
+	}
+	if configFile != "" {
+		color.Cyan("[*] Loaded config: %s", configFile)
+	}
+	switch strings.ToLower(*argStrict) {
+	case "auto", "true", "false":
+	default:
+		return opts, errors.New("Invalid -strict value. Use 'auto', 'true' or 'false'.")
+	}
+	switch *argOrphans {
+	case analysis.OrphanReport, analysis.OrphanSuppress, analysis.OrphanDowngrade:
+	default:
+		return opts, errors.New("Invalid -orphan-sinks. Use 'report', 'suppress' or 'downgrade'.")
+	}
+	switch *argDirection {
+	case directionUp, directionDown, directionBoth:
+	default:
+		return opts, errors.New("Invalid -direction. Use 'up', 'down' or 'both'.")
+	}
+	if opts.SourceKinds, err = model.ParseSourceKinds(*argSources); err != nil {
+		return opts, fmt.Errorf("Invalid -sources: %v", err)
+	}
+	for _, name := range splitList(*argAnalyzer) {
+		if name != analysis.AnalyzerAuthz {
+			return opts, fmt.Errorf("Invalid -analyzer %q. Supported: %s.", name, analysis.AnalyzerAuthz)
+		}
+	}
+	if *argMinSev != "" && model.SeverityRank(*argMinSev) < 0 {
+		return opts, errors.New("Invalid -min-severity. Use info, low, medium, high or critical.")
+	}
+	if *argMinConf != "" && model.ConfidenceRank(*argMinConf) < 0 {
+		return opts, errors.New("Invalid -min-confidence. Use low, medium or high.")
+	}
+	switch strings.ToLower(*argProgress) {
+	case "text", "json":
+	default:
+		return opts, errors.New("Invalid -progress value. Use 'text' or 'json'.")
+	}
+	if opts.Locale, err = reportLocale(*argLang); err != nil {
+		return opts, fmt.Errorf("Invalid -lang: %v", err)
+	}
+	report.OutputDir = *argOutput
+	env.DepsDir = *argDepsDir
+	report.ContextBudget, report.PageThreshold = *argCtxBudget<<20, *argPageSize
+	if err := configureHTML(*argTemplate, *argRepTitle, *argLogo); err != nil {
+		return opts, fmt.Errorf("[-] %v", err)
+	}
+	if opts.Owners, err = model.LoadOwners(*argOwners); err != nil {
+		return opts, fmt.Errorf("[-] Failed to load owners from %s: %v", *argOwners, err)
+	}
+	if opts.CodePaths, err = model.NewCodePaths(splitList(*argTestPaths), splitList(*argSamples)); err != nil {
+		return opts, fmt.Errorf("[-] %v", err)
+	}
+	if *argProject == "" {
+		return opts, errors.New("Please provide -project argument.\nExample: -project ./mall")
+	}
+
+	opts.Project, opts.Files, opts.TargetsFile, opts.Method = *argProject, argFiles, *argTargets, *argMethod
+	opts.Direction, opts.CalleeDepth, opts.DryRun, opts.KeepExtracted = *argDirection, *argCallDepth, *argDryRun, *argKeepExt
+
+	opts.Scope, opts.ScopeDirs, opts.Exclude = splitList(*argScope), splitList(*argScopeDir), splitList(*argExclude)
+	opts.FollowSymlinks, opts.NoGitignore = *argFollow, *argNoGitign
+	opts.IncludeGenerated, opts.TreatEnvAsTaint = *argGenerated, *argEnvTaint
+
+	opts.Mode, opts.RulesPath, opts.ConfigScan = *argMode, *argRules, *argCfgScan
+	opts.Secrets, opts.Templates, opts.Analyzers = *argSecrets, *argTemplates, splitList(*argAnalyzer)
+	opts.Warmup, opts.MaxCallers, opts.MaxDepth, opts.SinkTimeout = *argWarmup, *argMaxCall, *argMaxDepth, *argSinkTime
+	opts.OrphanSinks, opts.Spill = *argOrphans, *argSpill
+	opts.ConsoleSteps, opts.ConsoleChains = *argConSteps, *argConChains
+	opts.Verbose = *argVerbose
+
+	opts.JdtlsHome, opts.LombokJar, opts.NoLombok = *argJdtlsHome, *argLombokJar, *argNoLombok
+	opts.JvmOptions, opts.ReuseConfig = strings.Fields(*argJvmOpts), *argReuseCfg
+	opts.NoIndexProbe, opts.MinHealth = *argNoProbe, *argMinHealth
+	opts.LspLog, opts.LspLogMax = *argLspLog, *argLspLogMax
+
+	opts.Formats, opts.Strict, opts.Sources, opts.ShowUnverified = *argFormat, *argStrict, *argSources, *argShowUnv
+	opts.IncludeTestCode, opts.MinSeverity, opts.MinConfidence = *argTestCode, *argMinSev, *argMinConf
+	opts.Baseline, opts.PathMap, opts.EndpointsFile = *argBaseline, report.PathMap(argPathMap), *argEndpoints
+
+	opts.Progress, opts.ProgressFile = *argProgress, *argProgFile
+	return opts, nil
+}
+
+// startupError 语言服务器没能启动或完成索引；Run 先把配置检查的结果写入部分报告再返回
+type startupError struct {
+	msg string
+}
+
+func (e *startupError) Error() string { return e.msg }
+
+func startupErrorf(format string, args ...any) error {
+	return &startupError{msg: fmt.Sprintf(format, args...)}
+}
+
+// pipeline 一次扫描在各阶段之间传递的状态
+type pipeline struct {
+	opts   Options
+	start  time.Time
+	phases *phaseTimer
+	bus    *events.Bus
+
+	// resolveTarget
+	autoScan      bool
+	projectRoot   string // -project 的绝对路径
+	workspaceRoot string // 探测到的工作区根目录 (报告中的路径相对这个目录)
+	scope         analysis.Scope
+	anchorFile    string
+	moduleAnchors []string
+	targets       []manualTarget
+	mode          string
+	javaLevel     analysis.JavaLevel
+	rules         []model.SinkRule
+	rulesFile     string
+	provenance    *report.Provenance
+
+	configFindings [][]model.ChainStep
+
+	// prepareEnv / prepareWorkspace
+	javaLang         lang.JavaConfig
+	workspaceFolders []string // 多模块项目中各模块的目录
+
+	// startServer
+	tracer *analysis.Tracer
+	strict bool
+
+	// scan
+	scanErr   error
+	allow     []model.AllowRule
+	endpoints []entrypoints.Endpoint
+	callTrees []*model.CalleeNode
+
+	cleanup []func()
+}
+
+// Run 按顺序执行扫描的各个阶段:
+// resolveTarget → prepareEnv → prepareWorkspace → startServer → scan → report
+// 每个阶段返回错误而不是直接退出，临时目录和语言服务器进程在返回前清理
+func Run(ctx context.Context, opts Options) error {
+	start := time.Now()
+	p := &pipeline{opts: opts, start: start, phases: newPhaseTimer(start)}
+	defer func() {
+		for i := len(p.cleanup) - 1; i >= 0; i-- {
+			p.cleanup[i]()
+		}
+	}()
+
+	bus, closeEvents, err := newEventBus(p.opts.Progress, p.opts.ProgressFile)
+	if err != nil {
+		return fmt.Errorf("[-] Failed to open progress file: %v", err)
+	}
+	p.cleanup = append(p.cleanup, closeEvents)
+	p.bus, p.phases.bus = bus, bus
+
+	if err := p.resolveTarget(); err != nil {
+		return err
+	}
+	// 预览模式: 只做文本初筛，不下载环境、不启动语言服务器、不修改 .project/.classpath
+	if p.opts.DryRun {
+		preview := analysis.NewTracer(nil, p.workspaceRoot, p.mode)
+		applyWalkOptions(preview, p.opts, p.scope)
+		runDryRun(preview, p.rules, p.javaLevel, p.opts.Formats)
+		return nil
+	}
+	if err := p.checkConfigFiles(); err != nil {
+		return err
+	}
+
+	stages := []func() error{p.prepareEnv, p.prepareWorkspace, p.startServer, p.scan}
+	for _, stage := range stages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := stage(); err != nil {
+			var startup *startupError
+			if errors.As(err, &startup) {
+				p.writePartialReport(startup.msg)
+			}
+			return err
+		}
+	}
+	return p.report()
+}
+
+// resolveTarget 确定项目根目录、扫描范围、锚点文件和目标，加载规则
+func (p *pipeline) resolveTarget() error {
+	// 源码压缩包: 解压到临时目录，扫描检测到的项目根目录 (报告中的路径相对这个目录)
+	if info, err := os.Stat(p.opts.Project); err == nil && info.Mode().IsRegular() && env.IsArchive(p.opts.Project) {
+		tmpDir, root, err := env.ExtractProject(p.opts.Project)
+		if err != nil {
+			return fmt.Errorf("[-] Failed to extract %s: %v", p.opts.Project, err)
+		}
+		color.Cyan("[*] Extracted %s, project root: %s", filepath.Base(p.opts.Project), root)
+		if p.opts.KeepExtracted {
+			color.Cyan("[*] Extracted sources are kept (-keep-extracted): %s", tmpDir)
+		} else {
+			p.cleanup = append(p.cleanup, func() { os.RemoveAll(tmpDir) })
+		}
+		p.opts.Project = root
+	}
+
+	// 判断模式：是否为全自动扫描
+	p.autoScan = len(p.opts.Files) == 0 && p.opts.TargetsFile == "" && p.opts.Method == ""
+	if p.opts.DryRun && !p.autoScan {
+		return errors.New("[-] -dry-run previews an auto-scan; it cannot be combined with targets or -method.")
+	}
+
+	// 处理路径 (Project Root)
+	p.projectRoot, _ = filepath.Abs(p.opts.Project)
+
+	// .gitignore (从所在 git 仓库的根目录开始) 和 .lsptracerignore 中忽略的路径不参与任何遍历
+	analysis.UseIgnoreFiles(analysis.NewIgnoreMatcher(analysis.IgnoreRoot(p.projectRoot), !p.opts.NoGitignore))
+	// 依赖目录、报告输出目录和 JDT.LS 数据目录在项目中时 (在项目目录下运行) 也不参与任何遍历
+	excludeToolDirs(p.projectRoot)
+
+	// 扫描范围 (-scope / -scope-dir): 目录相对 -project 解析
+	p.scope = analysis.Scope{Packages: p.opts.Scope}
+	for _, dir := range p.opts.ScopeDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(p.projectRoot, dir)
+		}
+		p.scope.Dirs = append(p.scope.Dirs, filepath.Clean(dir))
+	}
+	if !p.scope.Empty() {
+		color.Cyan("[*] Scope: %s", strings.Join(p.scope.Labels(p.projectRoot), ", "))
+	}
+
+	// 确定启动锚点文件 (Anchor File) 和 目标文件/行号
+	if p.autoScan {
+		color.Cyan("[*] Auto-Scan Mode Enabled. Searching for anchor file...")
+		choice := analysis.PickAnchors(analysis.CollectAnchorFiles(p.projectRoot, p.opts.FollowSymlinks, p.scope))
+		p.anchorFile, p.moduleAnchors = choice.Primary, choice.ModuleAnchors
+		if p.anchorFile != "" {
+			rel, _ := filepath.Rel(p.projectRoot, p.anchorFile)
+			color.Cyan("[*] Anchor: %s (%s)", rel, choice.Reason)
+			if len(p.moduleAnchors) > 0 {
+				color.Cyan("[*] Opening %d additional module anchors for even indexing.", len(p.moduleAnchors))
+			}
+		}
+	} else {
+		// 解析 file:line 格式，无效的目标跳过；第一个有效目标作为锚点
+		targets, err := loadTargets(p.opts.Files, p.opts.TargetsFile, p.projectRoot)
+		if err != nil {
+			return fmt.Errorf("[-] Failed to read targets: %v", err)
+		}
+		p.targets = targets
+		switch {
+		case len(targets) > 0:
+			p.anchorFile = targets[0].File
+		case p.opts.Method != "":
+			// -method 需要 LSP 启动后才能解析，先用任意 .java 文件作为锚点
+			p.anchorFile = findAnchorFile(p.projectRoot, p.opts.FollowSymlinks)
+		default:
+			return errors.New("[-] No valid targets. Please use 'path/to/file:line' (e.g., Main.java:42)")
+		}
+	}
+	if p.anchorFile == "" {
+		if !p.scope.Empty() {
+			return errors.New("[-] No .java files found in -scope / -scope-dir. Cannot start analysis.")
+		}
+		return errors.New("[-] No .java files found in the project. Cannot start analysis.")
+	}
+
+	// 探测工作区根目录
+	p.workspaceRoot = SmartWorkspaceFinder(p.anchorFile)
+	color.Blue("[*] Smart Workspace Detected: %s", p.workspaceRoot)
+
+	p.mode = strings.ToLower(p.opts.Mode)
+	if p.mode != "light" && p.mode != "precise" {
+		return errors.New("Invalid mode. Use 'light' or 'precise'.")
+	}
+	color.Blue("[*] Running in %s mode", strings.ToUpper(p.mode))
+
+	// 语言级别不匹配 (e.g. 按 1.8 解析 record) 会让大量文件无法解析，是结果为空的常见原因
+	p.javaLevel = analysis.DetectJavaLevel(p.workspaceRoot, p.opts.FollowSymlinks)
+	color.Blue("[*] Java language level: %s (%s)", p.javaLevel.Level, p.javaLevel.Source)
+
+	// 全自动扫描和向下追踪 (-direction down/both) 需要 Sink 规则
+	if p.autoScan || p.opts.Direction != directionUp {
+		var err error
+		if p.rules, p.rulesFile, err = loadRules(p.opts.RulesPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// excludeToolDirs 让所有遍历跳过 LSPTracer 自己的目录: 依赖目录 (JDT.LS 的插件)、报告输出目录 (之前的报告中有 Java 代码片段)
+// 和 JDT.LS 数据目录；已经存在于项目根目录中时提示移到项目之外
+func excludeToolDirs(projectRoot string) {
+	depsRoot, err := env.DepsRoot()
+	if err != nil {
+		color.Yellow("[!] %v", err)
+	}
+	dirs := []string{depsRoot, report.OutputDir, env.DataCacheDirName}
+	analysis.ExcludeToolDirs(dirs...)
+
+	var inside []string
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if dir == "" || err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(projectRoot, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if info, err := os.Stat(abs); err == nil && info.IsDir() {
+			inside = append(inside, rel)
+		}
+	}
+	if len(inside) > 0 {
+		color.Yellow("[!] LSPTracer's own directories are inside the project: %s. They are excluded from the scan; run LSPTracer from outside the project or use -deps-dir / -output to keep them elsewhere.", strings.Join(inside, ", "))
+	}
+}
+
+// checkConfigFiles 配置文件检查只读取文件，在准备环境和启动语言服务器之前执行
+func (p *pipeline) checkConfigFiles() error {
+	// 报告中的产生环境 (版本、Java 运行时、规则哈希、命令行参数)
+	p.provenance = newProvenance(p.opts.Args, p.rulesFile, p.opts.JdtlsHome, "java")
+
+	if !p.autoScan || !p.opts.ConfigScan {
+		return nil
+	}
+	configRules, err := model.LoadConfigRules(p.rulesFile)
+	if err != nil {
+		return fmt.Errorf("[-] Failed to load config rules from %s: %v", p.rulesFile, err)
+	}
+	color.Cyan("[*] Checking configuration files (%d rules)...", len(configRules))
+	pre := analysis.NewTracer(nil, p.workspaceRoot, p.mode)
+	applyWalkOptions(pre, p.opts, p.scope)
+	n := pre.ScanConfig(configRules)
+	color.Blue("[*] Found %d configuration findings.", n)
+	p.configFindings = pre.Chains()
+	p.phases.mark("config")
+	return nil
+}
+
+// writePartialReport 语言服务器无法启动时，先把配置检查的结果写入报告
+func (p *pipeline) writePartialReport(msg string) {
+	if len(p.configFindings) == 0 {
+		return
+	}
+	color.Yellow("[*] Writing partial report with %d configuration findings.", len(p.configFindings))
+	allow, _ := model.LoadAllowRules(p.rulesFile)
+	analysis.ApplySuppressions(p.configFindings, allow, p.workspaceRoot)
+	analysis.AttributeModules(p.configFindings, p.workspaceRoot, p.opts.Owners)
+	analysis.ClassifyCodeKinds(p.configFindings, p.workspaceRoot, p.opts.CodePaths)
+	findings := p.configFindings
+	var excludedCode map[string]int
+	if !p.opts.IncludeTestCode {
+		findings, excludedCode = analysis.ExcludeTestCode(findings)
+	}
+	meta := report.Metadata{
+		ProjectName: filepath.Base(p.workspaceRoot),
+		ProjectRoot: p.workspaceRoot,
+		RulesFile:   p.rulesFile,
+		RuleCount:   len(p.rules),
+		ScanMode:    p.mode,
+		Scope:       p.scope.Labels(p.projectRoot),
+		StartedAt:   p.start,
+		Duration:    time.Since(p.start).Round(time.Second),
+		Version:     version,
+		ScanError:   msg,
+		PathMap:     p.opts.PathMap,
+		Provenance:  p.provenance,
+		Locale:      p.opts.Locale,
+
+		ExcludedCode: report.SortedCounts(excludedCode),
+	}
+	writeReports(p.opts.Formats, report.SliceSource(findings), p.workspaceRoot, meta)
+	summary := report.Summarize(findings, p.workspaceRoot)
+	printSummary(summary, nil, p.phases.phases)
+	emitSummary(p.bus, summary, nil, errors.New(msg))
+}
+
+// prepareEnv 准备 JDT.LS 和 Lombok (在预览模式之后，预览不需要下载 JDT.LS)
+func (p *pipeline) prepareEnv() error {
+	var lombokPath string
+	autoJdtls, autoLombok, envErr := env.EnsureEnv()
+	if envErr != nil {
+		log.Printf("[!] Environment setup warning: %v", envErr)
+	}
+
+	jdtlsHome := p.opts.JdtlsHome
+	if jdtlsHome == "" {
+		jdtlsHome = autoJdtls
+		if jdtlsHome != "" {
+			color.Green("[*] Using auto-installed JDT.LS: %s", jdtlsHome)
+		}
+	}
+
+	switch {
+	case p.opts.NoLombok:
+	case p.opts.LombokJar != "":
+		if _, err := os.Stat(p.opts.LombokJar); err != nil {
+			return fmt.Errorf("[-] -lombok-jar: %v", err)
+		}
+		lombokPath, _ = filepath.Abs(p.opts.LombokJar)
+	default:
+		lombokPath = autoLombok
+	}
+
+	if jdtlsHome == "" {
+		return startupErrorf("❌ JDT.LS not found. Please specify -jdtls or check network for auto-download.")
+	}
+	p.provenance.JdtlsVersion = lang.JdtlsVersion(jdtlsHome)
+
+	// 只有项目使用 Lombok 时才注入 agent (-lombok-jar 指定时总是注入)
+	if lombokPath != "" && p.opts.LombokJar == "" {
+		if hit := analysis.UsesLombok(p.workspaceRoot, p.opts.FollowSymlinks); hit != "" {
+			color.Blue("[*] Lombok detected (%s); attaching the agent.", hit)
+		} else {
+			lombokPath = ""
+		}
+	}
+
+	p.javaLang = lang.JavaConfig{
+		JdtlsHome:  jdtlsHome,
+		JavaExec:   "java",
+		LombokPath: lombokPath,
+		JvmOptions: p.opts.JvmOptions,
+	}
+	if lombokPath != "" {
+		p.javaLang.JavaMajor = lang.JavaMajorVersion(p.javaLang.JavaExec)
+	}
+	return nil
+}
+
+// prepareWorkspace 复用或重新生成工作区的 Eclipse 配置
+func (p *pipeline) prepareWorkspace() error {
+	reused := false
+	if p.opts.ReuseConfig {
+		var err error
+		if reused, err = analysis.ReuseEclipseConfig(p.workspaceRoot, p.opts.FollowSymlinks); err != nil {
+			color.Yellow("[!] Cannot reuse the existing Eclipse config (%v); regenerating.", err)
+		}
+	}
+
+	switch {
+	case reused:
+		// 已有的配置 (含依赖条目) 比生成的配置更准确，不清理也不重新生成
+	case p.mode == "light":
+		ForceClean(p.workspaceRoot, p.opts.FollowSymlinks)
+		// ✨✨✨ 生成欺骗性 Eclipse 配置 (Light Mode Only) ✨✨✨
+		folders, err := analysis.GenerateEclipseConfig(p.workspaceRoot, p.opts.FollowSymlinks, p.javaLevel)
+		if err != nil {
+			color.Red("[-] Failed to generate Eclipse config: %v", err)
+		}
+		p.workspaceFolders = folders
+	default:
+		// Precise Mode: Clean only cache, keep/let JDT.LS manage project files?
+		// Actually, to trigger a clean import, we might still want to clean old .project files
+		// if they were generated by Light mode previously.
+		ForceClean(p.workspaceRoot, p.opts.FollowSymlinks)
+		// But DO NOT generate new ones.
+	}
+	return nil
+}
+
+// startClient 启动语言服务器进程 (崩溃后重启时复用)
+func (p *pipeline) startClient() (*lsp.Client, error) {
+	buildCmd := p.javaLang.BuildCmd
+	if p.opts.ServerCmd != nil {
+		buildCmd = func() (*exec.Cmd, error) { return p.opts.ServerCmd(p.javaLang) }
+	}
+	cmd, err := buildCmd()
+	if err != nil {
+		return nil, err
+	}
+	client, err := lsp.NewClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if p.opts.LspLog != "" {
+		if err := client.EnableTrafficLog(p.opts.LspLog, p.opts.LspLogMax); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to open LSP log %s: %v", p.opts.LspLog, err)
+		}
+	}
+	return client, nil
+}
+
+// startServer 启动语言服务器和追踪器，等待索引完成并检查索引质量
+func (p *pipeline) startServer() error {
+	p.phases.mark("env setup")
+	client, err := p.startClient()
+	if err != nil {
+		return startupErrorf("Failed to start LSP: %v", err)
+	}
+	if p.opts.LspLog != "" {
+		color.Cyan("[*] Logging LSP traffic to: %s", p.opts.LspLog)
+	}
+
+	tracer := analysis.NewTracer(client, p.workspaceRoot, p.mode)
+	p.tracer = tracer
+	p.cleanup = append(p.cleanup, func() { tracer.Client.Close() }) // 重启后 Client 会被替换
+	tracer.SinkTimeout = p.opts.SinkTimeout
+	tracer.Events = p.bus
+	tracer.MaxCallers = p.opts.MaxCallers
+	tracer.MaxDepth = p.opts.MaxDepth
+	tracer.WarmupFiles = p.opts.Warmup
+	tracer.Console.MaxSteps = p.opts.ConsoleSteps
+	tracer.Console.MaxChains = p.opts.ConsoleChains
+	tracer.Console.Verbose = p.opts.Verbose
+	if tracer.Findings, err = p.newFindingSink(); err != nil {
+		return err
+	}
+	applyWalkOptions(tracer, p.opts, p.scope)
+	tracer.CodePaths = p.opts.CodePaths
+	tracer.OrphanSinks = p.opts.OrphanSinks
+	if tracer.Propagation, err = model.LoadPropagation(p.rulesFile); err != nil {
+		return fmt.Errorf("[-] Failed to load propagation rules from %s: %v", p.rulesFile, err)
+	}
+	p.strict = p.autoScan // Auto-Scan = Strict Mode; Single File = Loose Mode
+	if s := strings.ToLower(p.opts.Strict); s != "auto" {
+		p.strict = s == "true"
+	}
+	tracer.Restarter = p.startClient
+	tracer.ServerTrouble = reportServerStderr
+	tracer.ModuleAnchors = p.moduleAnchors
+	tracer.WorkspaceFolders = p.workspaceFolders
+	tracer.JavaLevel = p.javaLevel
+	tracer.SkipIndexProbe = p.opts.NoIndexProbe
+	startErr := tracer.Start(p.anchorFile) // 发送 didOpen 信号激活 LSP
+	if tracer.Client.Exited() {
+		reportServerStderr(tracer.Client, "JDT.LS exited during startup")
+		return startupErrorf("[-] %v", p.javaLang.StartupError(tracer.Client.ExitErr()))
+	}
+	if startErr != nil {
+		reportServerStderr(tracer.Client, "JDT.LS started but the project was not indexed")
+		return startupErrorf("[-] Index check failed: %v", startErr)
+	}
+
+	// 索引质量检查: 大量编译错误意味着引用查询结果不可信
+	health := tracer.CheckHealth()
+	if p.opts.MinHealth > 0 && health.Health() < p.opts.MinHealth {
+		return fmt.Errorf("[-] Scan health %.2f is below -min-health %.2f. Check the detected source roots and dependencies.", health.Health(), p.opts.MinHealth)
+	}
+	p.phases.mark("indexing")
+	return nil
+}
+
+// scan 全自动扫描，或者追踪手动指定的目标
+func (p *pipeline) scan() error {
+	if p.autoScan {
+		return p.autoScanAll()
+	}
+	p.traceTargets()
+	return nil
+}
+
+// autoScanAll ✨✨✨ 全自动扫描模式 ✨✨✨
+func (p *pipeline) autoScanAll() error {
+	tracer := p.tracer
+	var err error
+	if p.allow, err = model.LoadAllowRules(p.rulesFile); err != nil {
+		return fmt.Errorf("[-] Failed to load allowlist from %s: %v", p.rulesFile, err)
+	}
+	if len(p.allow) > 0 {
+		color.Blue("[*] Loaded %d allowlist entries.", len(p.allow))
+	}
+	tracer.CompositeRules = model.GetBuiltinCompositeRules()
+	color.Blue("[*] Loaded %d rules (+%d composite rules).", len(p.rules), len(tracer.CompositeRules))
+
+	if err := tracer.ScanAndTrace(p.rules); err != nil {
+		p.scanErr = err
+		color.Red("[-] Scan aborted: %v", err)
+		color.Yellow("[*] Writing partial report with %d chains found so far.", tracer.Findings.Len())
+	}
+	p.phases.record("candidate scan", tracer.Stats.CandidateScan)
+	if tracer.Stats.Warmup > 0 {
+		p.phases.record("warm-up", tracer.Stats.Warmup)
+	}
+	p.phases.record("tracing", tracer.Stats.Tracing)
+
+	if p.opts.Secrets {
+		patterns, err := model.LoadSecretPatterns(p.rulesFile)
+		if err != nil {
+			return fmt.Errorf("[-] Failed to load secret patterns from %s: %v", p.rulesFile, err)
+		}
+		color.Cyan("[*] Scanning for hardcoded secrets (%d patterns)...", len(patterns))
+		n := tracer.ScanSecrets(patterns)
+		color.Blue("[*] Found %d distinct secrets.", n)
+		p.phases.mark("secrets")
+	}
+	if p.opts.Templates {
+		color.Cyan("[*] Scanning JSP / Thymeleaf templates for unescaped output...")
+		n := tracer.ScanTemplates()
+		color.Blue("[*] Found %d template XSS findings.", n)
+		p.phases.mark("templates")
+	}
+	for _, chain := range p.configFindings {
+		tracer.AddFinding(chain)
+	}
+	p.endpoints = tracer.Endpoints()
+	color.Blue("[*] Found %d HTTP endpoints.", len(p.endpoints))
+	p.phases.mark("endpoints")
+	if p.opts.EndpointsFile != "" {
+		hostPaths := p.opts.PathMap
+		if err := report.WriteEndpoints(p.opts.EndpointsFile, report.MapEndpoints(p.endpoints, hostPaths.ToHost), hostPaths.ToHost(p.workspaceRoot)); err != nil {
+			color.Red("[-] Failed to write endpoint inventory: %v", err)
+		} else {
+			color.Green("[+] Endpoint inventory generated: %s", p.opts.EndpointsFile)
+		}
+	}
+	for _, name := range p.opts.Analyzers {
+		if name == analysis.AnalyzerAuthz {
+			color.Cyan("[*] Looking for endpoints without authorization...")
+			n := tracer.AnalyzeAuthz(p.endpoints)
+			color.Blue("[*] Found %d endpoints without authorization.", n)
+			p.phases.mark("authz")
+		}
+	}
+	return nil
+}
+
+// traceTargets ✨✨✨ 单点狙击模式 ✨✨✨ (多个目标共用同一个 LSP 会话，结果汇总到一份报告)
+func (p *pipeline) traceTargets() {
+	tracer := p.tracer
+	if p.opts.Method != "" {
+		if target, ok := resolveMethodTarget(tracer, p.opts.Method); ok {
+			p.targets = append(p.targets, target)
+		}
+	}
+	traced := 0
+	for _, target := range p.targets {
+		ok := false
+		if p.opts.Direction != directionDown {
+			ok = traceManualTarget(tracer, target)
+		}
+		if p.opts.Direction != directionUp {
+			if tree := traceCallees(tracer, target, p.rules, p.opts.CalleeDepth); tree != nil {
+				p.callTrees = append(p.callTrees, tree)
+				ok = true
+			}
+		}
+		if ok {
+			traced++
+		}
+	}
+	if len(p.targets) > 1 {
+		color.Blue("[*] Traced %d/%d targets.", traced, len(p.targets))
+	}
+
+	// Wait for async trace tasks to complete
+	color.Cyan("[*] Waiting for trace chains to complete...")
+	tracer.Wg.Wait()
+	tracer.Console.Flush()
+	p.phases.mark("tracing")
+}
+
+// report 后处理发现 (路由、严格模式、模块、抑制、过滤) 并生成报告
+func (p *pipeline) report() error {
+	tracer := p.tracer
+	finalHealth := tracer.Client.DiagnosticStats()
+	meta := report.Metadata{
+		Health:         &finalHealth,
+		ServerRestarts: tracer.Restarts,
+		ProjectName:    filepath.Base(p.workspaceRoot),
+		ProjectRoot:    p.workspaceRoot,
+		RulesFile:      p.rulesFile,
+		RuleCount:      len(p.rules),
+		StrictMode:     p.strict,
+		Sources:        p.opts.Sources,
+		ShowUnverified: p.opts.ShowUnverified,
+		ScanMode:       p.mode,
+		Scope:          p.scope.Labels(p.projectRoot),
+		StartedAt:      p.start,
+		Duration:       time.Since(p.start).Round(time.Second),
+		Version:        version,
+		Endpoints:      p.endpoints,
+		CallTrees:      p.callTrees,
+		PathMap:        p.opts.PathMap,
+		Provenance:     p.provenance,
+		Locale:         p.opts.Locale,
+	}
+	if tracer.ServerInfo != "" {
+		p.provenance.JdtlsVersion = tracer.ServerInfo
+	}
+	if p.rulesFile != "" {
+		if abs, err := filepath.Abs(p.rulesFile); err == nil {
+			meta.RulesFile = abs
+		}
+	}
+	meta.GitCommit, meta.GitBranch = gitRevision(p.workspaceRoot)
+	if p.scanErr != nil {
+		meta.ScanError = p.scanErr.Error()
+	}
+	if p.autoScan {
+		meta.Stats = &report.ScanStats{
+			Candidates:   tracer.Stats.Candidates,
+			Verified:     tracer.Stats.Verified,
+			Traced:       tracer.Findings.Sinks(),
+			ZeroHitRules: tracer.Stats.ZeroHitRules(),
+			Phases:       append([]report.Phase(nil), p.phases.phases...),
+			WarmedFiles:  tracer.Stats.WarmedFiles,
+			VerifyAvg:    tracer.Stats.AvgVerify().Round(time.Millisecond),
+			VerifiedBy:   report.SortedCounts(tracer.Stats.VerifiedBy),
+		}
+		for _, hit := range tracer.FanOutHits(fanOutTop) {
+			meta.Stats.FanOut = append(meta.Stats.FanOut, report.Count{Name: hit.Method, Count: hit.Callers})
+		}
+	}
+
+	kept, counts, err := p.postProcess()
+	if err != nil {
+		return err
+	}
+	if counts.routes > 0 {
+		color.Blue("[*] Mapped %d findings to HTTP routes.", counts.routes)
+	}
+	if counts.strict > 0 {
+		color.Blue("[*] Strict mode: %d chains without an accepted framework entry or input excluded (-show-unverified lists them).", counts.strict)
+	}
+	if s := counts.suppressions; s.Suppressed+s.Downgraded > 0 {
+		color.Blue("[*] Allowlist: %d findings suppressed, %d downgraded.", s.Suppressed, s.Downgraded)
+	}
+	if counts.suppressions.Expired > 0 {
+		color.Red("[!] %d lsptracer:ignore comments have expired; the findings are reported again.", counts.suppressions.Expired)
+	}
+	if !p.opts.IncludeTestCode {
+		meta.ExcludedCode = report.SortedCounts(counts.excludedCode)
+		if counts.excluded > 0 {
+			color.Blue("[*] Test/sample code: %d findings not reported (-include-test-code lists them).", counts.excluded)
+		}
+	}
+	if p.opts.Baseline != "" {
+		color.Blue("[*] Baseline %s: %d known findings suppressed, %d new.", p.opts.Baseline, counts.baselineKnown, kept.Len())
+	}
+
+	if kept.Len() == 0 {
+		fmt.Println()
+		color.Yellow("[*] No vulnerability chains found.")
+	}
+	// ✨✨✨ 传入 workspaceRoot (项目根目录) ✨✨✨
+	writeReports(p.opts.Formats, kept, p.workspaceRoot, meta)
+	p.phases.mark("reporting")
+	summary, err := report.SummarizeSource(kept, p.workspaceRoot)
+	if err != nil {
+		color.Red("[-] Failed to read back findings for the summary: %v", err)
+	}
+	printSummary(summary, meta.Stats, p.phases.phases)
+	emitSummary(p.bus, summary, meta.Stats, p.scanErr)
+	return nil
+}
+
+// findingBatch 报告阶段每批读回并后处理的链路数
+const findingBatch = 1000
+
+// reportCounts 报告阶段后处理的计数 (按批累加)
+type reportCounts struct {
+	routes        int // 映射到 HTTP 路由的发现
+	strict        int // 严格模式排除的链路
+	suppressions  analysis.SuppressionCounts
+	excluded      int            // 测试/示例代码中不写入报告的发现
+	excludedCode  map[string]int // 同上，按代码类别
+	baselineKnown int            // -baseline 中已经存在、不再报告的发现
+}
+
+// newFindingSink 保存链路的 FindingSink: -spill 时使用临时文件 (扫描结束时删除)，否则保存在内存中
+func (p *pipeline) newFindingSink() (analysis.FindingSink, error) {
+	if !p.opts.Spill {
+		return analysis.NewMemorySink(), nil
+	}
+	findings, err := analysis.NewSpillSink("")
+	if err != nil {
+		return nil, fmt.Errorf("[-] %v", err)
+	}
+	p.cleanup = append(p.cleanup, func() { findings.Close() })
+	return findings, nil
+}
+
+// postProcess 按批读回追踪结果并后处理 (路由、严格模式、模块、代码类别、抑制、测试代码、等级和可信度过滤、基线)，
+// 写入报告的链路保存到新的 FindingSink 中。-spill 时它同样是临时文件，报告生成器再逐条读回，内存占用不随链路数量增长
+func (p *pipeline) postProcess() (analysis.FindingSink, reportCounts, error) {
+	counts := reportCounts{excludedCode: make(map[string]int)}
+	kept, err := p.newFindingSink()
+	if err != nil {
+		return nil, counts, err
+	}
+	var baseline *report.Baseline
+	if p.opts.Baseline != "" {
+		if baseline, err = loadBaseline(p.opts.Baseline); err != nil {
+			return nil, counts, err
+		}
+	}
+
+	var batch [][]model.ChainStep
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		counts.routes += analysis.AttachRoutes(batch, p.endpoints)
+		// 严格模式在报告阶段过滤: 链路都保留在 JSON 中，render 可以切换严格模式而不需要重新扫描
+		counts.strict += applyStrict(batch, p.strict, p.opts.SourceKinds)
+		// 多模块项目: 每个发现归到 Sink 所在的模块和 -owners 中负责的团队
+		analysis.AttributeModules(batch, p.workspaceRoot, p.opts.Owners)
+		analysis.ClassifyCodeKinds(batch, p.workspaceRoot, p.opts.CodePaths)
+		// 白名单和 lsptracer:ignore 注释: 被抑制的发现仍然写入报告 (单独列出)
+		s := analysis.ApplySuppressions(batch, p.allow, p.workspaceRoot)
+		counts.suppressions.Suppressed += s.Suppressed
+		counts.suppressions.Downgraded += s.Downgraded
+		counts.suppressions.Expired += s.Expired
+
+		results := batch
+		// 测试/示例代码中的 Sink 默认不写入报告，只在概览中计数
+		if !p.opts.IncludeTestCode {
+			var excluded map[string]int
+			results, excluded = analysis.ExcludeTestCode(results)
+			counts.excluded += len(batch) - len(results)
+			for kind, n := range excluded {
+				counts.excludedCode[kind] += n
+			}
+		}
+		if p.opts.MinSeverity != "" {
+			results = filterBySeverity(results, p.opts.MinSeverity)
+		}
+		if p.opts.MinConfidence != "" {
+			results = filterByConfidence(results, p.opts.MinConfidence)
+		}
+		if baseline != nil {
+			var known int
+			results, known = baseline.Filter(results, p.workspaceRoot)
+			counts.baselineKnown += known
+		}
+		for _, chain := range results {
+			if err := kept.Record(chain); err != nil {
+				color.Red("[-] %v (later findings are kept in memory)", err)
+			}
+		}
+		batch = nil
+	}
+	err = p.tracer.Findings.Each(func(chain []model.ChainStep) error {
+		if batch = append(batch, chain); len(batch) == findingBatch {
+			flush()
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		color.Red("[-] Failed to read back findings, the report is incomplete: %v", err)
+	}
+	return kept, counts, nil
+}
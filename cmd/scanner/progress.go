@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"LSPTracer/internal/events"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// consoleEvents 把扫描事件输出为控制台上的可读信息
+func consoleEvents(e events.Event) {
+	switch e.Type {
+	case events.TypeCandidates:
+		color.Blue("[*] Found %d potential risky sinks (Text Match).", e.Count)
+	case events.TypeSinkStart:
+		// 清除进度行
+		fmt.Print("\r                                                                 \r")
+		color.Red("[+] Confirmed Sink: %s", e.Code)
+		fmt.Printf("    File: %s:%d\n", filepath.Base(e.File), e.Line)
+	}
+}
+
+// newEventBus 创建扫描事件总线: 控制台输出总是订阅 (stdout 的内容不变)；
+// mode 为 json 时同时把事件写为 NDJSON，写入 file (为空时写入 stderr)。返回的函数关闭输出文件
+func newEventBus(mode, file string) (*events.Bus, func(), error) {
+	bus := &events.Bus{}
+	bus.Subscribe(consoleEvents)
+	closeFn := func() {}
+	if strings.ToLower(mode) != "json" {
+		return bus, closeFn, nil
+	}
+
+	var w io.Writer = os.Stderr
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closeFn = f, func() { f.Close() }
+	}
+	bus.Subscribe(events.JSONLines(w))
+	return bus, closeFn, nil
+}
+
+// emitSummary 发送扫描结束的 summary 事件
+func emitSummary(bus *events.Bus, summary report.Summary, stats *report.ScanStats, scanErr error) {
+	s := &events.Summary{ByType: make(map[string]int), BySeverity: make(map[string]int)}
+	for _, c := range summary.ByType {
+		s.ByType[c.Name] = c.Count
+		s.Findings += c.Count
+	}
+	for _, c := range summary.BySeverity {
+		s.BySeverity[c.Name] = c.Count
+	}
+	if stats != nil {
+		s.Candidates, s.Verified, s.Traced = stats.Candidates, stats.Verified, stats.Traced
+	}
+	if scanErr != nil {
+		s.Error = scanErr.Error()
+	}
+	bus.Emit(events.Event{Type: events.TypeSummary, Summary: s})
+}
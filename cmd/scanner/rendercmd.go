@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+)
+
+// runRender 实现 render 子命令: 从 JSON 结果重新生成报告 (不需要源码和语言服务器)
+//
+//	render -in results.json [-format html,sarif] [-o out/] [-strict false] [-show-unverified]
+//	render results.json
+//
+// JSON 中保留了所有链路及其 Source 信号，严格模式和 -sources 可以在这里重新选择
+// 报告中的路径默认沿用 JSON 中记录的 -path-map，-path-map 可以替换为其它映射
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	in := fs.String("in", "", "JSON results produced by -format json.")
+	format := fs.String("format", "html", "Report formats, comma separated: html, json, sarif")
+	out := fs.String("o", report.OutputDir, "Output directory.")
+	strict := fs.String("strict", "auto", "Strict mode: 'auto' (as recorded in the JSON), 'true' or 'false'.")
+	sources := fs.String("sources", "", "Source kinds accepted in strict mode (default: as recorded in the JSON).")
+	showUnverified := fs.Bool("show-unverified", false, "List chains excluded by strict mode in a separate section.")
+	contextBudget := fs.Int("html-context-budget", report.DefaultContextBudget>>20, "Total size (MB) of full-context code blocks in the HTML report (0 = unlimited).")
+	templatePath := fs.String("template", "", "(Optional) Custom HTML report template.")
+	title := fs.String("report-title", "", "(Optional) Title of the HTML report.")
+	logo := fs.String("logo", "", "(Optional) Image shown in the HTML report sidebar.")
+	lang := fs.String("lang", "", "Report language: 'en' or 'zh' (default: as recorded in the JSON, otherwise from LC_ALL / LANG).")
+	pageThreshold := fs.Int("html-page-threshold", report.DefaultPageThreshold, "Split the HTML report into per-type pages above this many findings (0 = never split).")
+	var pathMap pathMapList
+	fs.Var(&pathMap, "path-map", "Rewrite report paths with these mappings instead of the ones recorded in the JSON, e.g. /src=/home/dev/app. Repeatable.")
+	fs.Parse(args)
+
+	if *in == "" && fs.NArg() == 1 {
+		*in = fs.Arg(0)
+	}
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "Usage: render -in results.json [-format html,json,sarif] [-o output/]")
+		return 2
+	}
+
+	chains, meta, err := report.LoadJSON(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Failed to load %s: %v\n", *in, err)
+		return 1
+	}
+
+	switch s := strings.ToLower(*strict); s {
+	case "auto":
+	case "true", "false":
+		meta.StrictMode = s == "true"
+	default:
+		fmt.Fprintln(os.Stderr, "[-] Invalid -strict value. Use 'auto', 'true' or 'false'.")
+		return 2
+	}
+	if *sources != "" {
+		meta.Sources = *sources
+	}
+	if meta.Sources == "" {
+		meta.Sources = model.DefaultSources
+	}
+	accepted, err := model.ParseSourceKinds(meta.Sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[-] Invalid -sources: %v\n", err)
+		return 2
+	}
+	meta.ShowUnverified = *showUnverified
+	if *lang != "" || meta.Locale == "" {
+		if meta.Locale, err = reportLocale(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Invalid -lang: %v\n", err)
+			return 2
+		}
+	}
+	// LoadJSON 已经按 JSON 中记录的映射还原为扫描环境的路径，这里换成新的映射
+	if len(pathMap) > 0 {
+		meta.PathMap = report.PathMap(pathMap)
+	}
+	applyStrict(chains, meta.StrictMode, accepted)
+
+	report.OutputDir = *out
+	report.ContextBudget, report.PageThreshold = *contextBudget<<20, *pageThreshold
+	if err := configureHTML(*templatePath, *title, *logo); err != nil {
+		fmt.Fprintf(os.Stderr, "[-] %v\n", err)
+		return 1
+	}
+	writeReports(*format, report.SliceSource(chains), meta.ProjectRoot, meta)
+	return 0
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/report"
+)
+
+// renderFixture 一次扫描保存的 JSON 结果 (源码片段嵌入在其中，render 不需要源码)
+var renderFixture = filepath.Join("testdata", "render", "results.json")
+
+// withReportGlobals 在测试结束后恢复 render 设置的报告全局参数
+func withReportGlobals(t *testing.T) {
+	t.Helper()
+	outputDir, budget, threshold := report.OutputDir, report.ContextBudget, report.PageThreshold
+	t.Cleanup(func() { report.OutputDir, report.ContextBudget, report.PageThreshold = outputDir, budget, threshold })
+}
+
+// findingsOf JSON 报告中的 findings (原始结构，用于整体比较)
+func findingsOf(t *testing.T, path string) []interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep struct {
+		Findings []interface{} `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatal(err)
+	}
+	return rep.Findings
+}
+
+// render 从 JSON 结果生成各种格式的报告，重新生成的 JSON 中的发现与原来的相同
+func TestRenderFormats(t *testing.T) {
+	withReportGlobals(t)
+	out := t.TempDir()
+	if code := runRender([]string{"-in", renderFixture, "-format", "html,json,sarif", "-o", out}); code != 0 {
+		t.Fatalf("render exited with %d", code)
+	}
+	outputs := make(map[string]string)
+	for _, ext := range []string{"html", "json", "sarif"} {
+		matches, _ := filepath.Glob(filepath.Join(out, "report_*."+ext))
+		if len(matches) != 1 {
+			t.Fatalf("want one %s report, got %v", ext, matches)
+		}
+		outputs[ext] = matches[0]
+	}
+
+	if got, want := findingsOf(t, outputs["json"]), findingsOf(t, renderFixture); !reflect.DeepEqual(got, want) {
+		t.Errorf("re-rendered JSON findings differ from the input")
+	}
+	html, err := os.ReadFile(outputs["html"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"CommandService.run(String)", "GET /ping", "Runtime.getRuntime"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML report does not contain %q", want)
+		}
+	}
+}
+
+func TestRenderUsageErrors(t *testing.T) {
+	withReportGlobals(t)
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, 2},
+		{[]string{"-in", renderFixture, "-strict", "maybe", "-o", t.TempDir()}, 2},
+		{[]string{"-in", renderFixture, "-sources", "ftp", "-o", t.TempDir()}, 2},
+		{[]string{"-in", filepath.Join(t.TempDir(), "missing.json")}, 1},
+	}
+	for _, tt := range tests {
+		if code := runRender(tt.args); code != tt.code {
+			t.Errorf("render %v exited with %d, want %d", tt.args, code, tt.code)
+		}
+	}
+}
+
+// render -path-map: 输入 JSON 按其中记录的映射还原为扫描环境的路径，再按新的映射写出 (映射不会叠加)
+func TestRenderPathMap(t *testing.T) {
+	withReportGlobals(t)
+	// render 把 in 渲染为 JSON，返回输出文件和其中的 project_root、path_map
+	render := func(in string, args ...string) (string, string, report.PathMap) {
+		t.Helper()
+		out := t.TempDir()
+		if code := runRender(append([]string{"-in", in, "-format", "json", "-o", out}, args...)); code != 0 {
+			t.Fatalf("render %v exited with %d", args, code)
+		}
+		matches, _ := filepath.Glob(filepath.Join(out, "report_*.json"))
+		if len(matches) != 1 {
+			t.Fatalf("want one JSON report, got %v", matches)
+		}
+		data, err := os.ReadFile(matches[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rep struct {
+			Metadata struct {
+				ProjectRoot string         `json:"project_root"`
+				PathMap     report.PathMap `json:"path_map"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &rep); err != nil {
+			t.Fatal(err)
+		}
+		return matches[0], rep.Metadata.ProjectRoot, rep.Metadata.PathMap
+	}
+
+	hostReport, root, pm := render(renderFixture, "-path-map", "/src=/home/dev/app")
+	if root != "/home/dev/app/demo" || len(pm) != 1 || pm[0].String() != "/src=/home/dev/app" {
+		t.Errorf("mapped report: project_root %s, path_map %v", root, pm)
+	}
+	if got, want := findingsOf(t, hostReport), findingsOf(t, renderFixture); !reflect.DeepEqual(got, want) {
+		t.Error("findings changed by the path mapping")
+	}
+
+	// 没有 -path-map 时沿用 JSON 中记录的映射
+	if _, root, pm := render(hostReport); root != "/home/dev/app/demo" || len(pm) != 1 {
+		t.Errorf("re-rendered report: project_root %s, path_map %v", root, pm)
+	}
+	// 映射到另一台机器: 从扫描环境的 /src/demo 出发 (而不是 /home/dev/app/demo)，最长的前缀优先
+	if _, root, pm := render(hostReport, "-path-map", "/src=/mnt/review,/src/demo=/work/demo"); root != "/work/demo" || len(pm) != 2 {
+		t.Errorf("re-mapped report: project_root %s, path_map %v", root, pm)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"LSPTracer/internal/analysis"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+)
+
+// syntheticChains 向 sink 写入 n 条五步的合成链路 (分布在 50 个文件中，每步带代码和分析说明)
+func syntheticChains(t testing.TB, sink analysis.FindingSink, root string, n int) {
+	t.Helper()
+	rules := []*model.SinkRule{
+		{Name: "Runtime.exec", VulnType: "RCE", Severity: "critical", Desc: "Command execution"},
+		{Name: "Statement.executeQuery", VulnType: "SQLI", Severity: "high", Desc: "SQL injection"},
+	}
+	for i := 0; i < n; i++ {
+		file := filepath.Join(root, "src", "main", "java", "com", "example", fmt.Sprintf("Service%d.java", i%50))
+		chain := make([]model.ChainStep, 5)
+		for j := range chain {
+			chain[j] = model.ChainStep{
+				File:     file,
+				Line:     i/50*10 + j,
+				Func:     fmt.Sprintf("method%d", j),
+				Code:     fmt.Sprintf("String value%d = helper.transform(request.getParameter(\"field%d\"), %d);", j, i, j),
+				Analysis: []string{fmt.Sprintf("argument %d flows into the call", j)},
+			}
+		}
+		chain[0].Rule = rules[i%len(rules)]
+		if err := sink.Record(chain); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// reportPipeline 只包含报告阶段需要的状态，追踪结果按 opts.Spill 保存在内存或临时文件中
+func reportPipeline(t testing.TB, root string, opts Options) *pipeline {
+	t.Helper()
+	p := &pipeline{opts: opts, workspaceRoot: root, phases: &phaseTimer{}}
+	findings, err := p.newFindingSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.tracer = &analysis.Tracer{Findings: findings}
+	t.Cleanup(func() {
+		for _, fn := range p.cleanup {
+			fn()
+		}
+	})
+	return p
+}
+
+// writeTestReports 后处理并把 JSON 和 SARIF 报告写入 dir，按扩展名返回报告内容
+func writeTestReports(t testing.TB, p *pipeline, dir string) map[string]string {
+	t.Helper()
+	kept, _, err := p.postProcess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kept.Close()
+	report.OutputDir = dir
+	writeReports("json,sarif", kept, p.workspaceRoot, report.Metadata{ProjectRoot: p.workspaceRoot})
+
+	out := make(map[string]string)
+	matches, _ := filepath.Glob(filepath.Join(dir, "report_*"))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[filepath.Ext(path)] = string(data)
+	}
+	return out
+}
+
+// -spill 时报告从临时文件逐条读回，内容与保存在内存中时相同
+func TestReportsFromSpillMatchMemory(t *testing.T) {
+	root := t.TempDir()
+	var reports [2]map[string]string
+	for i, spill := range []bool{false, true} {
+		p := reportPipeline(t, root, Options{Spill: spill})
+		syntheticChains(t, p.tracer.Findings, root, 120)
+		reports[i] = writeTestReports(t, p, t.TempDir())
+	}
+	generated := regexp.MustCompile(`"generated_at": "[^"]*"`)
+	for _, ext := range []string{".json", ".sarif"} {
+		memory := generated.ReplaceAllString(reports[0][ext], "")
+		spill := generated.ReplaceAllString(reports[1][ext], "")
+		if memory == "" {
+			t.Fatalf("no %s report written", ext)
+		}
+		if memory != spill {
+			t.Errorf("%s report differs between memory and -spill", ext)
+		}
+	}
+}
+
+func TestPostProcessBaseline(t *testing.T) {
+	root := t.TempDir()
+	p := reportPipeline(t, root, Options{})
+	syntheticChains(t, p.tracer.Findings, root, 10)
+	reports := writeTestReports(t, p, t.TempDir())
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(baseline, []byte(reports[".json"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 基线之后新增两条
+	syntheticChains(t, p.tracer.Findings, filepath.Join(root, "extra"), 2)
+	p.opts.Baseline = baseline
+	kept, counts, err := p.postProcess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kept.Close()
+	if counts.baselineKnown != 10 || kept.Len() != 2 {
+		t.Errorf("baseline: %d known, %d kept; want 10 and 2", counts.baselineKnown, kept.Len())
+	}
+
+	p.opts.Baseline = filepath.Join(t.TempDir(), "missing.json")
+	if _, _, err := p.postProcess(); err == nil {
+		t.Error("postProcess with a missing baseline: want an error")
+	}
+}
+
+// peakHeap 执行 fn 期间堆内存的峰值 (相对开始时)，每毫秒采样一次
+func peakHeap(fn func()) uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	base, peak := m.HeapAlloc, m.HeapAlloc
+
+	var mu sync.Mutex
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				mu.Lock()
+				if m.HeapAlloc > peak {
+					peak = m.HeapAlloc
+				}
+				mu.Unlock()
+			}
+		}
+	}()
+	fn()
+	close(done)
+	<-stopped
+	if peak < base {
+		return 0
+	}
+	return peak - base
+}
+
+// BenchmarkReportMemory 报告阶段 (后处理 + JSON/SARIF/HTML) 的峰值堆内存: 链路保存在内存中和 -spill 时逐条读回
+func BenchmarkReportMemory(b *testing.B) {
+	const chains = 5000
+	for _, spill := range []bool{false, true} {
+		name := "memory"
+		if spill {
+			name = "spill"
+		}
+		b.Run(name, func(b *testing.B) {
+			root := b.TempDir()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				p := reportPipeline(b, root, Options{Spill: spill})
+				syntheticChains(b, p.tracer.Findings, root, chains)
+				// 追踪结果本身不计入: -spill 时它们已经在临时文件中
+				out := b.TempDir()
+				b.StartTimer()
+				if n := peakHeap(func() {
+					kept, _, err := p.postProcess()
+					if err != nil {
+						b.Fatal(err)
+					}
+					report.OutputDir = out
+					writeReports("json,sarif,html", kept, root, report.Metadata{ProjectRoot: root})
+					kept.Close()
+				}); n > peak {
+					peak = n
+				}
+				b.StopTimer()
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"LSPTracer/internal/model"
+)
+
+// runRules 实现 rules 子命令:
+//
+//	rules export [-rules file]  以 YAML 输出规则 (可重定向为 rules.yaml 后修改)
+//	rules list   [-rules file]  以表格列出规则
+func runRules(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: rules <export|list> [-rules rules.yaml]")
+		return 2
+	}
+
+	sub := args[0]
+	fs := flag.NewFlagSet("rules "+sub, flag.ExitOnError)
+	rulesPath := fs.String("rules", "", "(Optional) Path to a rules.yaml file. Default: built-in rules.")
+	fs.Parse(args[1:])
+
+	rules := model.GetBuiltinRules()
+	if *rulesPath != "" {
+		var err error
+		rules, err = model.LoadRulesFromFile(*rulesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Failed to load rules from %s: %v\n", *rulesPath, err)
+			return 2
+		}
+	}
+
+	switch sub {
+	case "export":
+		if err := model.ExportRules(os.Stdout, rules); err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Failed to export rules: %v\n", err)
+			return 1
+		}
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tSEVERITY\tTARGET\tSKIP_SAFE")
+		for _, r := range rules {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\n", r.Name, r.VulnType, r.Severity, r.Target(), r.SkipSafe)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown rules command %q (expected export or list)\n", sub)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// captureStdout 返回 fn 执行期间写到标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	defer func() { os.Stdout = saved }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// rules export 的输出可以作为 -rules 文件加载，得到与内置规则相同数量的规则
+func TestRulesExportLoads(t *testing.T) {
+	var code int
+	out := captureStdout(t, func() { code = runRules([]string{"export"}) })
+	if code != 0 {
+		t.Fatalf("rules export exited with %d", code)
+	}
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := model.LoadRulesFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(model.GetBuiltinRules()); len(rules) != want {
+		t.Errorf("exported %d rules, want %d", len(rules), want)
+	}
+}
+
+func TestRulesList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := "- vuln_type: RCE\n  severity: High\n  class_name: com.example.Shell\n  method_name: run\n  skip_safe: true\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var code int
+	out := captureStdout(t, func() { code = runRules([]string{"list", "-rules", path}) })
+	if code != 0 {
+		t.Fatalf("rules list exited with %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want a header and one rule, got:\n%s", out)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "NAME TYPE SEVERITY TARGET SKIP_SAFE" {
+		t.Errorf("header = %q", lines[0])
+	}
+	for _, want := range []string{"RCE (Shell.run)", "High", "com.example.Shell.run", "true"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("rule line %q does not contain %q", lines[1], want)
+		}
+	}
+}
+
+func TestRulesUsageErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	for _, args := range [][]string{nil, {"show"}, {"list", "-rules", missing}} {
+		if code := runRules(args); code != 2 {
+			t.Errorf("rules %v exited with %d, want 2", args, code)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lang"
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// stderrExcerptLines 控制台上显示的 JDT.LS stderr 行数
+const stderrExcerptLines = 20
+
+// reportServerStderr 语言服务器启动失败、没有就绪或崩溃时: 输出 stderr 的最后几行和匹配到的故障提示，
+// 并把保留的 stderr 写入输出目录的 jdtls-<时间>.log
+func reportServerStderr(client *lsp.Client, reason string) {
+	lines, total := client.Stderr()
+	color.Red("[!] %s", reason)
+	if total == 0 {
+		color.Yellow("    JDT.LS wrote nothing to stderr.")
+		return
+	}
+
+	faint := color.New(color.Faint).SprintFunc()
+	excerpt := lines[max(len(lines)-stderrExcerptLines, 0):]
+	fmt.Printf("    %s\n", faint(fmt.Sprintf("┌─ JDT.LS said (last %d of %d lines) ", len(excerpt), total)+strings.Repeat("─", 20)))
+	for _, line := range excerpt {
+		fmt.Printf("    %s %s\n", faint("│"), line)
+	}
+	fmt.Printf("    %s\n", faint("└"+strings.Repeat("─", 40)))
+
+	for _, hint := range lang.DiagnoseStderr(lines) {
+		color.Yellow("    Hint: %s", hint)
+	}
+
+	if path, err := writeServerLog(lines, total); err != nil {
+		color.Red("[-] Failed to write the JDT.LS log: %v", err)
+	} else {
+		color.Cyan("[*] JDT.LS stderr written to: %s", path)
+	}
+}
+
+// writeServerLog 把保留的 stderr 写入输出目录；缓冲区只保留最后 lsp.DefaultStderrLines 行，被丢弃的行数写在文件开头
+func writeServerLog(lines []string, total int) (string, error) {
+	if err := os.MkdirAll(report.OutputDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(report.OutputDir, fmt.Sprintf("jdtls-%s.log", time.Now().Format("20060102-150405")))
+	var b strings.Builder
+	if dropped := total - len(lines); dropped > 0 {
+		fmt.Fprintf(&b, "[%d earlier lines dropped]\n", dropped)
+	}
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return path, os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/events"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// fanOutTop 汇总中列出的调用者过多的方法数量
+const fanOutTop = 5
+
+// phaseTimer 记录扫描各阶段的耗时
+type phaseTimer struct {
+	last   time.Time
+	phases []report.Phase
+	bus    *events.Bus // 每个阶段结束时发送 phase 事件 (nil 时不发送)
+}
+
+func newPhaseTimer(start time.Time) *phaseTimer {
+	return &phaseTimer{last: start}
+}
+
+// mark 结束从上一个阶段结束 (或开始计时) 到现在的阶段
+func (p *phaseTimer) mark(name string) {
+	now := time.Now()
+	p.record(name, now.Sub(p.last))
+	p.last = now
+}
+
+// record 追加一个已经测量好耗时的阶段 (e.g. ScanAndTrace 内部的阶段)，并把下一个阶段的起点移到现在
+func (p *phaseTimer) record(name string, d time.Duration) {
+	p.phases = append(p.phases, report.Phase{Name: name, Duration: d.Round(100 * time.Millisecond)})
+	p.last = time.Now()
+	p.bus.Emit(events.Event{Type: events.TypePhase, Phase: name, DurationMs: d.Milliseconds()})
+}
+
+// printSummary 扫描结束时输出汇总: 各类型/等级的数量、候选点漏斗、零命中规则、发现最多的文件和各阶段耗时
+func printSummary(summary report.Summary, stats *report.ScanStats, phases []report.Phase) {
+	faint := color.New(color.Faint).SprintFunc()
+	row := func(name, value string) {
+		fmt.Printf("    %s %s\n", faint(fmt.Sprintf("%-15s", name)), value)
+	}
+
+	fmt.Println()
+	color.Cyan("[*] Scan summary")
+	if len(summary.ByType) > 0 {
+		row("By type", joinCounts(summary.ByType))
+		row("By severity", joinCounts(summary.BySeverity))
+	}
+	// 多模块项目: 每个模块一行 (只有一个模块时不输出)
+	if len(summary.ByModule) > 1 {
+		for i, m := range summary.ByModule {
+			name := ""
+			if i == 0 {
+				name = "By module"
+			}
+			line := fmt.Sprintf("%s %d (%s)", m.Name, m.Count, joinCounts(m.BySeverity))
+			if len(m.Owners) > 0 {
+				line += " " + faint(strings.Join(m.Owners, ", "))
+			}
+			row(name, line)
+		}
+	}
+	if summary.Suppressed > 0 {
+		row("Suppressed", fmt.Sprintf("%d (listed separately in the report)", summary.Suppressed))
+	}
+	if summary.Excluded > 0 {
+		row("Strict-excluded", fmt.Sprintf("%d (kept in JSON; -show-unverified lists them in HTML/SARIF)", summary.Excluded))
+	}
+	if len(summary.ByTermination) > 0 {
+		row("Chains ended", terminationShares(summary.ByTermination))
+		if hint := noCallersHint(summary.ByTermination); hint != "" {
+			row("", color.YellowString(hint))
+		}
+	}
+	if stats != nil {
+		row("Sinks", fmt.Sprintf("%d candidates -> %d verified -> %d traced to a source", stats.Candidates, stats.Verified, stats.Traced))
+		if len(stats.ZeroHitRules) > 0 {
+			row("Zero-hit rules", color.YellowString(strings.Join(stats.ZeroHitRules, ", ")))
+		}
+		for i, c := range stats.FanOut {
+			name := ""
+			if i == 0 {
+				name = "Fan-out limited"
+			}
+			row(name, color.YellowString("%4d callers  %s", c.Count, c.Name))
+		}
+		if stats.VerifyAvg > 0 {
+			warm := "no warm-up"
+			if stats.WarmedFiles > 0 {
+				warm = fmt.Sprintf("%d files warmed up", stats.WarmedFiles)
+			}
+			row("Verification", fmt.Sprintf("%s per candidate (%s)", stats.VerifyAvg, warm))
+		}
+		if len(stats.VerifiedBy) > 0 {
+			row("Verified by", joinCounts(stats.VerifiedBy))
+		}
+	}
+	for i, c := range summary.TopFiles {
+		name := ""
+		if i == 0 {
+			name = "Top files"
+		}
+		row(name, fmt.Sprintf("%3d  %s", c.Count, c.Name))
+	}
+	if len(phases) > 0 {
+		var total time.Duration
+		parts := make([]string, 0, len(phases))
+		for _, p := range phases {
+			total += p.Duration
+			parts = append(parts, fmt.Sprintf("%s %s", p.Name, p.Duration))
+		}
+		row("Phases", fmt.Sprintf("%s (total %s)", strings.Join(parts, ", "), total.Round(time.Second)))
+	}
+}
+
+func joinCounts(counts []report.Count) string {
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		parts = append(parts, fmt.Sprintf("%s %d", c.Name, c.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// terminationShares 按结束原因列出链路数量和占比 (e.g. "REACHED_ENTRY 12 (40%), NO_CALLERS 18 (60%)")
+func terminationShares(counts []report.Count) string {
+	total := 0
+	for _, c := range counts {
+		total += c.Count
+	}
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		parts = append(parts, fmt.Sprintf("%s %d (%.0f%%)", c.Name, c.Count, float64(c.Count)*100/float64(total)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// noCallersHint 超过一半的片段 (没有到达入口的链路) 停在 NO_CALLERS 时提示检查索引
+func noCallersHint(counts []report.Count) string {
+	fragments, noCallers := 0, 0
+	for _, c := range counts {
+		if c.Name == model.TerminationReachedEntry {
+			continue
+		}
+		fragments += c.Count
+		if c.Name == model.TerminationNoCallers {
+			noCallers = c.Count
+		}
+	}
+	if fragments == 0 || noCallers*2 <= fragments {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%% of fragments ended with NO_CALLERS, which usually means incomplete indexing (compile errors, missing source roots)", float64(noCallers)*100/float64(fragments))
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"LSPTracer/internal/analysis"
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/report"
+
+	"github.com/fatih/color"
+)
+
+// manualTargetRule 单点模式下作为 Sink 规则展示的人工目标
+var manualTargetRule = &model.SinkRule{Name: "Manual Target", VulnType: "MANUAL", Desc: "Location selected for manual verification"}
+
+// 单点模式的追踪方向 (-direction)
+const (
+	directionUp   = "up"   // 谁调用了目标行所在的函数
+	directionDown = "down" // 目标行调用了什么 (能否到达 Sink)
+	directionBoth = "both"
+)
+
+// fileList 可重复的 -file 参数
+type fileList []string
+
+func (l *fileList) String() string { return strings.Join(*l, ",") }
+
+func (l *fileList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// pathMapList 可重复的 -path-map 参数 (配置文件中的多条映射以逗号分隔传入)
+type pathMapList report.PathMap
+
+func (l *pathMapList) String() string {
+	parts := make([]string, len(*l))
+	for i, m := range *l {
+		parts[i] = m.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *pathMapList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		m, err := report.ParsePathMapping(part)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, m)
+	}
+	return nil
+}
+
+// manualTarget 单点模式的一个目标位置 (Line/Col 从 1 开始，Col 为 0 表示未指定)
+type manualTarget struct {
+	File   string
+	Line   int
+	Col    int
+	Method string // 来自 -method 时为方法的完整签名 (Class#method(Args))
+}
+
+// parseTarget 解析 path:line 或 path:line:col
+// 从右向左按冒号拆分，因此 Windows 盘符 (C:\x\Main.java:42) 也能正确解析
+func parseTarget(spec, projectRoot string) (manualTarget, error) {
+	lastColon := strings.LastIndex(spec, ":")
+	if lastColon == -1 {
+		return manualTarget{}, fmt.Errorf("expected 'path/to/file:line[:col]' (e.g. Main.java:42)")
+	}
+
+	rawFilePath := strings.TrimSpace(spec[:lastColon])
+	lineStr := strings.TrimSpace(spec[lastColon+1:])
+	col := 0
+	// path:line:col —— 倒数第二段也是数字时视为行号
+	if prev := strings.LastIndex(rawFilePath, ":"); prev != -1 {
+		if n, err := strconv.Atoi(strings.TrimSpace(rawFilePath[prev+1:])); err == nil {
+			c, err := strconv.Atoi(lineStr)
+			if err != nil || c <= 0 {
+				return manualTarget{}, fmt.Errorf("invalid column: %s", lineStr)
+			}
+			rawFilePath, lineStr, col = strings.TrimSpace(rawFilePath[:prev]), strconv.Itoa(n), c
+		}
+	}
+	line, err := strconv.Atoi(lineStr)
+	if err != nil || line <= 0 {
+		return manualTarget{}, fmt.Errorf("invalid line number: %s", lineStr)
+	}
+
+	if !filepath.IsAbs(rawFilePath) {
+		rawFilePath = filepath.Join(projectRoot, rawFilePath)
+	}
+	if info, err := os.Stat(rawFilePath); err != nil || info.IsDir() {
+		return manualTarget{}, fmt.Errorf("file not found: %s", rawFilePath)
+	}
+	return manualTarget{File: rawFilePath, Line: line, Col: col}, nil
+}
+
+// loadTargets 汇总 -file 和 -targets 文件中的目标；无效的行报告后跳过，不中断整批
+func loadTargets(files []string, targetsFile, projectRoot string) ([]manualTarget, error) {
+	var targets []manualTarget
+	add := func(where, spec string) {
+		t, err := parseTarget(spec, projectRoot)
+		if err != nil {
+			color.Yellow("[!] Skipping target %s (%s): %v", spec, where, err)
+			return
+		}
+		targets = append(targets, t)
+	}
+
+	for _, spec := range files {
+		add("-file", spec)
+	}
+
+	if targetsFile != "" {
+		f, err := os.Open(targetsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			line := scanner.Text()
+			if idx := strings.Index(line, "#"); idx != -1 {
+				line = line[:idx]
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				add(fmt.Sprintf("%s:%d", filepath.Base(targetsFile), lineNum), line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// resolveMethodTarget 通过 workspace/symbol 定位 -method 指定的方法声明
+// 匹配到多个声明 (重载、多个同名类) 时列出候选项，由用户用完整签名区分
+func resolveMethodTarget(tracer *analysis.Tracer, spec string) (manualTarget, bool) {
+	matches, err := tracer.FindMethods(spec)
+	if err != nil {
+		color.Red("[-] Failed to look up method %s: %v", spec, err)
+		return manualTarget{}, false
+	}
+	switch len(matches) {
+	case 0:
+		color.Red("[-] No method declaration found for %s", spec)
+		return manualTarget{}, false
+	case 1:
+		m := matches[0]
+		color.Green("[+] Resolved %s -> %s", spec, m)
+		return manualTarget{File: m.File, Line: m.Line + 1, Col: m.Col + 1, Method: m.Class + "#" + m.Signature}, true
+	}
+
+	color.Yellow("[!] %s is ambiguous (%d matches). Use the full signature, e.g.:", spec, len(matches))
+	for _, m := range matches {
+		fmt.Printf("    -method '%s#%s'    %s:%d\n", m.Class, m.Signature, m.File, m.Line+1)
+	}
+	return manualTarget{}, false
+}
+
+// callAt 返回 col (0-based) 处开始的调用表达式，用于在报告中标出同一行中的具体调用
+func callAt(line string, col int) string {
+	if col < 0 || col >= len(line) {
+		return ""
+	}
+	depth := 0
+	for i := col; i < len(line); i++ {
+		switch line[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return line[col : i+1]
+			}
+		case ';':
+			if depth == 0 {
+				return strings.TrimSpace(line[col:i])
+			}
+		}
+	}
+	return strings.TrimSpace(line[col:])
+}
+
+// traceManualTarget 从目标行所在的函数开始向上追踪；找不到函数上下文时返回 false
+func traceManualTarget(tracer *analysis.Tracer, target manualTarget) bool {
+	color.Cyan("[*] Analyzing Sink at %s:%d", filepath.Base(target.File), target.Line)
+
+	targetLineIndex := target.Line - 1
+	fn, ok := tracer.GetEnclosingFunction(lsp.ToUri(target.File), targetLineIndex)
+	if !ok {
+		color.Red("[-] Could not find function context for %s:%d. Is the line number correct?", target.File, target.Line)
+		return false
+	}
+	color.Green("[+] Hit Initial Function: %s (Line:%d)", fn.Name, fn.SelectionStart+1)
+
+	firstStep := model.ChainStep{
+		File:  target.File,
+		Line:  targetLineIndex,
+		Func:  fn.Name,
+		Class: fn.Class,
+		Code:  GetLineContent(target.File, target.Line),
+		Rule:  manualTargetRule,
+
+		Confidence: &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByManual, Evidence: "target line given on the command line"}},
+	}
+	if start, end, ok := fn.SourceRange(); ok {
+		firstStep.FuncStartLine, firstStep.FuncEndLine = start, end
+	}
+
+	if target.Method != "" {
+		firstStep.Analysis = append(firstStep.Analysis, fmt.Sprintf("🎯 Method target `%s`: tracing all callers", target.Method))
+	} else if target.Col > 0 {
+		if raw, err := analysis.ReadLine(target.File, targetLineIndex); err == nil {
+			if call := callAt(raw, target.Col-1); call != "" {
+				firstStep.Analysis = append(firstStep.Analysis, fmt.Sprintf("🎯 Target column %d: `%s`", target.Col, call))
+			}
+		}
+	}
+
+	// 匿名类/lambda 中的目标行: 从外层命名方法继续追踪
+	traceFrom, note := tracer.ResolveTraceTarget(target.File, targetLineIndex, fn)
+	if note != "" {
+		firstStep.Analysis = append(firstStep.Analysis, note)
+	}
+
+	label := fmt.Sprintf("%s:%d", filepath.Base(target.File), target.Line)
+	tracer.TraceSink(label, target.File, traceFrom.SelectionStart, traceFrom.Column, []model.ChainStep{firstStep}, "")
+	return true
+}
+
+// traceCallees 向下展开目标行调用的方法并在控制台输出调用树；找不到函数上下文时返回 nil
+func traceCallees(tracer *analysis.Tracer, target manualTarget, rules []model.SinkRule, depth int) *model.CalleeNode {
+	color.Cyan("[*] Expanding calls made at %s:%d (depth %d)", filepath.Base(target.File), target.Line, depth)
+	tree, ok := tracer.TraceCallees(target.File, target.Line-1, depth, rules)
+	if !ok {
+		color.Red("[-] Could not find function context for %s:%d. Is the line number correct?", target.File, target.Line)
+		return nil
+	}
+	printCallTree(tree, tracer.ProjectRoot)
+	if n := tree.Sinks(); n > 0 {
+		color.Red("[!] %d sinks reachable from %s:%d", n, filepath.Base(target.File), target.Line)
+	} else {
+		color.Green("[+] No sinks reachable within %d levels.", depth)
+	}
+	return tree
+}
+
+// printCallTree 以树的形式输出调用树，Sink 标红
+func printCallTree(root *model.CalleeNode, projectRoot string) {
+	faint := color.New(color.Faint).SprintFunc()
+	fmt.Printf("    %s  %s\n", root.Func, faint(calleeLocation(root, projectRoot)))
+
+	var walk func(nodes []*model.CalleeNode, prefix string)
+	walk = func(nodes []*model.CalleeNode, prefix string) {
+		for i, n := range nodes {
+			branch, indent := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, indent = "└── ", "    "
+			}
+			label := n.Func
+			if n.Rule != nil {
+				label = color.RedString("⚠ %s: %s", n.Rule.Name, n.Code)
+			}
+			if n.Note != "" {
+				label += faint(" (" + n.Note + ")")
+			}
+			fmt.Printf("    %s%s%s  %s\n", prefix, branch, label, faint(calleeLocation(n, projectRoot)))
+			walk(n.Children, prefix+indent)
+		}
+	}
+	walk(root.Children, "")
+}
+
+func calleeLocation(n *model.CalleeNode, projectRoot string) string {
+	path := n.File
+	if rel, err := filepath.Rel(projectRoot, n.File); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(path), n.Line+1)
+}
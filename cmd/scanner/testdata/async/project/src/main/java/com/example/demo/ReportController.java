@@ -0,0 +1,17 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class ReportController {
+
+    private final ReportService reports = new ReportService();
+
+    @GetMapping("/reports")
+    public String export(@RequestParam String host) {
+        reports.generate(host);
+        return "queued";
+    }
+}
@@ -0,0 +1,13 @@
+package com.example.demo;
+
+import org.springframework.scheduling.annotation.Async;
+import org.springframework.stereotype.Service;
+
+@Service
+public class ReportService {
+
+    @Async
+    public void generate(String host) throws Exception {
+        Runtime.getRuntime().exec("ping -c 1 " + host);
+    }
+}
@@ -0,0 +1,31 @@
+package com.example.demo;
+
+public class Service {
+
+    public void handle(String input) {
+        dispatch(audit(normalize(input)), 2);
+    }
+
+    String audit(String s) {
+        return normalize(s);
+    }
+
+    String normalize(String s) {
+        return s.trim();
+    }
+
+    void dispatch(String cmd, int retries) {
+        if (retries > 0) {
+            dispatch(cmd, retries - 1);
+        }
+        execute(cmd);
+    }
+
+    void execute(String cmd) {
+        try {
+            Runtime.getRuntime().exec(cmd);
+        } catch (Exception e) {
+            throw new IllegalStateException(e);
+        }
+    }
+}
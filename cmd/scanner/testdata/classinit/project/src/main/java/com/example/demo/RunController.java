@@ -0,0 +1,21 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class RunController {
+
+    private CommandRunner runner;
+
+    @GetMapping("/run")
+    public String run() {
+        runner = new CommandRunner();
+        return runner.status();
+    }
+
+    @GetMapping("/version")
+    public String version() {
+        return CommandRunner.describe();
+    }
+}
@@ -0,0 +1,17 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.PostMapping;
+import org.springframework.web.bind.annotation.RequestBody;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class ExportController {
+
+    private final ExportService exporter = new ExportService();
+
+    @PostMapping("/exports")
+    public String export(@RequestBody ExportRequest req) throws Exception {
+        exporter.save(req);
+        return "saved";
+    }
+}
@@ -0,0 +1,15 @@
+package com.example.demo;
+
+public class ExportRequest {
+
+    private String path;
+    private String content;
+
+    public String getPath() {
+        return path;
+    }
+
+    public String getContent() {
+        return content;
+    }
+}
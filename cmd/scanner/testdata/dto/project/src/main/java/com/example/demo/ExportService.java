@@ -0,0 +1,13 @@
+package com.example.demo;
+
+import java.nio.file.Files;
+import java.nio.file.Paths;
+import org.springframework.stereotype.Service;
+
+@Service
+public class ExportService {
+
+    public void save(ExportRequest req) throws Exception {
+        Files.write(Paths.get(req.getPath()), req.getContent().getBytes());
+    }
+}
@@ -0,0 +1,16 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class PingController {
+
+    private final CommandService commands = new CommandService();
+
+    @GetMapping("/ping")
+    public String ping(@RequestParam String host) throws Exception {
+        return commands.run("ping -c 1 " + host);
+    }
+}
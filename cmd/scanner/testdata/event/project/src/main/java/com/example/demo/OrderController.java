@@ -0,0 +1,29 @@
+package com.example.demo;
+
+import org.springframework.context.ApplicationEventPublisher;
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class OrderController {
+
+    private final ApplicationEventPublisher publisher;
+
+    public OrderController(ApplicationEventPublisher publisher) {
+        this.publisher = publisher;
+    }
+
+    @GetMapping("/orders")
+    public String create(@RequestParam String host) {
+        publisher.publishEvent(new OrderCreated(host));
+        return "created";
+    }
+
+    @GetMapping("/orders/retry")
+    public String retry(@RequestParam String host) {
+        OrderCreated event = new OrderCreated(host);
+        publisher.publishEvent(event);
+        return "retried";
+    }
+}
@@ -0,0 +1,14 @@
+package com.example.demo;
+
+public class OrderCreated {
+
+    private final String host;
+
+    public OrderCreated(String host) {
+        this.host = host;
+    }
+
+    public String getHost() {
+        return host;
+    }
+}
@@ -0,0 +1,13 @@
+package com.example.demo;
+
+import org.springframework.context.event.EventListener;
+import org.springframework.stereotype.Component;
+
+@Component
+public class OrderListener {
+
+    @EventListener
+    public void onOrder(OrderCreated event) throws Exception {
+        Runtime.getRuntime().exec("ping -c 1 " + event.getHost());
+    }
+}
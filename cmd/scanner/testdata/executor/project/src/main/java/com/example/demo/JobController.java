@@ -0,0 +1,22 @@
+package com.example.demo;
+
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class JobController {
+
+    private final ExecutorService executor = Executors.newFixedThreadPool(4);
+    private final JobService jobs = new JobService();
+
+    @GetMapping("/jobs")
+    public String start(@RequestParam String host) {
+        executor.submit(() -> {
+            jobs.run(host);
+        });
+        return "started";
+    }
+}
@@ -0,0 +1,14 @@
+package com.example.demo;
+
+import java.io.IOException;
+
+public class JobService {
+
+    public void run(String host) {
+        try {
+            Runtime.getRuntime().exec("ping -c 1 " + host);
+        } catch (IOException e) {
+            throw new IllegalStateException(e);
+        }
+    }
+}
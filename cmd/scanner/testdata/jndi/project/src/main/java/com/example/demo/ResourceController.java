@@ -0,0 +1,16 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class ResourceController {
+
+    private final ResourceLocator locator = new ResourceLocator();
+
+    @GetMapping("/resources")
+    public String handle(@RequestParam String name) throws Exception {
+        return String.valueOf(locator.locate(name));
+    }
+}
@@ -0,0 +1,24 @@
+package com.example.demo;
+
+import javax.naming.InitialContext;
+import javax.sql.DataSource;
+
+public class ResourceLocator {
+
+    private static final String DATASOURCE = "java:comp/env/jdbc/main";
+
+    private final InitialContext context;
+
+    public ResourceLocator() {
+        try {
+            context = new InitialContext();
+        } catch (Exception e) {
+            throw new IllegalStateException(e);
+        }
+    }
+
+    public Object locate(String name) throws Exception {
+        DataSource ds = (DataSource) context.lookup(DATASOURCE);
+        return context.lookup(name);
+    }
+}
@@ -0,0 +1,24 @@
+package com.example.demo;
+
+import java.util.List;
+import org.springframework.web.bind.annotation.PostMapping;
+import org.springframework.web.bind.annotation.RequestBody;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class ArchiveController {
+
+    private final ArchiveService archiver = new ArchiveService();
+
+    @PostMapping("/archive")
+    public String archive(@RequestBody List<String> names) {
+        archiver.writeAll(names);
+        return "archived";
+    }
+
+    @PostMapping("/archive/each")
+    public String archiveEach(@RequestBody List<String> names) {
+        archiver.writeEach(names);
+        return "archived";
+    }
+}
@@ -0,0 +1,39 @@
+package com.example.demo;
+
+import java.io.IOException;
+import java.io.UncheckedIOException;
+import java.nio.file.Files;
+import java.nio.file.Paths;
+import java.util.List;
+import java.util.function.Consumer;
+import org.springframework.stereotype.Service;
+
+@Service
+public class ArchiveService {
+
+    public void writeAll(List<String> names) {
+        names.stream()
+            .map(String::trim)
+            .filter(n -> !n.isEmpty())
+            .forEach(n -> {
+                try {
+                    Files.write(Paths.get("/tmp/archive", n), n.getBytes());
+                } catch (IOException e) {
+                    throw new UncheckedIOException(e);
+                }
+            });
+    }
+
+    public void writeEach(List<String> names) {
+        names.forEach(new Consumer<String>() {
+            @Override
+            public void accept(String n) {
+                try {
+                    Files.write(Paths.get("/tmp/archive", n), n.getBytes());
+                } catch (IOException e) {
+                    throw new UncheckedIOException(e);
+                }
+            }
+        });
+    }
+}
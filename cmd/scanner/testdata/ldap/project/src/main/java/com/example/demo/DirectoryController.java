@@ -0,0 +1,16 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class DirectoryController {
+
+    private final DirectoryService directory = new DirectoryService();
+
+    @GetMapping("/directory")
+    public String handle(@RequestParam String user) throws Exception {
+        return String.valueOf(directory.find(user));
+    }
+}
@@ -0,0 +1,24 @@
+package com.example.demo;
+
+import java.util.List;
+import javax.naming.directory.DirContext;
+import javax.naming.directory.SearchControls;
+import org.springframework.ldap.core.AttributesMapper;
+import org.springframework.ldap.core.LdapTemplate;
+
+public class DirectoryService {
+
+    private static final String PEOPLE = "ou=people,dc=example,dc=com";
+
+    private DirContext dirContext;
+    private LdapTemplate ldapTemplate;
+
+    public Object find(String user) throws Exception {
+        SearchControls controls = new SearchControls();
+        dirContext.search(user, "(objectClass=person)", controls);
+        dirContext.search(PEOPLE, "(uid=" + user + ")", controls);
+        AttributesMapper<String> mapper = attrs -> attrs.get("cn").toString();
+        List<String> names = ldapTemplate.search(PEOPLE, "(cn=" + user + ")", mapper);
+        return names;
+    }
+}
@@ -0,0 +1,11 @@
+package com.example.common;
+
+import java.io.IOException;
+
+public class CommandService {
+
+    public String run(String cmd) throws IOException {
+        Process process = Runtime.getRuntime().exec(cmd);
+        return String.valueOf(process.pid());
+    }
+}
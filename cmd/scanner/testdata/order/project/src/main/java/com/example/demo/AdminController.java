@@ -0,0 +1,15 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.PostMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class AdminController {
+
+    @PostMapping("/restart")
+    public String restart(@RequestParam String service) throws Exception {
+        Runtime.getRuntime().exec("systemctl restart " + service);
+        return "ok";
+    }
+}
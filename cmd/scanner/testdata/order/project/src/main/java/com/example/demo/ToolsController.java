@@ -0,0 +1,21 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class ToolsController {
+
+    @GetMapping("/ping")
+    public String ping(@RequestParam String host) throws Exception {
+        Runtime.getRuntime().exec("ping -c 1 " + host);
+        return "ok";
+    }
+
+    @GetMapping("/trace")
+    public String trace(@RequestParam String host) throws Exception {
+        Runtime.getRuntime().exec("traceroute " + host);
+        return "ok";
+    }
+}
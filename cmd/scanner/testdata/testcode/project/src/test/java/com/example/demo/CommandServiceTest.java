@@ -0,0 +1,12 @@
+package com.example.demo;
+
+public class CommandServiceTest {
+
+    public void runsPing() throws Exception {
+        new CommandService().run("ping -c 1 localhost");
+    }
+
+    public void shell(String cmd) throws Exception {
+        Runtime.getRuntime().exec(cmd);
+    }
+}
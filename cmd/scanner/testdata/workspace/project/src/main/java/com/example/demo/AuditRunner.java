@@ -0,0 +1,8 @@
+package com.example.demo;
+
+public class AuditRunner {
+
+    public String run(String event) {
+        return event;
+    }
+}
@@ -0,0 +1,8 @@
+package com.example.demo;
+
+public class LegacySqlRunner {
+
+    public String run(String sql) {
+        return sql;
+    }
+}
@@ -0,0 +1,22 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class QueryController {
+
+    private final LegacySqlRunner runner = new LegacySqlRunner();
+    private final AuditRunner audit = new AuditRunner();
+
+    @GetMapping("/query")
+    public String query(@RequestParam String where) {
+        return runner.run("SELECT * FROM orders WHERE " + where);
+    }
+
+    @GetMapping("/audit")
+    public String record(@RequestParam String event) {
+        return audit.run(event);
+    }
+}
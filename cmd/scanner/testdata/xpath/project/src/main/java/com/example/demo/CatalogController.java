@@ -0,0 +1,16 @@
+package com.example.demo;
+
+import org.springframework.web.bind.annotation.GetMapping;
+import org.springframework.web.bind.annotation.RequestParam;
+import org.springframework.web.bind.annotation.RestController;
+
+@RestController
+public class CatalogController {
+
+    private final CatalogService catalog = new CatalogService();
+
+    @GetMapping("/catalog")
+    public String handle(@RequestParam String sku) throws Exception {
+        return String.valueOf(catalog.search(sku));
+    }
+}
@@ -0,0 +1,20 @@
+package com.example.demo;
+
+import javax.xml.xpath.XPath;
+import javax.xml.xpath.XPathExpression;
+import javax.xml.xpath.XPathFactory;
+import org.w3c.dom.Document;
+
+public class CatalogService {
+
+    private static final String ALL_ITEMS = "/catalog/item";
+
+    private final XPath xpath = XPathFactory.newInstance().newXPath();
+    private Document catalog;
+
+    public Object search(String sku) throws Exception {
+        Object all = xpath.evaluate(ALL_ITEMS, catalog);
+        XPathExpression byName = xpath.compile("/catalog/item[@name='" + sku + "']");
+        return xpath.evaluate("/catalog/item[@sku='" + sku + "']/price", catalog) + byName.evaluate(catalog);
+    }
+}
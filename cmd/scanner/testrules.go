@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"LSPTracer/internal/analysis"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// runTestRules 实现 test-rules 子命令: 在 fixture 目录上验证规则的匹配结果
+// 返回进程退出码 (有不符合期望的行时非 0)
+func runTestRules(args []string) int {
+	fs := flag.NewFlagSet("test-rules", flag.ExitOnError)
+	rulesPath := fs.String("rules", "", "(Optional) Path to the rules.yaml file under test. Default: built-in rules.")
+	fixtures := fs.String("fixtures", "", "Directory of Java fixtures annotated with '// EXPECT: <VULN_TYPE>' or '// EXPECT: none'")
+	verbose := fs.Bool("v", false, "Also print passing lines")
+	fs.Parse(args)
+
+	if *fixtures == "" {
+		fmt.Fprintln(os.Stderr, "Please provide -fixtures argument.\nExample: test-rules -rules my.yaml -fixtures ./fixtures")
+		return 2
+	}
+
+	var rules []model.SinkRule
+	if *rulesPath != "" {
+		var err error
+		rules, err = model.LoadRulesFromFile(*rulesPath)
+		if err != nil {
+			color.Red("[-] Failed to load rules from %s: %v", *rulesPath, err)
+			return 2
+		}
+	} else {
+		rules = model.GetBuiltinRules()
+	}
+
+	results, err := analysis.TestRules(rules, *fixtures)
+	if err != nil {
+		color.Red("[-] Failed to read fixtures: %v", err)
+		return 2
+	}
+
+	failed := 0
+	fmt.Printf("%-6s %-40s %-12s %-12s %s\n", "RESULT", "LOCATION", "EXPECTED", "GOT", "CODE")
+	for _, r := range results {
+		if r.Pass && !*verbose {
+			continue
+		}
+		status := color.GreenString("PASS  ")
+		if !r.Pass {
+			failed++
+			status = color.RedString("FAIL  ")
+		}
+		fmt.Printf("%s %-40s %-12s %-12s %s\n", status,
+			fmt.Sprintf("%s:%d", r.File, r.Line),
+			typesOrNone(r.Expected), typesOrNone(r.Got), r.Code)
+		if !r.Pass && len(r.Rules) > 0 {
+			fmt.Printf("       matched rules: %s\n", strings.Join(r.Rules, "; "))
+		}
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		color.Red("[-] %d/%d fixture lines failed.", failed, len(results))
+		return 1
+	}
+	color.Green("[+] All %d fixture lines passed.", len(results))
+	return 0
+}
+
+func typesOrNone(types []string) string {
+	if len(types) == 0 {
+		return "none"
+	}
+	return strings.Join(types, ",")
+}
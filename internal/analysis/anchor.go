@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxModuleAnchors 多模块项目中额外 didOpen 的锚点数量上限 (按模块内 Java 文件数排序)
+const maxModuleAnchors = 8
+
+var (
+	springBootAppPattern = regexp.MustCompile(`@SpringBootApplication\b`)
+	controllerPattern    = regexp.MustCompile(`@(?:Rest)?Controller\b`)
+)
+
+// AnchorFile 选择锚点时需要的文件信息
+type AnchorFile struct {
+	Path          string
+	Module        string // 所在模块 (最近的包含 pom.xml/build.gradle 的目录，相对项目根目录)
+	Size          int64
+	SpringBootApp bool // 包含 @SpringBootApplication
+	Controller    bool // 包含 @RestController / @Controller
+}
+
+// AnchorChoice 锚点选择结果
+type AnchorChoice struct {
+	Primary       string   // 启动时打开的主锚点
+	Reason        string   // 选择原因 (用于日志)
+	ModuleAnchors []string // 其它模块各一个锚点 (不含 Primary)，让多模块工作区的索引更均匀
+}
+
+// PickAnchors 按优先级选择锚点: @SpringBootApplication > 最大的 Controller > Java 文件最多的模块中的第一个文件
+// package-info.java / module-info.java 不参与选择；files 为空时返回零值
+func PickAnchors(files []AnchorFile) AnchorChoice {
+	var usable []AnchorFile
+	for _, f := range files {
+		if name := filepath.Base(f.Path); name != "package-info.java" && name != "module-info.java" {
+			usable = append(usable, f)
+		}
+	}
+	if len(usable) == 0 {
+		return AnchorChoice{}
+	}
+	sort.Slice(usable, func(i, j int) bool { return usable[i].Path < usable[j].Path })
+
+	// 按模块分组，模块按 Java 文件数降序 (相同时按名称)
+	byModule := make(map[string][]AnchorFile)
+	for _, f := range usable {
+		byModule[f.Module] = append(byModule[f.Module], f)
+	}
+	modules := make([]string, 0, len(byModule))
+	for m := range byModule {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		ni, nj := len(byModule[modules[i]]), len(byModule[modules[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return modules[i] < modules[j]
+	})
+
+	primary, reason := bestAnchor(usable)
+	if !primary.SpringBootApp && !primary.Controller {
+		primary = byModule[modules[0]][0]
+		reason = "first file in the module with the most Java files (" + moduleLabel(primary.Module) + ")"
+	}
+
+	choice := AnchorChoice{Primary: primary.Path, Reason: reason}
+	for _, m := range modules {
+		if m == primary.Module {
+			continue
+		}
+		if len(choice.ModuleAnchors) >= maxModuleAnchors {
+			break
+		}
+		anchor, _ := bestAnchor(byModule[m])
+		choice.ModuleAnchors = append(choice.ModuleAnchors, anchor.Path)
+	}
+	return choice
+}
+
+// bestAnchor 在一组文件 (已按路径排序) 中按优先级选择: @SpringBootApplication > 最大的 Controller > 第一个文件
+func bestAnchor(files []AnchorFile) (AnchorFile, string) {
+	for _, f := range files {
+		if f.SpringBootApp {
+			return f, "@SpringBootApplication"
+		}
+	}
+	var largest *AnchorFile
+	for i := range files {
+		if files[i].Controller && (largest == nil || files[i].Size > largest.Size) {
+			largest = &files[i]
+		}
+	}
+	if largest != nil {
+		return *largest, "largest controller"
+	}
+	return files[0], "first file"
+}
+
+func moduleLabel(module string) string {
+	if module == "." || module == "" {
+		return "root module"
+	}
+	return module
+}
+
+// CollectAnchorFiles 遍历项目收集 .java 文件的锚点信息 (跳过隐藏目录和构建输出目录)
+// 设置了 scope 时只收集范围内的文件
+func CollectAnchorFiles(root string, followSymlinks bool, scope Scope) []AnchorFile {
+	root = filepath.Clean(root)
+	moduleCache := make(map[string]string)
+
+	var files []AnchorFile
+	WalkProject(root, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (ScanWalk.SkipDir(root, path) || !scope.MayContainDir(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".java") || ScanWalk.SkipFile(path) || !scope.ContainsFile(path) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, AnchorFile{
+			Path:          path,
+			Module:        moduleOf(root, filepath.Dir(path), moduleCache),
+			Size:          int64(len(content)),
+			SpringBootApp: springBootAppPattern.Match(content),
+			Controller:    controllerPattern.Match(content),
+		})
+		return nil
+	})
+	return files
+}
+
+// moduleOf 向上查找最近的包含构建文件的目录，返回相对 root 的路径 (找不到时为 ".")
+func moduleOf(root, dir string, cache map[string]string) string {
+	if m, ok := cache[dir]; ok {
+		return m
+	}
+
+	module := "."
+	for _, name := range []string{"pom.xml", "build.gradle", "build.gradle.kts"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			if rel, err := filepath.Rel(root, dir); err == nil {
+				module = filepath.ToSlash(rel)
+			}
+			cache[dir] = module
+			return module
+		}
+	}
+	if parent := filepath.Dir(dir); dir != root && parent != dir && strings.HasPrefix(parent, root) {
+		module = moduleOf(root, parent, cache)
+	}
+	cache[dir] = module
+	return module
+}
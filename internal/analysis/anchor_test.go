@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPickAnchors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []AnchorFile
+		primary string
+		reason  string
+		modules []string
+	}{
+		{
+			name: "spring boot application wins over controllers",
+			files: []AnchorFile{
+				{Path: "api/src/OrderController.java", Module: "api", Size: 9000, Controller: true},
+				{Path: "app/src/Application.java", Module: "app", Size: 300, SpringBootApp: true},
+			},
+			primary: "app/src/Application.java", reason: "@SpringBootApplication",
+			modules: []string{"api/src/OrderController.java"},
+		},
+		{
+			name: "largest controller",
+			files: []AnchorFile{
+				{Path: "src/AController.java", Module: ".", Size: 100, Controller: true},
+				{Path: "src/BController.java", Module: ".", Size: 500, Controller: true},
+				{Path: "src/Util.java", Module: ".", Size: 5000},
+			},
+			primary: "src/BController.java", reason: "largest controller",
+		},
+		{
+			name: "package-info is never picked; first file of the largest module",
+			files: []AnchorFile{
+				{Path: "annotations/src/package-info.java", Module: "annotations", Size: 10},
+				{Path: "annotations/src/Marker.java", Module: "annotations", Size: 10},
+				{Path: "core/src/B.java", Module: "core", Size: 10},
+				{Path: "core/src/A.java", Module: "core", Size: 10},
+			},
+			primary: "core/src/A.java", reason: "first file in the module with the most Java files (core)",
+			modules: []string{"annotations/src/Marker.java"},
+		},
+		{
+			name: "root module label",
+			files: []AnchorFile{
+				{Path: "src/Main.java", Module: ".", Size: 10},
+			},
+			primary: "src/Main.java", reason: "first file in the module with the most Java files (root module)",
+		},
+		{
+			name:  "only package-info",
+			files: []AnchorFile{{Path: "src/package-info.java", Module: "."}},
+		},
+	}
+	for _, tt := range tests {
+		got := PickAnchors(tt.files)
+		if got.Primary != tt.primary || got.Reason != tt.reason || !reflect.DeepEqual(got.ModuleAnchors, tt.modules) {
+			t.Errorf("%s: got %+v, want primary %q (%s), module anchors %v", tt.name, got, tt.primary, tt.reason, tt.modules)
+		}
+	}
+}
+
+// 每个模块最多一个额外锚点，总数不超过 maxModuleAnchors，模块按文件数从多到少
+func TestPickAnchorsModuleLimit(t *testing.T) {
+	var files []AnchorFile
+	for m := 0; m < maxModuleAnchors+3; m++ {
+		module := string(rune('a' + m))
+		for f := 0; f <= m; f++ {
+			files = append(files, AnchorFile{Path: module + "/F" + string(rune('0'+f)) + ".java", Module: module})
+		}
+	}
+	got := PickAnchors(files)
+	if got.Primary != "k/F0.java" {
+		t.Errorf("primary = %s, want the first file of the largest module k", got.Primary)
+	}
+	if len(got.ModuleAnchors) != maxModuleAnchors || got.ModuleAnchors[0] != "j/F0.java" {
+		t.Errorf("module anchors = %v, want %d starting with j/F0.java", got.ModuleAnchors, maxModuleAnchors)
+	}
+}
+
+func TestCollectAnchorFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pom.xml", "<project/>")
+	write("web/pom.xml", "<project/>")
+	write("web/src/main/java/App.java", "@SpringBootApplication\npublic class App {}")
+	write("web/src/main/java/Api.java", "@RestController\npublic class Api {}")
+	write("src/main/java/Util.java", "public class Util {}")
+	write("target/classes/Generated.java", "public class Generated {}")
+
+	got := make(map[string]AnchorFile)
+	for _, f := range CollectAnchorFiles(root, false, Scope{}) {
+		rel, _ := filepath.Rel(root, f.Path)
+		got[filepath.ToSlash(rel)] = f
+	}
+	if len(got) != 3 {
+		t.Fatalf("collected %v, want App, Api and Util (target/ skipped)", got)
+	}
+	if f := got["web/src/main/java/App.java"]; f.Module != "web" || !f.SpringBootApp || f.Controller {
+		t.Errorf("App.java = %+v", f)
+	}
+	if f := got["web/src/main/java/Api.java"]; f.Module != "web" || !f.Controller {
+		t.Errorf("Api.java = %+v", f)
+	}
+	if f := got["src/main/java/Util.java"]; f.Module != "." || f.Size != int64(len("public class Util {}")) {
+		t.Errorf("Util.java = %+v", f)
+	}
+}
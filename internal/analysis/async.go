@@ -0,0 +1,314 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"LSPTracer/internal/lsp"
+)
+
+// 异步边界: 数据跨过异步调用后，调用关系不再是直接的方法调用。
+// 1. @EventListener / @TransactionalEventListener 方法由框架调用，没有直接的调用者: 从事件的发布点继续追踪
+// 2. @Async 方法的调用者照常追踪，在调用者步骤上标注边界 (调用方不等待执行结果)
+// 3. 提交给线程池的 lambda: ResolveTraceTarget 回退到提交它的方法，并说明是线程池的边界
+
+const (
+	boundaryAsync = "async"
+	boundaryEvent = "event"
+)
+
+// asyncBoundary 被追踪的方法所在的异步边界，kind 为空表示不是异步方法
+type asyncBoundary struct {
+	kind   string
+	method string   // 被追踪的方法 (展示名)
+	events []string // 监听的事件类型 (简单类名)
+}
+
+// asyncAnnotationRe 方法上标记异步边界的注解
+var asyncAnnotationRe = regexp.MustCompile(`@(?:[\w$]+\.)*(EventListener|TransactionalEventListener|Async)\b`)
+
+// eventClassRe 注解参数中的类字面量: @EventListener(OrderCreated.class)、classes = {A.class, B.class}
+var eventClassRe = regexp.MustCompile(`([A-Za-z_$][\w$.]*)\s*\.\s*class\b`)
+
+// genericEvents 不指向具体事件的参数类型，无法确定发布点
+var genericEvents = map[string]bool{"Object": true, "ApplicationEvent": true, "PayloadApplicationEvent": true}
+
+// asyncBoundaryOf 检查 line 所在的方法是否为异步方法
+func (t *Tracer) asyncBoundaryOf(file string, line int) asyncBoundary {
+	fn, ok := t.GetEnclosingFunction(lsp.ToUri(file), line)
+	if !ok || fn.Initializer != "" {
+		return asyncBoundary{}
+	}
+	lines := t.masked.lines(file)
+	annotations := methodAnnotations(lines, fn.SelectionStart)
+	m := asyncAnnotationRe.FindStringSubmatchIndex(annotations)
+	if m == nil {
+		return asyncBoundary{}
+	}
+	b := asyncBoundary{kind: boundaryAsync, method: fn.Name}
+	if annotations[m[2]:m[3]] == "Async" {
+		return b
+	}
+
+	b.kind = boundaryEvent
+	if rest := strings.TrimLeft(annotations[m[1]:], " \t"); strings.HasPrefix(rest, "(") {
+		for _, lit := range eventClassRe.FindAllStringSubmatch(callArgs(rest, 0), -1) {
+			b.events = append(b.events, simpleTypeName(lit[1]))
+		}
+	}
+	if len(b.events) == 0 {
+		if typ := firstParamType(lines, fn); typ != "" && !genericEvents[typ] {
+			b.events = append(b.events, typ)
+		}
+	}
+	return b
+}
+
+// methodAnnotations 方法声明行及其之前紧邻的注解 (遇到上一个成员的结尾为止)，用空格连接
+func methodAnnotations(lines []string, start int) string {
+	if start < 0 || start >= len(lines) {
+		return ""
+	}
+	parts := []string{strings.TrimSpace(lines[start])}
+	for i := start - 1; i >= 0 && start-i <= 15; i-- {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasSuffix(text, ";") || strings.HasSuffix(text, "}") || strings.HasSuffix(text, "{") {
+			break
+		}
+		parts = append([]string{text}, parts...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// annotationPrefixRe 参数前的注解 (@NonNull、@Payload("x"))
+var annotationPrefixRe = regexp.MustCompile(`@[\w$.]+(?:\s*\([^)]*\))?\s*`)
+
+// firstParamType 方法第一个参数的类型 (简单类名，不含泛型参数)，没有参数时为空
+func firstParamType(lines []string, fn FunctionInfo) string {
+	sig := methodSignature(lines, fn.SelectionStart)
+	name := methodRefOf(fn).Name
+	loc := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\s*\(`).FindStringIndex(sig)
+	if loc == nil {
+		return ""
+	}
+	params := splitArgs(callArgs(sig, loc[1]-1))
+	if len(params) == 0 {
+		return ""
+	}
+	fields := strings.Fields(annotationPrefixRe.ReplaceAllString(params[0], ""))
+	if len(fields) > 0 && fields[0] == "final" {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return ""
+	}
+	typ, _, _ := strings.Cut(fields[0], "<")
+	return simpleTypeName(typ)
+}
+
+// callerNote 调用者步骤上的边界说明；event 边界只标注事件的发布点 (bridged 中的位置)
+func (b asyncBoundary) callerNote(key string, bridged map[string]string) string {
+	if event, ok := bridged[key]; ok {
+		return fmt.Sprintf("⏩ Async boundary: event — `%s` published here is delivered to `@EventListener` method `%s`", event, b.method)
+	}
+	if b.kind == boundaryAsync {
+		return fmt.Sprintf("⏩ Async boundary: `@Async` method `%s` runs on a task executor (the caller does not wait for it)", b.method)
+	}
+	return ""
+}
+
+// publisherCache 按事件类型缓存的发布点
+type publisherCache struct {
+	mu    sync.Mutex
+	sites map[string][]lsp.Location
+}
+
+// publishTimeout 查找事件构造位置时 references 请求的超时
+const publishTimeout = 5 * time.Second
+
+// eventPublishers 事件的发布点: 文本中的 publishEvent(new FooEvent(...))，
+// 以及事件类是项目中的类时 LSP 找到的所有 new FooEvent(...) (事件对象先赋值给变量再发布的情况)
+func (t *Tracer) eventPublishers(ctx context.Context, listenerFile, event string) []lsp.Location {
+	classFile, isProjectClass := projectClassFile(listenerFile, t.masked.lines(listenerFile), event)
+	key := event
+	if isProjectClass {
+		key = lsp.NormalizePath(classFile)
+	}
+	t.publishers.mu.Lock()
+	sites, ok := t.publishers.sites[key]
+	t.publishers.mu.Unlock()
+	if ok {
+		return sites
+	}
+
+	seen := make(map[string]bool)
+	add := func(loc lsp.Location) {
+		k := lineKey(lsp.FromUri(loc.Uri), loc.Range.Start.Line)
+		if !seen[k] {
+			seen[k] = true
+			sites = append(sites, loc)
+		}
+	}
+	for _, loc := range t.publishCalls(event) {
+		add(loc)
+	}
+	if isProjectClass {
+		refs, err := t.eventConstructions(ctx, classFile, event)
+		if err != nil && ctx.Err() != nil {
+			return sites // 预算耗尽: 不缓存不完整的结果
+		}
+		for _, loc := range refs {
+			add(loc)
+		}
+	}
+
+	t.publishers.mu.Lock()
+	if t.publishers.sites == nil {
+		t.publishers.sites = make(map[string][]lsp.Location)
+	}
+	t.publishers.sites[key] = sites
+	t.publishers.mu.Unlock()
+	return sites
+}
+
+// publishCalls 文本搜索 publishEvent(new FooEvent / multicastEvent(new FooEvent (忽略注释和字符串)
+func (t *Tracer) publishCalls(event string) []lsp.Location {
+	re := regexp.MustCompile(`\b(?:publishEvent|multicastEvent)\s*\(\s*new\s+(?:[\w$]+\.)*` + regexp.QuoteMeta(event) + `\b`)
+	var sites []lsp.Location
+	t.walkFiles(func(name string) bool { return strings.HasSuffix(name, ".java") }, func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(content, []byte(event)) {
+			return
+		}
+		for i, text := range t.masked.lines(path) {
+			if loc := re.FindStringIndex(text); loc != nil {
+				sites = append(sites, lsp.Location{Uri: lsp.ToUri(path), Range: lsp.Range{
+					Start: lsp.Position{Line: i, Character: loc[0]},
+					End:   lsp.Position{Line: i, Character: loc[1]},
+				}})
+			}
+		}
+	})
+	return sites
+}
+
+// newBeforeRe 引用位置之前是 "new " (类型引用是实例创建)
+var newBeforeRe = regexp.MustCompile(`\bnew\s+(?:[\w$]+\s*\.\s*)*$`)
+
+// eventConstructions 事件类的 references 中创建事件对象的位置
+func (t *Tracer) eventConstructions(ctx context.Context, classFile, event string) ([]lsp.Location, error) {
+	symbols, err := t.Docs.Symbols(classFile)
+	if err != nil {
+		return nil, err
+	}
+	class, ok := findClassSymbol(symbols, event)
+	if !ok {
+		return nil, nil
+	}
+	callCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	var refs []lsp.Location
+	err = t.Client.Call(callCtx, "textDocument/references", map[string]interface{}{
+		"textDocument": map[string]string{"uri": lsp.ToUri(classFile)},
+		"position":     class.SelectionRange.Start,
+		"context":      map[string]bool{"includeDeclaration": false},
+	}, &refs)
+	if err != nil {
+		return nil, err
+	}
+	var sites []lsp.Location
+	for _, ref := range refs {
+		lines := t.masked.lines(lsp.FromUri(ref.Uri))
+		pos := ref.Range.Start
+		if pos.Line < len(lines) && pos.Character <= len(lines[pos.Line]) && newBeforeRe.MatchString(lines[pos.Line][:pos.Character]) {
+			sites = append(sites, ref)
+		}
+	}
+	return sites, nil
+}
+
+// findClassSymbol 在符号树中查找名为 name 的类 (包括嵌套类)
+func findClassSymbol(symbols []lsp.DocumentSymbol, name string) (lsp.DocumentSymbol, bool) {
+	for _, node := range symbols {
+		if isClassKind(node.Kind) && node.Name == name {
+			return node, true
+		}
+		if found, ok := findClassSymbol(node.Children, name); ok {
+			return found, true
+		}
+	}
+	return lsp.DocumentSymbol{}, false
+}
+
+// executorCallRe 把任务交给其它线程执行的调用: CompletableFuture.supplyAsync(...)、x.thenApplyAsync(...)、
+// executor.submit(...)、taskPool.execute(...)、new Thread(...)。submit/execute 只认接收者名称像线程池的调用
+// (jdbcTemplate.execute 等回调是同步的)
+var executorCallRe = regexp.MustCompile(`(?:\b([\w$]+)\s*\.\s*)?\b(\w+Async|submit|execute|invokeAll|invokeAny|schedule\w*)\s*\(|\bnew\s+Thread\s*\(`)
+
+var executorReceiverRe = regexp.MustCompile(`(?i)(executor|pool|scheduler)`)
+
+// executorHandoff 返回包围 line 的 lambda/匿名类被提交给线程池的调用 (e.g. "executor.submit")，不是时为空
+// masked 是屏蔽后的源码，from 是外层方法的起始行
+func executorHandoff(masked []string, from, line int) string {
+	if from < 0 || line >= len(masked) || from > line {
+		return ""
+	}
+	before := strings.Join(masked[from:line], "\n")
+	current := masked[line]
+	text := before + "\n" + current
+	handoff := ""
+	for _, m := range executorCallRe.FindAllStringSubmatchIndex(text, -1) {
+		call := strings.TrimSpace(text[m[0]:m[1]])
+		if m[4] != -1 {
+			method := text[m[4]:m[5]]
+			receiver := ""
+			if m[2] != -1 {
+				receiver = text[m[2]:m[3]]
+			}
+			if !strings.HasSuffix(method, "Async") && !executorReceiverRe.MatchString(receiver) {
+				continue
+			}
+			call = method
+			if receiver != "" {
+				call = receiver + "." + method
+			}
+		} else {
+			call = "new Thread"
+		}
+
+		open := m[1] - 1
+		if open < len(before) {
+			// 调用在之前的行: 到当前行开始时参数列表仍未闭合
+			if !closes(before[open:]) {
+				handoff = call
+			}
+		} else if strings.Contains(current[open-len(before)-1:], "->") {
+			// 调用和 lambda 在同一行
+			handoff = call
+		}
+	}
+	return handoff
+}
+
+// closes text 开头的 "(" 是否在 text 中闭合
+func closes(text string) bool {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
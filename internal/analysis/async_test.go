@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+// 只有把 lambda 交给其它线程的调用算异步边界: 同步回调 (jdbcTemplate.execute) 和已经闭合的调用不算
+func TestExecutorHandoff(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string // 第一行是方法声明，最后一行是调用点
+		want string
+	}{
+		{"submit block", "void start(String host) {\n    executor.submit(() -> {\n        jobs.run(host);", "executor.submit"},
+		{"same line", "void start(String host) {\n    CompletableFuture.supplyAsync(() -> jobs.run(host));", "CompletableFuture.supplyAsync"},
+		{"chained async", "void start(String host) {\n    future.thenAcceptAsync(r -> {\n        jobs.run(host);", "future.thenAcceptAsync"},
+		{"new thread", "void start(String host) {\n    new Thread(() -> {\n        jobs.run(host);", "new Thread"},
+		{"scheduler", "void start(String host) {\n    taskScheduler.schedule(() ->\n        jobs.run(host),", "taskScheduler.schedule"},
+		{"sync callback", "void start(String host) {\n    jdbcTemplate.execute(conn -> {\n        jobs.run(host);", ""},
+		{"closed call", "void start(String host) {\n    executor.submit(() -> audit(host));\n    list.forEach(h -> {\n        jobs.run(h);", ""},
+	}
+	for _, tt := range tests {
+		lines := strings.Split(tt.src, "\n")
+		if got := executorHandoff(lines, 0, len(lines)-1); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFirstParamType(t *testing.T) {
+	tests := []struct {
+		decl string
+		want string
+	}{
+		{"public void onOrder(OrderCreated event) {", "OrderCreated"},
+		{"public void onOrder(final @NonNull com.acme.OrderCreated event) {", "OrderCreated"},
+		{"public void onOrder(@Payload(\"x\") PayloadApplicationEvent<String> event) {", "PayloadApplicationEvent"},
+		{"public void onOrder() {", ""},
+	}
+	for _, tt := range tests {
+		fn := FunctionInfo{Symbol: "onOrder(...)", SelectionStart: 0}
+		if got := firstParamType([]string{tt.decl}, fn); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.decl, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/model"
+)
+
+// AnalyzerAuthz -analyzer 的取值: 列出没有任何授权检查的 Controller 端点
+const AnalyzerAuthz = "authz"
+
+// AuthzVulnType 授权缺失发现的漏洞类型，在报告中单独分组
+const AuthzVulnType = "AUTHZ"
+
+// authzAnnotations 方法或类上表示已做授权检查的注解
+var authzAnnotations = []string{"PreAuthorize", "PostAuthorize", "Secured", "RolesAllowed"}
+
+var (
+	// 安全配置中的一条授权规则: .requestMatchers(...).hasRole(...) / .anyRequest().authenticated()
+	authzRuleRe     = regexp.MustCompile(`\.\s*(requestMatchers|antMatchers|mvcMatchers|regexMatchers|anyRequest)\s*\(([^)]*)\)\s*\.\s*(\w+)\s*\(([^)]*)\)`)
+	httpMethodRe    = regexp.MustCompile(`HttpMethod\.(\w+)`)
+	pathVariableRe  = regexp.MustCompile(`\{[^}]*\}`)
+	stringLiteralRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+)
+
+// authzRule 安全配置 (SecurityFilterChain / WebSecurityConfigurerAdapter) 中的一条 URL 授权规则
+type authzRule struct {
+	Method   string // 限定的 HTTP 方法，为空表示不限制
+	Patterns []*regexp.Regexp
+	Access   string // 授权方式 (permitAll / authenticated / hasRole ...)
+	Text     string // 规则原文 (用于报告)
+	File     string
+	Line     int
+}
+
+// permits 规则是否放行所有人 (不构成授权)
+func (r authzRule) permits() bool {
+	return r.Access == "permitAll"
+}
+
+// matches 规则是否匹配端点 (路径变量按任意单段处理)
+func (r authzRule) matches(e entrypoints.Endpoint) bool {
+	if r.Method != "" && len(e.Methods) > 0 && !containsString(e.Methods, r.Method) {
+		return false
+	}
+	path := pathVariableRe.ReplaceAllString(e.Path, "x")
+	for _, p := range r.Patterns {
+		if p.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeAuthz 列出没有授权检查的 Controller 端点: 方法和类上都没有 @PreAuthorize / @Secured / @RolesAllowed，
+// 且路径没有被安全配置中非 permitAll 的规则匹配 (按声明顺序取第一条匹配的规则)
+// 结果作为 INFO 等级的单步链路追加到 Results，返回新增的结果数量；已记录链路的 auth 等级覆盖在这里检查
+func (t *Tracer) AnalyzeAuthz(endpoints []entrypoints.Endpoint) int {
+	var rules []authzRule
+	configs := 0
+	t.walkJavaFiles(func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		if found := parseAuthzRules(path, content); found != nil {
+			rules = append(rules, found...)
+			configs++
+		}
+	})
+	fmt.Printf("    [authz] %d endpoints, %d URL rules in %d security config classes\n", len(endpoints), len(rules), configs)
+
+	var results [][]model.ChainStep
+	unprotected := make(map[string]map[int]bool)
+	for _, e := range endpoints {
+		if _, ok := e.HasAnnotation(authzAnnotations...); ok {
+			continue
+		}
+		var matched *authzRule
+		for i := range rules {
+			if rules[i].matches(e) {
+				matched = &rules[i]
+				break
+			}
+		}
+		if matched != nil && !matched.permits() {
+			continue
+		}
+		results = append(results, []model.ChainStep{t.authzFinding(e, matched)})
+		if unprotected[e.File] == nil {
+			unprotected[e.File] = make(map[int]bool)
+		}
+		unprotected[e.File][e.Line] = true
+	}
+	// 规则的 severity_overrides 可以按 Source 端点的授权状态 (auth: none / required) 调整等级
+	t.applyAuthOverrides(endpoints, unprotected)
+
+	for _, chain := range results {
+		t.AddFinding(chain)
+	}
+	return len(results)
+}
+
+// authzFinding 端点的授权缺失发现；matched 是放行该路径的 permitAll 规则，没有匹配的规则时为 nil
+func (t *Tracer) authzFinding(e entrypoints.Endpoint, matched *authzRule) model.ChainStep {
+	rule := model.SinkRule{
+		Name:        "AUTHZ (Missing authorization)",
+		VulnType:    AuthzVulnType,
+		Desc:        "端点没有授权检查",
+		Severity:    "Info",
+		CWE:         "CWE-862",
+		References:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Authorization_Cheat_Sheet.html"},
+		Remediation: "Add @PreAuthorize / @Secured / @RolesAllowed to the handler or its controller, or cover the path with an authorizeHttpRequests rule. Mark intentionally public endpoints in the allowlist.",
+	}
+	step := model.ChainStep{
+		File:       e.File,
+		Line:       e.Line,
+		Func:       e.HandlerName() + "()",
+		Rule:       &rule,
+		SourceKind: model.SourceHTTP,
+		Analysis: []string{
+			fmt.Sprintf("🚨 Matched Rule: %s", rule.Name),
+			fmt.Sprintf("🌐 Endpoint: %s %s", e.Verb(), e.Path),
+			"🔓 No @PreAuthorize / @Secured / @RolesAllowed on the handler or its class",
+		},
+	}
+	if code, err := ReadLine(e.File, e.Line); err == nil {
+		step.Code = strings.TrimSpace(code)
+	}
+	if matched != nil {
+		rel, err := filepath.Rel(t.ProjectRoot, matched.File)
+		if err != nil {
+			rel = matched.File
+		}
+		step.Analysis = append(step.Analysis, fmt.Sprintf("🛡️ Permitted to everyone by `%s` (%s:%d)", matched.Text, filepath.ToSlash(rel), matched.Line+1))
+	} else {
+		step.Analysis = append(step.Analysis, "🛡️ No authorizeHttpRequests rule matches this path")
+	}
+	embedSnippets([]model.ChainStep{step})
+	return step
+}
+
+// parseAuthzRules 提取安全配置类中的 URL 授权规则 (按声明顺序)，不是安全配置类时返回 nil
+func parseAuthzRules(path string, content []byte) []authzRule {
+	text := string(content)
+	if !strings.Contains(text, "SecurityFilterChain") && !strings.Contains(text, "WebSecurityConfigurerAdapter") {
+		return nil
+	}
+	rules := []authzRule{}
+	for _, loc := range authzRuleRe.FindAllStringSubmatchIndex(text, -1) {
+		kind := text[loc[2]:loc[3]]
+		args := text[loc[4]:loc[5]]
+		rule := authzRule{
+			Access: text[loc[6]:loc[7]],
+			Text:   strings.Join(strings.Fields(text[loc[0]:loc[1]]), " "),
+			File:   path,
+			Line:   strings.Count(text[:loc[0]], "\n"),
+		}
+		if m := httpMethodRe.FindStringSubmatch(args); m != nil {
+			rule.Method = m[1]
+		}
+		switch kind {
+		case "anyRequest":
+			rule.Patterns = []*regexp.Regexp{regexp.MustCompile(`.*`)}
+		case "regexMatchers":
+			for _, s := range stringLiteralRe.FindAllStringSubmatch(args, -1) {
+				if re, err := regexp.Compile(s[1]); err == nil {
+					rule.Patterns = append(rule.Patterns, re)
+				}
+			}
+		default:
+			for _, s := range stringLiteralRe.FindAllStringSubmatch(args, -1) {
+				rule.Patterns = append(rule.Patterns, antPattern(s[1]))
+			}
+		}
+		if len(rule.Patterns) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// antPattern 把 Spring 的 Ant 风格路径 ("/api/**", "/users/*") 转为正则
+func antPattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "/**"):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '{':
+			// 配置中的路径变量匹配任意单段
+			if end := strings.IndexByte(pattern[i:], '}'); end != -1 {
+				b.WriteString("[^/]+")
+				i += end
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("/?$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"LSPTracer/internal/events"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// DefaultSinkTimeout 单个 Sink 追踪的默认时间预算
+const DefaultSinkTimeout = 60 * time.Second
+
+const truncatedNote = "⏱ Trace truncated (budget exceeded)"
+
+// sinkBudget 单个 Sink 的追踪状态，通过 context 传给 TraceChain 的所有分支
+type sinkBudget struct {
+	label     string
+	sink      model.ChainStep // Sink 步骤 (统计该 Sink 记录的链路数量)
+	start     time.Time
+	pending   sync.WaitGroup // 该 Sink 派生出的异步追踪分支
+	truncated atomic.Bool
+}
+
+// SinkTiming 单个 Sink 的追踪耗时
+type SinkTiming struct {
+	Label     string
+	Duration  time.Duration
+	Truncated bool
+}
+
+type budgetKey struct{}
+
+// withSinkBudget 为一个 Sink 创建追踪 context；timeout <= 0 表示不限时
+func withSinkBudget(label string, timeout time.Duration) (context.Context, context.CancelFunc, *sinkBudget) {
+	b := &sinkBudget{label: label, start: time.Now()}
+	ctx := context.WithValue(context.Background(), budgetKey{}, b)
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, b
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, b
+}
+
+func budgetFrom(ctx context.Context) *sinkBudget {
+	b, _ := ctx.Value(budgetKey{}).(*sinkBudget)
+	return b
+}
+
+// recordTruncated 预算耗尽时记录已经追踪到的部分链路 (每个 Sink 只记录一次)
+// 部分链路没有到达入口，不记录 Source 的输入信号；结束原因为 TerminationTimeBudget，严格模式下被排除
+func (t *Tracer) recordTruncated(ctx context.Context, stack []model.ChainStep) {
+	if b := budgetFrom(ctx); b != nil && !b.truncated.CompareAndSwap(false, true) {
+		return
+	}
+	if len(stack) == 0 {
+		return
+	}
+
+	chain := append([]model.ChainStep(nil), stack...)
+	last := chain[len(chain)-1]
+	last.Analysis = append(append([]string(nil), last.Analysis...), truncatedNote)
+	last.Termination = model.TerminationTimeBudget
+	chain[len(chain)-1] = last
+	kind, tainted, _ := t.sourceSignals(chain)
+	scoreChain(chain, kind != "", tainted, false)
+	classifySource(chain, kind)
+	embedSnippets(chain)
+
+	t.AddFinding(chain)
+}
+
+// finishSink 记录 Sink 的追踪耗时，并发送 sink_finish 事件
+func (t *Tracer) finishSink(b *sinkBudget) {
+	duration := time.Since(b.start)
+	t.mu.Lock()
+	t.SinkTimings = append(t.SinkTimings, SinkTiming{
+		Label:     b.label,
+		Duration:  duration,
+		Truncated: b.truncated.Load(),
+	})
+	t.mu.Unlock()
+	chains := t.Findings.ChainsAt(b.sink.File, b.sink.Line)
+
+	outcome := events.OutcomeNoChain
+	switch {
+	case b.truncated.Load():
+		outcome = events.OutcomeTruncated
+	case chains > 0:
+		outcome = events.OutcomeTraced
+	}
+	t.Events.Emit(events.Event{
+		Type:       events.TypeSinkFinish,
+		Sink:       b.label,
+		File:       b.sink.File,
+		Line:       b.sink.Line + 1,
+		Outcome:    outcome,
+		Chains:     chains,
+		DurationMs: duration.Milliseconds(),
+	})
+}
+
+// printSlowestSinks 输出耗时最长的 n 个 Sink，方便调整规则或排除目录
+func (t *Tracer) printSlowestSinks(n int) {
+	t.mu.RLock()
+	timings := append([]SinkTiming(nil), t.SinkTimings...)
+	t.mu.RUnlock()
+	if len(timings) == 0 {
+		return
+	}
+
+	sort.Slice(timings, func(i, j int) bool { return timings[i].Duration > timings[j].Duration })
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	color.Cyan("[*] Slowest sinks:")
+	for _, st := range timings {
+		suffix := ""
+		if st.Truncated {
+			suffix = " (truncated)"
+		}
+		fmt.Printf("    %8s  %s%s\n", st.Duration.Round(time.Millisecond), st.Label, suffix)
+	}
+}
+
+// progressMeter 根据最近若干个候选点的处理时间估算剩余时间
+type progressMeter struct {
+	start  time.Time
+	last   time.Time
+	window []time.Duration
+}
+
+const progressWindow = 20
+
+func newProgressMeter() *progressMeter {
+	now := time.Now()
+	return &progressMeter{start: now, last: now}
+}
+
+// tick 记录一个候选点处理完成，返回已用时间和预计剩余时间
+func (p *progressMeter) tick(remaining int) (elapsed, eta time.Duration) {
+	now := time.Now()
+	p.window = append(p.window, now.Sub(p.last))
+	if len(p.window) > progressWindow {
+		p.window = p.window[1:]
+	}
+	p.last = now
+
+	var sum time.Duration
+	for _, d := range p.window {
+		sum += d
+	}
+	avg := sum / time.Duration(len(p.window))
+	return now.Sub(p.start), avg * time.Duration(remaining)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// completedSinks 已经完成追踪的 Sink 数量
+func (t *Tracer) completedSinks() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.SinkTimings)
+}
+
+// TraceSink 在单个 Sink 的时间预算内追踪调用链，等待所有异步分支结束后记录耗时
+// field 非空时 Sink 的参数可能来自该字段，同时追踪同一个类中对它的赋值
+func (t *Tracer) TraceSink(label, file string, line, col int, stack []model.ChainStep, field string) {
+	ctx, cancel, budget := withSinkBudget(label, t.SinkTimeout)
+	defer cancel()
+	if len(stack) > 0 {
+		budget.sink = stack[0]
+	}
+
+	t.TraceChain(ctx, file, line, col, stack, make(map[string]bool))
+	if field != "" && len(stack) > 0 {
+		sink := stack[len(stack)-1]
+		t.traceFieldWrites(ctx, sink.File, sink.Line, field, stack, make(map[string]bool))
+	}
+	budget.pending.Wait()
+	t.finishSink(budget)
+}
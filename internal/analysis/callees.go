@@ -0,0 +1,185 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// DefaultCalleeDepth -direction down 默认展开的调用层数
+const DefaultCalleeDepth = 3
+
+// maxCalleeNodes 单棵调用树的节点上限，超过后不再展开 (工具类方法的调用树可能非常大)
+const maxCalleeNodes = 500
+
+// 调用树中没有展开的原因
+const (
+	calleeRecursive  = "recursive call"
+	calleeDepthLimit = "depth limit"
+	calleeExpanded   = "expanded above"
+)
+
+// callNameRe 代码中的方法调用 (名称后紧跟括号)，在屏蔽了注释和字符串的源码上匹配
+var callNameRe = regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*\(`)
+
+// nonCallWords 后面可以紧跟括号、但不是方法调用的关键字
+var nonCallWords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"synchronized": true, "return": true, "throw": true, "assert": true, "try": true,
+}
+
+// calleeWalker 向下展开调用树: path 打破递归，expanded 保证同一个方法只展开一次，nodes 限制树的大小
+type calleeWalker struct {
+	t        *Tracer
+	rules    []model.SinkRule
+	expanded map[string]int // 已展开的方法 -> 子树中的 Sink 数量
+	nodes    int
+}
+
+// TraceCallees 列出目标行调用的方法，并在项目源码中递归展开被调用的方法 (最多 depth 层)，
+// 命中 Sink 规则 (并通过 LSP 验证) 的调用作为叶子节点；找不到目标行所在的函数时 ok 为 false
+func (t *Tracer) TraceCallees(file string, line, depth int, rules []model.SinkRule) (*model.CalleeNode, bool) {
+	fn, ok := t.GetEnclosingFunction(lsp.ToUri(file), line)
+	if !ok {
+		return nil, false
+	}
+	code, _ := ReadLine(file, line)
+	root := &model.CalleeNode{Func: fn.Name, File: file, Line: line, Code: strings.TrimSpace(code)}
+
+	w := &calleeWalker{t: t, rules: rules, expanded: make(map[string]int)}
+	path := map[string]bool{lineKey(file, fn.SelectionStart): true}
+	root.Children = w.expand(file, line, line, -1, -1, depth, path)
+	return root, true
+}
+
+// expand 返回 file 中 [start, end] 行内的调用；declLine/declCol 是方法名的位置 (声明本身不是调用)
+func (w *calleeWalker) expand(file string, start, end, declLine, declCol, depth int, path map[string]bool) []*model.CalleeNode {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(content), "\n")
+	masked := w.t.masked.lines(file)
+	w.t.Docs.Open(file)
+
+	var children []*model.CalleeNode
+	seen := make(map[string]bool) // 同一个方法体中多次调用同一个方法只列出一次
+	for i := start; i <= end && i < len(lines) && i < len(masked); i++ {
+		// 1. Sink: 复用文本初筛的规则匹配和 LSP 验证
+		sinkMethods := make(map[string]bool)
+		for _, cand := range matchLine(lines, i, w.rules, w.t.TreatEnvAsTaint) {
+			cand.File, cand.Line = file, i
+			sinkMethods[cand.Rule.MethodName] = true
+			if !w.t.verifySink(cand).OK() {
+				continue
+			}
+			rule := cand.Rule
+			children = append(children, &model.CalleeNode{Func: rule.Name, File: file, Line: i, Code: cand.Code, Rule: &rule})
+			w.nodes++
+		}
+
+		// 2. 项目中的方法: definition 指向项目源码中的方法声明时继续展开
+		for _, m := range callNameRe.FindAllStringSubmatchIndex(masked[i], -1) {
+			name := masked[i][m[2]:m[3]]
+			if nonCallWords[name] || sinkMethods[name] {
+				continue
+			}
+			col := utf16Col(masked[i], m[2])
+			if i == declLine && col == declCol {
+				continue
+			}
+			if w.nodes >= maxCalleeNodes {
+				return children
+			}
+			callee, ok := w.resolve(file, i, col)
+			if !ok {
+				continue
+			}
+			defFile := lsp.FromUri(callee.uri)
+			key := lineKey(defFile, callee.fn.SelectionStart)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			node := &model.CalleeNode{Func: callee.fn.Name, File: defFile, Line: callee.fn.SelectionStart, Code: strings.TrimSpace(lines[i])}
+			w.nodes++
+			sinks, done := w.expanded[key]
+			switch {
+			case path[key]:
+				node.Note = calleeRecursive
+			case done:
+				node.Note = calleeExpanded
+				if sinks > 0 {
+					node.Note = fmt.Sprintf("%s, reaches %d sinks", calleeExpanded, sinks)
+				}
+			case depth <= 1:
+				node.Note = calleeDepthLimit
+			default:
+				path[key] = true
+				node.Children = w.expand(defFile, callee.fn.SelectionStart, callee.fn.RangeEnd, callee.fn.SelectionStart, callee.fn.Column, depth-1, path)
+				delete(path, key)
+				w.expanded[key] = node.Sinks()
+			}
+			children = append(children, node)
+		}
+	}
+	return children
+}
+
+type resolvedCallee struct {
+	uri string
+	fn  FunctionInfo
+}
+
+// resolve 用 definition 找到被调用的方法；库方法、测试代码和被排除的路径中的方法不展开
+func (w *calleeWalker) resolve(file string, line, col int) (resolvedCallee, bool) {
+	var locs []lsp.Location
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	err := w.t.Client.Call(ctx, "textDocument/definition", map[string]interface{}{
+		"textDocument": map[string]string{"uri": lsp.ToUri(file)},
+		"position":     lsp.Position{Line: line, Character: col},
+	}, &locs)
+	if err != nil || len(locs) == 0 {
+		return resolvedCallee{}, false
+	}
+
+	loc := locs[0]
+	path := lsp.FromUri(loc.Uri)
+	if !strings.HasPrefix(loc.Uri, "file:") || filepath.Ext(path) != ".java" || !w.t.traceableRef(path) {
+		return resolvedCallee{}, false
+	}
+	fn, ok := w.t.GetEnclosingFunction(loc.Uri, loc.Range.Start.Line)
+	// definition 指向的必须是方法声明本身 (不是变量、字段或类)
+	if !ok || fn.SelectionStart != loc.Range.Start.Line {
+		return resolvedCallee{}, false
+	}
+	if _, _, ok := fn.SourceRange(); !ok {
+		return resolvedCallee{}, false
+	}
+	return resolvedCallee{uri: loc.Uri, fn: fn}, true
+}
+
+// utf16Col 把行内的字节偏移转换为 LSP 的 UTF-16 列号 (byteOffset 的逆运算)
+func utf16Col(line string, byteIdx int) int {
+	units := 0
+	for i, r := range line {
+		if i >= byteIdx {
+			break
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return units
+}
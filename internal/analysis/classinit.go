@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+)
+
+// 类初始化中的代码 (static 块、字段初始化表达式) 没有调用者，"调用者" 是加载或实例化这个类的代码:
+// 从类名的引用中只保留 new X(...) / X::new (以及静态初始化时的 X.member)，类型声明、X.class 等引用不会执行初始化代码
+
+// 类初始化上下文 (FunctionInfo.Initializer)
+const (
+	initStatic   = "<clinit>"     // static 块、static 字段的初始化表达式: 类第一次被使用时执行
+	initInstance = "<init field>" // 实例字段的初始化表达式、实例初始化块: 每次 new 时执行
+)
+
+const classInitNote = "🏗️ Runs during class initialization (`%s`), tracing code that %s `%s`"
+
+var (
+	staticBlockRe = regexp.MustCompile(`^static\s*\{`)
+	staticWordRe  = regexp.MustCompile(`\bstatic\b`)
+	newPrefixRe   = regexp.MustCompile(`\bnew\s+(?:[\w$]+\.)*$`)
+	memberAfterRe = regexp.MustCompile(`^\s*\.\s*([\w$]+)`)
+)
+
+// initializerNote 类初始化上下文的分析信息
+func initializerNote(fn FunctionInfo) string {
+	verb := "instantiates"
+	if fn.Initializer == initStatic {
+		verb = "loads"
+	}
+	return fmt.Sprintf(classInitNote, fn.Name, verb, fn.Class)
+}
+
+// classInitKind 判断类中 line 处的代码属于哪种初始化 (initStatic / initInstance)，不是初始化代码 (类声明、注解等) 时返回空
+// lines 是屏蔽了注释和字符串的源码，classSel 是类名所在的行
+func classInitKind(lines []string, line, classSel, classEnd int) string {
+	if line >= len(lines) || line > classEnd {
+		return ""
+	}
+	// 类体的 "{"
+	open := -1
+	for i := classSel; i <= line; i++ {
+		if strings.Contains(lines[i], "{") {
+			open = i
+			break
+		}
+	}
+	if open == -1 || line <= open {
+		return ""
+	}
+	if staticBlockRe.MatchString(strings.TrimSpace(lines[line])) {
+		return initStatic
+	}
+
+	// 向上查找包含 line 的代码块的 "{": static 块、实例初始化块，或者直接位于类体中 (字段初始化表达式)
+	// lambda、匿名类、数组初始化的 "{" 属于字段初始化表达式的一部分，继续向外查找
+	depth := 0
+	for i := line - 1; i >= open; i-- {
+		text := lines[i]
+		for j := len(text) - 1; j >= 0; j-- {
+			switch text[j] {
+			case '}':
+				depth++
+			case '{':
+				if depth > 0 {
+					depth--
+					continue
+				}
+				if i == open && !strings.Contains(text[:j], "{") {
+					return fieldInitKind(lines, line, open)
+				}
+				before := strings.TrimSpace(text[:j])
+				switch {
+				case strings.HasSuffix(before, "static"):
+					return initStatic
+				case before == "":
+					return initInstance
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// fieldInitKind 直接位于类体中的代码: 所在语句是带初始化表达式的字段声明时，按是否有 static 修饰区分
+// 语句从类体层级上最后一个 ";" 或 "}" 之后开始，字段初始化表达式中 lambda、匿名类的语句不算
+func fieldInitKind(lines []string, line, open int) string {
+	var b strings.Builder
+	depth := 0
+	for i := open; i < line; i++ {
+		text := lines[i]
+		if i == open {
+			text = text[strings.Index(text, "{")+1:]
+		}
+		for j := 0; j < len(text); j++ {
+			b.WriteByte(text[j])
+			switch text[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					b.Reset()
+				}
+			case ';':
+				if depth == 0 {
+					b.Reset()
+				}
+			}
+		}
+		b.WriteByte(' ')
+	}
+	stmt := b.String() + lines[line]
+	eq := strings.Index(stmt, "=")
+	if eq == -1 {
+		return ""
+	}
+	if staticWordRe.MatchString(stmt[:eq]) {
+		return initStatic
+	}
+	return initInstance
+}
+
+// classLoadTarget 追踪位置 (line, col) 是类名时返回类名: 追踪的是类初始化中的代码，只有加载类的引用才是调用点
+func (t *Tracer) classLoadTarget(file string, line, col int) (string, bool) {
+	symbols, err := t.Docs.Symbols(file)
+	if err != nil {
+		return "", false
+	}
+	var find func(nodes []lsp.DocumentSymbol) (string, bool)
+	find = func(nodes []lsp.DocumentSymbol) (string, bool) {
+		for _, node := range nodes {
+			if node.Range.Start.Line > line || node.Range.End.Line < line {
+				continue
+			}
+			if isClassKind(node.Kind) && !isAnonymousClass(node) &&
+				node.SelectionRange.Start.Line == line && node.SelectionRange.Start.Character == col {
+				return node.Name, true
+			}
+			if name, ok := find(node.Children); ok {
+				return name, true
+			}
+		}
+		return "", false
+	}
+	return find(symbols)
+}
+
+// loadsClass 引用 ref 是否会执行类的初始化代码: new X(...)、X::new；static 为 true 时还包括静态成员访问 X.member
+func (t *Tracer) loadsClass(ref lsp.Location, class string, static bool) bool {
+	lines := t.masked.lines(lsp.FromUri(ref.Uri))
+	pos := ref.Range.Start
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return true
+	}
+	text := lines[pos.Line]
+	col := byteOffset(text, pos.Character)
+	if !strings.HasPrefix(text[col:], class) {
+		return true
+	}
+	before, after := text[:col], text[col+len(class):]
+	after = strings.TrimLeft(after, " ")
+	if newPrefixRe.MatchString(before) || strings.HasPrefix(after, "::new") {
+		return true
+	}
+	if m := memberAfterRe.FindStringSubmatch(after); static && m != nil {
+		return m[1] != "class" && m[1] != "this"
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/textutil"
+)
+
+// 行号对应 testdata/classinit/Widgets.java (0 起始)，类名在第 5 行
+func TestClassInitKind(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "classinit", "Widgets.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := textutil.MaskJavaSource(strings.Split(string(content), "\n"))
+	tests := []struct {
+		line int
+		want string
+	}{
+		{4, ""}, // 类上的注解
+		{5, ""}, // 类声明
+		{7, initStatic},
+		{10, initInstance}, // 跨行的字段初始化表达式
+		{13, initInstance}, // 字段初始化表达式中的 lambda
+		{17, initStatic},
+		{20, ""}, // 没有初始化表达式的字段
+		{23, initInstance},
+		{26, initStatic},
+		{27, initStatic},
+		{40, ""},
+	}
+	for _, tt := range tests {
+		if got := classInitKind(lines, tt.line, 5, 32); got != tt.want {
+			t.Errorf("line %d: %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// 初始化块和字段初始化表达式都从类名继续追踪
+func TestFindEnclosingFunctionInitializers(t *testing.T) {
+	symbols := loadSymbols(t, "Widgets.json")
+	tests := []struct {
+		line        int
+		want        string
+		kind        int
+		initializer string
+	}{
+		{23, "Widgets.<init>", symbolKindConstructor, initInstance},
+		{27, "Widgets.<clinit>", symbolKindConstructor, initStatic},
+		{10, "Widgets.<init field>", symbolKindClass, initInstance},
+		{13, "Widgets.<init field>", symbolKindClass, initInstance},
+		{30, "Widgets.<init>()", symbolKindConstructor, ""},
+	}
+	for _, tt := range tests {
+		fn, ok := findEnclosingFunction(symbols, tt.line)
+		if !ok {
+			t.Errorf("line %d: not found", tt.line)
+			continue
+		}
+		if fn.Name != tt.want || fn.Kind != tt.kind || fn.Initializer != tt.initializer {
+			t.Errorf("line %d: %s (kind %d, initializer %q), want %s (kind %d, initializer %q)", tt.line, fn.Name, fn.Kind, fn.Initializer, tt.want, tt.kind, tt.initializer)
+		}
+		if tt.initializer != "" && (fn.SelectionStart != 5 || fn.Column != 13) {
+			t.Errorf("line %d: traced from %d:%d, want the class name at 5:13", tt.line, fn.SelectionStart, fn.Column)
+		}
+	}
+}
+
+// 类名的引用中只有执行初始化代码的才是调用点: new X()、X::new，静态初始化时还包括 X.member
+func TestLoadsClass(t *testing.T) {
+	tracer := NewTracer(nil, t.TempDir(), "light")
+	file, err := filepath.Abs(filepath.Join("testdata", "classinit", "Caller.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := func(line, col int) lsp.Location {
+		return lsp.Location{Uri: lsp.ToUri(file), Range: lsp.Range{Start: lsp.Position{Line: line, Character: col}}}
+	}
+	tests := []struct {
+		name                string
+		ref                 lsp.Location
+		instance, staticRef bool
+	}{
+		{"variable type", ref(4, 8), false, false},
+		{"new", ref(4, 24), true, true},
+		{"type argument", ref(5, 17), false, false},
+		{"constructor reference", ref(5, 30), true, true},
+		{"static method", ref(6, 8), false, true},
+		{"class literal", ref(7, 21), false, false},
+		{"qualified new", ref(8, 35), true, true},
+	}
+	for _, tt := range tests {
+		if got := tracer.loadsClass(tt.ref, "Widgets", false); got != tt.instance {
+			t.Errorf("%s: loadsClass(instance) = %v, want %v", tt.name, got, tt.instance)
+		}
+		if got := tracer.loadsClass(tt.ref, "Widgets", true); got != tt.staticRef {
+			t.Errorf("%s: loadsClass(static) = %v, want %v", tt.name, got, tt.staticRef)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// 已有 Eclipse 配置的复用 (-reuse-config)
+// 真实的 mvn eclipse:eclipse / VS Code 生成的 .classpath 带有依赖 (lib/con)，比我们只含源码目录的配置更准确；
+// 这里只补充缺少的源码目录，其余条目 (包括未知的属性和子元素) 原样保留
+
+const javaNature = "org.eclipse.jdt.core.javanature"
+
+// eclipseProject .project 中用到的部分
+type eclipseProject struct {
+	XMLName xml.Name `xml:"projectDescription"`
+	Name    string   `xml:"name"`
+	Natures []string `xml:"natures>nature"`
+}
+
+// eclipseClasspath .classpath 文件
+type eclipseClasspath struct {
+	XMLName xml.Name         `xml:"classpath"`
+	Entries []classpathEntry `xml:"classpathentry"`
+}
+
+// classpathEntry 一个 classpathentry，所有属性和子元素 (e.g. <attributes>) 原样保留
+type classpathEntry struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner string     `xml:",innerxml"`
+}
+
+func (e classpathEntry) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (e classpathEntry) Kind() string { return e.attr("kind") }
+func (e classpathEntry) Path() string { return e.attr("path") }
+
+func newSrcEntry(path string) classpathEntry {
+	return classpathEntry{Attrs: []xml.Attr{
+		{Name: xml.Name{Local: "kind"}, Value: "src"},
+		{Name: xml.Name{Local: "path"}, Value: path},
+	}}
+}
+
+// parseClasspath 解析 .classpath 内容
+func parseClasspath(data []byte) (*eclipseClasspath, error) {
+	var cp eclipseClasspath
+	if err := xml.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Marshal 序列化为 .classpath 文件内容 (每个条目一行，没有子元素时自闭合，与 Eclipse 生成的格式一致)
+func (cp *eclipseClasspath) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<classpath>\n")
+	for _, e := range cp.Entries {
+		buf.WriteString("\t<classpathentry")
+		for _, a := range e.Attrs {
+			fmt.Fprintf(&buf, ` %s="`, a.Name.Local)
+			if err := xml.EscapeText(&buf, []byte(a.Value)); err != nil {
+				return nil, err
+			}
+			buf.WriteString(`"`)
+		}
+		if strings.TrimSpace(e.Inner) == "" {
+			buf.WriteString("/>\n")
+			continue
+		}
+		fmt.Fprintf(&buf, ">%s</classpathentry>\n", e.Inner)
+	}
+	buf.WriteString("</classpath>\n")
+	return buf.Bytes(), nil
+}
+
+// srcPaths 已有的源码目录 (kind="src" 且不是引用其它项目的 "/project")
+func (cp *eclipseClasspath) srcPaths() map[string]bool {
+	paths := make(map[string]bool)
+	for _, e := range cp.Entries {
+		if e.Kind() == "src" && !strings.HasPrefix(e.Path(), "/") {
+			paths[filepath.Clean(filepath.FromSlash(e.Path()))] = true
+		}
+	}
+	return paths
+}
+
+// AddSources 把缺少的源码目录 (相对项目根目录，/ 分隔) 插入到已有 src 条目之后，返回新增的数量
+func (cp *eclipseClasspath) AddSources(rels []string) int {
+	existing := cp.srcPaths()
+	var added []classpathEntry
+	for _, rel := range rels {
+		key := filepath.Clean(filepath.FromSlash(rel))
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		added = append(added, newSrcEntry(filepath.ToSlash(rel)))
+	}
+	if len(added) == 0 {
+		return 0
+	}
+	insert := 0
+	for i, e := range cp.Entries {
+		if e.Kind() == "src" {
+			insert = i + 1
+		}
+	}
+	entries := append([]classpathEntry(nil), cp.Entries[:insert]...)
+	entries = append(entries, added...)
+	cp.Entries = append(entries, cp.Entries[insert:]...)
+	return len(added)
+}
+
+// loadEclipseConfig 读取并校验项目根目录下已有的 .project / .classpath
+// 要求 .project 带有 Java nature，.classpath 至少有一个源码目录
+func loadEclipseConfig(projectRoot string) (*eclipseClasspath, error) {
+	data, err := os.ReadFile(filepath.Join(projectRoot, ".project"))
+	if err != nil {
+		return nil, err
+	}
+	var project eclipseProject
+	if err := xml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf(".project: %v", err)
+	}
+	hasNature := false
+	for _, n := range project.Natures {
+		if n == javaNature {
+			hasNature = true
+		}
+	}
+	if !hasNature {
+		return nil, fmt.Errorf(".project has no Java nature")
+	}
+
+	data, err = os.ReadFile(filepath.Join(projectRoot, ".classpath"))
+	if err != nil {
+		return nil, err
+	}
+	cp, err := parseClasspath(data)
+	if err != nil {
+		return nil, fmt.Errorf(".classpath: %v", err)
+	}
+	if len(cp.srcPaths()) == 0 {
+		return nil, fmt.Errorf(".classpath has no source entries")
+	}
+	return cp, nil
+}
+
+// ReuseEclipseConfig 复用项目中已有的 Eclipse 配置: 保留依赖条目，补充扫描到的源码目录后写回
+// 配置不存在或无效时返回 false 和原因，调用方回退到重新生成
+func ReuseEclipseConfig(projectRoot string, followSymlinks bool) (bool, error) {
+	cp, err := loadEclipseConfig(projectRoot)
+	if err != nil {
+		return false, err
+	}
+
+	srcDirs, err := scanSourceDirs(projectRoot, followSymlinks)
+	if err != nil {
+		return false, err
+	}
+	absRoot, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return false, err
+	}
+	var rels []string
+	for _, dir := range srcDirs {
+		rel, err := filepath.Rel(absRoot, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+
+	added := cp.AddSources(rels)
+	if added > 0 {
+		data, err := cp.Marshal()
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(filepath.Join(projectRoot, ".classpath"), data, 0644); err != nil {
+			return false, err
+		}
+	}
+	color.Green("[+] Reusing existing Eclipse config (%d entries, %d source roots added)", len(cp.Entries), added)
+	return true, nil
+}
@@ -0,0 +1,157 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func readClasspathFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "classpath", "maven.classpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func classpathKeys(cp *eclipseClasspath) []string {
+	var keys []string
+	for _, e := range cp.Entries {
+		keys = append(keys, e.Kind()+" "+e.Path())
+	}
+	return keys
+}
+
+// m2e 生成的 .classpath 解析后再序列化，内容逐字节不变 (子元素、转义和未知属性都保留)
+func TestClasspathRoundTrip(t *testing.T) {
+	data := readClasspathFixture(t)
+	cp, err := parseClasspath(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Entries) != 7 || cp.Entries[4].Path() != "lib/legacy & patched.jar" {
+		t.Fatalf("entries = %v", classpathKeys(cp))
+	}
+	out, err := cp.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(data) {
+		t.Errorf("round trip changed the file:\n%s\nwant:\n%s", out, data)
+	}
+}
+
+// 缺少的源码目录插入到最后一个 src 条目之后；已有的目录 (写法不同) 和引用其它项目的 "/common" 不重复添加
+func TestClasspathAddSources(t *testing.T) {
+	cp, err := parseClasspath(readClasspathFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := cp.AddSources([]string{"src/main/java", "./src/main/java/", "src/test/java", "target/generated-sources/annotations", "src/test/java", "common"})
+	if added != 3 {
+		t.Errorf("added %d entries, want 3", added)
+	}
+	want := []string{
+		"src src/main/java",
+		"src src/main/resources",
+		"con org.eclipse.jdt.launching.JRE_CONTAINER/org.eclipse.jdt.internal.debug.ui.launcher.StandardVMType/JavaSE-17",
+		"con org.eclipse.m2e.MAVEN2_CLASSPATH_CONTAINER",
+		"lib lib/legacy & patched.jar",
+		"src /common",
+		"src src/test/java",
+		"src target/generated-sources/annotations",
+		"src common",
+		"output target/classes",
+	}
+	if got := classpathKeys(cp); !slices.Equal(got, want) {
+		t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if cp.AddSources([]string{"src/test/java"}) != 0 {
+		t.Error("second AddSources added a duplicate")
+	}
+}
+
+// writeProjectFiles 写入 .project (natures) 和 .classpath
+func writeProjectFiles(t *testing.T, root string, natures []string, classpath []byte) {
+	t.Helper()
+	project := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<projectDescription>\n\t<name>demo</name>\n\t<natures>\n"
+	for _, n := range natures {
+		project += "\t\t<nature>" + n + "</nature>\n"
+	}
+	project += "\t</natures>\n</projectDescription>\n"
+	if err := os.WriteFile(filepath.Join(root, ".project"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if classpath != nil {
+		if err := os.WriteFile(filepath.Join(root, ".classpath"), classpath, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// 复用有效的配置: 保留依赖条目，补充项目中扫描到的源码目录
+func TestReuseEclipseConfig(t *testing.T) {
+	root := t.TempDir()
+	writeProjectFiles(t, root, []string{"org.eclipse.m2e.core.maven2Nature", javaNature}, readClasspathFixture(t))
+	for _, f := range []string{"src/main/java/com/example/App.java", "src/test/java/com/example/AppTest.java"} {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package com.example;\n\nclass X {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reused, err := ReuseEclipseConfig(root, false)
+	if !reused || err != nil {
+		t.Fatalf("ReuseEclipseConfig = %v, %v", reused, err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".classpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := parseClasspath(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := classpathKeys(cp)
+	for _, want := range []string{"con org.eclipse.m2e.MAVEN2_CLASSPATH_CONTAINER", "lib lib/legacy & patched.jar", "src src/main/java", "src src/test/java"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("merged .classpath has no %q:\n%s", want, data)
+		}
+	}
+	if strings.Count(string(data), `path="src/main/java"`) != 1 {
+		t.Errorf("src/main/java duplicated:\n%s", data)
+	}
+}
+
+// 配置不存在或无效时不复用，调用方重新生成
+func TestReuseEclipseConfigInvalid(t *testing.T) {
+	noSources := []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<classpath>\n\t<classpathentry kind=\"con\" path=\"org.eclipse.m2e.MAVEN2_CLASSPATH_CONTAINER\"/>\n</classpath>\n")
+	tests := []struct {
+		name      string
+		natures   []string
+		classpath []byte
+		want      string
+	}{
+		{"no java nature", []string{"org.eclipse.m2e.core.maven2Nature"}, readClasspathFixture(t), "no Java nature"},
+		{"no source entries", []string{javaNature}, noSources, "no source entries"},
+		{"broken classpath", []string{javaNature}, []byte("<classpath><classpathentry"), ".classpath"},
+		{"missing classpath", []string{javaNature}, nil, ".classpath"},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeProjectFiles(t, root, tt.natures, tt.classpath)
+		reused, err := ReuseEclipseConfig(root, false)
+		if reused || err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ReuseEclipseConfig = %v, %v; want an error mentioning %q", tt.name, reused, err, tt.want)
+		}
+	}
+	if reused, err := ReuseEclipseConfig(t.TempDir(), false); reused || err == nil {
+		t.Errorf("no config: ReuseEclipseConfig = %v, %v", reused, err)
+	}
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"path/filepath"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// ClassifyCodeKinds 记录链路中每个步骤所在代码的类别 (生产/测试/示例)，报告按 Sink 的类别决定是否列出
+// 项目之外的文件 (e.g. 解压的依赖源码) 不分类
+func ClassifyCodeKinds(chains [][]model.ChainStep, projectRoot string, paths model.CodePaths) {
+	root := filepath.Clean(projectRoot)
+	for _, stack := range chains {
+		for i := range stack {
+			rel, err := filepath.Rel(root, stack[i].File)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			stack[i].CodeKind = model.ClassifyCode(filepath.ToSlash(rel), paths)
+		}
+	}
+}
+
+// ExcludeTestCode 去掉 Sink 位于测试/示例代码中的链路，返回保留的链路和按类别统计的去掉的数量
+func ExcludeTestCode(chains [][]model.ChainStep) ([][]model.ChainStep, map[string]int) {
+	var kept [][]model.ChainStep
+	excluded := make(map[string]int)
+	for _, stack := range chains {
+		if len(stack) > 0 && (stack[0].CodeKind == model.CodeTest || stack[0].CodeKind == model.CodeSample) {
+			excluded[stack[0].CodeKind]++
+			continue
+		}
+		kept = append(kept, stack)
+	}
+	return kept, excluded
+}
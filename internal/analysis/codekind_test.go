@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// 每个步骤按所在文件分类，项目之外的文件 (解压的依赖源码) 不分类
+func TestClassifyCodeKinds(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "deps", "Lib.java")
+	chains := [][]model.ChainStep{{
+		{File: filepath.Join(root, "src", "main", "java", "App.java")},
+		{File: filepath.Join(root, "src", "test", "java", "AppTest.java")},
+		{File: filepath.Join(root, "samples", "src", "main", "java", "Sample.java")},
+		{File: outside},
+	}}
+	ClassifyCodeKinds(chains, root+string(filepath.Separator), model.CodePaths{})
+	want := []string{model.CodeMain, model.CodeTest, model.CodeSample, ""}
+	for i, step := range chains[0] {
+		if step.CodeKind != want[i] {
+			t.Errorf("step %d (%s): %q, want %q", i, step.File, step.CodeKind, want[i])
+		}
+	}
+}
+
+// 只按 Sink (第一个步骤) 的类别排除，Source 在测试代码中的链路保留
+func TestExcludeTestCode(t *testing.T) {
+	chain := func(sink, source string) []model.ChainStep {
+		return []model.ChainStep{{Func: "sink", CodeKind: sink}, {Func: "source", CodeKind: source}}
+	}
+	chains := [][]model.ChainStep{
+		chain(model.CodeMain, model.CodeMain),
+		chain(model.CodeTest, model.CodeMain),
+		chain(model.CodeMain, model.CodeTest),
+		chain(model.CodeSample, model.CodeSample),
+		chain(model.CodeTest, model.CodeTest),
+		chain("", ""),
+	}
+	kept, excluded := ExcludeTestCode(chains)
+	if len(kept) != 3 || kept[0][0].CodeKind != model.CodeMain || kept[1][1].CodeKind != model.CodeTest || kept[2][0].CodeKind != "" {
+		t.Errorf("kept %d chains: %+v", len(kept), kept)
+	}
+	if len(excluded) != 2 || excluded[model.CodeTest] != 2 || excluded[model.CodeSample] != 1 {
+		t.Errorf("excluded = %v, want TEST:2 SAMPLE:1", excluded)
+	}
+}
@@ -0,0 +1,182 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+var assignLHSRe = regexp.MustCompile(`(?:^|[\s(;])([a-zA-Z_$][\w$]*)\s*=[^=]`)
+
+// findCompositeCandidates 在每个方法内检查多条件规则
+// 方法范围来自 documentSymbol；拿不到符号时把整个文件当作一个范围
+func (t *Tracer) findCompositeCandidates(rules []model.CompositeRule) []candidate {
+	if len(rules) == 0 {
+		return nil
+	}
+	var results []candidate
+
+	t.walkJavaFiles(func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+
+		// 先用 Trigger 做文本初筛，避免为每个文件请求符号
+		var active []model.CompositeRule
+		for _, rule := range rules {
+			for _, re := range rule.TriggerRes {
+				if re.Match(content) {
+					active = append(active, rule)
+					break
+				}
+			}
+		}
+		if len(active) == 0 {
+			return
+		}
+
+		lines := strings.Split(string(content), "\n")
+		ranges := [][2]int{{0, len(lines) - 1}}
+		if symbols, err := t.Docs.Symbols(path); err == nil {
+			if methods := methodRanges(symbols); len(methods) > 0 {
+				ranges = methods
+			}
+		}
+
+		seen := make(map[int]bool)
+		for _, rule := range active {
+			for _, r := range ranges {
+				for _, c := range matchComposite(rule, lines, r[0], r[1]) {
+					if seen[c.Line] {
+						continue
+					}
+					seen[c.Line] = true
+					c.File = path
+					results = append(results, c)
+				}
+			}
+		}
+	})
+	return results
+}
+
+// methodRanges 返回所有方法/构造器的行范围 (不包含嵌套在方法内部的方法，它们属于外层方法的范围)
+func methodRanges(symbols []lsp.DocumentSymbol) [][2]int {
+	var ranges [][2]int
+	var walk func(nodes []lsp.DocumentSymbol)
+	walk = func(nodes []lsp.DocumentSymbol) {
+		for _, node := range nodes {
+			switch node.Kind {
+			case symbolKindMethod, symbolKindFunction, symbolKindConstructor:
+				ranges = append(ranges, [2]int{node.Range.Start.Line, node.Range.End.Line})
+			default:
+				walk(node.Children)
+			}
+		}
+	}
+	walk(symbols)
+	return ranges
+}
+
+// matchComposite 在 lines[start..end] 范围内匹配一条多条件规则
+func matchComposite(rule model.CompositeRule, lines []string, start, end int) []candidate {
+	if start < 0 {
+		start = 0
+	}
+	if end >= len(lines) {
+		end = len(lines) - 1
+	}
+	if start > end {
+		return nil
+	}
+	body := lines[start : end+1]
+
+	// 1. 必须出现 Trigger
+	triggerLine := -1
+	for i, line := range body {
+		if matchAny(rule.TriggerRes, line) {
+			triggerLine = start + i
+			break
+		}
+	}
+	if triggerLine == -1 {
+		return nil
+	}
+
+	// 2. 方法内有防护代码则跳过
+	text := strings.Join(body, "\n")
+	if matchAny(rule.GuardRes, text) {
+		return nil
+	}
+
+	// 3. 跟踪 Source 赋值的局部变量，找到使用它们的 Sink 操作
+	tracked := make(map[string]bool)
+	var results []candidate
+	for i, line := range body {
+		code := strings.TrimSpace(line)
+		if code == "" || strings.HasPrefix(code, "//") || strings.HasPrefix(code, "*") {
+			continue
+		}
+		usesSource := rule.SourceRe.MatchString(code) || usesTracked(code, tracked)
+
+		if col := sinkCall(rule.SinkRes, code); usesSource && col != -1 {
+			results = append(results, candidate{
+				Line: start + i,
+				Col:  col,
+				Code: code,
+				Rule: rule.AsSinkRule(),
+				Notes: []string{fmt.Sprintf("📦 %s read at line %d: `%s`",
+					simpleName(rule.ClassName), triggerLine+1, strings.TrimSpace(lines[triggerLine]))},
+				Composite: true,
+			})
+			continue
+		}
+		if usesSource {
+			if m := assignLHSRe.FindStringSubmatch(code); m != nil {
+				tracked[m[1]] = true
+			}
+		}
+	}
+	return results
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sinkCall 返回 code 中第一个 Sink 调用的列，没有时返回 -1
+// 与单行规则的候选点一致: 相对去掉缩进后的代码，指向匹配的起点 (e.g. ".invoke(" 的 "."，"new File(" 的 "new")
+func sinkCall(res []*regexp.Regexp, code string) int {
+	for _, re := range res {
+		if loc := re.FindStringIndex(code); loc != nil {
+			return loc[0]
+		}
+	}
+	return -1
+}
+
+func usesTracked(code string, tracked map[string]bool) bool {
+	for name := range tracked {
+		if regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(code) {
+			return true
+		}
+	}
+	return false
+}
+
+func simpleName(className string) string {
+	if idx := strings.LastIndex(className, "."); idx != -1 {
+		return className[idx+1:]
+	}
+	return className
+}
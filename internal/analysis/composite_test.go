@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// reflectionRules 内置规则中反射/类加载相关的单行规则
+func reflectionRules() []model.SinkRule {
+	var rules []model.SinkRule
+	for _, r := range model.GetBuiltinRules() {
+		if r.MethodName == "forName" || (r.MethodName == "invoke" && r.ClassName == "java.lang.reflect.Method") {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func compositeRule(t *testing.T, name string) model.CompositeRule {
+	t.Helper()
+	for _, r := range model.GetBuiltinCompositeRules() {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("composite rule %q not found", name)
+	return model.CompositeRule{}
+}
+
+// reflectFixture 返回 testdata/reflect 中 fixture 的路径和内容
+func reflectFixture(t *testing.T, name string) (string, []string) {
+	t.Helper()
+	path := filepath.Join("testdata", "reflect", name)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, strings.Split(string(content), "\n")
+}
+
+// positions 把候选点列为 "行:方法名" (行号从 1 开始)，便于比较
+func positions(cands []candidate) []string {
+	var out []string
+	for _, c := range cands {
+		kind := c.Rule.MethodName
+		if c.Composite {
+			kind = "composite"
+		}
+		out = append(out, strconv.Itoa(c.Line+1)+":"+kind)
+	}
+	return out
+}
+
+func TestReflectionRulesSafe(t *testing.T) {
+	path, lines := reflectFixture(t, "SafeReflection.java")
+
+	// 常量类名 (字面量和 static final 常量) 的 Class.forName 被跳过，Method.invoke 只作为 Low 报告
+	cands := scanFileCandidates(path, reflectionRules(), false)
+	if got := strings.Join(positions(cands), " "); got != "15:invoke" {
+		t.Fatalf("candidates = %s, want 15:invoke", got)
+	}
+	if cands[0].Rule.Severity != "Low" {
+		t.Errorf("invoke severity = %s, want Low", cands[0].Rule.Severity)
+	}
+
+	// 方法名是常量: 组合规则不触发
+	rule := compositeRule(t, "RCE (reflective invoke)")
+	if got := matchComposite(rule, lines, 12, 15); len(got) != 0 {
+		t.Errorf("composite = %v, want none", positions(got))
+	}
+}
+
+func TestReflectionRulesUnsafe(t *testing.T) {
+	path, lines := reflectFixture(t, "UnsafeReflection.java")
+
+	singles := scanFileCandidates(path, reflectionRules(), false)
+	if got, want := strings.Join(positions(singles), " "), "10:forName 12:invoke 16:forName 16:invoke"; got != want {
+		t.Fatalf("candidates = %s, want %s", got, want)
+	}
+
+	rule := compositeRule(t, "RCE (reflective invoke)")
+	var composites []candidate
+	for _, r := range [][2]int{{6, 12}, {14, 16}} {
+		for _, c := range matchComposite(rule, lines, r[0], r[1]) {
+			c.File = path
+			composites = append(composites, c)
+		}
+	}
+	if got, want := strings.Join(positions(composites), " "), "12:composite 16:composite"; got != want {
+		t.Fatalf("composites = %s, want %s", got, want)
+	}
+	for _, c := range composites {
+		if c.Rule.Severity != "Medium" || !strings.HasPrefix(c.Code[c.Col:], ".invoke(") {
+			t.Errorf("composite at line %d: severity %s, call %q", c.Line+1, c.Rule.Severity, c.Code[c.Col:])
+		}
+	}
+
+	// 组合规则只认领 invoke 调用: 同一行链式调用中的 Class.forName 仍然保留
+	merged := withComposites(singles, composites)
+	if got, want := strings.Join(positions(merged), " "), "10:forName 16:forName 12:composite 16:composite"; got != want {
+		t.Errorf("merged = %s, want %s", got, want)
+	}
+}
+
+func TestSinkCall(t *testing.T) {
+	rule := compositeRule(t, "RCE (reflective invoke)")
+	tests := []struct {
+		code string
+		want int
+	}{
+		{"return m.invoke(target);", 8},
+		{"return Class.forName(c).getMethod(m).invoke(null);", 36},
+		{"m.getName();", -1},
+	}
+	for _, tt := range tests {
+		if got := sinkCall(rule.SinkRes, tt.code); got != tt.want {
+			t.Errorf("sinkCall(%q) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"os"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// sourceSignals 检查链路的 Source: 入口类型 (不是框架入口时为空)、是否有参数或隐式输入
+// known 为 false 表示找不到 Source 所在的函数，无法判断输入
+func (t *Tracer) sourceSignals(stack []model.ChainStep) (kind string, tainted, known bool) {
+	if len(stack) == 0 {
+		return "", false, false
+	}
+	source := stack[len(stack)-1]
+	kind = t.entryKind(source.File, source.Line)
+	tainted, known = t.sourceInput(stack, kind)
+	return kind, tainted, known
+}
+
+// sourceInput 检查 Source 所在的函数是否有参数或读取了隐式输入，kind 是已经判断出的入口类型
+func (t *Tracer) sourceInput(stack []model.ChainStep, kind string) (tainted, known bool) {
+	if len(stack) == 0 {
+		return false, false
+	}
+	source := stack[len(stack)-1]
+	if fn, ok := t.GetEnclosingFunction(lsp.ToUri(source.File), source.Line); ok {
+		tainted = t.checkSourceValidity(source.File, fn.SelectionStart, fn.RangeEnd)
+		// Struts2 Action 的 execute() 没有参数，请求参数通过 setter 绑定到字段
+		if !tainted && kind == model.SourceHTTP {
+			if content, err := os.ReadFile(source.File); err == nil {
+				src, _ := detectServletSource(strings.Split(string(content), "\n"), fn)
+				tainted = len(src.Setters) > 0
+			}
+		}
+		return tainted, true
+	}
+	return false, false
+}
+
+// recordSourceInput 在 Source 步骤上记录输入信号，报告阶段的严格模式过滤使用
+func recordSourceInput(chain []model.ChainStep, tainted, known bool) {
+	if len(chain) == 0 {
+		return
+	}
+	input := model.InputUnknown
+	if known {
+		input = model.InputNone
+		if tainted {
+			input = model.InputTainted
+		}
+	}
+	chain[len(chain)-1].SourceInput = input
+}
+
+// classifySource 在链路的 Source 步骤上记录入口类型 (不是入口时为 model.SourceUnknown)
+func classifySource(chain []model.ChainStep, kind string) {
+	if len(chain) == 0 {
+		return
+	}
+	if kind == "" {
+		kind = model.SourceUnknown
+	}
+	chain[len(chain)-1].SourceKind = kind
+}
+
+// scoreChain 把 Source 相关的信号和链路中的净化函数写入链路 Sink 步骤的可信度 (复制一份，Sink 步骤会被多条链路共享)
+func scoreChain(chain []model.ChainStep, entry, tainted, complete bool) {
+	if len(chain) == 0 || chain[0].Confidence == nil {
+		return
+	}
+	c := *chain[0].Confidence
+	c.FrameworkEntry = entry
+	c.TaintedInput = tainted
+	c.Complete = complete && len(chain) > 1 && chain[0].Unverified == ""
+	c.Sanitizer = sanitizedBy(chain)
+	chain[0].Confidence = &c
+}
@@ -0,0 +1,40 @@
+package analysis
+
+import (
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// scoreChain 在副本上记录 Source 信号和净化函数，共享同一个 Sink 步骤的其它链路不受影响
+func TestScoreChain(t *testing.T) {
+	shared := &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByDefinition}, DataFlow: true}
+	sink := model.ChainStep{Func: "exec", Confidence: shared}
+	through := model.ChainStep{Func: "clean", Analysis: []string{sanitizedPrefix + " StringEscapeUtils.escapeHtml4"}}
+	source := model.ChainStep{Func: "handle"}
+
+	full := []model.ChainStep{sink, source}
+	scoreChain(full, true, true, true)
+	if c := full[0].Confidence; !c.FrameworkEntry || !c.TaintedInput || !c.Complete || c.Sanitizer != "" || c.Level() != model.ConfidenceHigh {
+		t.Errorf("full chain: %+v (%s)", *c, c.Level())
+	}
+
+	sanitized := []model.ChainStep{sink, through, source}
+	scoreChain(sanitized, true, true, true)
+	if c := sanitized[0].Confidence; c.Sanitizer != "StringEscapeUtils.escapeHtml4" || c.Level() != model.ConfidenceMedium {
+		t.Errorf("sanitized chain: %+v (%s)", *c, c.Level())
+	}
+
+	// 只有 Sink 一步、或 Sink 没有调用上下文时不算完整
+	orphan := []model.ChainStep{sink}
+	scoreChain(orphan, false, false, true)
+	unverified := []model.ChainStep{{Confidence: shared, Unverified: "no call context"}, source}
+	scoreChain(unverified, true, true, true)
+	if orphan[0].Confidence.Complete || unverified[0].Confidence.Complete {
+		t.Error("orphan sink or unverified chain scored as complete")
+	}
+
+	if shared.FrameworkEntry || shared.TaintedInput || shared.Complete || shared.Sanitizer != "" {
+		t.Errorf("shared sink confidence was modified: %+v", *shared)
+	}
+}
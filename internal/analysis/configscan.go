@@ -0,0 +1,314 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"LSPTracer/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件检查: web.xml、Spring XML 和 application.properties / yml 中的危险配置 (config_rules)
+// 只读取文件内容，不需要 LSP，因此在启动语言服务器之前执行；结果是单步链路
+
+// configEntry 配置文件中的一个值 (Line 从 0 开始)
+type configEntry struct {
+	Key   string // properties / yml: 规范化的键；XML: 元素路径 (/a/b 或 /a/b/@attr)
+	Value string
+	Line  int
+}
+
+// configFile 解析后的配置文件
+type configFile struct {
+	entries []configEntry
+	xml     bool
+	root    int // XML 根元素所在行，missing 规则的报告位置
+}
+
+// ScanConfig 按 config_rules 检查项目中的配置文件，结果作为单步链路追加到 Results，返回新增的结果数量
+// 解析失败的文件跳过 (e.g. 含模板占位符的 XML)
+func (t *Tracer) ScanConfig(rules []model.ConfigRule) int {
+	var results [][]model.ChainStep
+
+	applies := func(name string) bool {
+		for i := range rules {
+			if rules[i].AppliesTo(name) {
+				return true
+			}
+		}
+		return false
+	}
+	t.walkFiles(applies, func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		file, err := parseConfigFile(path, content)
+		if err != nil {
+			return
+		}
+		lines := strings.Split(string(content), "\n")
+		rel, err := filepath.Rel(t.ProjectRoot, path)
+		if err != nil {
+			rel = path
+		}
+
+		for i := range rules {
+			r := &rules[i]
+			if !r.AppliesTo(filepath.Base(path)) || (r.XPath != "") != file.xml {
+				continue
+			}
+			found := false
+			for _, e := range file.entries {
+				if !configEntryMatches(r, e.Key) {
+					continue
+				}
+				found = true
+				if strings.Contains(e.Value, "${") || !r.Violates(e.Value) {
+					continue
+				}
+				detail := fmt.Sprintf("⚙️ `%s` = `%s`", configRuleTarget(r), strings.TrimSpace(e.Value))
+				results = append(results, []model.ChainStep{configStep(r, path, rel, lines, e.Line, detail)})
+			}
+			if !found && r.Missing {
+				detail := fmt.Sprintf("⚙️ `%s` is not set", configRuleTarget(r))
+				results = append(results, []model.ChainStep{configStep(r, path, rel, lines, file.root, detail)})
+			}
+		}
+	})
+
+	for _, chain := range results {
+		embedSnippets(chain)
+		t.AddFinding(chain)
+	}
+	return len(results)
+}
+
+// configStep 配置问题的单步链路
+func configStep(r *model.ConfigRule, path, rel string, lines []string, line int, detail string) model.ChainStep {
+	rule := r.AsSinkRule()
+	step := model.ChainStep{
+		File: path,
+		Line: line,
+		Func: filepath.ToSlash(rel),
+		Rule: &rule,
+		Analysis: []string{
+			fmt.Sprintf("🚨 Matched Rule: %s", rule.Name),
+			detail,
+		},
+	}
+	if line >= 0 && line < len(lines) {
+		step.Code = strings.TrimSpace(lines[line])
+	}
+	return step
+}
+
+func configRuleTarget(r *model.ConfigRule) string {
+	if r.XPath != "" {
+		return r.XPath
+	}
+	return r.Key
+}
+
+// configEntryMatches 配置项是否是规则检查的键或 XML 路径
+func configEntryMatches(r *model.ConfigRule, key string) bool {
+	if r.Key != "" {
+		return normalizeConfigKey(r.Key) == key
+	}
+	return xpathMatches(r.XPath, key)
+}
+
+// normalizeConfigKey 规范化配置项的键: 小写并去掉 - 和 _ (Spring 的宽松绑定)
+func normalizeConfigKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(key)))
+}
+
+// xpathMatches 元素路径 path (/a/b/c 或 /a/b/@attr) 是否匹配 xpath
+// "/a/b" 从根元素开始完全匹配，"//b/c" 匹配任意位置结尾的路径，"*" 匹配任意一个元素
+func xpathMatches(xpath, path string) bool {
+	anywhere := strings.HasPrefix(xpath, "//")
+	want := strings.Split(strings.Trim(xpath, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(got) < len(want) || (!anywhere && len(got) != len(want)) {
+		return false
+	}
+	got = got[len(got)-len(want):]
+	for i := range want {
+		if want[i] == "*" && !strings.HasPrefix(got[i], "@") {
+			continue
+		}
+		if want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseConfigFile 按扩展名解析配置文件
+func parseConfigFile(path string, content []byte) (configFile, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return parseXMLConfig(content)
+	case ".properties":
+		return configFile{entries: parseProperties(string(content))}, nil
+	case ".yml", ".yaml":
+		entries, err := parseYAMLConfig(content)
+		return configFile{entries: entries}, err
+	}
+	return configFile{}, fmt.Errorf("unsupported config file %s", path)
+}
+
+// parseXMLConfig 记录每个元素的文本和每个属性的值 (忽略命名空间前缀)
+func parseXMLConfig(content []byte) (configFile, error) {
+	file := configFile{xml: true, root: -1}
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	dec.Strict = false
+	// 声明了其它编码 (e.g. ISO-8859-1) 的文件按原样读取，规则关心的键和值都是 ASCII
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	type open struct {
+		path string
+		line int
+		text strings.Builder
+	}
+	var stack []*open
+	for {
+		// 读取前的位置就是下一个 token 的起点
+		line, _ := dec.InputPos()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return file, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].path
+			} else if file.root == -1 {
+				file.root = line - 1
+			}
+			el := &open{path: parent + "/" + tok.Name.Local, line: line - 1}
+			stack = append(stack, el)
+			for _, attr := range tok.Attr {
+				file.entries = append(file.entries, configEntry{Key: el.path + "/@" + attr.Name.Local, Value: attr.Value, Line: el.line})
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			file.entries = append(file.entries, configEntry{Key: el.path, Value: strings.TrimSpace(el.text.String()), Line: el.line})
+		}
+	}
+	if file.root == -1 {
+		file.root = 0
+	}
+	return file, nil
+}
+
+// parseProperties 解析 .properties (key=value / key: value / key value，支持 \ 续行)
+func parseProperties(content string) []configEntry {
+	var entries []configEntry
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+		}
+		sep := strings.IndexAny(line, "=: \t")
+		if sep == -1 {
+			entries = append(entries, configEntry{Key: normalizeConfigKey(line), Line: start})
+			continue
+		}
+		value := strings.TrimLeft(line[sep+1:], " \t")
+		if line[sep] == ' ' || line[sep] == '\t' {
+			value = strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(value, "="), ":"), " \t")
+		}
+		entries = append(entries, configEntry{Key: normalizeConfigKey(line[:sep]), Value: value, Line: start})
+	}
+	return entries
+}
+
+// continued 行尾是否是续行符 (奇数个反斜杠)
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// parseYAMLConfig 把 YAML (可以有多个文档，e.g. 按 profile 分隔) 展开为点分隔的键
+// 标量列表合并为逗号分隔的值 (与 properties 中的写法一致)，其它列表按下标展开 (key[0].name)
+func parseYAMLConfig(content []byte) ([]configEntry, error) {
+	var entries []configEntry
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		for _, n := range doc.Content {
+			flattenYAML(n, "", &entries)
+		}
+	}
+	return entries, nil
+}
+
+func flattenYAML(n *yaml.Node, prefix string, entries *[]configEntry) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenYAML(n.Content[i+1], key, entries)
+		}
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(n.Content))
+		for i, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				flattenYAML(item, prefix+"["+strconv.Itoa(i)+"]", entries)
+				continue
+			}
+			values = append(values, item.Value)
+		}
+		if len(values) > 0 {
+			*entries = append(*entries, configEntry{Key: normalizeConfigKey(prefix), Value: strings.Join(values, ","), Line: n.Line - 1})
+		}
+	case yaml.AliasNode:
+		if n.Alias != nil {
+			flattenYAML(n.Alias, prefix, entries)
+		}
+	case yaml.ScalarNode:
+		if prefix != "" {
+			*entries = append(*entries, configEntry{Key: normalizeConfigKey(prefix), Value: n.Value, Line: n.Line - 1})
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package analysis
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// 控制台上的链路输出: 追踪是并发的，链路通过 channel 交给一个 goroutine 依次打印，不会互相穿插。
+// 很长的链路只打印两端的步骤，完整打印的链路达到上限后只打印一行摘要；完整内容总是写入报告
+// 追踪过程 (找到的调用者、字段写入) 只在 Verbose 时经过同一个队列打印
+
+// 控制台输出的默认上限
+const (
+	DefaultConsoleSteps  = 12 // 超过该步数的链路折叠中间的步骤
+	DefaultConsoleChains = 50 // 完整打印的链路数量
+)
+
+// consoleQueueSize 等待打印的链路数量，队列满时记录链路的追踪等待打印
+const consoleQueueSize = 64
+
+// ConsolePrinter 串行打印链路 (由一个 goroutine 消费队列)
+type ConsolePrinter struct {
+	MaxSteps  int    // 链路超过该步数时只打印 Source 和两端各 K 步 (<= 0 表示不折叠)
+	MaxChains int    // 完整打印的链路数量上限，之后每条链路只打印一行 (<= 0 表示不限)
+	Root      string // 显示相对路径
+	Verbose   bool   // 同时打印追踪过程 (-verbose)
+
+	once    sync.Once
+	queue   chan consoleChain
+	pending sync.WaitGroup
+	printed int // 只在打印 goroutine 中访问
+}
+
+// consoleChain 队列中的一项: 链路，或 Verbose 时的一行追踪过程 (line 非空)
+type consoleChain struct {
+	stack  []model.ChainStep
+	reason string
+	line   string
+}
+
+// NewConsolePrinter 使用默认上限的打印组件
+func NewConsolePrinter(root string) *ConsolePrinter {
+	return &ConsolePrinter{MaxSteps: DefaultConsoleSteps, MaxChains: DefaultConsoleChains, Root: root}
+}
+
+// Print 把链路加入打印队列 (stack 不能再被修改)，reason 是链路的结束原因
+func (p *ConsolePrinter) Print(stack []model.ChainStep, reason string) {
+	p.enqueue(consoleChain{stack: stack, reason: reason})
+}
+
+// Progress 把一行追踪过程加入打印队列，不是 Verbose 时忽略
+func (p *ConsolePrinter) Progress(format string, args ...interface{}) {
+	if !p.Verbose {
+		return
+	}
+	p.enqueue(consoleChain{line: fmt.Sprintf(format, args...)})
+}
+
+func (p *ConsolePrinter) enqueue(c consoleChain) {
+	p.once.Do(func() {
+		p.queue = make(chan consoleChain, consoleQueueSize)
+		go p.run()
+	})
+	p.pending.Add(1)
+	p.queue <- c
+}
+
+// Flush 等待队列中的链路打印完
+func (p *ConsolePrinter) Flush() {
+	p.pending.Wait()
+}
+
+func (p *ConsolePrinter) run() {
+	for c := range p.queue {
+		// 清除进度行
+		fmt.Print("\r                                                                 \r")
+		if c.line != "" {
+			fmt.Println(c.line)
+			p.pending.Done()
+			continue
+		}
+		if p.MaxChains > 0 && p.printed >= p.MaxChains {
+			if p.printed == p.MaxChains {
+				color.Yellow("[*] %d chains printed; further chains are listed in one line each (full detail in the report).", p.MaxChains)
+			}
+			p.printLine(c.stack, c.reason)
+		} else {
+			p.printChain(c.stack, c.reason)
+		}
+		p.printed++
+		p.pending.Done()
+	}
+}
+
+func (p *ConsolePrinter) displayPath(file string) string {
+	if rel, err := filepath.Rel(p.Root, file); err == nil {
+		return rel
+	}
+	return file
+}
+
+// printLine 一行摘要: 规则、Sink 位置、Source 和步数
+func (p *ConsolePrinter) printLine(stack []model.ChainStep, reason string) {
+	sink, source := stack[0], stack[len(stack)-1]
+	rule := "Sink"
+	if sink.Rule != nil {
+		rule = sink.Rule.Name
+	}
+	fmt.Printf("%s %s at %s:%d <- %s (%d steps, %s)\n",
+		color.New(color.FgRed, color.Bold).Sprint("🔥 [TRACE]"), rule,
+		p.displayPath(sink.File), sink.Line+1, source.QualifiedFunc(), len(stack), reason)
+}
+
+// printChain 从 Source 到 Sink 打印链路，超过 MaxSteps 时折叠中间的步骤
+func (p *ConsolePrinter) printChain(stack []model.ChainStep, reason string) {
+	boldRed := color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	white := color.New(color.FgWhite).SprintFunc()
+	faint := color.New(color.Faint).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	// 保留 Source 之后的 keep 步和 Sink 一侧的 keep 步，即下标 [keep+1, len-keep) 之外 (从 Source 数起)
+	keep, hidden := len(stack), 0
+	if p.MaxSteps > 0 && len(stack) > p.MaxSteps {
+		keep = max((p.MaxSteps-1)/2, 1)
+		hidden = max(len(stack)-1-2*keep, 0)
+	}
+
+	fmt.Println()
+	fmt.Println(strings.Repeat(faint("-"), 60))
+	// 追踪是并发的，这里的顺序不稳定；最终编号在生成报告时按位置排序后分配
+	fmt.Printf("%s Found Vulnerability Chain #? (%d steps, numbered in report)\n", boldRed("🔥 [TRACE]"), len(stack))
+	fmt.Println(strings.Repeat(faint("-"), 60))
+
+	// 逆序打印 (从 Source -> Sink)
+	for i := len(stack) - 1; i >= 0; i-- {
+		pos := len(stack) - 1 - i
+		if hidden > 0 && pos > keep && pos <= keep+hidden {
+			if pos == keep+1 {
+				fmt.Printf("     %s\n", faint(fmt.Sprintf("… %d intermediate steps (see report)", hidden)))
+				fmt.Printf("        %s\n", faint("↓"))
+			}
+			continue
+		}
+		step := stack[i]
+
+		var tag string
+		if i == len(stack)-1 {
+			tag = boldRed("🟥 SOURCE")
+		} else if i == 0 {
+			tag = boldRed("💀 SINK  ")
+		} else {
+			tag = yellow("🔸 STEP  ")
+		}
+
+		fmt.Printf(" %s: %s\n", tag, white(step.QualifiedFunc()))
+		if i == len(stack)-1 {
+			fmt.Printf("     %s: %s\n", faint("Ended"), yellow(reason))
+		}
+
+		fileInfo := fmt.Sprintf("%s:%d", p.displayPath(step.File), step.Line+1)
+		fmt.Printf("     %s: %s\n", faint("File"), cyan(fileInfo))
+
+		if step.Code != "" {
+			cleanCode := truncateString(strings.TrimSpace(step.Code), 100)
+			fmt.Printf("     %s: `%s`\n", faint("Code"), white(cleanCode))
+		}
+
+		for _, info := range step.Analysis {
+			var coloredInfo string
+			if strings.Contains(info, "🟢") {
+				coloredInfo = green(info)
+			} else if strings.Contains(info, "🚨") {
+				coloredInfo = boldRed(info)
+			} else {
+				coloredInfo = yellow(info)
+			}
+			fmt.Printf("     %s\n", coloredInfo)
+		}
+
+		if i > 0 {
+			fmt.Printf("        %s\n", faint("↓"))
+		}
+	}
+	fmt.Println(strings.Repeat(faint("-"), 60))
+	fmt.Println()
+}
@@ -0,0 +1,51 @@
+package analysis
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// captureConsole 收集 fn 期间打印到标准输出的内容
+func captureConsole(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	defer func() { os.Stdout = saved }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// 追踪过程只在 Verbose 时打印，并且和链路经过同一个队列 (按加入的顺序，不会穿插)
+func TestConsolePrinterProgress(t *testing.T) {
+	chain := []model.ChainStep{{File: "/p/A.java", Func: "sink()"}, {File: "/p/B.java", Func: "source()"}}
+	p := &ConsolePrinter{Root: "/p"}
+	out := captureConsole(t, func() {
+		p.Progress("    [↑] Found caller: %s", "hidden()")
+		p.Verbose = true
+		p.Progress("    [↑] Found caller: %s", "source()")
+		p.Print(chain, model.TerminationNoCallers)
+		p.Progress("    [↑] Field write: %s", "after()")
+		p.Flush()
+	})
+	if strings.Contains(out, "hidden()") {
+		t.Error("progress printed without Verbose")
+	}
+	caller, chainAt, field := strings.Index(out, "Found caller: source()"), strings.Index(out, "Found Vulnerability Chain"), strings.Index(out, "Field write: after()")
+	if caller == -1 || chainAt == -1 || field == -1 || !(caller < chainAt && chainAt < field) {
+		t.Errorf("output order: caller %d, chain %d, field write %d\n%s", caller, chainAt, field, out)
+	}
+}
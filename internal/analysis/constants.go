@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 参数表达式的取值分类 (候选点常量过滤和调用点分析共用)
+const (
+	valueConstant    = "constant"    // 字面量、static final 常量、枚举常量
+	valueEnvironment = "environment" // System.getenv / System.getProperty: 部署方控制，是否可信取决于威胁模型
+	valueDynamic     = "dynamic"     // 变量、方法调用等
+)
+
+const (
+	constantNote    = "🟢 Constant: `%s`"
+	environmentNote = "🌐 Environment-derived: `%s`"
+)
+
+var (
+	envReadRe = regexp.MustCompile(`^(?:java\.lang\.)?System\s*\.\s*(?:getenv|getProperty)\s*\((.*)\)$`)
+	// 枚举常量或其它类的常量 (Mode.FAST、Config.PING_COMMAND，可带 .name() / .toString())
+	enumConstRe = regexp.MustCompile(`^(?:[A-Z]\w*\.)+[A-Z][A-Z0-9_]*(?:\.(?:name|toString)\(\))?$`)
+)
+
+// maxConstantDepth 常量引用常量时最多解析的层数
+const maxConstantDepth = 4
+
+// classifyExpr 判断表达式的取值来源；字符串拼接的各部分分别判断，任一部分是动态的则整体是动态的
+// 标识符按同一文件中的 static final 字段解析 (findConstantField)
+func classifyExpr(expr string, lines []string) string {
+	return classifyExprDepth(expr, lines, 0)
+}
+
+func classifyExprDepth(expr string, lines []string, depth int) string {
+	expr = strings.TrimSpace(expr)
+	// 模板源码通常包装在 StringReader 中: new StringReader("...")
+	if m := stringReaderRe.FindStringSubmatch(expr); m != nil {
+		expr = m[1]
+	}
+	if isStrictConstant(expr) {
+		return valueConstant
+	}
+
+	result := valueConstant
+	// 参数列表: 每个参数分别判断 (e.g. getForObject(url, String.class) 中的 url)
+	if args := splitArgs(expr); len(args) > 1 {
+		for _, arg := range args {
+			switch classifyExprDepth(arg, lines, depth) {
+			case valueDynamic:
+				return valueDynamic
+			case valueEnvironment:
+				result = valueEnvironment
+			}
+		}
+		return result
+	}
+	for _, part := range splitTopLevel(expr, '+') {
+		part = strings.TrimSpace(part)
+		switch {
+		case isStrictConstant(part), enumConstRe.MatchString(part):
+		case envReadRe.MatchString(part):
+			// 变量名/默认值本身必须是常量: System.getenv(name) 中的 name 可能来自外部
+			args := envReadRe.FindStringSubmatch(part)[1]
+			for _, arg := range splitArgs(args) {
+				if classifyExprDepth(arg, lines, depth+1) != valueConstant {
+					return valueDynamic
+				}
+			}
+			result = valueEnvironment
+		case isVar(part) && depth < maxConstantDepth:
+			value, ok := findConstantField(lines, part)
+			if !ok {
+				return valueDynamic
+			}
+			switch classifyExprDepth(value, lines, depth+1) {
+			case valueDynamic:
+				return valueDynamic
+			case valueEnvironment:
+				result = valueEnvironment
+			}
+		default:
+			return valueDynamic
+		}
+	}
+	return result
+}
+
+// classNote 返回取值分类的分析信息 (动态值没有说明)
+func classNote(class, expr string) string {
+	switch class {
+	case valueConstant:
+		return fmt.Sprintf(constantNote, expr)
+	case valueEnvironment:
+		return fmt.Sprintf(environmentNote, expr)
+	}
+	return ""
+}
+
+// classifyFieldArg 用 documentSymbol 在 Sink 所在的类中查找参数引用的 final 字段，返回其初始值的分类
+// 补充文本匹配: 初始值跨行、同名字段位于其它嵌套类等情况；参数不是字段或字段不是 final 时返回 valueDynamic
+func (t *Tracer) classifyFieldArg(cand candidate) string {
+	name := strings.TrimPrefix(strings.TrimSpace(cand.Arg), "this.")
+	if !isVar(name) || t.Docs == nil {
+		return valueDynamic
+	}
+	symbols, err := t.Docs.Symbols(cand.File)
+	if err != nil {
+		return valueDynamic
+	}
+	_, field, ok := findFieldClass(symbols, cand.Line, name)
+	if !ok {
+		return valueDynamic
+	}
+	content, err := os.ReadFile(cand.File)
+	if err != nil {
+		return valueDynamic
+	}
+	lines := strings.Split(string(content), "\n")
+	var decl []string
+	for i := field.Range.Start.Line; i <= field.Range.End.Line && i < len(lines); i++ {
+		decl = append(decl, strings.TrimSpace(lines[i]))
+	}
+	text := strings.Join(decl, " ")
+	if !regexp.MustCompile(`\bfinal\b`).MatchString(text) {
+		return valueDynamic
+	}
+	_, value, ok := strings.Cut(text, "=")
+	if !ok {
+		return valueDynamic
+	}
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), ";"))
+	return classifyExpr(value, lines)
+}
@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+func constantsFixture(t *testing.T) (string, []string) {
+	t.Helper()
+	path := filepath.Join("testdata", "constants", "Commands.java")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, strings.Split(string(content), "\n")
+}
+
+func TestClassifyExpr(t *testing.T) {
+	_, lines := constantsFixture(t)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`"ping -c 1 localhost"`, valueConstant},
+		{`PING_COMMAND`, valueConstant},
+		{`FULL_COMMAND`, valueConstant}, // 常量引用常量
+		{`PREFIX + "-c 3" + PING_COMMAND`, valueConstant},
+		{`Mode.FAST`, valueConstant},
+		{`Commands.Mode.SLOW.name()`, valueConstant},
+		{`new StringReader("hello")`, valueConstant},
+		{`System.getenv("CMD")`, valueEnvironment},
+		{`java.lang.System.getProperty("cmd", "ls")`, valueEnvironment},
+		{`ENV_COMMAND`, valueEnvironment}, // static final 字段的初始值来自环境变量
+		{`PREFIX + System.getenv("HOST")`, valueEnvironment},
+		{`"url", System.getenv("TARGET")`, valueEnvironment}, // 参数列表
+		{`System.getenv(name)`, valueDynamic},                // 变量名来自外部
+		{`PREFIX + host`, valueDynamic},
+		{`"url", host`, valueDynamic},
+		{`mutable`, valueDynamic}, // 不是 final
+		{`LOOP_A`, valueDynamic},  // 互相引用，超过解析层数
+		{`request.getParameter("cmd")`, valueDynamic},
+	}
+	for _, tt := range tests {
+		if got := classifyExpr(tt.expr, lines); got != tt.want {
+			t.Errorf("classifyExpr(%s) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+// 候选点的常量过滤: 常量参数总是跳过，环境变量参数只在 -treat-env-as-taint 时保留 (带说明)
+func TestMatchLineEnvironment(t *testing.T) {
+	_, lines := constantsFixture(t)
+	var rules []model.SinkRule
+	for _, r := range model.GetBuiltinRules() {
+		if r.Name == "RCE (Runtime.exec)" {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		t.Fatal("no builtin Runtime.exec rule")
+	}
+	scan := func(envTaint bool) map[int][]string {
+		got := map[int][]string{}
+		for i := range lines {
+			for _, c := range matchLine(lines, i, rules, envTaint) {
+				got[i] = append([]string{}, c.Notes...)
+			}
+		}
+		return got
+	}
+
+	dynamic := map[int][]string{19: {}, 20: {}, 21: {}, 23: {}}
+	if got := scan(false); !reflect.DeepEqual(got, dynamic) {
+		t.Errorf("default candidates = %v, want %v", got, dynamic)
+	}
+	withEnv := map[int][]string{
+		17: {"🌐 Environment-derived: `System.getenv(\"CMD\")`"},
+		18: {"🌐 Environment-derived: `ENV_COMMAND`"},
+		19: {}, 20: {}, 21: {}, 23: {},
+	}
+	if got := scan(true); !reflect.DeepEqual(got, withEnv) {
+		t.Errorf("-treat-env-as-taint candidates = %v, want %v", got, withEnv)
+	}
+}
+
+// 调用点分析在 Analysis 中标出取值分类 (参数本身或局部变量的初始值)
+func TestAnalyzeCallSiteClassification(t *testing.T) {
+	path, _ := constantsFixture(t)
+	fn := FunctionInfo{Symbol: "dispatch(Commands, String)", Class: "Commands"}
+	tests := []struct {
+		line int
+		want string
+	}{
+		{27, "🟢 Constant: `PING_COMMAND, \"\"`"},
+		{28, "🌐 Environment-derived: `System.getenv(\"CMD\")`"},
+		{30, "🌐 Environment-derived: `System.getProperty(\"cmd\", \"ls\")`"},
+		{31, "⚠️ Variable Definition: Method Parameter `host`"},
+	}
+	for _, tt := range tests {
+		res := AnalyzeCallSite(path, tt.line, fn)
+		found := false
+		for _, flow := range res.DataFlow {
+			found = found || flow == tt.want
+		}
+		if !found {
+			t.Errorf("line %d: data flow %q, want %q", tt.line, res.DataFlow, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// RuleCandidates 一条规则在文本初筛中匹配到的候选点
+type RuleCandidates struct {
+	Rule       string
+	Candidates []PreviewCandidate
+}
+
+// PreviewCandidate -dry-run 列出的候选点 (Line 从 1 开始)
+type PreviewCandidate struct {
+	File string
+	Line int
+	Code string
+}
+
+// PreviewCandidates 只执行文本初筛 (不需要语言服务器)，按规则分组返回候选点:
+// 候选点多的规则在前，没有命中的规则也列出 (数量为 0)
+// 组合规则需要 LSP 的方法范围，不在预览中
+func (t *Tracer) PreviewCandidates(rules []model.SinkRule) []RuleCandidates {
+	byRule := make(map[string]*RuleCandidates)
+	var groups []*RuleCandidates
+	for _, rule := range rules {
+		if byRule[rule.Name] == nil {
+			byRule[rule.Name] = &RuleCandidates{Rule: rule.Name}
+			groups = append(groups, byRule[rule.Name])
+		}
+	}
+	for _, cand := range t.findCandidates(rules) {
+		g := byRule[cand.Rule.Name]
+		if g == nil {
+			g = &RuleCandidates{Rule: cand.Rule.Name}
+			byRule[cand.Rule.Name] = g
+			groups = append(groups, g)
+		}
+		g.Candidates = append(g.Candidates, PreviewCandidate{File: cand.File, Line: cand.Line + 1, Code: strings.TrimSpace(cand.Code)})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Candidates) != len(groups[j].Candidates) {
+			return len(groups[i].Candidates) > len(groups[j].Candidates)
+		}
+		return groups[i].Rule < groups[j].Rule
+	})
+	out := make([]RuleCandidates, len(groups))
+	for i, g := range groups {
+		out[i] = *g
+	}
+	return out
+}
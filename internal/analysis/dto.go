@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DTO 的 getter: Controller 绑定 @RequestBody OrderRequest req 后把 req 传下去，Sink 使用 req.getPath()。
+// 变量回溯只认识变量名，req.getPath() 会被当作不透明的方法调用；接收者是方法参数或字段、
+// 类型是项目中的简单 bean (有对应的字段或 Lombok 生成 getter) 时，把值视为来自接收者
+
+// getterRe 无参 getter 调用: req.getPath()、this.form.isAdmin() (接收者不能是其它表达式的成员)
+var getterRe = regexp.MustCompile(`(?:^|[^\w$.])((?:this\s*\.\s*)?([a-z_$][\w$]*)\s*\.\s*((?:get|is)[A-Z][\w$]*)\s*\(\s*\))`)
+
+// lombokGetterRe 为所有字段生成 getter 的 Lombok 注解
+var lombokGetterRe = regexp.MustCompile(`(?m)^\s*@(?:lombok\.)?(?:Data|Getter|Value)\b`)
+
+var importDeclRe = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+?)(\.\*)?\s*;`)
+
+// beanGetter 调用点参数中对 DTO 的 getter 调用
+type beanGetter struct {
+	Call     string // req.getPath()
+	Receiver string // req
+	Property string // path
+	Type     string // 接收者的类型 (简单类名)
+	Param    bool   // 接收者是方法参数，否则是字段
+}
+
+// notes 写入调用点的 Analysis；接收者是方法参数时值直接来自参数 (污点)，字段由 traceFieldWrites 继续追踪
+func (g beanGetter) notes() []string {
+	if g.Param {
+		return []string{
+			fmt.Sprintf("⚠️ Variable Definition: Method Parameter `%s` via getter `%s`", g.Receiver, g.Call),
+			fmt.Sprintf("📦 Getter on bound request object `%s` (property `%s`)", g.Type, g.Property),
+		}
+	}
+	return []string{fmt.Sprintf("📦 Getter on field `%s` of type `%s` (property `%s`)", g.Receiver, g.Type, g.Property)}
+}
+
+// findBeanGetter 在参数表达式中查找接收者为方法参数或字段的 getter 调用，
+// 接收者的类型必须能在源码目录中找到并且是简单 bean
+func findBeanGetter(path string, lines []string, line int, fn FunctionInfo, args string) (beanGetter, bool) {
+	ref := methodRefOf(fn)
+	for _, m := range getterRe.FindAllStringSubmatchIndex(args, -1) {
+		receiver, method := args[m[4]:m[5]], args[m[6]:m[7]]
+		if isKeyword(receiver) || isLocalVar(lines, line, fn, receiver) {
+			continue // 局部变量由变量回溯处理
+		}
+		g := beanGetter{
+			Call:     args[m[2]:m[3]],
+			Receiver: receiver,
+			Property: beanProperty(method),
+		}
+		if signature, ok := enclosingSignature(lines, line, ref); ok {
+			if open := strings.Index(signature, ref.Name+"("); open != -1 {
+				g.Type = declaredType(signature[open+len(ref.Name):], receiver)
+				g.Param = g.Type != ""
+			}
+		}
+		if g.Type == "" {
+			g.Type = fieldType(lines, receiver)
+		}
+		if g.Type == "" {
+			continue
+		}
+		if content, ok := projectClassSource(path, lines, g.Type); ok && isSimpleBean(content, g.Property) {
+			g.Type = simpleTypeName(g.Type)
+			return g, true
+		}
+	}
+	return beanGetter{}, false
+}
+
+// isLocalVar 变量在调用点所在的方法中被赋值 (方法之外的赋值是字段的初始值)
+func isLocalVar(lines []string, line int, fn FunctionInfo, name string) bool {
+	def := findDefinitionLine(lines, line, name)
+	if def == -1 {
+		return false
+	}
+	start, _, ok := fn.SourceRange()
+	return !ok || def >= start
+}
+
+// beanProperty getter 名对应的属性名 (JavaBeans 规则): getPath -> path，getURL -> URL
+func beanProperty(method string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(method, "get"), "is")
+	if name == "" || len(name) > 1 && unicode.IsUpper(rune(name[1])) {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// declaredType 在声明文本中查找变量 name 的类型 (忽略泛型参数和注解)，找不到时为空
+func declaredType(decl, name string) string {
+	re := regexp.MustCompile(`([A-Za-z_$][\w.$]*)\s*(?:<[^()]*>)?\s*(?:\[\s*\])*\s+` + regexp.QuoteMeta(name) + `\s*[,);=]`)
+	m := re.FindStringSubmatch(decl)
+	if m == nil || isKeyword(m[1]) {
+		return ""
+	}
+	return m[1]
+}
+
+// fieldType 在文件中查找字段 name 的声明类型
+func fieldType(lines []string, name string) string {
+	for _, text := range lines {
+		if typ := fieldDeclType(text, name); typ != "" {
+			return typ
+		}
+	}
+	return ""
+}
+
+// fieldDeclType 一行中字段 name 的声明类型，只看 "(" 之前的部分 (排除方法声明和参数)
+func fieldDeclType(text, name string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "return ") {
+		return ""
+	}
+	text, _, _ = strings.Cut(text, "(")
+	return declaredType(text, name)
+}
+
+func simpleTypeName(typ string) string {
+	if idx := strings.LastIndex(typ, "."); idx != -1 {
+		return typ[idx+1:]
+	}
+	return typ
+}
+
+// projectClassSource 类型的源文件内容 (见 projectClassFile)
+func projectClassSource(path string, lines []string, typ string) (string, bool) {
+	file, ok := projectClassFile(path, lines, typ)
+	if !ok {
+		return "", false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+// projectClassFile 按 import 和当前文件的包推断类型的源文件 (同一源码目录下)
+// 找不到源文件的类型 (JDK、第三方库) 不是项目中的类
+func projectClassFile(path string, lines []string, typ string) (string, bool) {
+	pkg := ""
+	for _, text := range lines {
+		if m := packageDeclRe.FindStringSubmatch(text); m != nil {
+			pkg = m[1]
+			break
+		}
+	}
+	dir := filepath.Dir(path)
+	pkgDir := filepath.FromSlash(strings.ReplaceAll(pkg, ".", "/"))
+	if pkgDir != "" && !strings.HasSuffix(dir, string(filepath.Separator)+pkgDir) {
+		return "", false
+	}
+	root := strings.TrimSuffix(dir, pkgDir)
+
+	var fqns []string
+	if strings.Contains(typ, ".") {
+		fqns = append(fqns, typ)
+	} else {
+		source := strings.Join(lines, "\n")
+		for _, m := range importDeclRe.FindAllStringSubmatch(source, -1) {
+			switch {
+			case m[2] != "":
+				fqns = append(fqns, m[1]+"."+typ)
+			case strings.HasSuffix(m[1], "."+typ):
+				fqns = append([]string{m[1]}, fqns...)
+			}
+		}
+		if pkg != "" {
+			fqns = append(fqns, pkg+"."+typ)
+		} else {
+			fqns = append(fqns, typ)
+		}
+	}
+	for _, fqn := range fqns {
+		file := filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(fqn, ".", "/"))+".java")
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// isSimpleBean 类是否为 property 提供了简单的 getter: 声明了同名字段，或由 Lombok 生成 getter
+func isSimpleBean(content, property string) bool {
+	if lombokGetterRe.MatchString(content) {
+		return true
+	}
+	for _, text := range strings.Split(content, "\n") {
+		if fieldDeclType(text, property) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// @RequestBody 绑定的 DTO: Sink 参数中 req.getPath() 的值来自方法参数 req (污点)，
+// 字段的 getter 交给字段写入的追踪；不是简单 bean 属性的 getter、局部变量和项目外的类型不处理
+func TestAnalyzeCallSiteBeanGetter(t *testing.T) {
+	path := filepath.Join("testdata", "dto", "com", "acme", "ExportService.java")
+	method := func(symbol string, start, end int) FunctionInfo {
+		return FunctionInfo{Symbol: symbol, Class: "ExportService", Kind: symbolKindMethod, SelectionStart: start, RangeStart: start, RangeEnd: end}
+	}
+	tests := []struct {
+		name  string
+		line  int
+		fn    FunctionInfo
+		flows []string
+		field string
+	}{
+		{"parameter getter", 12, method("save(OrderRequest)", 11, 13), []string{
+			"⚠️ Variable Definition: Method Parameter `req` via getter `req.getPath()`",
+			"📦 Getter on bound request object `OrderRequest` (property `path`)",
+		}, ""},
+		{"field getter (Lombok)", 20, method("fallback()", 19, 21), []string{
+			"📦 Getter on field `defaults` of type `ExportForm` (property `target`)",
+		}, "defaults"},
+		{"computed getter", 16, method("checksum(OrderRequest)", 15, 17), nil, ""},
+		{"local variable", 25, method("copy(OrderRequest)", 23, 26), nil, ""},
+		{"not a project class", 29, method("name(String)", 28, 30), nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := AnalyzeCallSite(path, tt.line, tt.fn)
+			var flows []string
+			for _, f := range res.DataFlow {
+				if strings.HasPrefix(f, "📦") || strings.HasPrefix(f, "⚠️ Variable Definition: Method Parameter") {
+					flows = append(flows, f)
+				}
+			}
+			if !reflect.DeepEqual(flows, tt.flows) {
+				t.Errorf("data flow = %q, want getter notes %q", res.DataFlow, tt.flows)
+			}
+			if res.Field != tt.field {
+				t.Errorf("field = %q, want %q", res.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestBeanProperty(t *testing.T) {
+	for method, want := range map[string]string{"getPath": "path", "isAdmin": "admin", "getURL": "URL", "getX": "x"} {
+		if got := beanProperty(method); got != want {
+			t.Errorf("beanProperty(%s) = %s, want %s", method, got, want)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package analysis
 
 import (
 	"bufio"
-	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,109 +13,123 @@ import (
 // GenerateEclipseConfig 自动生成 .project 和 .classpath 文件
 // 这里的核心思路是：找到所有的源码目录，把它们加入到 .classpath 中
 // 这样 JDT.LS 就会直接读取源码，而不去解析 pom.xml
-func GenerateEclipseConfig(projectRoot string) error {
-	projectFile := filepath.Join(projectRoot, ".project")
-	classpathFile := filepath.Join(projectRoot, ".classpath")
-
-	// 1. 扫描所有的 source root (src/main/java 等)
-	srcDirs, err := scanSourceDirs(projectRoot)
+// followSymlinks 为 true 时也会进入符号链接指向的目录 (按真实路径去重)
+// 多模块项目中每个模块生成独立的项目，返回各模块目录 (作为 LSP 的 workspace folders)；单模块时返回 nil
+// level 为项目的 Java 语言级别 (DetectJavaLevel)，决定 JRE 容器
+func GenerateEclipseConfig(projectRoot string, followSymlinks bool, level JavaLevel) ([]string, error) {
+	srcDirs, modules, err := PlanEclipseConfig(projectRoot, followSymlinks)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(srcDirs) == 0 {
-		// 如果找不到标准目录，就把根目录当作源码目录（兜底）
-		srcDirs = append(srcDirs, "") 
+	// 2. 多模块: 每个模块一个项目 (注意：这里不包含 maven nature)，模块之间的依赖写成项目引用
+	if len(modules) > 1 {
+		var folders []string
+		for _, m := range modules {
+			if err := writeEclipseProject(m.Dir, m.Name, m.SrcDirs, m.Deps, level); err != nil {
+				return nil, err
+			}
+			folders = append(folders, m.Dir)
+		}
+		color.Green("[+] Generated lightweight Eclipse config (Modules: %d, Source Roots: %d)", len(modules), len(srcDirs))
+		return folders, nil
 	}
 
-	// 2. 生成 .project 内容 (注意：这里不包含 maven nature)
-	projectName := filepath.Base(projectRoot)
-	projectContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<projectDescription>
-	<name>%s</name>
-	<comment></comment>
-	<projects>
-	</projects>
-	<buildSpec>
-		<buildCommand>
-			<name>org.eclipse.jdt.core.javabuilder</name>
-			<arguments>
-			</arguments>
-		</buildCommand>
-	</buildSpec>
-	<natures>
-		<nature>org.eclipse.jdt.core.javanature</nature>
-	</natures>
-</projectDescription>
-`, projectName)
-
-	// 3. 生成 .classpath 内容
-	var sb strings.Builder
-	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-	sb.WriteString(`<classpath>` + "\n")
-	
-	// 添加所有扫描到的源码目录
-	for _, src := range srcDirs {
-		// 转换为相对路径
-		rel, _ := filepath.Rel(projectRoot, src)
-		if rel == "." { rel = "" }
-		// kind="src" 告诉 JDTLS 这里面是源码，建立索引！
-		sb.WriteString(fmt.Sprintf(`	<classpathentry kind="src" path="%s"/>` + "\n", rel))
+	// 3. 单模块: 根目录下一个项目
+	if err := writeEclipseProject(projectRoot, filepath.Base(projectRoot), srcDirs, nil, level); err != nil {
+		return nil, err
 	}
 
-	// 添加基本的 JRE 容器 (让 String, System 等基础类能识别)
-	sb.WriteString(`	<classpathentry kind="con" path="org.eclipse.jdt.launching.JRE_CONTAINER/org.eclipse.jdt.internal.debug.ui.launcher.StandardVMType/JavaSE-1.8"/>` + "\n")
-	sb.WriteString(`	<classpathentry kind="output" path="bin"/>` + "\n")
-	sb.WriteString(`</classpath>`)
+	color.Green("[+] Generated lightweight Eclipse config (Source Roots: %d)", len(srcDirs))
+	return nil, nil
+}
 
-	// 4. 写入文件
-	if err := os.WriteFile(projectFile, []byte(projectContent), 0644); err != nil {
-		return err
-	}
-	if err := os.WriteFile(classpathFile, []byte(sb.String()), 0644); err != nil {
-		return err
+// PlanEclipseConfig 返回 GenerateEclipseConfig 会配置的源码目录和模块 (不写入任何文件，-dry-run 使用)
+// 找不到源码目录时为 [""] (根目录兜底)；modules 只有一个元素时按单模块生成
+func PlanEclipseConfig(projectRoot string, followSymlinks bool) ([]string, []EclipseModule, error) {
+	// 1. 扫描所有的 source root (src/main/java 等)
+	srcDirs, err := scanSourceDirs(projectRoot, followSymlinks)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	color.Green("[+] Generated lightweight Eclipse config (Source Roots: %d)", len(srcDirs))
-	return nil
+	if len(srcDirs) == 0 {
+		// 如果找不到标准目录，就把根目录当作源码目录（兜底）
+		srcDirs = append(srcDirs, "")
+	}
+	return srcDirs, groupSourceModules(projectRoot, srcDirs), nil
 }
 
 // 递归查找所有包含 .java 文件的目录，并尝试定位到 source root
-func scanSourceDirs(root string) ([]string, error) {
+// 同一个物理目录 (通过符号链接出现在多个位置) 只保留一次，重复的 source root 会让 JDT.LS 报告重复类型
+// package 声明与目录不一致的文件 (草稿/示例) 不参与推断；整个目录都没有 package 声明时目录本身作为 source root。
+// 嵌套在其它 source root 中的目录最后会被去掉，否则同一个类会以两个包名出现，整个项目的解析都会出错
+func scanSourceDirs(root string, followSymlinks bool) ([]string, error) {
 	var srcDirs []string
 	seen := make(map[string]bool)
+	add := func(dir string) {
+		key := dir
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			key = real
+		}
+		if !seen[key] {
+			srcDirs = append(srcDirs, dir)
+			seen[key] = true
+		}
+	}
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	var mismatched, defaultDirs []string
+	packageDirs := make(map[string]bool) // 有 package 声明的文件所在的目录
+	err := WalkProject(root, followSymlinks, func(path string, info fs.DirEntry, err error) error {
 		if err != nil { return nil }
 		
-		// 忽略隐藏目录和构建目录
+		// 忽略隐藏目录和构建目录 (保留 target/generated-sources 等生成源码目录，MapStruct 等生成的类才能被解析)
 		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") || info.Name() == "target" || info.Name() == "build" || info.Name() == "node_modules" {
+			if IndexWalk.SkipDir(root, path) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		if strings.HasSuffix(info.Name(), ".java") {
+		if strings.HasSuffix(info.Name(), ".java") && !IndexWalk.SkipFile(path) {
 			// ✨✨✨ 智能倒推逻辑 ✨✨✨
-			detectedRoot := detectSourceRootFromPackage(path)
-			if detectedRoot != "" {
-				if !seen[detectedRoot] {
-					srcDirs = append(srcDirs, detectedRoot)
-					seen[detectedRoot] = true
+			detectedRoot, status := detectSourceRootFromPackage(path)
+			// 与按包倒推出的 source root 一样使用绝对路径 (去重和嵌套检查都按路径比较)
+			dir, _ := filepath.Abs(filepath.Dir(path))
+			switch status {
+			case packageMatched:
+				packageDirs[dir] = true
+				add(detectedRoot)
+			case packageMismatched:
+				packageDirs[dir] = true
+				mismatched = append(mismatched, path)
+			case packageMissing:
+				if len(defaultDirs) == 0 || defaultDirs[len(defaultDirs)-1] != dir {
+					defaultDirs = append(defaultDirs, dir)
 				}
-				// 一旦在这个目录下找到了一个合法的 java 文件并确定了 root，
-				// 这个 root 下的其他文件就不需要再扫描了，避免重复 IO，但 Walk 无法跳过同级文件，只能继续
 			}
 		}
 		return nil
 	})
+
+	if len(mismatched) > 0 {
+		warnMismatchedPackages(root, mismatched)
+	}
+
+	// 没有 package 声明的目录 (default package): 目录中的文件都没有声明，且目录中不包含按包推断出的 source root
+	for _, dir := range defaultDirs {
+		if packageDirs[dir] || containsRoot(dir, srcDirs) {
+			continue
+		}
+		add(dir)
+	}
+	srcDirs = removeNestedRoots(root, srcDirs)
 	
 	// 如果没找到任何包结构，但有 java 文件，把根目录算进去
 	if len(srcDirs) == 0 {
 		hasJava := false
-		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-			if strings.HasSuffix(info.Name(), ".java") {
+		WalkProject(root, followSymlinks, func(path string, info fs.DirEntry, err error) error {
+			if err == nil && strings.HasSuffix(info.Name(), ".java") {
 				hasJava = true
 				return filepath.SkipDir // 只要找到一个就行
 			}
@@ -129,10 +143,68 @@ func scanSourceDirs(root string) ([]string, error) {
 	return srcDirs, err
 }
 
+// maxListedMismatches 警告中列出的 package 不一致的文件数
+const maxListedMismatches = 5
+
+// warnMismatchedPackages 列出因 package 声明与目录不一致而没有参与 source root 推断的文件
+func warnMismatchedPackages(root string, files []string) {
+	color.Yellow("[!] Skipped %d Java file(s) whose package declaration does not match the directory layout:", len(files))
+	for i, path := range files {
+		if i == maxListedMismatches {
+			color.Yellow("    ... and %d more", len(files)-maxListedMismatches)
+			break
+		}
+		color.Yellow("    %s", relPath(root, path))
+	}
+}
+
+// isWithinDir dir 是否位于 parent 之下 (不含 parent 本身)
+func isWithinDir(parent, dir string) bool {
+	rel, err := filepath.Rel(parent, dir)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// containsRoot roots 中是否有位于 dir 之下的目录
+func containsRoot(dir string, roots []string) bool {
+	for _, r := range roots {
+		if isWithinDir(dir, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeNestedRoots 去掉嵌套在其它 source root 中的目录 (保持原有顺序)
+func removeNestedRoots(root string, dirs []string) []string {
+	var result []string
+	for _, dir := range dirs {
+		nested := false
+		for _, other := range dirs {
+			if isWithinDir(other, dir) {
+				nested = true
+				color.Yellow("[!] Ignored source root %s nested inside %s", relPath(root, dir), relPath(root, other))
+				break
+			}
+		}
+		if !nested {
+			result = append(result, dir)
+		}
+	}
+	return result
+}
+
+// 源码文件的 package 声明与所在目录的关系
+const (
+	packageMatched    = iota // 目录以包路径结尾，可以倒推出 source root
+	packageMismatched        // 包路径与目录不一致 (草稿/示例文件)
+	packageMissing           // 没有 package 声明 (default package)
+)
+
 // 读取 Java 文件头，提取 package，计算源码根
-func detectSourceRootFromPackage(javaFilePath string) string {
+// 只有 packageMatched 时返回 source root
+func detectSourceRootFromPackage(javaFilePath string) (string, int) {
 	f, err := os.Open(javaFilePath)
-	if err != nil { return "" }
+	if err != nil { return "", packageMismatched }
 	defer f.Close()
 
 	// 只读前 20 行，通常 package 声明都在最前面
@@ -147,16 +219,16 @@ func detectSourceRootFromPackage(javaFilePath string) string {
 		line := strings.TrimSpace(scanner.Text())
 		if strings.HasPrefix(line, "package ") && strings.HasSuffix(line, ";") {
 			// 提取包名: package com.example.util; -> com.example.util
-			rawPkg := strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";")
+			rawPkg := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";"))
 			// 转换为路径: com/example/util
 			packagePath = strings.ReplaceAll(rawPkg, ".", string(os.PathSeparator))
 			break
 		}
 	}
 
-	// 如果没有 package 声明 (Default Package)，那么文件所在目录就是 Source Root
+	// 没有 package 声明 (Default Package)，由调用方按整个目录判断
 	if packagePath == "" {
-		return filepath.Dir(javaFilePath)
+		return "", packageMissing
 	}
 
 	// 倒推逻辑
@@ -167,13 +239,14 @@ func detectSourceRootFromPackage(javaFilePath string) string {
 	absPath, _ := filepath.Abs(javaFilePath)
 	dir := filepath.Dir(absPath) // /A/B/src/com/demo
 	
-	if strings.HasSuffix(dir, packagePath) {
+	// 必须在路径分隔符处对齐: /A/B/src/xcom/demo 不是 com.demo 的目录
+	if strings.HasSuffix(dir, string(os.PathSeparator)+packagePath) {
 		// 截取
 		root := strings.TrimSuffix(dir, packagePath)
 		// 去掉末尾可能残留的路径分隔符
 		root = strings.TrimSuffix(root, string(os.PathSeparator))
-		return root
+		return root, packageMatched
 	}
 	
-	return ""
+	return "", packageMismatched
 }
\ No newline at end of file
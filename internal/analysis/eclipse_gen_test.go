@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// sourceRoots 对 testdata/sourceroots/<layout> 推断 source root，返回相对布局目录的路径 (/ 分隔)
+func sourceRoots(t *testing.T, root string) []string {
+	t.Helper()
+	dirs, err := scanSourceDirs(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			t.Errorf("source root %s is not absolute", dir)
+		}
+		rel, err := filepath.Rel(abs, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	return rels
+}
+
+func TestScanSourceDirs(t *testing.T) {
+	tests := []struct {
+		layout string
+		want   []string
+	}{
+		// 包路径与目录一致: 倒推到 src/main/java (包声明之前有注释也可以)
+		{"matching", []string{"app/src/main/java", "lib/src/main/java"}},
+		// 包路径与目录不一致的文件不参与推断；xcom/acme 不是 com.acme 的目录
+		{"mismatching", []string{"src/main/java"}},
+		// 没有 package 声明: 整个目录都没有声明时才作为 source root (mixed 中有带声明的文件)
+		{"default", []string{"src/main/java", "scripts"}},
+		// 嵌套在 src/main/java 中的 source root (package gen 倒推出的 com/acme、default package 的 tools) 被去掉
+		{"nested", []string{"src/main/java"}},
+	}
+	for _, tt := range tests {
+		root := filepath.Join("testdata", "sourceroots", tt.layout)
+		if got := sourceRoots(t, root); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: source roots %v, want %v", tt.layout, got, tt.want)
+		}
+	}
+}
+
+// 所有文件的 package 都与目录不一致时退回项目根目录
+func TestScanSourceDirsOnlyMismatched(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"drafts/A.java": "package com.acme;\n\npublic class A {\n}\n",
+		"drafts/B.java": "package org.other;\n\npublic class B {\n}\n",
+	})
+	if got := sourceRoots(t, root); !slices.Equal(got, []string{"."}) {
+		t.Errorf("source roots %v, want the project root", got)
+	}
+}
+
+func TestDetectSourceRootFromPackage(t *testing.T) {
+	layout, err := filepath.Abs(filepath.Join("testdata", "sourceroots"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file   string
+		root   string
+		status int
+	}{
+		{"matching/app/src/main/java/com/acme/util/Strings.java", "matching/app/src/main/java", packageMatched},
+		{"mismatching/src/main/java/com/acme/scratch/Draft.java", "", packageMismatched},
+		{"mismatching/src/main/java/xcom/acme/Odd.java", "", packageMismatched},
+		{"default/scripts/Hello.java", "", packageMissing},
+		{"default/Missing.java", "", packageMismatched}, // 无法读取的文件
+	}
+	for _, tt := range tests {
+		root, status := detectSourceRootFromPackage(filepath.Join(layout, filepath.FromSlash(tt.file)))
+		want := ""
+		if tt.root != "" {
+			want = filepath.Join(layout, filepath.FromSlash(tt.root))
+		}
+		if root != want || status != tt.status {
+			t.Errorf("%s: %q (status %d), want %q (status %d)", tt.file, root, status, want, tt.status)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// Endpoints 提取项目中所有的 HTTP 端点 (Controller 映射方法和 Servlet)，按路径和 HTTP 方法排序
+// 路径和 HTTP 方法来自注解 / web.xml 的文本解析；语言服务器可用时用 documentSymbol 补充处理器的方法签名 (区分重载)
+func (t *Tracer) Endpoints() []entrypoints.Endpoint {
+	servlets := make(map[string][]string)
+	t.walkFiles(func(name string) bool { return name == "web.xml" }, func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		found, err := entrypoints.ParseWebXML(content)
+		if err != nil {
+			fmt.Printf("    [!] Failed to parse %s: %v\n", path, err)
+			return
+		}
+		for class, patterns := range found {
+			servlets[class] = append(servlets[class], patterns...)
+		}
+	})
+
+	var endpoints []entrypoints.Endpoint
+	t.walkJavaFiles(func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		endpoints = append(endpoints, entrypoints.Parse(path, content, servlets)...)
+	})
+
+	if t.Docs != nil {
+		for i := range endpoints {
+			e := &endpoints[i]
+			if e.Handler == "" {
+				continue
+			}
+			if fn, ok := t.GetEnclosingFunction(lsp.ToUri(e.File), e.Line); ok {
+				e.Signature = fn.Symbol
+			}
+		}
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Verb() < endpoints[j].Verb()
+	})
+	return endpoints
+}
+
+// AttachRoutes 在 Source 是端点处理器方法的链路上记录 HTTP 路由 ("POST /api/files/upload")，返回找到路由的链路数量
+// 有 LSP 函数范围时按范围匹配处理器所在行，否则按文件 + 方法名匹配；一个方法有多个映射时全部记录
+func AttachRoutes(chains [][]model.ChainStep, endpoints []entrypoints.Endpoint) int {
+	byFile := make(map[string][]entrypoints.Endpoint)
+	for _, e := range endpoints {
+		byFile[e.File] = append(byFile[e.File], e)
+	}
+	attached := 0
+	for _, chain := range chains {
+		if len(chain) == 0 {
+			continue
+		}
+		source := &chain[len(chain)-1]
+		source.Routes = nil
+		name := methodName(source.Func)
+		if i := strings.LastIndex(name, "."); i != -1 {
+			name = name[i+1:]
+		}
+		for _, e := range byFile[source.File] {
+			if e.Handler == "" {
+				continue
+			}
+			if source.FuncEndLine > 0 {
+				if e.Line < source.FuncStartLine || e.Line > source.FuncEndLine {
+					continue
+				}
+			} else if e.Handler != name {
+				continue
+			}
+			route := e.Verb() + " " + e.Path
+			if !containsString(source.Routes, route) {
+				source.Routes = append(source.Routes, route)
+			}
+		}
+		if len(source.Routes) > 0 {
+			attached++
+		}
+	}
+	return attached
+}
@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// 端点清单: Controller 映射和 web.xml 中映射的 Servlet，按路径和 HTTP 方法排序
+func TestEndpoints(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("testdata", "endpoints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Tracer{ProjectRoot: root}
+	var got []string
+	for _, e := range tr.Endpoints() {
+		got = append(got, e.Verb()+" "+e.Path+" "+e.HandlerName()+" "+filepath.Base(e.File))
+	}
+	want := []string{
+		"POST /legacy LegacyServlet.doPost LegacyServlet.java",
+		"POST /orders OrderController.create OrderController.java",
+		"POST /orders/bulk OrderController.create OrderController.java",
+		"GET /orders/{id} OrderController.get OrderController.java",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Endpoints =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// 链路的 Source 是端点处理器时记录路由: 有函数范围时按范围匹配，否则按方法名匹配
+func TestAttachRoutes(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("testdata", "endpoints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints := (&Tracer{ProjectRoot: root}).Endpoints()
+	controller := filepath.Join(root, "src", "main", "java", "com", "acme", "web", "OrderController.java")
+	sink := model.ChainStep{Func: "run(String)", File: filepath.Join(root, "Sink.java")}
+	chains := [][]model.ChainStep{
+		{sink, {Func: "create(String)", File: controller}},
+		{sink, {Func: "OrderController.get(String)", File: controller, FuncStartLine: 6, FuncEndLine: 9}},
+		{sink, {Func: "helper(String)", File: controller, FuncStartLine: 10, FuncEndLine: 11}},
+		{sink, {Func: "create(String)", File: filepath.Join(root, "Other.java")}},
+	}
+	if n := AttachRoutes(chains, endpoints); n != 2 {
+		t.Errorf("AttachRoutes = %d, want 2", n)
+	}
+	want := [][]string{{"POST /orders", "POST /orders/bulk"}, {"GET /orders/{id}"}, nil, nil}
+	for i, chain := range chains {
+		if got := chain[len(chain)-1].Routes; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("chain %d routes = %q, want %q", i, got, want[i])
+		}
+		if chain[0].Routes != nil {
+			t.Errorf("chain %d: routes recorded on the sink", i)
+		}
+	}
+}
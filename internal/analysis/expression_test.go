@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// expressionRules 内置的表达式/模板注入规则；exclude 中的类的规则不包含在内
+func expressionRules(exclude ...string) []model.SinkRule {
+	var rules []model.SinkRule
+	for _, r := range model.GetBuiltinRules() {
+		if r.VulnType == "EXPRESSION_INJECTION" && !slices.Contains(exclude, r.ClassName) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// 常量的 SpEL / OGNL 表达式 (字面量和 static final 常量) 和常量模板源码被跳过，来自参数的表达式被报告
+func TestExpressionRulesSkipConstants(t *testing.T) {
+	path := filepath.Join("testdata", "expression", "ExpressionService.java")
+	// Thymeleaf 的 process 规则在文本阶段会命中 Freemarker 的 template.process(...)，由 LSP 验证排除
+	cands := mergeDuplicates(scanFileCandidates(path, expressionRules("org.thymeleaf.ITemplateEngine"), false))
+	var got []string
+	for _, c := range cands {
+		got = append(got, c.Rule.ClassName+"#"+c.Rule.MethodName+"@"+strings.Join(positions([]candidate{c}), ""))
+	}
+	want := []string{
+		"org.springframework.expression.ExpressionParser#parseExpression@28:parseExpression",
+		"ognl.Ognl#setValue@37:setValue",
+		"ognl.Ognl#getValue@38:getValue",
+		"freemarker.template.Template#<init>@50:<init>",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("candidates =\n%q\nwant\n%q", got, want)
+	}
+	// parseExpression 同名的 SpEL 和 OGNL 规则命中同一个调用，由 LSP 验证决定是哪一个
+	if also := cands[0].Also; len(also) != 1 || also[0].Rule.ClassName != "ognl.Ognl" {
+		t.Errorf("parseExpression also matched %v", positions(also))
+	}
+}
+
+// Template.process: 只报告渲染由非常量 StringReader 构造的模板，常量模板和 getTemplate() 加载的模板文件不报告
+func TestFreemarkerProcessComposite(t *testing.T) {
+	path := filepath.Join("testdata", "expression", "ExpressionService.java")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	rule := compositeRule(t, "EXPRESSION_INJECTION (Freemarker Template.process)")
+	tests := []struct {
+		method     string
+		start, end int // 方法范围 (行号从 0 开始)
+		want       string
+	}{
+		{"greet", 40, 45, ""},
+		{"render", 47, 53, "52:composite"},
+		{"renderFile", 55, 60, ""},
+	}
+	var composites []candidate
+	for _, tt := range tests {
+		got := matchComposite(rule, lines, tt.start, tt.end)
+		for _, c := range got {
+			c.File = path
+			composites = append(composites, c)
+		}
+		if s := strings.Join(positions(got), " "); s != tt.want {
+			t.Errorf("%s: composite = %q, want %q", tt.method, s, tt.want)
+			continue
+		}
+		if len(got) == 0 {
+			continue
+		}
+		c := got[0]
+		if c.Rule.VulnType != "EXPRESSION_INJECTION" || !strings.HasPrefix(c.Code[c.Col:], ".process(") {
+			t.Errorf("%s: rule %s, call %q", tt.method, c.Rule.Name, c.Code[c.Col:])
+		}
+		if want := "📦 Template read at line 49: `StringReader reader = new StringReader(source);`"; len(c.Notes) != 1 || c.Notes[0] != want {
+			t.Errorf("%s: notes = %q, want %q", tt.method, c.Notes, want)
+		}
+	}
+
+	// 组合规则认领 render 中的 process 调用: 同一调用上 Thymeleaf 规则的文本候选点不再保留
+	merged := withComposites(mergeDuplicates(scanFileCandidates(path, expressionRules(), false)), composites)
+	if got, want := strings.Join(positions(merged), " "), "28:parseExpression 37:setValue 38:getValue 44:process 50:<init> 59:process 52:composite"; got != want {
+		t.Errorf("merged = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// DefaultMaxCallers 单个方法最多继续追踪的调用者数量
+// toString、日志方法、基类的 execute 等可能有成千上万个引用，全部展开会让追踪时间和链路数量失控
+const DefaultMaxCallers = 50
+
+const fanOutNote = "🔀 Fan-out limit: %d callers not explored"
+
+// FanOutHit 调用者数量超过 -max-callers 的方法
+type FanOutHit struct {
+	Method  string // Func (file:line)
+	Callers int    // 过滤后的调用者数量
+}
+
+// traceableRef 引用是否参与追踪: 位于 -exclude 排除的路径或测试/示例代码中的调用者不追踪，也不计入调用者上限
+func (t *Tracer) traceableRef(path string) bool {
+	if t.codeKind(path) != model.CodeMain {
+		return false
+	}
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if t.isExcluded(dir) {
+			return false
+		}
+		parent := filepath.Dir(dir)
+		if dir == t.ProjectRoot || parent == dir {
+			return true
+		}
+	}
+}
+
+// codeKind 文件属于生产、测试还是示例代码 (model.ClassifyCode)；项目之外的文件按生产代码处理
+func (t *Tracer) codeKind(path string) string {
+	rel, err := filepath.Rel(t.ProjectRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return model.CodeMain
+	}
+	return model.ClassifyCode(filepath.ToSlash(rel), t.CodePaths)
+}
+
+// limitCallers 调用者数量超过 MaxCallers 时只保留前 MaxCallers 个 (按位置排序，结果稳定)
+// 剩下的调用者不再展开: 记录一条到当前方法为止的部分链路 (TerminationFanOutLimit)，并记录该方法以便在汇总中列出
+func (t *Tracer) limitCallers(stack []model.ChainStep, refs []lsp.Location) []lsp.Location {
+	if t.MaxCallers <= 0 || len(refs) <= t.MaxCallers || len(stack) == 0 {
+		return refs
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].Uri != refs[j].Uri {
+			return refs[i].Uri < refs[j].Uri
+		}
+		return refs[i].Range.Start.Line < refs[j].Range.Start.Line
+	})
+	skipped := len(refs) - t.MaxCallers
+
+	chain := append([]model.ChainStep(nil), stack...)
+	last := chain[len(chain)-1]
+	last.Analysis = append(append([]string(nil), last.Analysis...), fmt.Sprintf(fanOutNote, skipped))
+	last.Termination = model.TerminationFanOutLimit
+	chain[len(chain)-1] = last
+	kind, tainted, _ := t.sourceSignals(chain)
+	scoreChain(chain, kind != "", tainted, false)
+	classifySource(chain, kind)
+	embedSnippets(chain)
+
+	method := fmt.Sprintf("%s (%s:%d)", last.QualifiedFunc(), filepath.Base(last.File), last.Line+1)
+	t.AddFinding(chain)
+	t.mu.Lock()
+	if t.fanOut == nil {
+		t.fanOut = make(map[string]int)
+	}
+	if len(refs) > t.fanOut[method] {
+		t.fanOut[method] = len(refs)
+	}
+	t.mu.Unlock()
+
+	return refs[:t.MaxCallers]
+}
+
+// FanOutHits 返回调用者数量超过上限的方法，按调用者数量降序，最多 n 个 (n <= 0 表示全部)
+// 这些方法通常是通用的工具方法，可以加入白名单或排除
+func (t *Tracer) FanOutHits(n int) []FanOutHit {
+	t.mu.RLock()
+	hits := make([]FanOutHit, 0, len(t.fanOut))
+	for method, callers := range t.fanOut {
+		hits = append(hits, FanOutHit{Method: method, Callers: callers})
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Callers != hits[j].Callers {
+			return hits[i].Callers > hits[j].Callers
+		}
+		return hits[i].Method < hits[j].Method
+	})
+	if n > 0 && len(hits) > n {
+		hits = hits[:n]
+	}
+	return hits
+}
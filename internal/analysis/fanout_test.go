@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTraceableRef(t *testing.T) {
+	root := filepath.FromSlash("/src/demo")
+	tr := NewTracer(nil, root, "light")
+	tr.Exclude = []string{"generated", "legacy/*"}
+	for rel, want := range map[string]bool{
+		"src/main/java/com/example/App.java":          true,
+		"src/test/java/com/example/AppTest.java":      false,
+		"generated/com/example/Gen.java":              false,
+		"module/generated/com/example/Gen.java":       false,
+		"legacy/Old.java":                             false,
+		"src/main/java/com/example/generatedApp.java": true,
+	} {
+		if got := tr.traceableRef(filepath.Join(root, filepath.FromSlash(rel))); got != want {
+			t.Errorf("traceableRef(%s) = %v, want %v", rel, got, want)
+		}
+	}
+	if !tr.traceableRef(filepath.FromSlash("/opt/lib/Outside.java")) {
+		t.Error("a caller outside the project was not traced")
+	}
+}
+
+// 按调用者数量降序，数量相同时按方法名
+func TestFanOutHits(t *testing.T) {
+	tr := NewTracer(nil, t.TempDir(), "light")
+	tr.fanOut = map[string]int{"b.log (B.java:3)": 80, "a.log (A.java:9)": 80, "toString (C.java:1)": 400, "execute (D.java:2)": 51}
+
+	hits := tr.FanOutHits(3)
+	want := []FanOutHit{{"toString (C.java:1)", 400}, {"a.log (A.java:9)", 80}, {"b.log (B.java:3)", 80}}
+	if len(hits) != len(want) {
+		t.Fatalf("FanOutHits(3) = %v, want %v", hits, want)
+	}
+	for i := range want {
+		if hits[i] != want[i] {
+			t.Errorf("#%d = %v, want %v", i, hits[i], want[i])
+		}
+	}
+	if all := tr.FanOutHits(0); len(all) != 4 {
+		t.Errorf("FanOutHits(0) returned %d methods, want 4", len(all))
+	}
+}
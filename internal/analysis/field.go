@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// 字段级数据流: 调用点参数不是局部变量也不是方法参数时，它可能是类的字段，
+// 由另一个方法赋值 (例如 Controller 保存请求参数，定时任务再使用)。
+// 在同一个类中查找对该字段的赋值，把每个赋值所在的方法作为额外的分支继续追踪。
+
+// fieldCandidate 返回参数表达式可能对应的字段名 ("this.x" 或单个标识符)，否则返回空
+func fieldCandidate(args string) string {
+	name := strings.TrimPrefix(args, "this.")
+	if !isVar(name) || isKeyword(name) {
+		return ""
+	}
+	return name
+}
+
+// findFieldClass 返回包含 line 且直接声明了字段 name 的最内层类，以及字段的声明
+func findFieldClass(symbols []lsp.DocumentSymbol, line int, name string) (class, field lsp.DocumentSymbol, ok bool) {
+	for _, node := range symbols {
+		if node.Range.Start.Line > line || node.Range.End.Line < line {
+			continue
+		}
+		if c, f, found := findFieldClass(node.Children, line, name); found {
+			return c, f, true
+		}
+		if !isClassKind(node.Kind) {
+			continue
+		}
+		for _, child := range node.Children {
+			if (child.Kind == symbolKindField || child.Kind == symbolKindConstant) && child.Name == name {
+				return node, child, true
+			}
+		}
+	}
+	return lsp.DocumentSymbol{}, lsp.DocumentSymbol{}, false
+}
+
+// fieldWrites 返回 [start, end] 行范围内对字段 name 的赋值行 (不含 == 比较)
+func fieldWrites(lines []string, start, end int, name string) []int {
+	re := regexp.MustCompile(`(?:^|[^\w.]|\bthis\.)` + regexp.QuoteMeta(name) + `\s*=[^=]`)
+	var result []int
+	for i := start; i <= end && i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "/*") {
+			continue
+		}
+		if re.MatchString(text) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// traceFieldWrites 追踪 usageLine 处使用的字段 name 在同一个类中的赋值点
+// 每个赋值所在的方法 (使用点所在方法除外) 作为 stack 的一个新分支继续向上追踪；
+// 赋值为常量的写入不会携带外部输入，直接跳过
+func (t *Tracer) traceFieldWrites(ctx context.Context, file string, usageLine int, name string, stack []model.ChainStep, visited map[string]bool) {
+	if ctx.Err() != nil || name == "" {
+		return
+	}
+	symbols, err := t.Docs.Symbols(file)
+	if err != nil {
+		return
+	}
+	class, field, ok := findFieldClass(symbols, usageLine, name)
+	if !ok {
+		return
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(content), "\n")
+	usageFn, _ := findEnclosingFunction(symbols, usageLine)
+
+	for _, line := range fieldWrites(lines, class.Range.Start.Line, class.Range.End.Line, name) {
+		if ctx.Err() != nil {
+			return
+		}
+		if line >= field.Range.Start.Line && line <= field.Range.End.Line {
+			continue
+		}
+		fn, ok := findEnclosingFunction(symbols, line)
+		if _, _, isMethod := fn.SourceRange(); !ok || !isMethod {
+			continue
+		}
+		if fn.SelectionStart == usageFn.SelectionStart && fn.Name == usageFn.Name {
+			continue
+		}
+
+		code := strings.TrimSpace(lines[line])
+		rhs := strings.TrimSpace(extractRHS(code))
+		if isStrictConstant(rhs) {
+			continue
+		}
+
+		key := "field:" + lineKey(file, line)
+		if visited[key] {
+			continue
+		}
+		newVisited := make(map[string]bool, len(visited)+1)
+		for k, v := range visited {
+			newVisited[k] = v
+		}
+		newVisited[key] = true
+
+		t.Console.Progress("    [↑] Field write: %s.%s (in %s:%d)", fn.Name, name, filepath.Base(file), line+1)
+
+		step := model.ChainStep{
+			File:  file,
+			Line:  line,
+			Func:  fn.Name,
+			Class: fn.Class,
+			Code:  code,
+			Analysis: []string{
+				fmt.Sprintf("🔗 Via field `%s`", name),
+				fmt.Sprintf("⚠️ Variable Definition: `%s`", rhs),
+			},
+		}
+		step.FuncStartLine, step.FuncEndLine, _ = fn.SourceRange()
+
+		target, note := t.ResolveTraceTarget(file, line, fn)
+		if note != "" {
+			step.Analysis = append(step.Analysis, note)
+		}
+
+		branch := append(append([]model.ChainStep(nil), stack...), step)
+		t.TraceChain(ctx, file, target.SelectionStart, target.Column, branch, newVisited)
+	}
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldCandidate(t *testing.T) {
+	for args, want := range map[string]string{
+		"this.uploadDir":     "uploadDir",
+		"uploadDir":          "uploadDir",
+		"\"rm -rf \" + dir":  "",
+		"final":              "",
+		"config.getDir()":    "",
+		"this.config.getDir": "",
+	} {
+		if got := fieldCandidate(args); got != want {
+			t.Errorf("fieldCandidate(%q) = %q, want %q", args, got, want)
+		}
+	}
+}
+
+// 行号对应 testdata/field/UploadService.java (0 起始): Controller 方法给字段赋值，定时任务在 Sink 中使用
+func TestFindFieldClass(t *testing.T) {
+	symbols := loadSymbols(t, "UploadService.json")
+	tests := []struct {
+		line      int
+		name      string
+		class     string
+		fieldLine int
+	}{
+		{17, "uploadDir", "UploadService", 5}, // cleanup() 中的使用
+		{11, "ready", "UploadService", 6},
+		{25, "uploadDir", "Helper", 22}, // 嵌套类自己的同名字段
+	}
+	for _, tt := range tests {
+		class, field, ok := findFieldClass(symbols, tt.line, tt.name)
+		if !ok || class.Name != tt.class || field.Range.Start.Line != tt.fieldLine {
+			t.Errorf("line %d %s: class %q field line %d (found %v), want %q line %d", tt.line, tt.name, class.Name, field.Range.Start.Line, ok, tt.class, tt.fieldLine)
+		}
+	}
+	if _, _, ok := findFieldClass(symbols, 17, "dir"); ok {
+		t.Error("a method parameter was reported as a field")
+	}
+}
+
+func TestFieldWrites(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "field", "UploadService.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	tests := []struct {
+		name       string
+		start, end int
+		want       []int
+	}{
+		{"uploadDir", 2, 20, []int{5, 10}}, // 声明的初始值和 setDir() 中的 this.uploadDir = dir
+		{"ready", 2, 20, []int{11}},        // "ready == true" 是比较，不是赋值
+		{"uploadDir", 21, 27, []int{25}},
+	}
+	for _, tt := range tests {
+		if got := fieldWrites(lines, tt.start, tt.end, tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fieldWrites(%s, %d-%d) = %v, want %v", tt.name, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// 追踪完成的链路交给 FindingSink 保存。默认保存在内存中；-spill 时逐条追加到临时的 NDJSON 文件，
+// 内存中只保留每个 Sink 的链路数等摘要，报告阶段再按记录顺序读回 (超大项目的内存占用不随链路数量增长)
+
+// FindingSink 保存追踪完成的链路，可以并发调用
+type FindingSink interface {
+	// Record 保存一条链路，之后调用方不再修改它
+	Record(chain []model.ChainStep) error
+	// Len 已保存的链路数
+	Len() int
+	// ChainsAt Sink 位于 file 第 line 行 (0-based) 的链路数
+	ChainsAt(file string, line int) int
+	// Sinks 至少有一条链路的 Sink 数量 (按文件和行号去重)
+	Sinks() int
+	// Each 按记录顺序读回链路，fn 返回错误时停止并返回该错误
+	Each(fn func(chain []model.ChainStep) error) error
+	// Chain 按记录下标 (0-based，与 Each 的顺序相同) 读回一条链路，报告阶段按排序后的顺序逐条读取
+	Chain(i int) ([]model.ChainStep, error)
+	// Rewrite 按记录顺序修改已保存的链路 (不能改变 Sink 的位置)
+	Rewrite(fn func(chain []model.ChainStep)) error
+	// Close 释放资源 (删除临时文件)
+	Close() error
+}
+
+// AddFinding 把追踪完成的链路交给 Findings 保存 (RecordResult 和单步分析器都经过这里)；临时文件写入失败时提示一次，之后的链路保存在内存中
+func (t *Tracer) AddFinding(chain []model.ChainStep) {
+	if err := t.Findings.Record(chain); err != nil {
+		color.Red("[-] %v (later findings are kept in memory)", err)
+	}
+}
+
+// Chains 读回所有链路 (配置检查等结果较少的场景)
+func (t *Tracer) Chains() [][]model.ChainStep {
+	var chains [][]model.ChainStep
+	if err := t.Findings.Each(func(chain []model.ChainStep) error {
+		chains = append(chains, chain)
+		return nil
+	}); err != nil {
+		color.Red("[-] Failed to read findings: %v", err)
+	}
+	return chains
+}
+
+// findingSummary 两种实现共用的摘要: 链路总数和每个 Sink 的链路数
+type findingSummary struct {
+	count int
+	sinks map[string]int // lineKey(Sink 文件, 行) -> 链路数
+}
+
+func (s *findingSummary) add(chain []model.ChainStep) {
+	s.count++
+	if len(chain) == 0 {
+		return
+	}
+	if s.sinks == nil {
+		s.sinks = make(map[string]int)
+	}
+	s.sinks[lineKey(chain[0].File, chain[0].Line)]++
+}
+
+// memoryFindings 保存在内存中的链路 (默认)
+type memoryFindings struct {
+	mu      sync.RWMutex
+	chains  [][]model.ChainStep
+	summary findingSummary
+}
+
+// NewMemorySink 在内存中保存链路的 FindingSink
+func NewMemorySink() FindingSink {
+	return &memoryFindings{}
+}
+
+func (m *memoryFindings) Record(chain []model.ChainStep) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chains = append(m.chains, chain)
+	m.summary.add(chain)
+	return nil
+}
+
+func (m *memoryFindings) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.summary.count
+}
+
+func (m *memoryFindings) ChainsAt(file string, line int) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.summary.sinks[lineKey(file, line)]
+}
+
+func (m *memoryFindings) Sinks() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.summary.sinks)
+}
+
+func (m *memoryFindings) Each(fn func(chain []model.ChainStep) error) error {
+	m.mu.RLock()
+	chains := m.chains[:len(m.chains):len(m.chains)]
+	m.mu.RUnlock()
+	for _, chain := range chains {
+		if err := fn(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryFindings) Chain(i int) ([]model.ChainStep, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if i < 0 || i >= len(m.chains) {
+		return nil, fmt.Errorf("finding %d out of range (%d recorded)", i, len(m.chains))
+	}
+	return m.chains[i], nil
+}
+
+func (m *memoryFindings) Rewrite(fn func(chain []model.ChainStep)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, chain := range m.chains {
+		fn(chain)
+	}
+	return nil
+}
+
+func (m *memoryFindings) Close() error {
+	return nil
+}
+
+// spillFindings 逐条追加到临时 NDJSON 文件的链路。规则保存在内存中的规则表里，文件中只记录编号
+// (规则的正则等运行时字段不能序列化，也避免每条链路重复规则的描述和修复建议)
+// 写入失败 (e.g. 磁盘已满) 之后的链路留在内存中，读回时排在文件内容之后
+type spillFindings struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	size     int64   // 已完整写入的字节数，写入失败时截断到这里
+	offsets  []int64 // 文件中每条链路的起始位置，Chain 按下标读取时使用
+	rules    ruleTable
+	summary  findingSummary
+	overflow [][]model.ChainStep
+	err      error
+}
+
+// NewSpillSink 在 dir 中创建临时 NDJSON 文件保存链路 (dir 为空时使用系统临时目录)，Close 时删除文件
+func NewSpillSink(dir string) (FindingSink, error) {
+	file, err := os.CreateTemp(dir, "lsptracer-findings-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %v", err)
+	}
+	return &spillFindings{path: file.Name(), file: file}, nil
+}
+
+func (s *spillFindings) Record(chain []model.ChainStep) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.add(chain)
+	if s.err == nil {
+		if s.err = s.write(s.file, chain); s.err == nil {
+			return nil
+		}
+		s.err = fmt.Errorf("spill file %s: %v", s.path, s.err)
+		return s.err
+	}
+	s.overflow = append(s.overflow, chain)
+	return nil
+}
+
+// write 把链路作为一行写入 f；写入失败时截断不完整的行，链路转存到 overflow
+func (s *spillFindings) write(f *os.File, chain []model.ChainStep) error {
+	data, err := json.Marshal(s.rules.encode(chain))
+	if err == nil {
+		var n int
+		n, err = f.Write(append(data, '\n'))
+		if err == nil {
+			s.offsets = append(s.offsets, s.size)
+			s.size += int64(n)
+			return nil
+		}
+		f.Truncate(s.size)
+		f.Seek(s.size, io.SeekStart)
+	}
+	s.overflow = append(s.overflow, chain)
+	return err
+}
+
+func (s *spillFindings) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary.count
+}
+
+func (s *spillFindings) ChainsAt(file string, line int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary.sinks[lineKey(file, line)]
+}
+
+func (s *spillFindings) Sinks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.summary.sinks)
+}
+
+// Each 从文件开头逐行读回 (只读到调用时已写入的位置)，同一时刻只有一条链路在内存中
+func (s *spillFindings) Each(fn func(chain []model.ChainStep) error) error {
+	s.mu.Lock()
+	size := s.size
+	overflow := s.overflow[:len(s.overflow):len(s.overflow)]
+	rules := s.rules.snapshot()
+	s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := readSpill(io.LimitReader(f, size), rules, fn); err != nil {
+		return err
+	}
+	for _, chain := range overflow {
+		if err := fn(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Chain 按起始位置读取文件中的一行，写入失败后留在内存中的链路排在文件之后
+func (s *spillFindings) Chain(i int) ([]model.ChainStep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < 0 || i >= len(s.offsets)+len(s.overflow) {
+		return nil, fmt.Errorf("finding %d out of range (%d recorded)", i, len(s.offsets)+len(s.overflow))
+	}
+	if i >= len(s.offsets) {
+		return s.overflow[i-len(s.offsets)], nil
+	}
+	end := s.size
+	if i+1 < len(s.offsets) {
+		end = s.offsets[i+1]
+	}
+	line := make([]byte, end-s.offsets[i])
+	if _, err := s.file.ReadAt(line, s.offsets[i]); err != nil {
+		return nil, fmt.Errorf("spill file %s: %v", s.path, err)
+	}
+	var steps []spilledStep
+	if err := json.Unmarshal(line, &steps); err != nil {
+		return nil, fmt.Errorf("corrupt spill record: %v", err)
+	}
+	return decodeSpilled(steps, s.rules.snapshot()), nil
+}
+
+// Rewrite 把修改后的链路写入新的临时文件，完成后替换原文件
+func (s *spillFindings) Rewrite(fn func(chain []model.ChainStep)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, chain := range s.overflow {
+		fn(chain)
+	}
+	if s.size == 0 {
+		return nil
+	}
+
+	in, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(s.path), "lsptracer-findings-*.ndjson")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	var size int64
+	var offsets []int64
+	err = readSpill(io.LimitReader(in, s.size), s.rules.snapshot(), func(chain []model.ChainStep) error {
+		fn(chain)
+		data, err := json.Marshal(s.rules.encode(chain))
+		if err != nil {
+			return err
+		}
+		offsets = append(offsets, size)
+		n, err := w.Write(append(data, '\n'))
+		size += int64(n)
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), s.path)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return fmt.Errorf("failed to rewrite spill file %s: %v", s.path, err)
+	}
+	s.file.Close()
+	s.file, s.size, s.offsets = out, size, offsets
+	return nil
+}
+
+func (s *spillFindings) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.file.Close()
+	return os.Remove(s.path)
+}
+
+// readSpill 逐行解码临时文件中的链路
+func readSpill(r io.Reader, rules []*model.SinkRule, fn func(chain []model.ChainStep) error) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			var steps []spilledStep
+			if err := json.Unmarshal(line, &steps); err != nil {
+				return fmt.Errorf("corrupt spill record: %v", err)
+			}
+			if err := fn(decodeSpilled(steps, rules)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// spilledStep 临时文件中的一步: 规则和生效的等级覆盖替换为规则表中的编号
+type spilledStep struct {
+	model.ChainStep
+	Rule             int   // 规则表中的下标 + 1，0 表示没有
+	AlsoMatched      []int // 同上
+	SeverityOverride int   // Rule.SeverityOverrides 中的下标 + 1，0 表示没有
+}
+
+// ruleTable 临时文件中引用的规则，按规则内容去重 (单步分析器的发现每条都有自己的规则副本)
+type ruleTable struct {
+	rules []*model.SinkRule
+	ids   map[string]int
+}
+
+// id 规则的编号 (下标 + 1)，第一次出现时加入规则表
+func (t *ruleTable) id(rule *model.SinkRule) int {
+	if rule == nil {
+		return 0
+	}
+	key := ruleKey(rule)
+	if id, ok := t.ids[key]; ok {
+		return id
+	}
+	if t.ids == nil {
+		t.ids = make(map[string]int)
+	}
+	copied := *rule
+	t.rules = append(t.rules, &copied)
+	t.ids[key] = len(t.rules)
+	return len(t.rules)
+}
+
+func (t *ruleTable) snapshot() []*model.SinkRule {
+	return t.rules[:len(t.rules):len(t.rules)]
+}
+
+func ruleKey(rule *model.SinkRule) string {
+	pattern := ""
+	if rule.Pattern != nil {
+		pattern = rule.Pattern.String()
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%d", rule.Name, rule.VulnType, rule.Severity,
+		rule.ClassName, rule.MethodName, rule.CWE, pattern, len(rule.SeverityOverrides))
+}
+
+func (t *ruleTable) encode(chain []model.ChainStep) []spilledStep {
+	steps := make([]spilledStep, len(chain))
+	for i, step := range chain {
+		s := spilledStep{ChainStep: step, Rule: t.id(step.Rule)}
+		for j := range step.AlsoMatched {
+			s.AlsoMatched = append(s.AlsoMatched, t.id(&step.AlsoMatched[j]))
+		}
+		if step.Rule != nil && step.SeverityOverride != nil {
+			for j, o := range step.Rule.SeverityOverrides {
+				if o == *step.SeverityOverride {
+					s.SeverityOverride = j + 1
+					break
+				}
+			}
+		}
+		s.ChainStep.Rule, s.ChainStep.AlsoMatched, s.ChainStep.SeverityOverride = nil, nil, nil
+		steps[i] = s
+	}
+	return steps
+}
+
+func decodeSpilled(steps []spilledStep, rules []*model.SinkRule) []model.ChainStep {
+	rule := func(id int) *model.SinkRule {
+		if id <= 0 || id > len(rules) {
+			return nil
+		}
+		return rules[id-1]
+	}
+	chain := make([]model.ChainStep, len(steps))
+	for i, s := range steps {
+		step := s.ChainStep
+		step.Rule = rule(s.Rule)
+		for _, id := range s.AlsoMatched {
+			if r := rule(id); r != nil {
+				step.AlsoMatched = append(step.AlsoMatched, *r)
+			}
+		}
+		if step.Rule != nil && s.SeverityOverride > 0 && s.SeverityOverride <= len(step.Rule.SeverityOverrides) {
+			step.SeverityOverride = &step.Rule.SeverityOverrides[s.SeverityOverride-1]
+		}
+		chain[i] = step
+	}
+	return chain
+}
@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// findingChain 一条两步的合成链路，Sink 在 file 第 line 行
+func findingChain(rule *model.SinkRule, file string, line int) []model.ChainStep {
+	return []model.ChainStep{
+		{File: file, Line: line, Func: "exec", Code: fmt.Sprintf("Runtime.getRuntime().exec(cmd%d);", line), Rule: rule},
+		{File: file, Line: line - 1, Func: "handle", Code: "String cmd = request.getParameter(\"cmd\");"},
+	}
+}
+
+// testSinks 两种实现各一个 (临时文件在测试结束时删除)
+func testSinks(t *testing.T) map[string]FindingSink {
+	t.Helper()
+	spill, err := NewSpillSink(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks := map[string]FindingSink{"memory": NewMemorySink(), "spill": spill}
+	t.Cleanup(func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	})
+	return sinks
+}
+
+func TestFindingSinkChain(t *testing.T) {
+	rule := &model.SinkRule{Name: "Runtime.exec", VulnType: "RCE", Severity: "critical"}
+	for name, sink := range testSinks(t) {
+		t.Run(name, func(t *testing.T) {
+			for line := 10; line < 13; line++ {
+				if err := sink.Record(findingChain(rule, "/p/A.java", line)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := sink.Chain(3); err == nil {
+				t.Error("Chain(3) with 3 findings: want an out of range error")
+			}
+
+			// Chain 与 Each 的顺序一致
+			i := 0
+			sink.Each(func(want []model.ChainStep) error {
+				got, err := sink.Chain(i)
+				if err != nil {
+					t.Fatalf("Chain(%d): %v", i, err)
+				}
+				if got[0].Line != want[0].Line || got[0].Code != want[0].Code || got[1].Code != want[1].Code {
+					t.Errorf("Chain(%d) = %+v, Each returned %+v", i, got[0], want[0])
+				}
+				if got[0].Rule == nil || got[0].Rule.Name != rule.Name {
+					t.Errorf("Chain(%d) rule = %v, want %s", i, got[0].Rule, rule.Name)
+				}
+				i++
+				return nil
+			})
+
+			// Rewrite 之后按下标读到修改后的链路 (临时文件被替换，记录的位置随之更新)
+			if err := sink.Rewrite(func(chain []model.ChainStep) {
+				chain[0].Module = fmt.Sprintf("module-%d", chain[0].Line)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				got, err := sink.Chain(i)
+				if err != nil {
+					t.Fatalf("Chain(%d) after Rewrite: %v", i, err)
+				}
+				if want := fmt.Sprintf("module-%d", 10+i); got[0].Module != want {
+					t.Errorf("Chain(%d) after Rewrite: module = %q, want %q", i, got[0].Module, want)
+				}
+			}
+		})
+	}
+}
+
+// 写入失败后留在内存中的链路排在文件之后，下标接着文件中的链路
+func TestSpillSinkChainOverflow(t *testing.T) {
+	sink, err := NewSpillSink(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	spill := sink.(*spillFindings)
+
+	rule := &model.SinkRule{Name: "Runtime.exec", VulnType: "RCE"}
+	sink.Record(findingChain(rule, "/p/A.java", 10))
+	spill.file.Close() // 之后的写入失败
+	if err := sink.Record(findingChain(rule, "/p/A.java", 20)); err == nil {
+		t.Fatal("Record after closing the spill file: want an error")
+	}
+	sink.Record(findingChain(rule, "/p/A.java", 30))
+
+	// 文件已经关闭，只检查留在内存中的链路
+	for i, want := range map[int]int{1: 20, 2: 30} {
+		got, err := sink.Chain(i)
+		if err != nil {
+			t.Fatalf("Chain(%d): %v", i, err)
+		}
+		if got[0].Line != want {
+			t.Errorf("Chain(%d) line = %d, want %d", i, got[0].Line, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package analysis
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// LsptracerIgnoreFile 与 .gitignore 语法相同、只对 LSPTracer 生效的忽略文件
+const LsptracerIgnoreFile = ".lsptracerignore"
+
+// ignorePattern .gitignore 中的一条规则
+type ignorePattern struct {
+	base    string         // 规则文件所在目录 (相对 IgnoreMatcher.root，"/" 分隔，根目录为 "")
+	re      *regexp.Regexp // 匹配相对 base 的路径
+	negate  bool           // !pattern: 重新包含
+	dirOnly bool           // pattern/: 只匹配目录
+}
+
+// IgnoreMatcher 按 .gitignore 语义判断路径是否被忽略
+// 根目录和各级子目录中的 .gitignore / .lsptracerignore 按需加载，越深的文件优先级越高，同一文件中后面的规则优先；
+// 被忽略的目录由遍历方跳过，因此其中的文件无法被重新包含 (与 git 一致)
+type IgnoreMatcher struct {
+	root  string
+	files []string // 每个目录中依次读取的规则文件
+
+	mu    sync.Mutex
+	rules map[string][]ignorePattern // 目录 (相对 root) -> 从根目录到该目录累积的规则
+}
+
+// NewIgnoreMatcher 创建以 root 为起点的匹配器: gitignore 为 true 时读取 .gitignore (以及根目录的 .git/info/exclude)，
+// .lsptracerignore 总是读取；root 为空时返回 nil (不忽略任何路径)
+func NewIgnoreMatcher(root string, gitignore bool) *IgnoreMatcher {
+	if root == "" {
+		return nil
+	}
+	m := &IgnoreMatcher{root: filepath.Clean(root), rules: make(map[string][]ignorePattern)}
+	if gitignore {
+		m.files = append(m.files, ".gitignore")
+	}
+	m.files = append(m.files, LsptracerIgnoreFile)
+
+	var rootRules []ignorePattern
+	if gitignore {
+		rootRules = append(rootRules, readIgnoreFile(filepath.Join(m.root, ".git", "info", "exclude"), "")...)
+	}
+	for _, name := range m.files {
+		rootRules = append(rootRules, readIgnoreFile(filepath.Join(m.root, name), "")...)
+	}
+	m.rules[""] = rootRules
+	return m
+}
+
+// IgnoreRoot 返回 .gitignore 链的起点: 项目所在的 git 仓库根目录，不在仓库中时为项目根目录本身
+func IgnoreRoot(projectRoot string) string {
+	for dir := filepath.Clean(projectRoot); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return filepath.Clean(projectRoot)
+		}
+	}
+}
+
+// Ignored 路径是否被忽略；m 为 nil 或路径不在 root 之下时返回 false
+func (m *IgnoreMatcher) Ignored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	dir := ""
+	if i := strings.LastIndex(rel, "/"); i != -1 {
+		dir = rel[:i]
+	}
+
+	rules := m.dirRules(dir)
+	for i := len(rules) - 1; i >= 0; i-- {
+		p := rules[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if p.base != "" {
+			if !strings.HasPrefix(rel, p.base+"/") {
+				continue
+			}
+			target = rel[len(p.base)+1:]
+		}
+		if p.re.MatchString(target) {
+			return !p.negate
+		}
+	}
+	return false
+}
+
+// dirRules 返回对 dir 中的路径生效的全部规则 (上级目录的规则在前)
+func (m *IgnoreMatcher) dirRules(dir string) []ignorePattern {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dirRulesLocked(dir)
+}
+
+func (m *IgnoreMatcher) dirRulesLocked(dir string) []ignorePattern {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	parent := ""
+	if i := strings.LastIndex(dir, "/"); i != -1 {
+		parent = dir[:i]
+	}
+	inherited := m.dirRulesLocked(parent)
+
+	var own []ignorePattern
+	for _, name := range m.files {
+		own = append(own, readIgnoreFile(filepath.Join(m.root, filepath.FromSlash(dir), name), dir)...)
+	}
+	rules := inherited
+	if len(own) > 0 {
+		rules = append(append([]ignorePattern(nil), inherited...), own...)
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// readIgnoreFile 读取规则文件 (不存在时返回 nil)
+func readIgnoreFile(path, base string) []ignorePattern {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := parseIgnoreLine(scanner.Text(), base); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// parseIgnoreLine 解析一行 gitignore 规则: 空行和 # 注释跳过，\# \! 转义，
+// 末尾的 / 表示只匹配目录，开头或中间包含 / 的规则相对规则文件所在目录，否则匹配任意层级的名称
+func parseIgnoreLine(line, base string) (ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// 末尾未转义的空格忽略
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	p := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// globToRegexp 把 gitignore 的通配符转换为正则: * 和 ? 不跨越 /，** 匹配任意层级目录
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString("(?:.*/)?") // **/ 或 a/**/b: 零个或多个目录
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*") // a/**: 目录中的所有内容
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,190 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// ignoreCase 一个路径 (相对 root，"/" 分隔) 的期望结果
+type ignoreCase struct {
+	path    string
+	isDir   bool
+	ignored bool
+}
+
+func checkIgnored(t *testing.T, m *IgnoreMatcher, root string, tests []ignoreCase) {
+	t.Helper()
+	for _, tt := range tests {
+		if got := m.Ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.ignored {
+			t.Errorf("Ignored(%s, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoredPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore": "# 注释\n" +
+			"*.log\n" +
+			"!keep.log\n" +
+			"out/\n" +
+			"/dist\n" +
+			"docs/*.html\n" +
+			"**/fixtures/**\n" +
+			"tmp?\n" +
+			"\\#notes\n" +
+			"trailing   \n",
+	})
+	m := NewIgnoreMatcher(root, true)
+	checkIgnored(t, m, root, []ignoreCase{
+		{"app.log", false, true},
+		{"src/main/app.log", false, true},
+		{"keep.log", false, false}, // 后面的否定规则优先
+		{"src/keep.log", false, false},
+		{"out", true, true},
+		{"src/out", true, true},
+		{"out", false, false}, // 只匹配目录
+		{"dist", true, true},
+		{"dist", false, true},
+		{"src/dist", true, false}, // 以 / 开头的规则只匹配根目录
+		{"docs/index.html", false, true},
+		{"docs/api/index.html", false, false}, // * 不跨越 /
+		{"src/test/fixtures/Data.java", false, true},
+		{"tmp1", true, true},
+		{"tmp12", true, false},
+		{"#notes", false, true},
+		{"trailing", false, true},
+		{"src/Main.java", false, false},
+	})
+}
+
+// 子目录中的 .gitignore 只对该目录生效，且优先于上级目录的规则
+func TestIgnoredNested(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":                "*.gen.java\n",
+		"api/.gitignore":            "!Keep.gen.java\n/local/\n",
+		"api/sub/.gitignore":        "Keep.gen.java\n",
+		"web/local/Page.java":       "",
+		"api/local/Secret.java":     "",
+		"api/Keep.gen.java":         "",
+		"api/sub/Keep.gen.java":     "",
+		"web/Keep.gen.java":         "",
+		"api/sub/Other.gen.java":    "",
+		"api/sub/local/Local.java":  "",
+		"api/src/main/Service.java": "",
+	})
+	m := NewIgnoreMatcher(root, true)
+	checkIgnored(t, m, root, []ignoreCase{
+		{"api/Keep.gen.java", false, false},
+		{"api/sub/Keep.gen.java", false, true},
+		{"api/sub/Other.gen.java", false, true},
+		{"web/Keep.gen.java", false, true},
+		{"api/local", true, true},
+		{"api/sub/local", true, false}, // 锚定在 api/ 下
+		{"web/local", true, false},
+		{"api/src/main/Service.java", false, false},
+	})
+}
+
+// .lsptracerignore 总是读取；.gitignore 和 .git/info/exclude 只在 gitignore 为 true 时读取
+func TestIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".git/info/exclude":          "scratch/\n",
+		".gitignore":                 "generated/\n",
+		LsptracerIgnoreFile:          "legacy/\n",
+		"src/" + LsptracerIgnoreFile: "Fixture*.java\n",
+	})
+	checkIgnored(t, NewIgnoreMatcher(root, true), root, []ignoreCase{
+		{"scratch", true, true},
+		{"generated", true, true},
+		{"legacy", true, true},
+		{"src/Fixture1.java", false, true},
+	})
+	checkIgnored(t, NewIgnoreMatcher(root, false), root, []ignoreCase{
+		{"scratch", true, false},
+		{"generated", true, false},
+		{"legacy", true, true},
+		{"src/Fixture1.java", false, true},
+	})
+
+	// nil 匹配器和 root 之外的路径不忽略任何内容
+	if m := NewIgnoreMatcher("", true); m != nil || m.Ignored(filepath.Join(root, "legacy"), true) {
+		t.Error("empty root should give a nil matcher that ignores nothing")
+	}
+	if NewIgnoreMatcher(filepath.Join(root, "src"), true).Ignored(filepath.Join(root, "legacy"), true) {
+		t.Error("path outside the root is ignored")
+	}
+}
+
+// 项目在 git 仓库的子目录中时，从仓库根目录开始应用 .gitignore
+func TestIgnoreRoot(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		".git/HEAD":                      "ref: refs/heads/main\n",
+		".gitignore":                     "services/app/build-cache/\n",
+		"services/app/pom.xml":           "",
+		"services/app/src/A.java":        "",
+		"services/app/build-cache/x.bin": "",
+	})
+	project := filepath.Join(repo, "services", "app")
+	if got := IgnoreRoot(project); got != repo {
+		t.Errorf("IgnoreRoot = %s, want %s", got, repo)
+	}
+	m := NewIgnoreMatcher(IgnoreRoot(project), true)
+	if !m.Ignored(filepath.Join(project, "build-cache"), true) {
+		t.Error("repository .gitignore is not applied to the project subdirectory")
+	}
+
+	plain := t.TempDir()
+	if got := IgnoreRoot(plain); got != plain {
+		t.Errorf("IgnoreRoot outside a repository = %s, want %s", got, plain)
+	}
+}
+
+// 忽略的目录和文件在遍历时跳过，但被忽略的构建目录中的生成源码仍然会被索引
+func TestWalkPolicyIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":            "target/\nlegacy/\n*Generated.java\n",
+		"src/Main.java":         "",
+		"src/FooGenerated.java": "",
+		"legacy/Old.java":       "",
+		"target/generated-sources/annotations/Mapper.java":          "",
+		"target/generated-sources/annotations/MapperGenerated.java": "",
+	})
+	m := NewIgnoreMatcher(root, true)
+	index := WalkPolicy{Generated: true, Ignore: m}
+	scan := WalkPolicy{Ignore: m}
+	dir := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+
+	for _, tt := range []struct {
+		policy WalkPolicy
+		path   string
+		skip   bool
+	}{
+		{index, "src", false},
+		{index, "legacy", true},
+		{index, "target", false},
+		{index, "target/generated-sources", false},
+		{index, "target/generated-sources/annotations", false},
+		{scan, "target", true},
+		{scan, "legacy", true},
+		{WalkPolicy{}, "legacy", false},
+	} {
+		if got := tt.policy.SkipDir(root, dir(tt.path)); got != tt.skip {
+			t.Errorf("SkipDir(%s, generated=%v) = %v, want %v", tt.path, tt.policy.Generated, got, tt.skip)
+		}
+	}
+
+	if !index.SkipFile(dir("src/FooGenerated.java")) {
+		t.Error("ignored source file is not skipped")
+	}
+	if index.SkipFile(dir("src/Main.java")) {
+		t.Error("Main.java is skipped")
+	}
+	if index.SkipFile(dir("target/generated-sources/annotations/MapperGenerated.java")) {
+		t.Error("generated source in the build directory is skipped by the ignore rules")
+	}
+}
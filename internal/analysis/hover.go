@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// hoverVerdict 根据 hover 得到的接收者类型对候选 Sink 的判断
+type hoverVerdict int
+
+const (
+	hoverUnknown  hoverVerdict = iota // 无法判断，交给后续启发式
+	hoverMatch                        // 接收者类型就是规则的类
+	hoverMismatch                     // 接收者明确是其它类
+)
+
+// verifyByHover 在接收者变量上发送 textDocument/hover，从返回的声明中解析类型并与规则比较
+// 能够判断时同时返回依据 (接收者的声明类型)
+func (t *Tracer) verifyByHover(cand candidate) (hoverVerdict, string) {
+	if cand.Rule.MethodName == "<init>" || cand.Rule.Annotation {
+		return hoverUnknown, ""
+	}
+
+	raw, err := ReadLine(cand.File, cand.Line)
+	if err != nil {
+		return hoverUnknown, ""
+	}
+	// cand.Col 是相对去掉缩进后的代码，需要换算回原始行中的位置
+	offset := strings.Index(raw, cand.Code)
+	if offset == -1 {
+		return hoverUnknown, ""
+	}
+	receiver, col := receiverBefore(raw, offset+cand.Col)
+	if receiver == "" {
+		return hoverUnknown, ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var hover lsp.Hover
+	err = t.Client.Call(ctx, "textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]string{"uri": lsp.ToUri(cand.File)},
+		"position":     lsp.Position{Line: cand.Line, Character: col},
+	}, &hover)
+	if err != nil {
+		return hoverUnknown, ""
+	}
+
+	typ := parseHoverType(hover.Text(), receiver)
+	if typ == "" {
+		return hoverUnknown, ""
+	}
+	return matchRuleClass(typ, cand.Rule), fmt.Sprintf("hover: %s is declared as %s", receiver, typ)
+}
+
+// receiverBefore 返回 dotIdx 处 "." 之前的标识符及其起始列
+// 链式调用 (e.g. "getRuntime().exec") 没有可以 hover 的变量，返回空
+func receiverBefore(line string, dotIdx int) (string, int) {
+	if dotIdx <= 0 || dotIdx > len(line) || line[dotIdx] != '.' {
+		return "", 0
+	}
+	end := dotIdx
+	for end > 0 && line[end-1] == ' ' {
+		end--
+	}
+	start := end
+	for start > 0 && (isAlphaNumByte(line[start-1]) || line[start-1] == '$') {
+		start--
+	}
+	if start == end {
+		return "", 0
+	}
+	return line[start:end], start
+}
+
+func isAlphaNumByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+var genericArgsRe = regexp.MustCompile(`<[^<>]*>`)
+
+// parseHoverType 从 hover 文本中解析变量的声明类型
+// JDT.LS 对变量返回 "java.sql.Statement stmt - com.example.Dao.query(String)"，
+// 对类名 (静态调用的接收者) 返回 "java.nio.file.Files"
+func parseHoverType(text string, varName string) string {
+	declRe := regexp.MustCompile(`([\w.$]+)(?:\[\])*\s+` + regexp.QuoteMeta(varName) + `\b`)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		// 去掉泛型参数 (可能嵌套)，e.g. "Map<String, List<String>> m" -> "Map m"
+		for genericArgsRe.MatchString(line) {
+			line = genericArgsRe.ReplaceAllString(line, "")
+		}
+
+		if m := declRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		// 类型本身
+		name := strings.Fields(line)[0]
+		if name == varName || strings.HasSuffix(name, "."+varName) {
+			return name
+		}
+	}
+	return ""
+}
+
+// matchRuleClass 比较 hover 得到的类型和规则的类 (match_subtypes 时包含子类型)
+// 只有全限定名才能判定为不匹配；简单类名无法确定包，交给后续启发式
+func matchRuleClass(typ string, rule model.SinkRule) hoverVerdict {
+	if rule.MatchesClass(typ) {
+		return hoverMatch
+	}
+	if !strings.Contains(typ, ".") {
+		return hoverUnknown
+	}
+	return hoverMismatch
+}
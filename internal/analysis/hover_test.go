@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// 录制的 JDT.LS hover 结果: 变量显示声明类型的全限定名，类名 (静态调用) 显示类本身
+func TestHoverReceiverType(t *testing.T) {
+	statement := model.SinkRule{ClassName: "java.sql.Statement", MethodName: "executeQuery",
+		Subtypes: []string{"java.sql.PreparedStatement"}}
+	files := model.SinkRule{ClassName: "java.nio.file.Files", MethodName: "write"}
+	tests := []struct {
+		name     string
+		hover    string
+		receiver string
+		rule     model.SinkRule
+		typ      string
+		verdict  hoverVerdict
+	}{
+		{"local variable", `{"contents":[{"language":"java","value":"java.sql.Statement stmt - com.example.Dao.query(String)"}]}`,
+			"stmt", statement, "java.sql.Statement", hoverMatch},
+		{"subtype", `{"contents":[{"language":"java","value":"java.sql.PreparedStatement ps"}]}`,
+			"ps", statement, "java.sql.PreparedStatement", hoverMatch},
+		{"field with javadoc", `{"contents":[{"language":"java","value":"java.io.Writer out"},"The writer used for responses."]}`,
+			"out", statement, "java.io.Writer", hoverMismatch},
+		{"generic type", `{"contents":{"kind":"markdown","value":"` + "```java\\njava.util.Map<java.lang.String, java.util.List<java.lang.String>> stmt\\n```" + `"}}`,
+			"stmt", statement, "java.util.Map", hoverMismatch},
+		{"array", `{"contents":[{"language":"java","value":"java.sql.Statement[] stmt"}]}`,
+			"stmt", statement, "java.sql.Statement", hoverMatch},
+		{"static receiver", `{"contents":[{"language":"java","value":"java.nio.file.Files"},"This class consists exclusively of static methods."]}`,
+			"Files", files, "java.nio.file.Files", hoverMatch},
+		{"simple name only", `{"contents":"Statement stmt"}`,
+			"stmt", statement, "Statement", hoverUnknown},
+		{"no declaration", `{"contents":[]}`,
+			"stmt", statement, "", hoverUnknown},
+	}
+	for _, tt := range tests {
+		var hover lsp.Hover
+		if err := json.Unmarshal([]byte(tt.hover), &hover); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		typ := parseHoverType(hover.Text(), tt.receiver)
+		if typ != tt.typ {
+			t.Errorf("%s: type = %q, want %q", tt.name, typ, tt.typ)
+			continue
+		}
+		if typ == "" {
+			continue
+		}
+		if v := matchRuleClass(typ, tt.rule); v != tt.verdict {
+			t.Errorf("%s: verdict = %d, want %d", tt.name, v, tt.verdict)
+		}
+	}
+}
+
+func TestReceiverBefore(t *testing.T) {
+	tests := []struct {
+		line     string
+		receiver string
+		col      int
+	}{
+		{"        stmt.executeQuery(sql);", "stmt", 8},
+		{"    rs = this.stmt .executeQuery(sql);", "stmt", 14},
+		{"    Runtime.getRuntime().exec(cmd);", "", 0},
+		{".exec(cmd)", "", 0},
+	}
+	for _, tt := range tests {
+		receiver, col := receiverBefore(tt.line, strings.LastIndex(tt.line, "."))
+		if receiver != tt.receiver || col != tt.col {
+			t.Errorf("receiverBefore(%q) = %q, %d; want %q, %d", tt.line, receiver, col, tt.receiver, tt.col)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package analysis
+
+import (
+	"encoding/xml"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Java 语言级别探测: 生成的 .classpath 使用对应的 JRE 容器 (JavaSE-17 等)，
+// 语言级别不匹配时 record / switch 表达式 / 文本块会产生大量语法错误，符号解析随之失效
+
+// DefaultJavaLevel 探测不到时使用的语言级别
+const DefaultJavaLevel = "1.8"
+
+var (
+	propertyRefRe      = regexp.MustCompile(`^\$\{([^}]+)\}$`)
+	gradleCompatRe     = regexp.MustCompile(`(?:source|target)Compatibility\s*=\s*(?:JavaVersion\.VERSION_([\d_]+)|['"]?([\d.]+)['"]?)`)
+	gradleToolchainRe  = regexp.MustCompile(`JavaLanguageVersion\.of\(\s*['"]?(\d+)['"]?\s*\)`)
+	modernSyntaxRe     = regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|static|final)\s+)*(?:record\s+\w+\s*[(<]|sealed\s+(?:abstract\s+)?(?:class|interface)\b|non-sealed\s)`)
+	javaVersionFieldRe = regexp.MustCompile(`JAVA_VERSION="([^"]+)"`)
+)
+
+// pomCompiler pom.xml 中与语言级别相关的部分
+type pomCompiler struct {
+	Parent struct {
+		RelativePath string `xml:"relativePath"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Plugins []struct {
+		ArtifactID    string `xml:"artifactId"`
+		Configuration struct {
+			Release string `xml:"release"`
+			Source  string `xml:"source"`
+		} `xml:"configuration"`
+	} `xml:"build>plugins>plugin"`
+}
+
+// JavaLevel 探测到的语言级别及其来源
+type JavaLevel struct {
+	Level  string // "1.8" / "11" / "17" / "21" ...
+	Source string // 探测依据 (用于日志)
+}
+
+// ExecutionEnvironment 对应的 Eclipse 执行环境名 (JavaSE-1.8 / JavaSE-17)
+func (l JavaLevel) ExecutionEnvironment() string {
+	return "JavaSE-" + l.Level
+}
+
+// Major 主版本号 (1.8 -> 8)
+func (l JavaLevel) Major() int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(l.Level, "1."))
+	return n
+}
+
+// DetectJavaLevel 依次从 pom.xml (maven.compiler.release / source，支持引用父 POM 的属性)、
+// Gradle 的 sourceCompatibility / toolchain 探测语言级别；根目录没有时取各模块中最高的级别。
+// 都没有时扫描源码中的 record / sealed 关键字，最后回退到 DefaultJavaLevel
+func DetectJavaLevel(root string, followSymlinks bool) JavaLevel {
+	if level, ok := buildFileLevel(root); ok {
+		return level
+	}
+
+	var best JavaLevel
+	modern := ""
+	WalkProject(root, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "target" || name == "build" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			if path != root {
+				if level, ok := buildFileLevel(path); ok && level.Major() > best.Major() {
+					best = level
+				}
+			}
+			return nil
+		}
+		if modern == "" && best.Level == "" && strings.HasSuffix(d.Name(), ".java") {
+			if content, err := os.ReadFile(path); err == nil && modernSyntaxRe.Match(content) {
+				modern = path
+			}
+		}
+		return nil
+	})
+
+	switch {
+	case best.Level != "":
+		return best
+	case modern != "":
+		rel, _ := filepath.Rel(root, modern)
+		return JavaLevel{Level: "17", Source: "record/sealed syntax in " + filepath.ToSlash(rel)}
+	}
+	return JavaLevel{Level: DefaultJavaLevel, Source: "default"}
+}
+
+// buildFileLevel 读取 dir 下构建文件中声明的语言级别
+func buildFileLevel(dir string) (JavaLevel, bool) {
+	if level, ok := pomLevel(filepath.Join(dir, "pom.xml")); ok {
+		return level, true
+	}
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if m := gradleToolchainRe.FindSubmatch(data); m != nil {
+			return JavaLevel{Level: normalizeJavaLevel(string(m[1])), Source: name + " toolchain"}, true
+		}
+		if m := gradleCompatRe.FindSubmatch(data); m != nil {
+			v := string(m[2])
+			if len(m[1]) > 0 {
+				v = strings.ReplaceAll(string(m[1]), "_", ".")
+			}
+			if level := normalizeJavaLevel(v); level != "" {
+				return JavaLevel{Level: level, Source: name + " sourceCompatibility"}, true
+			}
+		}
+	}
+	return JavaLevel{}, false
+}
+
+// pomLevel 按 release 优先于 source 的顺序读取 pom.xml 中的语言级别
+// 属性引用 (${java.version}) 先在本 POM 中查找，找不到时再查找父 POM (只向上一级)
+func pomLevel(path string) (JavaLevel, bool) {
+	pom, ok := readPomCompiler(path)
+	if !ok {
+		return JavaLevel{}, false
+	}
+	props := pomProperties(pom)
+
+	var parentProps map[string]string
+	parentPath := filepath.Join(filepath.Dir(path), "..", "pom.xml")
+	if rel := strings.TrimSpace(pom.Parent.RelativePath); rel != "" {
+		parentPath = filepath.Join(filepath.Dir(path), rel)
+		if !strings.HasSuffix(parentPath, ".xml") {
+			parentPath = filepath.Join(parentPath, "pom.xml")
+		}
+	}
+	if parent, ok := readPomCompiler(parentPath); ok {
+		parentProps = pomProperties(parent)
+	}
+
+	resolve := func(v string) string {
+		v = strings.TrimSpace(v)
+		for i := 0; i < 3; i++ {
+			m := propertyRefRe.FindStringSubmatch(v)
+			if m == nil {
+				break
+			}
+			if next, ok := props[m[1]]; ok {
+				v = next
+			} else if next, ok := parentProps[m[1]]; ok {
+				v = next
+			} else {
+				return ""
+			}
+		}
+		return normalizeJavaLevel(v)
+	}
+
+	candidates := []struct{ value, source string }{
+		{props["maven.compiler.release"], "maven.compiler.release"},
+		{props["maven.compiler.source"], "maven.compiler.source"},
+	}
+	for _, p := range pom.Plugins {
+		if p.ArtifactID == "maven-compiler-plugin" {
+			candidates = append(candidates,
+				struct{ value, source string }{p.Configuration.Release, "maven-compiler-plugin release"},
+				struct{ value, source string }{p.Configuration.Source, "maven-compiler-plugin source"})
+		}
+	}
+	candidates = append(candidates,
+		struct{ value, source string }{props["java.version"], "java.version"},
+		struct{ value, source string }{parentProps["maven.compiler.release"], "parent maven.compiler.release"},
+		struct{ value, source string }{parentProps["maven.compiler.source"], "parent maven.compiler.source"},
+		struct{ value, source string }{parentProps["java.version"], "parent java.version"})
+
+	for _, c := range candidates {
+		if level := resolve(c.value); level != "" {
+			return JavaLevel{Level: level, Source: "pom.xml " + c.source}, true
+		}
+	}
+	return JavaLevel{}, false
+}
+
+func readPomCompiler(path string) (pomCompiler, bool) {
+	var pom pomCompiler
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pom, false
+	}
+	return pom, xml.Unmarshal(data, &pom) == nil
+}
+
+func pomProperties(pom pomCompiler) map[string]string {
+	props := make(map[string]string)
+	for _, e := range pom.Properties.Entries {
+		props[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	return props
+}
+
+// normalizeJavaLevel 统一版本写法: "8" / "1.8" -> "1.8"，"17" / "17.0" -> "17"；无法识别时返回空
+func normalizeJavaLevel(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return ""
+	}
+	v = strings.TrimPrefix(v, "1.")
+	if i := strings.Index(v, "."); i != -1 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return ""
+	}
+	if n <= 8 {
+		return "1." + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// JDKMajorVersion 读取 JDK 目录下 release 文件中的主版本号 (读取失败时为 0)
+func JDKMajorVersion(javaHome string) int {
+	data, err := os.ReadFile(filepath.Join(javaHome, "release"))
+	if err != nil {
+		return 0
+	}
+	m := javaVersionFieldRe.FindSubmatch(data)
+	if m == nil {
+		return 0
+	}
+	return JavaLevel{Level: normalizeJavaLevel(string(m[1]))}.Major()
+}
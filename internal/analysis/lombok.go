@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var lombokImportRe = regexp.MustCompile(`(?m)^\s*import\s+(?:static\s+)?lombok\.`)
+
+// lombokBuildFiles 声明依赖的构建文件
+var lombokBuildFiles = []string{"pom.xml", "build.gradle", "build.gradle.kts"}
+
+// UsesLombok 项目是否使用 Lombok: 任一构建文件声明了 lombok 依赖，或者源码中 import 了 lombok 包
+// 返回命中的文件 (相对项目根目录)，未使用时为空
+func UsesLombok(root string, followSymlinks bool) string {
+	found := ""
+	WalkProject(root, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "target" || name == "build" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		hit := false
+		switch {
+		case isLombokBuildFile(name):
+			content, err := os.ReadFile(path)
+			hit = err == nil && bytes.Contains(content, []byte("lombok"))
+		case strings.HasSuffix(name, ".java"):
+			content, err := os.ReadFile(path)
+			hit = err == nil && lombokImportRe.Match(content)
+		}
+		if hit {
+			found = path
+			if rel, err := filepath.Rel(root, path); err == nil {
+				found = filepath.ToSlash(rel)
+			}
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+func isLombokBuildFile(name string) bool {
+	for _, f := range lombokBuildFiles {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lsp"
+)
+
+// MethodMatch workspace/symbol 找到的方法声明
+type MethodMatch struct {
+	Class     string // 声明所在类 (containerName)
+	Signature string // 方法名和参数 (e.g. "runSql(String)")
+	File      string
+	Line      int // 方法名所在行 (0-based)
+	Col       int
+}
+
+func (m MethodMatch) String() string {
+	return fmt.Sprintf("%s#%s (%s:%d)", m.Class, m.Signature, m.File, m.Line+1)
+}
+
+// FindMethods 按 "com.acme.dao.LegacyDao#runSql" 查找方法声明
+// 类名可以是全限定名或简单类名；带参数签名时 (e.g. "LegacyDao#runSql(String, int)") 只匹配该重载
+func (t *Tracer) FindMethods(spec string) ([]MethodMatch, error) {
+	class, method, ok := strings.Cut(spec, "#")
+	if !ok || class == "" || method == "" {
+		return nil, fmt.Errorf("expected 'com.example.Class#method' or 'Class#method(ArgType)'")
+	}
+	name, params, hasParams := strings.Cut(method, "(")
+	name = strings.TrimSpace(name)
+	if hasParams {
+		params = normalizeParams(strings.TrimSuffix(strings.TrimSpace(params), ")"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	symbols, err := t.Client.WorkspaceSymbol(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []MethodMatch
+	for _, sym := range symbols {
+		if sym.Kind != symbolKindMethod && sym.Kind != symbolKindConstructor {
+			continue
+		}
+		symName, symParams, _ := strings.Cut(sym.Name, "(")
+		if symName != name || !classMatches(sym.ContainerName, class) {
+			continue
+		}
+		symParams, _, _ = strings.Cut(symParams, ")")
+		if hasParams && normalizeParams(symParams) != params {
+			continue
+		}
+		matches = append(matches, MethodMatch{
+			Class:     sym.ContainerName,
+			Signature: symName + "(" + normalizeParams(symParams) + ")",
+			File:      lsp.FromUri(sym.Location.Uri),
+			Line:      sym.Location.Range.Start.Line,
+			Col:       sym.Location.Range.Start.Character,
+		})
+	}
+	return matches, nil
+}
+
+// classMatches 比较 containerName 与用户给出的类名 (全限定名、简单类名或内部类 Outer.Inner)
+func classMatches(container, class string) bool {
+	container = strings.ReplaceAll(container, "$", ".")
+	class = strings.ReplaceAll(strings.TrimSpace(class), "$", ".")
+	return container == class || strings.HasSuffix(container, "."+class)
+}
+
+// normalizeParams 统一参数列表的空白: "String , int" -> "String, int"
+func normalizeParams(params string) string {
+	parts := strings.Split(params, ",")
+	for i, p := range parts {
+		parts[i] = strings.Join(strings.Fields(p), " ")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,208 @@
+package analysis
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 多模块项目: 每个带有源码的 Maven/Gradle 模块生成独立的 Eclipse 项目
+// 所有源码放进同一个项目时，不同模块中的同名类会互相冲突，引用查询会跨模块串到错误的类上。
+// 模块之间的依赖 (pom.xml 中的兄弟模块 artifactId、build.gradle 中的 project(':x')) 写成项目引用，
+// 跨模块的调用链仍然可以被追踪
+
+// generatedMarker 写在生成的 .project 的 comment 中，清理时只删除带有该标记的模块配置
+const generatedMarker = "Generated by LSPTracer"
+
+var gradleProjectDepRe = regexp.MustCompile(`project\(\s*(?:path\s*:\s*)?['"]([^'"]+)['"]\s*\)`)
+
+// EclipseModule 一个生成了独立 Eclipse 项目的模块
+type EclipseModule struct {
+	Dir     string   // 模块目录 (绝对路径)
+	Name    string   // 项目名，在工作区中唯一
+	SrcDirs []string // 模块内的源码目录 (绝对路径)
+	Deps    []string // 依赖的其它模块的项目名
+
+	artifact string   // pom.xml 的 artifactId 或 Gradle 模块目录名
+	depRefs  []string // 构建文件中引用的 artifactId / Gradle 项目名
+}
+
+// groupSourceModules 按最近的构建文件把源码目录分组为模块 (按目录排序)，并解析模块之间的依赖
+func groupSourceModules(root string, srcDirs []string) []EclipseModule {
+	cache := make(map[string]string)
+	byDir := make(map[string]*EclipseModule)
+	var dirs []string
+	for _, src := range srcDirs {
+		rel := moduleOf(root, src, cache)
+		dir := filepath.Join(root, filepath.FromSlash(rel))
+		m, ok := byDir[dir]
+		if !ok {
+			m = &EclipseModule{Dir: dir}
+			byDir[dir] = m
+			dirs = append(dirs, dir)
+		}
+		m.SrcDirs = append(m.SrcDirs, src)
+	}
+	sort.Strings(dirs)
+
+	modules := make([]EclipseModule, 0, len(dirs))
+	names := make(map[string]int)
+	for _, dir := range dirs {
+		m := *byDir[dir]
+		m.artifact, m.depRefs = readBuildDeps(dir)
+		m.Name = filepath.Base(dir)
+		names[m.Name]++
+		modules = append(modules, m)
+	}
+	// 不同目录下的同名模块: 用相对路径区分
+	for i := range modules {
+		if names[modules[i].Name] > 1 {
+			if rel, err := filepath.Rel(root, modules[i].Dir); err == nil && rel != "." {
+				modules[i].Name = strings.ReplaceAll(filepath.ToSlash(rel), "/", "_")
+			}
+		}
+	}
+
+	// Maven 按 artifactId 引用，Gradle 按项目 (目录) 名引用
+	byArtifact := make(map[string]string)
+	for _, m := range modules {
+		if m.artifact != "" {
+			byArtifact[m.artifact] = m.Name
+		}
+	}
+	for _, m := range modules {
+		if _, ok := byArtifact[filepath.Base(m.Dir)]; !ok {
+			byArtifact[filepath.Base(m.Dir)] = m.Name
+		}
+	}
+	for i := range modules {
+		seen := make(map[string]bool)
+		for _, ref := range modules[i].depRefs {
+			if name, ok := byArtifact[ref]; ok && name != modules[i].Name && !seen[name] {
+				seen[name] = true
+				modules[i].Deps = append(modules[i].Deps, name)
+			}
+		}
+	}
+	return modules
+}
+
+// pomModel pom.xml 中用到的部分 (顶层的 artifactId，不含 parent 中的)
+type pomModel struct {
+	ArtifactID   string `xml:"artifactId"`
+	Dependencies []struct {
+		ArtifactID string `xml:"artifactId"`
+	} `xml:"dependencies>dependency"`
+}
+
+// readBuildDeps 读取模块的标识和它引用的其它模块
+func readBuildDeps(dir string) (artifact string, deps []string) {
+	if data, err := os.ReadFile(filepath.Join(dir, "pom.xml")); err == nil {
+		var pom pomModel
+		if xml.Unmarshal(data, &pom) == nil {
+			for _, d := range pom.Dependencies {
+				deps = append(deps, d.ArtifactID)
+			}
+			return pom.ArtifactID, deps
+		}
+	}
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, m := range gradleProjectDepRe.FindAllStringSubmatch(string(data), -1) {
+			path := m[1]
+			deps = append(deps, path[strings.LastIndex(path, ":")+1:])
+		}
+		return filepath.Base(dir), deps
+	}
+	return "", nil
+}
+
+// writeEclipseProject 在 dir 下写入 .project 和 .classpath
+// srcDirs 为绝对路径 (写成相对 dir 的路径)，projectRefs 为依赖的其它项目名，level 决定 JRE 容器的执行环境
+func writeEclipseProject(dir, name string, srcDirs, projectRefs []string, level JavaLevel) error {
+	var refs strings.Builder
+	for _, ref := range projectRefs {
+		fmt.Fprintf(&refs, "\t\t<project>%s</project>\n", xmlEscape(ref))
+	}
+	projectContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<projectDescription>
+	<name>%s</name>
+	<comment>%s</comment>
+	<projects>
+%s	</projects>
+	<buildSpec>
+		<buildCommand>
+			<name>org.eclipse.jdt.core.javabuilder</name>
+			<arguments>
+			</arguments>
+		</buildCommand>
+	</buildSpec>
+	<natures>
+		<nature>org.eclipse.jdt.core.javanature</nature>
+	</natures>
+</projectDescription>
+`, xmlEscape(name), generatedMarker, refs.String())
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<classpath>` + "\n")
+	// kind="src" 告诉 JDTLS 这里面是源码，建立索引！
+	for _, src := range srcDirs {
+		rel, _ := filepath.Rel(dir, src)
+		if rel == "." {
+			rel = ""
+		}
+		fmt.Fprintf(&sb, "\t<classpathentry kind=\"src\" path=\"%s\"/>\n", xmlEscape(filepath.ToSlash(rel)))
+	}
+	// 依赖的模块作为项目引用 (导出给依赖本模块的项目，相当于传递依赖)，跨模块的类和引用可以被解析
+	for _, ref := range projectRefs {
+		fmt.Fprintf(&sb, "\t<classpathentry combineaccessrules=\"false\" exported=\"true\" kind=\"src\" path=\"/%s\"/>\n", xmlEscape(ref))
+	}
+	// 添加基本的 JRE 容器 (让 String, System 等基础类能识别)
+	fmt.Fprintf(&sb, "\t<classpathentry kind=\"con\" path=\"org.eclipse.jdt.launching.JRE_CONTAINER/org.eclipse.jdt.internal.debug.ui.launcher.StandardVMType/%s\"/>\n", level.ExecutionEnvironment())
+	sb.WriteString(`	<classpathentry kind="output" path="bin"/>` + "\n")
+	sb.WriteString(`</classpath>`)
+
+	if err := os.WriteFile(filepath.Join(dir, ".project"), []byte(projectContent), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ".classpath"), []byte(sb.String()), 0644)
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// RemoveGeneratedModuleConfigs 删除之前生成在子模块中的 .project/.classpath (只删除带有生成标记的)
+// 根目录的配置由 ForceClean 处理
+func RemoveGeneratedModuleConfigs(root string, followSymlinks bool) {
+	root = filepath.Clean(root)
+	WalkProject(root, followSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "target" || name == "build" || name == "node_modules") {
+			return filepath.SkipDir
+		}
+		if path == root {
+			return nil
+		}
+		project := filepath.Join(path, ".project")
+		if data, err := os.ReadFile(project); err == nil && strings.Contains(string(data), generatedMarker) {
+			os.Remove(project)
+			os.Remove(filepath.Join(path, ".classpath"))
+		}
+		return nil
+	})
+}
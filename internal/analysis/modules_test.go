@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+)
+
+// writeTree 在 root 下写入文件 (相对路径，/ 分隔)
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// 两个 Maven 模块中有同名类 Util: 每个模块生成独立的项目，web 引用 common
+func TestGenerateEclipseConfigModules(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"pom.xml": `<project><artifactId>demo</artifactId><packaging>pom</packaging>
+  <modules><module>common</module><module>web</module></modules></project>`,
+		"common/pom.xml": `<project><parent><artifactId>demo</artifactId></parent><artifactId>demo-common</artifactId></project>`,
+		"common/src/main/java/com/example/common/Util.java": "package com.example.common;\n\npublic class Util {}\n",
+		"web/pom.xml": `<project><parent><artifactId>demo</artifactId></parent><artifactId>demo-web</artifactId>
+  <dependencies>
+    <dependency><artifactId>demo-common</artifactId></dependency>
+    <dependency><artifactId>spring-boot-starter-web</artifactId></dependency>
+  </dependencies></project>`,
+		"web/src/main/java/com/example/common/Util.java":  "package com.example.common;\n\npublic class Util {}\n",
+		"web/src/test/java/com/example/web/UtilTest.java": "package com.example.web;\n\nclass UtilTest {}\n",
+	})
+
+	folders, err := GenerateEclipseConfig(root, false, JavaLevel{Level: "17"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "common"), filepath.Join(root, "web")}
+	if !slices.Equal(folders, want) {
+		t.Fatalf("workspace folders = %v, want %v", folders, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".project")); err == nil {
+		t.Error("multi-module project generated a .project at the root")
+	}
+
+	common := readFile(t, filepath.Join(root, "common", ".project"))
+	if !strings.Contains(common, "<name>common</name>") || strings.Contains(common, "<project>") {
+		t.Errorf("common/.project:\n%s", common)
+	}
+	if web := readFile(t, filepath.Join(root, "web", ".project")); !strings.Contains(web, "<project>common</project>") {
+		t.Errorf("web/.project does not reference common:\n%s", web)
+	}
+	classpath := readFile(t, filepath.Join(root, "web", ".classpath"))
+	for _, entry := range []string{`kind="src" path="src/main/java"`, `kind="src" path="src/test/java"`, `kind="src" path="/common"`, "JavaSE-17"} {
+		if !strings.Contains(classpath, entry) {
+			t.Errorf("web/.classpath has no %s:\n%s", entry, classpath)
+		}
+	}
+	if strings.Contains(readFile(t, filepath.Join(root, "common", ".classpath")), "web") {
+		t.Error("common/.classpath contains web sources")
+	}
+
+	// 清理只删除生成的模块配置，用户自己的 .project 保留
+	writeTree(t, root, map[string]string{"tools/.project": "<projectDescription><name>tools</name></projectDescription>"})
+	RemoveGeneratedModuleConfigs(root, false)
+	for _, rel := range []string{"common/.project", "common/.classpath", "web/.project", "web/.classpath"} {
+		if _, err := os.Stat(filepath.Join(root, rel)); err == nil {
+			t.Errorf("%s was not removed", rel)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "tools", ".project")); err != nil {
+		t.Error("a user .project was removed")
+	}
+}
+
+// 只有一个模块时在根目录生成项目，不注册额外的 workspace folder
+func TestGenerateEclipseConfigSingleModule(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"pom.xml":                            `<project><artifactId>demo</artifactId></project>`,
+		"src/main/java/com/example/App.java": "package com.example;\n\nclass App {}\n",
+	})
+	folders, err := GenerateEclipseConfig(root, false, JavaLevel{Level: "1.8"})
+	if err != nil || folders != nil {
+		t.Fatalf("GenerateEclipseConfig = %v, %v; want no workspace folders", folders, err)
+	}
+	if classpath := readFile(t, filepath.Join(root, ".classpath")); !strings.Contains(classpath, `path="src/main/java"`) {
+		t.Errorf(".classpath:\n%s", classpath)
+	}
+}
+
+// Gradle 按 project(':x') 引用模块 (目录名)；不同目录下的同名模块用相对路径区分
+func TestGroupSourceModulesGradle(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"settings.gradle":          "include 'app', 'core', 'shared:util', 'x:api', 'y:api'\n",
+		"app/build.gradle":         "dependencies {\n    implementation project(':core')\n    implementation project(path: ':shared:util')\n    implementation project(':app')\n}\n",
+		"core/build.gradle.kts":    "dependencies {}\n",
+		"shared/util/build.gradle": "",
+		"x/api/build.gradle":       "",
+		"y/api/build.gradle":       "",
+	})
+	var srcDirs []string
+	for _, m := range []string{"app", "core", "shared/util", "x/api", "y/api"} {
+		srcDirs = append(srcDirs, filepath.Join(root, filepath.FromSlash(m), "src", "main", "java"))
+	}
+	srcDirs = append(srcDirs, filepath.Join(root, "app", "src", "test", "java"))
+
+	var got []string
+	for _, m := range groupSourceModules(root, srcDirs) {
+		got = append(got, m.Name+" "+strings.Join(m.Deps, ",")+" "+strings.Repeat("*", len(m.SrcDirs)))
+	}
+	want := []string{"app core,util **", "core  *", "util  *", "x_api  *", "y_api  *"}
+	if !slices.Equal(got, want) {
+		t.Errorf("modules (name deps *srcDirs):\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTracerWorkspaceFolders(t *testing.T) {
+	tr := &Tracer{ProjectRoot: filepath.FromSlash("/src/demo")}
+	if got := tr.workspaceFolders(); len(got) != 1 || got[0].Uri != lsp.ToUri(tr.ProjectRoot) || got[0].Name != "Target" {
+		t.Errorf("single project folders = %+v", got)
+	}
+	tr.WorkspaceFolders = []string{filepath.FromSlash("/src/demo/common"), filepath.FromSlash("/src/demo/web")}
+	var names []string
+	for _, f := range tr.workspaceFolders() {
+		names = append(names, f.Name+" "+f.Uri)
+	}
+	want := []string{"common " + lsp.ToUri(tr.WorkspaceFolders[0]), "web " + lsp.ToUri(tr.WorkspaceFolders[1])}
+	if !slices.Equal(names, want) {
+		t.Errorf("module folders = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"path/filepath"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// AttributeModules 把每条链路归到 Sink 文件所在的模块 (最近的带 pom.xml / build.gradle 的上级目录)，
+// 并按 -owners 的映射记录负责的团队；项目之外的文件 (e.g. 解压的依赖源码) 不归属任何模块
+func AttributeModules(chains [][]model.ChainStep, projectRoot string, owners model.Owners) {
+	root := filepath.Clean(projectRoot)
+	cache := make(map[string]string)
+	for _, stack := range chains {
+		if len(stack) == 0 {
+			continue
+		}
+		sink := &stack[0]
+		rel, err := filepath.Rel(root, sink.File)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		sink.Module = moduleOf(root, filepath.Dir(sink.File), cache)
+		sink.Owner = owners.Match(filepath.ToSlash(rel))
+	}
+}
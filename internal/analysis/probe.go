@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/textutil"
+)
+
+// indexProbeTimeout 索引检查中 workspace/symbol 请求的超时
+const indexProbeTimeout = 10 * time.Second
+
+// typeDeclRe 顶层类型声明 (行首的修饰符之后)
+var typeDeclRe = regexp.MustCompile(`^\s*(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*(?:class|interface|enum|record|@interface)\s+(\w+)`)
+
+// readyProbeTimeout 就绪探测中单次 documentSymbol 请求的超时
+const readyProbeTimeout = 3 * time.Second
+
+// probeSymbols 就绪探测: 锚点文件的 documentSymbol 是否有结果
+// 直接发送请求而不经过 Docs 的缓存，否则第一次探测的空结果会被一直复用
+func (t *Tracer) probeSymbols(anchor string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), readyProbeTimeout)
+	defer cancel()
+	var symbols []json.RawMessage
+	err := t.Client.Call(ctx, "textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]string{"uri": lsp.ToUri(anchor)},
+	}, &symbols)
+	return err == nil && len(symbols) > 0
+}
+
+// probeIndex 语言服务器就绪后检查索引是否为空:
+// 锚点文件的 documentSymbol 和锚点中声明的类的 workspace/symbol 都没有结果时，说明项目没有被索引
+// (通常是生成的 .classpath 指向了错误的根目录)，继续扫描只会得到 0 个发现
+func (t *Tracer) probeIndex(anchor string) error {
+	symbols, symErr := t.Docs.Symbols(anchor)
+	if symErr == nil && len(symbols) > 0 {
+		return nil
+	}
+
+	class := declaredTypeName(anchor)
+	var wsErr error
+	if class != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), indexProbeTimeout)
+		found, err := t.Client.WorkspaceSymbol(ctx, class)
+		cancel()
+		if err == nil && len(found) > 0 {
+			return nil
+		}
+		wsErr = err
+	}
+	return t.indexProbeError(anchor, class, symErr, wsErr)
+}
+
+// indexProbeError 索引为空时的错误说明: 两个探测的结果、检测到的源码目录和排查建议
+func (t *Tracer) indexProbeError(anchor, class string, symErr, wsErr error) error {
+	var b strings.Builder
+	b.WriteString("the language server produced an empty index:\n")
+	fmt.Fprintf(&b, "    documentSymbol for the anchor %s: %s\n", relPath(t.ProjectRoot, anchor), probeOutcome(symErr))
+	if class != "" {
+		fmt.Fprintf(&b, "    workspace/symbol for class %s declared there: %s\n", class, probeOutcome(wsErr))
+	}
+
+	srcDirs, _, err := PlanEclipseConfig(t.ProjectRoot, t.FollowSymlinks)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "Source root detection failed: %v\n", err)
+	case len(srcDirs) == 0:
+		b.WriteString("No source roots were detected under the project root.\n")
+	default:
+		b.WriteString("Detected source roots:\n")
+		for _, dir := range srcDirs {
+			fmt.Fprintf(&b, "    %s\n", relPath(t.ProjectRoot, dir))
+		}
+	}
+	b.WriteString("The generated .classpath probably points at the wrong root (check -project and the package declarations of the anchor).\n")
+	b.WriteString("Use -dry-run to review the source roots, -lsp-log <file> to record the LSP traffic, or -no-index-probe to scan anyway.")
+	return fmt.Errorf("%s", b.String())
+}
+
+func probeOutcome(err error) string {
+	if err != nil {
+		return "failed (" + err.Error() + ")"
+	}
+	return "no results"
+}
+
+// declaredTypeName 返回文件中第一个顶层类型声明的类名 (注释和字符串中的内容不计)，找不到时为空
+func declaredTypeName(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range textutil.MaskJavaSource(strings.Split(string(content), "\n")) {
+		if m := typeDeclRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// relPath 相对项目根目录的展示路径
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
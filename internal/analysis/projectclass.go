@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// workspaceSymbolTimeout 查找项目内的类时 workspace/symbol 请求的超时
+const workspaceSymbolTimeout = 5 * time.Second
+
+// projectClasses 按全限定类名缓存 workspace/symbol 找到的类声明 (请求失败时不缓存，下次重试)
+type projectClasses struct {
+	mu      sync.Mutex
+	classes map[string][]lsp.SymbolInformation
+}
+
+// lookup 返回项目中声明 fqn 的类符号: 简单类名相同，containerName 是包名 (嵌套类是外层类的全限定名)
+func (c *projectClasses) lookup(client *lsp.Client, fqn string) ([]lsp.SymbolInformation, error) {
+	c.mu.Lock()
+	found, ok := c.classes[fqn]
+	c.mu.Unlock()
+	if ok {
+		return found, nil
+	}
+
+	container, name := "", fqn
+	if idx := strings.LastIndex(fqn, "."); idx != -1 {
+		container, name = fqn[:idx], fqn[idx+1:]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceSymbolTimeout)
+	defer cancel()
+	symbols, err := client.WorkspaceSymbol(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	found = nil
+	for _, sym := range symbols {
+		if sym.Name == name && sym.ContainerName == container && isClassKind(sym.Kind) {
+			found = append(found, sym)
+		}
+	}
+
+	c.mu.Lock()
+	if c.classes == nil {
+		c.classes = make(map[string][]lsp.SymbolInformation)
+	}
+	c.classes[fqn] = found
+	c.mu.Unlock()
+	return found, nil
+}
+
+// verifyProjectClass 确认 scope: project 规则的候选点: 调用的定义 (方法、构造的类或注解) 必须位于
+// workspace/symbol 找到的规则类所在的源文件中。项目内的类不经过 import 检查 (同包调用不需要 import，
+// 同名的类也可能来自其它包)，也不按文件名猜测
+func (t *Tracer) verifyProjectClass(cand candidate) model.VerificationResult {
+	raw, err := ReadLine(cand.File, cand.Line)
+	if err != nil {
+		return model.VerificationResult{}
+	}
+	// cand.Col 是相对去掉缩进后的代码，需要换算回原始行中的位置
+	offset := strings.Index(raw, cand.Code)
+	if offset == -1 {
+		return model.VerificationResult{}
+	}
+	col, ok := definitionColumn(cand)
+	if !ok {
+		return model.VerificationResult{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var res json.RawMessage
+	err = t.Client.Call(ctx, "textDocument/definition", map[string]interface{}{
+		"textDocument": map[string]string{"uri": lsp.ToUri(cand.File)},
+		"position":     lsp.Position{Line: cand.Line, Character: offset + col},
+	}, &res)
+	uri := definitionURI(res)
+	if err != nil || !strings.HasPrefix(uri, "file:") {
+		return model.VerificationResult{}
+	}
+	defined := lsp.NormalizePath(lsp.FromUri(uri))
+
+	for _, className := range cand.Rule.Classes() {
+		symbols, err := t.projectClasses.lookup(t.Client, className)
+		if err != nil {
+			continue
+		}
+		for _, sym := range symbols {
+			if lsp.NormalizePath(lsp.FromUri(sym.Location.Uri)) == defined {
+				return model.VerificationResult{Method: model.VerifiedByWorkspace,
+					Evidence: fmt.Sprintf("definition resolved to %s declared in %s (workspace/symbol)", className, relPath(t.ProjectRoot, lsp.FromUri(sym.Location.Uri)))}
+			}
+		}
+	}
+	return model.VerificationResult{}
+}
+
+// definitionColumn 候选点中发送 definition 的位置 (相对 cand.Code):
+// 方法调用取 "." 之后的方法名，构造和注解取类名
+func definitionColumn(cand candidate) (int, bool) {
+	if !cand.Rule.Annotation && cand.Rule.MethodName != "<init>" {
+		return cand.Col + 1, cand.Col+1 < len(cand.Code)
+	}
+	short := cand.Rule.ClassName
+	if idx := strings.LastIndex(short, "."); idx != -1 {
+		short = short[idx+1:]
+	}
+	idx := strings.Index(cand.Code[cand.Col:], short)
+	if idx == -1 {
+		return 0, false
+	}
+	return cand.Col + idx, true
+}
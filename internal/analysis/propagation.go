@@ -0,0 +1,221 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// 流入调用点的值经过的已知工具函数 (model.PropagationTable): trim / decode 之类的函数不改变结论，
+// 只有表达式中所有非常量的部分都经过了对当前漏洞类型有效的净化函数时，才认为输入被净化 (可信度降低)
+
+const (
+	passThroughNote   = "🔄 Pass-through: `%s` (input survives)"
+	neutralNote       = "🟢 Neutral: `%s` (result does not carry the input)"
+	notSanitizerNote  = "❗ `%s` does not neutralize %s"
+	partialNote       = "❗ Only part of the value is sanitized by `%s`"
+	sanitizedNote     = "🧼 Sanitized for %s by `%s`"
+	sanitizedPrefix   = "🧼"
+	maxPropagationHop = 8 // 嵌套调用最多展开的层数
+)
+
+// 表达式的传播结论
+const (
+	flowTainted   = iota // 输入可能到达 (包括无法判断的调用)
+	flowSafe             // 常量或 NEUTRAL 函数的结果
+	flowSanitized        // 经过了对该漏洞类型有效的净化函数
+)
+
+var castPrefixRe = regexp.MustCompile(`^\(\s*[\w.$]+(?:<[^()]*>)?(?:\[\])*\s*\)\s*`)
+
+// propagationNotes 分析流入调用点的表达式 expr，返回传播相关的说明；vulnType 是链路 Sink 的漏洞类型
+func (t *Tracer) propagationNotes(expr, vulnType string) []string {
+	if t.Propagation == nil || strings.TrimSpace(expr) == "" {
+		return nil
+	}
+	w := &propagationWalk{table: t.Propagation, vulnType: vulnType, seen: make(map[string]bool)}
+	flow := flowSafe
+	for _, arg := range splitArgs(expr) {
+		flow = combineFlow(flow, w.walk(arg, 0))
+	}
+
+	notes := w.notes
+	switch {
+	case flow == flowSanitized:
+		notes = append(notes, fmt.Sprintf(sanitizedNote, vulnType, strings.Join(w.sanitizers, "`, `")))
+	case len(w.sanitizers) > 0:
+		notes = append(notes, fmt.Sprintf(partialNote, strings.Join(w.sanitizers, "`, `")))
+	}
+	return notes
+}
+
+// sanitizedBy 返回链路中证明输入已被净化的说明 (去掉前缀)，没有时为空
+func sanitizedBy(chain []model.ChainStep) string {
+	for _, step := range chain {
+		for _, info := range step.Analysis {
+			if strings.HasPrefix(info, sanitizedPrefix) {
+				return strings.TrimSpace(strings.TrimPrefix(info, sanitizedPrefix))
+			}
+		}
+	}
+	return ""
+}
+
+type propagationWalk struct {
+	table      model.PropagationTable
+	vulnType   string
+	notes      []string
+	sanitizers []string
+	seen       map[string]bool
+}
+
+func (w *propagationWalk) note(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if !w.seen[text] {
+		w.seen[text] = true
+		w.notes = append(w.notes, text)
+	}
+}
+
+// walk 返回表达式的传播结论: 拼接的各部分分别判断，已知函数按表中的语义展开
+func (w *propagationWalk) walk(expr string, hop int) int {
+	expr = unwrapExpr(expr)
+	if isStrictConstant(expr) || enumConstRe.MatchString(expr) {
+		return flowSafe
+	}
+	if parts := splitTopLevel(expr, '+'); len(parts) > 1 {
+		flow := flowSafe
+		for _, part := range parts {
+			flow = combineFlow(flow, w.walk(part, hop))
+		}
+		return flow
+	}
+
+	qualifier, method, args, ok := splitCall(expr)
+	if !ok || hop >= maxPropagationHop {
+		return flowTainted
+	}
+	rule, ok := w.table.Lookup(qualifier, method)
+	if !ok {
+		return flowTainted
+	}
+	switch rule.Kind {
+	case model.PropagationNeutral:
+		w.note(neutralNote, rule.Function)
+		return flowSafe
+	case model.PropagationSanitizerFor:
+		if rule.Sanitizes(w.vulnType) {
+			if !w.seen[rule.Function] {
+				w.seen[rule.Function] = true
+				w.sanitizers = append(w.sanitizers, rule.Function)
+			}
+			return flowSanitized
+		}
+		w.note(notSanitizerNote, rule.Function, w.vulnType)
+		return flowTainted
+	}
+
+	// PASS_THROUGH: 静态方法的输入是第一个参数，实例方法的输入是接收者
+	w.note(passThroughNote, rule.Function)
+	if rule.Static() {
+		if parts := splitArgs(args); len(parts) > 0 {
+			return w.walk(parts[0], hop+1)
+		}
+		return flowSafe
+	}
+	return w.walk(qualifier, hop+1)
+}
+
+// combineFlow 合并两部分的结论: 任一部分可能携带输入则整体携带输入
+func combineFlow(a, b int) int {
+	switch {
+	case a == flowTainted || b == flowTainted:
+		return flowTainted
+	case a == flowSanitized || b == flowSanitized:
+		return flowSanitized
+	}
+	return flowSafe
+}
+
+// unwrapExpr 去掉外层的括号和类型转换
+func unwrapExpr(expr string) string {
+	for {
+		expr = strings.TrimSpace(expr)
+		if m := castPrefixRe.FindString(expr); m != "" && len(m) < len(expr) {
+			expr = expr[len(m):]
+			continue
+		}
+		if strings.HasPrefix(expr, "(") && closingParen(expr, 0) == len(expr)-1 {
+			expr = expr[1 : len(expr)-1]
+			continue
+		}
+		return expr
+	}
+}
+
+// splitCall 把以调用结尾的表达式拆分为接收者/类名、方法名和参数: `a.b(x).c(y)` -> "a.b(x)", "c", "y"
+// 没有接收者 (同一个类中的方法、new X(...)) 时 qualifier 为空
+func splitCall(expr string) (qualifier, method, args string, ok bool) {
+	if !strings.HasSuffix(expr, ")") {
+		return "", "", "", false
+	}
+	open := -1
+	for i := 0; i < len(expr); i++ {
+		if expr[i] != '(' {
+			continue
+		}
+		end := closingParen(expr, i)
+		if end == -1 {
+			return "", "", "", false
+		}
+		if end == len(expr)-1 {
+			open = i
+			break
+		}
+		i = end
+	}
+	if open <= 0 {
+		return "", "", "", false
+	}
+	callee := strings.TrimSpace(expr[:open])
+	dot := strings.LastIndex(callee, ".")
+	method = strings.TrimSpace(callee[dot+1:])
+	if !isVar(method) {
+		return "", "", "", false
+	}
+	if dot > 0 {
+		qualifier = strings.TrimSpace(callee[:dot])
+	}
+	return qualifier, method, expr[open+1 : len(expr)-1], true
+}
+
+// closingParen 返回 open 处 "(" 对应的 ")" 的位置 (忽略字符串中的括号)，没有闭合时返回 -1
+func closingParen(expr string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(expr); i++ {
+		c := expr[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// 流入调用点的表达式按漏洞类型判断: 只有所有非常量的部分都经过了对该类型有效的净化函数时才算被净化
+func TestPropagationNotes(t *testing.T) {
+	tracer := NewTracer(nil, t.TempDir(), "light")
+	tests := []struct {
+		expr, vulnType string
+		sanitized      string   // 期望的净化说明 (sanitizedBy 的结果)，空表示没有被净化
+		notes          []string // 其它必须出现的说明
+	}{
+		{"HtmlUtils.htmlEscape(name)", "XSS", "Sanitized for XSS by `HtmlUtils.htmlEscape`", nil},
+		// HTML 转义之后进入 SQL / 命令: 不降级，并说明原因
+		{"HtmlUtils.htmlEscape(name)", "SQLI", "", []string{fmt.Sprintf(notSanitizerNote, "HtmlUtils.htmlEscape", "SQLI")}},
+		{"\"SELECT * FROM t WHERE n = '\" + HtmlUtils.htmlEscape(name) + \"'\"", "SQLI", "", []string{fmt.Sprintf(notSanitizerNote, "HtmlUtils.htmlEscape", "SQLI")}},
+		{"HtmlUtils.htmlEscape(cmd)", "RCE", "", nil},
+		// 常量拼接不影响结论，未净化的部分会
+		{"\"<b>\" + HtmlUtils.htmlEscape(name) + \"</b>\"", "XSS", "Sanitized for XSS by `HtmlUtils.htmlEscape`", nil},
+		{"HtmlUtils.htmlEscape(name) + title", "XSS", "", []string{fmt.Sprintf(partialNote, "HtmlUtils.htmlEscape")}},
+		// PASS_THROUGH 展开到输入: 静态方法看第一个参数，实例方法看接收者
+		{"StringUtils.trim(HtmlUtils.htmlEscape(name))", "XSS", "Sanitized for XSS by `HtmlUtils.htmlEscape`", []string{fmt.Sprintf(passThroughNote, "StringUtils.trim")}},
+		{"StringUtils.trim(name)", "XSS", "", []string{fmt.Sprintf(passThroughNote, "StringUtils.trim")}},
+		{"Optional.ofNullable(name).orElse(\"guest\")", "SQLI", "", []string{fmt.Sprintf(passThroughNote, "orElse"), fmt.Sprintf(passThroughNote, "Optional.ofNullable")}},
+		{"URLDecoder.decode(path, \"UTF-8\").trim()", "PATH_TRAVERSAL", "", []string{fmt.Sprintf(passThroughNote, "URLDecoder.decode")}},
+		{"(String) Encode.forHtml(name).toLowerCase()", "XSS", "Sanitized for XSS by `Encode.forHtml`", nil},
+		// 转换为数字对任意类型有效
+		{"Integer.parseInt(id)", "SQLI", "Sanitized for SQLI by `Integer.parseInt`", nil},
+		{"\"rm \" + Long.valueOf(id)", "RCE", "Sanitized for RCE by `Long.valueOf`", nil},
+		// URL 编码只对部分类型有效
+		{"URLEncoder.encode(next, \"UTF-8\")", "REDIRECT", "Sanitized for REDIRECT by `URLEncoder.encode`", nil},
+		{"URLEncoder.encode(q, \"UTF-8\")", "SQLI", "", nil},
+		// NEUTRAL 的结果不携带输入，但也不算净化
+		{"name.length()", "SQLI", "", []string{fmt.Sprintf(neutralNote, "length")}},
+		{"cmd", "RCE", "", nil},
+		{"process(cmd)", "RCE", "", nil},
+	}
+	for _, tt := range tests {
+		notes := tracer.propagationNotes(tt.expr, tt.vulnType)
+		step := model.ChainStep{Analysis: notes}
+		if got := sanitizedBy([]model.ChainStep{step}); got != tt.sanitized {
+			t.Errorf("%s (%s): sanitized %q, want %q\nnotes: %q", tt.expr, tt.vulnType, got, tt.sanitized, notes)
+		}
+		for _, want := range tt.notes {
+			if !slices.Contains(notes, want) {
+				t.Errorf("%s (%s): missing note %q\nnotes: %q", tt.expr, tt.vulnType, want, notes)
+			}
+		}
+	}
+
+	tracer.Propagation = nil
+	if notes := tracer.propagationNotes("HtmlUtils.htmlEscape(name)", "XSS"); notes != nil {
+		t.Errorf("notes without a propagation table: %q", notes)
+	}
+}
+
+// 经过有效净化函数的链路降低可信度；同一个函数出现在 SQL 注入链路中时不降级
+func TestPropagationConfidence(t *testing.T) {
+	tracer := NewTracer(nil, t.TempDir(), "light")
+	score := func(vulnType string) *model.Confidence {
+		sink := model.ChainStep{
+			Func:       "run",
+			Confidence: &model.Confidence{Verification: model.VerificationResult{Method: model.VerifiedByDefinition}, DataFlow: true},
+		}
+		through := model.ChainStep{Func: "render", Analysis: tracer.propagationNotes("HtmlUtils.htmlEscape(input)", vulnType)}
+		chain := []model.ChainStep{sink, through, {Func: "handle"}}
+		scoreChain(chain, true, true, true)
+		return chain[0].Confidence
+	}
+	if c := score("XSS"); c.Sanitizer == "" || c.Level() != model.ConfidenceMedium {
+		t.Errorf("XSS through htmlEscape: sanitizer %q, level %s, want a downgrade to Medium", c.Sanitizer, c.Level())
+	}
+	c := score("SQLI")
+	if c.Sanitizer != "" || c.Level() != model.ConfidenceHigh {
+		t.Errorf("SQLI through htmlEscape: sanitizer %q, level %s, want High", c.Sanitizer, c.Level())
+	}
+	if explained := strings.Join(c.Explain(), "\n"); strings.Contains(explained, "sanitizer") {
+		t.Errorf("SQLI explanation mentions a sanitizer:\n%s", explained)
+	}
+}
+
+// 规则文件中的条目扩展内置表: 公司内部的 SQL 转义函数对 SQL 注入有效
+func TestPropagationFromRules(t *testing.T) {
+	table, err := model.LoadPropagation(filepath.Join("testdata", "propagation", "rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer(nil, t.TempDir(), "light")
+	tracer.Propagation = table
+
+	notes := tracer.propagationNotes("Texts.normalize(SqlSafe.quote(name))", "SQLI")
+	if got := sanitizedBy([]model.ChainStep{{Analysis: notes}}); got != "Sanitized for SQLI by `SqlSafe.quote`" {
+		t.Errorf("custom sanitizer: %q (notes %q)", got, notes)
+	}
+	if notes := tracer.propagationNotes("SqlSafe.quote(name)", "RCE"); sanitizedBy([]model.ChainStep{{Analysis: notes}}) != "" {
+		t.Errorf("SQL sanitizer applied to RCE: %q", notes)
+	}
+}
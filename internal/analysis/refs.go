@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/textutil"
+)
+
+// 被拒绝的引用 (不是真正的调用点) 的原因
+const (
+	refInComment = "comment or string literal" // javadoc {@link Foo#bar}、日志消息中的方法名等
+	refInImport  = "import/package statement"  // import static 等
+)
+
+// maskedSources 按文件缓存屏蔽了注释和字符串的源码 (textutil.MaskJavaSource)，判断引用位置时使用
+type maskedSources struct {
+	mu    sync.Mutex
+	files map[string][]string
+}
+
+func (c *maskedSources) lines(path string) []string {
+	key := lsp.NormalizePath(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if masked, ok := c.files[key]; ok {
+		return masked
+	}
+	var masked []string
+	if content, err := os.ReadFile(path); err == nil {
+		masked = textutil.MaskJavaSource(strings.Split(string(content), "\n"))
+	}
+	if c.files == nil {
+		c.files = make(map[string][]string)
+	}
+	c.files[key] = masked
+	return masked
+}
+
+// RefStats 追踪时被拒绝的引用数量
+type RefStats struct {
+	InComment atomic.Int64
+	InImport  atomic.Int64
+}
+
+// rejectRef 返回引用不是调用点的原因 (位于注释、字符串字面量或 import/package 语句中)，是调用点时返回空
+// 读取失败或位置超出文件范围时不拒绝
+func (t *Tracer) rejectRef(ref lsp.Location) string {
+	path := lsp.FromUri(ref.Uri)
+	lines := t.masked.lines(path)
+	pos := ref.Range.Start
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+	line := lines[pos.Line]
+	code := strings.TrimSpace(line)
+	if strings.HasPrefix(code, "import ") || strings.HasPrefix(code, "package ") {
+		return refInImport
+	}
+	// 屏蔽后的源码中注释和字面量的内容都是空格，而引用位置上应该是标识符
+	if col := byteOffset(line, pos.Character); col < len(line) && line[col] == ' ' {
+		return refInComment
+	}
+	return ""
+}
+
+// countRejectedRef 统计被拒绝的引用，扫描结束时由 printRejectedRefs 汇总输出
+func (t *Tracer) countRejectedRef(reason string) {
+	switch reason {
+	case refInComment:
+		t.RefStats.InComment.Add(1)
+	case refInImport:
+		t.RefStats.InImport.Add(1)
+	}
+}
+
+// printRejectedRefs 输出被拒绝的引用数量
+func (t *Tracer) printRejectedRefs() {
+	comments, imports := t.RefStats.InComment.Load(), t.RefStats.InImport.Load()
+	if comments+imports > 0 {
+		fmt.Printf("[*] Ignored %d references in comments/strings and %d in import statements.\n", comments, imports)
+	}
+}
+
+// byteOffset 把 LSP 的 UTF-16 列号转换为行内的字节偏移
+func byteOffset(line string, utf16Col int) int {
+	units := 0
+	for i, r := range line {
+		if units >= utf16Col {
+			return i
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return len(line)
+}
+
+// lineKey 以 "文件:行" 为键的 map (已访问的调用点、已处理的 Sink 等) 使用的键
+// 文件统一经过 lsp.NormalizePath，同一个文件的不同写法 (盘符大小写、相对路径) 得到相同的键
+func lineKey(file string, line int) string {
+	return fmt.Sprintf("%s:%d", lsp.NormalizePath(file), line)
+}
@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+)
+
+// refAt 返回 fixture 中第 n 次出现 name 的位置 (0 起始行号，UTF-16 列号)
+func refAt(t *testing.T, path, marker, name string) lsp.Location {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		if !strings.Contains(line, marker) {
+			continue
+		}
+		col := strings.Index(line, name)
+		if col < 0 {
+			t.Fatalf("%q not found in line %d", name, i+1)
+		}
+		return lsp.Location{
+			Uri:   lsp.ToUri(path),
+			Range: lsp.Range{Start: lsp.Position{Line: i, Character: col}},
+		}
+	}
+	t.Fatalf("marker %q not found in %s", marker, path)
+	return lsp.Location{}
+}
+
+func TestRejectRef(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("testdata", "refs", "OrderController.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		marker string
+		want   string
+	}{
+		{"import static", refInImport},
+		{"{@link OrderService#exec", refInComment},
+		{"log.info(", refInComment},
+		{"// exec(cmd)", refInComment},
+		{"service.exec(cmd);", ""},
+	}
+	tr := &Tracer{}
+	for _, tt := range tests {
+		ref := refAt(t, path, tt.marker, "exec")
+		if got := tr.rejectRef(ref); got != tt.want {
+			t.Errorf("rejectRef(%q) = %q, want %q", tt.marker, got, tt.want)
+		}
+	}
+}
+
+func TestCountRejectedRef(t *testing.T) {
+	tr := &Tracer{}
+	tr.countRejectedRef(refInComment)
+	tr.countRejectedRef(refInComment)
+	tr.countRejectedRef(refInImport)
+	if got := tr.RefStats.InComment.Load(); got != 2 {
+		t.Errorf("InComment = %d, want 2", got)
+	}
+	if got := tr.RefStats.InImport.Load(); got != 1 {
+		t.Errorf("InImport = %d, want 1", got)
+	}
+}
+
+func TestByteOffset(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want int
+	}{
+		{"abc", 1, 1},
+		{"中文x", 2, 6},
+		{"😀x", 2, 4},
+		{"ab", 5, 2},
+	}
+	for _, tt := range tests {
+		if got := byteOffset(tt.line, tt.col); got != tt.want {
+			t.Errorf("byteOffset(%q, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestLineKey(t *testing.T) {
+	abs, err := filepath.Abs(filepath.Join("testdata", "refs", "OrderController.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 相对路径、带 .. 的路径和绝对路径得到相同的键 (已处理的 Sink、已访问的调用点按它去重)
+	same := []string{
+		abs,
+		filepath.Join("testdata", "refs", "OrderController.java"),
+		filepath.Join("testdata", "refs", "..", "refs", "OrderController.java"),
+		lsp.FromUri(lsp.ToUri(abs)),
+	}
+	want := lineKey(abs, 12)
+	for _, path := range same {
+		if got := lineKey(path, 12); got != want {
+			t.Errorf("lineKey(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if lineKey(abs, 13) == want {
+		t.Error("different lines share a key")
+	}
+}
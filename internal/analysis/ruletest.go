@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// 规则测试: 在 fixture 目录上只运行文本匹配和常量过滤阶段 (不启动 LSP)，
+// 并和代码行尾的期望注释比较:
+//
+//	stmt.executeQuery(sql);        // EXPECT: SQLI
+//	stmt.executeQuery("select 1"); // EXPECT: none
+//
+// 没有 EXPECT 注释的行期望不命中任何规则。
+
+var expectRe = regexp.MustCompile(`//\s*EXPECT:\s*(.*)$`)
+
+// FixtureResult 一行 fixture 代码的测试结果
+type FixtureResult struct {
+	File     string // 相对 fixture 目录的路径
+	Line     int    // 1-based
+	Code     string
+	Expected []string // 期望的漏洞类型 (空表示 none)
+	Got      []string // 实际命中的漏洞类型
+	Rules    []string // 实际命中的规则名
+	Pass     bool
+}
+
+// TestRules 在 fixtureDir 下所有 .java 文件上运行规则，返回每个带期望或有命中的行的结果
+func TestRules(rules []model.SinkRule, fixtureDir string) ([]FixtureResult, error) {
+	var results []FixtureResult
+
+	err := filepath.Walk(fixtureDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".java") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(fixtureDir, path)
+		results = append(results, testFixture(filepath.ToSlash(rel), strings.Split(string(content), "\n"), rules)...)
+		return nil
+	})
+	return results, err
+}
+
+func testFixture(name string, lines []string, rules []model.SinkRule) []FixtureResult {
+	var results []FixtureResult
+
+	// 匹配前去掉期望注释，避免注释内容影响参数提取
+	clean := make([]string, len(lines))
+	for i, line := range lines {
+		clean[i] = expectRe.ReplaceAllString(line, "")
+	}
+
+	for i, line := range lines {
+		expected, hasExpect := parseExpect(line)
+		code := clean[i]
+
+		var got, names []string
+		for _, m := range matchLine(clean, i, rules, false) {
+			if IsTypeMismatch(m.Code, m.Rule, clean, i) {
+				continue
+			}
+			got = appendUnique(got, m.Rule.VulnType)
+			names = append(names, m.Rule.Name)
+		}
+
+		if !hasExpect && len(got) == 0 {
+			continue
+		}
+		sort.Strings(got)
+
+		results = append(results, FixtureResult{
+			File:     name,
+			Line:     i + 1,
+			Code:     strings.TrimSpace(code),
+			Expected: expected,
+			Got:      got,
+			Rules:    names,
+			Pass:     sameStrings(expected, got),
+		})
+	}
+	return results
+}
+
+// parseExpect 解析 "// EXPECT: SQLI, XSS" / "// EXPECT: none"
+func parseExpect(line string) ([]string, bool) {
+	m := expectRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	var types []string
+	for _, part := range strings.Split(m[1], ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || strings.EqualFold(part, "none") {
+			continue
+		}
+		types = appendUnique(types, part)
+	}
+	sort.Strings(types)
+	return types, true
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
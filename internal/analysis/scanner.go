@@ -2,12 +2,20 @@ package analysis
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"LSPTracer/internal/events"
 	"LSPTracer/internal/lsp"
 	"LSPTracer/internal/model"
 
@@ -16,189 +24,652 @@ import (
 
 // 候选点结构
 type candidate struct {
-	File string
-	Line int
-	Col  int
-	Code string
-	Rule model.SinkRule
+	File  string
+	Line  int
+	Col   int
+	Code  string
+	Rule  model.SinkRule
+	Notes []string // 文本匹配阶段得到的分析信息 (e.g. 还原的 SQL 拼接表达式)
+	Arg   string   // 规则检查的参数表达式 (注解规则为空)
+
+	Composite bool // 来自 CompositeRule (已在方法内确认，不需要 LSP 验身)
+
+	Suppression *model.Suppression // Sink 行或上一行的 lsptracer:ignore 注释 (不丢弃候选点，由报告单独列出)
+
+	// 同一个调用 (文件+行+列) 上命中的其它规则的候选点，按等级从高到低 (mergeDuplicates 填写)
+	Also []candidate
+}
+
+// ScanStats ScanAndTrace 的统计信息
+type ScanStats struct {
+	Candidates    int            // 文本初筛得到的候选点 (含组合规则)
+	Verified      int            // 通过 LSP 验证 (或组合规则确认) 的 Sink
+	Traces        int            // 合并同一方法内的同类 Sink 后实际发起的追踪次数
+	RuleHits      map[string]int // 每条规则匹配到的候选点数量 (包含 0 次的规则)
+	CandidateScan time.Duration  // 文本初筛耗时
+	Warmup        time.Duration  // 预先打开候选文件并等待诊断平静的耗时
+	WarmedFiles   int            // 预先打开的文件数量
+	Tracing       time.Duration  // 验证和追踪耗时
+	VerifyTime    time.Duration  // LSP 验证候选点的总耗时 (不含组合规则)
+	VerifyCount   int            // LSP 验证的候选点数量
+	VerifiedBy    map[string]int // 每种确认方式 (model.VerifiedBy*) 确认的 Sink 数量
+}
+
+// AvgVerify 每个候选点的平均 LSP 验证耗时
+func (s ScanStats) AvgVerify() time.Duration {
+	if s.VerifyCount == 0 {
+		return 0
+	}
+	return s.VerifyTime / time.Duration(s.VerifyCount)
+}
+
+// ZeroHitRules 没有匹配到任何候选点的规则 (按名称排序)，常见原因是自定义规则的类名或方法名写错
+func (s ScanStats) ZeroHitRules() []string {
+	var names []string
+	for name, hits := range s.RuleHits {
+		if hits == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
-func (t *Tracer) ScanAndTrace(rules []model.SinkRule) {
+// ScanAndTrace 扫描并追踪所有候选 Sink
+// 语言服务器崩溃时会重启一次并从当前候选点继续；再次崩溃则返回错误 (已记录的结果仍然保留)
+func (t *Tracer) ScanAndTrace(rules []model.SinkRule) error {
 	color.Cyan("\n[*] Starting Smart Vulnerability Scan...")
+	realSinks := 0
 
 	// 1. 文本初筛 + 常量过滤
-	candidates := t.findCandidates(rules)
-	color.Blue("[*] Found %d potential risky sinks (Text Match).", len(candidates))
+	scanStart := time.Now()
+	candidates := withComposites(mergeDuplicates(t.findCandidates(rules)), t.findCompositeCandidates(t.CompositeRules))
+	t.Events.Emit(events.Event{Type: events.TypeCandidates, Count: len(candidates)})
+
+	t.Stats = ScanStats{Candidates: len(candidates), RuleHits: make(map[string]int), VerifiedBy: make(map[string]int)}
+	for _, rule := range rules {
+		t.Stats.RuleHits[rule.Name] = 0
+	}
+	for _, rule := range t.CompositeRules {
+		t.Stats.RuleHits[rule.Name] = 0
+	}
+	for _, cand := range candidates {
+		t.Stats.RuleHits[cand.Rule.Name]++
+		for _, also := range cand.Also {
+			t.Stats.RuleHits[also.Rule.Name]++
+		}
+	}
+	t.Stats.CandidateScan = time.Since(scanStart)
+
+	t.warmUp(candidates)
+	traceStart := time.Now()
+	defer func() {
+		t.Stats.Verified = realSinks
+		t.Stats.Tracing = time.Since(traceStart)
+	}()
+
 	color.Blue("[*] Verifying candidates with LSP (Loose Mode)...")
 
 	processedSinks := make(map[string]bool)
-	realSinks := 0
+	meter := newProgressMeter()
+
+	// 同一个方法内命中同一规则的多个 Sink (重载、工具方法中的多处调用) 只追踪一次:
+	// 候选点按 文件+行号 有序，同一方法的候选点是连续的，换到下一个方法时再发起追踪
+	var pending []*sinkGroup
+	pendingMethod := ""
+	flush := func() {
+		for _, g := range pending {
+			t.launchTrace(g)
+			t.Stats.Traces++
+		}
+		pending = nil
+	}
 
 	for i, cand := range candidates {
-		// 打印进度
-		fmt.Printf("\r    [%d/%d] Checking: %s", i+1, len(candidates), truncateString(cand.Code, 40))
+		// 语言服务器崩溃: 等待进行中的追踪结束后重启，并从当前候选点继续
+		if t.Client.Exited() {
+			t.Wg.Wait()
+			if err := t.restartServer(); err != nil {
+				return err
+			}
+		}
+
+		// 打印进度: 候选点进度、已完成追踪的 Sink、耗时和预计剩余时间
+		elapsed, eta := meter.tick(len(candidates) - i)
+		fmt.Printf("\r    [%d/%d] Sinks traced: %d/%d | %s elapsed, ETA %s | Checking: %s",
+			i+1, len(candidates), t.completedSinks(), t.Stats.Traces,
+			formatDuration(elapsed), formatDuration(eta), truncateString(cand.Code, 40))
 
-		sinkKey := fmt.Sprintf("%s:%d", cand.File, cand.Line)
+		sinkKey := callKey(cand)
 		if processedSinks[sinkKey] {
 			continue
 		}
 
 		// 2. LSP 验身 (或 heuristic 兜底)
-		if t.verifySink(cand) {
+		verified, verification, alsoMatched := t.verifyCandidate(cand)
+		if verification.OK() {
+			cand = verified
 
 			// 3. 启发式二次检查 (Heuristic Filter)
 
 			realSinks++
+			t.Stats.VerifiedBy[verification.Method]++
 			processedSinks[sinkKey] = true
 
-			fmt.Print("\r                                                                 \r")
-			color.Red("[+] Confirmed Sink: %s", strings.TrimSpace(cand.Code))
-			fmt.Printf("    File: %s:%d\n", filepath.Base(cand.File), cand.Line+1)
+			t.Events.Emit(events.Event{
+				Type: events.TypeSinkStart,
+				File: cand.File,
+				Line: cand.Line + 1,
+				Rule: cand.Rule.Name,
+				Code: strings.TrimSpace(cand.Code),
+			})
 
 			t.ReportedEntry = make(map[string]bool)
 
+			rule := cand.Rule
 			firstStep := model.ChainStep{
 				File:     cand.File,
 				Line:     cand.Line,
 				Func:     "Sink Detection",
 				Code:     cand.Code,
 				Analysis: []string{fmt.Sprintf("🚨 Matched Rule: %s", cand.Rule.Name)},
+				Rule:     &rule,
+
+				Suppression: cand.Suppression,
 			}
+			if len(alsoMatched) > 0 {
+				firstStep.AlsoMatched = alsoMatched
+				names := make([]string, len(alsoMatched))
+				for i, r := range alsoMatched {
+					names[i] = r.Name
+				}
+				firstStep.Analysis = append(firstStep.Analysis, fmt.Sprintf("🚨 Also matched: %s", strings.Join(names, ", ")))
+			}
+			firstStep.Analysis = append(firstStep.Analysis, cand.Notes...)
 
 			// Get Enclosing Function Name FIRST
-			funcName, fLine, _, fCol := t.GetEnclosingFunction(lsp.ToUri(cand.File), cand.Line)
+			fn, ok := t.GetEnclosingFunction(lsp.ToUri(cand.File), cand.Line)
 
 			// Analyze Variable Definition using Enclosing Function Name
-			analysisRes := AnalyzeCallSite(cand.File, cand.Line, funcName)
+			analysisRes := AnalyzeCallSite(cand.File, cand.Line, fn)
 			firstStep.Analysis = append(firstStep.Analysis, analysisRes.DataFlow...)
+			firstStep.Analysis = append(firstStep.Analysis, t.propagationNotes(analysisRes.Expr, cand.Rule.VulnType)...)
+			firstStep.Analysis = append(firstStep.Analysis, analysisRes.Notes...)
+			firstStep.Confidence = &model.Confidence{Verification: verification, DataFlow: analysisRes.Tainted()}
+			if cand.Rule.VulnType == "SSRF" {
+				control, notes := AnalyzeURL(cand.File, cand.Line, fn, cand.Rule)
+				firstStep.Analysis = append(firstStep.Analysis, notes...)
+				firstStep.URLControl = control
+			}
 
-			if funcName != "" {
-				firstStep.Func = funcName
-
-				// Acquire semaphore slot
-				t.Sem <- struct{}{}
-				t.Wg.Add(1)
+			if ok {
+				firstStep.Func, firstStep.Class = fn.Name, fn.Class
+				if start, end, ok := fn.SourceRange(); ok {
+					firstStep.FuncStartLine, firstStep.FuncEndLine = start, end
+				}
 
-				go func(file string, line, col int, stack []model.ChainStep) {
-					defer func() {
-						<-t.Sem
-						t.Wg.Done()
-					}()
-					// Initialize per-chain visited map
-					initialVisited := make(map[string]bool)
-					t.TraceChain(file, line, col, stack, initialVisited)
-				}(cand.File, fLine, fCol, []model.ChainStep{firstStep})
+				// 匿名类/lambda 中的 Sink: 从外层命名方法继续追踪
+				target, note := t.ResolveTraceTarget(cand.File, cand.Line, fn)
+				if note != "" {
+					firstStep.Analysis = append(firstStep.Analysis, note)
+				}
 
+				methodKey := fmt.Sprintf("%s:%d", lineKey(cand.File, target.SelectionStart), target.Column)
+				if methodKey != pendingMethod {
+					flush()
+					pendingMethod = methodKey
+				}
+				if g := findGroup(pending, rule.Name); g != nil {
+					g.step.Analysis = append(g.step.Analysis, fmt.Sprintf("📍 Also matched at line %d: `%s`", cand.Line+1, strings.TrimSpace(cand.Code)))
+					fmt.Printf("    Merged into the trace from line %d (same method)\n", g.step.Line+1)
+					continue
+				}
+				pending = append(pending, &sinkGroup{
+					label: fmt.Sprintf("%s:%d %s", filepath.Base(cand.File), cand.Line+1, truncateString(cand.Code, 60)),
+					file:  cand.File,
+					line:  target.SelectionStart,
+					col:   target.Column,
+					field: analysisRes.Field,
+					step:  firstStep,
+				})
 			} else {
-				// FIX: Route through RecordResult to enforce Strict Mode check
-				// (Previously: t.Results = append(t.Results, []model.ChainStep{firstStep}))
-				// Even for orphan sinks, we must pass them through RecordResult validation.
-				t.RecordResult([]model.ChainStep{firstStep})
+				t.recordOrphan(firstStep)
 			}
 		}
 	}
 
+	flush()
+	if realSinks > t.Stats.Traces {
+		color.Blue("[*] Consolidated %d confirmed sinks into %d traces (%.1fx fewer).",
+			realSinks, t.Stats.Traces, float64(realSinks)/float64(t.Stats.Traces))
+	}
+
 	// Wait for all trace chains to complete
 	color.Cyan("[*] Waiting for all trace chains to complete...")
 	t.Wg.Wait()
+	t.Console.Flush()
 	fmt.Println()
+	t.printSlowestSinks(5)
+	t.printRejectedRefs()
 
 	if realSinks == 0 {
 		color.Yellow("\n[-] No confirmed vulnerabilities found.")
 	} else {
-		color.Green("\n[+] Scan finished. Found %d confirmed vulnerability chains.", len(t.Results))
+		color.Green("\n[+] Scan finished. Found %d confirmed vulnerability chains.", t.Findings.Len())
 	}
+	return nil
 }
 
+// verifyCandidate 依次检查候选点和合并进来的其它规则 (按等级从高到低)，返回等级最高的确认的候选点、
+// 它的确认结果和其它确认的规则；都没有确认时结果为空。匹配同一组类的规则只向语言服务器验证一次
+func (t *Tracer) verifyCandidate(cand candidate) (candidate, model.VerificationResult, []model.SinkRule) {
+	var primary candidate
+	var result model.VerificationResult
+	var also []model.SinkRule
+	byClasses := make(map[string]model.VerificationResult)
+	for _, c := range append([]candidate{cand}, cand.Also...) {
+		// 参数是同一个类中的 final 字段 (文本匹配没有解析出初始值，e.g. 跨行初始化)
+		if c.Rule.SkipSafe && !c.Composite {
+			switch t.classifyFieldArg(c) {
+			case valueConstant:
+				continue
+			case valueEnvironment:
+				if !t.TreatEnvAsTaint {
+					continue
+				}
+			}
+		}
+
+		verification := model.VerificationResult{Method: model.VerifiedByComposite, Evidence: "all conditions of the composite rule matched in the method"}
+		if !c.Composite {
+			key := fmt.Sprintf("%s|%s|%s", strings.Join(c.Rule.Classes(), ","), c.Rule.MethodName, c.Rule.Scope)
+			cached, ok := byClasses[key]
+			if !ok {
+				verifyStart := time.Now()
+				cached = t.verifySink(c)
+				t.Stats.VerifyTime += time.Since(verifyStart)
+				t.Stats.VerifyCount++
+				byClasses[key] = cached
+			}
+			verification = cached
+		}
+		if !verification.OK() {
+			continue
+		}
+		if !result.OK() {
+			primary, result = c, verification
+		} else {
+			also = append(also, c.Rule)
+		}
+	}
+	return primary, result, also
+}
+
+// sinkGroup 同一个方法内命中同一规则的 Sink，只从第一个 Sink 追踪一次，其余 Sink 作为证据附加在第一步
+type sinkGroup struct {
+	label     string
+	file      string
+	line, col int // 追踪起点 (Sink 所在方法或 lambda 的外层方法)
+	field     string
+	step      model.ChainStep
+}
+
+func findGroup(groups []*sinkGroup, rule string) *sinkGroup {
+	for _, g := range groups {
+		if g.step.Rule != nil && g.step.Rule.Name == rule {
+			return g
+		}
+	}
+	return nil
+}
+
+// launchTrace 占用一个并发槽位，异步追踪 g
+func (t *Tracer) launchTrace(g *sinkGroup) {
+	// Acquire semaphore slot
+	t.Sem <- struct{}{}
+	t.Wg.Add(1)
+
+	go func() {
+		defer func() {
+			<-t.Sem
+			t.Wg.Done()
+		}()
+		t.TraceSink(g.label, g.file, g.line, g.col, []model.ChainStep{g.step}, g.field)
+	}()
+}
+
+// findCandidates 并行地对所有 Java 文件做文本初筛
+// Walk 只负责收集文件，匹配由 runtime.NumCPU() 个 worker 完成；结果按 文件+行号 排序，保证输出顺序稳定
 func (t *Tracer) findCandidates(rules []model.SinkRule) []candidate {
+	paths := make(chan string, 256)
+	found := make(chan []candidate, 256)
+
+	var workers sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range paths {
+				if cands := scanFileCandidates(path, rules, t.TreatEnvAsTaint); len(cands) > 0 {
+					found <- cands
+				}
+			}
+		}()
+	}
+
+	go func() {
+		t.walkJavaFiles(func(path string) { paths <- path })
+		close(paths)
+		workers.Wait()
+		close(found)
+	}()
+
 	var results []candidate
+	for cands := range found {
+		results = append(results, cands...)
+	}
+	// 稳定排序: 同一位置的候选点保持规则的顺序 (mergeDuplicates 中等级相同时排在前面的规则作为主规则)
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		if results[i].Line != results[j].Line {
+			return results[i].Line < results[j].Line
+		}
+		return results[i].Col < results[j].Col
+	})
+	return results
+}
 
-	filepath.Walk(t.ProjectRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".java") {
-			return nil
+// mergeDuplicates 合并同一个调用 (文件+行+列) 上的候选点: 多条规则 (e.g. 内置的 exec 规则和团队的自定义规则)
+// 命中同一个调用时只验证和追踪一次，等级最高的规则作为主规则，其余规则放入 Also。
+// 同一行中不同列的调用是不同的调用，不会被合并。cands 需要按 文件+行号+列 排序
+func mergeDuplicates(cands []candidate) []candidate {
+	var merged []candidate
+	for i := 0; i < len(cands); {
+		j := i + 1
+		for j < len(cands) && cands[j].File == cands[i].File && cands[j].Line == cands[i].Line && cands[j].Col == cands[i].Col {
+			j++
+		}
+		group := cands[i:j]
+		sort.SliceStable(group, func(a, b int) bool {
+			return model.SeverityRank(group[a].Rule.Severity) > model.SeverityRank(group[b].Rule.Severity)
+		})
+		primary := group[0]
+		if len(group) > 1 {
+			primary.Also = append([]candidate(nil), group[1:]...)
 		}
+		merged = append(merged, primary)
+		i = j
+	}
+	return merged
+}
+
+// withComposites 追加组合规则的候选点；组合规则的 Sink 调用 (e.g. Method.invoke) 上不再保留单行规则的候选点
+// (组合规则已经在方法内确认了前提条件，e.g. 方法名可控的 Method.invoke 不应按 Low 的单行规则报告)。
+// 只认领 Sink 调用本身 (文件+行+列)，同一行上的其它调用 (e.g. 链式调用中的 Class.forName) 仍按单行规则检查
+func withComposites(candidates, composites []candidate) []candidate {
+	if len(composites) == 0 {
+		return candidates
+	}
+	claimed := make(map[string]bool, len(composites))
+	for _, c := range composites {
+		claimed[callKey(c)] = true
+	}
+	kept := candidates[:0]
+	for _, c := range candidates {
+		if !claimed[callKey(c)] {
+			kept = append(kept, c)
+		}
+	}
+	return append(kept, composites...)
+}
+
+// callKey 候选点所在调用的键 (文件+行+列)
+func callKey(c candidate) string {
+	return fmt.Sprintf("%s:%d", lineKey(c.File, c.Line), c.Col)
+}
 
-		f, err := os.Open(path)
+// 找不到所在函数的 Sink (orphan sink) 的处理方式
+const (
+	OrphanReport    = "report"    // 作为普通发现报告 (不经过严格模式过滤)
+	OrphanSuppress  = "suppress"  // 丢弃
+	OrphanDowngrade = "downgrade" // 标记为未验证并降低有效等级
+)
+
+// orphanReason 降级的 orphan sink 在报告中显示的原因
+const orphanReason = "no call context"
+
+// recordOrphan 按 OrphanSinks 处理没有调用上下文的 Sink
+// 这类 Sink 没有 Source 可以检查，因此不经过 RecordResult 的严格模式过滤
+func (t *Tracer) recordOrphan(step model.ChainStep) {
+	switch t.OrphanSinks {
+	case OrphanSuppress:
+		return
+	case OrphanReport:
+	default:
+		step.Unverified = orphanReason
+	}
+
+	fmt.Println()
+	color.Yellow("[?] Sink without call context (%s): %s:%d %s", t.OrphanSinks, filepath.Base(step.File), step.Line+1, truncateString(step.Code, 60))
+
+	chain := []model.ChainStep{step}
+	embedSnippets(chain)
+	t.AddFinding(chain)
+	t.Events.Emit(events.Event{Type: events.TypeSinkFinish, File: step.File, Line: step.Line + 1, Outcome: events.OutcomeOrphan, Chains: 1})
+}
+
+// walkJavaFiles 遍历项目中的 .java 文件，被 -exclude 匹配的目录整体跳过，不在 -scope 范围内的文件不回调
+// 同时匹配 scope 和 exclude 的文件按 exclude 处理；构建输出目录跳过，生成的源码只在 -include-generated 时遍历
+func (t *Tracer) walkJavaFiles(fn func(path string)) {
+	t.walkFiles(func(name string) bool { return strings.HasSuffix(name, ".java") }, func(path string) {
+		if t.Scope.ContainsFile(path) {
+			fn(path)
+		}
+	})
+}
+
+// walkFiles 遍历文件名满足 match 的文件，目录的跳过规则与 walkJavaFiles 相同
+// 文件级别的 -scope 检查 (按 package 声明) 只对 Java 文件有意义，交给调用方
+func (t *Tracer) walkFiles(match func(name string) bool, fn func(path string)) {
+	policy := ScanWalk
+	policy.Generated = t.IncludeGenerated
+	WalkProject(t.ProjectRoot, t.FollowSymlinks, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
-		defer f.Close()
+		if path != t.ProjectRoot && t.isExcluded(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != t.ProjectRoot && (policy.SkipDir(t.ProjectRoot, path) || !t.Scope.MayContainDir(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(d.Name()) && !policy.SkipFile(path) {
+			fn(path)
+		}
+		return nil
+	})
+}
 
-		scanner := bufio.NewScanner(f)
-		lineNum := 0
+// isExcluded 路径是否被 -exclude 的 glob 匹配 (匹配相对项目根目录的路径或文件/目录名)
+func (t *Tracer) isExcluded(path string) bool {
+	if len(t.Exclude) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(t.ProjectRoot, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range t.Exclude {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := pathpkg.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := pathpkg.Match(pattern, pathpkg.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
 
-		for scanner.Scan() {
-			text := strings.TrimSpace(scanner.Text())
+// scanFileCandidates 对单个文件做文本初筛 + 常量过滤
+// envTaint 为 false 时参数来自环境变量/系统属性的候选点与常量参数一样被跳过
+func scanFileCandidates(path string, rules []model.SinkRule, envTaint bool) []candidate {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(content), "\n")
 
-			if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "/*") {
-				lineNum++
-				continue
-			}
+	var results []candidate
+	now := time.Now()
+	for lineNum := range lines {
+		for _, m := range matchLine(lines, lineNum, rules, envTaint) {
+			m.File = path
+			m.Line = lineNum
+			m.Suppression = model.InlineSuppression(lines, lineNum, &m.Rule, now)
+			results = append(results, m)
+		}
+	}
+	return results
+}
 
-			for _, rule := range rules {
-				var idx int
-				if rule.Pattern != nil {
-					loc := rule.Pattern.FindStringIndex(text)
-					if loc != nil {
-						idx = loc[0]
-					} else {
-						idx = -1
-					}
-				} else {
-					idx = strings.Index(text, rule.MethodName+"(")
-				}
+// matchLine 返回命中 lines[i] 的规则 (File/Line 由调用方填写)
+// 常量判断需要整个文件: 参数可能引用 static final 常量，注解可能跨行
+func matchLine(lines []string, i int, rules []model.SinkRule, envTaint bool) []candidate {
+	text := strings.TrimSpace(lines[i])
+
+	if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "/*") {
+		return nil
+	}
 
-				if idx != -1 {
-					// ✨✨✨ 这里直接调用 utils.go 里的 isStrictConstant ✨✨✨
-					if rule.SkipSafe && isStrictConstant(extractArgs(text)) {
+	var results []candidate
+	for _, rule := range rules {
+		idx, openIdx := -1, -1
+		if rule.Pattern != nil {
+			if loc := rule.Pattern.FindStringIndex(text); loc != nil {
+				idx, openIdx = loc[0], loc[1]-1
+			}
+		} else if idx = strings.Index(text, rule.MethodName+"("); idx != -1 {
+			openIdx = idx + len(rule.MethodName)
+		}
+		if idx == -1 {
+			continue
+		}
+
+		var notes []string
+		var arg string
+		if rule.Annotation {
+			// 注解中的 SQL 只有拼接或 ${} 时才可能被注入
+			args := annotationArgs(text[openIdx:], lines, i)
+			if rule.SkipSafe && !isUnsafeAnnotationSQL(args, lines) {
+				continue
+			}
+			notes = append(notes, fmt.Sprintf("🧩 SQL built dynamically: `%s`", args))
+		} else {
+			args := extractArgs(text)
+			if openIdx >= 0 && openIdx < len(text) && text[openIdx] == '(' {
+				args = callArgs(text, openIdx)
+			}
+			arg = checkedArg(rule, args)
+
+			// ✨✨✨ 常量参数 (包括同文件的 static final 常量和枚举常量) 直接跳过 ✨✨✨
+			if rule.SkipSafe {
+				switch classifyExpr(arg, lines) {
+				case valueConstant:
+					continue
+				case valueEnvironment:
+					if !envTaint {
 						continue
 					}
-					results = append(results, candidate{
-						File: path,
-						Line: lineNum,
-						Col:  idx,
-						Code: text,
-						Rule: rule,
-					})
+					notes = append(notes, fmt.Sprintf(environmentNote, strings.TrimSpace(arg)))
+				}
+			}
+			if rule.VulnType == "SQLI" {
+				if note := describeDynamicSQL(arg, lines, i); note != "" {
+					notes = append(notes, note)
 				}
 			}
-			lineNum++
 		}
-		return nil
-	})
+
+		results = append(results, candidate{
+			Col:   idx,
+			Code:  text,
+			Rule:  rule,
+			Notes: notes,
+			Arg:   arg,
+		})
+	}
 	return results
 }
 
-func (t *Tracer) verifySink(cand candidate) bool {
+// verifySink 确认候选点调用的是规则的类，返回确认方式 (model.VerifiedBy*) 和依据，不是目标类时 Method 为空
+func (t *Tracer) verifySink(cand candidate) model.VerificationResult {
 	uri := lsp.ToUri(cand.File)
-	id := t.Client.SendRequest("textDocument/definition", map[string]interface{}{
-		"textDocument": map[string]string{"uri": uri},
-		"position":     lsp.Position{Line: cand.Line, Character: cand.Col + 1},
-	})
+
+	// 0. Hover: 接收者变量的声明类型 (JDT.LS 给出全限定名) 比 definition 的 URI 更可靠
+	switch verdict, evidence := t.verifyByHover(cand); verdict {
+	case hoverMatch:
+		return model.VerificationResult{Method: model.VerifiedByHover, Evidence: evidence}
+	case hoverMismatch:
+		return model.VerificationResult{}
+	}
+
+	// 项目自己的类 (scope: project): 第三方类的 import / 文件名判断对它们不成立
+	if cand.Rule.InProject() {
+		return t.verifyProjectClass(cand)
+	}
 
 	// Wait for result with timeout
-	res, err := t.Client.WaitForResult(id, 3*time.Second)
+	// "content modified" (-32801) 等可重试错误: 重新发送请求，其它错误直接进入兜底逻辑
+	var res json.RawMessage
+	var err error
+	supported := t.Client.Supports("textDocument/definition")
+	if !supported {
+		err = lsp.ErrUnsupported
+	}
+	for attempt := 0; supported && attempt < 3; attempt++ {
+		id := t.Client.SendRequest("textDocument/definition", map[string]interface{}{
+			"textDocument": map[string]string{"uri": uri},
+			"position":     lsp.Position{Line: cand.Line, Character: cand.Col + 1},
+		})
+		res, err = t.Client.WaitForResult(id, 3*time.Second)
+		if err == nil || !lsp.IsRetryable(err) || errors.Is(err, lsp.ErrTimeout) {
+			break
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
 
 	// 1. LSP Resolution Logic
 	if err == nil && res != nil && strings.TrimSpace(string(res)) != "[]" {
 		resStr := string(res)
 
-		// Normalize class name for matching (e.g. java.lang.Runtime -> java/lang/Runtime)
-		targetPath := strings.ReplaceAll(cand.Rule.ClassName, ".", "/")
-		shortName := cand.Rule.ClassName
-		if idx := strings.LastIndex(shortName, "."); idx != -1 {
-			shortName = shortName[idx+1:]
-		}
-
-		// A. Strong Positive: LSP points to the correct library class file
-		if strings.Contains(resStr, targetPath) || strings.Contains(resStr, shortName) {
-			return true
+		// A. Strong Positive: LSP points to the correct library class file (or a known subtype)
+		for _, className := range cand.Rule.Classes() {
+			// Normalize class name for matching (e.g. java.lang.Runtime -> java/lang/Runtime)
+			targetPath := strings.ReplaceAll(className, ".", "/")
+			shortName := className
+			if idx := strings.LastIndex(shortName, "."); idx != -1 {
+				shortName = shortName[idx+1:]
+			}
+			if strings.Contains(resStr, targetPath) || strings.Contains(resStr, shortName) {
+				return model.VerificationResult{Method: model.VerifiedByDefinition,
+					Evidence: fmt.Sprintf("definition resolved to %s (%s)", className, definitionURI(res))}
+			}
 		}
 
 		// B. Strong Negative: LSP points to a DIFFERENT library class file (e.g. jdt://.../WrongClass.class)
 		// If it's a binary file (.class) or in a JAR/JDT scheme, and didn't match above, it's definitely not our target.
 		if strings.Contains(resStr, ".class") || strings.Contains(resStr, "jdt:") || strings.Contains(resStr, "jar:") {
-			return false
+			return model.VerificationResult{}
 		}
 
 		// C. Ambiguous: LSP points to a local source file (file://.../MyFile.java)
@@ -211,50 +682,97 @@ func (t *Tracer) verifySink(cand candidate) bool {
 	// Used when:
 	// - LSP failed/timeout/empty
 	// - LSP returned a local file reference (Ambiguous)
-	if t.hasImport(cand.File, cand.Rule.ClassName) {
-		return true
-	} else {
-		if strings.Contains(cand.File, "OpenApiController.java") {
-			fmt.Printf("[DEBUG] Import Mismatch for OpenApiController. Class: %s\n", cand.Rule.ClassName)
-		}
+	if evidence := t.findImport(cand.File, cand.Rule); evidence != "" {
+		return model.VerificationResult{Method: model.VerifiedByImport, Evidence: evidence}
 	}
 
 	// 3. Catch-all for fully qualified names in code (e.g. java.lang.Runtime.getRuntime().exec())
 	// If the code explicitly uses the full class name, hasImport might say no, but it's valid.
 	if strings.Contains(cand.Code, cand.Rule.ClassName) {
-		return true
+		return model.VerificationResult{Method: model.VerifiedByFQN, Evidence: fmt.Sprintf("fully-qualified name %s in code", cand.Rule.ClassName)}
 	}
 
 	// Default to False if neither LSP validated it nor Imports matched it.
-	return false
+	return model.VerificationResult{}
 }
 
-// hasImport checks if a Java file imports a specific class
-func (t *Tracer) hasImport(file string, className string) bool {
+// definitionURI 返回 definition 结果中的第一个位置 (Location 或 LocationLink)，解析失败时返回截断的原始结果
+func definitionURI(res json.RawMessage) string {
+	type location struct {
+		URI       string `json:"uri"`
+		TargetURI string `json:"targetUri"`
+	}
+	var locs []location
+	if err := json.Unmarshal(res, &locs); err != nil {
+		var loc location
+		if json.Unmarshal(res, &loc) != nil {
+			return truncateString(string(res), 120)
+		}
+		locs = []location{loc}
+	}
+	for _, l := range locs {
+		if l.URI != "" {
+			return l.URI
+		}
+		if l.TargetURI != "" {
+			return l.TargetURI
+		}
+	}
+	return truncateString(string(res), 120)
+}
+
+// findImport checks if a Java file can refer to the rule's class without a qualified name:
+// explicit / star imports, static imports of the class members, or the same package.
+// It returns a description of the matching statement, or "" when there is none
+func (t *Tracer) findImport(file string, rule model.SinkRule) string {
 	f, err := os.Open(file)
 	if err != nil {
-		return false
+		return ""
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	className := rule.ClassName
 	pkgParts := strings.Split(className, ".")
 	if len(pkgParts) < 2 {
-		return false
+		return ""
 	}
 	// e.g., org.apache.http.client.HttpClient -> package: org.apache.http.client
 	packageName := strings.Join(pkgParts[:len(pkgParts)-1], ".")
+	simpleName := pkgParts[len(pkgParts)-1]
+	shadowed := false
 
-	for scanner.Scan() {
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
 		line := strings.TrimSpace(scanner.Text())
+
+		// 0. Same package: no import needed
+		if strings.HasPrefix(line, "package ") {
+			if strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";")) == packageName {
+				return fmt.Sprintf("same package %s (line %d), no import needed", packageName, lineNo)
+			}
+			continue
+		}
+
 		if strings.HasPrefix(line, "import ") {
+			path, static := parseImport(line)
+			if static {
+				// 3. Static Import: import static org.apache.commons.io.FileUtils.openInputStream;
+				//                   import static org.apache.commons.io.FileUtils.*;
+				if path == className+".*" || path == className+"."+rule.MethodName {
+					return fmt.Sprintf("static import of %s found at line %d", path, lineNo)
+				}
+				continue
+			}
 			// 1. Exact Import: import org.apache.http.client.HttpClient;
-			if strings.Contains(line, className) {
-				return true
+			if path == className {
+				return fmt.Sprintf("import of %s found at line %d", className, lineNo)
 			}
 			// 2. Star Import: import org.apache.http.client.*;
-			if strings.Contains(line, packageName+".*") {
-				return true
+			if path == packageName+".*" {
+				return fmt.Sprintf("import of %s.* found at line %d", packageName, lineNo)
+			}
+			if strings.HasSuffix(path, "."+simpleName) {
+				shadowed = true
 			}
 		}
 		// Stop scanning at class definition (optimization)
@@ -262,5 +780,24 @@ func (t *Tracer) hasImport(file string, className string) bool {
 			break
 		}
 	}
-	return false
+	// 4. java.lang 不需要 import (同名的类被显式导入时除外)
+	if packageName == "java.lang" && !shadowed {
+		return fmt.Sprintf("%s is imported implicitly", className)
+	}
+	return ""
+}
+
+// parseImport 解析 import 语句，返回导入路径以及是否为 static import
+// e.g. "import static a.b.C.m;" -> ("a.b.C.m", true)
+func parseImport(line string) (string, bool) {
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "import "))
+	static := false
+	if strings.HasPrefix(rest, "static ") {
+		static = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "static "))
+	}
+	if idx := strings.Index(rest, ";"); idx != -1 {
+		rest = rest[:idx]
+	}
+	return strings.Join(strings.Fields(rest), ""), static
 }
@@ -0,0 +1,230 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// syntheticProject 生成 packages × files 个 Java 文件的项目，一部分文件包含 Sink 调用
+// 另外生成一个 generated/ 目录，用于检查 -exclude 对整个子树的剪枝
+func syntheticProject(tb testing.TB, packages, files int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	for p := 0; p < packages; p++ {
+		dir := filepath.Join(root, "src", "main", "java", "com", "example", fmt.Sprintf("pkg%03d", p))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			writeSyntheticFile(tb, dir, p, f)
+		}
+	}
+	gen := filepath.Join(root, "generated", "com", "example")
+	os.MkdirAll(gen, 0755)
+	writeSyntheticFile(tb, gen, 999, 0)
+	return root
+}
+
+func writeSyntheticFile(tb testing.TB, dir string, p, f int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package com.example.pkg%03d;\n\npublic class C%03d {\n", p, f)
+	fmt.Fprintf(&b, "    private static final String CMD = \"ls\";\n")
+	for m := 0; m < 20; m++ {
+		fmt.Fprintf(&b, "    // 普通方法 %d\n    public String m%d(String in) {\n        return in.trim() + %d;\n    }\n", m, m, m)
+	}
+	if f%3 == 0 {
+		b.WriteString("    public void run(String cmd) throws Exception {\n")
+		b.WriteString("        Runtime.getRuntime().exec(CMD);\n") // 常量参数: 跳过
+		b.WriteString("        Runtime.getRuntime().exec(cmd);\n")
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("C%03d.java", f)), []byte(b.String()), 0644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// sequentialCandidates 串行版本的初筛，作为并行结果的对照
+func sequentialCandidates(t *Tracer, rules []model.SinkRule) []candidate {
+	var results []candidate
+	t.walkJavaFiles(func(path string) {
+		results = append(results, scanFileCandidates(path, rules, t.TreatEnvAsTaint)...)
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].Line < results[j].Line
+	})
+	return results
+}
+
+func candidateKeys(cands []candidate) []string {
+	keys := make([]string, len(cands))
+	for i, c := range cands {
+		keys[i] = fmt.Sprintf("%s:%d:%d %s", c.File, c.Line, c.Col, c.Rule.Name)
+	}
+	return keys
+}
+
+func TestFindCandidatesStableOrder(t *testing.T) {
+	root := syntheticProject(t, 8, 12)
+	tr := NewTracer(nil, root, "light")
+	rules := model.GetBuiltinRules()
+
+	want := candidateKeys(sequentialCandidates(tr, rules))
+	// 8 个包 × 4 个含 Sink 的文件 + generated 中的 1 个，每个文件只有非常量参数的 exec
+	if len(want) != 8*4+1 {
+		t.Fatalf("sequential scan found %d candidates, want %d", len(want), 8*4+1)
+	}
+	for i := 0; i < 5; i++ {
+		if got := candidateKeys(tr.findCandidates(rules)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: parallel order differs from sequential:\n%s\nwant:\n%s", i, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestFindCandidatesExclude(t *testing.T) {
+	root := syntheticProject(t, 2, 3)
+	tr := NewTracer(nil, root, "light")
+	tr.Exclude = []string{"generated", "src/main/java/com/example/pkg001"}
+
+	for _, c := range tr.findCandidates(model.GetBuiltinRules()) {
+		rel, _ := filepath.Rel(root, c.File)
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, "generated/") || strings.Contains(rel, "/pkg001/") {
+			t.Errorf("excluded file scanned: %s", rel)
+		}
+	}
+}
+
+// BenchmarkFindCandidates 对比并行和串行的初筛 (合成项目: 40 个包 × 50 个文件)
+func BenchmarkFindCandidates(b *testing.B) {
+	root := syntheticProject(b, 40, 50)
+	tr := NewTracer(nil, root, "light")
+	rules := model.GetBuiltinRules()
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tr.findCandidates(rules)
+		}
+	})
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sequentialCandidates(tr, rules)
+		}
+	})
+}
+
+func TestFindImport(t *testing.T) {
+	rule := model.SinkRule{ClassName: "org.apache.commons.io.FileUtils", MethodName: "openInputStream"}
+	tests := []struct {
+		name   string
+		header string
+		want   string // 期望的依据中包含的内容，空表示找不到
+	}{
+		{"exact import", "package com.example;\nimport org.apache.commons.io.FileUtils;\n", "import of org.apache.commons.io.FileUtils"},
+		{"star import", "package com.example;\nimport org.apache.commons.io.*;\n", "import of org.apache.commons.io.*"},
+		{"static import of the method", "package com.example;\nimport static org.apache.commons.io.FileUtils.openInputStream;\n", "static import of org.apache.commons.io.FileUtils.openInputStream"},
+		{"static star import", "package com.example;\nimport  static  org.apache.commons.io.FileUtils.* ;\n", "static import of org.apache.commons.io.FileUtils.*"},
+		{"static import of another method", "package com.example;\nimport static org.apache.commons.io.FileUtils.readLines;\n", ""},
+		{"same package", "package org.apache.commons.io;\n", "same package org.apache.commons.io"},
+		{"other package", "package org.apache.commons.io.input;\nimport java.io.File;\n", ""},
+		{"import after the class declaration", "package com.example;\npublic class Demo {\n}\nimport org.apache.commons.io.FileUtils;\n", ""},
+	}
+	dir := t.TempDir()
+	tr := NewTracer(nil, dir, "light")
+	for i, tt := range tests {
+		file := filepath.Join(dir, fmt.Sprintf("Demo%d.java", i))
+		if err := os.WriteFile(file, []byte(tt.header+"public class Demo {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got := tr.findImport(file, rule)
+		if tt.want == "" && got != "" || tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("%s: findImport = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// java.lang 中的类不需要 import，除非同名的类被显式导入
+func TestFindImportJavaLang(t *testing.T) {
+	rule := model.SinkRule{ClassName: "java.lang.Runtime", MethodName: "exec"}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"package com.example;\n", true},
+		{"package com.example;\nimport java.io.File;\n", true},
+		{"package com.example;\nimport com.acme.process.Runtime;\n", false},
+	}
+	dir := t.TempDir()
+	tr := NewTracer(nil, dir, "light")
+	for i, tt := range tests {
+		file := filepath.Join(dir, fmt.Sprintf("Demo%d.java", i))
+		if err := os.WriteFile(file, []byte(tt.header+"public class Demo {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := tr.findImport(file, rule); (got != "") != tt.want {
+			t.Errorf("%q: findImport = %q, want found=%v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	tests := []struct {
+		line   string
+		path   string
+		static bool
+	}{
+		{"import java.io.File;", "java.io.File", false},
+		{"import java.util.*; // comment", "java.util.*", false},
+		{"import static java.util.Collections.emptyList;", "java.util.Collections.emptyList", true},
+		{"import static java.util . Collections.*;", "java.util.Collections.*", true},
+	}
+	for _, tt := range tests {
+		path, static := parseImport(tt.line)
+		if path != tt.path || static != tt.static {
+			t.Errorf("parseImport(%q) = %q, %v; want %q, %v", tt.line, path, static, tt.path, tt.static)
+		}
+	}
+}
+
+// lsptracer:ignore 注释不丢弃候选点，而是附加到候选点上 (过期的注释同样保留，由报告醒目标出)
+func TestScanFileCandidatesSuppression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Jobs.java")
+	src := `public class Jobs {
+    void a(String cmd) throws Exception {
+        // lsptracer:ignore[RCE] 命令来自白名单
+        Runtime.getRuntime().exec(cmd);
+    }
+    void b(String cmd) throws Exception {
+        Runtime.getRuntime().exec(cmd); // lsptracer:ignore[SQLI]
+    }
+    void c(String cmd) throws Exception {
+        Runtime.getRuntime().exec(cmd); // lsptracer:ignore until=2000-01-01
+    }
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := map[int]string{}
+	for _, c := range scanFileCandidates(path, model.GetBuiltinRules(), false) {
+		action := "none"
+		if c.Suppression != nil {
+			action = c.Suppression.Action
+		}
+		got[c.Line] = action
+	}
+	want := map[int]string{3: model.AllowSuppress, 6: "none", 9: model.AllowExpired}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+}
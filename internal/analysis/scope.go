@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scope 把扫描限制在部分包或目录内 (-scope / -scope-dir)
+// 只影响 Sink 候选点和锚点的发现，语言服务器仍然加载整个工作区，跨模块的调用者照常可以追踪
+// 文件匹配任意一个包前缀或目录前缀即视为在范围内；两者都为空表示不限制
+type Scope struct {
+	Packages []string // 包名前缀 (e.g. com.acme.payments)
+	Dirs     []string // 目录前缀 (绝对路径)
+}
+
+// Empty 是否没有设置范围
+func (s Scope) Empty() bool {
+	return len(s.Packages) == 0 && len(s.Dirs) == 0
+}
+
+// Labels 返回范围的可读描述，用于报告元信息
+func (s Scope) Labels(root string) []string {
+	var labels []string
+	for _, p := range s.Packages {
+		labels = append(labels, "package "+p)
+	}
+	for _, d := range s.Dirs {
+		if rel, err := filepath.Rel(root, d); err == nil && !strings.HasPrefix(rel, "..") {
+			d = filepath.ToSlash(rel)
+		}
+		labels = append(labels, "dir "+d)
+	}
+	return labels
+}
+
+// ContainsFile 文件是否在范围内；按包匹配时读取文件头部的 package 声明
+func (s Scope) ContainsFile(path string) bool {
+	if s.Empty() {
+		return true
+	}
+	for _, dir := range s.Dirs {
+		if pathWithin(path, dir) {
+			return true
+		}
+	}
+	if len(s.Packages) > 0 {
+		pkg := readPackage(path)
+		for _, p := range s.Packages {
+			if pkg == p || strings.HasPrefix(pkg, p+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MayContainDir 目录下是否可能有范围内的文件，用于遍历时剪枝
+// 设置了包范围时任何目录都可能包含匹配的文件 (源码目录结构不一定与包名一致)
+func (s Scope) MayContainDir(dir string) bool {
+	if s.Empty() || len(s.Packages) > 0 {
+		return true
+	}
+	for _, d := range s.Dirs {
+		if pathWithin(dir, d) || pathWithin(d, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathWithin path 是否等于 dir 或位于 dir 之下
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readPackage 读取 Java 文件的 package 声明，没有时返回空串 (default package)
+func readPackage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 0; n < 50 && scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";"))
+		}
+		if strings.HasPrefix(line, "import ") || strings.Contains(line, "class ") || strings.Contains(line, "interface ") {
+			break
+		}
+	}
+	return ""
+}
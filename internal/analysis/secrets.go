@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// secretHit 同一个密钥值 (按哈希去重) 的所有出现位置
+type secretHit struct {
+	pattern   model.SecretPattern
+	value     string
+	locations []model.ChainStep
+}
+
+// ScanSecrets 扫描项目中的硬编码凭据，结果作为单步链路 (不追踪调用链) 追加到 Results
+// 相同的值出现在多个位置时合并为一条结果，返回新增的结果数量
+func (t *Tracer) ScanSecrets(patterns []model.SecretPattern) int {
+	hits := make(map[[32]byte]*secretHit)
+	var order [][32]byte
+
+	WalkProject(t.ProjectRoot, t.FollowSymlinks, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != t.ProjectRoot && (strings.HasPrefix(name, ".") || name == "target" || name == "build" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasSecretExtension(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		for lineNum, line := range strings.Split(string(content), "\n") {
+			// 一行中可能有多个不同的密钥，先收集再统一打码
+			var keys [][32]byte
+			var values []string
+			for _, p := range patterns {
+				if p.Re == nil || !p.AppliesTo(path) {
+					continue
+				}
+				value, ok := matchSecret(p, line)
+				if !ok || containsString(values, value) {
+					continue
+				}
+				// 没有捕获组的规则 (e.g. 私钥头) 匹配到的文本都相同，不能按值合并
+				dedup := value
+				if p.Re.NumSubexp() == 0 {
+					dedup = fmt.Sprintf("%s:%d:%s", path, lineNum, value)
+				}
+				key := sha256.Sum256([]byte(dedup))
+				if _, exists := hits[key]; !exists {
+					hits[key] = &secretHit{pattern: p, value: value}
+					order = append(order, key)
+				}
+				keys = append(keys, key)
+				values = append(values, value)
+			}
+
+			code := strings.TrimSpace(line)
+			for _, v := range values {
+				code = strings.ReplaceAll(code, v, maskSecret(v))
+			}
+			for _, key := range keys {
+				// 片段只保存打码后的这一行，报告不会再从磁盘读出原始值
+				hits[key].locations = append(hits[key].locations, model.ChainStep{
+					File:         path,
+					Line:         lineNum,
+					Func:         filepath.Base(path),
+					Code:         code,
+					Context:      []string{code},
+					ContextStart: lineNum,
+				})
+			}
+		}
+		return nil
+	})
+
+	var results [][]model.ChainStep
+	for _, key := range order {
+		hit := hits[key]
+		rule := hit.pattern.AsSinkRule()
+		step := hit.locations[0]
+		step.Rule = &rule
+		step.Analysis = []string{
+			fmt.Sprintf("🚨 Matched Rule: %s", rule.Name),
+			fmt.Sprintf("🔑 Value: `%s` (sha256 %x)", maskSecret(hit.value), key[:4]),
+		}
+		if len(hit.locations) > 1 {
+			var others []string
+			for _, loc := range hit.locations[1:] {
+				rel, err := filepath.Rel(t.ProjectRoot, loc.File)
+				if err != nil {
+					rel = loc.File
+				}
+				others = append(others, fmt.Sprintf("%s:%d", filepath.ToSlash(rel), loc.Line+1))
+			}
+			sort.Strings(others)
+			step.Analysis = append(step.Analysis, fmt.Sprintf("🔁 Same value also found in %d other location(s): %s", len(others), strings.Join(others, ", ")))
+		}
+		results = append(results, []model.ChainStep{step})
+	}
+
+	for _, chain := range results {
+		t.AddFinding(chain)
+	}
+	return len(results)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range model.SecretFileExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSecret 返回行中命中的密钥值，占位符 (${...}) 和熵过低的值被忽略
+func matchSecret(p model.SecretPattern, line string) (string, bool) {
+	m := p.Re.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	value := m[0]
+	for _, group := range m[1:] {
+		if group != "" {
+			value = group
+			break
+		}
+	}
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "${") || strings.HasPrefix(value, "#{") {
+		return "", false
+	}
+	if p.MinEntropy > 0 && model.ShannonEntropy(value) < p.MinEntropy {
+		return "", false
+	}
+	return value, true
+}
+
+// maskSecret 只保留首尾少量字符，避免报告本身泄露密钥
+func maskSecret(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:3]) + strings.Repeat("*", len(runes)-6) + string(runes[len(runes)-3:])
+}
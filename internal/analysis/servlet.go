@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// 没有注解的入口: main 方法、Servlet / Filter 的重写方法和 Struts2 Action 的 execute()
+// 这些入口按方法签名识别；请求参数作为污点根，方法内对请求的读取作为具体的 Source 表达式
+
+var (
+	mainMethodRe    = regexp.MustCompile(`\bstatic\s+void\s+main\s*\(\s*(final\s+)?String`)
+	servletMethodRe = regexp.MustCompile(`\b(?:doGet|doPost|doPut|doDelete|doHead|doOptions|doPatch|service)\s*\(\s*(?:final\s+)?(?:[\w.]+\.)?HttpServletRequest\s+(\w+)`)
+	filterMethodRe  = regexp.MustCompile(`\bdoFilter\s*\(\s*(?:final\s+)?(?:[\w.]+\.)?(?:Http)?ServletRequest\s+(\w+)`)
+	strutsExecuteRe = regexp.MustCompile(`\bpublic\s+String\s+execute\s*\(\s*\)`)
+	strutsSetterRe  = regexp.MustCompile(`\bpublic\s+void\s+(set\w+)\s*\(`)
+
+	// 读取请求输入的方法
+	requestReadRe = `\.(?:getParameter|getParameterValues|getParameterMap|getParameterNames|getHeader|getHeaders|getInputStream|getReader|getQueryString|getCookies|getPart|getParts|getPathInfo|getRequestURI)\s*\(`
+)
+
+// servletSource 描述 Servlet / Filter / Struts2 Action 入口的污点来源
+type servletSource struct {
+	Root    string   // 污点根: 请求参数 (e.g. "HttpServletRequest req")，Struts2 Action 为空
+	Reads   []string // 方法内读取请求的表达式 (带行号)
+	Setters []string // Struts2 Action 的 setter，请求参数通过它们绑定到字段
+}
+
+// methodSignature 返回从 start 行开始的方法签名 (跨行时拼接到 "{" 为止，最多 5 行)
+func methodSignature(lines []string, start int) string {
+	var parts []string
+	for i := start; i < len(lines) && i < start+5; i++ {
+		text := strings.TrimSpace(lines[i])
+		parts = append(parts, text)
+		if strings.Contains(text, "{") || strings.HasSuffix(text, ";") {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// signatureKind 按方法签名识别没有入口注解的入口，不是入口时返回空
+func signatureKind(lines []string, fn FunctionInfo) string {
+	if fn.SelectionStart < 0 || fn.SelectionStart >= len(lines) {
+		return ""
+	}
+	if mainMethodRe.MatchString(methodSignature(lines, fn.SelectionStart)) {
+		return model.SourceCLI
+	}
+	if _, ok := detectServletSource(lines, fn); ok {
+		return model.SourceHTTP
+	}
+	return ""
+}
+
+// detectServletSource 检查 fn 是否为 Servlet / Filter 的重写方法或 Struts2 Action 的 execute()
+func detectServletSource(lines []string, fn FunctionInfo) (servletSource, bool) {
+	if fn.SelectionStart < 0 || fn.SelectionStart >= len(lines) {
+		return servletSource{}, false
+	}
+	sig := methodSignature(lines, fn.SelectionStart)
+
+	m := servletMethodRe.FindStringSubmatch(sig)
+	if m == nil {
+		m = filterMethodRe.FindStringSubmatch(sig)
+	}
+	if m != nil {
+		param := m[1]
+		src := servletSource{Root: strings.TrimSpace(m[0][strings.Index(m[0], "(")+1:])}
+		src.Reads = requestReads(lines, fn.SelectionStart, fn.RangeEnd, param)
+		return src, true
+	}
+
+	if strutsExecuteRe.MatchString(sig) && isStrutsAction(lines) {
+		var src servletSource
+		for _, line := range lines {
+			if sm := strutsSetterRe.FindStringSubmatch(line); sm != nil {
+				src.Setters = append(src.Setters, sm[1])
+			}
+		}
+		return src, true
+	}
+	return servletSource{}, false
+}
+
+// isStrutsAction 文件是否为 Struts2 Action (继承 ActionSupport 或引用 xwork2 / struts2 包)
+func isStrutsAction(lines []string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, "com.opensymphony.xwork2") || strings.Contains(line, "org.apache.struts2") ||
+			strings.Contains(line, "extends ActionSupport") {
+			return true
+		}
+	}
+	return false
+}
+
+// requestReads 查找 [start, end] 范围内对请求参数 param (及其强转后的别名) 的读取
+func requestReads(lines []string, start, end int, param string) []string {
+	names := []string{param}
+	aliasRe := regexp.MustCompile(`(\w+)\s*=\s*\(\s*(?:[\w.]+\.)?HttpServletRequest\s*\)\s*` + regexp.QuoteMeta(param) + `\b`)
+
+	var reads []string
+	for i := start; i <= end && i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") {
+			continue
+		}
+		if m := aliasRe.FindStringSubmatch(text); m != nil {
+			names = append(names, m[1])
+		}
+		for _, name := range names {
+			re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + requestReadRe)
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				open := loc[1] - 1
+				expr := text[loc[0]:open] + "(" + callArgs(text, open) + ")"
+				reads = append(reads, fmt.Sprintf("`%s` (line %d)", expr, i+1))
+			}
+		}
+	}
+	return reads
+}
+
+// annotateServletSource 在 Servlet / Filter / Struts2 入口的 Source 步骤上记录污点根和读取请求的表达式
+func (t *Tracer) annotateServletSource(chain []model.ChainStep) {
+	if len(chain) == 0 {
+		return
+	}
+	source := &chain[len(chain)-1]
+	fn, ok := t.GetEnclosingFunction(lsp.ToUri(source.File), source.Line)
+	if !ok {
+		return
+	}
+	content, err := os.ReadFile(source.File)
+	if err != nil {
+		return
+	}
+	src, ok := detectServletSource(strings.Split(string(content), "\n"), fn)
+	if !ok {
+		return
+	}
+
+	notes := append([]string(nil), source.Analysis...)
+	if src.Root != "" {
+		notes = append(notes, fmt.Sprintf("🚨 Taint root: `%s`", src.Root))
+	}
+	for _, read := range src.Reads {
+		notes = append(notes, "🚨 Source: "+read)
+	}
+	if len(src.Setters) > 0 {
+		notes = append(notes, fmt.Sprintf("🚨 Struts2 action: request parameters bound via setters `%s`", strings.Join(src.Setters, "`, `")))
+	}
+	source.Analysis = notes
+}
@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// applySeverityOverride 按规则的 severity_overrides 检查链路特征，生效的条目记录在 Sink 步骤
+// auth 是 Source 端点的授权状态 (model.AuthNone 等)，只有 -analyzer authz 检查过端点时才知道，否则为空
+func applySeverityOverride(stack []model.ChainStep, auth string) {
+	sink := &stack[0]
+	if sink.Rule == nil || len(sink.Rule.SeverityOverrides) == 0 {
+		return
+	}
+	traits := model.ChainTraits{
+		Source:     stack[len(stack)-1].SourceKind,
+		URLControl: sink.URLControl,
+		Auth:       auth,
+	}
+	if c := sink.Confidence; c != nil {
+		traits.DataFlow = model.DataFlowUnconfirmed
+		if c.DataFlow {
+			traits.DataFlow = model.DataFlowConfirmed
+		}
+	}
+	sink.SeverityOverride = sink.Rule.MatchOverride(traits)
+}
+
+// applyAuthOverrides authz 分析之后，用 Source 端点的授权状态重新检查已记录链路的 severity_overrides
+// unprotected 是没有授权检查的端点 (文件 -> 处理器方法所在行)
+func (t *Tracer) applyAuthOverrides(endpoints []entrypoints.Endpoint, unprotected map[string]map[int]bool) {
+	err := t.Findings.Rewrite(func(stack []model.ChainStep) {
+		if len(stack) == 0 || stack[0].Rule == nil || len(stack[0].Rule.SeverityOverrides) == 0 {
+			return
+		}
+		source := stack[len(stack)-1]
+		if source.SourceKind != model.SourceHTTP || source.FuncEndLine == 0 {
+			return
+		}
+		for _, e := range endpoints {
+			if e.File != source.File || e.Line < source.FuncStartLine || e.Line > source.FuncEndLine {
+				continue
+			}
+			auth := model.AuthRequired
+			if unprotected[e.File][e.Line] {
+				auth = model.AuthNone
+			}
+			applySeverityOverride(stack, auth)
+			break
+		}
+	})
+	if err != nil {
+		color.Red("[-] Failed to apply auth severity overrides: %v", err)
+	}
+}
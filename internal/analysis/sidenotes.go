@@ -0,0 +1,256 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/textutil"
+)
+
+// 调用点的附加说明 (不产生新的发现): 追踪的变量被写入日志、调用点被吞掉异常的 catch 包围。
+// 只在有函数的符号范围 (documentSymbol) 时检查，方法体的边界不靠正则猜测
+
+// logCallRe 日志调用: log.info(...)、LOGGER.warn(...)、this.auditLogger.error(...)
+var logCallRe = regexp.MustCompile(`\b(?:log|LOG|logger|LOGGER|\w+Logger|\w+Log)\s*\.\s*(?:trace|debug|info|warn|warning|error|fatal|severe)\s*\(`)
+
+// catchSwallowRe 只记录异常的 catch 语句 (日志、printStackTrace、输出到控制台)
+var catchSwallowRe = regexp.MustCompile(`^(?:` + logCallRe.String() + `|[\w.]*printStackTrace\s*\(|System\s*\.\s*(?:out|err)\s*\.\s*print)`)
+
+// broadExceptions 会吞掉 Sink 失败的宽泛异常类型
+var broadExceptions = map[string]bool{"Exception": true, "Throwable": true, "RuntimeException": true, "Error": true}
+
+// maxLogNotes 每个调用点最多列出的日志调用
+const maxLogNotes = 3
+
+// callSiteNotes 在 fn 的方法体中检查调用点的日志和异常处理，line 和函数范围都是 0-based
+func callSiteNotes(lines []string, line int, fn FunctionInfo, args string) []string {
+	start, end, ok := fn.SourceRange()
+	if !ok || line < start || end >= len(lines) {
+		return nil
+	}
+	masked := textutil.MaskJavaSource(lines[start : end+1])
+
+	var notes []string
+	notes = append(notes, loggedVarNotes(lines, masked, start, trackedVars(args))...)
+	notes = append(notes, swallowedNotes(masked, start, line)...)
+	return notes
+}
+
+// trackedVars 参数表达式中引用的局部变量/参数 (排除方法名、字段访问的成员和类名/常量)
+func trackedVars(args string) []string {
+	var vars []string
+	seen := make(map[string]bool)
+	for _, loc := range identRe.FindAllStringIndex(args, -1) {
+		name := args[loc[0]:loc[1]]
+		if loc[0] > 0 && args[loc[0]-1] == '.' {
+			continue
+		}
+		if rest := strings.TrimSpace(args[loc[1]:]); strings.HasPrefix(rest, "(") {
+			continue
+		}
+		if name[0] < 'a' || name[0] > 'z' || javaLiterals[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		vars = append(vars, name)
+	}
+	return vars
+}
+
+var identRe = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+var javaLiterals = map[string]bool{"new": true, "this": true, "super": true, "null": true, "true": true, "false": true, "instanceof": true}
+
+// loggedVarNotes 方法体中把追踪的变量传给日志调用的位置 (字符串中的同名文本不算)
+func loggedVarNotes(lines, masked []string, start int, vars []string) []string {
+	if len(vars) == 0 {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, len(vars))
+	for i, v := range vars {
+		patterns[i] = regexp.MustCompile(`(?:^|[^.\w$])` + regexp.QuoteMeta(v) + `\b`)
+	}
+	var notes []string
+	for i, text := range masked {
+		loc := logCallRe.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		callArgs := callArgs(text, loc[1]-1)
+		for j, v := range vars {
+			if patterns[j].MatchString(callArgs) {
+				notes = append(notes, fmt.Sprintf("📝 Traced value `%s` is written to the log at line %d: `%s`", v, start+i+1, strings.TrimSpace(lines[start+i])))
+				break
+			}
+		}
+		if len(notes) == maxLogNotes {
+			break
+		}
+	}
+	return notes
+}
+
+// codeBlock 方法体中的一个 {} 块 (相对方法起始行的行号)
+type codeBlock struct {
+	kind      string // try / catch / finally，其它块为空
+	exception string // catch 的异常类型 (多个类型以 | 分隔)
+	startLine int
+	endLine   int
+	body      string // 屏蔽注释和字符串后的块内容 (不含大括号)
+	try       int    // catch 块所属的 try 块 (blocks 中的下标)，-1 表示没有
+}
+
+// parseBlocks 按大括号把屏蔽后的方法体拆成块，记录 try/catch/finally 以及 catch 所属的 try
+// 关键字只在当前块的语句层 (不在括号中) 识别，参数中的 lambda 和匿名类不会被当作 try/catch
+func parseBlocks(masked []string) []codeBlock {
+	type open struct {
+		index  int
+		offset int // 块内容的起始位置
+		parens int // 块开始时的括号深度
+	}
+	var blocks []codeBlock
+	var stack []open
+	lastTry := map[int]int{} // 每一层最近结束的 try 块
+	text := strings.Join(masked, "\n")
+
+	line, parens := 0, 0
+	pending, exception := "", ""
+	catchParens, catchStart := -1, -1
+	base := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		return stack[len(stack)-1].parens
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '(':
+			if parens == catchParens {
+				catchStart = i + 1
+			}
+			parens++
+		case c == ')':
+			parens--
+			if parens == catchParens && catchStart >= 0 {
+				exception = catchTypes(text[catchStart:i])
+				catchParens, catchStart = -1, -1
+			}
+		case c == '{':
+			block := codeBlock{kind: pending, exception: exception, startLine: line, try: -1}
+			if pending == "catch" {
+				if idx, ok := lastTry[len(stack)]; ok {
+					block.try = idx
+				}
+			}
+			blocks = append(blocks, block)
+			stack = append(stack, open{index: len(blocks) - 1, offset: i + 1, parens: parens})
+			pending, exception = "", ""
+		case c == '}':
+			if len(stack) == 0 {
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			blocks[top.index].endLine = line
+			blocks[top.index].body = text[top.offset:i]
+			switch blocks[top.index].kind {
+			case "try":
+				lastTry[len(stack)] = top.index
+			case "catch", "finally":
+				// 同一个 try 后面可以有多个 catch
+			default:
+				delete(lastTry, len(stack))
+			}
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(text) && (isAlphaNumByte(text[j]) || text[j] == '$') {
+				j++
+			}
+			if parens == base() {
+				switch word := text[i:j]; word {
+				case "try", "finally":
+					pending = word
+				case "catch":
+					pending, catchParens = word, parens
+				default:
+					pending = ""
+				}
+			}
+			i = j - 1
+		case c == ';' && parens == base():
+			pending = ""
+		}
+	}
+	return blocks
+}
+
+// catchTypes catch 括号中的异常类型: "final IOException | RuntimeException e" -> "IOException|RuntimeException"
+func catchTypes(decl string) string {
+	fields := strings.Fields(strings.ReplaceAll(decl, "|", " | "))
+	if len(fields) > 0 {
+		fields = fields[:len(fields)-1] // 变量名
+	}
+	var types []string
+	for _, f := range fields {
+		if f != "final" && f != "|" && !strings.HasPrefix(f, "@") {
+			types = append(types, f)
+		}
+	}
+	return strings.Join(types, "|")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// swallowedNotes 包围调用点的 try 后面有宽泛异常的 catch，且 catch 中什么都不做或只记录日志
+func swallowedNotes(masked []string, start, line int) []string {
+	blocks := parseBlocks(masked)
+	rel := line - start
+	var notes []string
+	for _, b := range blocks {
+		if b.kind != "catch" || b.try < 0 {
+			continue
+		}
+		try := blocks[b.try]
+		if rel < try.startLine || rel > try.endLine || !isBroadCatch(b.exception) {
+			continue
+		}
+		switch swallowKind(b.body) {
+		case "empty":
+			notes = append(notes, fmt.Sprintf("🔇 Failures are swallowed: `catch (%s)` at line %d has an empty body", b.exception, start+b.startLine+1))
+		case "log":
+			notes = append(notes, fmt.Sprintf("🔇 Failures are swallowed: `catch (%s)` at line %d only logs the exception", b.exception, start+b.startLine+1))
+		}
+	}
+	return notes
+}
+
+func isBroadCatch(exception string) bool {
+	for _, typ := range strings.Split(exception, "|") {
+		if broadExceptions[textutil.SimpleClassName(typ)] {
+			return true
+		}
+	}
+	return false
+}
+
+// swallowKind catch 块的内容: empty (空或只有注释)、log (只记录异常)，其它情况为空
+func swallowKind(body string) string {
+	if strings.TrimSpace(body) == "" {
+		return "empty"
+	}
+	if strings.ContainsAny(body, "{}") {
+		return ""
+	}
+	for _, stmt := range strings.Split(body, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" && !catchSwallowRe.MatchString(stmt) {
+			return ""
+		}
+	}
+	return "log"
+}
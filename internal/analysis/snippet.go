@@ -0,0 +1,51 @@
+package analysis
+
+import (
+	"os"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// snippetWindow 没有函数范围时在目标行前后保留的行数 (报告会在片段内再查找函数边界)
+const snippetWindow = 60
+
+// embedSnippets 把链路中每一步的源码片段保存到 ChainStep 中
+// 有 LSP 函数范围时保存整个函数，否则保存目标行附近的窗口
+func embedSnippets(chain []model.ChainStep) {
+	files := make(map[string][]string)
+	for i := range chain {
+		step := &chain[i]
+		if len(step.Context) > 0 {
+			continue
+		}
+
+		lines, ok := files[step.File]
+		if !ok {
+			if content, err := os.ReadFile(step.File); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			files[step.File] = lines
+		}
+		if step.Line < 0 || step.Line >= len(lines) {
+			continue
+		}
+
+		start, end := step.Line-snippetWindow, step.Line+snippetWindow
+		if step.FuncEndLine > 0 && step.Line >= step.FuncStartLine && step.Line <= step.FuncEndLine {
+			start, end = step.FuncStartLine, step.FuncEndLine
+		}
+		if start < 0 {
+			start = 0
+		}
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+
+		step.Context = append([]string(nil), lines[start:end+1]...)
+		step.ContextStart = start
+		if step.Code == "" {
+			step.Code = strings.TrimSpace(lines[step.Line])
+		}
+	}
+}
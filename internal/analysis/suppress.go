@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/textutil"
+
+	"github.com/fatih/color"
+)
+
+// 白名单和行内注释: 已知安全的封装 (e.g. 先校验命令白名单再调用 ProcessBuilder.start 的 SafeCommandRunner)
+// 每次扫描都会重复报告经过它的链路。匹配的发现不会被丢弃，而是在 Sink 步骤上记录 Suppression，
+// 报告单独列出被抑制的发现，白名单本身仍然可以审计
+
+var packageDeclRe = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+
+// SuppressionCounts ApplySuppressions 的结果
+type SuppressionCounts struct {
+	Suppressed int
+	Downgraded int
+	Expired    int // 已过期、不再生效的 lsptracer:ignore 注释
+}
+
+// ApplySuppressions 对每条链路依次检查 lsptracer:ignore 注释和白名单，命中时记录到 Sink 步骤
+// 自动扫描时注释已经在文本初筛阶段附加到候选点上，这里补充单点模式的 Sink 和白名单；
+// 白名单条目在链路中任意一步满足 class/method 和 path 条件时生效。过期的注释逐条醒目输出
+func ApplySuppressions(chains [][]model.ChainStep, allow []model.AllowRule, projectRoot string) SuppressionCounts {
+	files := newSourceCache()
+	now := time.Now()
+	var counts SuppressionCounts
+	for _, stack := range chains {
+		if len(stack) == 0 {
+			continue
+		}
+		sink := &stack[0]
+		if sink.Suppression == nil {
+			sink.Suppression = model.InlineSuppression(files.lines(sink.File), sink.Line, sink.Rule, now)
+		}
+		if sink.Suppression == nil {
+			sink.Suppression = allowlistSuppression(stack, allow, files, projectRoot)
+		}
+		if sink.Suppression == nil {
+			continue
+		}
+		switch sink.Suppression.Action {
+		case model.AllowDowngrade:
+			counts.Downgraded++
+		case model.AllowExpired:
+			counts.Expired++
+			color.Red("[!] Expired suppression (until %s) no longer applies: %s:%d %s",
+				sink.Suppression.Until, filepath.Base(sink.File), sink.Line+1, sink.Suppression.Reason)
+		default:
+			counts.Suppressed++
+		}
+	}
+	return counts
+}
+
+// allowlistSuppression 返回第一个匹配链路的白名单条目
+func allowlistSuppression(stack []model.ChainStep, allow []model.AllowRule, files *sourceCache, projectRoot string) *model.Suppression {
+	for _, rule := range allow {
+		for _, step := range stack {
+			rel := step.File
+			if r, err := filepath.Rel(projectRoot, step.File); err == nil {
+				rel = r
+			}
+			if !rule.MatchPath(filepath.ToSlash(rel)) {
+				continue
+			}
+			if rule.Class != "" {
+				lines := files.lines(step.File)
+				class := textutil.EnclosingClassName(lines, step.Line)
+				if !rule.MatchMethod(javaPackage(lines), class, methodName(step.Func)) {
+					continue
+				}
+			}
+			return &model.Suppression{Action: rule.Action, Source: model.SuppressedByAllowlist, Reason: rule.Label()}
+		}
+	}
+	return nil
+}
+
+// javaPackage 返回源码声明的包名 (默认包为空)
+func javaPackage(lines []string) string {
+	for _, line := range lines {
+		if m := packageDeclRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// methodName 从 documentSymbol 的方法名 ("run(String[])") 中取出方法名
+func methodName(fn string) string {
+	if i := strings.Index(fn, "("); i != -1 {
+		fn = fn[:i]
+	}
+	return strings.TrimSpace(fn)
+}
+
+// sourceCache 按文件缓存源码行 (读取失败时为 nil)
+type sourceCache struct {
+	files map[string][]string
+}
+
+func newSourceCache() *sourceCache {
+	return &sourceCache{files: make(map[string][]string)}
+}
+
+func (c *sourceCache) lines(path string) []string {
+	lines, ok := c.files[path]
+	if !ok {
+		if content, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		c.files[path] = lines
+	}
+	return lines
+}
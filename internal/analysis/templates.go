@@ -0,0 +1,270 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/textutil"
+)
+
+// 模板中的反射型 XSS: JSP 脚本表达式 / EL 和 Thymeleaf 的非转义输出
+// 单语言扫描，不需要 LSP；输出的是模型属性时，按属性名找到放入模型的 Controller 方法，作为 Source 步骤接在模板 Sink 之后
+
+// TemplateXSSType 模板发现的漏洞类型，在报告中单独分组
+const TemplateXSSType = "XSS_TEMPLATE"
+
+var (
+	// templateDirs 模板所在的目录名 (路径中任意一级)
+	templateDirs = []string{"webapp", "templates", "WebContent", "WebRoot"}
+
+	scriptletExprRe = regexp.MustCompile(`<%=(.*?)%>|<jsp:expression>(.*?)</jsp:expression>`)
+	// 脚本中读取请求的表达式，捕获 getAttribute 等的属性名
+	scriptletInputRe = regexp.MustCompile(`\b(?:request\.(getParameter|getParameterValues|getHeader|getQueryString|getRequestURI|getAttribute)|session\.(getAttribute))\s*\(\s*(?:"([^"]*)")?`)
+	// EL 中直接引用请求参数 / 请求头
+	paramELRe = regexp.MustCompile(`\$\{[^}]*\b(?:param|paramValues|header|cookie)\s*(?:\.\s*\w+|\[[^\]]*\])[^}]*\}`)
+	// th:utext 属性和 [(...)] 非转义内联表达式
+	thymeleafUtextRe = regexp.MustCompile(`\b(?:th|data-th):utext\s*=\s*"([^"]*)"|\[\((.*?)\)\]`)
+	elVariableRe     = regexp.MustCompile(`\$\{\s*([A-Za-z_]\w*)`)
+	escapedExprRe    = regexp.MustCompile(`(?i)fn:escapeXml|escapeHtml|htmlEscape|Encode\.for|encodeFor`)
+
+	// Java 中把值放入模型的调用，捕获属性名
+	modelAttributeRe = regexp.MustCompile(`\.(?:addAttribute|addObject)\s*\(\s*"(\w+)"|new\s+ModelAndView\s*\(\s*"[^"]*"\s*,\s*"(\w+)"`)
+	javaMethodDeclRe = regexp.MustCompile(`(\w+)\s*\([^;]*$`)
+)
+
+// templateHit 模板中的一处非转义输出
+type templateHit struct {
+	kind      string // 模板类型 (JSP scriptlet / JSP EL / Thymeleaf)
+	expr      string // 输出的表达式
+	attribute string // 输出的模型属性名，直接读取请求时为空
+	request   bool   // 直接读取请求输入
+}
+
+// ScanTemplates 扫描 JSP / Thymeleaf 模板中的非转义输出，结果追加到 Results，返回新增的结果数量
+func (t *Tracer) ScanTemplates() int {
+	var results [][]model.ChainStep
+	var writes map[string][]model.ChainStep
+
+	t.walkFiles(isTemplateFile, func(path string) {
+		rel, err := filepath.Rel(t.ProjectRoot, path)
+		if err != nil || !inTemplateDir(rel) {
+			return
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		for lineNum, line := range strings.Split(string(content), "\n") {
+			for _, hit := range templateHits(path, line) {
+				rule := templateXSSRule(hit)
+				sink := model.ChainStep{
+					File: path,
+					Line: lineNum,
+					Func: filepath.ToSlash(rel),
+					Code: strings.TrimSpace(line),
+					Rule: &rule,
+					Analysis: []string{
+						fmt.Sprintf("🚨 Matched Rule: %s", rule.Name),
+						fmt.Sprintf("🧩 Unescaped output: `%s`", hit.expr),
+					},
+				}
+				if hit.request {
+					sink.Analysis = append(sink.Analysis, "🌐 Request input is written to the page directly")
+					results = append(results, []model.ChainStep{sink})
+					continue
+				}
+
+				if writes == nil {
+					writes = t.indexModelAttributes()
+				}
+				sources := writes[hit.attribute]
+				if len(sources) == 0 {
+					sink.Analysis = append(sink.Analysis, fmt.Sprintf("❔ No controller method found that sets model attribute `%s`", hit.attribute))
+					results = append(results, []model.ChainStep{sink})
+					continue
+				}
+				for _, source := range sources {
+					source.Analysis = []string{fmt.Sprintf("📦 Model attribute `%s` is rendered unescaped in %s:%d", hit.attribute, filepath.ToSlash(rel), lineNum+1)}
+					results = append(results, []model.ChainStep{sink, source})
+				}
+			}
+		}
+	})
+
+	for _, chain := range results {
+		embedSnippets(chain)
+		t.AddFinding(chain)
+	}
+	return len(results)
+}
+
+// templateHits 返回一行模板中的非转义输出
+func templateHits(path, line string) []templateHit {
+	var hits []templateHit
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsp", ".jspx":
+		for _, m := range scriptletExprRe.FindAllStringSubmatch(line, -1) {
+			expr := strings.TrimSpace(m[1] + m[2])
+			if escapedExprRe.MatchString(expr) {
+				continue
+			}
+			in := scriptletInputRe.FindStringSubmatch(expr)
+			if in == nil {
+				continue
+			}
+			hit := templateHit{kind: "JSP scriptlet", expr: expr}
+			if (in[1] == "getAttribute" || in[2] == "getAttribute") && in[3] != "" {
+				hit.attribute = in[3]
+			} else {
+				hit.request = true
+			}
+			hits = append(hits, hit)
+		}
+		for _, loc := range paramELRe.FindAllStringIndex(line, -1) {
+			expr := line[loc[0]:loc[1]]
+			if escapedExprRe.MatchString(expr) || inEscapingTag(line, loc[0]) {
+				continue
+			}
+			hits = append(hits, templateHit{kind: "JSP EL", expr: expr, request: true})
+		}
+	case ".html":
+		for _, m := range thymeleafUtextRe.FindAllStringSubmatch(line, -1) {
+			expr := strings.TrimSpace(m[1] + m[2])
+			v := elVariableRe.FindStringSubmatch(expr)
+			if v == nil || escapedExprRe.MatchString(expr) {
+				continue
+			}
+			hit := templateHit{kind: "Thymeleaf utext", expr: expr, attribute: v[1]}
+			if v[1] == "param" {
+				hit.attribute, hit.request = "", true
+			}
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// inEscapingTag pos 处的表达式是否位于默认转义的 <c:out> 标签中 (escapeXml="false" 时不转义)
+func inEscapingTag(line string, pos int) bool {
+	open := strings.LastIndex(line[:pos], "<")
+	if open == -1 {
+		return false
+	}
+	tag := line[open:]
+	if end := strings.Index(tag, ">"); end != -1 {
+		tag = tag[:end]
+	}
+	return strings.HasPrefix(tag, "<c:out") && !strings.Contains(strings.ReplaceAll(tag, "'", `"`), `escapeXml="false"`)
+}
+
+// templateXSSRule 模板发现使用的规则；直接输出请求输入的是确定的反射型 XSS，等级更高
+func templateXSSRule(hit templateHit) model.SinkRule {
+	severity := "Medium"
+	if hit.request {
+		severity = "High"
+	}
+	return model.SinkRule{
+		Name:        fmt.Sprintf("XSS (%s)", hit.kind),
+		VulnType:    TemplateXSSType,
+		Desc:        "模板中的非转义输出",
+		Severity:    severity,
+		CWE:         "CWE-79",
+		References:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Cross_Site_Scripting_Prevention_Cheat_Sheet.html"},
+		Remediation: "Use escaping output: <c:out value=\"...\"/> or ${fn:escapeXml(...)} in JSP, th:text instead of th:utext and [[...]] instead of [(...)] in Thymeleaf.",
+	}
+}
+
+// indexModelAttributes 按属性名索引 Java 源码中放入模型的位置 (addAttribute / addObject / new ModelAndView)
+func (t *Tracer) indexModelAttributes() map[string][]model.ChainStep {
+	writes := make(map[string][]model.ChainStep)
+	t.walkFiles(func(name string) bool { return strings.HasSuffix(name, ".java") }, func(path string) {
+		content, err := os.ReadFile(path)
+		if err != nil || !modelAttributeRe.Match(content) {
+			return
+		}
+		lines := strings.Split(string(content), "\n")
+		for i, line := range lines {
+			for _, m := range modelAttributeRe.FindAllStringSubmatch(line, -1) {
+				name := m[1] + m[2]
+				writes[name] = append(writes[name], model.ChainStep{
+					File:       path,
+					Line:       i,
+					Func:       enclosingMethodName(lines, i),
+					Class:      textutil.EnclosingClassName(lines, i),
+					Code:       strings.TrimSpace(line),
+					SourceKind: model.SourceHTTP,
+				})
+			}
+		}
+	})
+	for _, list := range writes {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].File != list[j].File {
+				return list[i].File < list[j].File
+			}
+			return list[i].Line < list[j].Line
+		})
+	}
+	return writes
+}
+
+// enclosingMethodName 按花括号层级向上查找包含 idx 行的方法声明，返回 "name()" (找不到时为 "<unknown>()")
+func enclosingMethodName(lines []string, idx int) string {
+	depth := 0
+	for i := idx - 1; i >= 0; i-- {
+		line := lines[i]
+		for j := len(line) - 1; j >= 0; j-- {
+			switch line[j] {
+			case '}':
+				depth++
+			case '{':
+				depth--
+			}
+		}
+		if depth < 0 {
+			decl := line
+			if i > 0 && strings.TrimSpace(line) == "{" {
+				decl = lines[i-1]
+			}
+			if m := javaMethodDeclRe.FindStringSubmatch(decl); m != nil && !isControlKeyword(m[1]) {
+				return m[1] + "()"
+			}
+			depth = 0
+		}
+	}
+	return "<unknown>()"
+}
+
+func isControlKeyword(word string) bool {
+	switch word {
+	case "if", "for", "while", "switch", "catch", "synchronized", "try", "return", "new":
+		return true
+	}
+	return false
+}
+
+func isTemplateFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsp", ".jspx", ".html":
+		return true
+	}
+	return false
+}
+
+// inTemplateDir 相对路径是否位于模板目录 (src/main/webapp、resources/templates 等) 中
+func inTemplateDir(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		for _, dir := range templateDirs {
+			if part == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
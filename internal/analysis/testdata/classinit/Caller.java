@@ -0,0 +1,12 @@
+package com.example;
+
+public class Caller {
+    void use() {
+        Widgets w = new Widgets();
+        Supplier<Widgets> s = Widgets::new;
+        Widgets.describe();
+        Class<?> c = Widgets.class;
+        Object o = new com.example.Widgets();
+        String text = "Widgets.describe()";
+    }
+}
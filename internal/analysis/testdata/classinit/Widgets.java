@@ -0,0 +1,33 @@
+package com.example;
+
+import java.util.function.Supplier;
+
+@Component
+public class Widgets {
+
+    private static final Process BOOT = start("boot");
+
+    private final Process worker = Runtime.getRuntime()
+            .exec(command());
+
+    private final Supplier<String> lazy = () -> {
+        return name();
+    };
+
+    private static final Runnable HOOK = () -> {
+        cleanup();
+    };
+
+    private String label;
+
+    {
+        label = System.getenv("LABEL");
+    }
+
+    static {
+        init();
+    }
+
+    public Widgets() {
+    }
+}
@@ -0,0 +1,34 @@
+package com.example;
+
+public class Commands {
+
+    enum Mode { FAST, SLOW }
+
+    private static final String PING_COMMAND = "ping -c 1 localhost";
+    private static final String PREFIX = "ping ";
+    private static final String FULL_COMMAND = PREFIX + "-c 3 localhost";
+    private static final String ENV_COMMAND = System.getenv("PING_CMD");
+    private static final String LOOP_A = LOOP_B;
+    private static final String LOOP_B = LOOP_A;
+    private String mutable = "ls";
+
+    public void run(String host, String name) throws Exception {
+        Runtime.getRuntime().exec(PING_COMMAND);
+        Runtime.getRuntime().exec(FULL_COMMAND);
+        Runtime.getRuntime().exec(System.getenv("CMD"));
+        Runtime.getRuntime().exec(ENV_COMMAND);
+        Runtime.getRuntime().exec(PREFIX + host);
+        Runtime.getRuntime().exec(System.getenv(name));
+        Runtime.getRuntime().exec(mutable);
+        String fromEnv = System.getProperty("cmd", "ls");
+        Runtime.getRuntime().exec(fromEnv);
+    }
+
+    public void dispatch(Commands commands, String host) throws Exception {
+        commands.run(PING_COMMAND, "");
+        commands.run(System.getenv("CMD"));
+        String fromProp = System.getProperty("cmd", "ls");
+        commands.run(fromProp);
+        commands.run(host);
+    }
+}
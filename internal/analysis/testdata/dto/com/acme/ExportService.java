@@ -0,0 +1,32 @@
+package com.acme;
+
+import java.nio.file.Files;
+import java.nio.file.Paths;
+import com.acme.model.ExportForm;
+import com.acme.model.OrderRequest;
+
+public class ExportService {
+
+    private ExportForm defaults;
+
+    public void save(OrderRequest req) throws Exception {
+        Files.write(Paths.get(req.getPath()), req.getContent().getBytes());
+    }
+
+    public void checksum(OrderRequest req) throws Exception {
+        Files.write(Paths.get(req.getChecksum()), new byte[0]);
+    }
+
+    public void fallback() throws Exception {
+        Files.write(Paths.get(defaults.getTarget()), new byte[0]);
+    }
+
+    public void copy(OrderRequest req) throws Exception {
+        OrderRequest copy = req;
+        Files.write(Paths.get(copy.getPath()), new byte[0]);
+    }
+
+    public void name(String req) throws Exception {
+        Files.write(Paths.get(req.getBytes().toString()), new byte[0]);
+    }
+}
@@ -0,0 +1,8 @@
+package com.acme.model;
+
+import lombok.Data;
+
+@Data
+public class ExportForm {
+    private String target;
+}
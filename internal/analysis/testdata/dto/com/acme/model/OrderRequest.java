@@ -0,0 +1,19 @@
+package com.acme.model;
+
+public class OrderRequest {
+
+    private String path;
+    private String content;
+
+    public String getPath() {
+        return path;
+    }
+
+    public String getContent() {
+        return content;
+    }
+
+    public String getChecksum() {
+        return Integer.toHexString(content.hashCode());
+    }
+}
@@ -0,0 +1,7 @@
+package com.acme.web;
+
+public class LegacyServlet extends HttpServlet {
+
+    protected void doPost(HttpServletRequest req, HttpServletResponse resp) {
+    }
+}
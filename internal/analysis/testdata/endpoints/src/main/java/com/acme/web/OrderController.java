@@ -0,0 +1,16 @@
+package com.acme.web;
+
+@RestController
+@RequestMapping("/orders")
+public class OrderController {
+
+    @GetMapping("/{id}")
+    public String get(@PathVariable String id) {
+        return id;
+    }
+
+    @PostMapping({"", "/bulk"})
+    public String create(@RequestBody String body) {
+        return body;
+    }
+}
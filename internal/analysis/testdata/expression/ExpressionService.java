@@ -0,0 +1,62 @@
+package com.example.demo;
+
+import java.io.StringReader;
+import java.io.StringWriter;
+import java.io.Writer;
+import java.util.Map;
+
+import freemarker.template.Configuration;
+import freemarker.template.Template;
+import ognl.Ognl;
+import org.springframework.expression.Expression;
+import org.springframework.expression.ExpressionParser;
+import org.springframework.expression.spel.standard.SpelExpressionParser;
+
+public class ExpressionService {
+    private static final String TOTAL = "items.![price].sum()";
+    private static final String GREETING = "Hello ${user.name}";
+
+    private final ExpressionParser parser = new SpelExpressionParser();
+    private final Configuration cfg = new Configuration(Configuration.VERSION_2_3_31);
+
+    public Expression spelConstant() {
+        parser.parseExpression("#root.name");
+        return parser.parseExpression(TOTAL);
+    }
+
+    public Expression spel(String expr) {
+        return parser.parseExpression(expr);
+    }
+
+    public Object ognlConstant(Map<String, Object> ctx, Object root) throws Exception {
+        Ognl.getValue("name", ctx, root);
+        return Ognl.parseExpression(TOTAL);
+    }
+
+    public Object ognl(String expr, Map<String, Object> ctx, Object root) throws Exception {
+        Ognl.setValue(expr, ctx, root, "x");
+        return Ognl.getValue(expr, ctx, root);
+    }
+
+    public String greet(Map<String, Object> model) throws Exception {
+        Template template = new Template("greeting", new StringReader(GREETING), cfg);
+        Writer out = new StringWriter();
+        template.process(model, out);
+        return out.toString();
+    }
+
+    public String render(String source, Map<String, Object> model) throws Exception {
+        StringReader reader = new StringReader(source);
+        Template template = new Template("user", reader, cfg);
+        Writer out = new StringWriter();
+        template.process(model, out);
+        return out.toString();
+    }
+
+    public String renderFile(String name, Map<String, Object> model) throws Exception {
+        Template template = cfg.getTemplate(name);
+        Writer out = new StringWriter();
+        template.process(model, out);
+        return out.toString();
+    }
+}
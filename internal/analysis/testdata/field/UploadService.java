@@ -0,0 +1,29 @@
+package com.example;
+
+@RestController
+public class UploadService {
+
+    private String uploadDir = "/tmp";
+    private boolean ready;
+
+    @PostMapping("/dir")
+    public void setDir(@RequestParam String dir) {
+        this.uploadDir = dir;
+        ready = true;
+    }
+
+    @Scheduled(fixedRate = 60000)
+    public void cleanup() throws IOException {
+        if (ready == true) {
+            Runtime.getRuntime().exec("rm -rf " + uploadDir);
+        }
+    }
+
+    static class Helper {
+        private String uploadDir;
+
+        void reset() {
+            uploadDir = null;
+        }
+    }
+}
@@ -0,0 +1,17 @@
+package com.example.plugin;
+
+import java.lang.reflect.Method;
+
+public class SafeReflection {
+    private static final String DRIVER = "com.mysql.cj.jdbc.Driver";
+
+    public void loadDriver() throws Exception {
+        Class.forName("com.mysql.cj.jdbc.Driver");
+        Class.forName(DRIVER);
+    }
+
+    public Object describe(Object target) throws Exception {
+        Method m = target.getClass().getMethod("toString");
+        return m.invoke(target);
+    }
+}
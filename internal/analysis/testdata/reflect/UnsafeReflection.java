@@ -0,0 +1,18 @@
+package com.example.plugin;
+
+import java.lang.reflect.Method;
+import javax.servlet.http.HttpServletRequest;
+
+public class UnsafeReflection {
+    public Object dispatch(HttpServletRequest request, Object target) throws Exception {
+        String className = request.getParameter("class");
+        String action = request.getParameter("action");
+        Class<?> clazz = Class.forName(className);
+        Method m = clazz.getMethod(action, String.class);
+        return m.invoke(target, request.getParameter("arg"));
+    }
+
+    public Object chained(HttpServletRequest request) throws Exception {
+        return Class.forName(request.getParameter("c")).getMethod(request.getParameter("m")).invoke(null);
+    }
+}
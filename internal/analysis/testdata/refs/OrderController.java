@@ -0,0 +1,18 @@
+package com.example.web;
+
+import static com.example.service.OrderService.exec;
+
+import com.example.service.OrderService;
+
+public class OrderController {
+    private final OrderService service = new OrderService();
+
+    /**
+     * 转发到 {@link OrderService#exec(String)} 执行命令
+     */
+    public void run(String cmd) {
+        log.info("calling exec with " + cmd);
+        // exec(cmd) 的旧实现已删除
+        service.exec(cmd);
+    }
+}
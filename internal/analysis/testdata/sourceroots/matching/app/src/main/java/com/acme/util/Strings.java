@@ -0,0 +1,7 @@
+/*
+ * Licensed under the Apache License 2.0
+ */
+package com.acme.util;
+
+public class Strings {
+}
@@ -0,0 +1,4 @@
+package org.lib;
+
+public class Lib {
+}
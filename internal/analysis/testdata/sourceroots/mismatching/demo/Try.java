@@ -0,0 +1,4 @@
+package com.other;
+
+public class Try {
+}
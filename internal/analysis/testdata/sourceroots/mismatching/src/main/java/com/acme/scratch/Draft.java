@@ -0,0 +1,4 @@
+package draft;
+
+public class Draft {
+}
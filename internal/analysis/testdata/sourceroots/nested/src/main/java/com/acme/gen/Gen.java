@@ -0,0 +1,4 @@
+package gen;
+
+public class Gen {
+}
@@ -0,0 +1,2 @@
+public class Tool {
+}
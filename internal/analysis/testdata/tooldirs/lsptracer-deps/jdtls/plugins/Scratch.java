@@ -0,0 +1,6 @@
+public class Scratch {
+
+    public static void main(String[] args) throws Exception {
+        Runtime.getRuntime().exec(args[0]);
+    }
+}
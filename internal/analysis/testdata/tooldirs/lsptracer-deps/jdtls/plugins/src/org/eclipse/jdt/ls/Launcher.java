@@ -0,0 +1,8 @@
+package org.eclipse.jdt.ls;
+
+public class Launcher {
+
+    public void launch(String cmd) throws Exception {
+        Runtime.getRuntime().exec(cmd);
+    }
+}
@@ -0,0 +1,8 @@
+package com.acme;
+
+public class App {
+
+    public void run(String cmd) throws Exception {
+        Runtime.getRuntime().exec(cmd);
+    }
+}
@@ -0,0 +1,38 @@
+package com.example;
+
+import java.net.URL;
+import org.springframework.web.client.RestTemplate;
+import org.springframework.web.util.UriComponentsBuilder;
+
+public class Client {
+
+    private static final String SERVICE_URL = "http://svc.internal/api/users";
+    private String baseUrl;
+    private RestTemplate restTemplate;
+
+    public String byPath(String userPath) {
+        return restTemplate.getForObject(baseUrl + userPath, String.class);
+    }
+
+    public String byBuilderPath(String id) {
+        String url = UriComponentsBuilder.fromHttpUrl("http://svc.internal").path("/users").path(id).toUriString();
+        return restTemplate.getForObject(url, String.class);
+    }
+
+    public String byBuilderQuery(String q) {
+        String url = UriComponentsBuilder.fromHttpUrl(baseUrl).path("/search").queryParam("q", q).toUriString();
+        return restTemplate.getForObject(url, String.class);
+    }
+
+    public String byHost(String host) throws Exception {
+        return new URL("http://" + host + "/status").openStream().toString();
+    }
+
+    public String fixed() {
+        return restTemplate.getForObject(SERVICE_URL, String.class);
+    }
+
+    public String fixedTemplate(String id) {
+        return restTemplate.getForObject("http://svc.internal/users/{id}", String.class, id);
+    }
+}
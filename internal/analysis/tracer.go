@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/events"
 	"LSPTracer/internal/lsp"
 	"LSPTracer/internal/model"
 
@@ -19,32 +22,127 @@ import (
 type Tracer struct {
 	Client      *lsp.Client
 	ProjectRoot string
-	SymbolCache map[string][]lsp.DocumentSymbol
+	Docs        *lsp.DocumentManager // 文档打开状态 + documentSymbol 缓存
 	// Concurrency Control
 	mu  sync.RWMutex
 	Sem chan struct{}
 	Wg  sync.WaitGroup
 
 	ReportedEntry map[string]bool
-	Results       [][]model.ChainStep
-	StrictMode    bool
 	ScanMode      string // "light" or "precise"
+
+	// 追踪完成的链路 (默认在内存中，-spill 时写入临时文件)，通过 AddFinding 记录
+	Findings FindingSink
+
+	// 文本初筛时跳过的路径 (glob，匹配相对路径或文件/目录名)
+	Exclude []string
+	// 遍历项目时是否进入符号链接指向的目录
+	FollowSymlinks bool
+	// 查找 Sink 时是否包含生成的源码 (target/generated-sources 等；它们总是作为源码目录参与解析)
+	IncludeGenerated bool
+	// 只在这些包/目录中查找 Sink (调用者的追踪不受限制)
+	Scope Scope
+	// 测试/示例代码在约定之外的路径 (-test-paths / -sample-paths)；其中的调用者不追踪
+	CodePaths model.CodePaths
+
+	// 参数来自环境变量/系统属性 (System.getenv / getProperty) 的 Sink 是否仍然报告
+	TreatEnvAsTaint bool
+
+	// 找不到所在函数的 Sink 的处理方式 (OrphanReport / OrphanSuppress / OrphanDowngrade)
+	OrphanSinks string
+
+	// 方法内多条件规则 (e.g. ZipSlip)，与 ScanAndTrace 的 SinkRule 一起扫描
+	CompositeRules []model.CompositeRule
+
+	// 单个 Sink 的追踪时间预算 (<= 0 表示不限时) 和每个 Sink 的实际耗时
+	SinkTimeout time.Duration
+	SinkTimings []SinkTiming
+
+	// 单个方法最多继续追踪的调用者数量 (<= 0 表示不限)，超过上限的方法记录在 fanOut 中 (方法 -> 调用者数量)
+	MaxCallers int
+	fanOut     map[string]int
+	// 链路最多包含的调用者层数 (<= 0 表示不限)，达到上限时记录到此为止的部分链路
+	MaxDepth int
+
+	// 验证候选点之前预先打开的文件数量上限 (0 表示只打开锚点)
+	WarmupFiles int
+
+	// 位于注释、字符串和 import 语句中的引用不作为调用点 (按文件缓存屏蔽后的源码)
+	masked   maskedSources
+	RefStats RefStats
+
+	// scope: project 规则的类在项目中的声明 (workspace/symbol 的结果按类缓存)
+	projectClasses projectClasses
+
+	// @EventListener 方法的事件发布点 (按事件类型缓存)
+	publishers publisherCache
+
+	// 常用工具函数的传播语义 (StringUtils.trim 传递输入，HtmlUtils.htmlEscape 只对 XSS 有效)；nil 时不分析
+	Propagation model.PropagationTable
+
+	// 语言服务器崩溃恢复
+	Anchor    string                      // Start 时打开的锚点文件，重启后重新打开
+	Restarter func() (*lsp.Client, error) // 启动一个新的语言服务器进程 (为 nil 时不重启)
+	Restarts  int                         // 已经重启的次数
+	// 语言服务器没有就绪或崩溃时调用 (输出 stderr 摘录并保存完整日志)，为 nil 时不处理
+	ServerTrouble func(client *lsp.Client, reason string)
+
+	// 其它模块的锚点 (Start 时一起打开，重启后同样重新打开)
+	ModuleAnchors []string
+	// 生成了独立 Eclipse 项目的模块目录，作为 workspace folders 注册 (为空时只注册项目根目录)
+	WorkspaceFolders []string
+	// 项目的 Java 语言级别，为空时不配置 java.configuration.runtimes
+	JavaLevel JavaLevel
+	// 跳过 Start 中的索引检查 (锚点没有符号、锚点中的类也查不到时报错)，用于特殊的项目结构
+	SkipIndexProbe bool
+	// initialize 返回的 serverInfo ("名称 版本")，服务器没有返回版本时为空
+	ServerInfo string
+
+	// 扫描统计 (ScanAndTrace 填写)
+	Stats ScanStats
+
+	// 扫描事件 (候选点数量、Sink 的开始/结束)，控制台输出和 -progress json 都从这里消费；nil 时不发送
+	Events *events.Bus
+	// 控制台上的链路输出 (串行打印，长链路折叠)
+	Console *ConsolePrinter
 }
 
+// MaxServerRestarts 扫描过程中允许自动重启语言服务器的次数
+const MaxServerRestarts = 1
+
+// initializeTimeout 等待 initialize 响应的时间
+const initializeTimeout = 60 * time.Second
+
+// serviceReadyTimeout 等待语言服务器就绪 (ServiceReady / 索引进度结束 / 探测成功) 的时间
+const serviceReadyTimeout = 15 * time.Second
+
+// DefaultMaxDepth 链路默认最多包含的调用者层数
+// 真实的攻击路径很少超过十几层，更深的链路通常是在通用方法之间来回展开
+const DefaultMaxDepth = 30
+
 func NewTracer(client *lsp.Client, root string, mode string) *Tracer {
 	return &Tracer{
 		Client:        client,
 		ProjectRoot:   root,
-		SymbolCache:   make(map[string][]lsp.DocumentSymbol),
+		Docs:          lsp.NewDocumentManager(client, "java", lsp.DefaultMaxOpenDocuments),
 		ReportedEntry: make(map[string]bool),
-		Results:       make([][]model.ChainStep, 0),
-		StrictMode:    false,
+		Findings:      NewMemorySink(),
+		OrphanSinks:   OrphanDowngrade,
 		Sem:           make(chan struct{}, 20), // Limit to 20 concurrent tasks
 		ScanMode:      mode,
+		SinkTimeout:   DefaultSinkTimeout,
+		MaxCallers:    DefaultMaxCallers,
+		MaxDepth:      DefaultMaxDepth,
+		WarmupFiles:   DefaultWarmupFiles,
+		Console:       NewConsolePrinter(root),
+		Propagation:   model.GetBuiltinPropagation(),
 	}
 }
 
-func (t *Tracer) Start(startFile string) {
+// Start 初始化语言服务器并等待索引就绪；索引检查发现项目没有被索引时返回错误
+// 语言服务器在启动过程中退出时不检查索引 (调用方通过 Client.Exited 处理)
+func (t *Tracer) Start(startFile string) error {
+	t.Anchor = startFile
 	color.Cyan("[*] Sending Initialize...")
 	rootUri := lsp.ToUri(t.ProjectRoot)
 
@@ -70,6 +168,18 @@ func (t *Tracer) Start(startFile string) {
 			"incompleteClasspath": map[string]interface{}{"severity": "ignore"},
 		},
 	}
+	// 只有本机 JDK 的主版本与项目语言级别一致时才声明对应的运行时 (版本不一致时 JDT.LS 会拒绝整个配置)
+	if t.JavaLevel.Level != "" {
+		if jdk := JDKMajorVersion(javaHome); jdk == t.JavaLevel.Major() {
+			javaSettings["configuration"] = map[string]interface{}{
+				"runtimes": []map[string]interface{}{
+					{"name": t.JavaLevel.ExecutionEnvironment(), "path": javaHome, "default": true},
+				},
+			}
+		} else if jdk > 0 && jdk < t.JavaLevel.Major() {
+			color.Yellow("[!] The project targets Java %s but JAVA_HOME is JDK %d; newer syntax may not resolve.", t.JavaLevel.Level, jdk)
+		}
+	}
 
 	// Configure Import Settings based on Mode
 	if t.ScanMode == "precise" {
@@ -96,51 +206,143 @@ func (t *Tracer) Start(startFile string) {
 			"workspaceFolders":       true,
 			"configuration":          true,
 			"didChangeConfiguration": map[string]interface{}{"dynamicRegistration": true},
+			"symbol":                 map[string]interface{}{"dynamicRegistration": false},
 		},
 		"textDocument": map[string]interface{}{
 			"synchronization": map[string]interface{}{"didOpen": true, "didSave": true},
 			"documentSymbol":  map[string]interface{}{"hierarchicalDocumentSymbolSupport": true},
 			"references":      map[string]interface{}{"dynamicRegistration": true},
 		},
+		// 索引进度通过 $/progress 报告 (没有 language/status 的服务器用它判断就绪)
+		"window": map[string]interface{}{"workDoneProgress": true},
 	}
 
+	// 服务器通过 workspace/configuration 拉取配置时，返回同一份 java 设置
+	t.Client.HandleRequest("workspace/configuration", func(params json.RawMessage) (interface{}, *lsp.ResponseError) {
+		var p lsp.ConfigurationParams
+		json.Unmarshal(params, &p)
+		result := make([]interface{}, len(p.Items))
+		for i, item := range p.Items {
+			result[i] = lookupSetting(map[string]interface{}{"java": javaSettings}, item.Section)
+		}
+		return result, nil
+	})
+
 	initOpts := map[string]interface{}{
 		"bundles":                    []string{},
 		"extendedClientCapabilities": map[string]interface{}{"progressReportProvider": true},
 		"settings":                   map[string]interface{}{"java": javaSettings},
 	}
 
-	t.Client.SendRequest("initialize", lsp.InitializeParams{
+	ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	result, err := t.Client.Initialize(ctx, lsp.InitializeParams{
 		RootUri:               rootUri,
 		Capabilities:          caps,
 		InitializationOptions: initOpts,
-		WorkspaceFolders:      []lsp.WorkspaceFolder{{Uri: rootUri, Name: "Target"}},
+		WorkspaceFolders:      t.workspaceFolders(),
 	})
+	cancel()
+	if err != nil {
+		// 没有拿到服务器能力时按全部支持处理，不支持的请求会在调用时失败
+		color.Yellow("[!] initialize failed: %v (assuming all features are supported)", err)
+	} else {
+		if info := result.ServerInfo; info != nil && info.Version != "" {
+			t.ServerInfo = strings.TrimSpace(info.Name + " " + info.Version)
+		}
+		if missing := result.Capabilities.Unsupported(); len(missing) > 0 {
+			color.Yellow("[!] Language server does not support %s; related checks fall back to heuristics.", strings.Join(missing, ", "))
+		}
+	}
 
-	t.Client.SendRequest("initialized", struct{}{})
+	t.Client.SendNotification("initialized", struct{}{})
 	t.Client.SendNotification("workspace/didChangeConfiguration", map[string]interface{}{
 		"settings": map[string]interface{}{"java": javaSettings},
 	})
-	t.sendDidOpen(startFile)
+	t.Docs.Open(startFile)
+	// 多模块项目: 每个模块打开一个锚点，避免只索引主锚点所在模块就报告就绪
+	for _, anchor := range t.ModuleAnchors {
+		t.Docs.Open(anchor)
+	}
 
 	color.Cyan("[*] Waiting for JDT.LS to be fully ready...")
-	t.Client.WaitForServiceReady(15 * time.Second)
+	signal, err := t.Client.WaitReady(serviceReadyTimeout, func() bool { return t.probeSymbols(startFile) })
+	if err == nil {
+		color.Cyan("[*] Language server ready (signal: %s)", signal)
+	} else if !t.Client.Exited() && t.ServerTrouble != nil {
+		// 没有任何就绪信号时继续扫描 (索引检查会发现空索引)，但先保留服务器的输出
+		t.ServerTrouble(t.Client, fmt.Sprintf("language server reported no readiness signal (ServiceReady, $/progress end or documentSymbol probe) within %s", serviceReadyTimeout))
+	}
+	if !t.SkipIndexProbe && !t.Client.Exited() {
+		if err := t.probeIndex(startFile); err != nil {
+			return err
+		}
+	}
 	color.Green("[+] Index Ready!")
+	return nil
+}
+
+// workspaceFolders 多模块项目注册每个模块目录，否则只注册项目根目录
+func (t *Tracer) workspaceFolders() []lsp.WorkspaceFolder {
+	if len(t.WorkspaceFolders) == 0 {
+		return []lsp.WorkspaceFolder{{Uri: lsp.ToUri(t.ProjectRoot), Name: "Target"}}
+	}
+	folders := make([]lsp.WorkspaceFolder, 0, len(t.WorkspaceFolders))
+	for _, dir := range t.WorkspaceFolders {
+		folders = append(folders, lsp.WorkspaceFolder{Uri: lsp.ToUri(dir), Name: filepath.Base(dir)})
+	}
+	return folders
 }
 
-func (t *Tracer) sendDidOpen(path string) {
-	content, err := os.ReadFile(path)
+// restartServer 在语言服务器崩溃后重启一次，重放 initialize/配置/didOpen 并清空符号缓存
+// 调用前必须保证没有正在运行的追踪任务 (t.Wg 已经清空)
+func (t *Tracer) restartServer() error {
+	exitErr := t.Client.ExitErr()
+	if t.Restarter == nil || t.Restarts >= MaxServerRestarts {
+		return fmt.Errorf("language server crashed (%v) and the restart budget is exhausted", exitErr)
+	}
+
+	if t.ServerTrouble != nil {
+		t.ServerTrouble(t.Client, fmt.Sprintf("Language server exited unexpectedly (%v)", exitErr))
+	}
+	color.Red("\n[!] Language server exited unexpectedly (%v). Restarting...", exitErr)
+	client, err := t.Restarter()
 	if err != nil {
-		return
+		return fmt.Errorf("failed to restart language server: %v", err)
 	}
-	t.Client.SendRequest("textDocument/didOpen", map[string]interface{}{
-		"textDocument": map[string]interface{}{
-			"uri":        lsp.ToUri(path),
-			"languageId": "java",
-			"version":    1,
-			"text":       string(content),
-		},
-	})
+
+	t.Restarts++
+	t.Client.Close()
+	t.Client = client
+	t.Docs = lsp.NewDocumentManager(client, "java", lsp.DefaultMaxOpenDocuments)
+	return t.Start(t.Anchor)
+}
+
+// CheckHealth 汇总 JDT.LS 发布的编译错误，错误较多时提示结果可能不完整
+// 大量 "X cannot be resolved" 通常意味着源码根目录或依赖缺失，此时引用查询的结果不可信
+func (t *Tracer) CheckHealth() lsp.DiagnosticStats {
+	stats := t.Client.DiagnosticStats()
+	if stats.Errors > 0 {
+		color.Yellow("[!] ⚠ %s compile errors across %s files — results may be incomplete (common cause: missing source roots or dependencies)",
+			formatThousands(stats.Errors), formatThousands(stats.FilesWithErrors))
+		color.Yellow("    Scan health: %.0f%% of %d diagnosed files compile cleanly", stats.Health()*100, stats.FilesReported)
+	}
+	return stats
+}
+
+// lookupSetting 按 "java.import.maven" 形式的 section 查找嵌套设置，空 section 返回全部
+func lookupSetting(settings map[string]interface{}, section string) interface{} {
+	if section == "" {
+		return settings
+	}
+	var cur interface{} = settings
+	for _, key := range strings.Split(section, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return cur
 }
 
 func (t *Tracer) WaitForReady(uri string) {
@@ -149,182 +351,421 @@ func (t *Tracer) WaitForReady(uri string) {
 	})
 }
 
-func (t *Tracer) GetEnclosingFunction(uri string, line int) (string, int, int, int) {
-	var symbols []lsp.DocumentSymbol
-	normPath := lsp.NormalizePath(lsp.FromUri(uri))
+// LSP SymbolKind 常量 (仅列出追踪中用到的)
+const (
+	symbolKindClass       = 5
+	symbolKindMethod      = 6
+	symbolKindField       = 8
+	symbolKindConstant    = 14
+	symbolKindConstructor = 9
+	symbolKindEnum        = 10
+	symbolKindInterface   = 11
+	symbolKindFunction    = 12
+)
 
-	// Read Lock for Cache Check
-	t.mu.RLock()
-	cached, ok := t.SymbolCache[normPath]
-	t.mu.RUnlock()
+// FunctionInfo 描述包含某一行代码的函数符号 (来自 documentSymbol)
+type FunctionInfo struct {
+	Name           string // 展示名称 (构造器/匿名类会合成, e.g. "MyClass.<init>(String)", "MyClass$1.run()")
+	Symbol         string // documentSymbol 原始名称 (JDT.LS 通常带参数签名, e.g. "download(String)")
+	SelectionStart int    // 函数名所在行 (SelectionRange.Start.Line)
+	RangeStart     int    // 符号范围起始行 (包含注解/Javadoc)
+	RangeEnd       int    // 符号范围结束行
+	Column         int    // 函数名所在列 (SelectionRange.Start.Character)
+	Kind           int    // LSP SymbolKind
+	Class          string // 所在类的展示名 (e.g. "Outer.Inner", "Outer$1")
+	Initializer    string // 类初始化上下文 (initStatic / initInstance)，此时 SelectionStart/Column 指向类名，从加载类的代码继续追踪
+
+	Anonymous bool           // 是否位于匿名类/lambda 中 (自身没有外部引用)
+	Ancestors []FunctionInfo // 词法上的外层函数 (由外到内，不含自身)
+}
 
-	if ok {
-		symbols = cached
-	} else {
-		id := t.Client.SendRequest("textDocument/documentSymbol", map[string]interface{}{
-			"textDocument": map[string]string{"uri": uri},
-		})
-		raw, err := t.Client.WaitForResult(id, 3*time.Second)
-		if err != nil {
-			return "", 0, 0, 0
+// SourceRange 返回函数的源码范围 (包含注解/Javadoc)
+// 字段初始化的兜底结果 (类符号) 范围是整个类，不适合作为代码上下文，ok 为 false
+func (f FunctionInfo) SourceRange() (start, end int, ok bool) {
+	switch f.Kind {
+	case symbolKindMethod, symbolKindFunction, symbolKindConstructor:
+		return f.RangeStart, f.RangeEnd, f.RangeEnd > f.RangeStart
+	}
+	return 0, 0, false
+}
+
+// TraceTarget 返回应继续向上追踪的函数
+// 匿名类/lambda 中的方法不会被直接调用，回退到词法上最近的命名方法 (注册点)
+func (f FunctionInfo) TraceTarget() (FunctionInfo, bool) {
+	if !f.Anonymous {
+		return f, false
+	}
+	for i := len(f.Ancestors) - 1; i >= 0; i-- {
+		if !f.Ancestors[i].Anonymous {
+			return f.Ancestors[i], true
 		}
-		json.Unmarshal(raw, &symbols)
+	}
+	return f, false
+}
+
+// GetEnclosingFunction 返回包含指定行的最内层函数，找不到时 ok 为 false
+// 支持普通方法、构造器、静态/实例初始化块以及匿名内部类中的方法；
+// 如果代码位于字段初始化表达式或符号树中没有的初始化块中，则回退到所在类 (<clinit> / <init field>)，以便继续追踪加载类的代码。
+func (t *Tracer) GetEnclosingFunction(uri string, line int) (FunctionInfo, bool) {
+	path := lsp.FromUri(uri)
+	symbols, err := t.Docs.Symbols(path)
+	if err != nil {
+		return FunctionInfo{}, false
+	}
 
-		// Write Lock for Cache Update
-		t.mu.Lock()
-		t.SymbolCache[normPath] = symbols
-		t.mu.Unlock()
+	fn, ok := findEnclosingFunction(symbols, line)
+	if ok && fn.Initializer != "" && isClassKind(fn.Kind) {
+		// 类符号的兜底: 按源码判断是静态还是实例初始化，类声明、注解等不属于任何初始化代码
+		kind := classInitKind(t.masked.lines(path), line, fn.SelectionStart, fn.RangeEnd)
+		if kind == "" {
+			return FunctionInfo{}, false
+		}
+		fn.Initializer = kind
+		fn.Name = fn.Class + "." + kind
 	}
+	return fn, ok
+}
 
-	var foundName string
-	var foundLine int
-	var foundEndLine int
-	var foundCol int
+// findEnclosingFunction 在符号树中查找包含 line 的最内层函数 (纯函数，不依赖 LSP)
+func findEnclosingFunction(symbols []lsp.DocumentSymbol, line int) (FunctionInfo, bool) {
+	var found FunctionInfo
+	var hit bool
+
+	// 字段初始化表达式的兜底: 记录字段所在的类
+	var fieldClass *lsp.DocumentSymbol
+	var fieldClassName string
+	// 不在任何方法和字段中 (符号树中没有的初始化块): 记录最内层的命名类
+	var initClass *lsp.DocumentSymbol
+	var initClassName string
+
+	// className: 当前所在类的展示名 (嵌套类用 "." 连接，匿名类用 "$N")
+	// classNode: 当前所在的命名类符号
+	// anon: 当前命名类中匿名类的编号表
+	// funcs: 外层函数路径; inAnon: 是否处于匿名类内部
+	var walk func(nodes []lsp.DocumentSymbol, className string, classNode *lsp.DocumentSymbol, anon map[lsp.Position]int, funcs []FunctionInfo, inAnon bool)
+	walk = func(nodes []lsp.DocumentSymbol, className string, classNode *lsp.DocumentSymbol, anon map[lsp.Position]int, funcs []FunctionInfo, inAnon bool) {
+		for i := range nodes {
+			node := &nodes[i]
+			if node.Range.Start.Line > line || node.Range.End.Line < line {
+				continue
+			}
 
-	var walk func([]lsp.DocumentSymbol)
-	walk = func(nodes []lsp.DocumentSymbol) {
-		for _, node := range nodes {
-			if node.Range.Start.Line <= line && node.Range.End.Line >= line {
-				if node.Kind == 6 || node.Kind == 12 {
-					foundName = node.Name
-					foundLine = node.SelectionRange.Start.Line
-					foundEndLine = node.Range.End.Line
-					foundCol = node.SelectionRange.Start.Character
+			childClass, childNode, childAnon := className, classNode, anon
+			childFuncs, childInAnon := funcs, inAnon
+
+			switch {
+			case isAnonymousClass(*node):
+				outer := className
+				if outer == "" {
+					outer = "Anonymous"
+				}
+				childClass = fmt.Sprintf("%s$%d", outer, anon[node.Range.Start])
+				childInAnon = true
+			case isClassKind(node.Kind):
+				childClass = node.Name
+				if className != "" {
+					childClass = className + "." + node.Name
+				}
+				childNode = node
+				childAnon = numberAnonymousClasses(node.Children)
+				childInAnon = false
+				initClass, initClassName = node, childClass
+			case node.Kind == symbolKindMethod || node.Kind == symbolKindFunction || node.Kind == symbolKindConstructor:
+				found = FunctionInfo{
+					Name:           functionDisplayName(*node, className),
+					Symbol:         node.Name,
+					SelectionStart: node.SelectionRange.Start.Line,
+					RangeStart:     node.Range.Start.Line,
+					RangeEnd:       node.Range.End.Line,
+					Column:         node.SelectionRange.Start.Character,
+					Kind:           node.Kind,
+					Class:          className,
+					Anonymous:      inAnon || isLambdaSymbol(*node),
+					Ancestors:      append([]FunctionInfo(nil), funcs...),
 				}
-				if len(node.Children) > 0 {
-					walk(node.Children)
+				hit = true
+				childFuncs = append(append([]FunctionInfo(nil), funcs...), found)
+				// 初始化块没有调用者: 从加载类的代码继续追踪
+				if node.Kind == symbolKindConstructor && strings.Contains(node.Name, "{...}") && classNode != nil {
+					found.Initializer = initInstance
+					if strings.HasPrefix(node.Name, "static") {
+						found.Initializer = initStatic
+					}
+					found.SelectionStart, found.Column = classNode.SelectionRange.Start.Line, classNode.SelectionRange.Start.Character
 				}
+			case node.Kind == symbolKindField && classNode != nil:
+				fieldClass, fieldClassName = classNode, className
+			}
+
+			if len(node.Children) > 0 {
+				walk(node.Children, childClass, childNode, childAnon, childFuncs, childInAnon)
 			}
 		}
 	}
-	walk(symbols)
-	return foundName, foundLine, foundEndLine, foundCol
-}
+	walk(symbols, "", nil, nil, nil, false)
 
-func (t *Tracer) isFrameworkEntry(file string, line int) bool {
-	// 1. Find Enclosing Function Line first
-	// We need to know where the method STARTS to check annotations above it.
-	funcName, funcStartLine, _, _ := t.GetEnclosingFunction(lsp.ToUri(file), line)
-	if funcName == "" {
-		// Fallback: If LSP fails, maybe checking valid annotations around 'line' is okay?
-		// No, usually dangerous. Let's assume false to encourage tracing up.
-		return false
+	if hit {
+		return found, true
 	}
-
-	f, err := os.Open(file)
-	if err != nil {
-		return false
+	if fieldClass == nil {
+		fieldClass, fieldClassName = initClass, initClassName
 	}
-	defer f.Close()
-
-	// 2. Scan lines [funcStartLine - 15, funcStartLine]
-	// We only care about annotations attached to THIS method.
-	startScan := funcStartLine - 15
-	if startScan < 0 {
-		startScan = 0
+	if fieldClass != nil {
+		return FunctionInfo{
+			Name:           fieldClassName + "." + initInstance,
+			Symbol:         fieldClass.Name,
+			SelectionStart: fieldClass.SelectionRange.Start.Line,
+			RangeStart:     fieldClass.Range.Start.Line,
+			RangeEnd:       fieldClass.Range.End.Line,
+			Column:         fieldClass.SelectionRange.Start.Character,
+			Kind:           fieldClass.Kind,
+			Class:          fieldClassName,
+			Initializer:    initInstance,
+		}, true
 	}
+	return FunctionInfo{}, false
+}
 
-	scanner := bufio.NewScanner(f)
-	currLine := 0
+// isLambdaSymbol 部分 JDT.LS 版本会将 lambda 作为合成方法 (lambda$0) 暴露
+func isLambdaSymbol(node lsp.DocumentSymbol) bool {
+	return strings.HasPrefix(node.Name, "lambda$") || strings.Contains(node.Name, "->")
+}
 
-	entryAnnotations := []string{
-		// Spring Web
-		"@RequestMapping", "@GetMapping", "@PostMapping", "@PutMapping", "@DeleteMapping", "@PatchMapping",
-		// Spring Listeners
-		"@RabbitListener", "@KafkaListener", "@JmsListener", "@Scheduled",
-		// Java EE / Servlet
-		"@WebFilter", "@WebServlet",
+// ResolveTraceTarget 决定从哪个函数继续向上追踪，并在需要时返回说明
+// 1. 类初始化代码: 追踪位置已经是类名 (见 FunctionInfo.Initializer)，仅追加说明
+// 2. 匿名类/lambda 符号: 回退到外层命名方法
+// 3. 源码层面的 lambda (JDT.LS 不为其生成符号): 函数不变，仅追加说明
+func (t *Tracer) ResolveTraceTarget(file string, line int, fn FunctionInfo) (FunctionInfo, string) {
+	if fn.Initializer != "" {
+		return fn, initializerNote(fn)
+	}
+	if target, redirected := fn.TraceTarget(); redirected {
+		return target, t.lambdaNote(file, line, target)
 	}
+	if lines, err := ReadLinesBefore(file, line+1, line+1-fn.SelectionStart); err == nil && isInsideLambda(lines) {
+		return fn, t.lambdaNote(file, line, fn)
+	}
+	return fn, ""
+}
 
-	for scanner.Scan() {
-		// Only check lines in the window
-		if currLine >= startScan && currLine <= funcStartLine {
-			text := strings.TrimSpace(scanner.Text())
+// lambdaNote 回退到外层方法时的说明；lambda/匿名类被提交给线程池时说明异步边界
+func (t *Tracer) lambdaNote(file string, line int, target FunctionInfo) string {
+	if call := executorHandoff(t.masked.lines(file), target.SelectionStart, line); call != "" {
+		return fmt.Sprintf("⏩ Async boundary: executor — lambda submitted via `%s`, tracing submitting method `%s`", call, target.Name)
+	}
+	return fmt.Sprintf("🔸 Inside lambda/anonymous class, tracing enclosing method `%s`", target.Name)
+}
+
+func isClassKind(kind int) bool {
+	return kind == symbolKindClass || kind == symbolKindEnum || kind == symbolKindInterface
+}
+
+// isAnonymousClass JDT.LS 将匿名类命名为 "new Runnable() {...}"
+func isAnonymousClass(node lsp.DocumentSymbol) bool {
+	return node.Kind == symbolKindClass && (node.Name == "" || strings.HasPrefix(node.Name, "new "))
+}
 
-			// Skip comments
-			if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") {
-				currLine++
+// numberAnonymousClasses 按出现顺序为命名类中的匿名类编号 (对应 javac 的 Outer$1, Outer$2 ...)
+// 嵌套的命名类有自己的编号空间，不参与计数
+func numberAnonymousClasses(children []lsp.DocumentSymbol) map[lsp.Position]int {
+	numbers := make(map[lsp.Position]int)
+	counter := 0
+	var visit func(nodes []lsp.DocumentSymbol)
+	visit = func(nodes []lsp.DocumentSymbol) {
+		for _, node := range nodes {
+			if isAnonymousClass(node) {
+				counter++
+				numbers[node.Range.Start] = counter
+			} else if isClassKind(node.Kind) {
 				continue
 			}
+			visit(node.Children)
+		}
+	}
+	visit(children)
+	return numbers
+}
 
-			// Check Annotations
-			for _, ann := range entryAnnotations {
-				if strings.Contains(text, ann) {
-					return true
-				}
+// functionDisplayName 为构造器、初始化块和匿名类方法合成可读名称
+func functionDisplayName(node lsp.DocumentSymbol, className string) string {
+	if node.Kind == symbolKindConstructor {
+		if className == "" {
+			return node.Name
+		}
+		// 初始化块: JDT.LS 命名为 "{...}" 或 "static {...}"
+		if strings.Contains(node.Name, "{...}") {
+			if strings.HasPrefix(node.Name, "static") {
+				return className + ".<clinit>"
 			}
-
-			// Special: Servlet Inheritance logic (if needed, relies on class-level check which is harder here)
-			// For now, assume Annotations cover 99% of cases in modern frameworks.
+			return className + ".<init>"
 		}
-		if currLine > funcStartLine {
-			break
+		sig := ""
+		if idx := strings.Index(node.Name, "("); idx != -1 {
+			sig = node.Name[idx:]
 		}
-		currLine++
+		return className + ".<init>" + sig
 	}
-	return false
+	// 匿名类中的方法需要带上类名，否则只看到 "run()" 无法定位
+	if strings.Contains(className, "$") {
+		return className + "." + node.Name
+	}
+	return node.Name
+}
+
+func (t *Tracer) isFrameworkEntry(file string, line int) bool {
+	return t.entryKind(file, line) != ""
+}
+
+// entryKind 返回 line 所在方法的入口类型 (model.SourceHTTP 等)，不是入口时返回空
+func (t *Tracer) entryKind(file string, line int) string {
+	// 1. Find Enclosing Function Line first
+	// We need to know where the method STARTS to check annotations above it.
+	fn, ok := t.GetEnclosingFunction(lsp.ToUri(file), line)
+	if !ok {
+		// Fallback: If LSP fails, maybe checking valid annotations around 'line' is okay?
+		// No, usually dangerous. Let's assume false to encourage tracing up.
+		return ""
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+
+	// 2. 方法上的入口注解
+	if kind := entrypoints.AnnotatedKind(lines, fn.SelectionStart); kind != "" {
+		return kind
+	}
+
+	// 3. 没有入口注解: 按方法签名识别 (main / Servlet / Filter / Struts2 Action)
+	return signatureKind(lines, fn)
 }
 
-func (t *Tracer) TraceChain(file string, line, col int, stack []model.ChainStep, visited map[string]bool) {
-	// DEBUG
-	fmt.Printf("DEBUG: TraceChain called for %s:%d (stack: %d)\n", filepath.Base(file), line, len(stack))
+// TraceChain 从 (file, line, col) 处的方法向上查找调用者
+// ctx 携带单个 Sink 的时间预算，超时后记录已追踪到的部分链路并停止展开
+func (t *Tracer) TraceChain(ctx context.Context, file string, line, col int, stack []model.ChainStep, visited map[string]bool) {
+	if ctx.Err() != nil {
+		t.recordTruncated(ctx, stack)
+		return
+	}
 
-	if t.isFrameworkEntry(file, line) {
-		t.RecordResult(stack)
+	// 入口只在这里判断一次: 之后在本层记录的链路 Source 都不是入口
+	if kind := t.entryKind(file, line); kind != "" {
+		t.RecordResult(stack, kind, model.TerminationReachedEntry)
+		return
+	}
+
+	// stack[0] 是 Sink，其余每一步是一层调用者
+	if t.MaxDepth > 0 && len(stack)-1 >= t.MaxDepth {
+		t.RecordResult(stack, "", model.TerminationDepthLimit)
 		return
 	}
 
 	uri := lsp.ToUri(file)
-	maxRetries := 20
+	maxRetries := 5
+
+	// 追踪类初始化代码: 只保留加载类的引用 (静态初始化时包括静态成员访问)
+	loadClass, isClassLoad := t.classLoadTarget(file, line, col)
+	staticInit := strings.HasSuffix(stack[len(stack)-1].Func, "."+initStatic)
 
 	var validRefs []lsp.Location
+	testCallers := 0
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		id := t.Client.SendRequest("textDocument/references", map[string]interface{}{
+		var refs []lsp.Location
+		callCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := t.Client.Call(callCtx, "textDocument/references", map[string]interface{}{
 			"textDocument": map[string]string{"uri": uri},
 			"position":     lsp.Position{Line: line, Character: col},
 			"context":      map[string]bool{"includeDeclaration": true},
-		})
-
-		raw, _ := t.Client.WaitForResult(id, 2*time.Second)
-		// DEBUG
-		// if len(raw) == 0 { fmt.Println("DEBUG: JDT.LS returned empty/null response") }
-
-		var refs []lsp.Location
-		json.Unmarshal(raw, &refs)
+		}, &refs)
+		cancel()
+		if err != nil {
+			// 预算耗尽: 不再重试
+			if ctx.Err() != nil {
+				break
+			}
+			// 只对可重试的错误 (内容变化/服务器取消/超时) 进行重试
+			if lsp.IsRetryable(err) {
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+			color.Yellow("[!] references failed at %s:%d: %v", filepath.Base(file), line+1, err)
+			break
+		}
 
 		validRefs = []lsp.Location{}
+		testCallers = 0
 		for _, ref := range refs {
 			path := lsp.FromUri(ref.Uri)
 			refLine := ref.Range.Start.Line
 			if lsp.NormalizePath(path) == lsp.NormalizePath(file) && abs(refLine-line) <= 1 {
 				continue
 			}
-			if filepath.Ext(path) == ".java" {
-				validRefs = append(validRefs, ref)
+			if filepath.Ext(path) == ".java" && t.codeKind(path) != model.CodeMain {
+				testCallers++
+				continue
+			}
+			if filepath.Ext(path) != ".java" || !t.traceableRef(path) {
+				continue
+			}
+			if reason := t.rejectRef(ref); reason != "" {
+				t.countRejectedRef(reason)
+				continue
 			}
+			if isClassLoad && !t.loadsClass(ref, loadClass, staticInit) {
+				continue
+			}
+			validRefs = append(validRefs, ref)
 		}
+		break
+	}
 
-		if len(validRefs) > 0 {
-			break
+	// 异步边界: @EventListener 方法由框架调用，事件的发布点作为调用者继续追踪
+	boundary := t.asyncBoundaryOf(file, line)
+	bridged := make(map[string]string) // 发布点 -> 事件类型
+	for _, event := range boundary.events {
+		for _, ref := range t.eventPublishers(ctx, file, event) {
+			path := lsp.FromUri(ref.Uri)
+			key := lineKey(path, ref.Range.Start.Line)
+			if _, ok := bridged[key]; ok {
+				continue
+			}
+			if t.codeKind(path) != model.CodeMain {
+				testCallers++
+				continue
+			}
+			if !t.traceableRef(path) || t.rejectRef(ref) != "" {
+				continue
+			}
+			bridged[key] = event
+			validRefs = append(validRefs, ref)
 		}
-		time.Sleep(500 * time.Millisecond)
+	}
+
+	if ctx.Err() != nil {
+		t.recordTruncated(ctx, stack)
+		return
 	}
 
 	if len(validRefs) == 0 {
-		fmt.Println("DEBUG: No refs found, calling RecordResult")
-		t.RecordResult(stack)
+		reason := model.TerminationNoCallers
+		if testCallers > 0 {
+			reason = model.TerminationTestOnly
+		}
+		t.RecordResult(stack, "", reason)
 		return
 	}
 
-	fmt.Printf("DEBUG: Found %d valid refs\n", len(validRefs))
+	validRefs = t.limitCallers(stack, validRefs)
 
 	foundValidCaller := false
 	for _, ref := range validRefs {
 		callerPath := lsp.FromUri(ref.Uri)
 		callerLine := ref.Range.Start.Line
 
-		key := fmt.Sprintf("%s:%d", callerPath, callerLine)
+		key := lineKey(callerPath, callerLine)
 
 		// Context-Sensitive Visited Check
 		if visited[key] {
@@ -335,23 +776,45 @@ func (t *Tracer) TraceChain(file string, line, col int, stack []model.ChainStep,
 		// Define the trace task logic
 		traceTask := func(ref lsp.Location, callerPath string, callerLine int, parentVisited map[string]bool) {
 			// 1. 获取包围函数
-			funcName, funcLine, _, funcCol := t.GetEnclosingFunction(ref.Uri, callerLine)
-			if funcName == "" {
+			fn, ok := t.GetEnclosingFunction(ref.Uri, callerLine)
+			funcName := fn.Name
+			if !ok {
 				funcName = "Global/Anonymous"
 			}
 
 			// 2. 分析调用点
-			analysisData := AnalyzeCallSite(callerPath, callerLine, funcName)
+			analysisData := AnalyzeCallSite(callerPath, callerLine, fn)
+			if rule := stack[0].Rule; rule != nil {
+				analysisData.DataFlow = append(analysisData.DataFlow, t.propagationNotes(analysisData.Expr, rule.VulnType)...)
+			}
+			analysisData.DataFlow = append(analysisData.DataFlow, analysisData.Notes...)
+			if note := boundary.callerNote(lineKey(callerPath, callerLine), bridged); note != "" {
+				analysisData.DataFlow = append(analysisData.DataFlow, note)
+			}
 
-			fmt.Printf("    [↑] Found caller: %s (in %s:%d)\n", funcName, filepath.Base(callerPath), callerLine+1)
+			// 匿名类/lambda 中的调用点: 从外层命名方法继续追踪
+			target := fn
+			if ok {
+				var note string
+				target, note = t.ResolveTraceTarget(callerPath, callerLine, fn)
+				if note != "" {
+					analysisData.DataFlow = append(analysisData.DataFlow, note)
+				}
+			}
+
+			t.Console.Progress("    [↑] Found caller: %s (in %s:%d)", funcName, filepath.Base(callerPath), callerLine+1)
 
 			newStep := model.ChainStep{
 				File:     callerPath,
 				Line:     callerLine,
 				Func:     funcName,
+				Class:    fn.Class,
 				Code:     analysisData.Code,
 				Analysis: analysisData.DataFlow,
 			}
+			if start, end, ok := fn.SourceRange(); ok {
+				newStep.FuncStartLine, newStep.FuncEndLine = start, end
+			}
 
 			// Clone visited map for the new branch
 			newVisited := make(map[string]bool)
@@ -360,10 +823,20 @@ func (t *Tracer) TraceChain(file string, line, col int, stack []model.ChainStep,
 			}
 			newVisited[key] = true
 
-			if funcLine > 0 {
-				t.TraceChain(callerPath, funcLine, funcCol, append(stack, newStep), newVisited)
+			// 参数来自字段: 同一个类中给字段赋值的方法作为额外分支
+			if analysisData.Field != "" {
+				t.traceFieldWrites(ctx, callerPath, callerLine, analysisData.Field, append(stack, newStep), newVisited)
+			}
+
+			if ok && target.SelectionStart > 0 {
+				t.TraceChain(ctx, callerPath, target.SelectionStart, target.Column, append(stack, newStep), newVisited)
 			} else {
-				t.RecordResult(append(stack, newStep))
+				// 不再向上追踪的调用者没有经过 TraceChain 开头的入口判断，在这里判断一次
+				kind := ""
+				if ok {
+					kind = t.entryKind(callerPath, callerLine)
+				}
+				t.RecordResult(append(stack, newStep), kind, model.TerminationNoCallers)
 			}
 		}
 
@@ -372,9 +845,16 @@ func (t *Tracer) TraceChain(file string, line, col int, stack []model.ChainStep,
 		case t.Sem <- struct{}{}:
 			// Acquired successfully -> Run in new Goroutine
 			t.Wg.Add(1)
+			budget := budgetFrom(ctx)
+			if budget != nil {
+				budget.pending.Add(1)
+			}
 			go func(r lsp.Location, cp string, cl int, pv map[string]bool) {
 				defer func() {
 					<-t.Sem
+					if budget != nil {
+						budget.pending.Done()
+					}
 					t.Wg.Done()
 				}()
 				traceTask(r, cp, cl, pv)
@@ -389,106 +869,46 @@ func (t *Tracer) TraceChain(file string, line, col int, stack []model.ChainStep,
 	// PATCH: If we had validRefs but filtered them all out (e.g. visited),
 	// we still represent a valid chain end.
 	if !foundValidCaller {
-		t.RecordResult(stack)
+		t.RecordResult(stack, "", model.TerminationCycle)
 	}
 }
 
-func (t *Tracer) RecordResult(stack []model.ChainStep) {
-	// DEBUG
-	fmt.Printf("DEBUG: RecordResult called. Stacklen: %d, Strict: %v\n", len(stack), t.StrictMode)
-
-	// Strict Mode Check
-	if t.StrictMode && len(stack) > 0 {
-		sourceStep := stack[len(stack)-1]
-
-		// Create a synthetic "Source" check: must be a framework entry point
-		if !t.isFrameworkEntry(sourceStep.File, sourceStep.Line) {
-			// Skip logging to avoid noise, or log debug
-			// fmt.Printf("\r    [Strict] Skipped chain ending at %s (Not an Entry Point)\n", sourceStep.Func)
-			return
-		}
-
-		// ✨ Check for Implicit Inputs (aka 0-arg method check) ✨
-		funcName, startLine, endLine, _ := t.GetEnclosingFunction(lsp.ToUri(sourceStep.File), sourceStep.Line)
-		if funcName != "" {
-			if !t.checkSourceValidity(sourceStep.File, startLine, endLine) {
-				return
-			}
-		}
+// RecordResult 记录一条链路，reason 是追踪在这里停止的原因 (model.TerminationNoCallers 等)
+// kind 是调用方 (TraceChain) 已经判断出的 Source 入口类型，不是入口时为空；是入口时原因总是 TerminationReachedEntry。
+// 所有链路都会被记录，严格模式在生成报告时按结束原因、Source 的类型和输入信号过滤 (model.StrictExclusion)
+func (t *Tracer) RecordResult(stack []model.ChainStep, kind, reason string) {
+	if len(stack) == 0 {
+		return
+	}
+	if kind != "" {
+		reason = model.TerminationReachedEntry
 	}
+	t.recordChain(stack, kind, reason)
+}
+
+// recordChain 记录链路，kind 是 Source 的入口类型 (不是入口时为空)，reason 是结束原因
+func (t *Tracer) recordChain(stack []model.ChainStep, kind, reason string) {
+	// Source 信号: 严格模式的过滤和可信度评分共用
+	tainted, known := t.sourceInput(stack, kind)
+	entry := kind != ""
 
 	// 1. Valid Chain found. Store a COPY of the stack to prevent aliasing issues
 	finalStack := make([]model.ChainStep, len(stack))
 	copy(finalStack, stack)
-
-	t.mu.Lock()
-	t.Results = append(t.Results, finalStack)
-	t.mu.Unlock()
-
-	// 2. 准备颜色工具
-	boldRed := color.New(color.FgRed, color.Bold).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	cyan := color.New(color.FgCyan).SprintFunc()
-	white := color.New(color.FgWhite).SprintFunc()
-	faint := color.New(color.Faint).SprintFunc()
-	green := color.New(color.FgGreen).SprintFunc()
-
-	// 3. 打印 ASCII 报告头
-	fmt.Println()
-	fmt.Println(strings.Repeat(faint("-"), 60))
-	fmt.Printf("%s Found Vulnerability Chain (%d steps)\n", boldRed("🔥 [TRACE]"), len(stack))
-	fmt.Println(strings.Repeat(faint("-"), 60))
-
-	// 4. 逆序打印 (从 Source -> Sink)
-	for i := len(stack) - 1; i >= 0; i-- {
-		step := stack[i]
-
-		var tag string
-		if i == len(stack)-1 {
-			tag = boldRed("🟥 SOURCE")
-		} else if i == 0 {
-			tag = boldRed("💀 SINK  ")
-		} else {
-			tag = yellow("🔸 STEP  ")
-		}
-
-		fmt.Printf(" %s: %s\n", tag, white(step.Func))
-
-		// ✨✨✨ 这里仅为了显示美观，计算一次相对路径 ✨✨✨
-		displayPath := step.File
-		if rel, err := filepath.Rel(t.ProjectRoot, step.File); err == nil {
-			displayPath = rel
-		}
-
-		fileInfo := fmt.Sprintf("%s:%d", displayPath, step.Line+1)
-		fmt.Printf("     %s: %s\n", faint("File"), cyan(fileInfo))
-
-		if step.Code != "" {
-			cleanCode := strings.TrimSpace(step.Code)
-			if len(cleanCode) > 100 {
-				cleanCode = cleanCode[:100] + "..."
-			}
-			fmt.Printf("     %s: `%s`\n", faint("Code"), white(cleanCode))
-		}
-
-		for _, info := range step.Analysis {
-			var coloredInfo string
-			if strings.Contains(info, "🟢") {
-				coloredInfo = green(info)
-			} else if strings.Contains(info, "🚨") {
-				coloredInfo = boldRed(info)
-			} else {
-				coloredInfo = yellow(info)
-			}
-			fmt.Printf("     %s\n", coloredInfo)
-		}
-
-		if i > 0 {
-			fmt.Printf("        %s\n", faint("↓"))
-		}
+	scoreChain(finalStack, entry, tainted, true)
+	classifySource(finalStack, kind)
+	recordSourceInput(finalStack, tainted, known)
+	finalStack[len(finalStack)-1].Termination = reason
+	finalStack[0].TestOnly = reason == model.TerminationTestOnly
+	if kind == model.SourceHTTP {
+		t.annotateServletSource(finalStack)
 	}
-	fmt.Println(strings.Repeat(faint("-"), 60))
-	fmt.Println()
+	// 等级覆盖在所有链路信息 (Source 类型、可信度信号、URL 控制范围) 都记录之后检查
+	applySeverityOverride(finalStack, "")
+	embedSnippets(finalStack)
+
+	t.AddFinding(finalStack)
+	t.Console.Print(finalStack, reason)
 }
 
 func (t *Tracer) checkSourceValidity(file string, startLine, endLine int) bool {
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+)
+
+// loadSymbols 读取 testdata/symbols 中记录的 JDT.LS documentSymbol 响应
+func loadSymbols(t *testing.T, name string) []lsp.DocumentSymbol {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "symbols", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var symbols []lsp.DocumentSymbol
+	if err := json.Unmarshal(raw, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	return symbols
+}
+
+func TestFindEnclosingFunction(t *testing.T) {
+	// 行号对应 testdata/symbols/OrderService.java (0 起始)
+	symbols := loadSymbols(t, "hierarchical.json")
+	tests := []struct {
+		name        string
+		line        int
+		want        string // FunctionInfo.Name
+		class       string
+		selection   int
+		column      int
+		kind        int
+		initializer string
+		anonymous   bool
+	}{
+		{"overload (String)", 14, "process(String)", "OrderService", 13, 16, symbolKindMethod, "", false},
+		{"overload (String, int)", 17, "process(String, int)", "OrderService", 17, 16, symbolKindMethod, "", false},
+		{"source lambda has no symbol", 19, "process(String, int)", "OrderService", 17, 16, symbolKindMethod, "", false},
+		{"anonymous class method", 23, "OrderService$1.run()", "OrderService$1", 22, 24, symbolKindMethod, "", true},
+		{"nested class", 30, "run(String)", "OrderService.Worker", 29, 13, symbolKindMethod, "", false},
+		{"constructor", 10, "OrderService.<init>()", "OrderService", 9, 11, symbolKindConstructor, "", false},
+		{"static block", 6, "OrderService.<clinit>", "OrderService", 2, 13, symbolKindConstructor, initStatic, false},
+		{"field initializer", 3, "OrderService.<init field>", "OrderService", 2, 13, symbolKindClass, initInstance, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := findEnclosingFunction(symbols, tt.line)
+			if !ok {
+				t.Fatalf("line %d: not found", tt.line)
+			}
+			if fn.Name != tt.want || fn.Class != tt.class || fn.Kind != tt.kind || fn.Initializer != tt.initializer || fn.Anonymous != tt.anonymous {
+				t.Errorf("line %d: got %+v", tt.line, fn)
+			}
+			if fn.SelectionStart != tt.selection || fn.Column != tt.column {
+				t.Errorf("line %d: position = %d:%d, want %d:%d", tt.line, fn.SelectionStart, fn.Column, tt.selection, tt.column)
+			}
+		})
+	}
+}
+
+func TestFindEnclosingFunctionOutsideClass(t *testing.T) {
+	symbols := loadSymbols(t, "hierarchical.json")
+	if fn, ok := findEnclosingFunction(symbols, 0); ok {
+		t.Errorf("package line: got %+v", fn)
+	}
+	if fn, ok := findEnclosingFunction(symbols, 40); ok {
+		t.Errorf("past the end: got %+v", fn)
+	}
+}
+
+func TestFindEnclosingFunctionTraceTarget(t *testing.T) {
+	symbols := loadSymbols(t, "hierarchical.json")
+	fn, ok := findEnclosingFunction(symbols, 23)
+	if !ok {
+		t.Fatal("not found")
+	}
+	if len(fn.Ancestors) != 1 || fn.Ancestors[0].Name != "process(String, int)" {
+		t.Fatalf("ancestors = %+v", fn.Ancestors)
+	}
+	// 匿名类中的方法回退到外层命名方法 (注册点)
+	target, redirected := fn.TraceTarget()
+	if !redirected || target.Name != "process(String, int)" || target.SelectionStart != 17 {
+		t.Errorf("TraceTarget = %+v, %v", target, redirected)
+	}
+	if start, end, ok := target.SourceRange(); !ok || start != 17 || end != 26 {
+		t.Errorf("SourceRange = %d-%d, %v", start, end, ok)
+	}
+}
+
+func TestFindEnclosingFunctionSyntheticLambda(t *testing.T) {
+	// 部分 JDT.LS 版本把 lambda 作为合成方法 lambda$0 暴露
+	r := func(a, b int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: a, Character: 4}, End: lsp.Position{Line: b, Character: 5}}
+	}
+	symbols := []lsp.DocumentSymbol{{
+		Name: "Jobs", Kind: symbolKindClass, Range: r(0, 20), SelectionRange: r(0, 0),
+		Children: []lsp.DocumentSymbol{{
+			Name: "schedule()", Kind: symbolKindMethod, Range: r(2, 10), SelectionRange: r(2, 2),
+			Children: []lsp.DocumentSymbol{{
+				Name: "lambda$0", Kind: symbolKindMethod, Range: r(4, 6), SelectionRange: r(4, 4),
+			}},
+		}},
+	}}
+	fn, ok := findEnclosingFunction(symbols, 5)
+	if !ok || fn.Symbol != "lambda$0" || !fn.Anonymous {
+		t.Fatalf("got %+v, %v", fn, ok)
+	}
+	if target, redirected := fn.TraceTarget(); !redirected || target.Symbol != "schedule()" {
+		t.Errorf("TraceTarget = %+v, %v", target, redirected)
+	}
+}
+
+// 多层嵌套类用 "." 连接，匿名类按所在的命名类编号 (嵌套类有自己的编号空间)；步骤展示名不重复类名
+func TestFindEnclosingFunctionNestedClasses(t *testing.T) {
+	r := func(a, b int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: a, Character: 4}, End: lsp.Position{Line: b, Character: 5}}
+	}
+	symbols := []lsp.DocumentSymbol{{
+		Name: "Orders", Kind: symbolKindClass, Range: r(0, 40), SelectionRange: r(0, 0),
+		Children: []lsp.DocumentSymbol{
+			{
+				Name: "Inner", Kind: symbolKindClass, Range: r(2, 20), SelectionRange: r(2, 2),
+				Children: []lsp.DocumentSymbol{
+					{Name: "Inner(String)", Kind: symbolKindConstructor, Range: r(3, 5), SelectionRange: r(3, 3)},
+					{
+						Name: "Deep", Kind: symbolKindClass, Range: r(6, 10), SelectionRange: r(6, 6),
+						Children: []lsp.DocumentSymbol{{Name: "call()", Kind: symbolKindMethod, Range: r(7, 9), SelectionRange: r(7, 7)}},
+					},
+					{
+						Name: "start()", Kind: symbolKindMethod, Range: r(11, 19), SelectionRange: r(11, 11),
+						Children: []lsp.DocumentSymbol{{
+							Name: "new Runnable() {...}", Kind: symbolKindClass, Range: r(12, 17), SelectionRange: r(12, 12),
+							Children: []lsp.DocumentSymbol{{Name: "run()", Kind: symbolKindMethod, Range: r(13, 16), SelectionRange: r(13, 13)}},
+						}},
+					},
+				},
+			},
+			{
+				Name: "submit()", Kind: symbolKindMethod, Range: r(22, 30), SelectionRange: r(22, 22),
+				Children: []lsp.DocumentSymbol{{
+					Name: "new Runnable() {...}", Kind: symbolKindClass, Range: r(23, 28), SelectionRange: r(23, 23),
+					Children: []lsp.DocumentSymbol{{Name: "run()", Kind: symbolKindMethod, Range: r(24, 27), SelectionRange: r(24, 24)}},
+				}},
+			},
+		},
+	}}
+	tests := []struct {
+		line      int
+		class     string
+		qualified string // model.ChainStep.QualifiedFunc
+		anonymous bool
+	}{
+		{4, "Orders.Inner", "Orders.Inner.<init>(String)", false},
+		{8, "Orders.Inner.Deep", "Orders.Inner.Deep.call()", false},
+		{18, "Orders.Inner", "Orders.Inner.start()", false},
+		{14, "Orders.Inner$1", "Orders.Inner$1.run()", true},
+		{25, "Orders$1", "Orders$1.run()", true},
+	}
+	for _, tt := range tests {
+		fn, ok := findEnclosingFunction(symbols, tt.line)
+		if !ok {
+			t.Fatalf("line %d: not found", tt.line)
+		}
+		step := model.ChainStep{Func: fn.Name, Class: fn.Class}
+		if fn.Class != tt.class || step.QualifiedFunc() != tt.qualified || fn.Anonymous != tt.anonymous {
+			t.Errorf("line %d: class %q, qualified %s, anonymous %v; want %q, %s, %v", tt.line, fn.Class, step.QualifiedFunc(), fn.Anonymous, tt.class, tt.qualified, tt.anonymous)
+		}
+	}
+}
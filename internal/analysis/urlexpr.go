@@ -0,0 +1,477 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// SSRF 调用点的 URL 还原: 沿赋值、字符串拼接、String.format、URL/URI 构造器和
+// UriComponentsBuilder / OkHttp 的构建器链还原请求 URL 的结构，判断输入落在 URL 的哪个部分。
+// 叶子值的判断: 方法参数是输入；字面量、常量和字段 (通常来自配置) 是固定的；
+// 其它方法调用的结果在接收者或参数携带输入时携带输入。
+
+const (
+	urlExprNote     = "🔗 URL: `%s`"
+	urlFullNote     = "🎯 Input controls the scheme/host via `%s`"
+	urlPathNote     = "🎯 Input controls only the path via `%s` (host is fixed)"
+	urlQueryNote    = "🎯 Input controls only the query via `%s` (host and path are fixed)"
+	urlFixedNote    = "🟢 URL is built from constants and configuration only"
+	maxURLExprDepth = 8 // 变量和嵌套调用最多展开的层数
+)
+
+// URL 片段所在的位置: urlAuto 表示由前面的字面量决定 (拼接的字符串)
+const (
+	urlAuto = iota
+	urlAuthority
+	urlPath
+	urlQuery
+)
+
+// urlPart 还原出的 URL 的一段
+type urlPart struct {
+	text    string // 字面量的内容，或值的表达式
+	literal bool   // 字符串字面量
+	tainted bool   // 可能携带输入
+	role    int    // 明确的位置 (构建器的 host()/path()/queryParam() 等)，urlAuto 表示按前面的字面量判断
+}
+
+var (
+	newExprRe      = regexp.MustCompile(`^new\s+([\w.$]+)\s*(?:<[^<>]*>)?\s*\(`)
+	stringLitRe    = regexp.MustCompile(`^"((?:[^"\\]|\\.)*)"$`)
+	formatVerbRe   = regexp.MustCompile(`%[-#+ 0,(]*\d*(?:\.\d+)?[a-zA-Z]`)
+	uriTemplateRe  = regexp.MustCompile(`\{[^{}]*\}`)
+	urlBuilderName = regexp.MustCompile(`^(?:UriComponentsBuilder|ServletUriComponentsBuilder|UriBuilder|HttpUrl|URI|Uri)$`)
+)
+
+// URI 构造器各重载的参数位置 (按参数个数)
+var uriConstructorRoles = map[int][]int{
+	3: {urlAuthority, urlAuthority, urlQuery},                                                // (scheme, ssp, fragment)
+	4: {urlAuthority, urlAuthority, urlPath, urlQuery},                                       // (scheme, host, path, fragment)
+	5: {urlAuthority, urlAuthority, urlPath, urlQuery, urlQuery},                             // (scheme, authority, path, query, fragment)
+	7: {urlAuthority, urlAuthority, urlAuthority, urlAuthority, urlPath, urlQuery, urlQuery}, // (scheme, userInfo, host, port, path, query, fragment)
+}
+
+// RestTemplate 方法中 URI 模板变量开始的参数位置 (从 0 开始)
+var uriVariableArgs = map[string]int{
+	"getForObject":    2,
+	"getForEntity":    2,
+	"postForObject":   3,
+	"postForEntity":   3,
+	"postForLocation": 2,
+	"put":             2,
+	"patchForObject":  3,
+	"delete":          1,
+	"exchange":        4,
+}
+
+// AnalyzeURL 还原 SSRF 调用点请求的 URL，返回输入能控制的部分 (model.URLControlFull 等，无法判断时为空) 和分析信息
+// URL 取调用的第一个参数；没有参数时 (url.openConnection()) 取调用的接收者
+func AnalyzeURL(path string, line int, fn FunctionInfo, rule model.SinkRule) (string, []string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	lines := strings.Split(string(content), "\n")
+	if line >= len(lines) {
+		return "", nil
+	}
+	code := strings.TrimSpace(lines[line])
+
+	open, dot := sinkCallOpen(code, rule)
+	if open == -1 {
+		return "", nil
+	}
+	r := &urlResolver{lines: lines, ref: methodRefOf(fn)}
+	var parts []urlPart
+	if args := splitArgs(callArgs(code, open)); len(args) > 0 && args[0] != "" {
+		parts = r.parts(args[0], line, 0)
+		// RestTemplate 的 URI 模板变量: getForObject("http://svc/users/{id}", String.class, id)
+		if from, ok := uriVariableArgs[rule.MethodName]; ok && len(args) > from {
+			parts = expandTemplate(parts, r.anyTainted(args[from:], line, 0))
+		}
+	} else if dot != -1 {
+		if recv := receiverExpr(code, dot); recv != "" {
+			parts = r.parts(recv, line, 0)
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	notes := []string{fmt.Sprintf(urlExprNote, renderURL(parts))}
+	control, via := classifyURL(parts)
+	switch control {
+	case model.URLControlFull:
+		notes = append(notes, fmt.Sprintf(urlFullNote, via))
+	case model.URLControlPath:
+		notes = append(notes, fmt.Sprintf(urlPathNote, via))
+	case model.URLControlQuery:
+		notes = append(notes, fmt.Sprintf(urlQueryNote, via))
+	default:
+		notes = append(notes, urlFixedNote)
+	}
+	return control, notes
+}
+
+// sinkCallOpen 返回调用点中规则方法的 "(" 位置和方法名前 "." 的位置 (构造器没有 "."，为 -1)
+func sinkCallOpen(code string, rule model.SinkRule) (open, dot int) {
+	var re *regexp.Regexp
+	if rule.MethodName == "<init>" {
+		re = regexp.MustCompile(`new\s+(?:[\w.]+\.)?` + regexp.QuoteMeta(simpleName(rule.ClassName)) + `\s*(?:<[^<>]*>)?\s*\(`)
+	} else {
+		re = regexp.MustCompile(`\.\s*` + regexp.QuoteMeta(rule.MethodName) + `\s*\(`)
+	}
+	loc := re.FindStringIndex(code)
+	if loc == nil {
+		return -1, -1
+	}
+	if rule.MethodName == "<init>" {
+		return loc[1] - 1, -1
+	}
+	return loc[1] - 1, loc[0]
+}
+
+// receiverExpr 返回 end 之前的接收者表达式 (可以是链式调用或构造器，不同于只取标识符的 receiverBefore): `new URL(u).openConnection()` -> "new URL(u)"
+func receiverExpr(code string, end int) string {
+	depth := 0
+	start := end
+scan:
+	for ; start > 0; start-- {
+		c := code[start-1]
+		switch {
+		case c == ')' || c == ']':
+			depth++
+		case c == '(' || c == '[':
+			if depth == 0 {
+				break scan
+			}
+			depth--
+		case depth > 0:
+		case c == '.' || c == '$' || isAlphaNumByte(c):
+		default:
+			break scan
+		}
+	}
+	expr := strings.TrimSpace(code[start:end])
+	if before := strings.TrimRight(code[:start], " \t"); strings.HasSuffix(before, "new") && expr != "" {
+		expr = "new " + expr
+	}
+	return expr
+}
+
+// urlResolver 在调用点所在的文件中还原 URL 表达式
+type urlResolver struct {
+	lines []string
+	ref   methodRef // 调用点所在的方法，用于判断变量是否是方法参数
+}
+
+// parts 把表达式还原为 URL 片段；line 是表达式所在的行 (向上查找变量的赋值)
+func (r *urlResolver) parts(expr string, line, depth int) []urlPart {
+	expr = unwrapExpr(expr)
+	if expr == "" {
+		return nil
+	}
+	if m := stringLitRe.FindStringSubmatch(expr); m != nil {
+		return []urlPart{{text: m[1], literal: true}}
+	}
+	if depth >= maxURLExprDepth {
+		return []urlPart{{text: expr, tainted: true}}
+	}
+	if pieces := splitTopLevel(expr, '+'); len(pieces) > 1 {
+		var parts []urlPart
+		for _, piece := range pieces {
+			parts = append(parts, r.parts(piece, line, depth+1)...)
+		}
+		return parts
+	}
+	if isStrictConstant(expr) || classifyExpr(expr, r.lines) != valueDynamic {
+		return []urlPart{{text: expr}}
+	}
+	if name := strings.TrimPrefix(expr, "this."); isVar(name) {
+		return r.variable(name, name != expr, line, depth)
+	}
+	if m := newExprRe.FindStringSubmatchIndex(expr); m != nil && closingParen(expr, m[1]-1) == len(expr)-1 {
+		class := expr[m[2]:m[3]]
+		return r.construct(class[strings.LastIndex(class, ".")+1:], splitArgs(expr[m[1]:len(expr)-1]), line, depth)
+	}
+	if qualifier, method, args, ok := splitCall(expr); ok {
+		return r.call(expr, qualifier, method, splitArgs(args), line, depth)
+	}
+	return []urlPart{{text: expr, tainted: true}}
+}
+
+// variable 局部变量按最近的赋值展开；方法参数是输入；其它 (字段) 视为配置的固定值
+func (r *urlResolver) variable(name string, field bool, line, depth int) []urlPart {
+	if !field {
+		if def := findDefinitionLine(r.lines, line, name); def != -1 {
+			return r.parts(extractRHS(strings.TrimSpace(r.lines[def])), def, depth+1)
+		}
+		if isMethodParameter(r.lines, line, r.ref, name) {
+			return []urlPart{{text: name, tainted: true}}
+		}
+	}
+	return []urlPart{{text: name}}
+}
+
+// construct 处理 new X(...): URL / URI 的多参数构造器按参数位置确定片段位置，其它单参数的包装 (HttpGet 等) 直接展开参数
+func (r *urlResolver) construct(class string, args []string, line, depth int) []urlPart {
+	if len(args) == 1 && args[0] == "" {
+		args = nil
+	}
+	switch {
+	case class == "URL" && len(args) == 2:
+		// new URL(base, spec): spec 是绝对 URL 时会替换整个 base
+		return append(r.parts(args[0], line, depth+1), r.withRole(args[1:], urlAuthority, line, depth)...)
+	case class == "URL" && (len(args) == 3 || len(args) == 4):
+		// new URL(protocol, host, [port,] file)
+		parts := r.withRole(args[:len(args)-1], urlAuthority, line, depth)
+		return append(parts, r.withRole(args[len(args)-1:], urlPath, line, depth)...)
+	case class == "URI" && uriConstructorRoles[len(args)] != nil:
+		var parts []urlPart
+		for i, role := range uriConstructorRoles[len(args)] {
+			parts = append(parts, r.withRole(args[i:i+1], role, line, depth)...)
+		}
+		return parts
+	case len(args) > 0:
+		return r.parts(args[0], line, depth+1)
+	}
+	return nil
+}
+
+// call 处理方法调用: URL 工厂方法、String.format、构建器链，其它调用在接收者或参数携带输入时携带输入
+func (r *urlResolver) call(expr, qualifier, method string, args []string, line, depth int) []urlPart {
+	if len(args) == 1 && args[0] == "" {
+		args = nil
+	}
+	receiver := func() []urlPart { return r.parts(qualifier, line, depth+1) }
+
+	if urlBuilderName.MatchString(qualifier) {
+		switch method {
+		case "fromHttpUrl", "fromUriString", "fromUri", "create", "parse", "get", "of":
+			if len(args) > 0 {
+				return r.parts(args[0], line, depth+1)
+			}
+		case "fromPath":
+			return r.withRole(args, urlPath, line, depth)
+		case "newInstance", "fromCurrentRequest", "fromCurrentContextPath":
+			return nil
+		}
+	}
+	if qualifier == "String" && method == "format" && len(args) > 0 {
+		return r.format(args, line, depth)
+	}
+
+	switch method {
+	case "scheme":
+		return append(append(receiver(), r.withRole(args, urlAuthority, line, depth)...), urlPart{text: "://", literal: true, role: urlAuthority})
+	case "host", "userInfo", "username", "password":
+		return append(receiver(), r.withRole(args, urlAuthority, line, depth)...)
+	case "port":
+		parts := append(receiver(), urlPart{text: ":", literal: true, role: urlAuthority})
+		return append(parts, r.withRole(args, urlAuthority, line, depth)...)
+	case "path", "pathSegment", "replacePath", "addPathSegment", "addPathSegments", "encodedPath", "addEncodedPathSegment", "addEncodedPathSegments":
+		parts := receiver()
+		for _, arg := range args {
+			if lit := stringLitRe.FindStringSubmatch(unwrapExpr(arg)); lit == nil || !strings.HasPrefix(lit[1], "/") {
+				parts = append(parts, urlPart{text: "/", literal: true, role: urlPath})
+			}
+			parts = append(parts, r.withRole([]string{arg}, urlPath, line, depth)...)
+		}
+		return parts
+	case "queryParam", "replaceQueryParam", "addQueryParameter", "addEncodedQueryParameter", "setQueryParameter", "setEncodedQueryParameter":
+		parts := append(receiver(), urlPart{text: querySeparator(receiver()), literal: true, role: urlQuery})
+		if len(args) > 0 {
+			parts = append(parts, r.withRole(args[:1], urlQuery, line, depth)...)
+			parts = append(parts, urlPart{text: "=", literal: true, role: urlQuery})
+			parts = append(parts, r.withRole(args[1:], urlQuery, line, depth)...)
+		}
+		return parts
+	case "query", "replaceQuery", "queryParams", "encodedQuery", "fragment", "encodedFragment":
+		parts := append(receiver(), urlPart{text: querySeparator(receiver()), literal: true, role: urlQuery})
+		return append(parts, r.withRole(args, urlQuery, line, depth)...)
+	case "url", "uri":
+		// OkHttp Request.Builder.url(u) / WebClient.get().uri(u)
+		if len(args) > 0 {
+			return append(receiver(), r.parts(args[0], line, depth+1)...)
+		}
+	case "resolve":
+		// base.resolve(ref): ref 是绝对 URI 时会替换整个 base
+		return append(receiver(), r.withRole(args, urlAuthority, line, depth)...)
+	case "expand", "buildAndExpand":
+		return expandTemplate(receiver(), r.anyTainted(args, line, depth))
+	case "build":
+		if len(args) > 0 {
+			return expandTemplate(receiver(), r.anyTainted(args, line, depth))
+		}
+		return receiver()
+	case "toUriString", "toUri", "toURI", "toURL", "toString", "encode", "normalize", "toExternalForm", "trim", "strip":
+		return receiver()
+	}
+
+	// 其它调用: 接收者或参数携带输入时结果携带输入
+	tainted := qualifier != "" && anyPartTainted(receiver())
+	if !tainted {
+		tainted = r.anyTainted(args, line, depth)
+	}
+	return []urlPart{{text: expr, tainted: tainted}}
+}
+
+// format 展开 String.format(fmt, args...): 格式字符串是字面量时按占位符的位置插入参数
+func (r *urlResolver) format(args []string, line, depth int) []urlPart {
+	lit := stringLitRe.FindStringSubmatch(unwrapExpr(args[0]))
+	if lit == nil {
+		return []urlPart{{text: "String.format(" + strings.Join(args, ", ") + ")", tainted: r.anyTainted(args, line, depth)}}
+	}
+	var parts []urlPart
+	rest := args[1:]
+	last := 0
+	for _, loc := range formatVerbRe.FindAllStringIndex(lit[1], -1) {
+		parts = append(parts, urlPart{text: lit[1][last:loc[0]], literal: true})
+		if verb := lit[1][loc[0]:loc[1]]; verb != "%n" && verb != "%%" && len(rest) > 0 {
+			parts = append(parts, r.parts(rest[0], line, depth+1)...)
+			rest = rest[1:]
+		}
+		last = loc[1]
+	}
+	return append(parts, urlPart{text: lit[1][last:], literal: true})
+}
+
+// withRole 展开参数并把片段固定在 role 位置
+func (r *urlResolver) withRole(args []string, role, line, depth int) []urlPart {
+	var parts []urlPart
+	for _, arg := range args {
+		for _, p := range r.parts(arg, line, depth+1) {
+			p.role = role
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func (r *urlResolver) anyTainted(args []string, line, depth int) bool {
+	for _, arg := range args {
+		if anyPartTainted(r.parts(arg, line, depth+1)) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyPartTainted(parts []urlPart) bool {
+	for _, p := range parts {
+		if p.tainted {
+			return true
+		}
+	}
+	return false
+}
+
+// querySeparator 构建器追加查询参数时使用的分隔符
+func querySeparator(parts []urlPart) string {
+	for _, p := range parts {
+		if p.role == urlQuery || p.literal && strings.Contains(p.text, "?") {
+			return "&"
+		}
+	}
+	return "?"
+}
+
+// expandTemplate 把字面量中的 URI 模板变量 ({id}) 替换为变量值的片段，tainted 表示变量值是否携带输入
+func expandTemplate(parts []urlPart, tainted bool) []urlPart {
+	var out []urlPart
+	for _, p := range parts {
+		if !p.literal || !uriTemplateRe.MatchString(p.text) {
+			out = append(out, p)
+			continue
+		}
+		last := 0
+		for _, loc := range uriTemplateRe.FindAllStringIndex(p.text, -1) {
+			out = append(out, urlPart{text: p.text[last:loc[0]], literal: true, role: p.role})
+			out = append(out, urlPart{text: p.text[loc[0]:loc[1]], tainted: tainted, role: p.role})
+			last = loc[1]
+		}
+		out = append(out, urlPart{text: p.text[last:], literal: true, role: p.role})
+	}
+	return out
+}
+
+// classifyURL 按片段的位置判断输入能控制的最大范围，返回控制范围和对应的表达式
+// 拼接的字面量决定后续片段的位置: "://" 之后是主机，主机之后的 "/" 开始路径，"?" / "#" 开始查询；
+// 开头的非字面量固定值 (配置的 baseUrl 字段等) 视为包含协议和主机
+func classifyURL(parts []urlPart) (control, via string) {
+	state := urlAuto
+	rank := 0
+	for _, p := range parts {
+		pos := state
+		if p.role != urlAuto {
+			pos, state = p.role, p.role
+		}
+		switch {
+		case p.tainted:
+			if r := urlControlRank(pos); r > rank {
+				rank, via = r, p.text
+			}
+		case p.literal && p.role == urlAuto:
+			state = scanURLLiteral(state, p.text)
+		case !p.literal && state == urlAuto:
+			state = urlPath
+		}
+	}
+	switch rank {
+	case 3:
+		return model.URLControlFull, via
+	case 2:
+		return model.URLControlPath, via
+	case 1:
+		return model.URLControlQuery, via
+	}
+	return "", ""
+}
+
+// urlControlRank 片段位置对应的控制范围排序 (主机 > 路径 > 查询)
+func urlControlRank(pos int) int {
+	switch pos {
+	case urlPath:
+		return 2
+	case urlQuery:
+		return 1
+	}
+	// 开头或主机: 可以指定任意协议和主机
+	return 3
+}
+
+// scanURLLiteral 返回拼接字面量 s 之后的位置
+func scanURLLiteral(state int, s string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case state != urlQuery && strings.HasPrefix(s[i:], "://"):
+			state = urlAuthority
+			i += 2
+		case s[i] == '?' || s[i] == '#':
+			state = urlQuery
+		case s[i] == '/' && state <= urlAuthority:
+			state = urlPath
+		}
+	}
+	return state
+}
+
+// renderURL 还原出的 URL: 输入显示为 {expr}，非字面量的固定值显示为 ${expr}，模板变量保持原样
+func renderURL(parts []urlPart) string {
+	var b strings.Builder
+	for _, p := range parts {
+		switch {
+		case p.literal, uriTemplateRe.MatchString(p.text):
+			b.WriteString(p.text)
+		case p.tainted:
+			b.WriteString("{" + p.text + "}")
+		default:
+			b.WriteString("${" + p.text + "}")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// ssrfRule 按方法名取内置的 SSRF 规则
+func ssrfRule(t *testing.T, class, method string) model.SinkRule {
+	t.Helper()
+	for _, r := range model.GetBuiltinRules() {
+		if r.ClassName == class && r.MethodName == method {
+			return r
+		}
+	}
+	t.Fatalf("no builtin rule %s.%s", class, method)
+	return model.SinkRule{}
+}
+
+// URL 还原后按输入所在的位置分类: 主机 (FULL) / 路径 (PATH) / 查询 (QUERY) / 固定 ("")
+func TestAnalyzeURL(t *testing.T) {
+	path := filepath.Join("testdata", "urlexpr", "Client.java")
+	getForObject := ssrfRule(t, "org.springframework.web.client.RestTemplate", "getForObject")
+	openStream := ssrfRule(t, "java.net.URL", "openStream")
+
+	tests := []struct {
+		name    string
+		line    int
+		symbol  string
+		rule    model.SinkRule
+		want    string
+		wantURL string
+	}{
+		{"base + userPath", 13, "byPath(String)", getForObject, model.URLControlPath, "${baseUrl}{userPath}"},
+		{"builder path", 18, "byBuilderPath(String)", getForObject, model.URLControlPath, "http://svc.internal/users/{id}"},
+		{"builder queryParam", 23, "byBuilderQuery(String)", getForObject, model.URLControlQuery, "${baseUrl}/search?q={q}"},
+		{"scheme + host", 27, "byHost(String)", openStream, model.URLControlFull, "http://{host}/status"},
+		{"constant URL", 31, "fixed()", getForObject, "", "${SERVICE_URL}"},
+		{"template variable", 35, "fixedTemplate(String)", getForObject, model.URLControlPath, "http://svc.internal/users/{id}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := FunctionInfo{Symbol: tt.symbol, Class: "com.example.Client"}
+			got, notes := AnalyzeURL(path, tt.line, fn, tt.rule)
+			if got != tt.want {
+				t.Errorf("control = %q, want %q (notes %q)", got, tt.want, notes)
+			}
+			if len(notes) == 0 || notes[0] != "🔗 URL: `"+tt.wantURL+"`" {
+				t.Errorf("notes = %q, want URL %q", notes, tt.wantURL)
+			}
+			if tt.want == "" && (len(notes) < 2 || notes[1] != urlFixedNote) {
+				t.Errorf("notes = %q, want fixed URL note", notes)
+			}
+		})
+	}
+}
+
+// 调用点不是规则方法时不做分析
+func TestAnalyzeURLNoCall(t *testing.T) {
+	path := filepath.Join("testdata", "urlexpr", "Client.java")
+	rule := ssrfRule(t, "org.springframework.web.client.RestTemplate", "getForObject")
+	if got, notes := AnalyzeURL(path, 17, FunctionInfo{Symbol: "byBuilderPath(String)"}, rule); got != "" || notes != nil {
+		t.Errorf("AnalyzeURL on non-call line = %q, %q", got, notes)
+	}
+}
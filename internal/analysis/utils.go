@@ -2,10 +2,12 @@ package analysis
 
 import (
 	"LSPTracer/internal/model"
+	"LSPTracer/internal/textutil"
 	"bufio"
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -14,10 +16,26 @@ import (
 type AnalysisResult struct {
 	Code     string
 	DataFlow []string
+	Field    string // 参数既不是局部变量也不是方法参数时，可能对应的字段名 (由 traceFieldWrites 确认)
+	Expr     string // 流入调用点的值: 局部变量的赋值表达式或参数列表 (常量时为空)，用于传播分析
+
+	// 不影响判断的附加说明: 追踪的变量被写入日志、调用点的异常被吞掉 (基于函数的符号范围)
+	Notes []string
+}
+
+// Tainted 调用点参数是否追溯到了非常量的变量定义或方法参数
+func (r AnalysisResult) Tainted() bool {
+	for _, flow := range r.DataFlow {
+		if strings.HasPrefix(flow, "⚠️") {
+			return true
+		}
+	}
+	return false
 }
 
 // AnalyzeCallSite 分析调用点代码，尝试简单的变量回溯
-func AnalyzeCallSite(path string, line int, targetFunc string) AnalysisResult {
+// fn 是调用点所在的函数 (找不到时为零值)，用于判断参数是否为该函数的方法参数
+func AnalyzeCallSite(path string, line int, fn FunctionInfo) AnalysisResult {
 	file, err := os.Open(path)
 	if err != nil {
 		return AnalysisResult{}
@@ -35,9 +53,13 @@ func AnalyzeCallSite(path string, line int, targetFunc string) AnalysisResult {
 	}
 	code := strings.TrimSpace(lines[line])
 	var flows []string
+	var field, expr string
 
 	args := extractArgs(code)
-	if args != "" && !isStrictConstant(args) {
+	if class := classifyExpr(args, lines); args != "" && !isStrictConstant(args) && class != valueDynamic {
+		// 常量字段、枚举常量或环境变量/系统属性
+		flows = append(flows, classNote(class, args))
+	} else if args != "" && !isStrictConstant(args) {
 		// 1. 尝试查找本地变量定义
 		defLine := findDefinition(lines, line, args)
 
@@ -45,19 +67,25 @@ func AnalyzeCallSite(path string, line int, targetFunc string) AnalysisResult {
 			defValue := extractRHS(defLine)
 			if isStrictConstant(defValue) {
 				flows = append(flows, fmt.Sprintf("🟢 Defined as Constant: `%s`", strings.TrimSpace(defValue)))
+			} else if class := classifyExpr(defValue, lines); class != valueDynamic {
+				flows = append(flows, classNote(class, strings.TrimSpace(defValue)))
 			} else {
 				flows = append(flows, fmt.Sprintf("⚠️ Variable Definition: `%s`", strings.TrimSpace(defValue)))
+				expr = defValue
 			}
 		} else {
+			expr = args
 			// 2. 如果没找到定义，检查是否为方法参数
-			// (targetFunc 可能是 "download" 或 "download(String)")
-			funcNameSimple := targetFunc
-			if idx := strings.Index(targetFunc, "("); idx != -1 {
-				funcNameSimple = targetFunc[:idx]
-			}
-
-			if isMethodParameter(lines, line, funcNameSimple, args) {
+			if isMethodParameter(lines, line, methodRefOf(fn), args) {
 				flows = append(flows, fmt.Sprintf("⚠️ Variable Definition: Method Parameter `%s`", args))
+			} else if getter, ok := findBeanGetter(path, lines, line, fn, args); ok {
+				// 3. DTO 的 getter: 值来自 getter 的接收者 (方法参数或字段)
+				flows = append(flows, getter.notes()...)
+				if !getter.Param {
+					field = getter.Receiver
+				}
+			} else {
+				field = fieldCandidate(args)
 			}
 		}
 	}
@@ -65,10 +93,21 @@ func AnalyzeCallSite(path string, line int, targetFunc string) AnalysisResult {
 	return AnalysisResult{
 		Code:     code,
 		DataFlow: flows,
+		Field:    field,
+		Expr:     expr,
+		Notes:    callSiteNotes(lines, line, fn, args),
 	}
 }
 
 func findDefinition(lines []string, currentLine int, varName string) string {
+	if i := findDefinitionLine(lines, currentLine, varName); i != -1 {
+		return strings.TrimSpace(lines[i])
+	}
+	return ""
+}
+
+// findDefinitionLine 从 currentLine 向上查找变量最近一次赋值所在的行，没有找到时返回 -1
+func findDefinitionLine(lines []string, currentLine int, varName string) int {
 	start := currentLine - 1
 	limit := currentLine - 50 // 扩大搜索范围
 	if limit < 0 {
@@ -85,30 +124,72 @@ func findDefinition(lines []string, currentLine int, varName string) string {
 		}
 
 		if pattern.MatchString(text) {
-			return text
+			return i
 		}
 	}
-	return ""
+	return -1
+}
+
+// methodRef 按名称查找方法声明时的匹配条件: 重载方法按参数个数区分，同名方法按所在类区分
+type methodRef struct {
+	Name  string // 方法名 (不含参数)
+	Class string // 所在类的简单类名，空表示不检查
+	Arity int    // 参数个数，-1 表示不检查
+}
+
+// methodRefOf 从 documentSymbol 得到的函数构造匹配条件 (Symbol 通常带参数签名, e.g. "save(Order)")
+func methodRefOf(fn FunctionInfo) methodRef {
+	name, _, _ := strings.Cut(fn.Symbol, "(")
+	return methodRef{
+		Name:  strings.TrimSpace(name),
+		Class: textutil.SimpleClassName(fn.Class),
+		Arity: textutil.ParamCount(fn.Symbol),
+	}
 }
 
 // 检查变量是否为方法参数
-func isMethodParameter(lines []string, currentLine int, startFuncName string, varName string) bool {
+func isMethodParameter(lines []string, currentLine int, ref methodRef, varName string) bool {
+	signature, ok := enclosingSignature(lines, currentLine, ref)
+	if !ok {
+		return false
+	}
+	// 检查参数列表里是否有 varName (参数可能跨行)
+	// 简单正则匹配 \bvarName\b
+	matched, _ := regexp.MatchString(`\b`+regexp.QuoteMeta(varName)+`\b`, signature)
+	return matched
+}
+
+// enclosingSignature 返回 ref 对应的方法声明签名
+// 向上按名称查找方法声明，参数个数或所在类与 ref 不一致的同名声明 (重载/其它类) 会被跳过
+func enclosingSignature(lines []string, currentLine int, ref methodRef) (string, bool) {
+	if ref.Name == "" {
+		return "", false
+	}
+	nameRe := regexp.MustCompile(`\b` + regexp.QuoteMeta(ref.Name) + `\s*\(`)
+
 	// 向前搜索函数定义
 	for i := currentLine; i >= 0; i-- {
 		line := strings.TrimSpace(lines[i])
 
 		// 找到包含函数名和左括号的行 (public String download(String url))
-		if strings.Contains(line, startFuncName) && strings.Contains(line, "(") {
+		if nameRe.MatchString(line) {
 			// 避免匹配到调用 (e.g. this.download(...)) - 简单 heuristic: 方法定义通常有修饰符或返回类型
 			// 但这里简单判断：如果是调用，通常以 ; 结尾 (Java)
 			if strings.HasSuffix(line, ";") {
 				continue
 			}
 
-			// 检查参数列表里是否有 varName
-			// 简单正则匹配 \bvarName\b
-			matched, _ := regexp.MatchString(`\b`+regexp.QuoteMeta(varName)+`\b`, line)
-			return matched
+			// 重载或其它类中的同名方法
+			signature := methodSignature(lines, i)
+			if ref.Arity >= 0 && textutil.ParamCount(signature[nameRe.FindStringIndex(signature)[0]:]) != ref.Arity {
+				continue
+			}
+			if ref.Class != "" {
+				if class := textutil.EnclosingClassName(lines, i); class != "" && class != ref.Class {
+					continue
+				}
+			}
+			return signature, true
 		}
 
 		// 别找太远
@@ -116,7 +197,7 @@ func isMethodParameter(lines []string, currentLine int, startFuncName string, va
 			break
 		}
 	}
-	return false
+	return "", false
 }
 
 func extractRHS(code string) string {
@@ -137,6 +218,168 @@ func extractArgs(code string) string {
 	return ""
 }
 
+// callArgs 返回 openIdx 处 "(" 对应的参数列表 (不含括号)
+// 跨行调用没有闭合的 ")" 时截止到行尾
+func callArgs(code string, openIdx int) string {
+	if openIdx < 0 || openIdx >= len(code) || code[openIdx] != '(' {
+		return ""
+	}
+	depth := 0
+	var quote byte
+	for i := openIdx; i < len(code); i++ {
+		c := code[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(code[openIdx+1 : i])
+			}
+		}
+	}
+	return strings.TrimSpace(code[openIdx+1:])
+}
+
+// splitTopLevel 按顶层的 sep 拆分表达式 (忽略括号和字符串中的 sep)
+func splitTopLevel(expr string, sep byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(expr[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(expr[start:]); rest != "" || len(parts) > 0 {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// splitArgs 拆分参数列表，e.g. `"a, b", x, f(y, z)` -> [`"a, b"`, `x`, `f(y, z)`]
+func splitArgs(args string) []string {
+	return splitTopLevel(args, ',')
+}
+
+// checkedArg 返回规则需要做常量检查的参数表达式 (CheckArg 为 0 时是全部参数)
+func checkedArg(rule model.SinkRule, args string) string {
+	if rule.CheckArg <= 0 {
+		return args
+	}
+	parts := splitArgs(args)
+	if rule.CheckArg > len(parts) {
+		return args
+	}
+	return parts[rule.CheckArg-1]
+}
+
+// findConstantField 在文件中查找 static final 字段，返回其初始值
+func findConstantField(lines []string, name string) (string, bool) {
+	re := regexp.MustCompile(`\b(?:static\s+final|final\s+static)\b.*\b` + regexp.QuoteMeta(name) + `\s*=\s*(.+?);?\s*$`)
+	for _, line := range lines {
+		if m := re.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return strings.TrimSpace(m[1]), true
+		}
+	}
+	return "", false
+}
+
+var stringReaderRe = regexp.MustCompile(`^new\s+(?:java\.io\.)?StringReader\s*\((.*)\)$`)
+
+// isConstantExpr 在 isStrictConstant 的基础上，允许引用同一文件中的 static final 常量和枚举常量
+// e.g. `SQL_PREFIX + " where 1=1"` (SQL_PREFIX 为 static final String)
+func isConstantExpr(expr string, lines []string) bool {
+	return classifyExpr(expr, lines) == valueConstant
+}
+
+// describeDynamicSQL 如果 SQL 参数是拼接或 String.format 构造的，返回还原出的表达式说明
+// 参数是局部变量时，沿用 findDefinition 查找它的赋值
+func describeDynamicSQL(expr string, lines []string, currentLine int) string {
+	isDynamic := func(e string) bool {
+		return strings.Contains(e, "String.format(") || (len(splitTopLevel(e, '+')) > 1 && !isConstantExpr(e, lines))
+	}
+	if isDynamic(expr) {
+		return fmt.Sprintf("🧩 SQL built dynamically: `%s`", expr)
+	}
+	if isVar(expr) {
+		if def := findDefinition(lines, currentLine, expr); def != "" {
+			if rhs := strings.TrimSpace(extractRHS(def)); isDynamic(rhs) {
+				return fmt.Sprintf("🧩 SQL built dynamically: `%s = %s`", expr, rhs)
+			}
+		}
+	}
+	return ""
+}
+
+// annotationArgs 返回注解的参数 (支持跨行)，text 为首行从 "(" 开始的部分
+func annotationArgs(text string, lines []string, startLine int) string {
+	for i := startLine + 1; i < len(lines) && i < startLine+20; i++ {
+		if strings.Count(text, "(") <= strings.Count(text, ")") {
+			break
+		}
+		text += " " + strings.TrimSpace(lines[i])
+	}
+	return callArgs(text, 0)
+}
+
+// isUnsafeAnnotationSQL 注解中的 SQL 是否有拼接 (非常量) 或 MyBatis ${} 占位符
+func isUnsafeAnnotationSQL(args string, lines []string) bool {
+	if strings.Contains(args, "${") {
+		return true
+	}
+	for _, arg := range splitArgs(args) {
+		// value = "..." / nativeQuery = true
+		if idx := strings.Index(arg, "="); idx != -1 && isVar(strings.TrimSpace(arg[:idx])) {
+			arg = strings.TrimSpace(arg[idx+1:])
+		}
+		// 数组形式: @Select({"select *", "from t where id = #{id}"})
+		if strings.HasPrefix(arg, "{") && strings.HasSuffix(arg, "}") {
+			if isUnsafeAnnotationSQL(strings.TrimSpace(arg[1:len(arg)-1]), lines) {
+				return true
+			}
+			continue
+		}
+		if !isConstantExpr(arg, lines) {
+			return true
+		}
+	}
+	return false
+}
+
+// classLiteralRe 类字面量 (String.class、byte[].class)；参数列表 "url, String.class" 不算
+var classLiteralRe = regexp.MustCompile(`^[\w.$]+(?:\[\])*\.class$`)
+
 // 严格常量检测
 func isStrictConstant(expr string) bool {
 	expr = strings.TrimSpace(expr)
@@ -146,7 +389,7 @@ func isStrictConstant(expr string) bool {
 	if isNumber(expr) || expr == "true" || expr == "false" || expr == "null" {
 		return true
 	}
-	if strings.HasSuffix(expr, ".class") {
+	if classLiteralRe.MatchString(expr) {
 		return true
 	}
 	if !strings.Contains(expr, "\"") {
@@ -175,11 +418,62 @@ func hasVariableChar(s string) bool {
 	return false
 }
 
+// isInsideLambda 判断代码片段的最后一行是否处于尚未闭合的 lambda 代码块中
+// lines 从函数定义行开始，到目标行结束 (包含)
+func isInsideLambda(lines []string) bool {
+	if len(lines) == 0 {
+		return false
+	}
+	if strings.Contains(lines[len(lines)-1], "->") {
+		return true
+	}
+
+	// 记录每个 '{' 是否为 lambda 代码块的开始 ("-> {")
+	var stack []bool
+	for _, line := range lines[:len(lines)-1] {
+		code := line
+		if idx := strings.Index(code, "//"); idx != -1 {
+			code = code[:idx]
+		}
+		for j := 0; j < len(code); j++ {
+			switch code[j] {
+			case '{':
+				prefix := strings.TrimSpace(code[:j])
+				stack = append(stack, strings.HasSuffix(prefix, "->"))
+			case '}':
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+	for _, isLambda := range stack {
+		if isLambda {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateString 按显示宽度截断 (不会切开中文等多字节字符)
 func truncateString(s string, max int) string {
-	if len(s) > max {
-		return s[:max] + "..."
+	return textutil.Truncate(s, max)
+}
+
+// formatThousands 1243 -> "1,243"
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatThousands(-n)
+	}
+	var sb strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
 	}
-	return s
+	return sb.String()
 }
 
 func abs(x int) int {
@@ -191,6 +485,11 @@ func abs(x int) int {
 
 // IsTypeMismatch 使用启发式规则检查变量类型是否明显不匹配
 func IsTypeMismatch(code string, rule model.SinkRule, lines []string, currentLine int) bool {
+	// 注解规则没有接收者
+	if rule.Annotation {
+		return false
+	}
+
 	// 1. 提取调用方法的变量名 例如: "out.write(...)" -> "out"
 	idx := strings.Index(code, ".")
 	if idx == -1 {
@@ -223,7 +522,7 @@ func IsTypeMismatch(code string, rule model.SinkRule, lines []string, currentLin
 		//    If user writes `java.nio.file.Files.write`, varName matches "Files".
 		//    So we just check against ruleShort.
 
-		if varName != ruleShort && varName != rule.ClassName {
+		if varName != ruleShort && varName != rule.ClassName && !rule.MatchesSimpleName(varName) {
 			return true // Mismatch: 静态方法必须通过类名调用
 		}
 	}
@@ -285,6 +584,11 @@ func IsTypeMismatch(code string, rule model.SinkRule, lines []string, currentLin
 
 	declaredType = strings.TrimSpace(declaredType)
 
+	// 声明类型就是规则类或其已知子类型 (match_subtypes)
+	if rule.MatchesSimpleName(declaredType) {
+		return false
+	}
+
 	isStream := strings.HasSuffix(declaredType, "Stream")
 	isWriter := strings.HasSuffix(declaredType, "Writer") || strings.HasSuffix(declaredType, "Reader")
 
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMethodRefOf(t *testing.T) {
+	tests := []struct {
+		fn   FunctionInfo
+		want methodRef
+	}{
+		{FunctionInfo{Symbol: "save(Order)", Class: "Services.OrderService"}, methodRef{"save", "OrderService", 1}},
+		{FunctionInfo{Symbol: "run()", Class: "Services$1"}, methodRef{"run", "Services", 0}},
+		{FunctionInfo{Symbol: "save"}, methodRef{"save", "", -1}},
+	}
+	for _, tt := range tests {
+		if got := methodRefOf(tt.fn); got != tt.want {
+			t.Errorf("methodRefOf(%s in %s) = %+v, want %+v", tt.fn.Symbol, tt.fn.Class, got, tt.want)
+		}
+	}
+}
+
+// testdata/overload/Services.java 中 OrderService.save(Order)、OrderService.save(Order, boolean) 和 FileService.save(String) 同名
+// 按名称向上查找方法声明时，参数个数或所在类不同的 save 不能被当作调用点所在的方法 (行号 0 起始)
+func TestIsMethodParameterOverloads(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "overload", "Services.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	tests := []struct {
+		line int
+		ref  methodRef
+		arg  string
+		want bool
+	}{
+		{21, methodRef{"save", "FileService", 1}, "path", true},
+		{21, methodRef{"save", "OrderService", 1}, "path", false}, // 跳过 FileService.save 和两个参数的重载
+		{13, methodRef{"save", "OrderService", 2}, "flush", true}, // 参数跨行
+		{13, methodRef{"save", "OrderService", 1}, "flush", false},
+		{21, methodRef{"save", "", -1}, "path", true},
+		{21, methodRef{"", "", -1}, "path", false},
+	}
+	for _, tt := range tests {
+		if got := isMethodParameter(lines, tt.line, tt.ref, tt.arg); got != tt.want {
+			t.Errorf("isMethodParameter(line %d, %+v, %s) = %v, want %v", tt.line, tt.ref, tt.arg, got, tt.want)
+		}
+	}
+}
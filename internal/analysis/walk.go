@@ -0,0 +1,211 @@
+package analysis
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WalkProject 遍历项目目录，回调语义与 filepath.WalkDir 相同
+// 指向目录的符号链接默认跳过；follow 为 true 时进入这些目录，
+// 并按真实路径记录已经遍历过的目录，打破链接循环、避免同一物理目录 (e.g. 链接进来的 vendor 源码) 被扫描两次
+func WalkProject(root string, follow bool, fn fs.WalkDirFunc) error {
+	rootReal, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return filepath.WalkDir(root, fn)
+	}
+	w := &treeWalker{follow: follow, fn: fn, visited: make(map[string]bool)}
+	if err := w.walk(filepath.Clean(root), rootReal); err != nil && !errors.Is(err, filepath.SkipAll) {
+		return err
+	}
+	return nil
+}
+
+type treeWalker struct {
+	follow  bool
+	fn      fs.WalkDirFunc
+	visited map[string]bool // 已遍历的真实目录路径
+}
+
+// walk 遍历真实目录 real，回调中的路径以 display 为前缀 (经过符号链接时 display 是链接所在的路径)
+func (w *treeWalker) walk(display, real string) error {
+	return filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		shown := display
+		if rel, relErr := filepath.Rel(real, path); relErr == nil && rel != "." {
+			shown = filepath.Join(display, rel)
+		}
+		if err != nil || d.IsDir() {
+			if err == nil {
+				if path != real && (isToolDir(path) || isToolDir(shown)) {
+					return filepath.SkipDir
+				}
+				if w.visited[path] {
+					return filepath.SkipDir
+				}
+				w.visited[path] = true
+			}
+			return w.call(shown, d, err)
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return w.call(shown, d, nil)
+		}
+
+		// 符号链接: 指向文件的照常交给回调，指向目录的按 follow 决定
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil // 悬空链接
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			return w.call(shown, d, nil)
+		}
+		if !w.follow || w.visited[target] || isToolDir(target) || isToolDir(shown) {
+			return nil
+		}
+		if err := w.call(shown, fs.FileInfoToDirEntry(info), nil); err != nil {
+			if errors.Is(err, filepath.SkipDir) {
+				return nil
+			}
+			return err
+		}
+		return w.walk(shown, target)
+	})
+}
+
+// toolDirs LSPTracer 自己的目录 (依赖目录、报告输出目录、JDT.LS 数据目录) 的绝对路径和真实路径，
+// 在项目目录下运行时它们位于项目中，WalkProject 总是跳过
+var toolDirs = make(map[string]bool)
+
+// ExcludeToolDirs 让之后的 WalkProject 跳过这些目录 (相对路径按当前工作目录解析，扫描开始前调用)
+func ExcludeToolDirs(dirs ...string) {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		toolDirs[abs] = true
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			toolDirs[real] = true
+		}
+	}
+}
+
+// isToolDir 目录是否是 ExcludeToolDirs 登记的目录
+func isToolDir(path string) bool {
+	if len(toolDirs) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	return err == nil && toolDirs[abs]
+}
+
+// call 调用回调；SkipAll 需要穿过嵌套的 WalkDir 传回最外层
+func (w *treeWalker) call(path string, d fs.DirEntry, err error) error {
+	err = w.fn(path, d, err)
+	if errors.Is(err, filepath.SkipAll) {
+		return errSkipAll
+	}
+	return err
+}
+
+// errSkipAll 与 filepath.SkipAll 区分开，避免被内层 WalkDir 当作正常结束吞掉
+var errSkipAll = &wrappedSkipAll{}
+
+type wrappedSkipAll struct{}
+
+func (*wrappedSkipAll) Error() string        { return filepath.SkipAll.Error() }
+func (*wrappedSkipAll) Is(target error) bool { return target == filepath.SkipAll }
+
+// WalkPolicy 遍历项目时跳过哪些目录
+// 为 JDT.LS 建立索引 (生成 .classpath 的源码目录) 和查找 Sink 的需求不同:
+// MapStruct / Immutables / QueryDSL 生成的类必须作为源码目录才能解析到，但发现应该报告在手写的代码中
+type WalkPolicy struct {
+	Generated bool           // 进入 target/generated-sources、build/generated/sources 等生成源码目录
+	Ignore    *IgnoreMatcher // .gitignore / .lsptracerignore 忽略的路径 (nil 表示不读取忽略文件)
+}
+
+var (
+	// IndexWalk 查找源码目录 (索引用): 包含生成的源码
+	IndexWalk = WalkPolicy{Generated: true}
+	// ScanWalk 查找 Sink 候选点: 默认不包含生成的源码 (-include-generated 时包含)
+	ScanWalk = WalkPolicy{}
+)
+
+// UseIgnoreFiles 让 IndexWalk 和 ScanWalk 跳过 m 忽略的路径 (扫描开始前调用一次)
+func UseIgnoreFiles(m *IgnoreMatcher) {
+	IndexWalk.Ignore = m
+	ScanWalk.Ignore = m
+}
+
+// generatedDirs 构建输出目录下存放生成源码的子目录
+var generatedDirs = map[string][]string{
+	"target": {"generated-sources", "generated-test-sources"},
+	"build":  {"generated/sources", "generated/source"},
+}
+
+// SkipDir 目录 path 是否应该跳过 (root 本身不会被跳过)
+// 隐藏目录和 node_modules 总是跳过；target / build 下只有生成源码目录 (Generated 为 true 时) 会被进入；
+// 其它目录被忽略文件排除时跳过
+func (p WalkPolicy) SkipDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	name := parts[len(parts)-1]
+	if strings.HasPrefix(name, ".") || name == "node_modules" {
+		return true
+	}
+	for i, part := range parts {
+		allowed, ok := generatedDirs[part]
+		if !ok {
+			continue
+		}
+		if !p.Generated {
+			return true
+		}
+		rest := strings.Join(parts[i+1:], "/")
+		if rest == "" {
+			return false // 构建目录本身: 继续向下找生成源码目录
+		}
+		for _, dir := range allowed {
+			// rest 是生成源码目录、它的上级或者它里面的目录
+			if rest == dir || strings.HasPrefix(dir, rest+"/") || strings.HasPrefix(rest, dir+"/") {
+				return false
+			}
+		}
+		return true
+	}
+	// 构建目录由上面的规则决定 (它们通常也在 .gitignore 中，但生成源码目录仍然需要索引)
+	return p.Ignore.Ignored(path, true)
+}
+
+// SkipFile 文件 path 是否被忽略文件排除 (构建目录中的生成源码不受影响，见 SkipDir)
+func (p WalkPolicy) SkipFile(path string) bool {
+	if p.Ignore == nil || inBuildDir(p.Ignore.root, path) {
+		return false
+	}
+	return p.Ignore.Ignored(path, false)
+}
+
+// inBuildDir path 是否位于 target / build 目录中
+func inBuildDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if _, ok := generatedDirs[part]; ok {
+			return true
+		}
+	}
+	return false
+}
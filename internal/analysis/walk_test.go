@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"LSPTracer/internal/model"
+)
+
+// excludeToolDirs 登记 LSPTracer 自己的目录，测试结束后清除
+func excludeToolDirs(t *testing.T, dirs ...string) {
+	t.Helper()
+	ExcludeToolDirs(dirs...)
+	t.Cleanup(func() { toolDirs = make(map[string]bool) })
+}
+
+// 在项目目录下运行时依赖目录 (JDT.LS 插件) 和报告输出目录位于项目中: 不产生 source root 和候选点
+func TestExcludeToolDirs(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("testdata", "tooldirs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不排除时依赖目录中的源码会被当作 source root
+	if got := sourceRoots(t, root); !slices.Contains(got, "lsptracer-deps/jdtls/plugins/src") {
+		t.Fatalf("fixture without exclusion: source roots %v", got)
+	}
+
+	excludeToolDirs(t, filepath.Join(root, "lsptracer-deps"), filepath.Join(root, "reports"))
+	if got := sourceRoots(t, root); !slices.Equal(got, []string{"src/main/java"}) {
+		t.Errorf("source roots %v, want only src/main/java", got)
+	}
+
+	tr := &Tracer{ProjectRoot: root}
+	var files []string
+	for _, c := range tr.findCandidates(model.GetBuiltinRules()) {
+		rel, _ := filepath.Rel(root, c.File)
+		files = append(files, filepath.ToSlash(rel))
+	}
+	if len(files) == 0 {
+		t.Fatal("no candidates in the project sources")
+	}
+	for _, f := range files {
+		if f != "src/main/java/com/acme/App.java" {
+			t.Errorf("candidate in %s", f)
+		}
+	}
+}
+
+// 通过符号链接进入的依赖目录同样跳过
+func TestExcludeToolDirsSymlink(t *testing.T) {
+	deps, err := filepath.Abs(filepath.Join("testdata", "tooldirs", "lsptracer-deps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"src/main/java/App.java": "public class App {\n}\n"})
+	if err := os.Symlink(deps, filepath.Join(root, "vendor")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	excludeToolDirs(t, deps)
+
+	var walked []string
+	WalkProject(root, true, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			walked = append(walked, path)
+		}
+		return nil
+	})
+	for _, path := range walked {
+		if strings.Contains(path, "vendor") {
+			t.Errorf("walked %s", path)
+		}
+	}
+	if len(walked) != 1 {
+		t.Errorf("walked %v, want only App.java", walked)
+	}
+}
+
+// 跟随符号链接时链接循环 (指向自身、祖先目录或互相指向) 不会让遍历停不下来，每个文件只回调一次
+func TestWalkProjectSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"src/a/A.java": "class A {}\n",
+		"src/b/B.java": "class B {}\n",
+	})
+	links := map[string]string{
+		"self":       root,   // 指向项目根目录
+		"src/a/loop": ".",    // 指向所在目录
+		"src/a/up":   "..",   // 指向上级目录
+		"src/a/toB":  "../b", // a 和 b 互相指向
+		"src/b/toA":  "../a",
+		"src/dup":    "b",     // 同一目录的第二个入口
+		"src/ring1":  "ring2", // 链接互相指向，无法解析
+		"src/ring2":  "ring1",
+		"src/gone":   "missing/dir", // 悬空链接
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	for _, follow := range []bool{false, true} {
+		done := make(chan []string, 1)
+		go func() {
+			var files []string
+			WalkProject(root, follow, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					files = append(files, path)
+				}
+				return nil
+			})
+			done <- files
+		}()
+		var files []string
+		select {
+		case files = <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("follow=%v: walk did not terminate", follow)
+		}
+
+		seen := map[string]int{}
+		for _, path := range files {
+			if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+				t.Errorf("follow=%v: walked %s outside the project", follow, path)
+			}
+			seen[filepath.Base(path)]++
+		}
+		want := map[string]int{"A.java": 1, "B.java": 1}
+		if !reflect.DeepEqual(seen, want) {
+			t.Errorf("follow=%v: walked %v, want each file once", follow, files)
+		}
+	}
+}
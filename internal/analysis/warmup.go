@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// DefaultWarmupFiles 追踪前预先打开的文件数量上限 (不超过 DocumentManager 同时打开的文档数量)
+// 只打开了锚点文件时，JDT.LS 对尚未解析的文件的引用查询更慢，有时结果也不完整
+const DefaultWarmupFiles = 40
+
+const (
+	warmupBatch    = 10                     // 每批发送的 didOpen 数量
+	warmupInterval = 200 * time.Millisecond // 批次之间的间隔，避免一次性压满语言服务器
+	warmupQuiet    = 1500 * time.Millisecond
+	warmupMaxWait  = 30 * time.Second
+)
+
+// warmupTargets 需要预先打开的文件: 包含候选点的文件 (候选点多的优先)，以及这些文件所在目录中的 Controller
+// 结果最多 limit 个
+func warmupTargets(cands []candidate, limit int) []string {
+	hits := make(map[string]int)
+	var files []string
+	for _, c := range cands {
+		if hits[c.File] == 0 {
+			files = append(files, c.File)
+		}
+		hits[c.File]++
+	}
+	sort.SliceStable(files, func(i, j int) bool { return hits[files[i]] > hits[files[j]] })
+
+	seen := make(map[string]bool)
+	var targets []string
+	add := func(path string) bool {
+		if !seen[path] {
+			seen[path] = true
+			targets = append(targets, path)
+		}
+		return len(targets) < limit
+	}
+	for _, f := range files {
+		if !add(f) {
+			return targets
+		}
+	}
+
+	// 调用者通常在同一个包的 Controller 中
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".java") || seen[path] {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if err != nil || !controllerPattern.Match(content) {
+				continue
+			}
+			if !add(path) {
+				return targets
+			}
+		}
+	}
+	return targets
+}
+
+// warmUp 在验证候选点之前分批 didOpen 包含候选点的文件，并等待诊断发布平静下来
+// 文档由 Docs 管理: 已经打开的文件不会重复发送，超过上限时关闭最久未使用的文档
+func (t *Tracer) warmUp(cands []candidate) {
+	limit := t.WarmupFiles
+	if limit > t.Docs.Capacity() {
+		limit = t.Docs.Capacity()
+	}
+	if limit <= 0 || len(cands) == 0 {
+		return
+	}
+	start := time.Now()
+	targets := warmupTargets(cands, limit)
+	color.Blue("[*] Warming up: opening %d files with candidates...", len(targets))
+
+	opened := 0
+	for i, path := range targets {
+		if i > 0 && i%warmupBatch == 0 {
+			time.Sleep(warmupInterval)
+		}
+		if err := t.Docs.Open(path); err == nil {
+			opened++
+		}
+	}
+	if !t.Client.WaitDiagnosticsSettled(warmupQuiet, warmupMaxWait) {
+		color.Yellow("[!] Diagnostics still arriving after %s; starting verification anyway.", warmupMaxWait)
+	}
+	t.Stats.WarmedFiles = opened
+	t.Stats.Warmup = time.Since(start)
+}
@@ -1,24 +1,263 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultFile 默认的配置文件名 (在项目根目录或当前目录下自动查找)
+const DefaultFile = "lsptracer.yaml"
+
+// Config lsptracer.yaml 的内容；没有出现的键保持零值，不覆盖命令行默认值
+// 优先级: 命令行参数 > 配置文件 > 默认值
 type Config struct {
 	JdtlsHome   string `yaml:"jdtls_home"`
+	DepsDir     string `yaml:"deps_dir"`   // 自动下载的 JDT.LS 和 Lombok 的存放目录
+	LombokJar   string `yaml:"lombok_jar"` // 替代自动下载的 Lombok jar (总是注入)
+	NoLombok    *bool  `yaml:"no_lombok"`  // 从不注入 Lombok agent
 	ProjectRoot string `yaml:"project_root"`
 	Target      struct {
 		File string `yaml:"file"`
 		Line int    `yaml:"line"`
 		Col  int    `yaml:"col"`
 	} `yaml:"target"`
+	Targets string `yaml:"targets"` // 单点模式的目标列表文件 (每行一个 path:line)
+	// 单点模式的追踪方向 (up / down / both) 和向下展开的层数
+	Direction   string `yaml:"direction"`
+	CalleeDepth int    `yaml:"callee_depth"`
+
+	Mode             string   `yaml:"mode"`               // light / precise
+	Rules            string   `yaml:"rules"`              // 规则文件路径
+	Exclude          []string `yaml:"exclude"`            // 初筛阶段跳过的路径 glob
+	Scope            []string `yaml:"scope"`              // 只在这些包 (前缀) 中查找 Sink
+	ScopeDir         []string `yaml:"scope_dir"`          // 只在这些目录 (相对项目根目录) 中查找 Sink
+	FollowSymlinks   *bool    `yaml:"follow_symlinks"`    // 进入符号链接指向的目录
+	NoGitignore      *bool    `yaml:"no_gitignore"`       // 不读取 .gitignore (.lsptracerignore 仍然生效)
+	NoIndexProbe     *bool    `yaml:"no_index_probe"`     // 跳过 JDT.LS 就绪后的索引检查
+	KeepExtracted    *bool    `yaml:"keep_extracted"`     // project 是源码压缩包时保留解压的临时目录
+	ReuseConfig      *bool    `yaml:"reuse_config"`       // 复用已有的 .project/.classpath (补充缺少的源码目录)
+	IncludeGenerated *bool    `yaml:"include_generated"`  // 在生成的源码中查找 Sink
+	IncludeTestCode  *bool    `yaml:"include_test_code"`  // 报告 Sink 位于测试/示例代码中的发现
+	TestPaths        []string `yaml:"test_paths"`         // 约定之外的测试代码路径 glob
+	SamplePaths      []string `yaml:"sample_paths"`       // 约定之外的示例代码路径 glob
+	TreatEnvAsTaint  *bool    `yaml:"treat_env_as_taint"` // 参数来自环境变量/系统属性的 Sink 仍然报告
+	Strict           string   `yaml:"strict"`             // auto / true / false
+	ShowUnverified   *bool    `yaml:"show_unverified"`    // 在报告中单独列出被严格模式排除的链路
+	Secrets          *bool    `yaml:"secrets"`            // 同时扫描硬编码凭据
+	Templates        *bool    `yaml:"templates"`          // 扫描 JSP / Thymeleaf 模板中的非转义输出
+	ConfigScan       *bool    `yaml:"config_scan"`        // 检查 web.xml、Spring XML 和 application 配置中的危险配置
+	Analyzers        []string `yaml:"analyzers"`          // 额外的分析器: authz
+	EmitEndpoints    string   `yaml:"emit_endpoints"`     // HTTP 端点清单的输出文件 (JSON)
+	MinSeverity      string   `yaml:"min_severity"`       // 低于该等级的发现不写入报告
+	MinConfidence    string   `yaml:"min_confidence"`     // 低于该可信度的发现不写入报告
+	Sources          []string `yaml:"sources"`            // 严格模式接受的 Source 类型: http / mq / scheduled / cli
+	OrphanSinks      string   `yaml:"orphan_sinks"`       // 没有调用上下文的 Sink: report / suppress / downgrade
+	MinHealth        *float64 `yaml:"min_health"`         // 最低扫描健康度 (0~1)
+	MaxCallers       *int     `yaml:"max_callers"`        // 单个方法最多追踪的调用者数量 (0 = 不限)
+	MaxDepth         *int     `yaml:"max_depth"`          // 链路最多包含的调用者层数 (0 = 不限)
+	ConsoleSteps     *int     `yaml:"console_steps"`      // 控制台上超过该步数的链路折叠中间的步骤 (0 = 不折叠)
+	ConsoleChains    *int     `yaml:"console_chains"`     // 控制台上完整打印的链路数量，之后每条一行 (0 = 不限)
+	WarmupFiles      *int     `yaml:"warmup_files"`       // 验证前预先打开的候选文件数量 (0 = 只打开锚点)
+	Baseline         string   `yaml:"baseline"`           // 基线 JSON 结果，其中已有的发现不再报告
+	Owners           string   `yaml:"owners"`             // 路径 glob -> 团队的映射文件 (CODEOWNERS 风格)
+	JvmOptions       []string `yaml:"jvm_options"`        // 追加给 JDT.LS 的 JVM 参数 (e.g. -Xmx8G)
+
+	Output struct {
+		Dir     string   `yaml:"dir"`     // 报告输出目录
+		Formats []string `yaml:"formats"` // html / json / sarif
+		LspLog  string   `yaml:"lsp_log"` // LSP 通信日志
+
+		Progress     string `yaml:"progress"`      // text / json (NDJSON 进度事件)
+		ProgressFile string `yaml:"progress_file"` // json 进度事件的输出文件，空表示 stderr
+
+		Template    string `yaml:"template"`     // 自定义 HTML 报告模板
+		ReportTitle string `yaml:"report_title"` // HTML 报告标题
+		Logo        string `yaml:"logo"`         // HTML 报告侧边栏的 Logo 图片
+		Lang        string `yaml:"lang"`         // 报告语言 (en / zh)，空表示按 LANG / LC_ALL 推断
+
+		HTMLContextBudget *int `yaml:"html_context_budget"` // HTML 报告中完整上下文的总大小 (MB，0 = 不限)
+		HTMLPageThreshold *int `yaml:"html_page_threshold"` // 发现数量超过该值时按类型拆分 HTML 报告 (0 = 不拆分)
+
+		PathMap []string `yaml:"path_map"` // 报告中的路径映射 (容器路径=宿主机路径)
+	} `yaml:"output"`
+
+	Timeouts struct {
+		PerSink string `yaml:"per_sink"` // 单个 Sink 的追踪预算 (e.g. 60s, 2m, 0 = 不限)
+	} `yaml:"timeouts"`
 }
 
+// Load 读取配置文件；出现未知的键时报错 (通常是拼写错误)
+// 文件中的相对路径按配置文件所在目录解析
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
-	if err != nil { return nil, err }
+	if err != nil {
+		return nil, err
+	}
+
 	var cfg Config
-	err = yaml.Unmarshal(data, &cfg)
-	return &cfg, err
-}
\ No newline at end of file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.resolvePaths(filepath.Dir(absPath))
+	return &cfg, nil
+}
+
+// Find 返回自动探测到的配置文件: 先找项目根目录，再找当前目录；都没有时返回空串
+func Find(projectRoot string) string {
+	var candidates []string
+	if projectRoot != "" {
+		candidates = append(candidates, filepath.Join(projectRoot, DefaultFile))
+	}
+	candidates = append(candidates, DefaultFile)
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func (c *Config) resolvePaths(dir string) {
+	for _, p := range []*string{&c.JdtlsHome, &c.DepsDir, &c.LombokJar, &c.ProjectRoot, &c.Target.File, &c.Targets, &c.Rules, &c.Baseline, &c.Owners, &c.EmitEndpoints, &c.Output.Dir, &c.Output.LspLog, &c.Output.ProgressFile, &c.Output.Template, &c.Output.Logo} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+}
+
+// FlagValues 把配置项转换为对应命令行参数的字符串值 (参数名 -> 值)，只包含文件中设置了的项
+// 由调用方决定是否被命令行覆盖，值的校验交给各参数自己的解析逻辑
+func (c *Config) FlagValues() map[string]string {
+	values := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+
+	set("project", c.ProjectRoot)
+	set("jdtls", c.JdtlsHome)
+	set("deps-dir", c.DepsDir)
+	set("lombok-jar", c.LombokJar)
+	if c.NoLombok != nil {
+		set("no-lombok", strconv.FormatBool(*c.NoLombok))
+	}
+	set("targets", c.Targets)
+	set("mode", c.Mode)
+	set("rules", c.Rules)
+	set("exclude", strings.Join(c.Exclude, ","))
+	set("test-paths", strings.Join(c.TestPaths, ","))
+	set("sample-paths", strings.Join(c.SamplePaths, ","))
+	set("scope", strings.Join(c.Scope, ","))
+	set("scope-dir", strings.Join(c.ScopeDir, ","))
+	set("strict", c.Strict)
+	set("min-severity", c.MinSeverity)
+	set("min-confidence", c.MinConfidence)
+	set("sources", strings.Join(c.Sources, ","))
+	set("analyzer", strings.Join(c.Analyzers, ","))
+	set("emit-endpoints", c.EmitEndpoints)
+	set("orphan-sinks", c.OrphanSinks)
+	set("baseline", c.Baseline)
+	set("owners", c.Owners)
+	set("jvm-opts", strings.Join(c.JvmOptions, " "))
+	set("output", c.Output.Dir)
+	set("format", strings.Join(c.Output.Formats, ","))
+	set("lsp-log", c.Output.LspLog)
+	set("progress", c.Output.Progress)
+	set("progress-file", c.Output.ProgressFile)
+	set("template", c.Output.Template)
+	set("report-title", c.Output.ReportTitle)
+	set("logo", c.Output.Logo)
+	set("lang", c.Output.Lang)
+	set("path-map", strings.Join(c.Output.PathMap, ","))
+	set("per-sink-timeout", c.Timeouts.PerSink)
+	set("direction", c.Direction)
+	if c.CalleeDepth > 0 {
+		set("callee-depth", strconv.Itoa(c.CalleeDepth))
+	}
+
+	if c.Target.File != "" {
+		file := c.Target.File
+		if c.Target.Line > 0 {
+			file += ":" + strconv.Itoa(c.Target.Line)
+		}
+		set("file", file)
+	}
+	if c.FollowSymlinks != nil {
+		set("follow-symlinks", strconv.FormatBool(*c.FollowSymlinks))
+	}
+	if c.NoGitignore != nil {
+		set("no-gitignore", strconv.FormatBool(*c.NoGitignore))
+	}
+	if c.NoIndexProbe != nil {
+		set("no-index-probe", strconv.FormatBool(*c.NoIndexProbe))
+	}
+	if c.IncludeGenerated != nil {
+		set("include-generated", strconv.FormatBool(*c.IncludeGenerated))
+	}
+	if c.IncludeTestCode != nil {
+		set("include-test-code", strconv.FormatBool(*c.IncludeTestCode))
+	}
+	if c.TreatEnvAsTaint != nil {
+		set("treat-env-as-taint", strconv.FormatBool(*c.TreatEnvAsTaint))
+	}
+	if c.KeepExtracted != nil {
+		set("keep-extracted", strconv.FormatBool(*c.KeepExtracted))
+	}
+	if c.ReuseConfig != nil {
+		set("reuse-config", strconv.FormatBool(*c.ReuseConfig))
+	}
+	if c.ShowUnverified != nil {
+		set("show-unverified", strconv.FormatBool(*c.ShowUnverified))
+	}
+	if c.MaxCallers != nil {
+		set("max-callers", strconv.Itoa(*c.MaxCallers))
+	}
+	if c.MaxDepth != nil {
+		set("max-depth", strconv.Itoa(*c.MaxDepth))
+	}
+	if c.ConsoleSteps != nil {
+		set("console-steps", strconv.Itoa(*c.ConsoleSteps))
+	}
+	if c.ConsoleChains != nil {
+		set("console-chains", strconv.Itoa(*c.ConsoleChains))
+	}
+	if c.Output.HTMLContextBudget != nil {
+		set("html-context-budget", strconv.Itoa(*c.Output.HTMLContextBudget))
+	}
+	if c.Output.HTMLPageThreshold != nil {
+		set("html-page-threshold", strconv.Itoa(*c.Output.HTMLPageThreshold))
+	}
+	if c.WarmupFiles != nil {
+		set("warmup", strconv.Itoa(*c.WarmupFiles))
+	}
+	if c.Secrets != nil {
+		set("secrets", strconv.FormatBool(*c.Secrets))
+	}
+	if c.Templates != nil {
+		set("templates", strconv.FormatBool(*c.Templates))
+	}
+	if c.ConfigScan != nil {
+		set("config-scan", strconv.FormatBool(*c.ConfigScan))
+	}
+	if c.MinHealth != nil {
+		set("min-health", strconv.FormatFloat(*c.MinHealth, 'g', -1, 64))
+	}
+	return values
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+	}{
+		{"top level typo", "strict: true\nmin_severty: High\n", "min_severty"},
+		{"nested typo", "output:\n  dir: out\n  fromats: [json]\n", "fromats"},
+		{"timeouts typo", "timeouts:\n  per_snk: 30s\n", "per_snk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.content)
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Load succeeded, want an unknown key error")
+			}
+			if !strings.Contains(err.Error(), tt.key) || !strings.Contains(err.Error(), path) {
+				t.Errorf("error %q should name the key %q and the file", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsBadTypes(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "max_depth: deep\n")
+	if _, err := Load(path); err == nil {
+		t.Error("Load succeeded, want a type error")
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "# 只有注释\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := cfg.FlagValues(); len(values) != 0 {
+		t.Errorf("FlagValues() = %v, want empty", values)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	// lsptracer init 写出的模板必须能被原样加载
+	path := writeConfig(t, t.TempDir(), Template)
+	if _, err := Load(path); err != nil {
+		t.Fatalf("template does not load: %v", err)
+	}
+}
+
+func TestLoadResolvesPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, strings.Join([]string{
+		"project_root: ./app",
+		"rules: rules.yaml",
+		"baseline: /abs/baseline.json",
+		"deps_dir: ../deps",
+		"output:",
+		"  dir: reports",
+		"  template: tpl/report.gohtml",
+	}, "\n"))
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"project_root": filepath.Join(dir, "app"),
+		"rules":        filepath.Join(dir, "rules.yaml"),
+		"baseline":     "/abs/baseline.json",
+		"deps_dir":     filepath.Join(filepath.Dir(dir), "deps"),
+		"output.dir":   filepath.Join(dir, "reports"),
+		"template":     filepath.Join(dir, "tpl", "report.gohtml"),
+	}
+	got := map[string]string{
+		"project_root": cfg.ProjectRoot,
+		"rules":        cfg.Rules,
+		"baseline":     cfg.Baseline,
+		"deps_dir":     cfg.DepsDir,
+		"output.dir":   cfg.Output.Dir,
+		"template":     cfg.Output.Template,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestFlagValues(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), strings.Join([]string{
+		"strict: \"false\"",
+		"exclude: [\"**/gen/**\", \"**/vendor/**\"]",
+		"jvm_options: [-Xmx8G, -XX:+UseG1GC]",
+		"max_depth: 0",
+		"secrets: false",
+		"min_health: 0.8",
+		"callee_depth: 0",
+		"target:",
+		"  file: /src/A.java",
+		"  line: 42",
+		"output:",
+		"  formats: [json, sarif]",
+		"  lang: zh",
+		"timeouts:",
+		"  per_sink: 2m",
+	}, "\n"))
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"strict":           "false",
+		"exclude":          "**/gen/**,**/vendor/**",
+		"jvm-opts":         "-Xmx8G -XX:+UseG1GC",
+		"max-depth":        "0", // 显式的 0 (不限) 也要传递，指针字段区分未设置和零值
+		"secrets":          "false",
+		"min-health":       "0.8",
+		"file":             "/src/A.java:42",
+		"format":           "json,sarif",
+		"lang":             "zh",
+		"per-sink-timeout": "2m",
+	}
+	if got := cfg.FlagValues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlagValues() = %v\nwant %v", got, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	project := t.TempDir()
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+
+	if got := Find(project); got != "" {
+		t.Errorf("Find() = %q, want none", got)
+	}
+	writeConfig(t, cwd, "strict: auto\n")
+	if got := Find(project); got != DefaultFile {
+		t.Errorf("Find() = %q, want the current directory's file", got)
+	}
+	// 项目根目录中的文件优先
+	inProject := writeConfig(t, project, "strict: auto\n")
+	if got := Find(project); got != inProject {
+		t.Errorf("Find() = %q, want %q", got, inProject)
+	}
+}
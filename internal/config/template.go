@@ -0,0 +1,161 @@
+package config
+
+// Template lsptracer init 写出的带注释配置模板；所有项都可以省略，命令行参数优先于文件中的值
+const Template = `# LSPTracer 配置文件
+# 放在项目根目录或当前目录下会被自动加载，也可以通过 -config 指定
+# 优先级: 命令行参数 > 本文件 > 默认值；相对路径按本文件所在目录解析
+
+# 待扫描的项目根目录，也可以是源码压缩包 (.zip / .jar / .tar.gz，解压到临时目录后扫描)
+project_root: .
+
+# project_root 是压缩包时保留解压出的临时目录 (默认扫描结束后删除)
+keep_extracted: false
+
+# 单点模式: 只追踪列出的位置 (每行一个 path:line，# 开头为注释)，留空则自动扫描
+# targets: targets.txt
+
+# 单点模式的追踪方向: up (谁调用了目标行) / down (目标行调用了什么，标出能到达的 Sink) / both
+direction: up
+# direction 为 down / both 时向下展开的项目方法层数
+callee_depth: 3
+
+# JDT.LS 目录，留空则自动下载
+# jdtls_home: /opt/jdtls
+# 自动下载的 JDT.LS 和 Lombok 的存放目录 (默认为当前目录下的 .lsptracer_deps)，在项目目录下运行时建议放到项目之外
+# deps_dir: /opt/lsptracer-deps
+
+# Lombok agent: 默认只在项目使用 Lombok (构建文件中的依赖或 import lombok.) 时注入自动下载的 lombok.jar
+# lombok_jar 指定其它版本 (总是注入)，no_lombok 从不注入
+# lombok_jar: /opt/lombok/lombok.jar
+no_lombok: false
+
+# JDT.LS 就绪后检查索引: 锚点文件没有符号、锚点中声明的类也查不到时终止扫描 (通常是源码目录配置错误)
+# 特殊的项目结构误报时可以关闭
+no_index_probe: false
+
+# 扫描模式: light (生成模拟配置，快) / precise (完整 Maven/Gradle 构建，慢)
+mode: light
+
+# 自定义规则文件，留空使用内置规则
+# rules: rules.yaml
+
+# 初筛阶段跳过的路径 (glob，匹配相对路径或文件/目录名)
+exclude:
+  # - src/test
+  # - generated
+
+# 只在这些包 / 目录中查找 Sink，调用者的追踪仍覆盖整个工作区
+scope:
+  # - com.acme.payments
+scope_dir:
+  # - services/payments
+
+# 进入符号链接指向的目录
+follow_symlinks: false
+
+# 不跳过 .gitignore 中忽略的路径 (.lsptracerignore 仍然生效)
+no_gitignore: false
+
+# 在生成的源码 (target/generated-sources、build/generated/sources) 中查找 Sink；它们总是参与符号解析
+include_generated: false
+
+# 报告 Sink 位于测试代码 (src/test、src/integrationTest 等) 或示例代码 (samples/、demo/、examples/) 中的发现；
+# 默认只在概览中计数。test_paths / sample_paths 在约定之外追加路径 glob (相对项目根目录)
+include_test_code: false
+test_paths:
+  # - qa/**
+sample_paths:
+  # - tutorials/**
+
+# 复用项目中已有的有效 .project/.classpath (保留依赖条目，只补充缺少的源码目录)，而不是重新生成
+reuse_config: false
+
+# 参数来自 System.getenv / System.getProperty 的 Sink 仍然报告 (默认与常量参数一样跳过)
+treat_env_as_taint: false
+
+# 严格模式: auto (自动扫描时开启，单点模式关闭) / true / false
+strict: auto
+
+# 在 HTML/SARIF 报告中单独列出被严格模式排除的链路 (JSON 总是保留它们，render 子命令可以重新选择严格模式)
+show_unverified: false
+
+# 同时扫描硬编码凭据
+secrets: false
+
+# 扫描 JSP / Thymeleaf 模板 (webapp、templates 目录) 中的非转义输出
+templates: true
+
+# 检查 web.xml、Spring XML 和 application.properties / yml 中的危险配置 (规则文件的 config_rules 段)
+config_scan: true
+
+# 额外的分析器: authz (列出没有任何授权检查的 Controller 端点，INFO 等级)
+analyzers:
+  # - authz
+
+# 把 HTTP 端点清单 (路径、HTTP 方法、处理器、参数) 写入 JSON 文件
+# emit_endpoints: endpoints.json
+
+# 严格模式接受的 Source 类型: http / mq (消息队列) / scheduled (定时任务、@PostConstruct) / cli (main 方法)
+sources: [http, mq, scheduled]
+
+# 找不到所在函数 (没有调用上下文) 的 Sink: report / suppress / downgrade (标记为未验证并降低一级)
+orphan_sinks: downgrade
+
+# 低于该等级的发现不写入报告: info / low / medium / high / critical
+# min_severity: medium
+
+# 低于该可信度的发现不写入报告: low / medium / high
+# min_confidence: medium
+
+# 索引健康度低于该值 (0~1) 时终止扫描，0 表示不检查
+min_health: 0
+
+# 单个方法最多继续追踪的调用者数量，超过时只追踪一部分并在汇总中列出该方法，0 表示不限
+max_callers: 50
+
+# 链路最多包含的调用者层数，更深的链路记录为以 DEPTH_LIMIT 结束的部分链路，0 表示不限
+max_depth: 30
+
+# 控制台上超过该步数的链路只显示 Source 和两端的步骤，0 表示不折叠 (报告中总是完整的)
+console_steps: 12
+
+# 控制台上完整打印的链路数量，之后每条链路只打印一行，0 表示不限
+console_chains: 50
+
+# 验证候选点之前预先打开的文件数量 (包含候选点的文件及同目录的 Controller)，0 表示只打开锚点文件
+warmup_files: 40
+
+# 基线结果 (之前扫描生成的 JSON)，其中已有的发现不再报告
+# baseline: baseline.json
+
+# 多模块项目中发现的负责团队: 路径 glob -> 团队的映射 (CODEOWNERS 风格，后面的条目优先)
+# owners: owners.yaml
+
+# 追加给 JDT.LS 的 JVM 参数
+jvm_options:
+  # - -Xmx8G
+
+output:
+  dir: output
+  formats: [html]
+  # lsp_log: lsp.jsonl
+  # 进度输出: text (只输出到控制台) / json (同时把 NDJSON 事件写入 stderr 或 progress_file)
+  progress: text
+  # progress_file: progress.ndjson
+  # HTML 报告的品牌: 标题、侧边栏 Logo (以 base64 嵌入报告) 和自定义模板 (Go html/template)
+  # report_title: ACME Security Assessment
+  # logo: branding/logo.png
+  # template: branding/report.gohtml
+  # 报告语言 (规则描述、漏洞类型名称和 HTML 报告的界面): en / zh，默认按 LANG / LC_ALL 推断
+  # lang: zh
+  # HTML 报告中 "View Full Context" 代码块的总大小 (MB)，超过后只显示摘要行，0 表示不限
+  html_context_budget: 64
+  # 发现数量超过该值时拆分为索引页和按漏洞类型的分页，0 表示不拆分
+  html_page_threshold: 1000
+  # 在 Docker 中扫描时把报告中的容器路径换成宿主机路径 (容器路径=宿主机路径，最长的前缀优先)
+  # path_map: [/src=/home/dev/app]
+
+timeouts:
+  # 单个 Sink 的追踪预算，0 表示不限
+  per_sink: 60s
+`
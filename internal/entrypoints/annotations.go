@@ -0,0 +1,52 @@
+package entrypoints
+
+import (
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// EntryAnnotation 入口注解及其 Source 类型
+type EntryAnnotation struct {
+	Annotation string
+	Kind       string
+}
+
+// EntryAnnotations 标记框架入口的注解
+var EntryAnnotations = []EntryAnnotation{
+	// Spring Web
+	{"@RequestMapping", model.SourceHTTP}, {"@GetMapping", model.SourceHTTP}, {"@PostMapping", model.SourceHTTP},
+	{"@PutMapping", model.SourceHTTP}, {"@DeleteMapping", model.SourceHTTP}, {"@PatchMapping", model.SourceHTTP},
+	// Java EE / Servlet
+	{"@WebFilter", model.SourceHTTP}, {"@WebServlet", model.SourceHTTP},
+	// Spring Listeners
+	{"@RabbitListener", model.SourceMQ}, {"@KafkaListener", model.SourceMQ}, {"@JmsListener", model.SourceMQ},
+	// 定时任务 / 容器回调
+	{"@Scheduled", model.SourceScheduled}, {"@PostConstruct", model.SourceScheduled},
+}
+
+// annotationWindow 方法名所在行之前查找注解的行数
+const annotationWindow = 15
+
+// AnnotatedKind 返回 start 行 (方法名所在行) 的方法上入口注解对应的 Source 类型，没有入口注解时返回空
+// 从方法声明向上查看少量行，遇到上一个成员的结束 (以 "}"、";" 或 "{" 结尾的行) 时停止，注释行跳过
+func AnnotatedKind(lines []string, start int) string {
+	if start < 0 || start >= len(lines) {
+		return ""
+	}
+	for i := start; i >= 0 && i >= start-annotationWindow; i-- {
+		text := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") {
+			continue
+		}
+		if i < start && !strings.HasPrefix(text, "@") && (strings.HasSuffix(text, "}") || strings.HasSuffix(text, ";") || strings.HasSuffix(text, "{")) {
+			break
+		}
+		for _, ann := range EntryAnnotations {
+			if strings.Contains(text, ann.Annotation) {
+				return ann.Kind
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// 追踪时的入口判断: 方法上的入口注解决定 Source 类型，注释中的注解和上一个方法的注解不算
+func TestAnnotatedKind(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "Jobs.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	tests := []struct {
+		method string
+		line   int
+		want   string
+	}{
+		{"onOrder", 5, model.SourceMQ},
+		{"cleanup", 10, model.SourceScheduled}, // 入口注解和方法之间还有其它注解
+		{"disabled", 14, ""},                   // 注释掉的注解；上一个方法的 @Scheduled 不属于它
+		{"documented", 20, ""},                 // Javadoc 中的 @PostConstruct
+		{"init", 24, model.SourceScheduled},
+		{"plain", 27, ""},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(lines[tt.line], tt.method+"(") {
+			t.Fatalf("line %d is not %s: %q", tt.line, tt.method, lines[tt.line])
+		}
+		if got := AnnotatedKind(lines, tt.line); got != tt.want {
+			t.Errorf("AnnotatedKind(%s) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+	if got := AnnotatedKind(lines, len(lines)+3); got != "" {
+		t.Errorf("AnnotatedKind beyond the file = %q", got)
+	}
+}
+
+// Controller 的映射方法: 跨行的映射注解和类级别的前缀不影响判断
+func TestAnnotatedKindController(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "OrderController.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	for line, want := range map[int]string{9: model.SourceHTTP, 15: model.SourceHTTP, 25: model.SourceHTTP, 29: model.SourceHTTP, 33: ""} {
+		if got := AnnotatedKind(lines, line); got != want {
+			t.Errorf("AnnotatedKind(line %d: %q) = %q, want %q", line, strings.TrimSpace(lines[line]), got, want)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package entrypoints
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 从 Controller / Servlet 源码中提取 HTTP 端点 (纯文本解析，不需要 LSP)
+// 类级别的 @RequestMapping 路径与方法级别的路径拼接，HTTP 方法来自注解类型 (@GetMapping) 或 method = RequestMethod.X；
+// Servlet 的路径来自 @WebServlet 或 web.xml 的 servlet-mapping，HTTP 方法来自重写的 doGet / doPost 等方法
+
+// 端点所属的框架
+const (
+	FrameworkSpring  = "spring"
+	FrameworkServlet = "servlet"
+)
+
+// Endpoint 一个处理器方法暴露的 HTTP 端点
+type Endpoint struct {
+	Path      string   // 完整路径 (类级别 + 方法级别，e.g. "/api/users/{id}")
+	Methods   []string // HTTP 方法 (GET / POST ...)，为空表示不限制
+	Framework string   // FrameworkSpring / FrameworkServlet
+	File      string
+	Line      int    // 处理器方法名所在行 (0-based)
+	Class     string // 所在类的简单类名
+	Handler   string // 处理器方法名
+	Params    []Param
+	Signature string // documentSymbol 给出的方法签名 (e.g. "get(String)")，没有 LSP 时为空
+
+	ClassAnnotations  []Annotation
+	MethodAnnotations []Annotation
+}
+
+// Param 处理器方法的一个参数
+type Param struct {
+	Name    string
+	Type    string
+	Binding string // 参数上的注解 (e.g. `@RequestParam("q")`)，没有时为空
+}
+
+// Annotation 源码中的一个注解
+type Annotation struct {
+	Name string // 不带 @ 和包名 (e.g. "GetMapping")
+	Args string // 括号中的原始参数，没有参数时为空
+	Line int    // 注解所在行 (0-based)
+}
+
+// Verb 返回端点的 HTTP 方法描述 ("GET"、"GET|POST"，不限制时为 "ANY")
+func (e Endpoint) Verb() string {
+	if len(e.Methods) == 0 {
+		return "ANY"
+	}
+	return strings.Join(e.Methods, "|")
+}
+
+// HandlerName 返回 "Class.method" (没有处理器方法的 Servlet 只有类名)
+func (e Endpoint) HandlerName() string {
+	if e.Handler == "" {
+		return e.Class
+	}
+	return e.Class + "." + e.Handler
+}
+
+// HasAnnotation 方法或所在类是否带有 names 中的任意一个注解
+func (e Endpoint) HasAnnotation(names ...string) (Annotation, bool) {
+	for _, list := range [][]Annotation{e.MethodAnnotations, e.ClassAnnotations} {
+		for _, a := range list {
+			for _, name := range names {
+				if a.Name == name {
+					return a, true
+				}
+			}
+		}
+	}
+	return Annotation{}, false
+}
+
+// String 参数的展示形式 (e.g. `@PathVariable("id") String id`)
+func (p Param) String() string {
+	s := p.Type + " " + p.Name
+	if p.Binding != "" {
+		s = p.Binding + " " + s
+	}
+	return s
+}
+
+// mappingVerbs 映射注解及其固定的 HTTP 方法 (RequestMapping 由 method 参数决定)
+var mappingVerbs = map[string]string{
+	"RequestMapping": "",
+	"GetMapping":     "GET",
+	"PostMapping":    "POST",
+	"PutMapping":     "PUT",
+	"DeleteMapping":  "DELETE",
+	"PatchMapping":   "PATCH",
+}
+
+// servletVerbs Servlet 重写的方法及其 HTTP 方法 (service 处理所有方法)
+var servletVerbs = map[string]string{
+	"doGet": "GET", "doPost": "POST", "doPut": "PUT", "doDelete": "DELETE",
+	"doHead": "HEAD", "doOptions": "OPTIONS", "doPatch": "PATCH", "service": "",
+}
+
+var (
+	annotationStartRe = regexp.MustCompile(`^@([\w.]+)\s*(\()?`)
+	typeDeclRe        = regexp.MustCompile(`\b(?:class|interface|enum|record)\s+(\w+)`)
+	packageRe         = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+	methodNameRe      = regexp.MustCompile(`(\w+)\s*\(`)
+	stringLiteralRe   = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	requestMethodRe   = regexp.MustCompile(`RequestMethod\.(\w+)`)
+	namedArgRe        = regexp.MustCompile(`^\s*(\w+)\s*=`)
+	paramAnnotationRe = regexp.MustCompile(`^@[\w.]+(?:\s*\([^)]*\))?\s*`)
+)
+
+// Parse 提取一个 Java 源文件中的端点；只处理带 @Controller / @RestController、类级别 @RequestMapping 或 @WebServlet 的类，
+// 以及 servlets (web.xml 中 Servlet 全限定类名 -> URL 模式，可以为 nil) 中列出的类
+// 每个文件按一个顶层类处理 (内部类中的映射方法归属到外层类)
+func Parse(file string, content []byte, servlets map[string][]string) []Endpoint {
+	lines := strings.Split(string(content), "\n")
+	var (
+		endpoints  []Endpoint
+		pending    []Annotation
+		pkg        string
+		class      string
+		classLine  int
+		classAnns  []Annotation
+		controller bool
+		servlet    []string // Servlet 的 URL 模式
+		prefixes   = []string{""}
+		classVerbs []string
+	)
+
+	for i := 0; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if text == "" || strings.HasPrefix(text, "//") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "/*") {
+			continue
+		}
+		if m := packageRe.FindStringSubmatch(text); m != nil {
+			pkg = m[1]
+			continue
+		}
+
+		// 注解可能跨行 (参数中的数组)，读到括号闭合为止；同一行中可能还有其它注解或声明
+		for strings.HasPrefix(text, "@") && !strings.HasPrefix(text, "@interface") {
+			m := annotationStartRe.FindStringSubmatch(text)
+			if m == nil {
+				break
+			}
+			ann := Annotation{Name: m[1][strings.LastIndex(m[1], ".")+1:], Line: i}
+			rest := text[len(m[0]):]
+			if m[2] != "" {
+				args, remaining, consumed := readArgs(rest, lines, i)
+				ann.Args, rest = args, remaining
+				i += consumed
+			}
+			pending = append(pending, ann)
+			text = strings.TrimSpace(rest)
+		}
+		if text == "" {
+			continue
+		}
+
+		if class == "" {
+			if m := typeDeclRe.FindStringSubmatch(text); m != nil {
+				class, classLine, classAnns = m[1], i, pending
+				for _, a := range classAnns {
+					switch a.Name {
+					case "Controller", "RestController":
+						controller = true
+					case "RequestMapping":
+						controller = true
+						if paths := annotationPaths(a.Args, "value", "path"); len(paths) > 0 {
+							prefixes = paths
+						}
+						classVerbs = requestMethods(a.Args)
+					case "WebServlet":
+						servlet = annotationPaths(a.Args, "value", "urlPatterns")
+					}
+				}
+				fqn := class
+				if pkg != "" {
+					fqn = pkg + "." + class
+				}
+				servlet = append(servlet, servlets[fqn]...)
+			}
+			pending = nil
+			continue
+		}
+
+		m := methodNameRe.FindStringSubmatch(text)
+		if m == nil {
+			pending = nil
+			continue
+		}
+		if mapping, ok := findMapping(pending); ok && controller {
+			verbs := classVerbs
+			if v := mappingVerbs[mapping.Name]; v != "" {
+				verbs = []string{v}
+			} else if rm := requestMethods(mapping.Args); len(rm) > 0 {
+				verbs = rm
+			}
+			paths := annotationPaths(mapping.Args, "value", "path")
+			if len(paths) == 0 {
+				paths = []string{""}
+			}
+			params := parseParams(lines, i)
+			for _, prefix := range prefixes {
+				for _, p := range paths {
+					endpoints = append(endpoints, Endpoint{
+						Path:              JoinPaths(prefix, p),
+						Methods:           verbs,
+						Framework:         FrameworkSpring,
+						File:              file,
+						Line:              i,
+						Class:             class,
+						Handler:           m[1],
+						Params:            params,
+						ClassAnnotations:  classAnns,
+						MethodAnnotations: pending,
+					})
+				}
+			}
+		} else if verb, ok := servletVerbs[m[1]]; ok && len(servlet) > 0 && strings.Contains(text, "ServletRequest") {
+			var verbs []string
+			if verb != "" {
+				verbs = []string{verb}
+			}
+			params := parseParams(lines, i)
+			for _, p := range servlet {
+				endpoints = append(endpoints, Endpoint{
+					Path:              JoinPaths("", p),
+					Methods:           verbs,
+					Framework:         FrameworkServlet,
+					File:              file,
+					Line:              i,
+					Class:             class,
+					Handler:           m[1],
+					Params:            params,
+					ClassAnnotations:  classAnns,
+					MethodAnnotations: pending,
+				})
+			}
+		}
+		pending = nil
+	}
+
+	// 声明了映射但没有重写任何 doXxx 方法的 Servlet: 端点记录在类声明上
+	if len(servlet) > 0 && !hasFramework(endpoints, FrameworkServlet) {
+		for _, p := range servlet {
+			endpoints = append(endpoints, Endpoint{
+				Path:             JoinPaths("", p),
+				Framework:        FrameworkServlet,
+				File:             file,
+				Line:             classLine,
+				Class:            class,
+				ClassAnnotations: classAnns,
+			})
+		}
+	}
+	return endpoints
+}
+
+func hasFramework(endpoints []Endpoint, framework string) bool {
+	for _, e := range endpoints {
+		if e.Framework == framework {
+			return true
+		}
+	}
+	return false
+}
+
+// readArgs 读取注解括号中的参数 (rest 是左括号之后的文本)，返回参数、右括号之后的文本和额外读取的行数
+func readArgs(rest string, lines []string, line int) (string, string, int) {
+	var b strings.Builder
+	depth, inString := 1, false
+	consumed := 0
+	for {
+		for j := 0; j < len(rest); j++ {
+			c := rest[j]
+			switch {
+			case inString:
+				if c == '\\' {
+					j++
+				} else if c == '"' {
+					inString = false
+				}
+			case c == '"':
+				inString = true
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+				if depth == 0 {
+					b.WriteString(rest[:j])
+					return strings.TrimSpace(b.String()), rest[j+1:], consumed
+				}
+			}
+		}
+		b.WriteString(rest)
+		b.WriteString(" ")
+		consumed++
+		if line+consumed >= len(lines) {
+			return strings.TrimSpace(b.String()), "", consumed - 1
+		}
+		rest = strings.TrimSpace(lines[line+consumed])
+	}
+}
+
+// parseParams 解析从 line 行开始的方法声明的参数列表 (签名可以跨行)
+func parseParams(lines []string, line int) []Param {
+	open := strings.Index(lines[line], "(")
+	if open == -1 {
+		return nil
+	}
+	args, _, _ := readArgs(lines[line][open+1:], lines, line)
+	var params []Param
+	for _, part := range splitTopLevel(args) {
+		part = strings.TrimSpace(part)
+		var bindings []string
+		for {
+			loc := paramAnnotationRe.FindStringIndex(part)
+			if loc == nil {
+				break
+			}
+			bindings = append(bindings, strings.TrimSpace(part[:loc[1]]))
+			part = part[loc[1]:]
+		}
+		fields := strings.Fields(strings.TrimPrefix(part, "final "))
+		if len(fields) < 2 {
+			continue
+		}
+		params = append(params, Param{
+			Name:    fields[len(fields)-1],
+			Type:    strings.Join(fields[:len(fields)-1], " "),
+			Binding: strings.Join(bindings, " "),
+		})
+	}
+	return params
+}
+
+// findMapping 返回待处理注解中的映射注解
+func findMapping(anns []Annotation) (Annotation, bool) {
+	for _, a := range anns {
+		if _, ok := mappingVerbs[a.Name]; ok {
+			return a, true
+		}
+	}
+	return Annotation{}, false
+}
+
+// annotationPaths 提取注解中的路径: 位置参数或 names 中的命名参数里的字符串 (可以是数组)
+func annotationPaths(args string, names ...string) []string {
+	var paths []string
+	for _, part := range splitTopLevel(args) {
+		if m := namedArgRe.FindStringSubmatch(part); m != nil && !containsName(names, m[1]) {
+			continue
+		}
+		for _, s := range stringLiteralRe.FindAllStringSubmatch(part, -1) {
+			paths = append(paths, s[1])
+		}
+	}
+	return paths
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// requestMethods 提取 method = RequestMethod.X 中的 HTTP 方法
+func requestMethods(args string) []string {
+	var verbs []string
+	for _, part := range splitTopLevel(args) {
+		if m := namedArgRe.FindStringSubmatch(part); m == nil || m[1] != "method" {
+			continue
+		}
+		for _, v := range requestMethodRe.FindAllStringSubmatch(part, -1) {
+			verbs = append(verbs, v[1])
+		}
+	}
+	sort.Strings(verbs)
+	return verbs
+}
+
+// splitTopLevel 按不在括号/尖括号/花括号/字符串中的逗号拆分参数
+func splitTopLevel(args string) []string {
+	var parts []string
+	depth, inString, start := 0, false, 0
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(' || c == '{' || c == '<':
+			depth++
+		case c == ')' || c == '}' || c == '>':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, args[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(args[start:]) != "" {
+		parts = append(parts, args[start:])
+	}
+	return parts
+}
+
+// JoinPaths 拼接类级别和方法级别的路径，结果以 "/" 开头且没有重复的 "/"
+func JoinPaths(prefix, path string) string {
+	joined := strings.Trim(prefix, "/") + "/" + strings.Trim(path, "/")
+	joined = "/" + strings.Trim(joined, "/")
+	return joined
+}
@@ -0,0 +1,152 @@
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// route 测试比较的端点字段
+type route struct {
+	Verb    string
+	Path    string
+	Handler string
+	Line    int
+}
+
+func parseFixture(t *testing.T, name string, servlets map[string][]string) []Endpoint {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints := Parse(path, content, servlets)
+	for _, e := range endpoints {
+		if e.File != path {
+			t.Errorf("%s: endpoint file %s", name, e.File)
+		}
+	}
+	return endpoints
+}
+
+func routes(endpoints []Endpoint) []route {
+	var out []route
+	for _, e := range endpoints {
+		out = append(out, route{e.Verb(), e.Path, e.HandlerName(), e.Line})
+	}
+	return out
+}
+
+// 类级别的多个前缀与方法级别的路径拼接；HTTP 方法来自注解类型或 method 参数，注释掉的映射和普通方法不是端点
+func TestParseSpringController(t *testing.T) {
+	endpoints := parseFixture(t, "OrderController.java", nil)
+	want := []route{
+		{"GET", "/api/orders/{id}", "OrderController.get", 9},
+		{"GET", "/v2/orders/{id}", "OrderController.get", 9},
+		{"GET|POST", "/api/orders/search", "OrderController.search", 15},
+		{"GET|POST", "/v2/orders/search", "OrderController.search", 15},
+		{"POST", "/api/orders/import", "OrderController.upload", 25},
+		{"POST", "/api/orders/upload", "OrderController.upload", 25},
+		{"POST", "/v2/orders/import", "OrderController.upload", 25},
+		{"POST", "/v2/orders/upload", "OrderController.upload", 25},
+		{"ANY", "/api/orders", "OrderController.index", 29},
+		{"ANY", "/v2/orders", "OrderController.index", 29},
+	}
+	if got := routes(endpoints); !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes =\n%v\nwant\n%v", got, want)
+	}
+
+	// 跨行的参数列表和参数上的绑定注解
+	wantParams := []Param{
+		{Name: "q", Type: "String", Binding: `@RequestParam(value = "q", required = false)`},
+		{Name: "tenant", Type: "String", Binding: `@RequestHeader("X-Tenant")`},
+		{Name: "filters", Type: "Map<String, String>"},
+	}
+	if got := endpoints[2].Params; !reflect.DeepEqual(got, wantParams) {
+		t.Errorf("search params = %+v, want %+v", got, wantParams)
+	}
+	if got := endpoints[2].Params[0].String(); got != `@RequestParam(value = "q", required = false) String q` {
+		t.Errorf("param string = %s", got)
+	}
+	if e := endpoints[0]; e.Framework != FrameworkSpring || len(e.MethodAnnotations) != 1 || e.MethodAnnotations[0].Args != `"/{id}"` {
+		t.Errorf("get endpoint = %+v", e)
+	}
+}
+
+// 类级别 @RequestMapping 的 method 作为方法映射的默认 HTTP 方法；方法和类上的注解都可以查询
+func TestParseClassLevelMethod(t *testing.T) {
+	endpoints := parseFixture(t, "AdminController.java", nil)
+	if got, want := routes(endpoints), []route{{"POST", "/admin/reset", "AdminController.reset", 8}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes = %v, want %v", got, want)
+	}
+	if a, ok := endpoints[0].HasAnnotation("PreAuthorize", "Secured"); !ok || a.Args != `"hasRole('ADMIN')"` || a.Line != 6 {
+		t.Errorf("HasAnnotation(PreAuthorize) = %+v, %v", a, ok)
+	}
+	if _, ok := endpoints[0].HasAnnotation("Controller"); !ok {
+		t.Error("class annotation @Controller not found")
+	}
+	if _, ok := endpoints[0].HasAnnotation("RolesAllowed"); ok {
+		t.Error("unexpected @RolesAllowed")
+	}
+}
+
+// 没有 @Controller / @RestController 的类中的映射注解不产生端点
+func TestParseNotController(t *testing.T) {
+	if endpoints := parseFixture(t, "OrderService.java", nil); len(endpoints) != 0 {
+		t.Errorf("endpoints = %+v, want none", endpoints)
+	}
+}
+
+// Servlet: 路径来自 @WebServlet 或 web.xml，HTTP 方法来自重写的 doXxx (service 不限制)；
+// 没有重写任何方法的 Servlet 记录在类声明上
+func TestParseServlets(t *testing.T) {
+	servlets := map[string][]string{
+		"com.acme.legacy.LegacyServlet": {"/legacy/*"},
+		"com.acme.legacy.StatusServlet": {"/status"},
+	}
+	tests := []struct {
+		file string
+		want []route
+	}{
+		{"UploadServlet.java", []route{
+			{"GET", "/files/*", "UploadServlet.doGet", 6},
+			{"GET", "/files/raw", "UploadServlet.doGet", 6},
+			{"POST", "/files/*", "UploadServlet.doPost", 9},
+			{"POST", "/files/raw", "UploadServlet.doPost", 9},
+		}},
+		{"LegacyServlet.java", []route{{"ANY", "/legacy/*", "LegacyServlet.service", 4}}},
+		{"StatusServlet.java", []route{{"ANY", "/status", "StatusServlet", 2}}},
+	}
+	for _, tt := range tests {
+		endpoints := parseFixture(t, tt.file, servlets)
+		if got := routes(endpoints); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: routes = %v, want %v", tt.file, got, tt.want)
+		}
+		for _, e := range endpoints {
+			if e.Framework != FrameworkServlet {
+				t.Errorf("%s: framework %s", tt.file, e.Framework)
+			}
+		}
+	}
+	// 没有映射的 Servlet 类不是端点
+	if endpoints := parseFixture(t, "LegacyServlet.java", nil); len(endpoints) != 0 {
+		t.Errorf("unmapped servlet endpoints = %+v", endpoints)
+	}
+}
+
+func TestJoinPaths(t *testing.T) {
+	tests := []struct{ prefix, path, want string }{
+		{"", "", "/"},
+		{"/api", "", "/api"},
+		{"api/", "/users/", "/api/users"},
+		{"/v2/orders/", "{id}", "/v2/orders/{id}"},
+		{"", "files/*", "/files/*"},
+	}
+	for _, tt := range tests {
+		if got := JoinPaths(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("JoinPaths(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package com.acme.web;
+
+@Controller
+@RequestMapping(path = "/admin", method = RequestMethod.POST)
+public class AdminController {
+
+    @PreAuthorize("hasRole('ADMIN')")
+    @RequestMapping("/reset")
+    public String reset() {
+        return "ok";
+    }
+}
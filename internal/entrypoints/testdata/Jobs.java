@@ -0,0 +1,30 @@
+package com.acme.jobs;
+
+public class Jobs {
+
+    @KafkaListener(topics = "orders")
+    public void onOrder(String payload) {
+    }
+
+    @Scheduled(fixedRate = 60000)
+    @Transactional
+    public void cleanup() {
+    }
+
+    // @Scheduled(cron = "0 0 * * * *")
+    public void disabled() {
+    }
+
+    /**
+     * @PostConstruct 只在注释中出现
+     */
+    public void documented() {
+    }
+
+    @PostConstruct
+    public void init() {
+    }
+
+    public void plain() {
+    }
+}
@@ -0,0 +1,7 @@
+package com.acme.legacy;
+
+public class LegacyServlet extends HttpServlet {
+
+    public void service(ServletRequest req, ServletResponse resp) {
+    }
+}
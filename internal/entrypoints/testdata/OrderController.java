@@ -0,0 +1,36 @@
+package com.acme.web;
+
+import org.springframework.web.bind.annotation.*;
+
+@RestController
+@RequestMapping({"/api/orders", "/v2/orders/"})
+public class OrderController {
+
+    @GetMapping("/{id}")
+    public Order get(@PathVariable("id") String id) {
+        return null;
+    }
+
+    // @DeleteMapping("/commented")
+    @RequestMapping(value = "/search", method = {RequestMethod.POST, RequestMethod.GET})
+    public List<Order> search(@RequestParam(value = "q", required = false) String q,
+                              @RequestHeader("X-Tenant") final String tenant,
+                              Map<String, String> filters) {
+        return null;
+    }
+
+    @PostMapping(
+        path = {"/import", "/upload"},
+        consumes = "text/csv"
+    )
+    public void upload(@RequestBody byte[] body) {
+    }
+
+    @RequestMapping
+    public String index() {
+        return "index";
+    }
+
+    private void helper(String s) {
+    }
+}
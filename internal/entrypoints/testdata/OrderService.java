@@ -0,0 +1,9 @@
+package com.acme.web;
+
+public class OrderService {
+
+    @GetMapping("/not-a-controller")
+    public String find(String id) {
+        return id;
+    }
+}
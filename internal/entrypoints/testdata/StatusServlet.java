@@ -0,0 +1,4 @@
+package com.acme.legacy;
+
+public class StatusServlet extends GenericServlet {
+}
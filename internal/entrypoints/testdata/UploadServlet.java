@@ -0,0 +1,16 @@
+package com.acme.web;
+
+@WebServlet(urlPatterns = {"/files/*", "files/raw"})
+public class UploadServlet extends HttpServlet {
+
+    @Override
+    protected void doGet(HttpServletRequest req, HttpServletResponse resp) {
+    }
+
+    protected void doPost(HttpServletRequest req,
+                          HttpServletResponse resp) {
+    }
+
+    private void doAudit(String user) {
+    }
+}
@@ -0,0 +1,41 @@
+package entrypoints
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// webApp web.xml 中与 Servlet 映射相关的部分
+type webApp struct {
+	Servlets []struct {
+		Name  string `xml:"servlet-name"`
+		Class string `xml:"servlet-class"`
+	} `xml:"servlet"`
+	Mappings []struct {
+		Name     string   `xml:"servlet-name"`
+		Patterns []string `xml:"url-pattern"`
+	} `xml:"servlet-mapping"`
+}
+
+// ParseWebXML 解析 web.xml 的 servlet / servlet-mapping，返回 Servlet 全限定类名 -> URL 模式
+func ParseWebXML(content []byte) (map[string][]string, error) {
+	var app webApp
+	if err := xml.Unmarshal(content, &app); err != nil {
+		return nil, err
+	}
+	classes := make(map[string]string)
+	for _, s := range app.Servlets {
+		classes[strings.TrimSpace(s.Name)] = strings.TrimSpace(s.Class)
+	}
+	servlets := make(map[string][]string)
+	for _, m := range app.Mappings {
+		class := classes[strings.TrimSpace(m.Name)]
+		if class == "" {
+			continue
+		}
+		for _, p := range m.Patterns {
+			servlets[class] = append(servlets[class], strings.TrimSpace(p))
+		}
+	}
+	return servlets, nil
+}
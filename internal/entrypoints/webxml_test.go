@@ -0,0 +1,31 @@
+package entrypoints
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// servlet-mapping 按 servlet-name 关联到类 (去掉空白)，没有对应 servlet 的映射忽略
+func TestParseWebXML(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "web.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseWebXML(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"com.acme.legacy.LegacyServlet": {"/legacy/*", "/legacy-api/*"},
+		"com.acme.legacy.StatusServlet": {"/status"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseWebXML = %v, want %v", got, want)
+	}
+
+	if _, err := ParseWebXML([]byte("<web-app><servlet>")); err == nil {
+		t.Error("malformed web.xml parsed without error")
+	}
+}
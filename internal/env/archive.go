@@ -0,0 +1,253 @@
+package env
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveExts 可以作为 -project 的源码压缩包
+var archiveExts = []string{".tar.gz", ".tgz", ".zip", ".jar"}
+
+// projectMarkers 项目根目录的标志文件/目录
+var projectMarkers = []string{"pom.xml", "build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts", "src"}
+
+// archiveExt 返回路径的压缩包扩展名，不是支持的压缩包时返回空
+func archiveExt(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// IsArchive 路径是否是支持的源码压缩包 (.zip / .jar / .tar.gz / .tgz)
+func IsArchive(path string) bool {
+	return archiveExt(path) != ""
+}
+
+// ExtractProject 把源码压缩包解压到新的临时目录，返回临时目录 (由调用方删除) 和检测到的项目根目录
+// 文件权限统一设置为可读写 (源码包中常见的 000/只读权限不影响扫描和生成 .classpath)
+func ExtractProject(archive string) (tmpDir, root string, err error) {
+	ext := archiveExt(archive)
+	if ext == "" {
+		return "", "", fmt.Errorf("unsupported archive: %s", archive)
+	}
+	tmpDir, err = os.MkdirTemp("", "lsptracer-src-")
+	if err != nil {
+		return "", "", err
+	}
+	// 解压到以压缩包命名的子目录，报告中的项目名称保持有意义
+	dest := filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive)))
+	if ext == ".tar.gz" {
+		dest = strings.TrimSuffix(dest, ".tar")
+	}
+
+	switch ext {
+	case ".zip", ".jar":
+		err = extractZip(archive, dest)
+	default:
+		err = extractTarGz(archive, dest)
+	}
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return "", "", err
+	}
+
+	root = detectProjectRoot(dest)
+	if !hasProjectMarker(root) && !hasJavaFiles(root) {
+		if nested := findArchives(root); len(nested) > 0 {
+			os.RemoveAll(tmpDir)
+			return "", "", fmt.Errorf("nested archives are not supported (found %s); extract it and pass the directory or the inner archive as -project", strings.Join(nested, ", "))
+		}
+	}
+	return tmpDir, root, nil
+}
+
+// safeJoin 把压缩包中的条目名拼接到 dest 下，拒绝绝对路径和跳出 dest 的条目 (zip slip)
+func safeJoin(dest, name string) (string, error) {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	target := filepath.Join(dest, name)
+	if target != filepath.Clean(dest) && !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	return target, nil
+}
+
+func extractZip(archive, dest string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		target, err := safeJoin(dest, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue // 符号链接等特殊条目不解压
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarGz(archive, dest string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// detectProjectRoot 在解压目录中查找项目根目录: 最浅的包含 pom.xml / build.gradle / src 等标志的目录；
+// 都没有时跳过只有一个子目录的包装层 (e.g. app-src/app/)
+func detectProjectRoot(dir string) string {
+	level := []string{dir}
+	for depth := 0; depth < 4 && len(level) > 0; depth++ {
+		var next []string
+		for _, d := range level {
+			if hasProjectMarker(d) {
+				return d
+			}
+			entries, err := os.ReadDir(d)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() != "__MACOSX" {
+					next = append(next, filepath.Join(d, e.Name()))
+				}
+			}
+		}
+		level = next
+	}
+
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return dir
+		}
+		var only string
+		for _, e := range entries {
+			if e.Name() == "__MACOSX" || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if !e.IsDir() || only != "" {
+				return dir
+			}
+			only = e.Name()
+		}
+		if only == "" {
+			return dir
+		}
+		dir = filepath.Join(dir, only)
+	}
+}
+
+func hasProjectMarker(dir string) bool {
+	for _, name := range projectMarkers {
+		if exists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasJavaFiles(dir string) bool {
+	found := false
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(d.Name(), ".java") {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// findArchives 返回目录 (及子目录) 中的压缩包文件名 (相对 dir)，用于提示不支持嵌套压缩包
+func findArchives(dir string) []string {
+	var found []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !IsArchive(d.Name()) {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			found = append(found, rel)
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,275 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JDT.LS 的下载渠道: 每个渠道目录下的 latest.txt 记录最新的 tar.gz 文件名
+const (
+	ChannelSnapshot  = "snapshot"
+	ChannelMilestone = "milestone"
+)
+
+var channelBaseUrls = map[string]string{
+	ChannelSnapshot:  "https://download.eclipse.org/jdtls/snapshots",
+	ChannelMilestone: "https://download.eclipse.org/jdtls/milestones",
+}
+
+// LockFileName 依赖目录中记录已安装版本的文件，env check 离线时读取
+const LockFileName = "deps.lock"
+
+// jdtlsTarPrefix JDT.LS 发布包的文件名: jdt-language-server-<版本>-<时间戳>.tar.gz
+const jdtlsTarPrefix = "jdt-language-server-"
+
+// markerTimeout 读取 latest.txt 的超时
+const markerTimeout = 15 * time.Second
+
+// ValidChannel 检查 -channel 的取值
+func ValidChannel(channel string) error {
+	if _, ok := channelBaseUrls[channel]; !ok {
+		return fmt.Errorf("invalid channel %q (valid: %s, %s)", channel, ChannelSnapshot, ChannelMilestone)
+	}
+	return nil
+}
+
+// JdtlsRelease 渠道中可下载的一个 JDT.LS 版本
+type JdtlsRelease struct {
+	Channel string
+	Version string // e.g. 1.40.0-202409261450，版本标记不可用时为空
+	URL     string
+}
+
+// LatestJdtls 读取渠道的 latest.txt，得到最新版本的下载地址
+// 快照在 snapshots/<文件名>，里程碑在 milestones/<版本号>/<文件名>
+func LatestJdtls(channel string) (JdtlsRelease, error) {
+	if err := ValidChannel(channel); err != nil {
+		return JdtlsRelease{}, err
+	}
+	base := channelBaseUrls[channel]
+	client := &http.Client{Timeout: markerTimeout}
+	resp, err := client.Get(base + "/latest.txt")
+	if err != nil {
+		return JdtlsRelease{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return JdtlsRelease{}, fmt.Errorf("%s/latest.txt: http status: %s", base, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return JdtlsRelease{}, err
+	}
+	file := strings.TrimSpace(string(data))
+	version := versionFromTarball(file)
+	if version == "" || strings.ContainsAny(file, "/\\") {
+		return JdtlsRelease{}, fmt.Errorf("%s/latest.txt: unexpected content %q", base, file)
+	}
+
+	rel := JdtlsRelease{Channel: channel, Version: version, URL: base + "/" + file}
+	if channel == ChannelMilestone {
+		number, _, _ := strings.Cut(version, "-")
+		rel.URL = base + "/" + number + "/" + file
+	}
+	return rel, nil
+}
+
+// defaultJdtls 首次安装使用的版本: 读不到快照的版本标记时退回固定的 latest 地址，版本在解压后从 jar 名得到
+func defaultJdtls() JdtlsRelease {
+	if rel, err := LatestJdtls(ChannelSnapshot); err == nil {
+		return rel
+	}
+	return JdtlsRelease{Channel: ChannelSnapshot, URL: JdtlsUrl}
+}
+
+// versionFromTarball jdt-language-server-1.40.0-202409261450.tar.gz -> 1.40.0-202409261450
+func versionFromTarball(file string) string {
+	if !strings.HasPrefix(file, jdtlsTarPrefix) || !strings.HasSuffix(file, ".tar.gz") {
+		return ""
+	}
+	version := strings.TrimSuffix(strings.TrimPrefix(file, jdtlsTarPrefix), ".tar.gz")
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return ""
+	}
+	return version
+}
+
+// JdtlsPluginVersion 从 plugins 中 org.eclipse.jdt.ls.core_<版本>.jar 的文件名得到 JDT.LS 的版本，找不到时为空
+func JdtlsPluginVersion(jdtlsHome string) string {
+	if jdtlsHome == "" {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(jdtlsHome, "plugins", "org.eclipse.jdt.ls.core_*.jar"))
+	if len(matches) == 0 {
+		return ""
+	}
+	name := filepath.Base(matches[len(matches)-1])
+	return strings.TrimSuffix(strings.TrimPrefix(name, "org.eclipse.jdt.ls.core_"), ".jar")
+}
+
+// CompareVersions 按数字逐段比较 JDT.LS 版本，"." 和 "-" 都是分隔符
+// (版本标记 1.40.0-202409261450 与 jar 名中的 1.40.0.202409261450 相等)
+func CompareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '-' })
+	}
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errX := strconv.ParseInt(x, 10, 64)
+		ny, errY := strconv.ParseInt(y, 10, 64)
+		switch {
+		case errX == nil && errY == nil && nx != ny:
+			if nx < ny {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+// DepsLock 依赖目录中的 deps.lock: 已安装的依赖版本
+type DepsLock struct {
+	Jdtls *LockEntry `json:"jdtls,omitempty"`
+}
+
+// LockEntry 一个依赖的安装记录
+type LockEntry struct {
+	Version     string    `json:"version"`
+	Channel     string    `json:"channel,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// DepsDir -deps-dir 指定的依赖目录，为空时使用当前工作目录下的 .lsptracer_deps
+var DepsDir string
+
+// DepsRoot 依赖目录的绝对路径 (DepsDir，默认为当前工作目录下的 .lsptracer_deps)
+func DepsRoot() (string, error) {
+	if DepsDir != "" {
+		return filepath.Abs(DepsDir)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	return filepath.Join(cwd, DepsDirName), nil
+}
+
+// ReadLock 读取 deps.lock，文件不存在时返回空记录
+func ReadLock(depsRoot string) (DepsLock, error) {
+	var lock DepsLock
+	data, err := os.ReadFile(filepath.Join(depsRoot, LockFileName))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("%s: %v", LockFileName, err)
+	}
+	return lock, nil
+}
+
+// writeLock 先写临时文件再改名，中途失败不会留下损坏的 deps.lock
+func writeLock(depsRoot string, lock DepsLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(depsRoot, LockFileName)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// InstalledJdtls 已安装的 JDT.LS 版本: 优先取 deps.lock 的记录，没有记录时从 jar 名得到 (recorded 为 false)
+func InstalledJdtls(depsRoot string) (entry LockEntry, recorded bool, err error) {
+	lock, err := ReadLock(depsRoot)
+	if err == nil && lock.Jdtls != nil {
+		return *lock.Jdtls, true, nil
+	}
+	return LockEntry{Version: JdtlsPluginVersion(filepath.Join(depsRoot, "jdtls"))}, false, err
+}
+
+// recoverJdtls 上一次升级在替换目录的中途被打断 (只剩 jdtls.old) 时恢复原来的安装
+func recoverJdtls(depsRoot string) error {
+	home := filepath.Join(depsRoot, "jdtls")
+	backup := home + ".old"
+	if !exists(home) && exists(backup) {
+		return os.Rename(backup, home)
+	}
+	return nil
+}
+
+// InstallJdtls 安装或替换依赖目录中的 JDT.LS，并把版本记录到 deps.lock。
+// 新版本先解压到 jdtls.new，确认完整后再与 jdtls 交换；任何一步失败都保留 (或恢复) 原来的安装
+func InstallJdtls(depsRoot string, rel JdtlsRelease) (LockEntry, error) {
+	if err := recoverJdtls(depsRoot); err != nil {
+		return LockEntry{}, fmt.Errorf("failed to restore previous JDT.LS: %v", err)
+	}
+	home := filepath.Join(depsRoot, "jdtls")
+	staging, backup := home+".new", home+".old"
+
+	os.RemoveAll(staging)
+	if err := downloadAndExtractJdtls(rel.URL, staging); err != nil {
+		os.RemoveAll(staging)
+		return LockEntry{}, err
+	}
+	if launchers, _ := filepath.Glob(filepath.Join(staging, "plugins", "org.eclipse.equinox.launcher_*.jar")); len(launchers) == 0 {
+		os.RemoveAll(staging)
+		return LockEntry{}, fmt.Errorf("downloaded archive is incomplete: launcher jar not found")
+	}
+
+	os.RemoveAll(backup)
+	hadPrevious := exists(home)
+	if hadPrevious {
+		if err := os.Rename(home, backup); err != nil {
+			os.RemoveAll(staging)
+			return LockEntry{}, err
+		}
+	}
+	rollback := func() {
+		os.RemoveAll(home)
+		if hadPrevious {
+			os.Rename(backup, home)
+		}
+	}
+	if err := os.Rename(staging, home); err != nil {
+		os.RemoveAll(staging)
+		rollback()
+		return LockEntry{}, err
+	}
+
+	entry := LockEntry{Version: rel.Version, Channel: rel.Channel, URL: rel.URL, InstalledAt: time.Now().UTC()}
+	if entry.Version == "" {
+		entry.Version = JdtlsPluginVersion(home)
+	}
+	// 读不出旧的 deps.lock 时只重写 JDT.LS 的记录
+	lock, _ := ReadLock(depsRoot)
+	lock.Jdtls = &entry
+	if err := writeLock(depsRoot, lock); err != nil {
+		rollback()
+		return LockEntry{}, fmt.Errorf("failed to write %s: %v", LockFileName, err)
+	}
+	os.RemoveAll(backup)
+	return entry, nil
+}
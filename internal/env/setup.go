@@ -23,19 +23,18 @@ const (
 	
 	// 存放依赖的目录名
 	DepsDirName = ".lsptracer_deps"
+	// JDT.LS 的数据目录 (工作区索引，位于当前工作目录)
+	DataCacheDirName = ".jdtls_data_cache"
 )
 
 // EnsureEnv 检查并准备环境，返回 (jdtlsHome, lombokPath, error)
 func EnsureEnv() (string, string, error) {
-	// ✨✨✨ 修改：改为使用当前工作目录 (Project Root) ✨✨✨
-	cwd, err := os.Getwd()
+	// 依赖将下载到 ./.lsptracer_deps (或 -deps-dir 指定的目录)
+	depsRoot, err := DepsRoot()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get current working directory: %v", err)
+		return "", "", err
 	}
-	
-	// 依赖将下载到 ./ .lsptracer_deps
-	depsRoot := filepath.Join(cwd, DepsDirName)
-	
+
 	jdtlsPath := filepath.Join(depsRoot, "jdtls")
 	lombokPath := filepath.Join(depsRoot, "lombok.jar")
 
@@ -44,13 +43,17 @@ func EnsureEnv() (string, string, error) {
 		os.MkdirAll(depsRoot, 0755)
 	}
 
-	// 2. 检查并下载 JDT.LS
+	// 2. 检查并下载 JDT.LS (上一次 env upgrade 被打断时先恢复原来的安装)
+	if err := recoverJdtls(depsRoot); err != nil {
+		return "", "", fmt.Errorf("failed to restore previous JDT.LS: %v", err)
+	}
 	if !exists(jdtlsPath) {
 		color.Cyan("[*] Environment: JDT.LS not found.")
-		if err := downloadAndExtractJdtls(JdtlsUrl, jdtlsPath); err != nil {
+		entry, err := InstallJdtls(depsRoot, defaultJdtls())
+		if err != nil {
 			return "", "", fmt.Errorf("failed to setup JDT.LS: %v", err)
 		}
-		color.Green("[+] Environment: JDT.LS installed to: %s", jdtlsPath)
+		color.Green("[+] Environment: JDT.LS %s installed to: %s", entry.Version, jdtlsPath)
 	}
 
 	// 3. 检查并下载 Lombok
@@ -126,7 +129,8 @@ func downloadAndExtractJdtls(url string, destDir string) error {
 		if err == io.EOF { break }
 		if err != nil { return err }
 
-		target := filepath.Join(destDir, header.Name)
+		target, err := safeJoin(destDir, header.Name)
+		if err != nil { return err }
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -0,0 +1,95 @@
+// Package events 扫描过程中的结构化事件
+// 控制台输出和 -progress json (NDJSON) 消费同一个事件流，包装工具不需要解析带颜色的控制台输出
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	TypePhase      = "phase"       // 一个阶段结束 (Phase, DurationMs)
+	TypeCandidates = "candidates"  // 文本初筛完成 (Count)
+	TypeSinkStart  = "sink_start"  // Sink 通过验证 (File, Line, Rule, Code)
+	TypeSinkFinish = "sink_finish" // 一个 Sink 的追踪结束 (Sink, File, Line, Outcome, Chains, DurationMs)
+	TypeSummary    = "summary"     // 扫描结束 (Summary)
+)
+
+// Sink 追踪的结果 (sink_finish 的 Outcome)
+const (
+	OutcomeTraced    = "traced"    // 记录了至少一条链路
+	OutcomeTruncated = "truncated" // 时间预算耗尽，记录了部分链路
+	OutcomeNoChain   = "no_chain"  // 没有记录任何链路 (e.g. 调用者都被过滤)
+	OutcomeOrphan    = "orphan"    // 找不到所在函数，没有追踪 (按 -orphan-sinks 处理)
+)
+
+// Event 一个扫描事件，按 Type 只填写对应的字段；行号从 1 开始
+type Event struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Phase      string    `json:"phase,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Count      int       `json:"count,omitempty"`
+	Sink       string    `json:"sink,omitempty"` // Sink 的展示名 (文件:行 代码)
+	File       string    `json:"file,omitempty"`
+	Line       int       `json:"line,omitempty"`
+	Rule       string    `json:"rule,omitempty"`
+	Code       string    `json:"code,omitempty"`
+	Outcome    string    `json:"outcome,omitempty"`
+	Chains     int       `json:"chains,omitempty"`
+	Summary    *Summary  `json:"summary,omitempty"`
+}
+
+// Summary 扫描结束时的汇总
+type Summary struct {
+	Findings   int            `json:"findings"` // 写入报告的发现 (不含被抑制和被严格模式排除的)
+	ByType     map[string]int `json:"by_type"`
+	BySeverity map[string]int `json:"by_severity"`
+	Candidates int            `json:"candidates"`
+	Verified   int            `json:"verified"`
+	Traced     int            `json:"traced"`
+	Error      string         `json:"error,omitempty"` // 扫描提前终止的原因
+}
+
+// Listener 事件的消费者，在 Emit 的调用方 goroutine 中同步执行 (同一时间只有一个 Listener 在运行)
+type Listener func(Event)
+
+// Bus 把事件分发给所有 Listener；nil 的 Bus 丢弃所有事件
+type Bus struct {
+	mu        sync.Mutex
+	listeners []Listener
+}
+
+// Subscribe 注册一个 Listener
+func (b *Bus) Subscribe(l Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, l)
+}
+
+// Emit 发送事件，Time 为空时填写当前时间
+func (b *Bus) Emit(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, l := range b.listeners {
+		l(e)
+	}
+}
+
+// JSONLines 返回把事件逐行写为 JSON (NDJSON) 的 Listener
+func JSONLines(w io.Writer) Listener {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return func(e Event) {
+		enc.Encode(e)
+	}
+}
@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 报告的界面语言。界面字符串以英文原文作为键，中文翻译在 messages.go 的表中；
+// 缺少翻译时使用英文原文，不会输出空的标签
+
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+// Detect 按 LC_ALL、LC_MESSAGES、LANG 的优先级推断界面语言 (zh_CN.UTF-8 -> zh)，其它语言和未设置时为英文
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if lang, err := Normalize(value); err == nil {
+				return lang
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// Normalize 检查 -lang 的取值，接受 locale 形式 (zh_CN.UTF-8、en-US)
+func Normalize(value string) (string, error) {
+	code := strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(code, "_-.@"); i != -1 {
+		code = code[:i]
+	}
+	switch code {
+	case English, Chinese:
+		return code, nil
+	}
+	return "", fmt.Errorf("unsupported language %q (supported: %s, %s)", value, English, Chinese)
+}
+
+// T 界面字符串 key (英文原文) 的 lang 版本
+func T(lang, key string) string {
+	if lang == Chinese {
+		if s := zhMessages[key]; s != "" {
+			return s
+		}
+	}
+	return key
+}
+
+// Tf 带参数的界面字符串 (key 是 fmt 格式)
+func Tf(lang, key string, args ...interface{}) string {
+	return fmt.Sprintf(T(lang, key), args...)
+}
+
+// VulnTypeName 漏洞类型 (RCE、SQLI 等) 的展示名称，没有收录的类型原样返回
+func VulnTypeName(lang, vulnType string) string {
+	names, ok := vulnTypeNames[vulnType]
+	if !ok {
+		return vulnType
+	}
+	if lang == Chinese && names[1] != "" {
+		return names[1]
+	}
+	return names[0]
+}
+
+// Desc 内置规则描述的 lang 版本: desc 是表中任意一种语言的描述时返回对应的翻译，否则原样返回
+func Desc(lang, desc string) string {
+	for _, pair := range builtinDescs {
+		if desc != pair[0] && desc != pair[1] {
+			continue
+		}
+		if lang == Chinese {
+			return pair[1]
+		}
+		return pair[0]
+	}
+	return desc
+}
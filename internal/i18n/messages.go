@@ -0,0 +1,155 @@
+package i18n
+
+// vulnTypeNames 漏洞类型的展示名称: {英文, 中文}
+var vulnTypeNames = map[string][2]string{
+	"RCE":                  {"Code Execution", "任意代码执行"},
+	"EXPRESSION_INJECTION": {"Expression / Template Injection", "表达式/模板注入"},
+	"JNDI_INJECTION":       {"JNDI Injection", "JNDI 注入"},
+	"LDAP_INJECTION":       {"LDAP Injection", "LDAP 注入"},
+	"XPATH_INJECTION":      {"XPath Injection", "XPath 注入"},
+	"UNSERIALIZE":          {"Unsafe Deserialization", "反序列化"},
+	"SSRF":                 {"Server-Side Request Forgery", "服务端请求伪造"},
+	"SQLI":                 {"SQL Injection", "SQL 注入"},
+	"XSS":                  {"Cross-Site Scripting", "跨站脚本"},
+	"XSS_TEMPLATE":         {"Template XSS", "模板 XSS"},
+	"PATH_TRAVERSAL":       {"Path Traversal", "路径遍历"},
+	"XXE":                  {"XML External Entity", "XML 外部实体注入"},
+	"REDIRECT":             {"Open Redirect", "URL 重定向"},
+	"CONFIG":               {"Insecure Configuration", "危险配置"},
+	"SECRET":               {"Hardcoded Secret", "硬编码凭据"},
+	"AUTHZ":                {"Missing Authorization", "缺少授权检查"},
+	"MANUAL":               {"Manual Target", "手动目标"},
+	"Uncategorized":        {"Uncategorized", "未分类"},
+}
+
+// builtinDescs 内置规则的描述: {英文, 中文}
+var builtinDescs = [][2]string{
+	{"Arbitrary code execution", "任意代码执行漏洞"},
+	{"Arbitrary class loading (a controlled class name runs the static initializer of the target class)", "任意类加载 (类名可控时会执行目标类的静态初始化代码)"},
+	{"Remote class loading (a controlled URL can load arbitrary code)", "远程类加载 (URL 可控时可以加载任意代码)"},
+	{"Arbitrary bytecode loading", "任意字节码加载"},
+	{"Remote class loading (MLet)", "远程类加载 (MLet)"},
+	{"Reflective invocation (noisy, only exploitable when the method name is controlled)", "反射调用 (噪声较大，仅在方法名可控时可利用)"},
+	{"Expression injection", "表达式注入漏洞"},
+	{"Template injection", "模板注入漏洞"},
+	{"JNDI injection", "JNDI注入漏洞"},
+	{"LDAP injection", "LDAP注入漏洞"},
+	{"XPath injection", "XPath注入漏洞"},
+	{"Unsafe deserialization", "反序列化漏洞"},
+	{"Server-side request forgery", "服务端请求伪造漏洞"},
+	{"SQL injection", "SQL注入漏洞"},
+	{"Cross-site scripting", "跨站脚本漏洞"},
+	{"Path traversal", "路径遍历漏洞"},
+	{"XML external entity injection", "XML外部实体注入"},
+	{"Open redirect", "URL重定向"},
+	{"Path traversal while extracting an archive (ZipSlip)", "压缩包解压路径穿越 (ZipSlip)"},
+	{"Reflectively invoked method name is controlled", "反射调用的方法名可控"},
+	{"Insecure configuration", "危险配置"},
+	{"Session cookie is not HttpOnly", "会话 Cookie 没有设置 HttpOnly"},
+	{"Deserialization component configured in Spring XML", "Spring XML 中配置了反序列化组件"},
+	{"H2 database console is enabled", "开启了 H2 数据库控制台"},
+	{"Sensitive Actuator endpoints are exposed over HTTP", "通过 HTTP 暴露了敏感的 Actuator 端点"},
+	{"Actuator endpoint authentication is disabled (Spring Boot 1.x)", "关闭了 Actuator 端点的认证 (Spring Boot 1.x)"},
+	{"Debug mode is enabled", "开启了调试模式"},
+	{"Error responses include stack traces", "错误响应中包含堆栈信息"},
+	{"Hardcoded credential / key", "硬编码凭据/密钥"},
+	{"Endpoint without authorization checks", "端点没有授权检查"},
+	{"Unescaped output in a template", "模板中的非转义输出"},
+	{"Location selected for manual verification", "手动选择的待验证位置"},
+}
+
+// zhMessages HTML 报告界面字符串的中文翻译 (键是英文原文，带参数的键是 fmt 格式)
+var zhMessages = map[string]string{
+	// 页面和侧边栏
+	"LSPTracer Scan Report": "LSPTracer 扫描报告",
+	"Scan Report":           "扫描报告",
+	"TOTAL CHAINS":          "链路总数",
+	"Rules":                 "规则",
+	"All modules":           "全部模块",
+	"Unverified":            "未验证",
+	"Suppressed":            "已抑制",
+	"Strict-excluded":       "被严格模式排除",
+	"New":                   "新增",
+	"Fixed":                 "已修复",
+
+	// 概览
+	"Back to the scan overview":                         "返回扫描概览",
+	"Scan Overview":                                     "扫描概览",
+	"Total confirmed vulnerability chains":              "已确认的漏洞链路",
+	"+%d suppressed":                                    "另有 %d 条已抑制",
+	"+%d excluded by strict mode":                       "另有 %d 条被严格模式排除",
+	"use -show-unverified to list them":                 "使用 -show-unverified 列出",
+	"+%d in %s code":                                    "另有 %d 条位于 %s 代码",
+	"not reported, use -include-test-code to list them": "未写入报告，使用 -include-test-code 列出",
+	"By type":         "按类型",
+	"By severity":     "按等级",
+	"Top files":       "文件排行",
+	"Module":          "模块",
+	"Findings":        "发现",
+	"Owners":          "负责团队",
+	"Chains ended by": "链路结束原因",
+	"all chains, including suppressed and strict-excluded": "所有链路，包括已抑制和被严格模式排除的",
+	"Sinks": "Sink",
+	"%d candidates → %d verified → %d traced to a source": "%d 个候选点 → %d 个已确认 → %d 个追踪到 Source",
+	"Zero-hit rules":                  "没有命中的规则",
+	"Fan-out limited":                 "调用者过多",
+	"%d callers":                      "%d 个调用者",
+	"Verification":                    "验证",
+	"%s per candidate":                "每个候选点 %s",
+	"%d files warmed up":              "预热了 %d 个文件",
+	"no warm-up":                      "没有预热",
+	"Verified by":                     "确认方式",
+	"Phases":                          "阶段",
+	"Compared with the previous scan": "与上次扫描相比",
+	"%d new":                          "新增 %d",
+	"%d fixed":                        "已修复 %d",
+	"%d unchanged":                    "未变化 %d",
+	"Project":                         "项目",
+	"Revision":                        "代码版本",
+	"%d rules":                        "%d 条规则",
+	"Options":                         "选项",
+	"Scope":                           "范围",
+	"Started":                         "开始时间",
+	"took %s":                         "耗时 %s",
+	"Command":                         "命令",
+	"on %s":                           "运行于 %s",
+	"⚠ Scan aborted: %s. This report is partial.":                                                                                 "⚠ 扫描提前终止: %s。本报告不完整。",
+	"⚠ The language server crashed and was restarted %d time(s) — results may be incomplete.":                                     "⚠ 语言服务器崩溃并重启了 %d 次，结果可能不完整。",
+	"⚠ %d compile errors across %d of %d files — results may be incomplete (common cause: missing source roots or dependencies).": "⚠ %d 个编译错误，分布在 %d/%d 个文件中，结果可能不完整 (常见原因: 缺少源码目录或依赖)。",
+	"Select a vulnerability from the sidebar to view detailed trace information.":                                                 "从侧边栏选择一个漏洞查看详细的追踪信息。",
+
+	// 端点、调用树和分节
+	"Endpoints (%d)":                     "端点 (%d)",
+	"Method":                             "方法",
+	"Path":                               "路径",
+	"Handler":                            "处理器",
+	"Parameters":                         "参数",
+	"Location":                           "位置",
+	"Calls from %s":                      "%s 的调用",
+	"%d reachable sinks":                 "可到达 %d 个 Sink",
+	"Suppressed (%d)":                    "已抑制 (%d)",
+	"Excluded by strict mode (%d)":       "被严格模式排除 (%d)",
+	"Fixed since the previous scan (%d)": "自上次扫描以来已修复 (%d)",
+
+	// 漏洞卡片
+	"Severity of the matched rule":                 "命中规则的等级",
+	"rule: %s":                                     "规则: %s",
+	"Also matched by this rule":                    "同时命中的规则",
+	"Severity override — %s":                       "等级覆盖 — %s",
+	"Unverified — %s":                              "未验证 — %s",
+	"Suppression expired on %s — %s":               "抑制已于 %s 过期 — %s",
+	"Downgraded":                                   "已降级",
+	"until %s":                                     "至 %s",
+	"Strict mode — %s":                             "严格模式 — %s",
+	"Confidence: %s":                               "可信度: %s",
+	"🧪 In %s code":                                 "🧪 位于 %s 代码",
+	"All callers found are in test or sample code": "找到的调用者都在测试或示例代码中",
+	"🧪 Reached only from tests":                    "🧪 只能从测试代码到达",
+	"Depth: %d steps":                              "深度: %d 步",
+	"Description":                                  "描述",
+	"Remediation":                                  "修复建议",
+	"(score %d/%d; High ≥ 6, Medium ≥ 3)":          "(得分 %d/%d；High ≥ 6，Medium ≥ 3)",
+	"✔ Verified by %s":                             "✔ 确认方式: %s",
+	"View Full Context":                            "查看完整上下文",
+	"Hide Context":                                 "隐藏上下文",
+}
@@ -0,0 +1,54 @@
+package lang
+
+import "regexp"
+
+// startupSignature JDT.LS 启动失败时 stderr 中的典型输出和对应的处理建议
+type startupSignature struct {
+	re   *regexp.Regexp
+	hint string
+}
+
+var startupSignatures = []startupSignature{
+	{
+		regexp.MustCompile(`UnsupportedClassVersionError|has been compiled by a more recent version of the Java Runtime`),
+		"JDT.LS needs a newer JDK than the `java` on PATH (recent releases require Java 21). Point JAVA_HOME/PATH at a newer JDK.",
+	},
+	{
+		regexp.MustCompile(`(?i)another instance .*running|workspace .*(?:is )?(?:already )?in use|Could not launch the product because the associated workspace is currently in use|\.metadata[/\\]\.lock`),
+		"Another JDT.LS instance is using the same data directory. Stop the other scan, or remove the stale lock file if no scan is running.",
+	},
+	{
+		regexp.MustCompile(`OutOfMemoryError`),
+		"JDT.LS ran out of memory. Raise the heap, e.g. -jvm-opts '-Xmx4G', or narrow the workspace with -scope-dir.",
+	},
+	{
+		regexp.MustCompile(`Could not create the Java Virtual Machine|Unrecognized (?:VM )?option|Invalid maximum heap size|Error occurred during initialization of VM`),
+		"The JVM rejected its options. Check the values passed with -jvm-opts.",
+	},
+	{
+		regexp.MustCompile(`error while loading shared libraries|cannot open shared object file`),
+		"The JDK is missing native libraries. Install a complete JDK (not a stripped JRE image) and set JAVA_HOME.",
+	},
+	{
+		regexp.MustCompile(`(?i)AccessDeniedException|Permission denied|read-only file system`),
+		"JDT.LS cannot write its configuration or data directory. Check the permissions of the JDT.LS installation and the workspace directory.",
+	},
+	{
+		regexp.MustCompile(`(?i)lombok.*(?:Exception|Error)|java\.lang\.instrument`),
+		"The Lombok agent failed to load. Rerun with -no-lombok, or point -lombok-jar at a lombok.jar that supports this JDK.",
+	},
+}
+
+// DiagnoseStderr 按已知的故障签名检查 JDT.LS 的 stderr，返回对应的处理建议 (按签名顺序，每个签名最多一条)
+func DiagnoseStderr(lines []string) []string {
+	var hints []string
+	for _, sig := range startupSignatures {
+		for _, line := range lines {
+			if sig.re.MatchString(line) {
+				hints = append(hints, sig.hint)
+				break
+			}
+		}
+	}
+	return hints
+}
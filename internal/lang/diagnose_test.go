@@ -0,0 +1,53 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+)
+
+// 每个故障签名对应一段真实的 JDT.LS / JVM stderr 输出
+func TestDiagnoseStderr(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		hint string // 建议中的关键内容
+	}{
+		{"old java", "Error: LinkageError occurred while loading main class org.eclipse.equinox.launcher.Main: java.lang.UnsupportedClassVersionError: org/eclipse/equinox/launcher/Main", "newer JDK"},
+		{"class file version", "org/eclipse/jdt/ls/core/internal/JavaLanguageServerPlugin has been compiled by a more recent version of the Java Runtime (class file version 65.0)", "newer JDK"},
+		{"workspace lock", "Could not launch the product because the associated workspace is currently in use by another Eclipse application.", "same data directory"},
+		{"metadata lock", "java.io.IOException: cannot lock /tmp/.jdtls_data_cache/.metadata/.lock", "same data directory"},
+		{"out of memory", "Exception in thread \"main\" java.lang.OutOfMemoryError: Java heap space", "-Xmx4G"},
+		{"jvm options", "Unrecognized VM option 'UseConcMarkSweepGC'", "-jvm-opts"},
+		{"heap size", "Invalid maximum heap size: -Xmx4Q", "-jvm-opts"},
+		{"shared libraries", "java: error while loading shared libraries: libjli.so: cannot open shared object file: No such file or directory", "native libraries"},
+		{"permissions", "java.nio.file.AccessDeniedException: /opt/jdtls/config_linux/org.eclipse.osgi", "permissions"},
+		{"read-only", "java.io.IOException: Read-only file system", "permissions"},
+		{"lombok", "java.lang.IllegalStateException: lombok agent failed: LombokException", "-no-lombok"},
+		{"instrument", "FATAL ERROR in native method: processing of -javaagent failed, java.lang.instrument.IllegalClassFormatException", "-no-lombok"},
+	}
+	for _, tt := range tests {
+		hints := DiagnoseStderr([]string{"Starting JDT.LS", tt.line})
+		if len(hints) != 1 || !strings.Contains(hints[0], tt.hint) {
+			t.Errorf("%s: hints %q, want one containing %q", tt.name, hints, tt.hint)
+		}
+	}
+}
+
+// 每个签名最多一条建议，按签名顺序输出；没有匹配时为空
+func TestDiagnoseStderrMultiple(t *testing.T) {
+	lines := []string{
+		"java.lang.OutOfMemoryError: Java heap space",
+		"java.lang.OutOfMemoryError: Metaspace",
+		"java.lang.UnsupportedClassVersionError: Main",
+	}
+	hints := DiagnoseStderr(lines)
+	if len(hints) != 2 || !strings.Contains(hints[0], "newer JDK") || !strings.Contains(hints[1], "out of memory") {
+		t.Errorf("hints = %q", hints)
+	}
+	if hints := DiagnoseStderr([]string{"!MESSAGE Started JDT.LS", "Initialized workspace"}); len(hints) != 0 {
+		t.Errorf("healthy output produced hints %q", hints)
+	}
+	if hints := DiagnoseStderr(nil); len(hints) != 0 {
+		t.Errorf("no output produced hints %q", hints)
+	}
+}
@@ -5,13 +5,18 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"LSPTracer/internal/env"
 )
 
 type JavaConfig struct {
 	JdtlsHome  string
 	JavaExec   string
-	LombokPath string // ✨ 新增：明确指定 Lombok 路径
+	LombokPath string // Lombok agent 路径，为空时不注入 (项目不使用 Lombok 或 -no-lombok)
+	JvmOptions []string // 追加的 JVM 参数 (e.g. -Xmx8G)，排在默认参数之后，可覆盖默认的堆大小
+	JavaMajor  int // JavaExec 的主版本号 (0 = 未知)，决定是否需要 -Xbootclasspath
 }
 
 // BuildCmd 现在返回 *exec.Cmd，符合 LSP Client 的期望
@@ -25,7 +30,7 @@ func (c *JavaConfig) BuildCmd() (*exec.Cmd, error) {
 	configDir := env.GetJdtlsConfigDir(c.JdtlsHome)
 	
 	// 3. 准备数据目录
-	dataDir, _ := filepath.Abs(".jdtls_data_cache")
+	dataDir, _ := filepath.Abs(env.DataCacheDirName)
 	os.MkdirAll(dataDir, 0755)
 
 	args := []string{
@@ -41,13 +46,17 @@ func (c *JavaConfig) BuildCmd() (*exec.Cmd, error) {
 		"--add-opens", "java.base/java.io=ALL-UNNAMED",
 	}
 
-	// ✨✨✨ 注入 Lombok Agent (如果有) ✨✨✨
+	// 注入 Lombok Agent (项目使用 Lombok 时)
 	if c.LombokPath != "" {
 		args = append(args, fmt.Sprintf("-javaagent:%s", c.LombokPath))
-		// 某些高版本 JDK/Lombok 可能还需要 bootclasspath，加上更保险
-		args = append(args, fmt.Sprintf("-Xbootclasspath/a:%s", c.LombokPath))
+		// 只有 JDK 8 需要 bootclasspath；JDK 9+ 上这个参数没有作用，部分 JDK 17+ 构建甚至会直接中止 JVM
+		if c.JavaMajor > 0 && c.JavaMajor < 9 {
+			args = append(args, fmt.Sprintf("-Xbootclasspath/a:%s", c.LombokPath))
+		}
 	}
 
+	args = append(args, c.JvmOptions...)
+
 	args = append(args,
 		"-jar", launcherJar,
 		"-configuration", configDir,
@@ -57,6 +66,49 @@ func (c *JavaConfig) BuildCmd() (*exec.Cmd, error) {
 	return exec.Command(c.JavaExec, args...), nil
 }
 
+// StartupError 语言服务器在启动阶段退出时的错误，注入了 Lombok agent 时单独说明，便于和一般的启动失败区分
+func (c *JavaConfig) StartupError(exitErr error) error {
+	if c.LombokPath != "" {
+		return fmt.Errorf("JDT.LS exited during startup with the Lombok agent attached (%s): %v; "+
+			"rerun with -no-lombok, or point -lombok-jar at a lombok.jar that supports this JDK", c.LombokPath, exitErr)
+	}
+	return fmt.Errorf("JDT.LS exited during startup: %v", exitErr)
+}
+
+var javaVersionRe = regexp.MustCompile(`version "(\d+)(?:\.(\d+))?`)
+
+// JavaMajorVersion 运行 `java -version` 得到主版本号 (1.8 -> 8)，失败时为 0
+func JavaMajorVersion(javaExec string) int {
+	out, err := exec.Command(javaExec, "-version").CombinedOutput()
+	if err != nil {
+		return 0
+	}
+	m := javaVersionRe.FindStringSubmatch(string(out))
+	if m == nil {
+		return 0
+	}
+	major, _ := strconv.Atoi(m[1])
+	if major == 1 && m[2] != "" {
+		major, _ = strconv.Atoi(m[2])
+	}
+	return major
+}
+
+// JavaVersion 返回 `java -version` 输出的第一行 (e.g. `openjdk version "21.0.2" 2024-01-16`)，失败时为空
+func JavaVersion(javaExec string) string {
+	out, err := exec.Command(javaExec, "-version").CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// JdtlsVersion 从 plugins 中 org.eclipse.jdt.ls.core_<版本>.jar 的文件名得到 JDT.LS 的版本，找不到时为空
+func JdtlsVersion(jdtlsHome string) string {
+	return env.JdtlsPluginVersion(jdtlsHome)
+}
+
 func findLauncherJar(dir string) (string, error) {
 	matches, _ := filepath.Glob(filepath.Join(dir, "org.eclipse.equinox.launcher_*.jar"))
 	if len(matches) == 0 {
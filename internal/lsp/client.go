@@ -2,15 +2,19 @@ package lsp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"LSPTracer/internal/textutil"
 )
 
 type Client struct {
@@ -22,12 +26,51 @@ type Client struct {
 	isRunning bool
 
 	// Async Response Handling
-	pendingResponses map[int]chan json.RawMessage
+	pendingResponses map[string]chan response // key: ID.Key()
 	responseMu       sync.Mutex
 	serviceReady     chan struct{}
 	once             sync.Once
+
+	// $/progress 中的索引任务 (token -> 是否仍在进行)，全部结束时关闭 progressReady
+	indexing      map[string]bool
+	progressMu    sync.Mutex
+	progressReady chan struct{}
+	progressOnce  sync.Once
+	statusSeen    atomic.Bool // 收到过 language/status (服务器会发送 ServiceReady)
+
+	// publishDiagnostics: uri -> 错误数量 (以最后一次发布为准)
+	diagnostics map[string]int
+	diagLast    time.Time // 最近一次收到 publishDiagnostics 的时间
+	diagMu      sync.Mutex
+
+	// 进程退出检测: readLoop 读到 EOF 后关闭 done
+	done    chan struct{}
+	exitErr error
+
+	// stderr 的最后 DefaultStderrLines 行，stderrDone 在读到 EOF 后关闭
+	stderr     *lineRing
+	stderrDone chan struct{}
+
+	// -lsp-log 流量日志 (nil 表示关闭)
+	traffic atomic.Pointer[trafficLog]
+
+	// 服务器主动发起的请求 (workspace/configuration 等)
+	handlers   map[string]RequestHandler
+	handlersMu sync.RWMutex
+
+	// initialize 返回的服务器能力 (nil 表示未知，所有请求都视为支持)
+	capabilities atomic.Pointer[ServerCapabilities]
+}
+
+// response 一次请求的结果，Err 非空表示服务器返回了错误对象
+type response struct {
+	Result json.RawMessage
+	Err    *ResponseError
 }
 
+// RequestHandler 处理服务器发起的请求，返回 result 或错误
+type RequestHandler func(params json.RawMessage) (interface{}, *ResponseError)
+
 func NewClient(cmd *exec.Cmd) (*Client, error) {
 	// 1. 获取 Stdin Pipe
 	stdin, err := cmd.StdinPipe()
@@ -52,17 +95,25 @@ func NewClient(cmd *exec.Cmd) (*Client, error) {
 		return nil, err
 	}
 
-	// 5. 启动 goroutine 处理 stderr
-	go io.Copy(os.Stderr, stderrPipe)
-
 	c := &Client{
 		cmd:              cmd,
 		stdin:            stdin,
 		stdout:           bufio.NewReader(stdoutPipe),
 		isRunning:        true,
-		pendingResponses: make(map[int]chan json.RawMessage),
+		pendingResponses: make(map[string]chan response),
 		serviceReady:     make(chan struct{}),
+		indexing:         make(map[string]bool),
+		progressReady:    make(chan struct{}),
+		handlers:         make(map[string]RequestHandler),
+		diagnostics:      make(map[string]int),
+		done:             make(chan struct{}),
+		stderr:           newLineRing(DefaultStderrLines),
+		stderrDone:       make(chan struct{}),
 	}
+	c.registerDefaultHandlers()
+
+	// 5. stderr 保存在环形缓冲区中，不与扫描输出混在一起 (启动失败时由调用方输出摘录)
+	go c.stderr.capture(stderrPipe, c.stderrDone)
 
 	// 6. 启动专用读取协程
 	go c.readLoop()
@@ -75,10 +126,43 @@ func (c *Client) Close() {
 		c.cmd.Process.Kill()
 		c.isRunning = false
 	}
+	c.traffic.Load().close()
+}
+
+// Done 在语言服务器进程退出 (崩溃或被关闭) 后关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Exited 语言服务器进程是否已经退出
+func (c *Client) Exited() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExitErr 返回进程的退出状态 (仅在 Exited 之后有意义)
+func (c *Client) ExitErr() error {
+	<-c.done
+	return c.exitErr
 }
 
 // readLoop 持续读取 stdout 并分发消息
 func (c *Client) readLoop() {
+	// stdout 关闭意味着进程已经退出: 回收进程并通知所有等待者
+	defer func() {
+		// Wait 会关闭 stderr 管道: 先让 stderr 读取完 (Stderr 在 done 之后总是完整的)
+		select {
+		case <-c.stderrDone:
+		case <-time.After(stderrDrainWait):
+		}
+		c.exitErr = c.cmd.Wait()
+		close(c.done)
+	}()
+
 	for {
 		line, err := c.stdout.ReadString('\n')
 		if err != nil {
@@ -97,32 +181,32 @@ func (c *Client) readLoop() {
 				return
 			}
 
+			c.traffic.Load().record("recv", bodyBuf)
+
 			var msg JsonRpcMessage
 			if err := json.Unmarshal(bodyBuf, &msg); err != nil {
 				continue
 			}
 
-			// 1. 处理日志/状态通知
+			// 1. 服务器发起的请求 (同时带 method 和 id)，必须回复
+			if msg.Method != "" && msg.Id != nil {
+				go c.handleServerRequest(msg)
+				continue
+			}
+
+			// 2. 处理日志/状态通知
 			c.handleNotification(msg)
 
-			// 2. 处理响应 (如果有 ID)
+			// 3. 处理响应 (如果有 ID)
 			if msg.Id != nil {
-				var currentId int
-				switch v := msg.Id.(type) {
-				case float64:
-					currentId = int(v)
-				case int:
-					currentId = v
-				}
-
 				c.responseMu.Lock()
-				ch, ok := c.pendingResponses[currentId]
+				ch, ok := c.pendingResponses[msg.Id.Key()]
 				c.responseMu.Unlock()
 
 				if ok {
 					// 非阻塞发送，防止 readLoop 卡死
 					select {
-					case ch <- msg.Result:
+					case ch <- response{Result: msg.Result, Err: msg.Error}:
 					default:
 					}
 				}
@@ -142,15 +226,35 @@ func (c *Client) handleNotification(msg JsonRpcMessage) {
 		}
 	}
 
+	if msg.Method == "textDocument/publishDiagnostics" {
+		raw, _ := json.Marshal(msg.Params)
+		var p PublishDiagnosticsParams
+		if err := json.Unmarshal(raw, &p); err == nil {
+			errors := 0
+			for _, d := range p.Diagnostics {
+				if d.Severity == DiagnosticSeverityError {
+					errors++
+				}
+			}
+			c.diagMu.Lock()
+			c.diagnostics[p.Uri] = errors
+			c.diagLast = time.Now()
+			c.diagMu.Unlock()
+		}
+	}
+
+	if msg.Method == "$/progress" {
+		c.handleProgress(msg.Params)
+	}
+
 	if msg.Method == "language/status" {
 		paramsMap, ok := msg.Params.(map[string]interface{})
 		if ok {
+			c.statusSeen.Store(true)
 			msgType, _ := paramsMap["type"].(string)
 			msgText, _ := paramsMap["message"].(string)
 			// 避免打印过长的状态信息，尤其是重复的 Refreshing
-			if len(msgText) > 100 {
-				msgText = msgText[:97] + "..."
-			}
+			msgText = textutil.Truncate(msgText, 100)
 			fmt.Printf("\r\033[K    -> Server Status: %s - %s", msgType, msgText)
 
 			if msgType == "ServiceReady" {
@@ -163,6 +267,106 @@ func (c *Client) handleNotification(msg JsonRpcMessage) {
 	}
 }
 
+// DiagnosticStats 汇总目前收到的编译诊断
+func (c *Client) DiagnosticStats() DiagnosticStats {
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+
+	stats := DiagnosticStats{FilesReported: len(c.diagnostics)}
+	for _, n := range c.diagnostics {
+		if n > 0 {
+			stats.Errors += n
+			stats.FilesWithErrors++
+		}
+	}
+	return stats
+}
+
+// WaitDiagnosticsSettled 等待 publishDiagnostics 平静下来: 连续 quiet 时间内没有新的诊断，最多等待 max
+// 返回 false 表示等到 max 时诊断仍在持续发布 (或者语言服务器已退出)
+func (c *Client) WaitDiagnosticsSettled(quiet, max time.Duration) bool {
+	deadline := time.Now().Add(max)
+	start := time.Now()
+	for {
+		c.diagMu.Lock()
+		last := c.diagLast
+		c.diagMu.Unlock()
+		if last.Before(start) {
+			last = start
+		}
+		if time.Since(last) >= quiet {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		select {
+		case <-c.done:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// HandleRequest 注册服务器请求的处理函数 (覆盖同名的默认处理)
+func (c *Client) HandleRequest(method string, handler RequestHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.handlers[method] = handler
+}
+
+// registerDefaultHandlers 注册 JDT.LS 常见的服务器请求的默认应答
+func (c *Client) registerDefaultHandlers() {
+	ack := func(json.RawMessage) (interface{}, *ResponseError) { return nil, nil }
+
+	c.handlers["client/registerCapability"] = ack
+	c.handlers["client/unregisterCapability"] = ack
+	c.handlers["window/workDoneProgress/create"] = ack
+	// 不展示消息框，等同于用户未选择任何操作
+	c.handlers["window/showMessageRequest"] = ack
+	// 扫描过程中不允许服务器修改源码
+	c.handlers["workspace/applyEdit"] = func(json.RawMessage) (interface{}, *ResponseError) {
+		return map[string]interface{}{
+			"applied":       false,
+			"failureReason": "LSPTracer is a read-only client",
+		}, nil
+	}
+	// 默认没有任何配置，Tracer 启动时会覆盖
+	c.handlers["workspace/configuration"] = func(params json.RawMessage) (interface{}, *ResponseError) {
+		var p ConfigurationParams
+		json.Unmarshal(params, &p)
+		return make([]interface{}, len(p.Items)), nil
+	}
+}
+
+// handleServerRequest 调用已注册的处理函数并回复，未知方法回复 MethodNotFound
+func (c *Client) handleServerRequest(msg JsonRpcMessage) {
+	c.handlersMu.RLock()
+	handler, ok := c.handlers[msg.Method]
+	c.handlersMu.RUnlock()
+
+	resp := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msg.Id,
+	}
+
+	if !ok {
+		resp["error"] = &ResponseError{Code: ErrMethodNotFound, Message: "method not found: " + msg.Method}
+	} else {
+		params, _ := json.Marshal(msg.Params)
+		result, rpcErr := handler(params)
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.write(resp)
+}
+
 // 发送请求
 func (c *Client) SendRequest(method string, params interface{}) int {
 	c.mu.Lock()
@@ -170,14 +374,15 @@ func (c *Client) SendRequest(method string, params interface{}) int {
 	c.msgId++
 
 	// 提前注册通道
-	ch := make(chan json.RawMessage, 1)
+	id := NewIntID(c.msgId)
+	ch := make(chan response, 1)
 	c.responseMu.Lock()
-	c.pendingResponses[c.msgId] = ch
+	c.pendingResponses[id.Key()] = ch
 	c.responseMu.Unlock()
 
 	req := JsonRpcMessage{
 		JsonRpc: "2.0",
-		Id:      c.msgId,
+		Id:      id,
 		Method:  method,
 		Params:  params,
 	}
@@ -203,15 +408,69 @@ func (c *Client) SendNotification(method string, params interface{}) {
 // 写入数据到底层 Pipe
 func (c *Client) write(msg interface{}) {
 	body, _ := json.Marshal(msg)
+	c.traffic.Load().record("send", body)
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
 	c.stdin.Write([]byte(header))
 	c.stdin.Write(body)
 }
 
 // 等待特定的响应结果
+// 服务器返回错误时，error 为 *ResponseError (可用 errors.As 获取错误码)；超时返回 ErrTimeout
 func (c *Client) WaitForResult(targetId int, timeout time.Duration) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.wait(ctx, targetId)
+}
+
+// Call 发送请求并等待结果，结果解析到 result (可为 nil)
+// 如果 ctx 在响应到达前结束，会向服务器发送 $/cancelRequest，避免其继续执行昂贵的查询
+// 服务器没有声明支持的请求不会发送，直接返回 ErrUnsupported
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if !c.Supports(method) {
+		return fmt.Errorf("%s: %w", method, ErrUnsupported)
+	}
+	id := c.SendRequest(method, params)
+	raw, err := c.wait(ctx, id)
+	if err != nil {
+		return err
+	}
+	if result != nil && len(raw) > 0 {
+		return json.Unmarshal(raw, result)
+	}
+	return nil
+}
+
+// Initialize 发送 initialize 并等待结果，记录服务器能力供 Supports 使用
+func (c *Client) Initialize(ctx context.Context, params InitializeParams) (*InitializeResult, error) {
+	var result InitializeResult
+	if err := c.Call(ctx, "initialize", params, &result); err != nil {
+		return nil, err
+	}
+	c.capabilities.Store(&result.Capabilities)
+	return &result, nil
+}
+
+// Supports 服务器是否声明支持该请求；initialize 没有返回结果时视为支持
+func (c *Client) Supports(method string) bool {
+	caps := c.capabilities.Load()
+	return caps == nil || caps.Supports(method)
+}
+
+// WorkspaceSymbol 发送 workspace/symbol，在整个工作区中按名称查找符号
+func (c *Client) WorkspaceSymbol(ctx context.Context, query string) ([]SymbolInformation, error) {
+	var symbols []SymbolInformation
+	if err := c.Call(ctx, "workspace/symbol", WorkspaceSymbolParams{Query: query}, &symbols); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// wait 等待响应，无论成功与否都会从 pendingResponses 中移除该请求
+func (c *Client) wait(ctx context.Context, targetId int) (json.RawMessage, error) {
+	key := NewIntID(targetId).Key()
+
 	c.responseMu.Lock()
-	ch, ok := c.pendingResponses[targetId]
+	ch, ok := c.pendingResponses[key]
 	c.responseMu.Unlock()
 
 	if !ok {
@@ -220,25 +479,23 @@ func (c *Client) WaitForResult(targetId int, timeout time.Duration) (json.RawMes
 
 	defer func() {
 		c.responseMu.Lock()
-		delete(c.pendingResponses, targetId)
+		delete(c.pendingResponses, key)
 		c.responseMu.Unlock()
 	}()
 
 	select {
 	case res := <-ch:
-		return res, nil
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("timeout")
-	}
-}
-
-// 等待 JDT.LS 就绪
-func (c *Client) WaitForServiceReady(timeout time.Duration) error {
-	fmt.Printf("    -> Waiting for JDT.LS 'ServiceReady' signal...\n")
-	select {
-	case <-c.serviceReady:
-		return nil
-	case <-time.After(timeout):
-		return fmt.Errorf("timeout waiting for ServiceReady")
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Result, nil
+	case <-c.done:
+		return nil, ErrServerExited
+	case <-ctx.Done():
+		c.SendNotification("$/cancelRequest", map[string]interface{}{"id": targetId})
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrTimeout
+		}
+		return nil, ctx.Err()
 	}
 }
@@ -0,0 +1,207 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func callFake(t *testing.T, c *Client, method string, params, result interface{}) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.Call(ctx, method, params, result)
+}
+
+func TestCallNumericIDs(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	var got map[string]string
+	if err := callFake(t, c, "test/echo", map[string]string{"k": "v"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["k"] != "v" {
+		t.Errorf("result = %v", got)
+	}
+}
+
+func TestCallStringIDs(t *testing.T) {
+	// 服务器用字符串回显 ID 时响应仍然能匹配到请求，不会一直等到超时
+	c := startFakeServer(t, "string-ids")
+	for i := 0; i < 3; i++ {
+		var got []int
+		if err := callFake(t, c, "test/echo", []int{i}, &got); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(got) != 1 || got[0] != i {
+			t.Errorf("call %d: result = %v", i, got)
+		}
+	}
+}
+
+func TestCallResponseError(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	tests := []struct {
+		code      int
+		retryable bool
+	}{
+		{ErrContentModified, true},
+		{ErrServerCancelled, true},
+		{ErrInternal, false},
+	}
+	for _, tt := range tests {
+		err := callFake(t, c, "test/fail", ResponseError{Code: tt.code, Message: "failed"}, nil)
+		var rpcErr *ResponseError
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("code %d: err = %v, want *ResponseError", tt.code, err)
+		}
+		if rpcErr.Code != tt.code || rpcErr.Message != "failed" {
+			t.Errorf("code %d: err = %+v", tt.code, rpcErr)
+		}
+		if IsRetryable(err) != tt.retryable {
+			t.Errorf("code %d: IsRetryable = %v, want %v", tt.code, !tt.retryable, tt.retryable)
+		}
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	// 假服务器等待客户端回复 srv-1，但客户端的处理函数阻塞，请求会超时
+	block := make(chan struct{})
+	defer close(block)
+	c.HandleRequest("test/block", func(json.RawMessage) (interface{}, *ResponseError) {
+		<-block
+		return nil, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := c.Call(ctx, "test/serverRequest", map[string]string{"method": "test/block"}, nil)
+	if !errors.Is(err, ErrTimeout) || !IsRetryable(err) {
+		t.Errorf("err = %v, want a retryable ErrTimeout", err)
+	}
+}
+
+// serverRequest 让假服务器向客户端发起请求，返回客户端的回复
+func serverRequest(t *testing.T, c *Client, method string, params interface{}) JsonRpcMessage {
+	t.Helper()
+	var reply JsonRpcMessage
+	if err := callFake(t, c, "test/serverRequest", map[string]interface{}{"method": method, "params": params}, &reply); err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	if reply.Id == nil || !reply.Id.IsStr {
+		t.Errorf("%s: reply id = %+v, want the server's string id", method, reply.Id)
+	}
+	return reply
+}
+
+func TestServerRequests(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+
+	reply := serverRequest(t, c, "client/registerCapability", map[string]interface{}{
+		"registrations": []map[string]string{{"id": "1", "method": "workspace/didChangeWatchedFiles"}},
+	})
+	if reply.Error != nil || string(reply.Result) != "null" {
+		t.Errorf("registerCapability: result = %s, error = %v", reply.Result, reply.Error)
+	}
+
+	reply = serverRequest(t, c, "workspace/applyEdit", map[string]interface{}{"edit": map[string]interface{}{}})
+	var applied struct {
+		Applied       bool   `json:"applied"`
+		FailureReason string `json:"failureReason"`
+	}
+	if err := json.Unmarshal(reply.Result, &applied); err != nil || applied.Applied || applied.FailureReason == "" {
+		t.Errorf("applyEdit: result = %s", reply.Result)
+	}
+
+	reply = serverRequest(t, c, "workspace/configuration", ConfigurationParams{
+		Items: []ConfigurationItem{{Section: "java.home"}, {Section: "java.format"}},
+	})
+	var settings []interface{}
+	if err := json.Unmarshal(reply.Result, &settings); err != nil || len(settings) != 2 {
+		t.Errorf("configuration: result = %s, want one entry per item", reply.Result)
+	}
+
+	reply = serverRequest(t, c, "java/unknownRequest", nil)
+	if reply.Error == nil || reply.Error.Code != ErrMethodNotFound {
+		t.Errorf("unknown request: error = %v, want MethodNotFound", reply.Error)
+	}
+}
+
+func TestHandleRequestOverride(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	c.HandleRequest("workspace/configuration", func(params json.RawMessage) (interface{}, *ResponseError) {
+		var p ConfigurationParams
+		json.Unmarshal(params, &p)
+		result := make([]string, len(p.Items))
+		for i, item := range p.Items {
+			result[i] = "value of " + item.Section
+		}
+		return result, nil
+	})
+	c.HandleRequest("workspace/applyEdit", func(json.RawMessage) (interface{}, *ResponseError) {
+		return nil, &ResponseError{Code: ErrInternal, Message: "rejected"}
+	})
+
+	reply := serverRequest(t, c, "workspace/configuration", ConfigurationParams{
+		Items: []ConfigurationItem{{Section: "java.home"}},
+	})
+	var settings []string
+	json.Unmarshal(reply.Result, &settings)
+	if len(settings) != 1 || settings[0] != "value of java.home" {
+		t.Errorf("configuration: result = %s", reply.Result)
+	}
+
+	reply = serverRequest(t, c, "workspace/applyEdit", nil)
+	if reply.Error == nil || reply.Error.Code != ErrInternal || reply.Error.Message != "rejected" {
+		t.Errorf("applyEdit: error = %v", reply.Error)
+	}
+}
+
+// initialize 之前所有请求都视为支持；之后服务器没有声明的请求不发送，直接返回 ErrUnsupported
+func TestInitializeCapabilities(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	for _, method := range []string{"textDocument/references", "textDocument/documentSymbol", "textDocument/hover"} {
+		if !c.Supports(method) {
+			t.Errorf("Supports(%s) = false before initialize", method)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := c.Initialize(ctx, InitializeParams{InitializationOptions: json.RawMessage(
+		`{"referencesProvider":false,"hoverProvider":{"workDoneProgress":true},"definitionProvider":true}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ServerInfo == nil || result.ServerInfo.Name != "fake" {
+		t.Errorf("serverInfo = %+v", result.ServerInfo)
+	}
+
+	path := docFixture(t, 1)[0]
+	doc := map[string]interface{}{"textDocument": map[string]string{"uri": ToUri(path)}}
+	if err := callFake(t, c, "textDocument/references", doc, nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("references: error %v, want ErrUnsupported", err)
+	}
+	// 声明为选项对象的能力视为支持: 请求发送到服务器 (假服务器不认识 hover，返回 MethodNotFound)
+	if err := callFake(t, c, "textDocument/hover", doc, nil); err == nil || errors.Is(err, ErrUnsupported) {
+		t.Errorf("hover: error %v, want the server's error", err)
+	}
+	if _, err := NewDocumentManager(c, "java", 10).Symbols(path); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("documentSymbol: error %v, want ErrUnsupported", err)
+	}
+	// 没有对应能力字段的请求照常发送
+	var echo []int
+	if err := callFake(t, c, "test/echo", []int{1}, &echo); err != nil || len(echo) != 1 {
+		t.Errorf("test/echo = %v, %v", echo, err)
+	}
+
+	want := []string{
+		"textDocument/hover " + ToUri(path),
+		"textDocument/didOpen " + ToUri(path),
+	}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package lsp
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxOpenDocuments 同时保持打开的文档数量上限
+const DefaultMaxOpenDocuments = 50
+
+// DocumentManager 管理客户端打开的文档 (didOpen/didChange/didClose) 以及 documentSymbol 缓存
+// 所有方法都可以在多个 goroutine 中并发调用
+type DocumentManager struct {
+	client     *Client
+	languageId string
+	maxOpen    int
+
+	mu      sync.RWMutex
+	docs    map[string]*openDocument // key: NormalizePath
+	lru     *list.List               // 最近使用在前，元素为 NormalizePath
+	symbols map[string][]DocumentSymbol
+}
+
+type openDocument struct {
+	uri     string
+	version int
+	elem    *list.Element
+}
+
+func NewDocumentManager(client *Client, languageId string, maxOpen int) *DocumentManager {
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenDocuments
+	}
+	return &DocumentManager{
+		client:     client,
+		languageId: languageId,
+		maxOpen:    maxOpen,
+		docs:       make(map[string]*openDocument),
+		lru:        list.New(),
+		symbols:    make(map[string][]DocumentSymbol),
+	}
+}
+
+// Open 打开文档 (仅在第一次调用时发送 didOpen)，超过上限时关闭最久未使用的文档
+func (m *DocumentManager) Open(path string) error {
+	key := NormalizePath(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if doc, ok := m.docs[key]; ok {
+		m.lru.MoveToFront(doc.elem)
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	doc := &openDocument{uri: ToUri(path), version: 1}
+	doc.elem = m.lru.PushFront(key)
+	m.docs[key] = doc
+
+	m.client.SendNotification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        doc.uri,
+			"languageId": m.languageId,
+			"version":    doc.version,
+			"text":       string(content),
+		},
+	})
+
+	for m.lru.Len() > m.maxOpen {
+		m.closeLocked(m.lru.Back().Value.(string))
+	}
+	return nil
+}
+
+// Capacity 同时保持打开的文档数量上限
+func (m *DocumentManager) Capacity() int {
+	return m.maxOpen
+}
+
+// Symbols 返回文档的 documentSymbol 结果 (带缓存)，必要时先打开文档
+func (m *DocumentManager) Symbols(path string) ([]DocumentSymbol, error) {
+	key := NormalizePath(path)
+
+	m.mu.RLock()
+	cached, ok := m.symbols[key]
+	m.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	if err := m.Open(path); err != nil {
+		return nil, err
+	}
+
+	if !m.client.Supports("textDocument/documentSymbol") {
+		return nil, ErrUnsupported
+	}
+	id := m.client.SendRequest("textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]string{"uri": ToUri(path)},
+	})
+	raw, err := m.client.WaitForResult(id, 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// 扁平格式需要文档内容来推算 selectionRange
+	var lines []string
+	if content, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(string(content), "\n")
+	}
+
+	symbols, err := DecodeDocumentSymbols(raw, lines)
+	if err != nil {
+		return nil, fmt.Errorf("decode documentSymbol: %v", err)
+	}
+
+	m.mu.Lock()
+	m.symbols[key] = symbols
+	m.mu.Unlock()
+	return symbols, nil
+}
+
+// Invalidate 丢弃文件的符号缓存；如果文档处于打开状态，则用磁盘上的新内容发送 didChange
+func (m *DocumentManager) Invalidate(path string) {
+	key := NormalizePath(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.symbols, key)
+
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		// 文件已被删除: 直接关闭
+		m.closeLocked(key)
+		return
+	}
+	doc.version++
+	m.client.SendNotification("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":     doc.uri,
+			"version": doc.version,
+		},
+		"contentChanges": []map[string]string{{"text": string(content)}},
+	})
+}
+
+// Close 关闭单个文档
+func (m *DocumentManager) Close(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeLocked(NormalizePath(path))
+}
+
+func (m *DocumentManager) closeLocked(key string) {
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	m.lru.Remove(doc.elem)
+	delete(m.docs, key)
+	m.client.SendNotification("textDocument/didClose", map[string]interface{}{
+		"textDocument": map[string]string{"uri": doc.uri},
+	})
+}
@@ -0,0 +1,128 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// docFixture 在临时目录中写入 n 个 Java 文件
+func docFixture(t *testing.T, n int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, string(rune('A'+i))+".java")
+		if err := os.WriteFile(paths[i], []byte("class Demo {\n    public void run() {\n    }\n}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// serverLog 返回假服务器收到的文档请求和通知
+func serverLog(t *testing.T, c *Client) []string {
+	t.Helper()
+	var log []string
+	if err := callFake(t, c, "test/log", nil, &log); err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func TestDocumentManagerEviction(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	m := NewDocumentManager(c, "java", 2)
+	p := docFixture(t, 3)
+
+	for _, path := range []string{p[0], p[1], p[0], p[2]} {
+		if err := m.Open(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 重复打开 A 只刷新 LRU 顺序；打开 C 时关闭最久未使用的 B
+	want := []string{
+		"textDocument/didOpen " + ToUri(p[0]),
+		"textDocument/didOpen " + ToUri(p[1]),
+		"textDocument/didOpen " + ToUri(p[2]),
+		"textDocument/didClose " + ToUri(p[1]),
+	}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+
+	// B 已被关闭，再次打开会重新发送 didOpen 并挤掉 A
+	m.Open(p[1])
+	want = []string{
+		"textDocument/didOpen " + ToUri(p[1]),
+		"textDocument/didClose " + ToUri(p[0]),
+	}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
+
+func TestDocumentManagerDefaultCapacity(t *testing.T) {
+	if got := NewDocumentManager(nil, "java", 0).Capacity(); got != DefaultMaxOpenDocuments {
+		t.Errorf("Capacity() = %d, want %d", got, DefaultMaxOpenDocuments)
+	}
+}
+
+func TestDocumentManagerSymbolCache(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	m := NewDocumentManager(c, "java", 10)
+	path := docFixture(t, 1)[0]
+
+	for i := 0; i < 3; i++ {
+		symbols, err := m.Symbols(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(symbols) != 1 || symbols[0].Name != "Demo" || len(symbols[0].Children) != 1 {
+			t.Fatalf("symbols = %+v", symbols)
+		}
+	}
+	// 只打开一次，只请求一次
+	want := []string{
+		"textDocument/didOpen " + ToUri(path),
+		"textDocument/documentSymbol " + ToUri(path),
+	}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
+
+func TestDocumentManagerInvalidate(t *testing.T) {
+	c := startFakeServer(t, "numeric-ids")
+	m := NewDocumentManager(c, "java", 10)
+	p := docFixture(t, 2)
+
+	m.Symbols(p[0])
+	serverLog(t, c)
+
+	// 打开的文档: 发送 didChange 并丢弃符号缓存
+	m.Invalidate(p[0])
+	m.Symbols(p[0])
+	want := []string{
+		"textDocument/didChange " + ToUri(p[0]),
+		"textDocument/documentSymbol " + ToUri(p[0]),
+	}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+
+	// 没有打开的文档: 不发送任何通知
+	m.Invalidate(p[1])
+	if got := serverLog(t, c); len(got) != 0 {
+		t.Errorf("log = %q, want nothing", got)
+	}
+
+	// 文件被删除: 关闭文档
+	os.Remove(p[0])
+	m.Invalidate(p[0])
+	want = []string{"textDocument/didClose " + ToUri(p[0])}
+	if got := serverLog(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeServerEnv 设置后测试二进制作为假的语言服务器运行 (值为模式)
+const fakeServerEnv = "LSPTRACER_FAKE_SERVER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeServerEnv); mode != "" {
+		runFakeServer(mode, os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startFakeServer 启动假的语言服务器并返回连接它的 Client
+// 模式 "string-ids" 把响应的 ID 改为字符串 (数字 1 回复为 "1")
+func startFakeServer(t *testing.T, mode string) *Client {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), fakeServerEnv+"="+mode)
+	c, err := NewClient(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// rawFrame 假服务器收发的消息 (保留原始的 id 和 params)
+type rawFrame struct {
+	JsonRpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// fakeSymbols 假服务器的 documentSymbol 结果: 一个类和它的一个方法
+const fakeSymbols = `[{"name":"Demo","kind":5,
+	"range":{"start":{"line":0,"character":0},"end":{"line":4,"character":1}},
+	"selectionRange":{"start":{"line":0,"character":13},"end":{"line":0,"character":17}},
+	"children":[{"name":"run()","kind":6,
+		"range":{"start":{"line":1,"character":4},"end":{"line":3,"character":5}},
+		"selectionRange":{"start":{"line":1,"character":16},"end":{"line":1,"character":19}}}]}]`
+
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			length, _ = strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+func writeFrame(w io.Writer, msg interface{}) {
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// runFakeServer 假服务器的主循环，支持的请求:
+//
+//	initialize          返回的服务器能力取自 params 中的 initializationOptions
+//	test/echo           返回 params
+//	test/fail           返回 params 中的错误对象 ({"code": -32801, "message": "..."})
+//	test/serverRequest  向客户端发起 params 描述的请求 ({"method": ..., "params": ...})，
+//	                    把客户端的回复原样作为结果返回
+//	test/log            返回并清空收到的文档请求和通知 ("方法 uri")
+//	test/notify         依次发送 params 中的通知 ([{"method": ..., "params": ...}])，之后返回 null
+//	textDocument/documentSymbol  返回 fakeSymbols
+func runFakeServer(mode string, in io.Reader, out io.Writer) {
+	r := bufio.NewReader(in)
+	serverSeq := 0
+	var log []string
+	for {
+		body, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		var req rawFrame
+		if json.Unmarshal(body, &req) != nil {
+			continue
+		}
+		if strings.HasPrefix(req.Method, "textDocument/") {
+			var p struct {
+				TextDocument struct {
+					Uri string `json:"uri"`
+				} `json:"textDocument"`
+			}
+			json.Unmarshal(req.Params, &p)
+			log = append(log, req.Method+" "+p.TextDocument.Uri)
+		}
+		if req.Id == nil {
+			continue
+		}
+		id := *req.Id
+		if mode == "string-ids" {
+			quoted, _ := json.Marshal(strings.Trim(string(id), `"`))
+			id = quoted
+		}
+		resp := rawFrame{JsonRpc: "2.0", Id: &id}
+
+		switch req.Method {
+		case "initialize":
+			var p struct {
+				InitializationOptions json.RawMessage `json:"initializationOptions"`
+			}
+			json.Unmarshal(req.Params, &p)
+			resp.Result, _ = json.Marshal(map[string]interface{}{
+				"capabilities": p.InitializationOptions,
+				"serverInfo":   map[string]string{"name": "fake"},
+			})
+		case "test/log":
+			resp.Result, _ = json.Marshal(log)
+			log = nil
+		case "textDocument/documentSymbol":
+			resp.Result = json.RawMessage(fakeSymbols)
+		case "test/echo":
+			resp.Result = req.Params
+		case "test/notify":
+			var notes []struct {
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			json.Unmarshal(req.Params, &notes)
+			for _, n := range notes {
+				writeFrame(out, rawFrame{JsonRpc: "2.0", Method: n.Method, Params: n.Params})
+			}
+			resp.Result = json.RawMessage("null")
+		case "test/fail":
+			var rpcErr ResponseError
+			json.Unmarshal(req.Params, &rpcErr)
+			resp.Error = &rpcErr
+		case "test/serverRequest":
+			var p struct {
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			json.Unmarshal(req.Params, &p)
+			serverSeq++
+			srvID := json.RawMessage(strconv.Quote(fmt.Sprintf("srv-%d", serverSeq)))
+			writeFrame(out, rawFrame{JsonRpc: "2.0", Id: &srvID, Method: p.Method, Params: p.Params})
+			for {
+				reply, err := readFrame(r)
+				if err != nil {
+					return
+				}
+				var m rawFrame
+				if json.Unmarshal(reply, &m) == nil && m.Method == "" && m.Id != nil && string(*m.Id) == string(srvID) {
+					resp.Result = reply
+					break
+				}
+			}
+		default:
+			resp.Error = &ResponseError{Code: ErrMethodNotFound, Message: "unknown method " + req.Method}
+		}
+		writeFrame(out, resp)
+	}
+}
@@ -1,17 +1,125 @@
 package lsp
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // 通用 JSON-RPC 消息
 type JsonRpcMessage struct {
 	JsonRpc string          `json:"jsonrpc"`
-	Id      interface{}     `json:"id,omitempty"` // ID 可以是 int, string 或 nil
+	Id      *ID             `json:"id,omitempty"` // nil 表示通知 (或 null ID)
 	Method  string          `json:"method,omitempty"`
 	Params  interface{}     `json:"params,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
-	Error   interface{}     `json:"error,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
 }
 
+// ID JSON-RPC 请求 ID，规范允许数字或字符串
+// 注意: JSON 中的 "id": null 会被解析为 nil 指针 (只出现在无法解析请求的错误响应中)
+type ID struct {
+	Num   int
+	Str   string
+	IsStr bool
+}
+
+func NewIntID(n int) *ID { return &ID{Num: n} }
+
+// Key 返回用于匹配请求/响应的规范化字符串，数字 1 与字符串 "1" 视为同一个 ID
+func (id ID) Key() string {
+	if id.IsStr {
+		return id.Str
+	}
+	return strconv.Itoa(id.Num)
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	if id.IsStr {
+		return json.Marshal(id.Str)
+	}
+	return json.Marshal(id.Num)
+}
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	switch val := v.(type) {
+	case json.Number:
+		n, err := val.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid jsonrpc id %s", val)
+		}
+		*id = ID{Num: int(n)}
+	case string:
+		*id = ID{Str: val, IsStr: true}
+	default:
+		return fmt.Errorf("invalid jsonrpc id %s", string(data))
+	}
+	return nil
+}
+
+// JSON-RPC / LSP 错误码
+const (
+	ErrMethodNotFound   = -32601
+	ErrInternal         = -32603
+	ErrRequestCancelled = -32800
+	ErrContentModified  = -32801
+	ErrServerCancelled  = -32802
+)
+
+// ErrTimeout 在指定时间内没有收到响应
+var ErrTimeout = errors.New("timeout")
+
+// ErrServerExited 语言服务器进程已经退出 (崩溃或 OOM)
+var ErrServerExited = errors.New("language server exited")
+
+// ErrUnsupported 服务器在 initialize 的结果中没有声明支持该请求，请求没有发送
+var ErrUnsupported = errors.New("not supported by the language server")
+
+// IsRetryable 判断请求失败后是否值得重试:
+// 文档内容变化 (-32801)、服务器主动取消 (-32802) 以及超时
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	var rpcErr *ResponseError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == ErrContentModified || rpcErr.Code == ErrServerCancelled
+	}
+	return false
+}
+
+// ResponseError JSON-RPC 错误对象
+type ResponseError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// ConfigurationParams workspace/configuration 请求参数
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type ConfigurationItem struct {
+	ScopeUri string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
 
 // 初始化参数
 type InitializeParams struct {
@@ -21,6 +129,78 @@ type InitializeParams struct {
 	InitializationOptions interface{}            `json:"initializationOptions,omitempty"`
 }
 
+// InitializeResult initialize 请求的返回结果
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	} `json:"serverInfo,omitempty"`
+}
+
+// ServerCapabilities 服务器能力中与追踪相关的部分
+type ServerCapabilities struct {
+	HoverProvider           Capability `json:"hoverProvider"`
+	DefinitionProvider      Capability `json:"definitionProvider"`
+	ImplementationProvider  Capability `json:"implementationProvider"`
+	ReferencesProvider      Capability `json:"referencesProvider"`
+	DocumentSymbolProvider  Capability `json:"documentSymbolProvider"`
+	WorkspaceSymbolProvider Capability `json:"workspaceSymbolProvider"`
+	CallHierarchyProvider   Capability `json:"callHierarchyProvider"`
+}
+
+// Capability 能力字段的原始值: true/false 或选项对象 (对象表示支持)
+type Capability json.RawMessage
+
+func (c *Capability) UnmarshalJSON(data []byte) error {
+	*c = append((*c)[:0], data...)
+	return nil
+}
+
+// Supported 字段存在且不是 false/null
+func (c Capability) Supported() bool {
+	s := strings.TrimSpace(string(c))
+	return s != "" && s != "false" && s != "null"
+}
+
+// capabilityOf 请求方法对应的能力字段，nil 表示不需要检查 (服务器必须支持或没有对应的能力字段)
+func (s *ServerCapabilities) capabilityOf(method string) *Capability {
+	switch method {
+	case "textDocument/hover":
+		return &s.HoverProvider
+	case "textDocument/definition":
+		return &s.DefinitionProvider
+	case "textDocument/implementation":
+		return &s.ImplementationProvider
+	case "textDocument/references":
+		return &s.ReferencesProvider
+	case "textDocument/documentSymbol":
+		return &s.DocumentSymbolProvider
+	case "workspace/symbol":
+		return &s.WorkspaceSymbolProvider
+	case "textDocument/prepareCallHierarchy", "callHierarchy/incomingCalls", "callHierarchy/outgoingCalls":
+		return &s.CallHierarchyProvider
+	}
+	return nil
+}
+
+// Supports 服务器是否声明支持该请求
+func (s *ServerCapabilities) Supports(method string) bool {
+	c := s.capabilityOf(method)
+	return c == nil || c.Supported()
+}
+
+// Unsupported 追踪用到的请求中服务器没有声明支持的部分
+func (s *ServerCapabilities) Unsupported() []string {
+	var missing []string
+	for _, method := range []string{"textDocument/references", "textDocument/definition", "textDocument/documentSymbol", "textDocument/hover", "workspace/symbol"} {
+		if !s.Supports(method) {
+			missing = append(missing, method)
+		}
+	}
+	return missing
+}
+
 type WorkspaceFolder struct {
 	Uri  string `json:"uri"`
 	Name string `json:"name"`
@@ -47,4 +227,88 @@ type DocumentSymbol struct {
 	Range          Range            `json:"range"`
 	SelectionRange Range            `json:"selectionRange"`
 	Children       []DocumentSymbol `json:"children,omitempty"`
-}
\ No newline at end of file
+}
+
+// SymbolInformation 是 documentSymbol 的扁平返回格式 (服务器不支持层级结构时使用)，也是 workspace/symbol 的返回结果
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+// WorkspaceSymbolParams workspace/symbol 请求参数
+// 返回 SymbolInformation[]；JDT.LS 的 containerName 为声明所在类型的全限定名
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+// Hover textDocument/hover 的返回结果
+// contents 可能是 MarkupContent、MarkedString ({language, value} 或纯字符串) 或 MarkedString 数组
+type Hover struct {
+	Contents json.RawMessage `json:"contents"`
+	Range    *Range          `json:"range,omitempty"`
+}
+
+type markedString struct {
+	Language string `json:"language,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Value    string `json:"value"`
+}
+
+// Text 将 hover 内容展开为纯文本 (多个片段以换行分隔)，代码片段保持原样
+func (h Hover) Text() string {
+	var parts []string
+	var decode func(raw json.RawMessage)
+	decode = func(raw json.RawMessage) {
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			parts = append(parts, str)
+			return
+		}
+		var list []json.RawMessage
+		if json.Unmarshal(raw, &list) == nil {
+			for _, item := range list {
+				decode(item)
+			}
+			return
+		}
+		var ms markedString
+		if json.Unmarshal(raw, &ms) == nil {
+			parts = append(parts, ms.Value)
+		}
+	}
+	if len(h.Contents) > 0 {
+		decode(h.Contents)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// DiagnosticSeverityError LSP 诊断等级: 1=Error 2=Warning 3=Information 4=Hint
+const DiagnosticSeverityError = 1
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+type PublishDiagnosticsParams struct {
+	Uri         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// DiagnosticStats 编译诊断汇总，用于判断索引质量
+type DiagnosticStats struct {
+	Errors          int `json:"compile_errors"`
+	FilesWithErrors int `json:"files_with_errors"`
+	FilesReported   int `json:"files_reported"`
+}
+
+// Health 没有编译错误的文件占比 (0~1)，没有收到任何诊断时视为 1
+func (s DiagnosticStats) Health() float64 {
+	if s.FilesReported == 0 {
+		return 1
+	}
+	return 1 - float64(s.FilesWithErrors)/float64(s.FilesReported)
+}
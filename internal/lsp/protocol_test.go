@@ -0,0 +1,174 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIDUnmarshal(t *testing.T) {
+	tests := []struct {
+		frame   string
+		wantNil bool
+		want    ID
+	}{
+		{`{"jsonrpc":"2.0","id":7,"result":null}`, false, ID{Num: 7}},
+		{`{"jsonrpc":"2.0","id":"req-7","result":null}`, false, ID{Str: "req-7", IsStr: true}},
+		{`{"jsonrpc":"2.0","id":"7","result":null}`, false, ID{Str: "7", IsStr: true}},
+		{`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`, true, ID{}},
+		{`{"jsonrpc":"2.0","method":"window/logMessage","params":{}}`, true, ID{}},
+	}
+	for _, tt := range tests {
+		var msg JsonRpcMessage
+		if err := json.Unmarshal([]byte(tt.frame), &msg); err != nil {
+			t.Fatalf("%s: %v", tt.frame, err)
+		}
+		if tt.wantNil {
+			if msg.Id != nil {
+				t.Errorf("%s: id = %+v, want nil", tt.frame, *msg.Id)
+			}
+			continue
+		}
+		if msg.Id == nil || *msg.Id != tt.want {
+			t.Errorf("%s: id = %+v, want %+v", tt.frame, msg.Id, tt.want)
+		}
+	}
+}
+
+func TestIDInvalid(t *testing.T) {
+	for _, frame := range []string{`1.5`, `true`, `{"a":1}`, `[1]`} {
+		var id ID
+		if err := json.Unmarshal([]byte(frame), &id); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", frame)
+		}
+	}
+}
+
+func TestIDRoundTrip(t *testing.T) {
+	for _, id := range []ID{{Num: 42}, {Str: "abc", IsStr: true}, {Str: "", IsStr: true}} {
+		data, err := json.Marshal(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back ID
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		if back != id {
+			t.Errorf("round trip %+v -> %s -> %+v", id, data, back)
+		}
+	}
+}
+
+func TestIDKey(t *testing.T) {
+	// 服务器把数字 ID 回显为字符串时仍然能匹配到请求
+	if NewIntID(12).Key() != (ID{Str: "12", IsStr: true}).Key() {
+		t.Error("numeric and string ids with the same text should share a key")
+	}
+	if NewIntID(12).Key() == NewIntID(21).Key() {
+		t.Error("different ids share a key")
+	}
+}
+
+func TestResponseErrorDecode(t *testing.T) {
+	frame := `{"jsonrpc":"2.0","id":3,"error":{"code":-32801,"message":"Content modified","data":{"uri":"file:///A.java"}}}`
+	var msg JsonRpcMessage
+	if err := json.Unmarshal([]byte(frame), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Error == nil {
+		t.Fatal("error object not decoded")
+	}
+	if msg.Error.Code != ErrContentModified || msg.Error.Message != "Content modified" {
+		t.Errorf("error = %+v", msg.Error)
+	}
+	if string(msg.Error.Data) != `{"uri":"file:///A.java"}` {
+		t.Errorf("data = %s", msg.Error.Data)
+	}
+	if got, want := msg.Error.Error(), "jsonrpc error -32801: Content modified"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrTimeout, true},
+		{fmt.Errorf("textDocument/references: %w", ErrTimeout), true},
+		{&ResponseError{Code: ErrContentModified}, true},
+		{&ResponseError{Code: ErrServerCancelled}, true},
+		{fmt.Errorf("wrapped: %w", &ResponseError{Code: ErrContentModified}), true},
+		{&ResponseError{Code: ErrRequestCancelled}, false},
+		{&ResponseError{Code: ErrInternal}, false},
+		{&ResponseError{Code: ErrMethodNotFound}, false},
+		{ErrServerExited, false},
+		{fmt.Errorf("textDocument/hover: %w", ErrUnsupported), false},
+		{errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// hover 的三种内容格式都展开为纯文本
+func TestHoverText(t *testing.T) {
+	tests := []struct {
+		contents string
+		want     string
+	}{
+		{`{"kind":"markdown","value":"` + "```java\\njava.io.File f\\n```" + `"}`, "```java\njava.io.File f\n```"},
+		{`{"language":"java","value":"java.io.File f"}`, "java.io.File f"},
+		{`"java.io.File f"`, "java.io.File f"},
+		{`[{"language":"java","value":"java.io.File f"},"An abstract representation of file pathnames."]`, "java.io.File f\nAn abstract representation of file pathnames."},
+		{`[]`, ""},
+	}
+	for _, tt := range tests {
+		var h Hover
+		if err := json.Unmarshal([]byte(`{"contents":`+tt.contents+`}`), &h); err != nil {
+			t.Fatalf("%s: %v", tt.contents, err)
+		}
+		if got := h.Text(); got != tt.want {
+			t.Errorf("%s: Text() = %q, want %q", tt.contents, got, tt.want)
+		}
+	}
+}
+
+// 能力字段可以是 true/false 或选项对象；缺少的字段视为不支持，没有对应字段的请求总是发送
+func TestServerCapabilities(t *testing.T) {
+	var caps ServerCapabilities
+	payload := `{"hoverProvider":false,"definitionProvider":true,"referencesProvider":{"workDoneProgress":true},
+		"documentSymbolProvider":null,"callHierarchyProvider":{}}`
+	if err := json.Unmarshal([]byte(payload), &caps); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"textDocument/hover", false},
+		{"textDocument/definition", true},
+		{"textDocument/references", true},
+		{"textDocument/documentSymbol", false},
+		{"textDocument/implementation", false},
+		{"workspace/symbol", false},
+		{"textDocument/prepareCallHierarchy", true},
+		{"callHierarchy/incomingCalls", true},
+		{"workspace/executeCommand", true},
+		{"textDocument/didOpen", true},
+	}
+	for _, tt := range tests {
+		if got := caps.Supports(tt.method); got != tt.want {
+			t.Errorf("Supports(%s) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+	want := []string{"textDocument/documentSymbol", "textDocument/hover", "workspace/symbol"}
+	if got := caps.Unsupported(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Unsupported() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// WaitReady 返回的就绪信号，按优先级排列
+const (
+	ReadyServiceReady = "language/status ServiceReady" // JDT.LS 的扩展通知
+	ReadyProgress     = "$/progress end"               // 标准的工作进度: 索引任务结束
+	ReadyProbe        = "documentSymbol probe"         // 兜底: 锚点文件的 documentSymbol 返回了符号
+)
+
+// 探测请求的退避: 第一次在 readyProbeDelay 之后，每次间隔翻倍，最长 readyProbeMaxDelay
+const (
+	readyProbeDelay    = 2 * time.Second
+	readyProbeMaxDelay = 8 * time.Second
+)
+
+// indexingTitle 索引类工作进度的标题 (JDT.LS: "Initialize Workspace" / "Importing Maven project(s)"，其它服务器: "Indexing" 等)
+var indexingTitle = regexp.MustCompile(`(?i)index|initiali[sz]|import|loading|building|synchroniz`)
+
+// handleProgress 跟踪 $/progress 中的索引任务: 开始时记录 token，所有记录的任务都结束后关闭 progressReady
+func (c *Client) handleProgress(params interface{}) {
+	p, ok := params.(map[string]interface{})
+	if !ok {
+		return
+	}
+	value, ok := p["value"].(map[string]interface{})
+	if !ok || p["token"] == nil {
+		return
+	}
+	token := fmt.Sprint(p["token"])
+	kind, _ := value["kind"].(string)
+
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	switch kind {
+	case "begin":
+		title, _ := value["title"].(string)
+		if indexingTitle.MatchString(title) {
+			c.indexing[token] = true
+		}
+	case "end":
+		if !c.indexing[token] {
+			return
+		}
+		c.indexing[token] = false
+		for _, active := range c.indexing {
+			if active {
+				return
+			}
+		}
+		c.progressOnce.Do(func() { close(c.progressReady) })
+	}
+}
+
+// indexingActive 是否有尚未结束的索引任务
+func (c *Client) indexingActive() bool {
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	for _, active := range c.indexing {
+		if active {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitReady 等待语言服务器就绪，返回采用的信号 (ReadyServiceReady 等)
+// 优先等待 ServiceReady: 服务器发送过 language/status 时只认 ServiceReady (JDT.LS 的导入和构建是多个连续的进度任务)，
+// 否则索引进度全部结束即可；两种信号都没有时，用 probe 按退避轮询作为最后的手段
+// (服务器正在报告索引进度时不探测: 索引完成之前 documentSymbol 也可能有结果)
+func (c *Client) WaitReady(timeout time.Duration, probe func() bool) (string, error) {
+	fmt.Printf("    -> Waiting for the language server to become ready...\n")
+	deadline := time.After(timeout)
+	progress := c.progressReady
+	delay := readyProbeDelay
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.serviceReady:
+			return ReadyServiceReady, nil
+		case <-progress:
+			if !c.statusSeen.Load() {
+				return ReadyProgress, nil
+			}
+			progress = nil
+		case <-c.done:
+			return "", ErrServerExited
+		case <-deadline:
+			return "", fmt.Errorf("timeout waiting for the language server to become ready")
+		case <-timer.C:
+			if probe != nil && !c.statusSeen.Load() && !c.indexingActive() && probe() {
+				return ReadyProbe, nil
+			}
+			if delay *= 2; delay > readyProbeMaxDelay {
+				delay = readyProbeMaxDelay
+			}
+			timer.Reset(delay)
+		}
+	}
+}
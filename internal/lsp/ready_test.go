@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// notification 让假服务器发送的通知
+type notification struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+func status(kind string) notification {
+	return notification{"language/status", map[string]string{"type": kind, "message": kind}}
+}
+
+func progress(token, kind, title string) notification {
+	value := map[string]string{"kind": kind}
+	if title != "" {
+		value["title"] = title
+	}
+	return notification{"$/progress", map[string]interface{}{"token": token, "value": value}}
+}
+
+// notify 让假服务器依次发送通知；返回时客户端已经处理完这些通知 (通知在响应之前按顺序读取)
+func notify(t *testing.T, c *Client, notes ...notification) {
+	t.Helper()
+	if err := callFake(t, c, "test/notify", notes, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countingProbe 记录调用次数的探测函数
+func countingProbe(result bool) (func() bool, *atomic.Int32) {
+	var calls atomic.Int32
+	return func() bool {
+		calls.Add(1)
+		return result
+	}, &calls
+}
+
+func TestWaitReadyServiceReady(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	notify(t, c, status("Starting"), progress("1", "begin", "Initialize Workspace"), status("ServiceReady"))
+	probe, calls := countingProbe(true)
+	got, err := c.WaitReady(5*time.Second, probe)
+	if err != nil || got != ReadyServiceReady {
+		t.Errorf("WaitReady = %q, %v, want %q", got, err, ReadyServiceReady)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("probe called %d times", calls.Load())
+	}
+}
+
+// 没有 language/status 的服务器: 索引进度全部结束即就绪，与索引无关的进度不算
+func TestWaitReadyProgress(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	notify(t, c,
+		progress("build", "begin", "Indexing"),
+		progress("fmt", "begin", "Formatting"),
+		progress("import", "begin", "Importing Maven project(s)"),
+		progress("build", "end", ""),
+	)
+	if !c.indexingActive() {
+		t.Fatal("indexing finished before all indexing tokens ended")
+	}
+	if got, err := c.WaitReady(200*time.Millisecond, nil); err == nil {
+		t.Fatalf("ready (%s) while an indexing token is still active", got)
+	}
+
+	notify(t, c, progress("import", "end", ""))
+	got, err := c.WaitReady(5*time.Second, nil)
+	if err != nil || got != ReadyProgress {
+		t.Errorf("WaitReady = %q, %v, want %q", got, err, ReadyProgress)
+	}
+}
+
+// 发送过 language/status 的服务器只认 ServiceReady: JDT.LS 的导入和构建是多个连续的进度任务
+func TestWaitReadyProgressAfterStatus(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	notify(t, c, status("Starting"), progress("1", "begin", "Initialize Workspace"), progress("1", "end", ""))
+	probe, calls := countingProbe(true)
+	if got, err := c.WaitReady(2500*time.Millisecond, probe); err == nil {
+		t.Fatalf("ready (%s) before ServiceReady", got)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("probe called %d times after language/status", calls.Load())
+	}
+
+	notify(t, c, status("ServiceReady"))
+	if got, err := c.WaitReady(5*time.Second, probe); err != nil || got != ReadyServiceReady {
+		t.Errorf("WaitReady = %q, %v, want %q", got, err, ReadyServiceReady)
+	}
+}
+
+// 两种信号都没有时按退避轮询探测
+func TestWaitReadyProbe(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	probe, calls := countingProbe(true)
+	start := time.Now()
+	got, err := c.WaitReady(10*time.Second, probe)
+	if err != nil || got != ReadyProbe {
+		t.Fatalf("WaitReady = %q, %v, want %q", got, err, ReadyProbe)
+	}
+	if elapsed := time.Since(start); elapsed < readyProbeDelay {
+		t.Errorf("probed after %v, before the first backoff of %v", elapsed, readyProbeDelay)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("probe called %d times", calls.Load())
+	}
+}
+
+// 服务器正在报告索引进度时不探测: 索引完成之前 documentSymbol 也可能有结果
+func TestWaitReadyProbeSuppressedWhileIndexing(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	notify(t, c, progress("1", "begin", "Indexing"))
+	probe, calls := countingProbe(true)
+	_, err := c.WaitReady(readyProbeDelay+500*time.Millisecond, probe)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("WaitReady error = %v, want a timeout", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("probe called %d times while indexing", calls.Load())
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	probe, calls := countingProbe(false)
+	_, err := c.WaitReady(readyProbeDelay+500*time.Millisecond, probe)
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for the language server") {
+		t.Errorf("WaitReady error = %v, want a timeout", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("probe called %d times, want 1", calls.Load())
+	}
+}
+
+func TestWaitReadyServerExited(t *testing.T) {
+	c := startFakeServer(t, "ready")
+	c.Close()
+	if _, err := c.WaitReady(5*time.Second, nil); err != ErrServerExited {
+		t.Errorf("WaitReady error = %v, want %v", err, ErrServerExited)
+	}
+}
@@ -0,0 +1,69 @@
+package lsp
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultStderrLines 保留的语言服务器 stderr 行数 (启动失败时输出摘录并写入日志文件)
+const DefaultStderrLines = 200
+
+// stderrDrainWait stdout 关闭后等待 stderr 读取完的最长时间
+const stderrDrainWait = time.Second
+
+// lineRing 只保留最后 size 行的环形缓冲区
+type lineRing struct {
+	mu    sync.Mutex
+	lines []string
+	next  int // 下一行写入的位置
+	total int // 收到的总行数 (包括被覆盖的)
+}
+
+func newLineRing(size int) *lineRing {
+	return &lineRing{lines: make([]string, 0, size)}
+}
+
+// Add 追加一行，缓冲区满时覆盖最早的一行
+func (r *lineRing) Add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total++
+	if len(r.lines) < cap(r.lines) {
+		r.lines = append(r.lines, line)
+		return
+	}
+	if len(r.lines) == 0 {
+		return
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+}
+
+// Lines 按时间顺序返回保留的行，以及收到的总行数
+func (r *lineRing) Lines() ([]string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(r.lines))
+	out = append(out, r.lines[r.next:]...)
+	out = append(out, r.lines[:r.next]...)
+	return out, r.total
+}
+
+// capture 按行读取 rd 直到 EOF，然后关闭 done
+func (r *lineRing) capture(rd io.Reader, done chan<- struct{}) {
+	defer close(done)
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		r.Add(scanner.Text())
+	}
+	// 超长的行让 Scanner 停止: 继续读完，避免服务器写 stderr 时阻塞
+	io.Copy(io.Discard, rd)
+}
+
+// Stderr 返回语言服务器 stderr 的最后若干行 (按时间顺序) 和总行数
+func (c *Client) Stderr() ([]string, int) {
+	return c.stderr.Lines()
+}
@@ -0,0 +1,85 @@
+package lsp
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineRingWraparound(t *testing.T) {
+	tests := []struct {
+		size  int
+		added int
+		want  []string
+	}{
+		{3, 0, []string{}},
+		{3, 2, []string{"line 1", "line 2"}},
+		{3, 3, []string{"line 1", "line 2", "line 3"}},
+		{3, 4, []string{"line 2", "line 3", "line 4"}}, // 覆盖最早的一行
+		{3, 7, []string{"line 5", "line 6", "line 7"}}, // 绕回不止一圈
+		{3, 9, []string{"line 7", "line 8", "line 9"}}, // 写入位置正好回到开头
+		{1, 5, []string{"line 5"}},
+		{0, 2, []string{}}, // 不保留任何行，只计数
+	}
+	for _, tt := range tests {
+		r := newLineRing(tt.size)
+		for i := 1; i <= tt.added; i++ {
+			r.Add(fmt.Sprintf("line %d", i))
+		}
+		lines, total := r.Lines()
+		if !slices.Equal(lines, tt.want) || total != tt.added {
+			t.Errorf("size %d after %d lines: %q (total %d), want %q (total %d)", tt.size, tt.added, lines, total, tt.want, tt.added)
+		}
+	}
+}
+
+// capture 按行切分: 没有换行结尾的最后一行、CRLF 和空行都保留，超长的行之后的内容被读完丢弃
+func TestLineRingCapture(t *testing.T) {
+	long := strings.Repeat("x", 2*1024*1024)
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		total int
+	}{
+		{"partial last line", "Starting\nError occurred during initialization of VM", []string{"Starting", "Error occurred during initialization of VM"}, 2},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}, 2},
+		{"empty lines", "a\n\nb\n", []string{"a", "", "b"}, 3},
+		{"no output", "", []string{}, 0},
+		{"overlong line", "first\n" + long + "\nafter\n", []string{"first"}, 1},
+	}
+	for _, tt := range tests {
+		r := newLineRing(10)
+		done := make(chan struct{})
+		pr, pw := io.Pipe()
+		go r.capture(pr, done)
+		go func() {
+			io.WriteString(pw, tt.input)
+			pw.Close()
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: capture did not finish (writer blocked)", tt.name)
+		}
+		lines, total := r.Lines()
+		if !slices.Equal(lines, tt.want) || total != tt.total {
+			t.Errorf("%s: %d lines %q (total %d), want %q (total %d)", tt.name, len(lines), truncateLines(lines), total, tt.want, tt.total)
+		}
+	}
+}
+
+// truncateLines 错误信息中截短超长的行
+func truncateLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) > 40 {
+			l = l[:40] + "..."
+		}
+		out[i] = l
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package lsp
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// DecodeDocumentSymbols 解析 textDocument/documentSymbol 的响应
+// 服务器可能返回层级的 DocumentSymbol[] 或扁平的 SymbolInformation[] (带 location 字段)，
+// 后者会被转换为层级结构。lines 为文档内容，用于推算扁平格式缺失的 selectionRange，可为 nil。
+func DecodeDocumentSymbols(raw json.RawMessage, lines []string) ([]DocumentSymbol, error) {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	if _, flat := items[0]["location"]; !flat {
+		var symbols []DocumentSymbol
+		if err := json.Unmarshal(raw, &symbols); err != nil {
+			return nil, err
+		}
+		return symbols, nil
+	}
+
+	var infos []SymbolInformation
+	if err := json.Unmarshal(raw, &infos); err != nil {
+		return nil, err
+	}
+	return NestSymbolInformation(infos, lines), nil
+}
+
+// NestSymbolInformation 根据范围包含关系 (以及 containerName 兜底) 将扁平符号嵌套为树
+func NestSymbolInformation(infos []SymbolInformation, lines []string) []DocumentSymbol {
+	type node struct {
+		sym      DocumentSymbol
+		children []int
+	}
+
+	nodes := make([]node, len(infos))
+	order := make([]int, len(infos))
+	for i, info := range infos {
+		nodes[i].sym = DocumentSymbol{
+			Name:           info.Name,
+			Kind:           info.Kind,
+			Range:          info.Location.Range,
+			SelectionRange: guessSelectionRange(info, lines),
+		}
+		order[i] = i
+	}
+
+	// 按起始位置升序、范围大小降序排列，保证父节点先于子节点出现
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := infos[order[a]].Location.Range, infos[order[b]].Location.Range
+		if ra.Start != rb.Start {
+			return positionBefore(ra.Start, rb.Start)
+		}
+		return positionBefore(rb.End, ra.End)
+	})
+
+	var roots []int
+	var stack []int
+	lastByName := make(map[string]int)
+
+	for _, idx := range order {
+		r := infos[idx].Location.Range
+		for len(stack) > 0 && !rangeContains(infos[stack[len(stack)-1]].Location.Range, r) {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := -1
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		} else if container := infos[idx].ContainerName; container != "" {
+			// 部分服务器只返回符号名的范围，无法通过包含关系判断父子，退回 containerName
+			if p, ok := lastByName[simpleSymbolName(container)]; ok {
+				parent = p
+			}
+		}
+
+		if parent == -1 {
+			roots = append(roots, idx)
+		} else {
+			nodes[parent].children = append(nodes[parent].children, idx)
+		}
+		stack = append(stack, idx)
+		lastByName[simpleSymbolName(infos[idx].Name)] = idx
+	}
+
+	var build func(idx int) DocumentSymbol
+	build = func(idx int) DocumentSymbol {
+		sym := nodes[idx].sym
+		for _, c := range nodes[idx].children {
+			sym.Children = append(sym.Children, build(c))
+		}
+		return sym
+	}
+
+	result := make([]DocumentSymbol, 0, len(roots))
+	for _, r := range roots {
+		result = append(result, build(r))
+	}
+	return result
+}
+
+// guessSelectionRange 在符号范围内查找符号名出现的位置，找不到时使用范围起点
+func guessSelectionRange(info SymbolInformation, lines []string) Range {
+	r := info.Location.Range
+	name := simpleSymbolName(info.Name)
+	if name != "" {
+		for l := r.Start.Line; l <= r.End.Line && l < len(lines); l++ {
+			from := 0
+			if l == r.Start.Line {
+				from = r.Start.Character
+			}
+			if from > len(lines[l]) {
+				continue
+			}
+			if col := strings.Index(lines[l][from:], name); col != -1 {
+				start := Position{Line: l, Character: from + col}
+				return Range{Start: start, End: Position{Line: l, Character: start.Character + len(name)}}
+			}
+		}
+	}
+	return Range{Start: r.Start, End: r.Start}
+}
+
+// simpleSymbolName 去掉参数签名和外层类名: "com.a.Foo.bar(String)" -> "bar"
+func simpleSymbolName(name string) string {
+	if idx := strings.Index(name, "("); idx != -1 {
+		name = name[:idx]
+	}
+	if idx := strings.LastIndex(name, "."); idx != -1 {
+		name = name[idx+1:]
+	}
+	return strings.TrimSpace(name)
+}
+
+func positionBefore(a, b Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
+
+// rangeContains outer 是否完整包含 inner (相同范围不算包含)
+func rangeContains(outer, inner Range) bool {
+	if outer == inner {
+		return false
+	}
+	return !positionBefore(inner.Start, outer.Start) && !positionBefore(outer.End, inner.End)
+}
@@ -0,0 +1,166 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// loadSymbolFixture 读取 testdata/symbols 中记录的 documentSymbol 响应和对应的源码
+func loadSymbolFixture(t *testing.T, name string) (json.RawMessage, []string) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "symbols", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join("testdata", "symbols", "OrderService.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw, strings.Split(string(src), "\n")
+}
+
+// outline 把符号树展开为 "父/子@起始行" 的列表，便于比较结构
+func outline(symbols []DocumentSymbol, prefix string) []string {
+	var out []string
+	for _, s := range symbols {
+		path := prefix + s.Name
+		out = append(out, path+"@"+strconv.Itoa(s.Range.Start.Line))
+		out = append(out, outline(s.Children, path+"/")...)
+	}
+	return out
+}
+
+// findSymbol 按路径 ("OrderService/Worker/run(String)") 查找符号
+func findSymbol(symbols []DocumentSymbol, path string) *DocumentSymbol {
+	name, rest, nested := strings.Cut(path, "/")
+	for i := range symbols {
+		if symbols[i].Name != name {
+			continue
+		}
+		if !nested {
+			return &symbols[i]
+		}
+		return findSymbol(symbols[i].Children, rest)
+	}
+	return nil
+}
+
+var orderServiceOutline = []string{
+	"OrderService@2",
+	"OrderService/task@3",
+	"OrderService/static {...}@5",
+	"OrderService/OrderService()@9",
+	"OrderService/process(String)@13",
+	"OrderService/process(String, int)@17",
+	"OrderService/process(String, int)/new Runnable() {...}@21",
+	"OrderService/process(String, int)/new Runnable() {...}/run()@22",
+	"OrderService/Worker@28",
+	"OrderService/Worker/run(String)@29",
+}
+
+func TestDecodeDocumentSymbolsHierarchical(t *testing.T) {
+	raw, lines := loadSymbolFixture(t, "hierarchical.json")
+	symbols, err := DecodeDocumentSymbols(raw, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outline(symbols, ""); strings.Join(got, "\n") != strings.Join(orderServiceOutline, "\n") {
+		t.Errorf("outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(orderServiceOutline, "\n"))
+	}
+	run := findSymbol(symbols, "OrderService/Worker/run(String)")
+	if run == nil || run.SelectionRange.Start != (Position{Line: 29, Character: 13}) {
+		t.Errorf("run(String) = %+v", run)
+	}
+}
+
+func TestDecodeDocumentSymbolsFlat(t *testing.T) {
+	raw, lines := loadSymbolFixture(t, "flat.json")
+	symbols, err := DecodeDocumentSymbols(raw, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := outline(symbols, ""); strings.Join(got, "\n") != strings.Join(orderServiceOutline, "\n") {
+		t.Errorf("outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(orderServiceOutline, "\n"))
+	}
+
+	// 扁平格式没有 selectionRange: 从源码中找到的名字位置应与层级格式一致
+	hraw, _ := loadSymbolFixture(t, "hierarchical.json")
+	hier, _ := DecodeDocumentSymbols(hraw, nil)
+	for _, path := range []string{
+		"OrderService",
+		"OrderService/OrderService()",
+		"OrderService/process(String)",
+		"OrderService/process(String, int)",
+		"OrderService/process(String, int)/new Runnable() {...}/run()",
+		"OrderService/Worker",
+		"OrderService/Worker/run(String)",
+	} {
+		got, want := findSymbol(symbols, path), findSymbol(hier, path)
+		if got == nil || want == nil {
+			t.Fatalf("%s: missing symbol", path)
+		}
+		if got.SelectionRange != want.SelectionRange || got.Range != want.Range || got.Kind != want.Kind {
+			t.Errorf("%s: got %+v, want %+v", path, *got, *want)
+		}
+	}
+}
+
+func TestDecodeDocumentSymbolsContainerName(t *testing.T) {
+	// 范围只覆盖符号名时无法按包含关系嵌套，按 containerName 挂到最近的同名类下
+	raw, lines := loadSymbolFixture(t, "flat_name_ranges.json")
+	symbols, err := DecodeDocumentSymbols(raw, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"OrderService@2",
+		"OrderService/process(String)@13",
+		"OrderService/Worker@28",
+		"OrderService/Worker/run(String)@29",
+	}
+	if got := outline(symbols, ""); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("outline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDecodeDocumentSymbolsEmpty(t *testing.T) {
+	for _, raw := range []string{`null`, `[]`} {
+		symbols, err := DecodeDocumentSymbols(json.RawMessage(raw), nil)
+		if err != nil || symbols != nil {
+			t.Errorf("%s: symbols = %v, err = %v", raw, symbols, err)
+		}
+	}
+	if _, err := DecodeDocumentSymbols(json.RawMessage(`{"name":"x"}`), nil); err == nil {
+		t.Error("decoding an object succeeded, want error")
+	}
+}
+
+func TestGuessSelectionRangeWithoutSource(t *testing.T) {
+	info := SymbolInformation{
+		Name:     "process(String)",
+		Location: Location{Range: Range{Start: Position{Line: 13, Character: 4}, End: Position{Line: 15, Character: 5}}},
+	}
+	want := Range{Start: Position{Line: 13, Character: 4}, End: Position{Line: 13, Character: 4}}
+	if got := guessSelectionRange(info, nil); got != want {
+		t.Errorf("guessSelectionRange = %+v, want %+v", got, want)
+	}
+}
+
+func TestSimpleSymbolName(t *testing.T) {
+	tests := map[string]string{
+		"bar":                      "bar",
+		"bar(String)":              "bar",
+		"com.a.Foo.bar(String)":    "bar",
+		"com.example.OrderService": "OrderService",
+		"process(String, int)":     "process",
+	}
+	for in, want := range tests {
+		if got := simpleSymbolName(in); got != want {
+			t.Errorf("simpleSymbolName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package com.example;
+
+public class OrderService {
+    private final Runnable task = () -> process("init");
+
+    static {
+        System.loadLibrary("orders");
+    }
+
+    public OrderService() {
+        init();
+    }
+
+    public void process(String id) {
+        run(id);
+    }
+
+    public void process(String id, int n) {
+        items.forEach(x -> {
+            run(x);
+        });
+        executor.submit(new Runnable() {
+            public void run() {
+                exec(id);
+            }
+        });
+    }
+
+    static class Worker {
+        void run(String cmd) {
+            exec(cmd);
+        }
+    }
+}
@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultLogPayloadSize LSP 流量日志中单条消息内容的默认截断长度 (字节)
+const DefaultLogPayloadSize = 2048
+
+// trafficLog 将收发的每条 JSON-RPC 消息以 JSON Lines 形式追加到文件，用于调试
+type trafficLog struct {
+	mu         sync.Mutex
+	file       *os.File
+	maxPayload int
+	inflight   map[string]sentRequest // 请求 ID -> 发送信息，用于计算延迟
+}
+
+type sentRequest struct {
+	method string
+	at     time.Time
+}
+
+type trafficEntry struct {
+	Time      string  `json:"time"`
+	Direction string  `json:"direction"` // "send" / "recv"
+	Method    string  `json:"method,omitempty"`
+	Id        *ID     `json:"id,omitempty"`
+	Payload   string  `json:"payload,omitempty"` // params / result / error，超长时截断
+	Truncated bool    `json:"truncated,omitempty"`
+	LatencyMs float64 `json:"latency_ms,omitempty"` // 仅响应: 与对应请求的时间差
+}
+
+// EnableTrafficLog 开启 LSP 流量日志，maxPayload <= 0 时使用默认截断长度
+// 需要在发送 initialize 之前调用，才能记录完整的会话
+func (c *Client) EnableTrafficLog(path string, maxPayload int) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if maxPayload <= 0 {
+		maxPayload = DefaultLogPayloadSize
+	}
+	c.traffic.Store(&trafficLog{
+		file:       f,
+		maxPayload: maxPayload,
+		inflight:   make(map[string]sentRequest),
+	})
+	return nil
+}
+
+// record 记录一条消息；body 为完整的 JSON-RPC 报文
+func (l *trafficLog) record(direction string, body []byte) {
+	if l == nil {
+		return
+	}
+
+	var msg struct {
+		Id     *ID             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return
+	}
+
+	now := time.Now()
+	entry := trafficEntry{
+		Time:      now.Format(time.RFC3339Nano),
+		Direction: direction,
+		Method:    msg.Method,
+		Id:        msg.Id,
+	}
+
+	payload := msg.Params
+	if msg.Error != nil {
+		payload = msg.Error
+	} else if msg.Result != nil {
+		payload = msg.Result
+	}
+	entry.Payload = string(payload)
+	if len(entry.Payload) > l.maxPayload {
+		entry.Payload = entry.Payload[:l.maxPayload]
+		entry.Truncated = true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if msg.Id != nil {
+		key := msg.Id.Key()
+		if msg.Method != "" && direction == "send" {
+			l.inflight[key] = sentRequest{method: msg.Method, at: now}
+		} else if msg.Method == "" && direction == "recv" {
+			if req, ok := l.inflight[key]; ok {
+				entry.Method = req.method
+				entry.LatencyMs = float64(now.Sub(req.at).Microseconds()) / 1000
+				delete(l.inflight, key)
+			}
+		}
+	}
+
+	line, _ := json.Marshal(entry)
+	l.file.Write(append(line, '\n'))
+}
+
+func (l *trafficLog) close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file.Close()
+}
@@ -13,40 +13,109 @@ func ToUri(path string) string {
 	if err != nil {
 		abs = path
 	}
-	
-	// Windows 补丁: C:\Users -> /C:/Users
-	if runtime.GOOS == "windows" {
-		abs = "/" + strings.ReplaceAll(abs, "\\", "/")
+	return pathToURI(abs, runtime.GOOS == "windows")
+}
+
+// FromUri 将 URI 转换为本地文件路径
+func FromUri(uriStr string) string {
+	return uriToPath(uriStr, runtime.GOOS == "windows")
+}
+
+// NormalizePath 用于比较路径时忽略大小写差异 (针对 Mac/Windows)
+// 所有以路径为键的 map (已访问的调用点、已处理的 Sink、打开的文档) 都应当使用它的结果
+func NormalizePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
 	}
+	return normalizePath(abs, runtime.GOOS == "windows" || runtime.GOOS == "darwin")
+}
 
-	// 使用 url.PathEscape 处理空格等特殊字符
-	u := url.URL{
-		Scheme: "file",
-		Path:   abs,
+// pathToURI 按 LSP / VS Code 的约定把绝对路径转换为 file URI (与运行平台无关，windows 表示按 Windows 路径解析):
+//
+//	/home/a b/X.java          -> file:///home/a%20b/X.java
+//	c:\Users\X.java          -> file:///C:/Users/X.java (盘符大写)
+//	\\server\share\X.java     -> file://server/share/X.java (UNC 路径的主机名放在 authority 中)
+//
+// 空格、% 和非 ASCII 字符 (e.g. 中文目录名) 按 UTF-8 百分号编码
+func pathToURI(path string, windows bool) string {
+	host := ""
+	if windows {
+		path = strings.ReplaceAll(path, "\\", "/")
+		if strings.HasPrefix(path, "//") {
+			// UNC: //server/share/dir
+			rest := path[2:]
+			if i := strings.Index(rest, "/"); i != -1 {
+				host, path = rest[:i], rest[i:]
+			} else {
+				host, path = rest, "/"
+			}
+		} else if hasDriveLetter(path) {
+			path = "/" + strings.ToUpper(path[:1]) + path[1:]
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
 	}
+	u := url.URL{Scheme: "file", Host: host, Path: path}
 	return u.String()
 }
 
-// FromUri 将 URI 转换为本地文件路径
-func FromUri(uriStr string) string {
+// uriToPath 把 file URI 转换为本地路径 (与运行平台无关，windows 表示生成 Windows 路径)
+// 百分号编码被解码，Windows 盘符统一为大写，带主机名的 URI 转换为 UNC 路径；无法解析时原样返回
+func uriToPath(uriStr string, windows bool) string {
 	u, err := url.Parse(uriStr)
 	if err != nil {
 		return uriStr // 解析失败原样返回
 	}
-	
+
 	path := u.Path
-	// Windows 补丁: /C:/Users -> C:\Users
-	if runtime.GOOS == "windows" && strings.HasPrefix(path, "/") {
-		path = strings.TrimPrefix(path, "/")
+	if u.Opaque != "" {
+		// file:C:/x 这样没有 // 的写法，路径在 Opaque 中且没有解码
+		if p, err := url.PathUnescape(u.Opaque); err == nil {
+			path = p
+		} else {
+			path = u.Opaque
+		}
+	}
+	// 只有 file URI 的主机名表示 UNC 路径 (jdt:// 等 URI 的 authority 不是主机)
+	host := u.Host
+	if u.Scheme != "file" || strings.EqualFold(host, "localhost") {
+		host = ""
+	}
+
+	if !windows {
+		if host != "" {
+			return "//" + host + path
+		}
+		return path
+	}
+
+	// Windows: /c:/Users -> C:\Users, file://server/share -> \\server\share
+	if host == "" {
+		trimmed := strings.TrimPrefix(path, "/")
+		if hasDriveLetter(trimmed) {
+			path = strings.ToUpper(trimmed[:1]) + trimmed[1:]
+		}
+	} else {
+		path = "//" + host + path
 	}
-	return filepath.FromSlash(path)
+	return strings.ReplaceAll(path, "/", "\\")
 }
 
-// NormalizePath 用于比较路径时忽略大小写差异 (针对 Mac/Windows)
-func NormalizePath(path string) string {
-	abs, _ := filepath.Abs(path)
-	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+// hasDriveLetter 路径是否以 Windows 盘符开头 (e.g. "C:" / "c:/")
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// normalizePath 比较用的路径: foldCase 时忽略大小写 (大小写不敏感的文件系统)
+func normalizePath(abs string, foldCase bool) string {
+	if foldCase {
 		return strings.ToLower(abs)
 	}
 	return abs
-}
\ No newline at end of file
+}
@@ -0,0 +1,90 @@
+package lsp
+
+import "testing"
+
+func TestPathToURI(t *testing.T) {
+	tests := []struct {
+		path    string
+		windows bool
+		want    string
+	}{
+		{"/home/dev/src/App.java", false, "file:///home/dev/src/App.java"},
+		{"/home/my project/App.java", false, "file:///home/my%20project/App.java"},
+		{"/home/dev/100%/App.java", false, "file:///home/dev/100%25/App.java"},
+		{"/home/开发/源码/App.java", false, "file:///home/%E5%BC%80%E5%8F%91/%E6%BA%90%E7%A0%81/App.java"},
+		{`C:\Users\dev\App.java`, true, "file:///C:/Users/dev/App.java"},
+		{`c:\Users\dev\App.java`, true, "file:///C:/Users/dev/App.java"},
+		{`d:/work/App.java`, true, "file:///D:/work/App.java"},
+		{`C:\Program Files\app\App.java`, true, "file:///C:/Program%20Files/app/App.java"},
+		{`C:\代码\项目\App.java`, true, "file:///C:/%E4%BB%A3%E7%A0%81/%E9%A1%B9%E7%9B%AE/App.java"},
+		{`\\fileserver\share\src\App.java`, true, "file://fileserver/share/src/App.java"},
+		{`\\fileserver\my share\App.java`, true, "file://fileserver/my%20share/App.java"},
+		{`\\fileserver`, true, "file://fileserver/"},
+	}
+	for _, tt := range tests {
+		if got := pathToURI(tt.path, tt.windows); got != tt.want {
+			t.Errorf("pathToURI(%q, %v) = %q, want %q", tt.path, tt.windows, got, tt.want)
+		}
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	tests := []struct {
+		uri     string
+		windows bool
+		want    string
+	}{
+		{"file:///home/dev/src/App.java", false, "/home/dev/src/App.java"},
+		{"file:///home/my%20project/App.java", false, "/home/my project/App.java"},
+		{"file:///home/%E5%BC%80%E5%8F%91/App.java", false, "/home/开发/App.java"},
+		{"file:///home/开发/App.java", false, "/home/开发/App.java"},
+		{"file://localhost/home/dev/App.java", false, "/home/dev/App.java"},
+		{"file://fileserver/share/App.java", false, "//fileserver/share/App.java"},
+		{"file:///C:/Users/dev/App.java", true, `C:\Users\dev\App.java`},
+		{"file:///c:/Users/dev/App.java", true, `C:\Users\dev\App.java`},
+		{"file:///c%3A/Users/dev/App.java", true, `C:\Users\dev\App.java`},
+		{"file:///C:/Program%20Files/App.java", true, `C:\Program Files\App.java`},
+		{"file:///C:/%E4%BB%A3%E7%A0%81/App.java", true, `C:\代码\App.java`},
+		{"file:c:/work/App.java", true, `C:\work\App.java`},
+		{"file://fileserver/share/src/App.java", true, `\\fileserver\share\src\App.java`},
+		{"file://fileserver/my%20share/App.java", true, `\\fileserver\my share\App.java`},
+		{"file://localhost/C:/work/App.java", true, `C:\work\App.java`},
+		// 非 file URI 的 authority 不是 UNC 主机名
+		{"jdt://contents/rt.jar/java.lang/String.class", false, "/rt.jar/java.lang/String.class"},
+	}
+	for _, tt := range tests {
+		if got := uriToPath(tt.uri, tt.windows); got != tt.want {
+			t.Errorf("uriToPath(%q, %v) = %q, want %q", tt.uri, tt.windows, got, tt.want)
+		}
+	}
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	tests := []struct {
+		path    string
+		windows bool
+	}{
+		{"/home/my project/源码/App.java", false},
+		{"/tmp/a#b/c?d/App.java", false},
+		{`C:\Users\开发 者\App.java`, true},
+		{`\\fileserver\share\深 层\App.java`, true},
+	}
+	for _, tt := range tests {
+		uri := pathToURI(tt.path, tt.windows)
+		if got := uriToPath(uri, tt.windows); got != tt.path {
+			t.Errorf("%q -> %q -> %q", tt.path, uri, got)
+		}
+	}
+}
+
+func TestNormalizePathKeys(t *testing.T) {
+	// 大小写不敏感的文件系统上，盘符和目录的大小写差异得到相同的键
+	a := normalizePath(uriToPath("file:///c:/Work/App.java", true), true)
+	b := normalizePath(`C:\work\App.java`, true)
+	if a != b {
+		t.Errorf("keys differ: %q vs %q", a, b)
+	}
+	if normalizePath("/Work/App.java", false) == normalizePath("/work/App.java", false) {
+		t.Error("case-sensitive keys should differ")
+	}
+}
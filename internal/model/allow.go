@@ -0,0 +1,151 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// 抑制动作
+const (
+	AllowSuppress  = "suppress"  // 从结果中移出，只在报告的 Suppressed 一节列出
+	AllowDowngrade = "downgrade" // 保留在结果中，有效等级降低一级
+)
+
+// 抑制的来源
+const (
+	SuppressedByAllowlist = "allowlist" // 规则文件的 allow 段
+	SuppressedByInline    = "inline"    // Sink 行上的 lsptracer:ignore 注释
+)
+
+// AllowRule 规则文件 allow 段中的一条白名单 (已知安全的封装)
+// 链路中任意一步位于 class (可选 method) 中，或 Sink 文件匹配 path 时生效；class 和 path 同时设置时都需要匹配
+type AllowRule struct {
+	Class  string `yaml:"class,omitempty"`  // 类名，全限定名或简单类名
+	Method string `yaml:"method,omitempty"` // 方法名，为空表示类中的所有方法
+	Path   string `yaml:"path,omitempty"`   // 相对项目根目录的 glob，支持 ** (e.g. "**/SafeCommandRunner.java")
+	Action string `yaml:"action,omitempty"` // suppress (默认) 或 downgrade
+	Reason string `yaml:"reason,omitempty"` // 说明，写入报告
+}
+
+// Suppression 一条发现被抑制或降级的依据 (仅 Sink 步骤)
+type Suppression struct {
+	Action string // AllowSuppress / AllowDowngrade / AllowExpired
+	Source string // SuppressedByAllowlist / SuppressedByInline
+	Reason string // 匹配的白名单条目或注释原文
+	Until  string // 行内注释的有效期 (YYYY-MM-DD)，为空表示不过期
+}
+
+// Validate 检查白名单条目并补全默认动作
+func (a *AllowRule) Validate() error {
+	if a.Class == "" && a.Path == "" {
+		return fmt.Errorf("allow entry needs a class or a path")
+	}
+	if a.Method != "" && a.Class == "" {
+		return fmt.Errorf("allow entry for method %q needs a class", a.Method)
+	}
+	switch a.Action {
+	case "":
+		a.Action = AllowSuppress
+	case AllowSuppress, AllowDowngrade:
+	default:
+		return fmt.Errorf("unknown allow action %q (use %s or %s)", a.Action, AllowSuppress, AllowDowngrade)
+	}
+	if a.Path != "" {
+		if _, err := globRegexp(a.Path); err != nil {
+			return fmt.Errorf("invalid allow path %q: %v", a.Path, err)
+		}
+	}
+	return nil
+}
+
+// Label 白名单条目的展示形式 (e.g. "class com.acme.SafeCommandRunner.run")
+func (a AllowRule) Label() string {
+	var parts []string
+	if a.Class != "" {
+		target := a.Class
+		if a.Method != "" {
+			target += "." + a.Method
+		}
+		parts = append(parts, "class "+target)
+	}
+	if a.Path != "" {
+		parts = append(parts, "path "+a.Path)
+	}
+	label := strings.Join(parts, ", ")
+	if a.Reason != "" {
+		label += " (" + a.Reason + ")"
+	}
+	return label
+}
+
+// MatchPath relPath (相对项目根目录，/ 分隔) 是否匹配 Path；没有设置 Path 时返回 true
+func (a AllowRule) MatchPath(relPath string) bool {
+	if a.Path == "" {
+		return true
+	}
+	re, err := globRegexp(a.Path)
+	return err == nil && re.MatchString(relPath)
+}
+
+// MatchMethod 声明在 pkg 包的 class 类中的方法 method 是否匹配 Class/Method；没有设置 Class 时返回 true
+func (a AllowRule) MatchMethod(pkg, class, method string) bool {
+	if a.Class == "" {
+		return true
+	}
+	want := a.Class
+	if i := strings.LastIndex(want, "."); i != -1 {
+		if pkg != "" && want[:i] != pkg {
+			return false
+		}
+		want = want[i+1:]
+	}
+	if want != class {
+		return false
+	}
+	return a.Method == "" || a.Method == method
+}
+
+// globRegexp 把 glob 转为正则: ** 匹配任意层目录，* 和 ? 不跨越 /
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimPrefix(path.Clean("/"+pattern), "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// LoadAllowRules 读取规则文件的 allow 段 (path 为空时返回 nil)
+func LoadAllowRules(path string) ([]AllowRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range file.Allow {
+		if err := file.Allow[i].Validate(); err != nil {
+			return nil, fmt.Errorf("allow[%d]: %v", i, err)
+		}
+	}
+	return file.Allow, nil
+}
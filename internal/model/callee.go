@@ -0,0 +1,25 @@
+package model
+
+// CalleeNode 向下追踪 (-direction down) 得到的调用树节点
+// 根节点是目标行所在的方法；项目内的方法节点指向方法声明，命中 Sink 规则的节点指向调用处
+type CalleeNode struct {
+	Func     string
+	File     string
+	Line     int       // 0-based
+	Code     string    // 调用处的代码 (根节点为目标行)
+	Rule     *SinkRule // 命中的 Sink 规则
+	Note     string    // 没有继续展开的原因 (递归、深度上限、已在别处展开)
+	Children []*CalleeNode
+}
+
+// Sinks 子树中命中 Sink 规则的节点数量
+func (n *CalleeNode) Sinks() int {
+	count := 0
+	if n.Rule != nil {
+		count++
+	}
+	for _, c := range n.Children {
+		count += c.Sinks()
+	}
+	return count
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 发现所在代码的类别
+const (
+	CodeMain   = "MAIN"   // 生产代码
+	CodeTest   = "TEST"   // 测试代码 (src/test、src/integrationTest、src/testFixtures 等)
+	CodeSample = "SAMPLE" // 示例和演示代码 (samples/、demo/、examples/ 等目录)
+)
+
+// CodeKindDescriptions 报告中展示的类别说明
+var CodeKindDescriptions = map[string]string{
+	CodeTest:   "test code",
+	CodeSample: "sample code",
+}
+
+// sampleDirs 约定的示例代码目录名；只在源码根目录 (src/ 或 java/) 之上检查，
+// 避免 com/example/demo 这样的包名把生产代码当作示例
+var sampleDirs = map[string]bool{
+	"sample": true, "samples": true,
+	"demo": true, "demos": true,
+	"example": true, "examples": true,
+}
+
+// CodePaths -test-paths / -sample-paths 在约定之外追加的 glob (相对项目根目录，** 匹配任意层目录)
+type CodePaths struct {
+	Test   []*regexp.Regexp
+	Sample []*regexp.Regexp
+}
+
+// NewCodePaths 编译追加的 glob
+func NewCodePaths(test, sample []string) (CodePaths, error) {
+	var paths CodePaths
+	compile := func(patterns []string, out *[]*regexp.Regexp) error {
+		for _, p := range patterns {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			re, err := globRegexp(strings.TrimPrefix(p, "/"))
+			if err != nil {
+				return fmt.Errorf("invalid path glob %q: %v", p, err)
+			}
+			*out = append(*out, re)
+		}
+		return nil
+	}
+	if err := compile(test, &paths.Test); err != nil {
+		return CodePaths{}, err
+	}
+	if err := compile(sample, &paths.Sample); err != nil {
+		return CodePaths{}, err
+	}
+	return paths, nil
+}
+
+// ClassifyCode 按 Maven/Gradle 的目录约定和追加的 glob 判断文件属于生产、测试还是示例代码
+// relPath 相对项目根目录，/ 分隔；测试优先于示例 (samples/app/src/test 中的文件是测试代码)
+func ClassifyCode(relPath string, paths CodePaths) string {
+	segments := strings.Split(relPath, "/")
+	dirs := segments[:len(segments)-1]
+
+	// 源码根目录: 第一个 src/<source set> (Maven/Gradle)，没有时退回第一个 java 目录
+	root := -1
+	for i, dir := range dirs {
+		if dir == "src" && i+1 < len(dirs) {
+			root = i
+			break
+		}
+	}
+	if root >= 0 && isTestSourceSet(dirs[root+1]) {
+		return CodeTest
+	}
+	for _, re := range paths.Test {
+		if re.MatchString(relPath) {
+			return CodeTest
+		}
+	}
+
+	if root < 0 {
+		for i, dir := range dirs {
+			if dir == "java" {
+				root = i
+				break
+			}
+		}
+	}
+	// 找不到源码根目录时只检查第一层目录 (之下可能已经是包路径)
+	above := dirs
+	if root >= 0 {
+		above = dirs[:root]
+	} else if len(dirs) > 1 {
+		above = dirs[:1]
+	}
+	for _, dir := range above {
+		if sampleDirs[strings.ToLower(dir)] {
+			return CodeSample
+		}
+	}
+	for _, re := range paths.Sample {
+		if re.MatchString(relPath) {
+			return CodeSample
+		}
+	}
+	return CodeMain
+}
+
+// isTestSourceSet src/ 下的目录是否是测试的 source set:
+// test、testFixtures、Maven 的 it (集成测试) 以及 Gradle 中以 Test 结尾的自定义 source set (integrationTest 等)
+func isTestSourceSet(name string) bool {
+	return name == "it" || strings.HasPrefix(name, "test") || strings.HasSuffix(name, "Test")
+}
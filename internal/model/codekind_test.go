@@ -0,0 +1,80 @@
+package model
+
+import "testing"
+
+// Maven/Gradle 的目录约定: src/<source set> 决定测试代码，源码根目录之上的 samples/、demo/ 等目录是示例代码
+func TestClassifyCode(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/main/java/com/acme/App.java", CodeMain},
+		{"src/test/java/com/acme/AppTest.java", CodeTest},
+		{"module-a/src/test/java/com/acme/AppTest.java", CodeTest},
+		{"src/integrationTest/java/com/acme/AppIT.java", CodeTest},
+		{"src/testFixtures/java/com/acme/Fixtures.java", CodeTest},
+		{"src/it/java/com/acme/AppIT.java", CodeTest},
+		{"src/main/java/com/acme/test/TestUtils.java", CodeMain}, // 包名中的 test 不算
+		{"samples/basic/src/main/java/com/acme/Sample.java", CodeSample},
+		{"Demo/src/main/java/com/acme/Demo.java", CodeSample},
+		{"examples/src/main/java/Example.java", CodeSample},
+		{"samples/app/src/test/java/com/acme/SampleTest.java", CodeTest}, // 测试优先于示例
+		{"src/main/java/com/example/demo/App.java", CodeMain},            // 源码根目录之下的 demo 是包名
+		{"web/java/com/acme/demo/App.java", CodeMain},                    // 没有 src/ 时以 java/ 为源码根目录
+		{"demo/java/com/acme/App.java", CodeSample},
+		{"demo/App.java", CodeSample},               // 没有源码根目录时只看第一层目录
+		{"app/com/example/demo/App.java", CodeMain}, // 之下可能已经是包路径
+		{"App.java", CodeMain},
+	}
+	for _, tt := range tests {
+		if got := ClassifyCode(tt.path, CodePaths{}); got != tt.want {
+			t.Errorf("ClassifyCode(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// -test-paths / -sample-paths 在约定之外追加的 glob
+func TestClassifyCodeCustomPaths(t *testing.T) {
+	paths, err := NewCodePaths([]string{"qa/**", " /tools/*Check.java ", ""}, []string{"tutorials/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths.Test) != 2 || len(paths.Sample) != 1 {
+		t.Fatalf("compiled %d test and %d sample globs", len(paths.Test), len(paths.Sample))
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"qa/src/main/java/com/acme/Smoke.java", CodeTest},
+		{"tools/LintCheck.java", CodeTest},
+		{"tools/nested/LintCheck.java", CodeMain},
+		{"tutorials/src/main/java/com/acme/Step1.java", CodeSample},
+		{"tutorials/qa/Smoke.java", CodeSample},
+		{"qa/tutorials/Step1.java", CodeTest}, // 测试优先于示例
+		{"src/main/java/com/acme/App.java", CodeMain},
+	}
+	for _, tt := range tests {
+		if got := ClassifyCode(tt.path, paths); got != tt.want {
+			t.Errorf("ClassifyCode(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// 只能从测试代码到达的链路降低一级，与未验证的降级叠加
+func TestEffectiveSeverityTestOnly(t *testing.T) {
+	rule := &SinkRule{Severity: "High"}
+	tests := []struct {
+		step ChainStep
+		want string
+	}{
+		{ChainStep{Rule: rule}, "High"},
+		{ChainStep{Rule: rule, TestOnly: true}, LowerSeverity("High")},
+		{ChainStep{Rule: rule, TestOnly: true, Unverified: "no definition"}, LowerSeverity(LowerSeverity("High"))},
+	}
+	for _, tt := range tests {
+		if got := EffectiveSeverity(tt.step); got != tt.want {
+			t.Errorf("EffectiveSeverity(test only %v, unverified %q) = %s, want %s", tt.step.TestOnly, tt.step.Unverified, got, tt.want)
+		}
+	}
+}
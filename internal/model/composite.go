@@ -0,0 +1,151 @@
+package model
+
+import (
+	"regexp"
+)
+
+// CompositeRule 方法内多条件检测规则
+// 普通 SinkRule 只看单行调用；有些漏洞需要同一方法内多处代码共同成立 (e.g. ZipSlip)：
+//   - Triggers: 方法中必须出现的代码 (作为辅助证据报告)
+//   - Source:   需要跟踪的值，赋值给局部变量后变量也会被跟踪
+//   - Sinks:    使用了 Source (或被跟踪变量) 的危险操作，命中行作为 Sink 报告
+//   - Guards:   方法中出现任意一个即视为已做防护 (在整个方法文本上匹配，可以跨行)
+type CompositeRule struct {
+	Name        string   `yaml:"name"`
+	VulnType    string   `yaml:"vuln_type"`
+	Desc        string   `yaml:"desc"`
+	DescEn      string   `yaml:"desc_en,omitempty"`
+	DescZh      string   `yaml:"desc_zh,omitempty"`
+	Severity    string   `yaml:"severity"`
+	ClassName   string   `yaml:"class_name"`  // Source 所属的类 (用于报告和 hasImport 校验)
+	MethodName  string   `yaml:"method_name"` // Source 方法名
+	Triggers    []string `yaml:"triggers"`
+	Source      string   `yaml:"source"`
+	Sinks       []string `yaml:"sinks"`
+	Guards      []string `yaml:"guards,omitempty"`
+	CWE         string   `yaml:"cwe,omitempty"`
+	References  []string `yaml:"references,omitempty"`
+	Remediation string   `yaml:"remediation,omitempty"`
+
+	TriggerRes []*regexp.Regexp `yaml:"-"`
+	SourceRe   *regexp.Regexp   `yaml:"-"`
+	SinkRes    []*regexp.Regexp `yaml:"-"`
+	GuardRes   []*regexp.Regexp `yaml:"-"`
+}
+
+// Compile 预编译规则中的正则
+func (r *CompositeRule) Compile() error {
+	compileAll := func(patterns []string) ([]*regexp.Regexp, error) {
+		var res []*regexp.Regexp
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, re)
+		}
+		return res, nil
+	}
+
+	var err error
+	if r.TriggerRes, err = compileAll(r.Triggers); err != nil {
+		return err
+	}
+	if r.SinkRes, err = compileAll(r.Sinks); err != nil {
+		return err
+	}
+	if r.GuardRes, err = compileAll(r.Guards); err != nil {
+		return err
+	}
+	r.SourceRe, err = regexp.Compile(r.Source)
+	return err
+}
+
+// AsSinkRule 转换为报告使用的 SinkRule (名称、类型、CWE 等元信息)
+func (r *CompositeRule) AsSinkRule() SinkRule {
+	return SinkRule{
+		Name:        r.Name,
+		VulnType:    r.VulnType,
+		Desc:        r.Desc,
+		DescEn:      r.DescEn,
+		DescZh:      r.DescZh,
+		Severity:    r.Severity,
+		ClassName:   r.ClassName,
+		MethodName:  r.MethodName,
+		CWE:         r.CWE,
+		References:  r.References,
+		Remediation: r.Remediation,
+	}
+}
+
+// GetBuiltinCompositeRules 返回内置的多条件规则
+func GetBuiltinCompositeRules() []CompositeRule {
+	rules := []CompositeRule{
+		{
+			// ZipSlip: 压缩包条目名直接拼接到解压路径，"../" 可以写出目标目录
+			Name:       "PATH_TRAVERSAL (ZipSlip)",
+			VulnType:   "PATH_TRAVERSAL",
+			Desc:       "压缩包解压路径穿越 (ZipSlip)",
+			Severity:   "High",
+			ClassName:  "java.util.zip.ZipEntry",
+			MethodName: "getName",
+			Triggers:   []string{`\.getNextEntry\s*\(`, `\.getNextJarEntry\s*\(`, `\.entries\s*\(\s*\)`},
+			Source:     `\.getName\s*\(\s*\)`,
+			Sinks: []string{
+				`new\s+File\s*\(`,
+				`new\s+FileOutputStream\s*\(`,
+				`Paths\.get\s*\(`,
+				`Path\.of\s*\(`,
+				`\.resolve\s*\(`,
+			},
+			Guards: []string{
+				`(?s)(getCanonicalPath|getCanonicalFile|normalize|toRealPath)\s*\(.*\.startsWith\s*\(`,
+			},
+			CWE:         "CWE-22",
+			References:  []string{"https://security.snyk.io/research/zip-slip-vulnerability"},
+			Remediation: "Resolve each entry against the target directory, normalize it (getCanonicalPath or Path.normalize) and reject entries whose result does not start with the target directory.",
+		},
+		{
+			// 反射调用的方法名来自变量: 与单独的 Method.invoke 规则 (Low) 相比，方法名可控才是可以利用的前提
+			Name:       "RCE (reflective invoke)",
+			VulnType:   "RCE",
+			Desc:       "反射调用的方法名可控",
+			Severity:   "Medium",
+			ClassName:  "java.lang.Class",
+			MethodName: "getMethod",
+			Triggers:   []string{`\.get(?:Declared)?Method\s*\(\s*[^"\s)]`},
+			Source:     `\.get(?:Declared)?Method\s*\(\s*[^"\s)]`,
+			Sinks:      []string{`\.invoke\s*\(`},
+			Guards: []string{
+				// 方法名先经过白名单校验
+				`(?i)(allowed|whitelist|allowlist)\w*\.contains\s*\(`,
+			},
+			CWE:         "CWE-470",
+			References:  []string{"https://cwe.mitre.org/data/definitions/470.html"},
+			Remediation: "Do not resolve methods or classes from user-supplied names. Map the input to a fixed set of allowed methods instead of passing it to getMethod / Class.forName.",
+		},
+		{
+			// 用字符串源码构造的 Freemarker 模板: 源码可控时 process() 渲染会执行任意 FTL (e.g. ?new() 实例化 Execute)。
+			// 全大写的 static final 常量和字符串字面量视为固定模板，cfg.getTemplate() 加载的模板文件不在此列
+			Name:        "EXPRESSION_INJECTION (Freemarker Template.process)",
+			VulnType:    "EXPRESSION_INJECTION",
+			Desc:        "渲染由可控字符串构造的模板",
+			Severity:    "High",
+			ClassName:   "freemarker.template.Template",
+			MethodName:  "process",
+			Triggers:    []string{`new\s+StringReader\s*\(\s*(?:[^"\s)A-Z]|[A-Z][\w$]*[a-z])`},
+			Source:      `new\s+StringReader\s*\(\s*(?:[^"\s)A-Z]|[A-Z][\w$]*[a-z])`,
+			Sinks:       []string{`\.process\s*\(`},
+			CWE:         "CWE-917",
+			References:  []string{"https://portswigger.net/web-security/server-side-template-injection"},
+			Remediation: "Load templates from a fixed template directory instead of building them from request data. If user-defined templates are required, configure the TemplateClassResolver as ALLOWS_NOTHING_RESOLVER and disable the ?api built-in.",
+		},
+	}
+	for i := range rules {
+		// 内置正则都是常量，编译失败属于编码错误
+		if err := rules[i].Compile(); err != nil {
+			panic(err)
+		}
+	}
+	return rules
+}
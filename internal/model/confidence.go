@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sink 的类型是如何确认的 (verifySink 的判断依据)
+const (
+	VerifiedByHover      = "hover"      // hover 得到接收者的声明类型
+	VerifiedByDefinition = "definition" // textDocument/definition 指向规则的类
+	VerifiedByWorkspace  = "workspace"  // definition 指向 workspace/symbol 找到的项目内的类 (scope: project)
+	VerifiedByImport     = "import"     // 只找到 import / 同包声明 (LSP 无法解析时的兜底)
+	VerifiedByFQN        = "fqn"        // 代码中直接写了全限定类名
+	VerifiedByComposite  = "composite"  // 方法内多条件规则 (文本匹配)
+	VerifiedByManual     = "manual"     // 单点模式的人工目标
+)
+
+// VerificationResult Sink 的确认方式和依据 (e.g. definition 指向的类文件、import 所在的行)
+type VerificationResult struct {
+	Method   string // VerifiedBy*，空表示没有确认
+	Evidence string // 人可读的确认依据
+}
+
+// OK 是否确认了 Sink 的类型
+func (v VerificationResult) OK() bool {
+	return v.Method != ""
+}
+
+// 可信度等级
+const (
+	ConfidenceHigh   = "High"
+	ConfidenceMedium = "Medium"
+	ConfidenceLow    = "Low"
+)
+
+// Confidence 计算发现可信度的信号 (仅 Sink 步骤)，与规则的严重等级相互独立
+type Confidence struct {
+	Verification   VerificationResult // Sink 类型的确认方式和依据
+	FrameworkEntry bool               // Source 是框架入口 (Controller 方法、监听器等)
+	TaintedInput   bool               // Source 有参数或读取了隐式输入 (HttpServletRequest 等)
+	DataFlow       bool               // 调用点的参数追溯到非常量的变量或方法参数
+	Complete       bool               // 链路完整: 有调用上下文、没有被截断
+	Sanitizer      string             // 输入经过了对该漏洞类型有效的净化函数 (说明)，空表示没有
+}
+
+// Score 返回可信度得分 (0 ~ MaxConfidenceScore)
+//
+//	Sink 确认方式: hover / definition / workspace +3, import / fqn / composite +2, 人工目标 +1
+//	框架入口 +2, 污点输入 +1, 数据流到达 Sink 参数 +1, 链路完整 +1, 经过净化函数 -3 (最低为 0)
+func (c Confidence) Score() int {
+	score := 0
+	switch c.Verification.Method {
+	case VerifiedByHover, VerifiedByDefinition, VerifiedByWorkspace:
+		score += 3
+	case VerifiedByImport, VerifiedByFQN, VerifiedByComposite:
+		score += 2
+	case VerifiedByManual:
+		score++
+	}
+	if c.FrameworkEntry {
+		score += 2
+	}
+	if c.TaintedInput {
+		score++
+	}
+	if c.DataFlow {
+		score++
+	}
+	if c.Complete {
+		score++
+	}
+	if c.Sanitizer != "" {
+		score = max(score-sanitizerPenalty, 0)
+	}
+	return score
+}
+
+// sanitizerPenalty 输入经过净化函数时扣除的分数，足以让最高的得分降为 Medium
+const sanitizerPenalty = 3
+
+// MaxConfidenceScore Score 的最大值
+const MaxConfidenceScore = 8
+
+// MaxScore 返回 MaxConfidenceScore (供模板使用)
+func (c Confidence) MaxScore() int { return MaxConfidenceScore }
+
+// Level 把得分映射为等级: >= 6 High, >= 3 Medium, 其余 Low
+func (c Confidence) Level() string {
+	switch score := c.Score(); {
+	case score >= 6:
+		return ConfidenceHigh
+	case score >= 3:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+// Explain 逐条列出参与评分的信号，用于报告中的说明
+func (c Confidence) Explain() []string {
+	verified := map[string]string{
+		VerifiedByHover:      "+3 sink type resolved by hover",
+		VerifiedByDefinition: "+3 sink type resolved by definition",
+		VerifiedByWorkspace:  "+3 sink type resolved to a project class",
+		VerifiedByImport:     "+2 sink type inferred from imports",
+		VerifiedByFQN:        "+2 fully-qualified class name in code",
+		VerifiedByComposite:  "+2 composite rule matched in method",
+		VerifiedByManual:     "+1 manual target",
+	}
+	var lines []string
+	if text, ok := verified[c.Verification.Method]; ok {
+		lines = append(lines, text)
+	} else {
+		lines = append(lines, "+0 sink type not verified")
+	}
+	lines = append(lines,
+		signalLine(c.FrameworkEntry, 2, "source is a framework entry point"),
+		signalLine(c.TaintedInput, 1, "source takes parameters or implicit request input"),
+		signalLine(c.DataFlow, 1, "sink argument traced to a non-constant value"),
+		signalLine(c.Complete, 1, "complete call chain"),
+	)
+	if c.Sanitizer != "" {
+		lines = append(lines, fmt.Sprintf("-%d input passes through a sanitizer: %s", sanitizerPenalty, c.Sanitizer))
+	}
+	return lines
+}
+
+func signalLine(ok bool, points int, text string) string {
+	if !ok {
+		points = 0
+	}
+	return fmt.Sprintf("+%d %s", points, text)
+}
+
+// ConfidenceRank 返回可信度等级的排序值 (Low < Medium < High)，无法识别时返回 -1
+func ConfidenceRank(level string) int {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "low":
+		return 0
+	case "medium":
+		return 1
+	case "high":
+		return 2
+	}
+	return -1
+}
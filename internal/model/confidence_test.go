@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 信号组合的得分和等级
+func TestConfidenceScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     Confidence
+		score int
+		level string
+	}{
+		{"definition + entry + tainted + dataflow + complete",
+			Confidence{Verification: VerificationResult{Method: VerifiedByDefinition}, FrameworkEntry: true, TaintedInput: true, DataFlow: true, Complete: true}, 8, ConfidenceHigh},
+		{"hover + entry + tainted",
+			Confidence{Verification: VerificationResult{Method: VerifiedByHover}, FrameworkEntry: true, TaintedInput: true}, 6, ConfidenceHigh},
+		{"workspace + entry",
+			Confidence{Verification: VerificationResult{Method: VerifiedByWorkspace}, FrameworkEntry: true}, 5, ConfidenceMedium},
+		{"import + entry + tainted + dataflow",
+			Confidence{Verification: VerificationResult{Method: VerifiedByImport}, FrameworkEntry: true, TaintedInput: true, DataFlow: true}, 6, ConfidenceHigh},
+		{"import fallback, orphan sink",
+			Confidence{Verification: VerificationResult{Method: VerifiedByImport}}, 2, ConfidenceLow},
+		{"fqn + dataflow",
+			Confidence{Verification: VerificationResult{Method: VerifiedByFQN}, DataFlow: true}, 3, ConfidenceMedium},
+		{"composite + complete",
+			Confidence{Verification: VerificationResult{Method: VerifiedByComposite}, Complete: true}, 3, ConfidenceMedium},
+		{"manual target",
+			Confidence{Verification: VerificationResult{Method: VerifiedByManual}}, 1, ConfidenceLow},
+		{"not verified, all source signals",
+			Confidence{FrameworkEntry: true, TaintedInput: true, DataFlow: true, Complete: true}, 5, ConfidenceMedium},
+		{"sanitized maximum drops to medium",
+			Confidence{Verification: VerificationResult{Method: VerifiedByDefinition}, FrameworkEntry: true, TaintedInput: true, DataFlow: true, Complete: true, Sanitizer: "ESAPI.encoder()"}, 5, ConfidenceMedium},
+		{"sanitized score does not go below zero",
+			Confidence{Verification: VerificationResult{Method: VerifiedByManual}, Sanitizer: "escapeHtml"}, 0, ConfidenceLow},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Score(); got != tt.score {
+			t.Errorf("%s: score %d, want %d", tt.name, got, tt.score)
+		}
+		if got := tt.c.Level(); got != tt.level {
+			t.Errorf("%s: level %s, want %s", tt.name, got, tt.level)
+		}
+	}
+}
+
+// Explain 中每行的分数之和等于 Score (未经过净化函数时)
+func TestConfidenceExplainMatchesScore(t *testing.T) {
+	methods := []string{"", VerifiedByHover, VerifiedByDefinition, VerifiedByWorkspace, VerifiedByImport, VerifiedByFQN, VerifiedByComposite, VerifiedByManual}
+	for _, method := range methods {
+		for bits := 0; bits < 16; bits++ {
+			c := Confidence{
+				Verification:   VerificationResult{Method: method},
+				FrameworkEntry: bits&1 != 0,
+				TaintedInput:   bits&2 != 0,
+				DataFlow:       bits&4 != 0,
+				Complete:       bits&8 != 0,
+			}
+			sum := 0
+			for _, line := range c.Explain() {
+				points, err := strconv.Atoi(strings.Fields(line)[0])
+				if err != nil {
+					t.Fatalf("%q: %v", line, err)
+				}
+				sum += points
+			}
+			if sum != c.Score() {
+				t.Errorf("%s: explained %d points, score %d\n%s", fmt.Sprintf("%+v", c), sum, c.Score(), strings.Join(c.Explain(), "\n"))
+			}
+			if c.Score() > MaxConfidenceScore {
+				t.Errorf("%+v: score %d above the maximum %d", c, c.Score(), MaxConfidenceScore)
+			}
+		}
+	}
+}
+
+func TestConfidenceRank(t *testing.T) {
+	for level, want := range map[string]int{"low": 0, "Medium": 1, " HIGH ": 2, "certain": -1, "": -1} {
+		if got := ConfidenceRank(level); got != want {
+			t.Errorf("ConfidenceRank(%q) = %d, want %d", level, got, want)
+		}
+	}
+}
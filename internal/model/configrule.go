@@ -0,0 +1,184 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ConfigType 配置文件检查的发现在报告中的漏洞类型
+const ConfigType = "CONFIG"
+
+// ConfigRule 配置文件中的危险配置 (规则文件的 config_rules 段)
+// key 匹配 .properties / .yml 中的配置项 (点分隔，比较时忽略大小写和 - _，与 Spring 的宽松绑定一致)；
+// xpath 匹配 XML 中的元素或属性 ("/web-app/session-config" 从根元素开始，"//bean/@class" 匹配任意位置，
+// 末尾的 "/@name" 表示属性，不处理命名空间前缀)。两者必须且只能指定一个。
+// 找到的值匹配 match 时报告；或者不匹配 expect 时报告；missing 为 true 时文件中没有该项也报告 (位置是文件开头)
+type ConfigRule struct {
+	Name        string   `yaml:"name"`
+	Files       []string `yaml:"files"` // 文件名 glob (e.g. "web.xml", "*-context.xml", "application*.properties")
+	Key         string   `yaml:"key,omitempty"`
+	XPath       string   `yaml:"xpath,omitempty"`
+	Match       string   `yaml:"match,omitempty"`  // 值的正则，匹配时报告
+	Expect      string   `yaml:"expect,omitempty"` // 期望值的正则，不匹配时报告
+	Missing     bool     `yaml:"missing,omitempty"`
+	Severity    string   `yaml:"severity"`
+	Desc        string   `yaml:"desc,omitempty"`
+	DescEn      string   `yaml:"desc_en,omitempty"`
+	DescZh      string   `yaml:"desc_zh,omitempty"`
+	CWE         string   `yaml:"cwe,omitempty"`
+	References  []string `yaml:"references,omitempty"`
+	Remediation string   `yaml:"remediation,omitempty"`
+
+	MatchRe  *regexp.Regexp `yaml:"-"`
+	ExpectRe *regexp.Regexp `yaml:"-"`
+}
+
+// Compile 检查规则并预编译正则
+func (r *ConfigRule) Compile() error {
+	if (r.Key == "") == (r.XPath == "") {
+		return fmt.Errorf("config rule %q: exactly one of key and xpath is required", r.Name)
+	}
+	if len(r.Files) == 0 {
+		return fmt.Errorf("config rule %q: files is required", r.Name)
+	}
+	if r.Match == "" && r.Expect == "" && !r.Missing {
+		return fmt.Errorf("config rule %q: one of match, expect or missing is required", r.Name)
+	}
+	if r.XPath != "" && !strings.HasPrefix(r.XPath, "/") {
+		return fmt.Errorf("config rule %q: xpath must start with / or //", r.Name)
+	}
+	var err error
+	if r.Match != "" {
+		if r.MatchRe, err = regexp.Compile(r.Match); err != nil {
+			return fmt.Errorf("config rule %q: match: %v", r.Name, err)
+		}
+	}
+	if r.Expect != "" {
+		if r.ExpectRe, err = regexp.Compile(r.Expect); err != nil {
+			return fmt.Errorf("config rule %q: expect: %v", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// AppliesTo 文件名是否匹配规则的 files
+func (r *ConfigRule) AppliesTo(fileName string) bool {
+	for _, glob := range r.Files {
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(fileName)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Violates 找到的值是否违反规则: 匹配 match，或者不匹配 expect
+func (r *ConfigRule) Violates(value string) bool {
+	value = strings.TrimSpace(value)
+	if r.MatchRe != nil && r.MatchRe.MatchString(value) {
+		return true
+	}
+	return r.ExpectRe != nil && !r.ExpectRe.MatchString(value)
+}
+
+// AsSinkRule 转换为报告使用的 SinkRule，所有配置问题归入 CONFIG 分组
+func (r *ConfigRule) AsSinkRule() SinkRule {
+	desc := r.Desc
+	if desc == "" && r.DescEn == "" && r.DescZh == "" {
+		desc = "危险配置"
+	}
+	return SinkRule{
+		Name:        fmt.Sprintf("CONFIG (%s)", r.Name),
+		VulnType:    ConfigType,
+		Desc:        desc,
+		DescEn:      r.DescEn,
+		DescZh:      r.DescZh,
+		Severity:    r.Severity,
+		CWE:         r.CWE,
+		References:  r.References,
+		Remediation: r.Remediation,
+	}
+}
+
+// springPropertyFiles Spring Boot 的配置文件 (含 profile，e.g. application-prod.yml)
+var springPropertyFiles = []string{"application*.properties", "application*.yml", "application*.yaml", "bootstrap*.properties", "bootstrap*.yml", "bootstrap*.yaml"}
+
+// GetBuiltinConfigRules 返回内置的配置检查规则
+func GetBuiltinConfigRules() []ConfigRule {
+	rules := []ConfigRule{
+		{
+			Name: "Session cookie without HttpOnly", Severity: "Low", CWE: "CWE-1004",
+			Files: []string{"web.xml"}, XPath: "/web-app/session-config/cookie-config/http-only", Expect: `(?i)^true$`, Missing: true,
+			Desc:        "会话 Cookie 没有设置 HttpOnly",
+			Remediation: "Add <session-config><cookie-config><http-only>true</http-only></cookie-config></session-config> to web.xml (and <secure>true</secure> when served over HTTPS).",
+		},
+		{
+			Name: "Deserializer bean in Spring XML", Severity: "Medium", CWE: "CWE-502",
+			Files: []string{"*-context.xml", "applicationContext*.xml", "*-servlet.xml", "spring*.xml"}, XPath: "//bean/@class",
+			Match:       `(?:\bXStream(?:Marshaller)?|java\.io\.ObjectInputStream|\bSerializingConverter|\bHessianServiceExporter|\bHttpInvokerServiceExporter)$`,
+			Desc:        "Spring XML 中配置了反序列化组件",
+			Remediation: "Avoid Java serialization and XStream for untrusted data. If the bean is required, restrict the accepted types (XStream allowTypes / ObjectInputFilter) or switch to a JSON mapper with default typing disabled.",
+		},
+		{
+			Name: "H2 console enabled", Severity: "High", CWE: "CWE-749",
+			Files: springPropertyFiles, Key: "spring.h2.console.enabled", Match: `(?i)^true$`,
+			Desc:        "开启了 H2 数据库控制台",
+			Remediation: "Disable spring.h2.console.enabled outside local development; the console allows arbitrary SQL and JNDI/RCE payloads.",
+		},
+		{
+			Name: "Actuator endpoints exposed", Severity: "Medium", CWE: "CWE-200",
+			Files: springPropertyFiles, Key: "management.endpoints.web.exposure.include",
+			Match:       `(?i)(?:^|,)\s*(?:\*|env|heapdump|threaddump|jolokia|logfile|shutdown|gateway|configprops|mappings)\s*(?:,|$)`,
+			Desc:        "通过 HTTP 暴露了敏感的 Actuator 端点",
+			Remediation: "Only expose health and info over HTTP, or move the management endpoints to a separate port behind authentication.",
+		},
+		{
+			Name: "Actuator security disabled", Severity: "Medium", CWE: "CWE-306",
+			Files: springPropertyFiles, Key: "management.security.enabled", Match: `(?i)^false$`,
+			Desc:        "关闭了 Actuator 端点的认证 (Spring Boot 1.x)",
+			Remediation: "Remove management.security.enabled=false and protect the management endpoints with Spring Security.",
+		},
+		{
+			Name: "Debug mode enabled", Severity: "Low", CWE: "CWE-489",
+			Files: springPropertyFiles, Key: "debug", Match: `(?i)^true$`,
+			Desc:        "开启了调试模式",
+			Remediation: "Do not enable debug in production profiles.",
+		},
+		{
+			Name: "Stack traces in error responses", Severity: "Low", CWE: "CWE-209",
+			Files: springPropertyFiles, Key: "server.error.include-stacktrace", Match: `(?i)^always$`,
+			Desc:        "错误响应中包含堆栈信息",
+			Remediation: "Set server.error.include-stacktrace to never (or on_param only for internal tools).",
+		},
+	}
+	for i := range rules {
+		// 内置规则都是常量，编译失败属于编码错误
+		if err := rules[i].Compile(); err != nil {
+			panic(err)
+		}
+	}
+	return rules
+}
+
+// LoadConfigRules 返回内置规则加上规则文件 config_rules 段中的规则 (path 为空时只返回内置规则)
+func LoadConfigRules(path string) ([]ConfigRule, error) {
+	rules := GetBuiltinConfigRules()
+	if path == "" {
+		return rules, nil
+	}
+	file, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range file.ConfigRules {
+		if r.Severity == "" {
+			r.Severity = "Medium"
+		}
+		if err := r.Compile(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
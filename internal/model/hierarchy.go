@@ -0,0 +1,118 @@
+package model
+
+import "strings"
+
+// TypeHierarchy 类型层级表: 父类/接口全限定名 -> 直接子类型全限定名
+// 用于 match_subtypes 规则，例如 Statement 规则同时匹配 PreparedStatement.executeQuery
+type TypeHierarchy map[string][]string
+
+// builtinTypeHierarchy JDK 和常用库的类型层级 (只收录规则涉及的类型)
+var builtinTypeHierarchy = TypeHierarchy{
+	"java.sql.Statement":         {"java.sql.PreparedStatement"},
+	"java.sql.PreparedStatement": {"java.sql.CallableStatement"},
+
+	"java.io.Writer": {
+		"java.io.PrintWriter", "java.io.BufferedWriter", "java.io.OutputStreamWriter",
+		"java.io.StringWriter", "java.io.CharArrayWriter", "javax.servlet.jsp.JspWriter",
+	},
+	"java.io.OutputStreamWriter": {"java.io.FileWriter"},
+	"java.io.OutputStream": {
+		"java.io.FileOutputStream", "java.io.ByteArrayOutputStream", "java.io.FilterOutputStream",
+		"java.io.ObjectOutputStream", "javax.servlet.ServletOutputStream",
+	},
+	"java.io.FilterOutputStream": {"java.io.BufferedOutputStream", "java.io.DataOutputStream", "java.io.PrintStream"},
+
+	"org.apache.http.client.HttpClient":               {"org.apache.http.impl.client.CloseableHttpClient"},
+	"org.apache.http.impl.client.CloseableHttpClient": {"org.apache.http.impl.client.InternalHttpClient", "org.apache.http.impl.client.MinimalHttpClient", "org.apache.http.impl.client.AbstractHttpClient"},
+	"org.apache.http.impl.client.AbstractHttpClient":  {"org.apache.http.impl.client.DefaultHttpClient"},
+
+	"javax.script.ScriptEngine":         {"javax.script.AbstractScriptEngine"},
+	"javax.script.AbstractScriptEngine": {"jdk.nashorn.api.scripting.NashornScriptEngine"},
+
+	"javax.persistence.EntityManager": {"org.hibernate.Session"},
+
+	"javax.naming.Context":              {"javax.naming.InitialContext", "javax.naming.directory.DirContext"},
+	"javax.naming.InitialContext":       {"javax.naming.directory.InitialDirContext"},
+	"javax.naming.directory.DirContext": {"javax.naming.directory.InitialDirContext", "javax.naming.ldap.LdapContext"},
+	"javax.naming.ldap.LdapContext":     {"javax.naming.ldap.InitialLdapContext"},
+
+	"org.springframework.expression.ExpressionParser": {"org.springframework.expression.spel.standard.SpelExpressionParser"},
+	"org.thymeleaf.ITemplateEngine":                   {"org.thymeleaf.TemplateEngine"},
+	"org.thymeleaf.TemplateEngine":                    {"org.thymeleaf.spring5.SpringTemplateEngine", "org.thymeleaf.spring6.SpringTemplateEngine"},
+}
+
+// BuiltinTypeHierarchy 返回内置层级表的副本
+func BuiltinTypeHierarchy() TypeHierarchy {
+	return builtinTypeHierarchy.Merge(nil)
+}
+
+// Merge 返回合并了 other 的新层级表 (子类型列表追加去重)
+func (h TypeHierarchy) Merge(other TypeHierarchy) TypeHierarchy {
+	merged := make(TypeHierarchy, len(h)+len(other))
+	for _, src := range []TypeHierarchy{h, other} {
+		for parent, subs := range src {
+			for _, sub := range subs {
+				if !containsString(merged[parent], sub) {
+					merged[parent] = append(merged[parent], sub)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// Subtypes 返回 class 的所有 (传递) 子类型，不包含 class 本身
+func (h TypeHierarchy) Subtypes(class string) []string {
+	var result []string
+	seen := map[string]bool{class: true}
+	queue := []string{class}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, sub := range h[cur] {
+			if seen[sub] {
+				continue
+			}
+			seen[sub] = true
+			result = append(result, sub)
+			queue = append(queue, sub)
+		}
+	}
+	return result
+}
+
+// Classes 返回规则匹配的所有类: 规则类本身以及 (match_subtypes 时) 其子类型
+func (r *SinkRule) Classes() []string {
+	return append([]string{r.ClassName}, r.Subtypes...)
+}
+
+// MatchesClass 全限定类名是否被规则匹配
+func (r *SinkRule) MatchesClass(fqn string) bool {
+	return containsString(r.Classes(), fqn)
+}
+
+// MatchesSimpleName 简单类名 (没有包名) 是否可能被规则匹配
+func (r *SinkRule) MatchesSimpleName(name string) bool {
+	for _, c := range r.Classes() {
+		if simpleClassName(c) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func simpleClassName(fqn string) string {
+	if idx := strings.LastIndex(fqn, "."); idx != -1 {
+		return fqn[idx+1:]
+	}
+	return fqn
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
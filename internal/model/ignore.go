@@ -0,0 +1,108 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AllowExpired 已过期的 lsptracer:ignore 注释: 不再抑制发现，报告中醒目标出
+const AllowExpired = "expired"
+
+// inlineIgnoreRe 匹配 "// lsptracer:ignore"、"// lsptracer:ignore[SQLI,SSRF]"，之后是可选的属性和说明
+var (
+	inlineIgnoreRe = regexp.MustCompile(`//\s*lsptracer:ignore(?:\[([^\]]*)\])?(.*)$`)
+	ignoreRuleRe   = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// InlineIgnore 源码中的 lsptracer:ignore 注释
+//
+//	// lsptracer:ignore                         忽略所有规则
+//	// lsptracer:ignore[SQLI,SSRF] 说明          只忽略列出的漏洞类型或规则名
+//	// lsptracer:ignore until=2025-12-31 说明    到期 (当天结束) 后不再生效
+//
+// 兼容不带方括号的写法 ("lsptracer:ignore RCE")：说明开头的大写标识符视为规则
+type InlineIgnore struct {
+	Rules   []string  // 为空表示所有规则
+	Until   time.Time // 零值表示不过期
+	Comment string    // 注释原文 (作为理由写入报告)
+}
+
+// ParseInlineIgnore 解析一行源码中的 lsptracer:ignore 注释
+func ParseInlineIgnore(line string) (InlineIgnore, bool) {
+	m := inlineIgnoreRe.FindStringSubmatch(line)
+	if m == nil {
+		return InlineIgnore{}, false
+	}
+	ignore := InlineIgnore{Comment: strings.TrimSpace(strings.TrimPrefix(m[0], "//"))}
+	for _, name := range strings.Split(m[1], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ignore.Rules = append(ignore.Rules, name)
+		}
+	}
+
+	bracketed := m[1] != ""
+	for _, field := range strings.Fields(m[2]) {
+		if value, ok := strings.CutPrefix(field, "until="); ok {
+			if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+				ignore.Until = t
+				continue
+			}
+		}
+		if bracketed || !ignoreRuleRe.MatchString(strings.TrimSuffix(field, ",")) {
+			bracketed = true // 说明开始，后面的单词不再视为规则
+			continue
+		}
+		ignore.Rules = append(ignore.Rules, strings.TrimSuffix(field, ","))
+	}
+	return ignore, true
+}
+
+// Matches 注释是否适用于规则 (按漏洞类型或规则名匹配，不区分大小写)
+func (i InlineIgnore) Matches(rule *SinkRule) bool {
+	if len(i.Rules) == 0 {
+		return true
+	}
+	if rule == nil {
+		return false
+	}
+	for _, name := range i.Rules {
+		if strings.EqualFold(name, rule.VulnType) || strings.EqualFold(name, rule.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired until 日期 (含当天) 是否已经过去
+func (i InlineIgnore) Expired(now time.Time) bool {
+	return !i.Until.IsZero() && !now.Before(i.Until.AddDate(0, 0, 1))
+}
+
+// Suppression 转为 Sink 步骤上的抑制信息；过期的注释记为 AllowExpired
+func (i InlineIgnore) Suppression(now time.Time) *Suppression {
+	s := &Suppression{Action: AllowSuppress, Source: SuppressedByInline, Reason: i.Comment}
+	if !i.Until.IsZero() {
+		s.Until = i.Until.Format("2006-01-02")
+	}
+	if i.Expired(now) {
+		s.Action = AllowExpired
+	}
+	return s
+}
+
+// InlineSuppression 检查 Sink 行和上一行 (只有注释的行) 的 lsptracer:ignore 注释，返回适用于 rule 的抑制信息 (没有时为 nil)
+func InlineSuppression(lines []string, line int, rule *SinkRule, now time.Time) *Suppression {
+	for _, i := range []int{line, line - 1} {
+		if i < 0 || i >= len(lines) {
+			continue
+		}
+		if i != line && !strings.HasPrefix(strings.TrimSpace(lines[i]), "//") {
+			continue
+		}
+		if ignore, ok := ParseInlineIgnore(lines[i]); ok && ignore.Matches(rule) {
+			return ignore.Suppression(now)
+		}
+	}
+	return nil
+}
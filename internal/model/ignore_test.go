@@ -0,0 +1,133 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseInlineIgnore(t *testing.T) {
+	tests := []struct {
+		line    string
+		rules   []string
+		until   string
+		comment string
+	}{
+		{`exec(cmd); // lsptracer:ignore`, nil, "", "lsptracer:ignore"},
+		{`// lsptracer:ignore[SQLI,SSRF] 参数已在网关校验`, []string{"SQLI", "SSRF"}, "", "lsptracer:ignore[SQLI,SSRF] 参数已在网关校验"},
+		{`//lsptracer:ignore[ SQLI , Runtime.exec ]`, []string{"SQLI", "Runtime.exec"}, "", "lsptracer:ignore[ SQLI , Runtime.exec ]"},
+		{`// lsptracer:ignore until=2025-12-31 等待迁移`, nil, "2025-12-31", "lsptracer:ignore until=2025-12-31 等待迁移"},
+		{`// lsptracer:ignore[RCE] until=2025-12-31`, []string{"RCE"}, "2025-12-31", "lsptracer:ignore[RCE] until=2025-12-31"},
+		// 不带方括号: 开头的大写标识符是规则，第一个其它单词之后都是说明
+		{`// lsptracer:ignore RCE, SQLI fixed upstream SSRF`, []string{"RCE", "SQLI"}, "", "lsptracer:ignore RCE, SQLI fixed upstream SSRF"},
+		// 日期无法解析时是说明的一部分
+		{`// lsptracer:ignore[XSS] until=someday`, []string{"XSS"}, "", "lsptracer:ignore[XSS] until=someday"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseInlineIgnore(tt.line)
+		if !ok {
+			t.Errorf("%q: not recognized", tt.line)
+			continue
+		}
+		until := ""
+		if !got.Until.IsZero() {
+			until = got.Until.Format("2006-01-02")
+		}
+		if !slices.Equal(got.Rules, tt.rules) || until != tt.until || got.Comment != tt.comment {
+			t.Errorf("%q: rules %v until %q comment %q, want %v %q %q", tt.line, got.Rules, until, got.Comment, tt.rules, tt.until, tt.comment)
+		}
+	}
+
+	for _, line := range []string{`exec(cmd);`, `// lsptracer: ignore`, `String s = "lsptracer:ignore";`} {
+		if _, ok := ParseInlineIgnore(line); ok {
+			t.Errorf("%q: recognized as an ignore comment", line)
+		}
+	}
+}
+
+func TestInlineIgnoreMatches(t *testing.T) {
+	rule := &SinkRule{Name: "Runtime.exec", VulnType: "RCE"}
+	tests := []struct {
+		rules []string
+		want  bool
+	}{
+		{nil, true},
+		{[]string{"RCE"}, true},
+		{[]string{"sqli", "rce"}, true}, // 不区分大小写
+		{[]string{"runtime.exec"}, true},
+		{[]string{"SQLI", "SSRF"}, false},
+	}
+	for _, tt := range tests {
+		if got := (InlineIgnore{Rules: tt.rules}).Matches(rule); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.rules, got, tt.want)
+		}
+	}
+	if (InlineIgnore{Rules: []string{"RCE"}}).Matches(nil) {
+		t.Error("a scoped ignore matched a nil rule")
+	}
+}
+
+// until 当天仍然有效，第二天零点起过期
+func TestInlineIgnoreExpired(t *testing.T) {
+	ignore, _ := ParseInlineIgnore(`// lsptracer:ignore until=2025-12-31`)
+	day := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	for now, want := range map[string]bool{
+		"2025-06-01 12:00": false,
+		"2025-12-31 23:59": false,
+		"2026-01-01 00:00": true,
+	} {
+		if got := ignore.Expired(day(now)); got != want {
+			t.Errorf("Expired(%s) = %v, want %v", now, got, want)
+		}
+	}
+	if (InlineIgnore{}).Expired(time.Now()) {
+		t.Error("an ignore without until expired")
+	}
+}
+
+func TestInlineSuppression(t *testing.T) {
+	rule := &SinkRule{Name: "Runtime.exec", VulnType: "RCE"}
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	lines := []string{
+		`    // lsptracer:ignore[RCE] 命令来自配置`,         // 0
+		`    Runtime.getRuntime().exec(cmd);`,         // 1: 上一行的注释
+		`    exec(a); // lsptracer:ignore[SQLI]`,      // 2: 不适用于 RCE
+		`    exec(b); // lsptracer:ignore`,            // 3: 上一行有代码，本行注释
+		`    exec(c);`,                                // 4: 上一行不是只有注释
+		`    // lsptracer:ignore until=2025-12-31 临时`, // 5
+		`    exec(d);`,                                // 6: 已过期
+	}
+	tests := []struct {
+		line   int
+		action string // 空表示没有抑制
+		until  string
+	}{
+		{1, AllowSuppress, ""},
+		{2, "", ""},
+		{3, AllowSuppress, ""},
+		{4, "", ""},
+		{6, AllowExpired, "2025-12-31"},
+	}
+	for _, tt := range tests {
+		s := InlineSuppression(lines, tt.line, rule, now)
+		action, until := "", ""
+		if s != nil {
+			action, until = s.Action, s.Until
+			if s.Source != SuppressedByInline {
+				t.Errorf("line %d: source %q, want %q", tt.line, s.Source, SuppressedByInline)
+			}
+		}
+		if action != tt.action || until != tt.until {
+			t.Errorf("line %d: action %q until %q, want %q %q", tt.line, action, until, tt.action, tt.until)
+		}
+	}
+	if s := InlineSuppression(lines, 1, rule, now); s == nil || s.Reason != "lsptracer:ignore[RCE] 命令来自配置" {
+		t.Errorf("line 1: reason = %+v, want the comment text", s)
+	}
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severity_overrides 条件中 dataflow 和 auth 的取值
+const (
+	DataFlowConfirmed   = "confirmed"   // 调用点的参数追溯到非常量的变量或方法参数 (Confidence.DataFlow)
+	DataFlowUnconfirmed = "unconfirmed" // 有可信度信号，但参数没有追溯到输入
+	AuthNone            = "none"        // Source 端点没有授权检查 (-analyzer authz)
+	AuthRequired        = "required"    // Source 端点有注解或安全配置中的授权规则
+)
+
+// 多条 severity_overrides 同时满足时的取舍 (规则的 override_policy)
+const (
+	OverrideMostSevere = "most_severe" // 默认: 取最严重的等级，等级相同时取先声明的条目
+	OverrideFirst      = "first"       // 按声明顺序取第一条满足的条目
+)
+
+// SeverityOverride 规则 severity_overrides 中的一条: 链路满足所有设置了的条件时，发现的等级改为 Severity
+// 没有设置任何条件的条目总是满足 (相当于修改规则等级)
+type SeverityOverride struct {
+	Source     string `yaml:"source,omitempty" json:"source,omitempty"`           // Source 类型: http / mq / scheduled / cli
+	DataFlow   string `yaml:"dataflow,omitempty" json:"dataflow,omitempty"`       // confirmed / unconfirmed
+	URLControl string `yaml:"url_control,omitempty" json:"url_control,omitempty"` // SSRF 中输入能控制的 URL 部分: full / path / query
+	Auth       string `yaml:"auth,omitempty" json:"auth,omitempty"`               // Source 端点的授权: none / required (需要 -analyzer authz)
+	Severity   string `yaml:"severity" json:"severity"`                           // 满足条件时的等级
+	Reason     string `yaml:"reason,omitempty" json:"reason,omitempty"`           // 说明，写入报告
+}
+
+// ChainTraits severity_overrides 检查的链路特征，空表示未知 (未知的特征不满足任何条件)
+type ChainTraits struct {
+	Source     string // Source 的入口类型 (SourceHTTP 等)
+	DataFlow   string // DataFlowConfirmed / DataFlowUnconfirmed
+	URLControl string // URLControlFull 等
+	Auth       string // AuthNone / AuthRequired
+}
+
+// Validate 检查条件的取值和等级，并把取值统一为小写
+func (o *SeverityOverride) Validate() error {
+	if SeverityRank(o.Severity) < 0 {
+		return fmt.Errorf("invalid severity %q (use info, low, medium, high or critical)", o.Severity)
+	}
+	severity := strings.ToLower(strings.TrimSpace(o.Severity))
+	o.Severity = strings.ToUpper(severity[:1]) + severity[1:]
+	check := func(name string, value *string, allowed ...string) error {
+		*value = strings.ToLower(strings.TrimSpace(*value))
+		if *value == "" {
+			return nil
+		}
+		for _, a := range allowed {
+			if *value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid %s %q (use %s)", name, *value, strings.Join(allowed, ", "))
+	}
+	if err := check("source", &o.Source, "http", "mq", "scheduled", "cli"); err != nil {
+		return err
+	}
+	if err := check("dataflow", &o.DataFlow, DataFlowConfirmed, DataFlowUnconfirmed); err != nil {
+		return err
+	}
+	if err := check("url_control", &o.URLControl, "full", "path", "query"); err != nil {
+		return err
+	}
+	return check("auth", &o.Auth, AuthNone, AuthRequired)
+}
+
+// Matches 链路是否满足所有设置了的条件
+func (o SeverityOverride) Matches(c ChainTraits) bool {
+	return (o.Source == "" || SourceKindNames[o.Source] == c.Source) &&
+		(o.DataFlow == "" || o.DataFlow == c.DataFlow) &&
+		(o.URLControl == "" || strings.EqualFold(o.URLControl, c.URLControl)) &&
+		(o.Auth == "" || o.Auth == c.Auth)
+}
+
+// Label 条件的展示形式 (e.g. "source=http, url_control=full")
+func (o SeverityOverride) Label() string {
+	var parts []string
+	for _, c := range [][2]string{{"source", o.Source}, {"dataflow", o.DataFlow}, {"url_control", o.URLControl}, {"auth", o.Auth}} {
+		if c[1] != "" {
+			parts = append(parts, c[0]+"="+c[1])
+		}
+	}
+	label := strings.Join(parts, ", ")
+	if label == "" {
+		label = "always"
+	}
+	if o.Reason != "" {
+		label += " (" + o.Reason + ")"
+	}
+	return label
+}
+
+// validateOverrides 检查规则的 severity_overrides 和 override_policy
+func (r *SinkRule) validateOverrides() error {
+	switch r.OverridePolicy {
+	case "", OverrideMostSevere, OverrideFirst:
+	default:
+		return fmt.Errorf("unknown override_policy %q (use %s or %s)", r.OverridePolicy, OverrideMostSevere, OverrideFirst)
+	}
+	for i := range r.SeverityOverrides {
+		if err := r.SeverityOverrides[i].Validate(); err != nil {
+			return fmt.Errorf("severity_overrides[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// MatchOverride 返回链路特征满足的 severity_overrides 条目，没有满足的条目时返回 nil
+// 多条同时满足时按 override_policy 取舍: 默认取最严重的等级 (等级相同时取先声明的条目)，first 取第一条
+func (r *SinkRule) MatchOverride(c ChainTraits) *SeverityOverride {
+	var best *SeverityOverride
+	for i := range r.SeverityOverrides {
+		o := &r.SeverityOverrides[i]
+		if !o.Matches(c) {
+			continue
+		}
+		if r.OverridePolicy == OverrideFirst {
+			return o
+		}
+		if best == nil || SeverityRank(o.Severity) > SeverityRank(best.Severity) {
+			best = o
+		}
+	}
+	return best
+}
+
+// EffectiveSeverity 返回 Sink 步骤的有效等级: 规则等级 (severity_overrides 生效时为覆盖的等级)，
+// 未验证、只能从测试到达和被白名单降级的发现各降低一级；没有覆盖时，只能控制 URL 路径/查询的 SSRF 发现再降低一级/两级
+// 没有规则或规则没有等级时为空
+func EffectiveSeverity(sink ChainStep) string {
+	if sink.Rule == nil || sink.Rule.Severity == "" {
+		return ""
+	}
+	severity := sink.Rule.Severity
+	penalty := URLControlPenalty(sink.URLControl)
+	// 覆盖条目已经按 url_control 等链路特征给出了等级，不再叠加 URL 控制范围的降级
+	if o := sink.SeverityOverride; o != nil {
+		severity, penalty = o.Severity, 0
+	}
+	if sink.Unverified != "" {
+		penalty++
+	}
+	if sink.TestOnly {
+		penalty++
+	}
+	if s := sink.Suppression; s != nil && s.Action == AllowDowngrade {
+		penalty++
+	}
+	for ; penalty > 0; penalty-- {
+		severity = LowerSeverity(severity)
+	}
+	return severity
+}
@@ -0,0 +1,88 @@
+package model
+
+import "testing"
+
+// 多条 severity_overrides 同时满足时的取舍: 默认取最严重的等级 (等级相同取先声明的)，first 取第一条满足的
+func TestMatchOverridePrecedence(t *testing.T) {
+	overrides := []SeverityOverride{
+		{Source: "http", Severity: "Medium", Reason: "http"},
+		{URLControl: "full", Severity: "Critical", Reason: "full"},
+		{Auth: AuthNone, Severity: "Critical", Reason: "no auth"},
+		{DataFlow: DataFlowUnconfirmed, Severity: "Info", Reason: "unconfirmed"},
+		{Severity: "Low", Reason: "always"},
+	}
+	tests := []struct {
+		name   string
+		policy string
+		traits ChainTraits
+		want   string // 选中条目的 Reason
+	}{
+		{"only the unconditional entry", "", ChainTraits{Source: SourceMQ}, "always"},
+		{"more severe wins over earlier", "", ChainTraits{Source: SourceHTTP, URLControl: URLControlFull}, "full"},
+		{"tie keeps first declared", "", ChainTraits{Source: SourceHTTP, URLControl: URLControlFull, Auth: AuthNone}, "full"},
+		{"later entry when earlier ones miss", "", ChainTraits{Source: SourceMQ, Auth: AuthNone}, "no auth"},
+		{"lower severity never wins", "", ChainTraits{Source: SourceHTTP, DataFlow: DataFlowUnconfirmed}, "http"},
+		{"explicit most_severe", OverrideMostSevere, ChainTraits{Source: SourceHTTP, Auth: AuthNone}, "no auth"},
+		{"first takes declaration order", OverrideFirst, ChainTraits{Source: SourceHTTP, URLControl: URLControlFull, Auth: AuthNone}, "http"},
+		{"first skips non-matching", OverrideFirst, ChainTraits{Source: SourceMQ, DataFlow: DataFlowUnconfirmed}, "unconfirmed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := SinkRule{Severity: "High", OverridePolicy: tt.policy, SeverityOverrides: overrides}
+			if err := rule.validateOverrides(); err != nil {
+				t.Fatal(err)
+			}
+			o := rule.MatchOverride(tt.traits)
+			if o == nil {
+				t.Fatalf("MatchOverride(%+v) = nil, want %q", tt.traits, tt.want)
+			}
+			if o.Reason != tt.want {
+				t.Errorf("MatchOverride(%+v) = %q, want %q", tt.traits, o.Reason, tt.want)
+			}
+			if o != &rule.SeverityOverrides[indexOfReason(rule.SeverityOverrides, tt.want)] {
+				t.Error("MatchOverride should return the rule's own entry")
+			}
+		})
+	}
+}
+
+func indexOfReason(overrides []SeverityOverride, reason string) int {
+	for i, o := range overrides {
+		if o.Reason == reason {
+			return i
+		}
+	}
+	return -1
+}
+
+// 没有满足的条目时返回 nil，未知的链路特征不满足任何条件
+func TestMatchOverrideNoMatch(t *testing.T) {
+	rule := SinkRule{SeverityOverrides: []SeverityOverride{
+		{Source: "http", Severity: "High"},
+		{URLControl: "path", Auth: AuthRequired, Severity: "Low"},
+	}}
+	for _, traits := range []ChainTraits{{}, {Source: SourceMQ}, {URLControl: URLControlPath}} {
+		if o := rule.MatchOverride(traits); o != nil {
+			t.Errorf("MatchOverride(%+v) = %+v, want nil", traits, *o)
+		}
+	}
+}
+
+// 覆盖生效时取覆盖的等级，不再叠加 URL 控制范围的降级，其它降级照常叠加
+func TestEffectiveSeverityOverride(t *testing.T) {
+	rule := &SinkRule{Severity: "High"}
+	override := &SeverityOverride{Severity: "Critical"}
+	tests := []struct {
+		step ChainStep
+		want string
+	}{
+		{ChainStep{Rule: rule, URLControl: URLControlPath}, LowerSeverity("High")},
+		{ChainStep{Rule: rule, URLControl: URLControlPath, SeverityOverride: override}, "Critical"},
+		{ChainStep{Rule: rule, SeverityOverride: override, TestOnly: true}, "High"},
+	}
+	for i, tt := range tests {
+		if got := EffectiveSeverity(tt.step); got != tt.want {
+			t.Errorf("case %d: EffectiveSeverity = %q, want %q", i, got, tt.want)
+		}
+	}
+}
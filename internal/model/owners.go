@@ -0,0 +1,102 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OwnerRule -owners 文件中的一条映射: 路径匹配 Path 的发现归 Team 负责
+// Path 是相对项目根目录的 glob (** 匹配任意层目录)，写法与 CODEOWNERS 相同:
+// 模式匹配的目录包含其下的所有文件 ("services/payments" 与 "services/payments/" 都匹配目录下的文件)；
+// 不含 / 的模式 (e.g. "docs"、"*.sql") 匹配任意层级中的同名文件或目录，含 / 的模式从项目根目录开始匹配
+type OwnerRule struct {
+	Path string `yaml:"path"`
+	Team string `yaml:"team"`
+
+	re *regexp.Regexp
+}
+
+// Owners 路径到团队的映射，和 CODEOWNERS 一样后面的条目优先
+type Owners []OwnerRule
+
+// ownersFile -owners 文件的格式；也兼容只有映射列表的写法
+type ownersFile struct {
+	Owners []OwnerRule `yaml:"owners"`
+}
+
+// LoadOwners 读取 -owners 文件 (path 为空时返回 nil)
+func LoadOwners(path string) (Owners, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []OwnerRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		var file ownersFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		rules = file.Owners
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("owners[%d]: %v", i, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *OwnerRule) compile() error {
+	if r.Path == "" || r.Team == "" {
+		return fmt.Errorf("owner entry needs a path and a team")
+	}
+	pattern := strings.TrimPrefix(r.Path, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(strings.TrimSuffix(r.Path, "/"), "/") {
+		pattern = "**/" + pattern
+	}
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid owner path %q: %v", r.Path, err)
+	}
+	r.re = re
+	return nil
+}
+
+// Match 返回负责 relPath (相对项目根目录，/ 分隔) 的团队，没有匹配的条目时为空
+// 多条匹配时取最后一条 (通用的规则写在前面，具体的目录写在后面)
+func (o Owners) Match(relPath string) string {
+	for i := len(o) - 1; i >= 0; i-- {
+		if o[i].matches(relPath) {
+			return o[i].Team
+		}
+	}
+	return ""
+}
+
+// matches 模式匹配 relPath 本身或它所在的任意一层目录
+func (r OwnerRule) matches(relPath string) bool {
+	if r.re == nil {
+		return false
+	}
+	for p := relPath; p != ""; {
+		if r.re.MatchString(p) {
+			return true
+		}
+		i := strings.LastIndex(p, "/")
+		if i == -1 {
+			break
+		}
+		p = p[:i]
+	}
+	return false
+}
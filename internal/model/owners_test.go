@@ -0,0 +1,83 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOwnersMatch(t *testing.T) {
+	owners := Owners{
+		{Path: "*", Team: "platform"},
+		{Path: "docs", Team: "writers"},
+		{Path: "*.sql", Team: "dba"},
+		{Path: "services/payments", Team: "payments"},
+		{Path: "services/api/", Team: "api"},
+		{Path: "/tools/*.java", Team: "tooling"},
+		{Path: "services/**/internal/", Team: "core"},
+	}
+	for i := range owners {
+		if err := owners[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"services/payments/src/A.java", "payments"}, // 不以 / 结尾的目录也包含其下的文件
+		{"services/payments", "payments"},
+		{"services/payments-v2/A.java", "platform"}, // 只按完整的目录名匹配
+		{"docs/x.md", "writers"},                    // 不含 / 的模式匹配任意层级
+		{"a/docs/y.java", "writers"},
+		{"db/schema.sql", "dba"},
+		{"services/api/src/Api.java", "api"},
+		{"services/api", "platform"}, // 以 / 结尾只匹配目录下的文件
+		{"tools/Gen.java", "tooling"},
+		{"a/tools/Gen.java", "platform"}, // 含 / 的模式从根目录开始
+		{"tools/nested/Gen.java", "platform"},
+		{"services/payments/internal/Ledger.java", "core"}, // 后面的条目优先
+		{"services/a/b/internal/X.java", "core"},
+		{"README.md", "platform"},
+	}
+	for _, tt := range tests {
+		if got := owners.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if got := (Owners{}).Match("a/B.java"); got != "" {
+		t.Errorf("empty owners matched %q", got)
+	}
+}
+
+func TestLoadOwners(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 两种写法: 映射列表，或 owners 段
+	for _, content := range []string{
+		"- path: services/payments\n  team: payments\n",
+		"owners:\n  - path: services/payments\n    team: payments\n",
+	} {
+		owners, err := LoadOwners(write("owners.yaml", content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := owners.Match("services/payments/A.java"); got != "payments" {
+			t.Errorf("%q: Match = %q", content, got)
+		}
+	}
+
+	if _, err := LoadOwners(write("bad.yaml", "- path: services\n")); err == nil {
+		t.Error("entry without a team loaded")
+	}
+	if owners, err := LoadOwners(""); owners != nil || err != nil {
+		t.Errorf("LoadOwners(\"\") = %v, %v", owners, err)
+	}
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 污点经过函数时的传播方式 (规则文件 propagation 段的 kind)
+const (
+	PropagationPassThrough  = "PASS_THROUGH"  // 返回值仍然携带输入 (trim、decode、toString 等)
+	PropagationSanitizerFor = "SANITIZER_FOR" // 对 vuln_types 中的漏洞类型消除了输入的危险性，其它类型不受影响
+	PropagationNeutral      = "NEUTRAL"       // 返回值不携带输入的内容 (isEmpty、length 等)
+)
+
+// PropagationRule 一个常用工具函数的传播语义
+// function 为 "Class.method" 时匹配静态调用 (类名可以是全限定名的最后一段)，污点来自第一个参数；
+// 只有方法名时匹配任意接收者上的实例方法，污点来自接收者
+type PropagationRule struct {
+	Function  string   `yaml:"function"`
+	Kind      string   `yaml:"kind"`
+	VulnTypes []string `yaml:"vuln_types,omitempty"` // SANITIZER_FOR 适用的漏洞类型，"*" 表示所有类型
+}
+
+// Static 是否是静态调用 (Class.method)
+func (r PropagationRule) Static() bool {
+	return strings.Contains(r.Function, ".")
+}
+
+// Sanitizes 对该漏洞类型是否是净化函数
+func (r PropagationRule) Sanitizes(vulnType string) bool {
+	if r.Kind != PropagationSanitizerFor {
+		return false
+	}
+	for _, t := range r.VulnTypes {
+		if t == "*" || strings.EqualFold(t, vulnType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 检查 kind 和 SANITIZER_FOR 的漏洞类型
+func (r *PropagationRule) Validate() error {
+	r.Kind = strings.ToUpper(strings.TrimSpace(r.Kind))
+	r.Function = strings.TrimSpace(r.Function)
+	if r.Function == "" {
+		return fmt.Errorf("propagation rule: function is required")
+	}
+	switch r.Kind {
+	case PropagationPassThrough, PropagationNeutral:
+	case PropagationSanitizerFor:
+		if len(r.VulnTypes) == 0 {
+			return fmt.Errorf("propagation rule %q: vuln_types is required for %s", r.Function, r.Kind)
+		}
+	default:
+		return fmt.Errorf("propagation rule %q: unknown kind %q (PASS_THROUGH, SANITIZER_FOR or NEUTRAL)", r.Function, r.Kind)
+	}
+	return nil
+}
+
+// PropagationTable 按函数查找传播语义
+type PropagationTable map[string]PropagationRule
+
+// Lookup 查找调用 qualifier.method 的传播语义: 先按 类名.方法名 查找静态规则 (qualifier 取最后一段)，
+// 再按方法名查找实例方法规则；qualifier 为空 (同一个类中的调用) 时不匹配
+func (t PropagationTable) Lookup(qualifier, method string) (PropagationRule, bool) {
+	if qualifier == "" {
+		return PropagationRule{}, false
+	}
+	class := qualifier[strings.LastIndex(qualifier, ".")+1:]
+	if r, ok := t[class+"."+method]; ok {
+		return r, true
+	}
+	r, ok := t[method]
+	return r, ok
+}
+
+// builtinPropagation 内置的传播语义
+var builtinPropagation = []PropagationRule{
+	// 输入原样 (或只改变大小写/空白) 传递
+	{Function: "StringUtils.trim", Kind: PropagationPassThrough},
+	{Function: "StringUtils.trimToEmpty", Kind: PropagationPassThrough},
+	{Function: "StringUtils.trimToNull", Kind: PropagationPassThrough},
+	{Function: "StringUtils.strip", Kind: PropagationPassThrough},
+	{Function: "StringUtils.defaultString", Kind: PropagationPassThrough},
+	{Function: "StringUtils.defaultIfBlank", Kind: PropagationPassThrough},
+	{Function: "StringUtils.defaultIfEmpty", Kind: PropagationPassThrough},
+	{Function: "StringUtils.lowerCase", Kind: PropagationPassThrough},
+	{Function: "StringUtils.upperCase", Kind: PropagationPassThrough},
+	{Function: "String.valueOf", Kind: PropagationPassThrough},
+	{Function: "Objects.toString", Kind: PropagationPassThrough},
+	{Function: "Objects.requireNonNull", Kind: PropagationPassThrough},
+	{Function: "Optional.of", Kind: PropagationPassThrough},
+	{Function: "Optional.ofNullable", Kind: PropagationPassThrough},
+	{Function: "URLDecoder.decode", Kind: PropagationPassThrough},
+	{Function: "IOUtils.toString", Kind: PropagationPassThrough},
+	{Function: "trim", Kind: PropagationPassThrough},
+	{Function: "strip", Kind: PropagationPassThrough},
+	{Function: "toLowerCase", Kind: PropagationPassThrough},
+	{Function: "toUpperCase", Kind: PropagationPassThrough},
+	{Function: "toString", Kind: PropagationPassThrough},
+	{Function: "substring", Kind: PropagationPassThrough},
+	{Function: "intern", Kind: PropagationPassThrough},
+	{Function: "orElse", Kind: PropagationPassThrough},
+	{Function: "orElseGet", Kind: PropagationPassThrough},
+	{Function: "orElseThrow", Kind: PropagationPassThrough},
+
+	// 按输出上下文转义: 只对对应的漏洞类型有效 (HTML 转义不能防止 SQL 注入)
+	{Function: "HtmlUtils.htmlEscape", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "StringEscapeUtils.escapeHtml", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "StringEscapeUtils.escapeHtml4", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "StringEscapeUtils.escapeEcmaScript", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "StringEscapeUtils.escapeXml", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "Encode.forHtml", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "Encode.forHtmlContent", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "Encode.forHtmlAttribute", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "Encode.forJavaScript", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "Jsoup.clean", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "encodeForHTML", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "encodeForHTMLAttribute", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "encodeForJavaScript", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS"}},
+	{Function: "encodeForSQL", Kind: PropagationSanitizerFor, VulnTypes: []string{"SQLI"}},
+	{Function: "encodeForLDAP", Kind: PropagationSanitizerFor, VulnTypes: []string{"LDAP_INJECTION"}},
+	{Function: "encodeForDN", Kind: PropagationSanitizerFor, VulnTypes: []string{"LDAP_INJECTION"}},
+	{Function: "encodeForXPath", Kind: PropagationSanitizerFor, VulnTypes: []string{"XPATH_INJECTION"}},
+	// URL 编码后 / < > 等字符不再有特殊含义，但对 SQL、命令等仍然不是可靠的防护
+	{Function: "URLEncoder.encode", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS", "PATH_TRAVERSAL", "REDIRECT"}},
+	{Function: "Encode.forUriComponent", Kind: PropagationSanitizerFor, VulnTypes: []string{"XSS", "PATH_TRAVERSAL", "REDIRECT"}},
+	{Function: "FilenameUtils.getName", Kind: PropagationSanitizerFor, VulnTypes: []string{"PATH_TRAVERSAL"}},
+	// 转换为数字 / UUID 后不再包含可注入的文本
+	{Function: "Integer.parseInt", Kind: PropagationSanitizerFor, VulnTypes: []string{"*"}},
+	{Function: "Integer.valueOf", Kind: PropagationSanitizerFor, VulnTypes: []string{"*"}},
+	{Function: "Long.parseLong", Kind: PropagationSanitizerFor, VulnTypes: []string{"*"}},
+	{Function: "Long.valueOf", Kind: PropagationSanitizerFor, VulnTypes: []string{"*"}},
+	{Function: "UUID.fromString", Kind: PropagationSanitizerFor, VulnTypes: []string{"*"}},
+
+	// 返回值不携带输入的内容
+	{Function: "StringUtils.isEmpty", Kind: PropagationNeutral},
+	{Function: "StringUtils.isBlank", Kind: PropagationNeutral},
+	{Function: "StringUtils.isNotEmpty", Kind: PropagationNeutral},
+	{Function: "StringUtils.isNotBlank", Kind: PropagationNeutral},
+	{Function: "length", Kind: PropagationNeutral},
+	{Function: "size", Kind: PropagationNeutral},
+	{Function: "isEmpty", Kind: PropagationNeutral},
+	{Function: "hashCode", Kind: PropagationNeutral},
+	{Function: "equals", Kind: PropagationNeutral},
+	{Function: "contains", Kind: PropagationNeutral},
+	{Function: "startsWith", Kind: PropagationNeutral},
+	{Function: "endsWith", Kind: PropagationNeutral},
+}
+
+// GetBuiltinPropagation 返回内置的传播语义表
+func GetBuiltinPropagation() PropagationTable {
+	table := make(PropagationTable, len(builtinPropagation))
+	for _, r := range builtinPropagation {
+		table[r.Function] = r
+	}
+	return table
+}
+
+// LoadPropagation 返回内置的传播语义加上规则文件 propagation 段中的条目 (同名函数覆盖内置条目；path 为空时只返回内置表)
+func LoadPropagation(path string) (PropagationTable, error) {
+	table := GetBuiltinPropagation()
+	if path == "" {
+		return table, nil
+	}
+	file, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range file.Propagation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		table[r.Function] = r
+	}
+	return table, nil
+}
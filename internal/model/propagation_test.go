@@ -0,0 +1,121 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPropagationLookup(t *testing.T) {
+	table := GetBuiltinPropagation()
+	tests := []struct {
+		qualifier, method string
+		want              string // 命中的条目，空表示没有
+	}{
+		{"StringUtils", "trim", "StringUtils.trim"},
+		{"org.apache.commons.lang3.StringUtils", "trim", "StringUtils.trim"}, // 全限定名取最后一段
+		{"name", "trim", "trim"},                                             // 实例方法
+		{"request.getParameter(\"q\")", "toLowerCase", "toLowerCase"},
+		{"HtmlUtils", "htmlEscape", "HtmlUtils.htmlEscape"},
+		{"MyUtils", "htmlEscape", ""}, // 静态条目只匹配对应的类
+		{"", "trim", ""},              // 同一个类中的方法
+		{"name", "replace", ""},
+	}
+	for _, tt := range tests {
+		rule, ok := table.Lookup(tt.qualifier, tt.method)
+		if got := map[bool]string{true: rule.Function}[ok]; got != tt.want {
+			t.Errorf("Lookup(%s, %s) = %q, want %q", tt.qualifier, tt.method, got, tt.want)
+		}
+	}
+}
+
+// 净化函数只对声明的漏洞类型有效: HTML 转义不能防止 SQL 注入或命令注入
+func TestPropagationSanitizes(t *testing.T) {
+	table := GetBuiltinPropagation()
+	tests := []struct {
+		function, vulnType string
+		want               bool
+	}{
+		{"HtmlUtils.htmlEscape", "XSS", true},
+		{"HtmlUtils.htmlEscape", "xss", true},
+		{"HtmlUtils.htmlEscape", "SQLI", false},
+		{"HtmlUtils.htmlEscape", "RCE", false},
+		{"URLEncoder.encode", "PATH_TRAVERSAL", true},
+		{"URLEncoder.encode", "SQLI", false},
+		{"encodeForSQL", "SQLI", true},
+		{"encodeForSQL", "XSS", false},
+		{"Integer.parseInt", "SQLI", true}, // "*": 任意类型
+		{"Integer.parseInt", "RCE", true},
+		{"StringUtils.trim", "XSS", false}, // PASS_THROUGH 不是净化函数
+		{"length", "SQLI", false},
+	}
+	for _, tt := range tests {
+		rule, ok := table[tt.function]
+		if !ok {
+			t.Fatalf("no built-in entry %s", tt.function)
+		}
+		if got := rule.Sanitizes(tt.vulnType); got != tt.want {
+			t.Errorf("%s.Sanitizes(%s) = %v, want %v", tt.function, tt.vulnType, got, tt.want)
+		}
+	}
+}
+
+func TestPropagationValidate(t *testing.T) {
+	tests := []struct {
+		rule PropagationRule
+		err  string // 错误信息的片段，空表示有效
+	}{
+		{PropagationRule{Function: " Texts.clean ", Kind: " pass_through "}, ""},
+		{PropagationRule{Function: "Texts.clean", Kind: "sanitizer_for", VulnTypes: []string{"XSS"}}, ""},
+		{PropagationRule{Function: "isValid", Kind: "neutral"}, ""},
+		{PropagationRule{Kind: PropagationNeutral}, "function is required"},
+		{PropagationRule{Function: "Texts.clean", Kind: PropagationSanitizerFor}, "vuln_types is required"},
+		{PropagationRule{Function: "Texts.clean", Kind: "SANITIZER"}, "unknown kind"},
+	}
+	for _, tt := range tests {
+		rule := tt.rule
+		err := rule.Validate()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%+v: %v", tt.rule, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%+v: error %v, want %q", tt.rule, err, tt.err)
+		case err == nil && (rule.Kind != strings.ToUpper(strings.TrimSpace(tt.rule.Kind)) || rule.Function != strings.TrimSpace(tt.rule.Function)):
+			t.Errorf("%+v: not normalized: %+v", tt.rule, rule)
+		}
+	}
+}
+
+// 规则文件的 propagation 段追加新条目，同名条目覆盖内置条目
+func TestLoadPropagation(t *testing.T) {
+	table, err := LoadPropagation(filepath.Join("testdata", "propagation.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != len(GetBuiltinPropagation())+2 {
+		t.Errorf("table has %d entries, want the built-in ones plus 2", len(table))
+	}
+	if r, ok := table.Lookup("com.acme.SqlSafe", "quote"); !ok || r.Kind != PropagationSanitizerFor || !r.Sanitizes("SQLI") || r.Sanitizes("XSS") {
+		t.Errorf("SqlSafe.quote = %+v, %v", r, ok)
+	}
+	if r, ok := table.Lookup("Texts", "normalize"); !ok || r.Kind != PropagationPassThrough {
+		t.Errorf("Texts.normalize = %+v, %v", r, ok)
+	}
+	if r := table["HtmlUtils.htmlEscape"]; !r.Sanitizes("SQLI") || !r.Sanitizes("XSS") {
+		t.Errorf("override of HtmlUtils.htmlEscape not applied: %+v", r)
+	}
+	if builtin := GetBuiltinPropagation()["HtmlUtils.htmlEscape"]; builtin.Sanitizes("SQLI") {
+		t.Error("loading a rules file modified the built-in table")
+	}
+
+	if table, err := LoadPropagation(""); err != nil || len(table) != len(GetBuiltinPropagation()) {
+		t.Errorf("LoadPropagation(\"\") = %d entries, %v", len(table), err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(invalid, []byte("propagation:\n  - function: \"Texts.clean\"\n    kind: \"SANITIZER_FOR\"\n"), 0644)
+	if _, err := LoadPropagation(invalid); err == nil || !strings.Contains(err.Error(), "vuln_types is required") {
+		t.Errorf("invalid propagation rule: %v", err)
+	}
+}
@@ -2,10 +2,13 @@ package model
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 
+	"LSPTracer/internal/i18n"
+
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,28 +23,238 @@ type SinkRule struct {
 	Pattern    *regexp.Regexp `yaml:"-"`           // 正则匹配模式 (运行时生成)
 	SkipSafe   bool           `yaml:"skip_safe"`   // 是否跳过常量参数
 	IsStatic   bool           `yaml:"is_static"`   // 是否为静态方法
+
+	MatchSubtypes bool     `yaml:"match_subtypes,omitempty"` // 是否同时匹配类型层级表中的子类/实现类
+	CheckArg      int      `yaml:"check_arg,omitempty"`      // skip_safe 只检查第 N 个参数 (从 1 开始，0 表示全部参数)
+	Annotation    bool     `yaml:"annotation,omitempty"`     // 匹配注解 (@ClassName(...)) 而不是方法调用，method_name 留空
+	Subtypes      []string `yaml:"-"`                        // 匹配的子类型 (加载时根据层级表生成)
+
+	// project: 类在项目源码中 (自己的封装)，只通过 workspace/symbol 确认，不退回 import 检查
+	Scope string `yaml:"scope,omitempty"`
+
+	// 自定义规则的双语描述 (-lang)，只写了一种时两种语言都使用它
+	DescEn string `yaml:"desc_en,omitempty"`
+	DescZh string `yaml:"desc_zh,omitempty"`
+
+	CWE         string   `yaml:"cwe,omitempty"`         // CWE 编号 (e.g. CWE-78)
+	References  []string `yaml:"references,omitempty"`  // 参考链接
+	Remediation string   `yaml:"remediation,omitempty"` // 修复建议
+
+	// 按链路特征 (Source 类型、数据流、URL 控制范围、授权) 调整发现的等级，多条同时满足时按 OverridePolicy 取舍
+	SeverityOverrides []SeverityOverride `yaml:"severity_overrides,omitempty"`
+	OverridePolicy    string             `yaml:"override_policy,omitempty"` // most_severe (默认) / first
 }
 
-// LoadRulesFromFile 从 YAML 文件加载规则
-func LoadRulesFromFile(path string) ([]SinkRule, error) {
+// RuleScopeProject 规则的类在项目源码中 (SinkRule.Scope)
+const RuleScopeProject = "project"
+
+// InProject 规则的类是否是项目自己的类
+func (r *SinkRule) InProject() bool {
+	return r.Scope == RuleScopeProject
+}
+
+// Description 报告语言 lang 的规则描述: 优先使用对应语言的 desc_en / desc_zh，
+// 其次是 desc (内置规则的描述有两种语言的版本)，最后是另一种语言的描述
+func (r *SinkRule) Description(lang string) string {
+	own, other := r.DescEn, r.DescZh
+	if lang == i18n.Chinese {
+		own, other = other, own
+	}
+	switch {
+	case own != "":
+		return own
+	case r.Desc != "":
+		return i18n.Desc(lang, r.Desc)
+	}
+	return other
+}
+
+// CWEURL 返回 CWE 在 MITRE 上的页面地址，没有 CWE 时返回空字符串
+func (r *SinkRule) CWEURL() string {
+	id := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(r.CWE)), "CWE-")
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", id)
+}
+
+// SeverityRank 返回严重等级的排序值 (info < low < medium < high < critical)，无法识别时返回 -1
+func SeverityRank(severity string) int {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "info":
+		return 0
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	case "critical":
+		return 4
+	}
+	return -1
+}
+
+// LowerSeverity 返回低一级的严重等级 (info 保持不变)，无法识别的等级视为 medium
+func LowerSeverity(severity string) string {
+	levels := []string{"Info", "Low", "Medium", "High", "Critical"}
+	rank := SeverityRank(severity)
+	if rank < 0 {
+		rank = 2
+	}
+	if rank > 0 {
+		rank--
+	}
+	return levels[rank]
+}
+
+// ruleInfo 内置规则按漏洞类型共享的 CWE / 参考链接 / 修复建议
+type ruleInfo struct {
+	cwe         string
+	references  []string
+	remediation string
+}
+
+var builtinRuleInfo = map[string]ruleInfo{
+	"RCE": {
+		cwe:         "CWE-78",
+		references:  []string{"https://owasp.org/www-community/attacks/Command_Injection"},
+		remediation: "Avoid passing user input to command or script execution. Use a fixed command with an allowlist of arguments, and never build commands or scripts by string concatenation.",
+	},
+	"EXPRESSION_INJECTION": {
+		cwe:         "CWE-917",
+		references:  []string{"https://owasp.org/www-community/vulnerabilities/Expression_Language_Injection", "https://portswigger.net/web-security/server-side-template-injection"},
+		remediation: "Never evaluate expressions or templates built from user input. Use fixed expressions/templates and pass user data as variables; for SpEL use SimpleEvaluationContext instead of StandardEvaluationContext.",
+	},
+	"JNDI_INJECTION": {
+		cwe:         "CWE-74",
+		references:  []string{"https://owasp.org/www-community/attacks/JNDI_Injection"},
+		remediation: "Never pass user input to JNDI lookups. Use fixed names, and on older JDKs disable remote codebases (com.sun.jndi.ldap.object.trustURLCodebase=false).",
+	},
+	"LDAP_INJECTION": {
+		cwe:         "CWE-90",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/LDAP_Injection_Prevention_Cheat_Sheet.html"},
+		remediation: "Escape user input with an LDAP filter encoder (e.g. LdapEncoder.filterEncode) or build filters with Spring LDAP's query builder instead of string concatenation.",
+	},
+	"XPATH_INJECTION": {
+		cwe:         "CWE-643",
+		references:  []string{"https://owasp.org/www-community/attacks/XPATH_Injection"},
+		remediation: "Use parameterized XPath expressions (XPathVariableResolver) instead of concatenating user input into the expression.",
+	},
+	"UNSERIALIZE": {
+		cwe:         "CWE-502",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Deserialization_Cheat_Sheet.html"},
+		remediation: "Do not deserialize untrusted data. If unavoidable, restrict the allowed classes (ObjectInputFilter, SafeConstructor for SnakeYAML, XStream allowlists, FastJSON safeMode).",
+	},
+	"SSRF": {
+		cwe:         "CWE-918",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Server_Side_Request_Forgery_Prevention_Cheat_Sheet.html"},
+		remediation: "Validate outgoing URLs against an allowlist of schemes and hosts, and block requests to internal address ranges after DNS resolution.",
+	},
+	"SQLI": {
+		cwe:         "CWE-89",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/SQL_Injection_Prevention_Cheat_Sheet.html"},
+		remediation: "Use parameterized queries (PreparedStatement placeholders, #{} in MyBatis) instead of concatenating user input into SQL.",
+	},
+	"XSS": {
+		cwe:         "CWE-79",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Cross_Site_Scripting_Prevention_Cheat_Sheet.html"},
+		remediation: "HTML-encode user input for the output context before writing it to the response, or rely on a template engine with auto-escaping.",
+	},
+	"PATH_TRAVERSAL": {
+		cwe:         "CWE-22",
+		references:  []string{"https://owasp.org/www-community/attacks/Path_Traversal"},
+		remediation: "Resolve the path against a fixed base directory, normalize it and verify it still starts with the base directory. Reject names containing path separators or \"..\".",
+	},
+	"XXE": {
+		cwe:         "CWE-611",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/XML_External_Entity_Prevention_Cheat_Sheet.html"},
+		remediation: "Disable DTDs and external entities on the parser (disallow-doctype-decl, external-general-entities and external-parameter-entities set to false).",
+	},
+	"REDIRECT": {
+		cwe:         "CWE-601",
+		references:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Unvalidated_Redirects_and_Forwards_Cheat_Sheet.html"},
+		remediation: "Only redirect to relative paths or to hosts on an allowlist. Map user input to known destinations instead of using it as the URL.",
+	},
+}
+
+// ruleFile 规则文件的完整格式；也兼容只有规则列表的旧格式
+type ruleFile struct {
+	Rules         []SinkRule        `yaml:"rules"`
+	TypeHierarchy TypeHierarchy     `yaml:"type_hierarchy"` // 追加到内置层级表
+	Secrets       []SecretPattern   `yaml:"secrets"`        // 追加到内置敏感信息规则
+	Allow         []AllowRule       `yaml:"allow"`          // 已知安全的封装 (白名单)
+	ConfigRules   []ConfigRule      `yaml:"config_rules"`   // 追加到内置配置检查规则
+	Propagation   []PropagationRule `yaml:"propagation"`    // 追加/覆盖内置的传播语义
+}
+
+func readRuleFile(path string) (ruleFile, error) {
+	var file ruleFile
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return file, err
+	}
+	if err := yaml.Unmarshal(data, &file.Rules); err != nil {
+		// 不是规则列表: 按 {rules, type_hierarchy, secrets, allow, config_rules, propagation} 格式解析
+		file = ruleFile{}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return file, err
+		}
 	}
+	return file, nil
+}
 
-	var rules []SinkRule
-	if err := yaml.Unmarshal(data, &rules); err != nil {
+// LoadRulesFromFile 从 YAML 文件加载规则
+func LoadRulesFromFile(path string) ([]SinkRule, error) {
+	file, err := readRuleFile(path)
+	if err != nil {
 		return nil, err
 	}
+	rules := file.Rules
 
 	// Post-process: Compile regex patterns
+	hierarchy := BuiltinTypeHierarchy().Merge(file.TypeHierarchy)
 	for i := range rules {
 		rules[i].Compile()
+		rules[i].applyHierarchy(hierarchy)
+		if s := rules[i].Scope; s != "" && s != RuleScopeProject {
+			return nil, fmt.Errorf("rule %q: unknown scope %q (use %q)", rules[i].Name, s, RuleScopeProject)
+		}
+		if err := rules[i].validateOverrides(); err != nil {
+			return nil, fmt.Errorf("rule %q: %v", rules[i].Name, err)
+		}
 	}
 
 	return rules, nil
 }
 
+// applyHierarchy 为 match_subtypes 规则填充子类型
+func (r *SinkRule) applyHierarchy(h TypeHierarchy) {
+	r.Subtypes = nil
+	if r.MatchSubtypes {
+		r.Subtypes = h.Subtypes(r.ClassName)
+	}
+}
+
+// ExportRules 将规则序列化为 LoadRulesFromFile 可读取的 YAML
+// Pattern 不会被导出，加载时由 class_name/method_name 重新生成
+func ExportRules(w io.Writer, rules []SinkRule) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(rules); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Target 规则目标的展示形式: "java.lang.Runtime.exec" 或注解规则的 "@org.example.Query"
+func (r *SinkRule) Target() string {
+	if r.Annotation {
+		return "@" + r.ClassName
+	}
+	return r.ClassName + "." + r.MethodName
+}
+
 // Compile 预编译规则的正则
 func (r *SinkRule) Compile() {
 	shortClass := r.ClassName
@@ -50,10 +263,16 @@ func (r *SinkRule) Compile() {
 	}
 
 	if r.Name == "" {
-		r.Name = fmt.Sprintf("%s (%s.%s)", r.VulnType, shortClass, r.MethodName)
+		if r.Annotation {
+			r.Name = fmt.Sprintf("%s (@%s)", r.VulnType, shortClass)
+		} else {
+			r.Name = fmt.Sprintf("%s (%s.%s)", r.VulnType, shortClass, r.MethodName)
+		}
 	}
 
-	if r.MethodName == "<init>" {
+	if r.Annotation {
+		r.Pattern = regexp.MustCompile(`@(?:` + regexp.QuoteMeta(r.ClassName) + `|` + regexp.QuoteMeta(shortClass) + `)\s*\(`)
+	} else if r.MethodName == "<init>" {
 		r.Pattern = regexp.MustCompile(`new\s+` + regexp.QuoteMeta(shortClass) + `\s*\(`)
 	} else {
 		r.Pattern = regexp.MustCompile(`\.` + regexp.QuoteMeta(r.MethodName) + `\s*\(`)
@@ -64,32 +283,30 @@ func (r *SinkRule) Compile() {
 func GetBuiltinRules() []SinkRule {
 	rules := []SinkRule{}
 
-	add := func(vulnType, desc, severity, className, methodName string, skipSafe bool, isStatic bool) {
-		shortClass := className
-		if idx := strings.LastIndex(className, "."); idx != -1 {
-			shortClass = className[idx+1:]
-		}
-
-		name := fmt.Sprintf("%s (%s.%s)", vulnType, shortClass, methodName)
-
-		var pat *regexp.Regexp
-		if methodName == "<init>" {
-			pat = regexp.MustCompile(`new\s+` + regexp.QuoteMeta(shortClass) + `\s*\(`)
-		} else {
-			pat = regexp.MustCompile(`\.` + regexp.QuoteMeta(methodName) + `\s*\(`)
-		}
-
-		rules = append(rules, SinkRule{
-			Name:       name,
+	// add 返回新规则的指针，只能在下一次 add 之前用于设置额外字段 (e.g. CheckArg)
+	add := func(vulnType, desc, severity, className, methodName string, skipSafe bool, isStatic bool) *SinkRule {
+		rule := SinkRule{
 			VulnType:   vulnType,
 			Desc:       desc,
 			Severity:   severity,
 			ClassName:  className,
 			MethodName: methodName,
-			Pattern:    pat,
 			SkipSafe:   skipSafe,
 			IsStatic:   isStatic,
-		})
+			Annotation: methodName == "",
+		}
+		if info, ok := builtinRuleInfo[vulnType]; ok {
+			rule.CWE = info.cwe
+			rule.References = info.references
+			rule.Remediation = info.remediation
+		}
+		// 层级表中有子类型的类默认匹配子类型 (e.g. Statement 规则覆盖 PreparedStatement)
+		rule.MatchSubtypes = len(builtinTypeHierarchy[className]) > 0
+		rule.applyHierarchy(builtinTypeHierarchy)
+		// 名称和正则都由 class/method 推导，与从 YAML 加载的规则保持一致 (保证 export 后可以原样加载)
+		rule.Compile()
+		rules = append(rules, rule)
+		return &rules[len(rules)-1]
 	}
 
 	// ================= RCE (任意代码执行) =================
@@ -102,6 +319,40 @@ func GetBuiltinRules() []SinkRule {
 	add("RCE", "任意代码执行漏洞", "High", "javax.script.ScriptEngine", "eval", true, false)
 	add("RCE", "任意代码执行漏洞", "High", "groovy.lang.GroovyShell", "evaluate", true, false)
 	add("RCE", "任意代码执行漏洞", "High", "org.codehaus.groovy.runtime.InvokerHelper", "runScript", true, true) // Static
+	// 反射 / 类加载: 只有类名、URL 或字节码可控时才能利用，常量参数 (Class.forName("com.mysql.jdbc.Driver")) 直接跳过
+	add("RCE", "任意类加载 (类名可控时会执行目标类的静态初始化代码)", "Medium", "java.lang.Class", "forName", true, true).CheckArg = 1 // Static
+	add("RCE", "远程类加载 (URL 可控时可以加载任意代码)", "High", "java.net.URLClassLoader", "<init>", true, false).CheckArg = 1
+	// defineClass 各重载的字节码参数位置不同，检查全部参数
+	add("RCE", "任意字节码加载", "High", "java.lang.ClassLoader", "defineClass", true, false)
+	add("RCE", "远程类加载 (MLet)", "High", "javax.management.loading.MLet", "<init>", true, false).CheckArg = 1
+	add("RCE", "远程类加载 (MLet)", "High", "javax.management.loading.MLet", "getMBeansFromURL", true, false).CheckArg = 1
+	// Method.invoke 在框架和工具代码中极为常见，方法名几乎都是常量或来自注解扫描，误报很多: 默认只作为 Low 报告；
+	// 方法名来自变量的 getMethod/getDeclaredMethod 流入 invoke 时由组合规则 "RCE (reflective invoke)" 以 Medium 报告
+	add("RCE", "反射调用 (噪声较大，仅在方法名可控时可利用)", "Low", "java.lang.reflect.Method", "invoke", false, false)
+
+	// ================= EXPRESSION_INJECTION (表达式/模板注入) =================
+	// 配置代码中有大量常量表达式，只检查表达式/模板源码所在的参数
+	add("EXPRESSION_INJECTION", "表达式注入漏洞", "High", "org.springframework.expression.ExpressionParser", "parseExpression", true, false).CheckArg = 1
+	add("EXPRESSION_INJECTION", "表达式注入漏洞", "High", "javax.el.ELProcessor", "eval", true, false).CheckArg = 1
+	// OGNL: getValue/setValue 的第一个参数是表达式源码 (或 parseExpression 解析后的语法树)
+	add("EXPRESSION_INJECTION", "表达式注入漏洞", "High", "ognl.Ognl", "parseExpression", true, true).CheckArg = 1 // Static
+	add("EXPRESSION_INJECTION", "表达式注入漏洞", "High", "ognl.Ognl", "getValue", true, true).CheckArg = 1        // Static
+	add("EXPRESSION_INJECTION", "表达式注入漏洞", "High", "ognl.Ognl", "setValue", true, true).CheckArg = 1        // Static
+	// new Template(name, new StringReader(src), cfg): 模板源码在构造时确定；
+	// 渲染这个模板的 process() 由组合规则 "EXPRESSION_INJECTION (Freemarker Template.process)" 报告
+	add("EXPRESSION_INJECTION", "模板注入漏洞", "High", "freemarker.template.Template", "<init>", true, false).CheckArg = 2
+	add("EXPRESSION_INJECTION", "模板注入漏洞", "High", "org.apache.velocity.app.Velocity", "evaluate", true, true).CheckArg = 4 // Static
+	add("EXPRESSION_INJECTION", "模板注入漏洞", "High", "org.apache.velocity.app.VelocityEngine", "evaluate", true, false).CheckArg = 4
+	add("EXPRESSION_INJECTION", "模板注入漏洞", "High", "org.thymeleaf.ITemplateEngine", "process", true, false).CheckArg = 1
+
+	// ================= JNDI / LDAP / XPATH 注入 =================
+	// InitialContext / DirContext 通过类型层级匹配，无需重复规则；常量的 JNDI 名称 (java:comp/env/...) 直接跳过
+	add("JNDI_INJECTION", "JNDI注入漏洞", "High", "javax.naming.Context", "lookup", true, false).CheckArg = 1
+	// search(name, filter, ...): 过滤器是第二个参数
+	add("LDAP_INJECTION", "LDAP注入漏洞", "Medium", "javax.naming.directory.DirContext", "search", true, false).CheckArg = 2
+	add("LDAP_INJECTION", "LDAP注入漏洞", "Medium", "org.springframework.ldap.core.LdapTemplate", "search", true, false).CheckArg = 2
+	add("XPATH_INJECTION", "XPath注入漏洞", "Medium", "javax.xml.xpath.XPath", "evaluate", true, false).CheckArg = 1
+	add("XPATH_INJECTION", "XPath注入漏洞", "Medium", "javax.xml.xpath.XPath", "compile", true, false).CheckArg = 1
 
 	// ================= UNSERIALIZE (反序列化) =================
 	// 这些通常是高危的，误报较少
@@ -117,7 +368,6 @@ func GetBuiltinRules() []SinkRule {
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "java.net.URL", "openConnection", false, false)
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "java.net.URL", "openStream", false, false)
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "org.apache.http.client.HttpClient", "execute", true, false)
-	add("SSRF", "服务端请求伪造漏洞", "Medium", "org.apache.http.impl.client.CloseableHttpClient", "execute", true, false)
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "okhttp3.OkHttpClient", "newCall", true, false)
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "org.springframework.web.client.RestTemplate", "exchange", true, false)
 	add("SSRF", "服务端请求伪造漏洞", "Medium", "org.springframework.web.client.RestTemplate", "getForObject", true, false)
@@ -133,6 +383,13 @@ func GetBuiltinRules() []SinkRule {
 	add("SQLI", "SQL注入漏洞", "High", "org.mybatis.spring.SqlSessionTemplate", "selectList", true, false)
 	add("SQLI", "SQL注入漏洞", "High", "javax.persistence.EntityManager", "createNativeQuery", true, false)
 	add("SQLI", "SQL注入漏洞", "High", "com.jfinal.plugin.activerecord.Db", "find", true, true) // Static
+	// Spring JdbcTemplate: 只有 SQL 字符串 (第一个参数) 决定是否可注入，后面的参数通常是 RowMapper / 绑定参数
+	for _, m := range []string{"query", "queryForObject", "queryForList", "queryForMap", "queryForRowSet", "update", "batchUpdate", "execute"} {
+		add("SQLI", "SQL注入漏洞", "High", "org.springframework.jdbc.core.JdbcTemplate", m, true, false).CheckArg = 1
+	}
+	// 注解中的 SQL: 只有拼接或 ${} 占位符时才报告 (method_name 为空表示注解规则)
+	add("SQLI", "SQL注入漏洞", "High", "org.springframework.data.jpa.repository.Query", "", true, false)
+	add("SQLI", "SQL注入漏洞", "High", "org.apache.ibatis.annotations.Select", "", true, false)
 
 	// ================= XSS (跨站脚本) =================
 	// ❌ 移除了 getWriter, getOutputStream, getResponse 等“获取流”的操作
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 导出的内置规则重新加载后逐条相同: 正则由 class_name/method_name 重新生成，其它字段原样保留
+func TestExportRulesRoundTrip(t *testing.T) {
+	builtin := GetBuiltinRules()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportRules(f, builtin); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	loaded, err := LoadRulesFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(builtin) {
+		t.Fatalf("loaded %d rules, exported %d", len(loaded), len(builtin))
+	}
+	for i := range builtin {
+		want, got := builtin[i], loaded[i]
+		if got.Pattern.String() != want.Pattern.String() {
+			t.Errorf("%s: pattern %q, want %q", want.Name, got.Pattern, want.Pattern)
+		}
+		want.Pattern, got.Pattern = nil, nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: loaded rule differs from the built-in\n got: %+v\nwant: %+v", want.Name, got, want)
+		}
+	}
+}
+
+// 只写了 class_name/method_name 的规则: 名称和正则由它们推导
+func TestCompileDerivesNameAndPattern(t *testing.T) {
+	tests := []struct {
+		rule    SinkRule
+		name    string
+		matches string
+	}{
+		{SinkRule{VulnType: "RCE", ClassName: "java.lang.Runtime", MethodName: "exec"}, "RCE (Runtime.exec)", "rt.exec (cmd)"},
+		{SinkRule{VulnType: "RCE", ClassName: "java.lang.ProcessBuilder", MethodName: "<init>"}, "RCE (ProcessBuilder.<init>)", "new ProcessBuilder(cmd)"},
+		{SinkRule{VulnType: "SQLI", ClassName: "org.apache.ibatis.annotations.Select", Annotation: true}, "SQLI (@Select)", `@Select("select")`},
+	}
+	for _, tt := range tests {
+		tt.rule.Compile()
+		if tt.rule.Name != tt.name {
+			t.Errorf("name = %q, want %q", tt.rule.Name, tt.name)
+		}
+		if !tt.rule.Pattern.MatchString(tt.matches) {
+			t.Errorf("%s: pattern %q does not match %q", tt.name, tt.rule.Pattern, tt.matches)
+		}
+	}
+}
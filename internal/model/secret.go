@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SecretPattern 硬编码凭据 / 密钥的检测规则
+// Pattern 中第一个非空的捕获组是密钥的值 (用于去重和熵检查)；没有捕获组时使用整个匹配
+type SecretPattern struct {
+	Name       string   `yaml:"name"`
+	Pattern    string   `yaml:"pattern"`
+	Severity   string   `yaml:"severity"`
+	Files      []string `yaml:"files,omitempty"`       // 适用的文件扩展名 (e.g. ".java")，为空表示所有支持的文件
+	MinEntropy float64  `yaml:"min_entropy,omitempty"` // 值的最小香农熵 (bits/char)，过滤 "changeme" 之类的占位值
+
+	Re *regexp.Regexp `yaml:"-"`
+}
+
+// SecretFileExtensions 敏感信息扫描覆盖的文件类型
+var SecretFileExtensions = []string{".java", ".properties", ".yml", ".yaml", ".xml"}
+
+// Compile 预编译规则的正则
+func (p *SecretPattern) Compile() error {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("secret pattern %q: %v", p.Name, err)
+	}
+	p.Re = re
+	return nil
+}
+
+// AppliesTo 规则是否适用于该文件
+func (p *SecretPattern) AppliesTo(path string) bool {
+	if len(p.Files) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range p.Files {
+		if strings.ToLower(f) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// AsSinkRule 转换为报告使用的 SinkRule，所有敏感信息归入 SECRET 分组
+func (p *SecretPattern) AsSinkRule() SinkRule {
+	return SinkRule{
+		Name:        fmt.Sprintf("SECRET (%s)", p.Name),
+		VulnType:    "SECRET",
+		Desc:        "硬编码凭据/密钥",
+		Severity:    p.Severity,
+		CWE:         "CWE-798",
+		References:  []string{"https://cheatsheetseries.owasp.org/cheatsheets/Secrets_Management_Cheat_Sheet.html"},
+		Remediation: "Move the credential out of source control (environment variables, a secrets manager or an encrypted config), then rotate it because it must be considered leaked.",
+	}
+}
+
+// ShannonEntropy 计算字符串每个字符的香农熵
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// GetBuiltinSecretPatterns 返回内置的敏感信息规则
+func GetBuiltinSecretPatterns() []SecretPattern {
+	patterns := []SecretPattern{
+		{Name: "Private Key", Severity: "High", Pattern: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED )?PRIVATE KEY-----`},
+		{Name: "AWS Access Key", Severity: "High", Pattern: `\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`},
+		{Name: "AWS Secret Key", Severity: "High", Pattern: `(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`},
+		{Name: "JDBC URL with Credentials", Severity: "Medium", Pattern: `(jdbc:[a-z0-9]+:(?://[^/\s:@"']+:[^@\s"']+@|[^\s"']*[?;&]password=[^&;\s"']+)[^\s"'<]*)`},
+		{Name: "Hardcoded Password", Severity: "Medium", Files: []string{".java"}, MinEntropy: 2,
+			Pattern: `(?i)\b\w*(?:password|passwd|pwd|secret)\w*\s*=\s*"([^"]{4,})"`},
+		{Name: "Hardcoded Password", Severity: "Medium", Files: []string{".properties", ".yml", ".yaml"}, MinEntropy: 2,
+			Pattern: `(?i)^\s*[\w.\-]*(?:password|passwd|pwd|secret)\s*[:=]\s*["']?([^"'\s#]{4,})`},
+		{Name: "Hardcoded Password", Severity: "Medium", Files: []string{".xml"}, MinEntropy: 2,
+			Pattern: `(?i)(?:<[\w.:\-]*password[\w.:\-]*>([^<${}]{4,})</|\b[\w.:\-]*password[\w.:\-]*\s*=\s*"([^"${}]{4,})")`},
+	}
+	for i := range patterns {
+		// 内置正则都是常量，编译失败属于编码错误
+		if err := patterns[i].Compile(); err != nil {
+			panic(err)
+		}
+	}
+	return patterns
+}
+
+// LoadSecretPatterns 返回内置规则加上规则文件 secrets 段中的规则 (path 为空时只返回内置规则)
+func LoadSecretPatterns(path string) ([]SecretPattern, error) {
+	patterns := GetBuiltinSecretPatterns()
+	if path == "" {
+		return patterns, nil
+	}
+	file, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range file.Secrets {
+		if p.Severity == "" {
+			p.Severity = "Medium"
+		}
+		if err := p.Compile(); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
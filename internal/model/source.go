@@ -0,0 +1,105 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Source 的入口类型 (链路最后一步的分类)
+const (
+	SourceHTTP      = "HTTP"          // Controller / Servlet 等 HTTP 入口
+	SourceMQ        = "MESSAGE_QUEUE" // 消息队列监听器
+	SourceScheduled = "SCHEDULED"     // 定时任务、@PostConstruct 等容器回调
+	SourceCLI       = "CLI"           // public static void main(String[] args)
+	SourceUnknown   = "UNKNOWN"       // 不是可识别的入口
+)
+
+// Source 的输入信号 (链路最后一步的 SourceInput)
+const (
+	InputTainted = "tainted" // 有参数或读取了隐式输入 (request.getParameter 等)
+	InputNone    = "none"    // 没有参数也没有隐式输入
+	InputUnknown = "unknown" // 找不到 Source 所在的函数，无法判断
+)
+
+// 链路结束的原因 (Source 步骤的 Termination)
+// 只有 TerminationReachedEntry 的链路是完整的攻击路径，其它都是追踪在中途停止的片段
+const (
+	TerminationReachedEntry = "REACHED_ENTRY" // 到达框架入口
+	TerminationNoCallers    = "NO_CALLERS"    // 没有更多调用者 (或找不到调用点所在的函数)
+	TerminationDepthLimit   = "DEPTH_LIMIT"   // 调用者层数达到 -max-depth
+	TerminationFanOutLimit  = "FANOUT_LIMIT"  // 调用者数量超过 -max-callers，未展开的部分到此为止
+	TerminationTimeBudget   = "TIME_BUDGET"   // Sink 的追踪时间预算耗尽
+	TerminationCycle        = "CYCLE"         // 所有调用者都已经在当前链路中 (递归或互相调用)
+	TerminationTestOnly     = "TEST_ONLY"     // 调用者都在测试/示例代码中 (生产代码中没有调用者)
+)
+
+// TerminationDescriptions 报告中展示的结束原因说明
+var TerminationDescriptions = map[string]string{
+	TerminationReachedEntry: "reached a framework entry",
+	TerminationNoCallers:    "no further callers found",
+	TerminationDepthLimit:   "stopped at the depth limit (-max-depth)",
+	TerminationFanOutLimit:  "stopped at the fan-out limit (-max-callers)",
+	TerminationTimeBudget:   "stopped when the time budget ran out (-per-sink-timeout)",
+	TerminationCycle:        "all callers are already on the chain (cycle)",
+	TerminationTestOnly:     "reached only from tests",
+}
+
+// SourceKindNames -sources 参数中可以使用的名称
+var SourceKindNames = map[string]string{
+	"http":      SourceHTTP,
+	"mq":        SourceMQ,
+	"scheduled": SourceScheduled,
+	"cli":       SourceCLI,
+}
+
+// DefaultSources 严格模式默认接受的 Source 类型
+const DefaultSources = "http,mq,scheduled"
+
+// ParseSourceKinds 解析逗号分隔的 Source 类型名称 (http, mq, scheduled, cli)
+func ParseSourceKinds(list string) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		kind, ok := SourceKindNames[name]
+		if !ok {
+			names := make([]string, 0, len(SourceKindNames))
+			for n := range SourceKindNames {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown source kind %q (use %s)", name, strings.Join(names, ", "))
+		}
+		kinds[kind] = true
+	}
+	return kinds, nil
+}
+
+// StrictExclusion 返回严格模式排除该链路的原因，保留时返回空
+// 严格模式只保留到达入口 (TerminationReachedEntry) 的链路，且入口类型被接受、有输入；
+// accepted 为 nil 表示接受所有入口类型；没有结束原因和 Source 输入信号的链路 (单步分析器、旧版本的 JSON 结果) 不受严格模式影响
+func StrictExclusion(chain []ChainStep, accepted map[string]bool) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	source := chain[len(chain)-1]
+	if source.Termination != "" && source.Termination != TerminationReachedEntry {
+		return "chain ended with " + source.Termination + " before reaching an entry"
+	}
+	if source.SourceInput == "" {
+		return ""
+	}
+	if source.SourceKind == "" || source.SourceKind == SourceUnknown {
+		return "source is not a framework entry"
+	}
+	if accepted != nil && !accepted[source.SourceKind] {
+		return "source kind " + source.SourceKind + " is not accepted by -sources"
+	}
+	if source.SourceInput == InputNone {
+		return "source has no input"
+	}
+	return ""
+}
@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 // ChainStep 定义了漏洞追踪链路中的一个节点
 // 它是 analysis 和 report 共用的数据结构
 type ChainStep struct {
@@ -8,4 +10,59 @@ type ChainStep struct {
 	Func     string
 	Code     string
 	Analysis []string
+	Rule     *SinkRule // 命中的规则 (仅 Sink 步骤)
+
+	// 验证状态 (仅 Sink 步骤)，与规则的严重等级相互独立
+	// 非空表示发现没有经过验证以及原因 (e.g. "no call context")，报告中会降低其有效等级
+	Unverified string
+	// 可信度信号 (仅 Sink 步骤)，nil 表示没有数据 (e.g. 旧版本的 JSON 结果)
+	Confidence *Confidence
+	// Source 的入口类型 (仅 Source 步骤，SourceHTTP 等)，空表示未分类
+	SourceKind string
+	// 白名单或 lsptracer:ignore 注释的抑制/降级 (仅 Sink 步骤)，nil 表示没有被抑制
+	Suppression *Suppression
+	// Source 处理器方法对应的 HTTP 路由 (仅 Source 步骤，e.g. "POST /api/files/upload")，空表示不是 HTTP 端点或没有找到
+	Routes []string
+	// Source 的输入信号 (仅 Source 步骤，InputTainted 等)，空表示没有检查 (e.g. 截断的链路、单步分析器的发现)
+	SourceInput string
+	// 链路结束的原因 (仅 Source 步骤，TerminationReachedEntry 等)，空表示没有记录 (e.g. 单步分析器的发现、旧版本的 JSON 结果)
+	Termination string
+	// 严格模式排除的原因 (仅 Sink 步骤，报告阶段设置)，非空时默认不写入 HTML/SARIF 报告
+	StrictExcluded string
+	// SSRF 发现中输入能够控制的 URL 部分 (仅 Sink 步骤，URLControlFull 等)，空表示不是 SSRF 或无法还原 URL
+	URLControl string
+	// 规则 severity_overrides 中生效的条目 (仅 Sink 步骤)，nil 表示使用规则等级
+	SeverityOverride *SeverityOverride
+	// Sink 所在的模块 (最近的带构建文件的上级目录，相对项目根目录，根目录为 ".") 和 -owners 中负责的团队 (仅 Sink 步骤)
+	Module string
+	Owner  string
+	// 所在代码的类别 (CodeMain / CodeTest / CodeSample，报告阶段设置)，空表示没有分类 (e.g. 项目之外的文件)
+	CodeKind string
+	// 链路只能从测试/示例代码到达 (仅 Sink 步骤，Source 的 Termination 为 TerminationTestOnly)，报告中降低一级
+	TestOnly bool
+	// 同一个调用上同时命中的其它规则 (仅 Sink 步骤，按等级从高到低)；Rule 是其中等级最高的主规则
+	AlsoMatched []SinkRule
+
+	// 所在的类 (来自 documentSymbol 的祖先链，嵌套类用 "." 连接 e.g. "Outer.Inner"，匿名类为 "Outer$1")
+	// 空表示没有 LSP 数据；展示时使用 QualifiedFunc
+	Class string
+
+	// 所在函数的源码范围 (来自 documentSymbol，0-based，包含注解)
+	// FuncEndLine 为 0 表示没有 LSP 数据，报告回退到启发式查找
+	FuncStartLine int
+	FuncEndLine   int
+
+	// 扫描时保存的源码片段 (Context[0] 是第 ContextStart 行，0-based)
+	// 报告优先使用这里的内容，切换分支或在其它机器上生成报告时不需要读取源码
+	Context      []string
+	ContextStart int
+}
+
+// QualifiedFunc 带类名的函数名 (e.g. "OrderDao.execute(String)")，用于标题、控制台和指纹
+// Func 已经以类名开头时 (构造器、初始化块、匿名类方法) 原样返回
+func (s ChainStep) QualifiedFunc() string {
+	if s.Class == "" || strings.HasPrefix(s.Func, s.Class+".") {
+		return s.Func
+	}
+	return s.Class + "." + s.Func
 }
\ No newline at end of file
@@ -0,0 +1,24 @@
+package model
+
+import "testing"
+
+// 展示名带上所在类；Func 已经以类名开头 (构造器、初始化块、匿名类方法) 时不重复
+func TestQualifiedFunc(t *testing.T) {
+	tests := []struct {
+		step ChainStep
+		want string
+	}{
+		{ChainStep{Func: "execute(String)", Class: "OrderDao"}, "OrderDao.execute(String)"},
+		{ChainStep{Func: "run(String)", Class: "OrderService.Worker"}, "OrderService.Worker.run(String)"},
+		{ChainStep{Func: "OrderService$1.run()", Class: "OrderService$1"}, "OrderService$1.run()"},
+		{ChainStep{Func: "OrderService.Worker.<init>()", Class: "OrderService.Worker"}, "OrderService.Worker.<init>()"},
+		{ChainStep{Func: "OrderService.<clinit>", Class: "OrderService"}, "OrderService.<clinit>"},
+		{ChainStep{Func: "OrderDaoImpl(String)", Class: "OrderDao"}, "OrderDao.OrderDaoImpl(String)"}, // 只是前缀相同
+		{ChainStep{Func: "execute(String)"}, "execute(String)"},                                       // 没有 LSP 数据
+	}
+	for _, tt := range tests {
+		if got := tt.step.QualifiedFunc(); got != tt.want {
+			t.Errorf("QualifiedFunc(%q, %q) = %s, want %s", tt.step.Class, tt.step.Func, got, tt.want)
+		}
+	}
+}
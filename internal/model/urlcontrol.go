@@ -0,0 +1,26 @@
+package model
+
+// SSRF 发现中输入能够控制的 URL 部分 (Sink 步骤的 URLControl)，决定发现的有效等级
+const (
+	URLControlFull  = "FULL"  // 协议/主机可控: 可以请求任意地址 (内网服务、云元数据)，保持规则等级
+	URLControlPath  = "PATH"  // 主机固定，只能控制路径: 有效等级降低一级
+	URLControlQuery = "QUERY" // 主机和路径固定，只能控制查询参数或片段: 有效等级降低两级
+)
+
+// URLControlDescriptions 报告中展示的说明
+var URLControlDescriptions = map[string]string{
+	URLControlFull:  "scheme/host controlled",
+	URLControlPath:  "path-only controlled",
+	URLControlQuery: "query-only controlled",
+}
+
+// URLControlPenalty 返回 URL 控制范围使有效等级降低的层数 (没有记录时为 0)
+func URLControlPenalty(control string) int {
+	switch control {
+	case URLControlPath:
+		return 1
+	case URLControlQuery:
+		return 2
+	}
+	return 0
+}
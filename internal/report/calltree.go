@@ -0,0 +1,100 @@
+package report
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// jsonCallee -direction down 的调用树节点 (路径相对项目根目录，行号从 1 开始)
+type jsonCallee struct {
+	Func     string       `json:"func"`
+	File     string       `json:"file"`
+	Line     int          `json:"line"`
+	Code     string       `json:"code,omitempty"`
+	Rule     string       `json:"rule,omitempty"` // 命中的 Sink 规则
+	Note     string       `json:"note,omitempty"`
+	Children []jsonCallee `json:"children,omitempty"`
+}
+
+func newJSONCallee(n *model.CalleeNode, projectRoot string) jsonCallee {
+	out := jsonCallee{Func: n.Func, File: relativePath(n.File, projectRoot), Line: n.Line + 1, Code: n.Code, Note: n.Note}
+	if n.Rule != nil {
+		out.Rule = n.Rule.Name
+	}
+	for _, c := range n.Children {
+		out.Children = append(out.Children, newJSONCallee(c, projectRoot))
+	}
+	return out
+}
+
+func (c jsonCallee) node(projectRoot string) *model.CalleeNode {
+	file := filepath.FromSlash(c.File)
+	if !filepath.IsAbs(file) && projectRoot != "" {
+		file = filepath.Join(projectRoot, file)
+	}
+	n := &model.CalleeNode{Func: c.Func, File: file, Line: c.Line - 1, Code: c.Code, Note: c.Note}
+	if c.Rule != "" {
+		n.Rule = &model.SinkRule{Name: c.Rule}
+	}
+	for _, child := range c.Children {
+		n.Children = append(n.Children, child.node(projectRoot))
+	}
+	return n
+}
+
+// CallTreeRow HTML 调用树的一行 (按深度缩进)
+type CallTreeRow struct {
+	Depth    int
+	Func     string
+	Location string
+	Code     string
+	Rule     string
+	Note     string
+}
+
+// CallTree HTML 报告中的一棵调用树
+type CallTree struct {
+	Root  CallTreeRow
+	Rows  []CallTreeRow
+	Sinks int
+}
+
+func callTrees(trees []*model.CalleeNode, projectRoot string) []CallTree {
+	out := make([]CallTree, 0, len(trees))
+	for _, root := range trees {
+		tree := CallTree{Root: callTreeRow(root, 0, projectRoot), Sinks: root.Sinks()}
+		var walk func(nodes []*model.CalleeNode, depth int)
+		walk = func(nodes []*model.CalleeNode, depth int) {
+			for _, n := range nodes {
+				tree.Rows = append(tree.Rows, callTreeRow(n, depth, projectRoot))
+				walk(n.Children, depth+1)
+			}
+		}
+		walk(root.Children, 0)
+		out = append(out, tree)
+	}
+	return out
+}
+
+func callTreeRow(n *model.CalleeNode, depth int, projectRoot string) CallTreeRow {
+	row := CallTreeRow{Depth: depth, Func: n.Func, Location: relativePath(n.File, projectRoot) + ":" + strconv.Itoa(n.Line+1), Code: n.Code, Note: n.Note}
+	if n.Rule != nil {
+		row.Rule = n.Rule.Name
+	}
+	return row
+}
+
+// Indent 行的缩进 (CSS 像素)
+func (r CallTreeRow) Indent() int {
+	return r.Depth * 20
+}
+
+func relativePath(path, projectRoot string) string {
+	if rel, err := filepath.Rel(projectRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
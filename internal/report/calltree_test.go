@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+func testCallTree(root string) *model.CalleeNode {
+	file := filepath.Join(root, "src", "Service.java")
+	return &model.CalleeNode{Func: "handle(String)", File: file, Line: 5, Code: "dispatch(audit(input));", Children: []*model.CalleeNode{
+		{Func: "dispatch(String)", File: file, Line: 16, Code: "dispatch(audit(input));", Children: []*model.CalleeNode{
+			{Func: "dispatch(String)", File: file, Line: 16, Code: "dispatch(cmd);", Note: "recursive call"},
+			{Func: "RCE (Runtime.exec)", File: file, Line: 25, Code: "Runtime.getRuntime().exec(cmd);", Rule: &model.SinkRule{Name: "RCE (Runtime.exec)"}},
+		}},
+		{Func: "audit(String)", File: file, Line: 8, Code: "dispatch(audit(input));", Note: "depth limit"},
+	}}
+}
+
+// JSON 中的调用树 (相对路径、1-based 行号) 读回后与原来的树相同，render 子命令可以重新生成调用树一节
+func TestCallTreeJSONRoundTrip(t *testing.T) {
+	root := filepath.FromSlash("/scan/demo")
+	tree := testCallTree(root)
+	out := newJSONCallee(tree, root)
+	if out.File != "src/Service.java" || out.Line != 6 || out.Children[0].Children[1].Rule != "RCE (Runtime.exec)" {
+		t.Errorf("JSON node = %+v", out)
+	}
+	if got := out.node(root); !reflect.DeepEqual(got, tree) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, tree)
+	}
+}
+
+func TestCallTreesRows(t *testing.T) {
+	trees := callTrees([]*model.CalleeNode{testCallTree("/scan/demo")}, "/scan/demo")
+	if len(trees) != 1 || trees[0].Sinks != 1 || trees[0].Root.Location != "src/Service.java:6" {
+		t.Fatalf("trees = %+v", trees)
+	}
+	var got []string
+	for _, row := range trees[0].Rows {
+		got = append(got, fmt.Sprintf("%s %s %s|%s|%d", filepath.Base(row.Location), row.Func, row.Rule, row.Note, row.Indent()))
+	}
+	want := []string{
+		"Service.java:17 dispatch(String) ||0",
+		"Service.java:17 dispatch(String) |recursive call|20",
+		"Service.java:26 RCE (Runtime.exec) RCE (Runtime.exec)||20",
+		"Service.java:9 audit(String) |depth limit|0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows:\n%q\nwant:\n%q", got, want)
+	}
+}
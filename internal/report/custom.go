@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"LSPTracer/internal/i18n"
+)
+
+// Title HTML 报告的标题 (-report-title)，为空时使用默认标题
+var Title string
+
+// Logo 侧边栏中显示的 Logo (-logo)，base64 编码的 data: URI，为空时显示默认标志
+var Logo template.URL
+
+// customTemplate -template 指定的模板 (文件名和内容)，为空时只使用内置模板
+var customTemplate struct {
+	name string
+	text string
+}
+
+// LoadTemplate 读取自定义 HTML 模板并检查语法，之后生成的 HTML 报告都使用它
+// 自定义模板在内置模板之后解析: 可以只重新定义 "vuln-card" 等子模板，也可以提供完整的页面 (数据见 ReportData)；
+// 错误信息包含模板文件名和行号
+func LoadTemplate(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	customTemplate.name, customTemplate.text = filepath.Base(path), string(content)
+
+	t, err := reportTemplate(nil, i18n.English)
+	if err == nil {
+		// html/template 在第一次执行时才做上下文转义检查，用空数据执行一次，尽早报告这类错误
+		var tErr *template.Error
+		if execErr := t.Execute(io.Discard, ReportData{}); errors.As(execErr, &tErr) {
+			err = execErr
+		}
+	}
+	if err != nil {
+		customTemplate.name, customTemplate.text = "", ""
+		return err
+	}
+	return nil
+}
+
+// LoadLogo 读取图片文件并编码为 data: URI
+func LoadLogo(path string) (template.URL, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(content)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("%s is not an image (%s)", path, mimeType)
+	}
+	if i := strings.Index(mimeType, ";"); i != -1 {
+		mimeType = mimeType[:i]
+	}
+	return template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content)), nil
+}
@@ -0,0 +1,175 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withBranding 设置 -report-title / -logo / -template，测试结束后恢复内置模板
+func withBranding(t *testing.T, title, logo, tmpl string) {
+	t.Helper()
+	savedTitle, savedLogo, savedTemplate := Title, Logo, customTemplate
+	t.Cleanup(func() { Title, Logo, customTemplate = savedTitle, savedLogo, savedTemplate })
+	Title = title
+	if logo != "" {
+		uri, err := LoadLogo(logo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Logo = uri
+	}
+	if tmpl != "" {
+		if err := LoadTemplate(filepath.Join("testdata", "templates", tmpl)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// pngHeader 最小的 PNG 文件头 (足够让 http.DetectContentType 识别)
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func writeLogo(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pngHeader, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// singleReport generateTestHTML 生成的唯一一个 HTML 文件
+func singleReport(t *testing.T, pages map[string]string) string {
+	t.Helper()
+	if len(pages) != 1 {
+		t.Fatalf("want one report, got %d files", len(pages))
+	}
+	for _, html := range pages {
+		return html
+	}
+	return ""
+}
+
+// 内置模板: -report-title 替换页面标题，-logo 替换侧边栏中的默认标志
+func TestEmbeddedTemplateBranding(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+
+	withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	html := singleReport(t, generateTestHTML(t, chains, root))
+	if !strings.Contains(html, "<title>LSPTracer Scan Report</title>") || !strings.Contains(html, "⚡ LSPTracer") {
+		t.Error("default report is missing the default title or mark")
+	}
+	if n := len(cardRe.FindAllString(html, -1)); n != 2 {
+		t.Errorf("default report has %d cards, want 2", n)
+	}
+
+	withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	withBranding(t, "Acme <Audit>", writeLogo(t, "acme.png"), "")
+	html = singleReport(t, generateTestHTML(t, chains, root))
+	if !strings.Contains(html, "<title>Acme &lt;Audit&gt;</title>") {
+		t.Error("report title is not used (or not escaped)")
+	}
+	if !strings.Contains(html, `<img src="data:image/png;base64,`) || strings.Contains(html, "⚡ LSPTracer") {
+		t.Error("logo does not replace the default mark")
+	}
+	if n := len(cardRe.FindAllString(html, -1)); n != 2 {
+		t.Errorf("branded report has %d cards, want 2", n)
+	}
+}
+
+// 完整的自定义页面: 使用 ReportData 的字段和 card 函数渲染同一份数据
+func TestTemplateOverridePage(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	withBranding(t, "", writeLogo(t, "acme.png"), "branded.gohtml")
+
+	html := singleReport(t, generateTestHTML(t, chains, root))
+	for _, want := range []string{
+		"<title>Acme Security Review</title>",
+		`<img src="data:image/png;base64,`,
+		`<p class="findings">Findings: 2</p>`,
+		`<footer class="disclaimer">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("custom page is missing %s", want)
+		}
+	}
+	if n := strings.Count(html, `<section class="finding" data-severity="`); n != 2 {
+		t.Errorf("custom page has %d finding sections, want 2", n)
+	}
+	if strings.Contains(html, "sidebar") {
+		t.Error("built-in page is rendered instead of the custom template")
+	}
+	// card 仍然使用内置的卡片模板，步骤在渲染时生成
+	if n := len(cardRe.FindAllString(html, -1)); n != 2 {
+		t.Errorf("custom page has %d cards, want 2", n)
+	}
+	if !strings.Contains(html, "RCE (Runtime.exec)") {
+		t.Error("card content is missing from the custom page")
+	}
+}
+
+// 只重新定义 vuln-card 的模板: 页面来自内置模板，卡片来自自定义模板
+func TestTemplateOverrideDefine(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	withBranding(t, "Acme", "", "card.gohtml")
+
+	html := singleReport(t, generateTestHTML(t, chains, root))
+	if !strings.Contains(html, "<title>Acme</title>") || !strings.Contains(html, "sidebar") {
+		t.Error("page is not rendered from the built-in template")
+	}
+	if n := strings.Count(html, `class="vuln-card acme-card"`); n != 2 {
+		t.Errorf("%d custom cards, want 2", n)
+	}
+	if !strings.Contains(html, "<h2>GET /run [High]</h2><p>SOURCE Service0.java:6</p>") {
+		t.Error("custom card does not render the steps")
+	}
+}
+
+// 无效的模板: 错误包含文件名和行号，之后仍然使用内置模板
+func TestLoadTemplateErrors(t *testing.T) {
+	withBranding(t, "", "", "")
+	for _, tt := range []struct {
+		file string
+		want string
+	}{
+		{"broken.gohtml", "broken.gohtml:3"},
+		{"context.gohtml", "context.gohtml:3"}, // 上下文转义错误在执行时才发现
+	} {
+		err := LoadTemplate(filepath.Join("testdata", "templates", tt.file))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadTemplate(%s) = %v, want an error at %s", tt.file, err, tt.want)
+		}
+		if customTemplate.text != "" {
+			t.Errorf("%s: invalid template is kept", tt.file)
+		}
+	}
+	if err := LoadTemplate(filepath.Join("testdata", "templates", "missing.gohtml")); !os.IsNotExist(err) {
+		t.Errorf("missing template: %v", err)
+	}
+}
+
+func TestLoadLogo(t *testing.T) {
+	for _, name := range []string{"logo.png", "logo"} { // 没有扩展名时按内容识别
+		uri, err := LoadLogo(writeLogo(t, name))
+		if err != nil || !strings.HasPrefix(string(uri), "data:image/png;base64,") {
+			t.Errorf("LoadLogo(%s) = %.40s, %v", name, uri, err)
+		}
+	}
+	svg := filepath.Join(t.TempDir(), "logo.svg")
+	os.WriteFile(svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644)
+	if uri, err := LoadLogo(svg); err != nil || !strings.HasPrefix(string(uri), "data:image/svg+xml;base64,") {
+		t.Errorf("LoadLogo(svg) = %.40s, %v", uri, err)
+	}
+
+	text := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(text, []byte("not an image"), 0644)
+	if _, err := LoadLogo(text); err == nil {
+		t.Error("text file accepted as a logo")
+	}
+}
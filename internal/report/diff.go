@@ -0,0 +1,170 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/i18n"
+	"LSPTracer/internal/model"
+)
+
+// Fingerprint 返回链路的稳定指纹，用于对比不同时间的扫描结果
+// 由漏洞类型、规则以及每一步的 (相对路径, 类名.函数, 代码) 计算，不包含行号，
+// 因此在文件中插入/删除代码导致行号变化时指纹不变；文件改名则视为不同的发现
+func Fingerprint(stack []model.ChainStep, projectRoot string) string {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	write(chainVulnType(stack))
+	if rule := chainRule(stack); rule != nil {
+		write(rule.Name)
+	}
+	for _, step := range stack {
+		path := step.File
+		if rel, err := filepath.Rel(projectRoot, step.File); err == nil {
+			path = rel
+		}
+		write(filepath.ToSlash(path))
+		write(step.QualifiedFunc())
+		write(strings.Join(strings.Fields(step.Code), " "))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// DiffSummary diff 报告的数量摘要
+type DiffSummary struct {
+	New       int
+	Fixed     int
+	Unchanged int
+}
+
+// DiffResult 两次扫描结果的对比
+type DiffResult struct {
+	New       [][]model.ChainStep // 只在新结果中出现
+	Fixed     [][]model.ChainStep // 只在旧结果中出现
+	Unchanged [][]model.ChainStep // 两边都有 (取新结果中的链路)
+}
+
+// Summary 返回各类发现的数量
+func (d DiffResult) Summary() DiffSummary {
+	return DiffSummary{New: len(d.New), Fixed: len(d.Fixed), Unchanged: len(d.Unchanged)}
+}
+
+// DiffChains 按指纹对比两次扫描的链路；指纹相同的多条链路按数量逐一配对
+// 被严格模式排除的链路不参与对比
+func DiffChains(oldChains [][]model.ChainStep, oldRoot string, newChains [][]model.ChainStep, newRoot string) DiffResult {
+	oldChains, newChains = reportedChains(oldChains), reportedChains(newChains)
+	remaining := make(map[string][]int) // 指纹 -> 尚未配对的旧链路下标
+	for i, stack := range oldChains {
+		fp := Fingerprint(stack, oldRoot)
+		remaining[fp] = append(remaining[fp], i)
+	}
+
+	var result DiffResult
+	for _, stack := range newChains {
+		fp := Fingerprint(stack, newRoot)
+		if olds := remaining[fp]; len(olds) > 0 {
+			remaining[fp] = olds[1:]
+			result.Unchanged = append(result.Unchanged, stack)
+			continue
+		}
+		result.New = append(result.New, stack)
+	}
+
+	// 保持旧结果中的顺序
+	unmatched := make(map[int]bool)
+	for _, olds := range remaining {
+		for _, i := range olds {
+			unmatched[i] = true
+		}
+	}
+	for i, stack := range oldChains {
+		if unmatched[i] {
+			result.Fixed = append(result.Fixed, stack)
+		}
+	}
+	return result
+}
+
+// Baseline 基线结果中各指纹的链路数，新结果逐条与之配对 (同 DiffChains，指纹相同的多条链路按数量逐一配对)
+type Baseline struct {
+	remaining map[string]int
+}
+
+// NewBaseline 按基线结果建立指纹表，被严格模式排除的链路不参与对比
+func NewBaseline(chains [][]model.ChainStep, projectRoot string) *Baseline {
+	b := &Baseline{remaining: make(map[string]int)}
+	for _, stack := range reportedChains(chains) {
+		b.remaining[Fingerprint(stack, projectRoot)]++
+	}
+	return b
+}
+
+// Filter 返回不在基线中的链路 (同 DiffChains 的 New，被严格模式排除的链路不保留) 和基线中已经存在的数量
+func (b *Baseline) Filter(chains [][]model.ChainStep, projectRoot string) ([][]model.ChainStep, int) {
+	var kept [][]model.ChainStep
+	known := 0
+	for _, stack := range reportedChains(chains) {
+		fp := Fingerprint(stack, projectRoot)
+		if b.remaining[fp] > 0 {
+			b.remaining[fp]--
+			known++
+			continue
+		}
+		kept = append(kept, stack)
+	}
+	return kept, known
+}
+
+// GenerateDiffHTML 生成 diff 报告: 新增的发现在前并高亮，已修复的发现单独一节并划掉
+func GenerateDiffHTML(d DiffResult, oldRoot, newRoot string, meta Metadata) {
+	var vulns, fixed []Vulnerability
+	newItems := NavGroup{Name: "New", Label: i18n.T(meta.Locale, "New")}
+	fixedItems := NavGroup{Name: "Fixed", Label: i18n.T(meta.Locale, "Fixed")}
+
+	// 新增和已修复的发现连续编号，卡片从各自的链路中读回
+	newChains, fixedChains := sliceChains(d.New), sliceChains(d.Fixed)
+	for i, stack := range d.New {
+		vuln, _ := buildVulnerability(i+1, stack, newRoot)
+		vuln.chains, vuln.chainID = newChains, i+1
+		vuln.Status = "new"
+		vulns = append(vulns, vuln)
+		newItems.Items = append(newItems.Items, navItem(vuln))
+	}
+	for i, stack := range d.Fixed {
+		vuln, _ := buildVulnerability(len(d.New)+i+1, stack, oldRoot)
+		vuln.chains, vuln.chainID = fixedChains, i+1
+		vuln.Status = "fixed"
+		fixed = append(fixed, vuln)
+		fixedItems.Items = append(fixedItems.Items, navItem(vuln))
+	}
+	newItems.Count, fixedItems.Count = len(newItems.Items), len(fixedItems.Items)
+
+	summary := d.Summary()
+	writeHTML(ReportData{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		TotalChains: len(d.New) + len(d.Unchanged),
+		Vulns:       vulns,
+		NavGroups:   []NavGroup{newItems, fixedItems},
+		Meta:        meta,
+		Fixed:       fixed,
+		Diff:        &summary,
+	})
+}
+
+// reportedChains 去掉被严格模式排除的链路
+func reportedChains(chains [][]model.ChainStep) [][]model.ChainStep {
+	var kept [][]model.ChainStep
+	for _, stack := range chains {
+		if chainStrictExcluded(stack) == "" {
+			kept = append(kept, stack)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+func TestBaselineFilter(t *testing.T) {
+	known := testChain("/old/A.java", 10, "exec(a)")
+	baseline := NewBaseline([][]model.ChainStep{known, known, testChain("/old/B.java", 4, "exec(b)")}, "/old")
+
+	// 指纹与路径的根目录和行号无关；同一指纹在基线中有两条，第三条算新增
+	moved := testChain("/new/A.java", 12, "exec(a)")
+	excluded := testChain("/new/C.java", 3, "exec(c)")
+	excluded[0].StrictExcluded = "no framework entry"
+	fresh := testChain("/new/D.java", 8, "exec(d)")
+
+	kept, n := baseline.Filter([][]model.ChainStep{moved, moved, excluded, fresh}, "/new")
+	if n != 2 {
+		t.Errorf("known = %d, want 2", n)
+	}
+	if len(kept) != 1 || kept[0][0].Code != "exec(d)" {
+		t.Fatalf("kept = %v, want only exec(d)", kept)
+	}
+
+	// 配对按数量消耗: 之后的批次中同一指纹都是新增
+	kept, n = baseline.Filter([][]model.ChainStep{moved}, "/new")
+	if n != 0 || len(kept) != 1 {
+		t.Errorf("second batch: known = %d, kept = %d; want 0 and 1", n, len(kept))
+	}
+}
+
+// 指纹包含所在类: 不同类中的同名方法是不同的发现
+func TestFingerprintIncludesClass(t *testing.T) {
+	a := testChain("/scan/A.java", 10, "exec(a)")
+	b := testChain("/scan/A.java", 10, "exec(a)")
+	a[0].Class, b[0].Class = "Outer.Inner", "Outer.Other"
+	if Fingerprint(a, "/scan") == Fingerprint(b, "/scan") {
+		t.Error("chains in different classes have the same fingerprint")
+	}
+	c := testChain("/scan/A.java", 10, "exec(a)")
+	c[0].Class, c[0].Func = "Outer.Inner", "Outer.Inner.run"
+	if Fingerprint(a, "/scan") != Fingerprint(c, "/scan") {
+		t.Error("fingerprint depends on whether Func already carries the class name")
+	}
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"LSPTracer/internal/entrypoints"
+)
+
+// jsonEndpoint -emit-endpoints 输出的一个端点
+type jsonEndpoint struct {
+	Path      string   `json:"path"`
+	Methods   []string `json:"methods"` // 空数组表示不限制 HTTP 方法
+	Handler   string   `json:"handler"` // Class.method
+	Signature string   `json:"signature,omitempty"`
+	File      string   `json:"file"` // 相对项目根目录
+	Line      int      `json:"line"` // 1-based
+	Framework string   `json:"framework"`
+	Params    []string `json:"params"`
+}
+
+// EndpointRow HTML 报告端点表中的一行
+type EndpointRow struct {
+	Verb     string
+	Path     string
+	Handler  string
+	Location string
+	Params   string
+}
+
+// WriteEndpoints 把端点清单写入 JSON 文件
+func WriteEndpoints(path string, endpoints []entrypoints.Endpoint, projectRoot string) error {
+	out := make([]jsonEndpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		je := jsonEndpoint{
+			Path:      e.Path,
+			Methods:   e.Methods,
+			Handler:   e.HandlerName(),
+			Signature: e.Signature,
+			File:      endpointFile(e, projectRoot),
+			Line:      e.Line + 1,
+			Framework: e.Framework,
+			Params:    make([]string, 0, len(e.Params)),
+		}
+		if je.Methods == nil {
+			je.Methods = []string{}
+		}
+		for _, p := range e.Params {
+			je.Params = append(je.Params, p.String())
+		}
+		out = append(out, je)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
+
+// endpointRows 转换为 HTML 端点表的行
+func endpointRows(endpoints []entrypoints.Endpoint, projectRoot string) []EndpointRow {
+	rows := make([]EndpointRow, 0, len(endpoints))
+	for _, e := range endpoints {
+		var params []string
+		for _, p := range e.Params {
+			params = append(params, p.String())
+		}
+		rows = append(rows, EndpointRow{
+			Verb:     e.Verb(),
+			Path:     e.Path,
+			Handler:  e.HandlerName(),
+			Location: endpointFile(e, projectRoot) + ":" + strconv.Itoa(e.Line+1),
+			Params:   strings.Join(params, ", "),
+		})
+	}
+	return rows
+}
+
+func endpointFile(e entrypoints.Endpoint, projectRoot string) string {
+	if rel, err := filepath.Rel(projectRoot, e.File); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(e.File)
+}
@@ -1,7 +1,7 @@
 package report
 
 import (
-	"bytes"
+	_ "embed"
 	"fmt"
 	"html/template"
 	"os"
@@ -11,7 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/i18n"
+	"LSPTracer/internal/lsp"
 	"LSPTracer/internal/model"
+	"LSPTracer/internal/textutil"
 
 	"github.com/fatih/color"
 )
@@ -21,24 +25,141 @@ type Vulnerability struct {
 	ID    int
 	Title string // e.g. "RunTime.exec"
 	Steps []ReportStep
+	Rule  *model.SinkRule // 命中的规则 (CWE / 修复建议)，可能为 nil
+
+	Status string // diff 报告中的状态: "new" / "fixed"，普通报告为空
+
+	Severity   string            // 有效等级 (model.EffectiveSeverity)
+	Unverified string            // 未验证的原因，已验证时为空
+	Confidence *model.Confidence // 可信度信号，nil 表示没有数据
+
+	Suppression *model.Suppression // 白名单或 lsptracer:ignore 注释，nil 表示没有被抑制
+
+	StrictExcluded string // 严格模式排除的原因 (仅 -show-unverified 时出现在报告中)
+
+	URLControl string // SSRF 发现中输入能控制的 URL 部分 (model.URLControlFull 等)，空表示不是 SSRF 或无法还原
+
+	BaseSeverity     string                  // 规则等级 (与有效等级不同时才设置，报告中两个等级都展示)
+	SeverityOverride *model.SeverityOverride // 生效的 severity_overrides 条目，nil 表示没有
+
+	Module string // Sink 所在的模块 (相对项目根目录，根目录为 ".")，空表示没有记录
+	Owner  string // -owners 中负责的团队，空表示没有匹配
+
+	CodeKind string // Sink 位于测试/示例代码中时为 model.CodeTest / model.CodeSample，生产代码为空
+	TestOnly bool   // 链路只能从测试代码到达 (有效等级已降低一级)
+
+	AlsoMatched []model.SinkRule // 同一个调用上命中的其它规则 (按等级从高到低，作为附加标记展示)
+
+	// 卡片渲染时才按编号 (chainID) 从 chains 读回链路并生成 Steps (链路和完整上下文只在写出当前卡片时占用内存)
+	chains  *Chains
+	chainID int
+	root    string
 }
 
 type NavItem struct {
-	ID    int
-	Title string
+	ID     int
+	Title  string
+	Page   string // 分页报告中卡片所在的文件，单页报告为空
+	Module string // Sink 所在的模块 (侧边栏的模块过滤)
 }
 
 type NavGroup struct {
-	Name  string
-	Count int
-	Items []NavItem
+	Name      string // 分组的键 (漏洞类型或 Unverified 等)，分页报告的文件名由它生成
+	Label     string // 侧边栏和分页标题中的展示名称 (按 -lang 翻译)
+	Count     int
+	Items     []NavItem
+	Collapsed bool // 默认折叠 (e.g. 未验证的发现)
+}
+
+// Metadata 扫描相关的元信息，由 main 填充后传入各个报告生成器
+type Metadata struct {
+	Health         *lsp.DiagnosticStats // JDT.LS 编译诊断汇总 (nil 表示未收集)
+	ServerRestarts int                  // 扫描过程中语言服务器崩溃后重启的次数
+	ScanError      string               // 扫描提前终止的原因 (空表示正常完成)
+
+	// 报告来源: 项目、代码版本、规则集和扫描参数
+	ProjectName string
+	ProjectRoot string // 绝对路径
+	GitCommit   string // 不是 git 仓库时为空
+	GitBranch   string
+	RulesFile   string // 为空表示内置规则
+	RuleCount   int
+	StrictMode  bool
+	// 严格模式接受的 Source 类型 (-sources，逗号分隔) 和是否单独列出被严格模式排除的链路
+	Sources        string
+	ShowUnverified bool
+	ScanMode       string   // light / precise
+	Scope          []string // -scope / -scope-dir 限定的扫描范围 (e.g. "package com.acme.payments")，空表示整个工作区
+	PathMap        PathMap  // -path-map: 写报告时路径从扫描环境 (容器) 映射到报告读者的环境 (宿主机)
+	StartedAt      time.Time
+	Duration       time.Duration
+	Version        string // LSPTracer 版本
+	// 版本、Java 运行时、规则哈希、命令行参数等产生环境 (nil 表示没有记录，例如旧版本的 JSON 结果)
+	Provenance *Provenance
+
+	// 报告的界面语言 (-lang，en / zh): 规则描述、漏洞类型名称和 HTML 报告的界面字符串
+	Locale string
+
+	Stats *ScanStats // 候选点/验证/追踪数量和各阶段耗时 (nil 表示未收集，例如单点模式)
+
+	Endpoints []entrypoints.Endpoint // HTTP 端点清单 (nil 表示未收集)
+
+	// Sink 位于测试/示例代码中、没有写入报告的发现数量 (按类别，-include-test-code 时为空)
+	ExcludedCode []Count
+
+	CallTrees []*model.CalleeNode // 单点模式 -direction down 的调用树
+}
+
+// ShortCommit 返回 12 位的 commit 哈希
+func (m Metadata) ShortCommit() string {
+	if len(m.GitCommit) > 12 {
+		return m.GitCommit[:12]
+	}
+	return m.GitCommit
+}
+
+// RulesLabel 规则集的展示名称
+func (m Metadata) RulesLabel() string {
+	if m.RulesFile == "" {
+		return "built-in"
+	}
+	return m.RulesFile
 }
 
+// ReportData HTML 模板的数据 (-template 自定义模板可以使用的全部字段)
 type ReportData struct {
-	GeneratedAt string
-	TotalChains int
-	Vulns       []Vulnerability
-	NavGroups   []NavGroup
+	GeneratedAt string          // 生成时间 (2006-01-02 15:04:05)
+	TotalChains int             // 报告的发现数量 (不含被抑制的)
+	Vulns       []Vulnerability // 发现，用 {{card .}} 渲染为卡片 (或者直接使用 Vulnerability 的字段)
+	NavGroups   []NavGroup      // 侧边栏分组
+	Meta        Metadata        // 扫描元信息
+	Summary     Summary         // 按类型、等级和文件的统计
+
+	// 品牌: -report-title 指定的标题 (为空时使用默认标题) 和 -logo 图片 (data: URI)
+	Title string
+	Logo  template.URL
+
+	// 分页报告: 分页的标题和索引页文件 (索引页和单页报告为空)
+	PageTitle string
+	IndexPage string
+
+	// diff 报告: 已修复的发现 (单独一节展示) 和对比摘要
+	Fixed []Vulnerability
+	Diff  *DiffSummary
+
+	// 被白名单或行内注释抑制的发现 (单独的折叠分组，保证白名单可审计)
+	Suppressed      []Vulnerability
+	SuppressedCount int
+
+	// 被严格模式排除的链路: -show-unverified 时单独列出，否则只在概览中计数
+	StrictExcluded      []Vulnerability
+	StrictExcludedCount int
+
+	// 攻击面: 扫描到的 HTTP 端点
+	Endpoints []EndpointRow
+
+	// 向下追踪: 目标行能到达的方法和 Sink
+	CallTrees []CallTree
 }
 
 type ReportStep struct {
@@ -51,419 +172,71 @@ type ReportStep struct {
 	Code      string
 	FullCode  template.HTML
 	Analysis  []string
+
+	SourceKind string // Source 的入口类型 (HTTP / MESSAGE_QUEUE / ...)，仅 Source 步骤
+	// 链路结束的原因 (REACHED_ENTRY / NO_CALLERS / ...) 和说明，仅 Source 步骤
+	Termination     string
+	TerminationDesc string
+
+	Verification string // Sink 类型的确认方式和依据，仅 Sink 步骤
+
+	CodeKind string // 步骤位于测试/示例代码中时为 TEST / SAMPLE，生产代码为空
 }
 
-// HTML 模板 (包含了 Sidebar 和 View Full Context 样式)
-// Modified to have a sidebar layout
-const htmlTemplateStr = `
-<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>LSPTracer Scan Report</title>
-    <style>
-        :root {
-            --sidebar-width: 280px;
-            --primary-color: #2c3e50;
-            --accent-color: #3498db;
-            --danger-color: #e74c3c;
-            --bg-color: #f4f7f6;
-            --card-bg: #ffffff;
-            --text-color: #333;
-            --text-light: #7f8c8d;
-        }
-
-        body { 
-            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; 
-            background-color: var(--bg-color); 
-            color: var(--text-color); 
-            margin: 0; 
-            padding: 0; 
-            display: flex;
-            height: 100vh;
-            overflow: hidden;
-        }
-
-        /* Sidebar Styles */
-        .sidebar {
-            width: var(--sidebar-width);
-            background-color: #ffffff; /* Light background */
-            color: #24292f; /* Dark text */
-            display: flex;
-            flex-direction: column;
-            border-right: 1px solid #d0d7de;
-            flex-shrink: 0;
-            overflow-y: auto;
-        }
-
-        .sidebar-header {
-            padding: 20px;
-            background-color: #ffffff; /* Light background */
-            border-bottom: 1px solid #d0d7de;
-        }
-
-        .sidebar-header h1 {
-            margin: 0;
-            font-size: 20px;
-            font-weight: 600;
-            color: #1f2328; /* Dark heading */
-            display: flex;
-            align-items: center;
-        }
-
-        .sidebar-header .meta {
-            font-size: 14px; /* Increased from 12px */
-            color: #656d76; 
-            margin-top: 5px;
-            font-weight: 500;
-        }
-
-        .nav-section {
-            padding: 10px 0;
-        }
-
-        .nav-group-title {
-            padding: 10px 20px;
-            font-size: 12px;
-            text-transform: uppercase;
-            letter-spacing: 1px;
-            color: #1f2328; 
-            font-weight: bold;
-            opacity: 0.8;
-        }
-
-        .nav-item {
-            display: block;
-            padding: 10px 20px 10px 30px;
-            color: #1f2328; 
-            text-decoration: none;
-            font-size: 14px;
-            border-left: 4px solid transparent;
-            transition: background 0.2s, border-color 0.2s;
-            cursor: pointer;
-            font-weight: 500;
-        }
-
-        .nav-item:hover {
-            background-color: #f6f8fa; 
-            color: #24292f;
-        }
-
-        .nav-item.active {
-            background-color: #ddf4ff; 
-            border-left-color: #0969da; 
-            color: #0969da;
-            font-weight: 600;
-        }
-
-        .nav-item .id-badge {
-            background: #eaeef2; 
-            padding: 2px 6px;
-            border-radius: 4px;
-            font-size: 11px;
-            margin-right: 8px;
-            color: #57606a; 
-            font-weight: 600;
-        }
-
-        /* Main Content Styles */
-        .main-content {
-            flex: 1;
-            padding: 30px 40px;
-            overflow-y: auto;
-            scroll-behavior: smooth;
-        }
-
-        .report-overview {
-            background: white;
-            padding: 20px 30px;
-            border-radius: 8px;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.05);
-            margin-bottom: 30px;
-            border-left: 5px solid var(--danger-color);
-        }
-
-        .vuln-card { 
-            background: var(--card-bg); 
-            border-radius: 8px; 
-            box-shadow: 0 2px 10px rgba(0,0,0,0.05); 
-            margin-bottom: 40px; 
-            overflow: hidden; 
-            border: 1px solid #eee;
-            scroll-margin-top: 20px;
-        }
-
-        .vuln-title { 
-            background: #fff; 
-            border-bottom: 1px solid #eee;
-            color: var(--primary-color); 
-            padding: 15px 25px; 
-            display: flex; 
-            justify-content: space-between; 
-            align-items: center;
-        }
-
-        .vuln-title h2 {
-            margin: 0;
-            font-size: 18px;
-            display: flex;
-            align-items: center;
-        }
-        
-        .vuln-id-tag {
-            background: var(--primary-color);
-            color: white;
-            padding: 4px 8px;
-            border-radius: 4px;
-            font-size: 14px;
-            margin-right: 10px;
-            font-family: monospace;
-        }
-
-        .chain-body { padding: 25px; }
-
-        .timeline { position: relative; padding-left: 20px; }
-        .timeline::before { content: ''; position: absolute; left: 0; top: 10px; bottom: 0; width: 2px; background: #e0e0e0; }
-        
-        .step { position: relative; margin-bottom: 30px; padding-left: 25px; }
-        .step::before { content: ''; position: absolute; left: -26px; top: 0; width: 14px; height: 14px; border-radius: 50%; border: 3px solid white; box-shadow: 0 0 0 2px #e0e0e0; z-index: 1; }
-        
-        .type-source::before { background: #e74c3c; box-shadow: 0 0 0 2px #e74c3c; }
-        .type-step::before { background: #f39c12; box-shadow: 0 0 0 2px #f39c12; }
-        .type-sink::before { background: #2c3e50; box-shadow: 0 0 0 2px #2c3e50; }
-
-        .step-header { display: flex; align-items: center; margin-bottom: 8px; flex-wrap: wrap; }
-        .tag { padding: 3px 8px; border-radius: 4px; font-size: 11px; font-weight: bold; margin-right: 10px; color: white; text-transform: uppercase;}
-        .tag-source { background: #e74c3c; }
-        .tag-step { background: #f39c12; }
-        .tag-sink { background: #2c3e50; }
-        
-        .func-name { font-family: 'JetBrains Mono', Consolas, monospace; font-weight: bold; color: #2c3e50; font-size: 1.05em; }
-        .file-loc { font-size: 13px; color: #95a5a6; margin-left: auto; font-family: monospace; }
-
-        .code-box { background: #fafafa; border: 1px solid #eee; border-radius: 6px; padding: 12px; margin-top: 8px; }
-        .summary-code { font-family: 'JetBrains Mono', Consolas, monospace; font-size: 13px; color: #444; overflow-x: auto; white-space: pre-wrap; background: #fff; padding: 8px; border: 1px solid #eee; border-radius: 4px; border-left: 3px solid #ddd; }
-        
-        .analysis-item { margin-top: 8px; font-size: 13px; color: #555; display: flex; align-items: start;}
-        .analysis-item span { margin-right: 5px; }
-
-        .toggle-btn { 
-            background: none; border: none; color: var(--accent-color); cursor: pointer; font-size: 12px; 
-            padding: 5px 0; margin-top: 5px; text-decoration: none; display: inline-flex; align-items: center;
-            font-weight: 600;
-        }
-        .toggle-btn:hover { text-decoration: underline; }
-        .toggle-btn::after { content: ' ▼'; font-size: 10px; margin-left: 4px; }
-        .toggle-btn.active::after { content: ' ▲'; }
-
-        .full-code-context { display: none; margin-top: 10px; background: #282c34; padding: 15px; border-radius: 6px; font-family: 'JetBrains Mono', Consolas, monospace; font-size: 12px; line-height: 1.6; color: #abb2bf; overflow-x: auto; border: 1px solid #1e222a; }
-        .code-line { display: block; white-space: pre; }
-        .line-num { color: #5c6370; margin-right: 15px; user-select: none; display: inline-block; width: 35px; text-align: right; border-right: 1px solid #3e4451; padding-right: 8px;}
-        
-        .highlight-line { background-color: #3e4451; display: block; width: 100%; border-left: 3px solid #e5c07b; }
-        .highlight-line .line-num { color: #e5c07b; font-weight: bold; }
-
-        .s-kwd { color: #c678dd; font-weight: bold; } 
-        .s-type { color: #e5c07b; } 
-        .s-str { color: #98c379; }  
-        .s-ann { color: #d19a66; }  
-        .s-com { color: #7f848e; font-style: italic; } 
-        .s-num { color: #d19a66; }  
-        .s-func { color: #61afef; } 
-    </style>
-</head>
-<body>
-    <div class="sidebar">
-        <div class="sidebar-header">
-            <h1>⚡ LSPTracer</h1>
-            <div class="meta" style="font-size: 13px; font-weight: 600; color: #1f2328; margin-top: 8px;">Scan Report</div>
-            <div class="meta" style="margin-top: 8px; opacity: 0.8; font-size: 14px;">
-                TOTAL CHAINS: {{.TotalChains}}<br>
-                <span style="font-size: 12px; opacity: 0.7; font-weight: 400">{{.GeneratedAt}}</span>
-            </div>
-        </div>
-        <div class="nav-section">
-            {{range .NavGroups}}
-            <div class="nav-group-title">{{.Name}} ({{.Count}})</div>
-            {{range .Items}}
-            <a href="#vuln-{{.ID}}" class="nav-item" onclick="setActive(this)">
-                <span class="id-badge">#{{.ID}}</span>
-                {{.Title}}
-            </a>
-            {{end}}
-            {{end}}
-        </div>
-    </div>
-
-    <div class="main-content">
-        <div class="report-overview">
-            <h2 style="margin-top: 0; color: #2c3e50;">Scan Overview</h2>
-            <p>Total confirmed vulnerability chains: <strong>{{.TotalChains}}</strong></p>
-            <p style="color: #666; font-size: 14px;">Select a vulnerability from the sidebar to view detailed trace information.</p>
-        </div>
-
-        {{range .Vulns}}
-        {{ $vulnID := .ID }}
-        <div id="vuln-{{.ID}}" class="vuln-card">
-            <div class="vuln-title">
-                <h2><span class="vuln-id-tag">#{{.ID}}</span> {{.Title}}</h2>
-                <span style="font-size: 0.9em; color: #7f8c8d; font-weight: normal;">Depth: {{len .Steps}} steps</span>
-            </div>
-            <div class="chain-body">
-                <div class="timeline">
-                    {{range .Steps}}
-                    <div class="step type-{{.TypeClass}}">
-                        <div class="step-header">
-                            <span class="tag tag-{{.TypeClass}}">{{.Type}}</span>
-                            <span class="func-name">{{.Func}}</span>
-                            <span class="file-loc">{{.File}}:{{.Line}}</span>
-                        </div>
-                        
-                        <div class="code-box">
-                            {{if .Code}}
-                                <div class="summary-code">{{.Code}}</div>
-                            {{end}}
-                            
-                            {{range .Analysis}}
-                                <div class="analysis-item">{{.}}</div>
-                            {{end}}
-
-                            <button class="toggle-btn" onclick="toggleCode('code-{{$vulnID}}-{{.Index}}', this)">View Full Context</button>
-                            
-                            <div id="code-{{$vulnID}}-{{.Index}}" class="full-code-context">
-                                {{.FullCode}}
-                            </div>
-                        </div>
-                    </div>
-                    {{end}}
-                </div>
-            </div>
-        </div>
-        {{end}}
-    </div>
-
-    <script>
-        function toggleCode(id, btn) {
-            var el = document.getElementById(id);
-            if (el.style.display === "block") {
-                el.style.display = "none";
-                btn.classList.remove('active');
-                btn.innerHTML = "View Full Context";
-            } else {
-                el.style.display = "block";
-                btn.classList.add('active');
-                btn.innerHTML = "Hide Context";
-            }
-        }
-
-        function setActive(el) {
-            document.querySelectorAll('.nav-item').forEach(item => {
-                item.classList.remove('active');
-            });
-            el.classList.add('active');
-        }
-
-        // Auto-select first item on load if exists
-        window.onload = function() {
-            if(window.location.hash) {
-                const id = window.location.hash.substring(1); // remove #
-                const el = document.querySelector('a[href="#' + id + '"]');
-                if(el) setActive(el);
-            }
-        }
-    </script>
-</body>
-</html>
-`
-
-// GenerateHTML 生成 HTML 报告
-func GenerateHTML(allChains [][]model.ChainStep, projectRoot string) {
-	if len(allChains) == 0 {
+// htmlTemplateStr 内置的 HTML 模板 (包含了 Sidebar 和 View Full Context 样式)，可以用 -template 覆盖
+//
+//go:embed templates/report.gohtml
+var htmlTemplateStr string
+
+// GenerateHTML 生成 HTML 报告；卡片只保留摘要，渲染时再从 chains 读回链路
+func GenerateHTML(chains *Chains, projectRoot string, meta Metadata) {
+	// 没有发现时仍然输出攻击面 (端点清单) 和调用树
+	if chains.Len() == 0 && len(meta.Endpoints) == 0 && len(meta.CallTrees) == 0 {
 		return
 	}
 
-	var vulns []Vulnerability
+	var vulns, suppressedVulns, excludedVulns []Vulnerability
+	excludedCount := 0
 	// Helper map to group vulns by type
 	vulnGroups := make(map[string][]NavItem)
-
-	for chainIdx, stack := range allChains {
-		var steps []ReportStep
-		chainLen := len(stack)
-
-		vulnTitle := "Unknown Vulnerability"
-		vulnType := "Uncategorized"
-
-		for i := chainLen - 1; i >= 0; i-- {
-			step := stack[i]
-
-			stepType := "STEP"
-			typeClass := "step"
-			if i == chainLen-1 {
-				stepType = "SOURCE"
-				typeClass = "source"
-			} else if i == 0 { // This is SINK
-				stepType = "SINK"
-				typeClass = "sink"
-			}
-
-			if i == 0 {
-				// Extract vulnerability type from Analysis if available
-				// e.g., "🚨 Matched Rule: RCE"
-				for _, analysisStr := range step.Analysis {
-					if strings.Contains(analysisStr, "Matched Rule") {
-						parts := strings.Split(analysisStr, ":")
-						if len(parts) > 1 {
-							ruleName := strings.TrimSpace(parts[1])
-							// Group by broad category (e.g. "SSRF")
-							if bracketIdx := strings.Index(ruleName, "("); bracketIdx != -1 {
-								vulnType = strings.TrimSpace(ruleName[:bracketIdx])
-							} else {
-								vulnType = ruleName
-							}
-						}
-					}
-				}
-
-				// Use Sink Function as Title or part of it
-				vulnTitle = fmt.Sprintf("%s", step.Func)
-			}
-
-			// ✨✨✨ 使用绝对路径读取代码 ✨✨✨
-			fullCodeHTML := getSmartCodeContext(step.File, step.Line, step.Func)
-
-			// ✨✨✨ 计算相对路径用于 HTML 展示 ✨✨✨
-			displayPath := step.File
-			if rel, err := filepath.Rel(projectRoot, step.File); err == nil {
-				displayPath = rel
+	// 未验证和被抑制的发现单独放在折叠的分组中
+	lang := meta.Locale
+	unverified := NavGroup{Name: "Unverified", Label: i18n.T(lang, "Unverified"), Collapsed: true}
+	suppressed := NavGroup{Name: "Suppressed", Label: i18n.T(lang, "Suppressed"), Collapsed: true}
+	excluded := NavGroup{Name: "Strict-excluded", Label: i18n.T(lang, "Strict-excluded"), Collapsed: true}
+
+	summary := newSummarizer(projectRoot)
+	err := chains.Each(func(id int, stack []model.ChainStep) error {
+		summary.add(stack)
+		vuln, vulnType := buildVulnerability(id, stack, projectRoot)
+		vuln.chains, vuln.chainID = chains, id
+		if vuln.StrictExcluded != "" {
+			excludedCount++
+			if meta.ShowUnverified {
+				excludedVulns = append(excludedVulns, vuln)
+				excluded.Items = append(excluded.Items, navItem(vuln))
 			}
+			return nil
+		}
+		if isSuppressed(stack) {
+			suppressedVulns = append(suppressedVulns, vuln)
+			suppressed.Items = append(suppressed.Items, navItem(vuln))
+			return nil
+		}
+		vulns = append(vulns, vuln)
 
-			steps = append(steps, ReportStep{
-				Index:     i,
-				Type:      stepType,
-				TypeClass: typeClass,
-				Func:      step.Func,
-				File:      displayPath,
-				Line:      step.Line + 1,
-				Code:      step.Code,
-				FullCode:  template.HTML(fullCodeHTML),
-				Analysis:  step.Analysis,
-			})
-		}
-
-		// Add to main list
-		vulnID := chainIdx + 1
-		vulns = append(vulns, Vulnerability{
-			ID:    vulnID,
-			Title: vulnTitle, // Simplified Title
-			Steps: steps,
-		})
+		if vuln.Unverified != "" {
+			unverified.Items = append(unverified.Items, navItem(vuln))
+			return nil
+		}
 
 		// Add to Group for Sidebar
-		vulnGroups[vulnType] = append(vulnGroups[vulnType], NavItem{
-			ID:    vulnID,
-			Title: truncateString(vulnTitle, 25),
-		})
+		vulnGroups[vulnType] = append(vulnGroups[vulnType], navItem(vuln))
+		return nil
+	})
+	if err != nil {
+		color.Red("[-] Failed to read findings for the HTML report: %v", err)
+		return
 	}
 
 	// Convert map to sorted slice for consistent rendering
@@ -482,81 +255,431 @@ func GenerateHTML(allChains [][]model.ChainStep, projectRoot string) {
 		})
 		navGroups = append(navGroups, NavGroup{
 			Name:  k,
+			Label: i18n.VulnTypeName(lang, k),
 			Count: len(items),
 			Items: items,
 		})
 	}
+	if unverified.Count = len(unverified.Items); unverified.Count > 0 {
+		navGroups = append(navGroups, unverified)
+	}
+	if suppressed.Count = len(suppressed.Items); suppressed.Count > 0 {
+		navGroups = append(navGroups, suppressed)
+	}
+	if excluded.Count = len(excluded.Items); excluded.Count > 0 {
+		navGroups = append(navGroups, excluded)
+	}
 
 	data := ReportData{
-		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
-		TotalChains: len(vulns),
-		Vulns:       vulns,
-		NavGroups:   navGroups,
+		GeneratedAt:     time.Now().Format("2006-01-02 15:04:05"),
+		TotalChains:     len(vulns),
+		Vulns:           vulns,
+		NavGroups:       navGroups,
+		Meta:            meta,
+		Summary:         summary.summary(),
+		Suppressed:      suppressedVulns,
+		SuppressedCount: len(suppressedVulns),
+
+		StrictExcluded:      excludedVulns,
+		StrictExcludedCount: excludedCount,
+		Endpoints:           endpointRows(meta.Endpoints, projectRoot),
+		CallTrees:           callTrees(meta.CallTrees, projectRoot),
 	}
+	// 发现很多时拆分为索引页和按类型的分页，避免单个 HTML 文件过大
+	if PageThreshold > 0 && len(vulns)+len(suppressedVulns)+len(excludedVulns) > PageThreshold {
+		writePagedHTML(data)
+		return
+	}
+	writeHTML(data)
+}
+
+// navItem 发现在侧边栏中的条目
+func navItem(vuln Vulnerability) NavItem {
+	return NavItem{ID: vuln.ID, Title: truncateString(vuln.Title, 25), Module: vuln.Module}
+}
+
+// buildVulnerability 把一条链路 (Sink -> Source) 转换为报告中的漏洞卡片，同时返回漏洞类型
+// 卡片的步骤 (包括完整上下文) 在渲染时由 buildSteps 生成
+func buildVulnerability(id int, stack []model.ChainStep, projectRoot string) (Vulnerability, string) {
+	vulnTitle := "Unknown Vulnerability"
+	vulnType := "Uncategorized"
+	if len(stack) > 0 {
+		vulnType = chainVulnType(stack)
+		// Use Sink Function as Title or part of it
+		vulnTitle = stack[0].QualifiedFunc()
+	}
+
+	// Source 是 HTTP 端点时用路由作为标题 (复现时要请求的 URL)，多个映射全部列出
+	if routes := chainRoutes(stack); len(routes) > 0 {
+		vulnTitle = strings.Join(routes, ", ")
+	}
+
+	return Vulnerability{
+		ID:         id,
+		Title:      vulnTitle, // Simplified Title
+		Rule:       chainRule(stack),
+		Severity:   chainSeverity(stack),
+		Unverified: chainUnverified(stack),
+		Confidence: chainConfidence(stack),
+
+		Suppression: chainSuppression(stack),
 
-	t, err := template.New("report").Parse(htmlTemplateStr)
+		StrictExcluded: chainStrictExcluded(stack),
+
+		URLControl: chainURLControl(stack),
+
+		BaseSeverity:     chainBaseSeverity(stack),
+		SeverityOverride: chainSeverityOverride(stack),
+
+		Module: chainModule(stack),
+		Owner:  chainOwner(stack),
+
+		CodeKind: chainCodeKind(stack),
+		TestOnly: chainTestOnly(stack),
+
+		AlsoMatched: chainAlsoMatched(stack),
+
+		root: projectRoot,
+	}, vulnType
+}
+
+// buildSteps 按 Source -> Sink 的顺序生成卡片中的步骤；budget 用完后不再生成完整上下文
+func buildSteps(stack []model.ChainStep, projectRoot string, budget *contextBudget) []ReportStep {
+	var steps []ReportStep
+	chainLen := len(stack)
+
+	for i := chainLen - 1; i >= 0; i-- {
+		step := stack[i]
+
+		stepType := "STEP"
+		typeClass := "step"
+		if i == chainLen-1 {
+			stepType = "SOURCE"
+			typeClass = "source"
+		} else if i == 0 { // This is SINK
+			stepType = "SINK"
+			typeClass = "sink"
+		}
+
+		// ✨✨✨ 计算相对路径用于 HTML 展示 ✨✨✨
+		displayPath := step.File
+		if rel, err := filepath.Rel(projectRoot, step.File); err == nil {
+			displayPath = rel
+		}
+
+		steps = append(steps, ReportStep{
+			Index:     i,
+			Type:      stepType,
+			TypeClass: typeClass,
+			Func:      step.QualifiedFunc(),
+			File:      displayPath,
+			Line:      step.Line + 1,
+			Code:      step.Code,
+			FullCode:  budget.context(step, displayPath),
+			Analysis:  step.Analysis,
+
+			SourceKind: step.SourceKind,
+
+			Termination:     step.Termination,
+			TerminationDesc: model.TerminationDescriptions[step.Termination],
+
+			Verification: stepVerification(step),
+
+			CodeKind: nonMainCode(step.CodeKind),
+		})
+	}
+	return steps
+}
+
+// writeHTML 渲染模板并写入 OutputDir
+func writeHTML(data ReportData) {
+	budget := newContextBudget(ContextBudget)
+	t, err := reportTemplate(budget, data.Meta.Locale)
 	if err != nil {
 		color.Red("[-] Failed to generate report template: %v", err)
 		return
 	}
 
-	outputDir := "output"
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		color.Red("[-] Failed to create output directory: %v", err)
-		return
-	}
-	fileName := fmt.Sprintf("report_%d.html", time.Now().Unix())
-	f, err := os.Create(filepath.Join(outputDir, fileName))
+	f, absReportPath, err := createOutputFile("html")
 	if err != nil {
 		color.Red("[-] Failed to create output file: %v", err)
 		return
 	}
 	defer f.Close()
 
-	err = t.Execute(f, data)
-	if err != nil {
+	if err := executeHTML(t, f, data); err != nil {
 		color.Red("[-] Failed to write report data: %v", err)
 		return
 	}
 
-	absReportPath, _ := filepath.Abs(filepath.Join(outputDir, fileName))
 	color.Green("[+] Report generated successfully: %s", absReportPath)
+	budget.warn()
 }
 
-func truncateString(s string, max int) string {
-	if len(s) > max {
-		return s[:max] + "..."
+// OutputDir 报告输出目录 (render 子命令可以通过 -o 修改)
+var OutputDir = "output"
+
+// createOutputFile 在 OutputDir 目录下创建 report_<时间戳>.<ext>，返回文件和绝对路径
+func createOutputFile(ext string) (*os.File, string, error) {
+	return createNamedOutputFile(fmt.Sprintf("report_%d.%s", time.Now().Unix(), ext))
+}
+
+// createNamedOutputFile 在 OutputDir 目录下创建指定名称的文件，返回文件和绝对路径
+func createNamedOutputFile(fileName string) (*os.File, string, error) {
+	if err := os.MkdirAll(OutputDir, 0755); err != nil {
+		return nil, "", err
+	}
+	f, err := os.Create(filepath.Join(OutputDir, fileName))
+	if err != nil {
+		return nil, "", err
+	}
+	absPath, _ := filepath.Abs(f.Name())
+	return f, absPath, nil
+}
+
+// chainRule 返回链路 Sink 步骤命中的规则 (单点模式下为 nil)
+func chainRule(stack []model.ChainStep) *model.SinkRule {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[0].Rule
+}
+
+// SeverityClass 有效等级对应的 CSS 类名后缀
+func (v Vulnerability) SeverityClass() string {
+	return strings.ToLower(v.Severity)
+}
+
+// URLControlDesc SSRF 发现中输入能控制的 URL 部分的说明 (e.g. "path-only controlled")
+func (v Vulnerability) URLControlDesc() string {
+	return model.URLControlDescriptions[v.URLControl]
+}
+
+// ConfidenceClass 可信度等级对应的 CSS 类名后缀
+func (v Vulnerability) ConfidenceClass() string {
+	if v.Confidence == nil {
+		return ""
 	}
-	return s
+	return strings.ToLower(v.Confidence.Level())
+}
+
+// chainConfidence 返回链路的可信度信号，没有数据时为 nil
+func chainConfidence(stack []model.ChainStep) *model.Confidence {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[0].Confidence
+}
+
+// stepVerification Sink 步骤的确认方式和依据 (e.g. "import: import of java.lang.ProcessBuilder found at line 12")，其它步骤为空
+func stepVerification(step model.ChainStep) string {
+	if step.Confidence == nil || !step.Confidence.Verification.OK() {
+		return ""
+	}
+	v := step.Confidence.Verification
+	if v.Evidence == "" {
+		return v.Method
+	}
+	return v.Method + ": " + v.Evidence
+}
+
+// chainUnverified 返回链路未经验证的原因，已验证时为空
+func chainUnverified(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[0].Unverified
+}
+
+// chainSuppression 返回链路的抑制信息，没有被抑制时为 nil
+func chainSuppression(stack []model.ChainStep) *model.Suppression {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[0].Suppression
+}
+
+// chainTermination 返回链路结束的原因 (REACHED_ENTRY 等)，没有记录时为空
+func chainTermination(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[len(stack)-1].Termination
+}
+
+// chainRoutes 返回链路 Source 对应的 HTTP 路由 (没有时为 nil)
+func chainRoutes(stack []model.ChainStep) []string {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[len(stack)-1].Routes
+}
+
+// chainStrictExcluded 返回严格模式排除链路的原因，保留时为空
+func chainStrictExcluded(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[0].StrictExcluded
+}
+
+// isSuppressed 链路是否被抑制 (降级的发现和过期的注释仍然算作正常结果)
+func isSuppressed(stack []model.ChainStep) bool {
+	s := chainSuppression(stack)
+	return s != nil && s.Action == model.AllowSuppress
+}
+
+// chainSeverity 返回链路的有效等级 (model.EffectiveSeverity)；没有规则时为空
+func chainSeverity(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return model.EffectiveSeverity(stack[0])
+}
+
+// chainBaseSeverity 返回规则等级，与有效等级相同或没有规则时为空
+func chainBaseSeverity(stack []model.ChainStep) string {
+	rule := chainRule(stack)
+	if rule == nil || rule.Severity == chainSeverity(stack) {
+		return ""
+	}
+	return rule.Severity
+}
+
+// chainSeverityOverride 返回生效的 severity_overrides 条目，没有时为 nil
+func chainSeverityOverride(stack []model.ChainStep) *model.SeverityOverride {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[0].SeverityOverride
+}
+
+// chainModule 返回 Sink 所在的模块，没有记录时为空
+func chainModule(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[0].Module
+}
+
+// chainCodeKind Sink 位于测试/示例代码中时返回类别，生产代码或没有分类时为空
+func chainCodeKind(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return nonMainCode(stack[0].CodeKind)
+}
+
+func nonMainCode(kind string) string {
+	if kind == model.CodeMain {
+		return ""
+	}
+	return kind
+}
+
+// chainAlsoMatched Sink 所在的调用上命中的其它规则 (主规则之外)
+func chainAlsoMatched(stack []model.ChainStep) []model.SinkRule {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[0].AlsoMatched
+}
+
+// chainTestOnly 链路是否只能从测试代码到达
+func chainTestOnly(stack []model.ChainStep) bool {
+	return len(stack) > 0 && stack[0].TestOnly
+}
+
+// chainOwner 返回负责 Sink 文件的团队，没有匹配时为空
+func chainOwner(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[0].Owner
+}
+
+// chainURLControl 返回 SSRF 发现中输入能控制的 URL 部分，没有记录时为空
+func chainURLControl(stack []model.ChainStep) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[0].URLControl
+}
+
+// chainVulnType 从 Sink 步骤的 "Matched Rule" 分析信息中提取漏洞大类 (e.g. "SSRF")
+func chainVulnType(stack []model.ChainStep) string {
+	vulnType := "Uncategorized"
+	if len(stack) == 0 {
+		return vulnType
+	}
+	if rule := chainRule(stack); rule != nil && rule.VulnType != "" {
+		return rule.VulnType
+	}
+	// e.g., "🚨 Matched Rule: RCE (Runtime.exec)"
+	for _, analysisStr := range stack[0].Analysis {
+		if strings.Contains(analysisStr, "Matched Rule") {
+			parts := strings.Split(analysisStr, ":")
+			if len(parts) > 1 {
+				ruleName := strings.TrimSpace(parts[1])
+				// Group by broad category (e.g. "SSRF")
+				if bracketIdx := strings.Index(ruleName, "("); bracketIdx != -1 {
+					vulnType = strings.TrimSpace(ruleName[:bracketIdx])
+				} else {
+					vulnType = ruleName
+				}
+			}
+		}
+	}
+	return vulnType
+}
+
+// truncateString 按显示宽度截断 (不会切开中文等多字节字符)
+func truncateString(s string, max int) string {
+	return textutil.Truncate(s, max)
 }
 
 // -----------------------------------------------------------------------------
 // 智能代码提取 (支持花括号平衡算法)
 // -----------------------------------------------------------------------------
 
-func getSmartCodeContext(path string, targetLine int, funcName string) string {
-	content, err := os.ReadFile(path)
+// getSmartCodeContext 提取步骤所在函数的代码
+// 优先使用 LSP 提供的函数范围 (重载方法也能定位到正确的函数体)，没有时回退到函数名 + 花括号平衡的启发式查找
+func getSmartCodeContext(step model.ChainStep) string {
+	lines, offset, err := stepSource(step)
 	if err != nil {
 		// 如果相对路径读不到，尝试报错信息
-		return fmt.Sprintf("Error reading source file: %s", path)
+		return fmt.Sprintf("Error reading source file: %s", step.File)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	// 以下行号都相对于 lines (片段) 计算，输出时再加上 offset
+	targetLine, funcName := step.Line-offset, step.Func
 	totalLines := len(lines)
+	// 注释和字面量屏蔽后的源码，只用于查找函数边界
+	masked := textutil.MaskJavaSource(lines)
 
-	// 1. 尝试智能查找函数边界
-	// Step A: Find the line where the function is defined (e.g., "public void foo(...)")
-	defLine := findFunctionDefLine(lines, targetLine, funcName)
 	startLine := -1
 	endLine := -1
 
+	// 0. LSP 范围 (需要覆盖目标行，文件在扫描后被修改时回退)
+	funcStart, funcEnd := step.FuncStartLine-offset, step.FuncEndLine-offset
+	if step.FuncEndLine > 0 && targetLine >= funcStart && targetLine <= funcEnd {
+		startLine, endLine = funcStart, funcEnd
+	}
+
+	// 1. 尝试智能查找函数边界
+	// Step A: Find the line where the function is defined (e.g., "public void foo(...)")
+	defLine := -1
+	if startLine == -1 {
+		defLine = findFunctionDefLine(masked, targetLine, funcName)
+	}
+
 	if defLine != -1 {
 		// Step B: Scan upwards for annotations (e.g. @RequestMapping)
 		startLine = scanUpForAnnotations(lines, defLine)
 
 		// Step C: Scan downwards for the closing brace, starting from the DEFINITION line
 		// (Avoids confusing braces inside annotations)
-		endLine = findFunctionEnd(lines, defLine)
+		endLine = findFunctionEnd(masked, defLine)
 	}
 
 	// Double Check: Ensure the Found Range covers the Target Line
@@ -586,16 +709,18 @@ func getSmartCodeContext(path string, targetLine int, funcName string) string {
 	}
 
 	var sb strings.Builder
+	state := textutil.LexCode
 	for i := startLine; i <= endLine; i++ { // 注意这里是 <=
 		if i >= totalLines {
 			break
 		}
 
-		lineNum := i + 1
+		lineNum := i + 1 + offset
 		rawCode := lines[i]
 
 		// 3. 语法高亮
-		highlightedCode := highlightJavaSyntax(rawCode)
+		var highlightedCode string
+		highlightedCode, state = highlightJavaSyntax(rawCode, state)
 
 		// 4. 包装 HTML
 		cssClass := "code-line"
@@ -608,13 +733,51 @@ func getSmartCodeContext(path string, targetLine int, funcName string) string {
 	return sb.String()
 }
 
+// stepSource 返回步骤的源码行以及第一行的行号 (0-based)
+// 优先使用扫描时嵌入的片段，没有时读取磁盘上的文件
+func stepSource(step model.ChainStep) ([]string, int, error) {
+	if len(step.Context) > 0 {
+		return step.Context, step.ContextStart, nil
+	}
+	content, err := os.ReadFile(step.File)
+	if err != nil {
+		return nil, 0, err
+	}
+	return strings.Split(string(content), "\n"), 0, nil
+}
+
 // 向上查找函数定义行 (不包含注解扫描，仅定位 public void xxx 部分)
+// lines 必须是 textutil.MaskJavaSource 处理过的源码
+// funcName 带参数签名时跳过参数个数不同的重载；合成名称中带类名时跳过其它类中的同名方法
 func findFunctionDefLine(lines []string, targetIdx int, funcName string) int {
 	// 如果 funcName 包含参数 (e.g. "query(String)"), 截取括号前的内容
+	arity := textutil.ParamCount(funcName)
 	if idx := strings.Index(funcName, "("); idx != -1 {
 		funcName = funcName[:idx]
 	}
 
+	// 合成名称 (e.g. "MyClass.<init>", "MyClass$1.run") 只保留源码中可见的部分
+	className := ""
+	if idx := strings.LastIndex(funcName, "."); idx != -1 {
+		owner, member := funcName[:idx], funcName[idx+1:]
+		className = textutil.SimpleClassName(owner)
+		switch member {
+		case "<init>":
+			// 构造器在源码中以类名出现
+			if dot := strings.LastIndex(owner, "."); dot != -1 {
+				owner = owner[dot+1:]
+			}
+			if dollar := strings.Index(owner, "$"); dollar != -1 {
+				owner = owner[:dollar]
+			}
+			funcName = owner
+		case "<clinit>":
+			funcName = "static"
+		default:
+			funcName = member
+		}
+	}
+
 	// 使用正则进行精确的全词匹配 (Word Boundary)
 	safePattern := regexp.QuoteMeta(funcName)
 	pattern := fmt.Sprintf(`\b%s\b`, safePattern)
@@ -636,8 +799,8 @@ func findFunctionDefLine(lines []string, targetIdx int, funcName string) int {
 			continue
 		}
 
-		// 2. ✨✨✨ Mask Strings to avoid matching function names inside quotes (e.g. @RequestMapping("/call")) ✨✨✨
-		maskedLine := maskStrings(line)
+		// 2. 字符串内容已被屏蔽，不会匹配到引号中的函数名 (e.g. @RequestMapping("/call"))
+		maskedLine := line
 
 		// Check keywords on the MASKED line (safer)
 		ignoredKeywords := []string{"return", "if", "else", "for", "while", "do", "switch", "case", "catch", "try", "throw", "new"}
@@ -679,34 +842,29 @@ func findFunctionDefLine(lines []string, targetIdx int, funcName string) int {
 			continue
 		}
 
+		// 7. 重载方法 (参数个数不同) 和其它类中的同名方法
+		if arity >= 0 && textutil.ParamCount(joinSignature(lines, i)[idx:]) != arity {
+			continue
+		}
+		if className != "" {
+			if class := textutil.EnclosingClassName(lines, i); class != "" && class != className {
+				continue
+			}
+		}
+
 		return i // Found the definition line!
 	}
 	return -1
 }
 
-// 辅助：将字符串内容屏蔽为空格，保持长度不变
-func maskStrings(code string) string {
-	var buf bytes.Buffer
-	inString := false
-	for i := 0; i < len(code); i++ {
-		c := code[i]
-		if c == '"' {
-			if i > 0 && code[i-1] == '\\' {
-				// 转义引号，保留原样或替换为空格 (替换为空格更安全)
-				buf.WriteByte(' ')
-			} else {
-				inString = !inString
-				buf.WriteByte('"')
-			}
-		} else {
-			if inString {
-				buf.WriteByte(' ')
-			} else {
-				buf.WriteByte(c)
-			}
-		}
+// joinSignature 拼接从 defLine 开始的方法签名 (参数跨行时拼接到 "{" 为止，最多 5 行)
+// 每行都去掉首尾空白，第一行与 findFunctionDefLine 中的 maskedLine 一致，可以直接使用该行中的下标
+func joinSignature(lines []string, defLine int) string {
+	sig := strings.TrimSpace(lines[defLine])
+	for i := defLine + 1; i < len(lines) && i < defLine+5 && !strings.Contains(sig, "{"); i++ {
+		sig += " " + strings.TrimSpace(lines[i])
 	}
-	return buf.String()
+	return sig
 }
 
 func scanUpForAnnotations(lines []string, funcDefLine int) int {
@@ -726,42 +884,13 @@ func scanUpForAnnotations(lines []string, funcDefLine int) int {
 }
 
 // 向下查找函数结尾
+// lines 必须是 textutil.MaskJavaSource 处理过的源码 (注释、字符串、字符字面量中的花括号不参与计数)
 func findFunctionEnd(lines []string, startLine int) int {
 	balance := 0
 	foundFirstBrace := false
 
 	for i := startLine; i < len(lines); i++ {
-		line := lines[i]
-
-		// 简单处理行注释：截断 // 后面
-		cleanLine := line
-		if idx := strings.Index(line, "//"); idx != -1 {
-			// 为了简化，我们假设 // 就是注释 (不考虑 url http://...)
-			cleanLine = line[:idx]
-		}
-
-		// 逐字符扫描
-		inString := false
-		for j := 0; j < len(cleanLine); j++ {
-			char := cleanLine[j]
-
-			// 处理转义
-			if char == '\\' && j+1 < len(cleanLine) {
-				j++
-				continue
-			}
-
-			// 处理字符串状态
-			if char == '"' {
-				inString = !inString
-				continue
-			}
-
-			if inString {
-				continue
-			}
-
-			// 处理花括号
+		for _, char := range lines[i] {
 			if char == '{' {
 				balance++
 				foundFirstBrace = true
@@ -785,71 +914,111 @@ func findFunctionEnd(lines []string, startLine int) int {
 	return startLine + 20
 }
 
-// highlightJavaSyntax (保持不变)
-func highlightJavaSyntax(code string) string {
-	code = strings.ReplaceAll(code, "&", "&amp;")
-	code = strings.ReplaceAll(code, "<", "&lt;")
-	code = strings.ReplaceAll(code, ">", "&gt;")
-	return fastLexer(code)
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var javaKeywords = map[string]bool{
+	"public": true, "private": true, "protected": true, "class": true, "interface": true, "enum": true,
+	"return": true, "if": true, "else": true, "for": true, "while": true, "do": true, "new": true,
+	"static": true, "final": true, "void": true, "import": true, "package": true, "try": true, "catch": true,
+	"throws": true, "throw": true, "extends": true, "implements": true, "this": true, "super": true,
+	"finally": true, "switch": true, "case": true, "default": true, "break": true, "continue": true,
+	"instanceof": true, "abstract": true, "synchronized": true, "null": true, "true": true, "false": true,
 }
 
-// fastLexer (保持不变)
-func fastLexer(code string) string {
-	var buf bytes.Buffer
+// highlightJavaSyntax 对一行代码做语法高亮
+// state 是上一行结束时的词法状态 (textutil.LexCode / textutil.LexBlockComment / textutil.LexTextBlock)，返回本行结束时的状态，
+// 这样跨行的块注释和文本块也能正确着色。HTML 转义按 token 进行，span 不会切开转义实体。
+func highlightJavaSyntax(code string, state int) (string, int) {
+	var buf strings.Builder
 	n := len(code)
 	i := 0
 
-	isAlpha := func(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' }
+	isAlpha := func(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$' }
 	isNum := func(c byte) bool { return c >= '0' && c <= '9' }
-
-	keywords := map[string]bool{
-		"public": true, "private": true, "protected": true, "class": true, "interface": true, "enum": true,
-		"return": true, "if": true, "else": true, "for": true, "while": true, "do": true, "new": true,
-		"static": true, "final": true, "void": true, "import": true, "package": true, "try": true, "catch": true,
-		"throws": true, "throw": true, "extends": true, "implements": true, "this": true, "super": true,
+	span := func(class, text string) {
+		buf.WriteString(`<span class="` + class + `">`)
+		buf.WriteString(htmlEscaper.Replace(text))
+		buf.WriteString(`</span>`)
+	}
+	// closeAt 从 from 开始查找结束符，返回结束位置 (包含结束符) 以及是否找到
+	closeAt := func(from int, end string, escapes bool) (int, bool) {
+		for j := from; j < n; j++ {
+			if escapes && code[j] == '\\' {
+				j++
+				continue
+			}
+			if strings.HasPrefix(code[j:], end) {
+				return j + len(end), true
+			}
+		}
+		return n, false
 	}
 
 	for i < n {
-		c := code[i]
-
-		if c == '"' {
-			start := i
-			i++
-			for i < n && code[i] != '"' {
-				if code[i] == '\\' {
-					i++
-				}
-				i++
+		// 上一行延续下来的块注释 / 文本块
+		if state == textutil.LexBlockComment {
+			end, ok := closeAt(i, "*/", false)
+			span("s-com", code[i:end])
+			if ok {
+				state = textutil.LexCode
 			}
-			if i < n {
-				i++
+			i = end
+			continue
+		}
+		if state == textutil.LexTextBlock {
+			end, ok := closeAt(i, `"""`, true)
+			span("s-str", code[i:end])
+			if ok {
+				state = textutil.LexCode
 			}
-			buf.WriteString(`<span class="s-str">`)
-			buf.WriteString(code[start:i])
-			buf.WriteString(`</span>`)
+			i = end
 			continue
 		}
 
-		if c == '/' && i+1 < n && code[i+1] == '/' {
-			buf.WriteString(`<span class="s-com">`)
-			buf.WriteString(code[i:])
-			buf.WriteString(`</span>`)
-			break
-		}
+		c := code[i]
+		switch {
+		case strings.HasPrefix(code[i:], "//"):
+			span("s-com", code[i:])
+			i = n
+
+		case strings.HasPrefix(code[i:], "/*"):
+			end, ok := closeAt(i+2, "*/", false)
+			span("s-com", code[i:end])
+			if !ok {
+				state = textutil.LexBlockComment
+			}
+			i = end
+
+		case strings.HasPrefix(code[i:], `"""`):
+			end, ok := closeAt(i+3, `"""`, true)
+			span("s-str", code[i:end])
+			if !ok {
+				state = textutil.LexTextBlock
+			}
+			i = end
 
-		if c == '@' {
+		case c == '"' || c == '\'':
+			end, _ := closeAt(i+1, string(c), true)
+			span("s-str", code[i:end])
+			i = end
+
+		case c == '@':
 			start := i
 			i++
-			for i < n && (isAlpha(code[i]) || isNum(code[i])) {
+			for i < n && (isAlpha(code[i]) || isNum(code[i]) || code[i] == '.') {
 				i++
 			}
-			buf.WriteString(`<span class="s-ann">`)
-			buf.WriteString(code[start:i])
-			buf.WriteString(`</span>`)
-			continue
-		}
+			span("s-ann", code[start:i])
+
+		case isNum(c):
+			// 整数、浮点、十六进制以及带后缀的数字 (e.g. 0x1F, 1_000L, 1.5f)
+			start := i
+			for i < n && (isAlpha(code[i]) || isNum(code[i]) || code[i] == '.') {
+				i++
+			}
+			span("s-num", code[start:i])
 
-		if isAlpha(c) {
+		case isAlpha(c):
 			start := i
 			for i < n && (isAlpha(code[i]) || isNum(code[i])) {
 				i++
@@ -865,29 +1034,23 @@ func fastLexer(code string) string {
 				isFuncCall = true
 			}
 
-			if keywords[word] {
-				buf.WriteString(`<span class="s-kwd">`)
-				buf.WriteString(word)
-				buf.WriteString(`</span>`)
+			if javaKeywords[word] {
+				span("s-kwd", word)
 			} else if isFuncCall {
-				buf.WriteString(`<span class="s-func">`)
-				buf.WriteString(word)
-				buf.WriteString(`</span>`)
-			} else if len(word) > 0 && word[0] >= 'A' && word[0] <= 'Z' {
-				buf.WriteString(`<span class="s-type">`)
-				buf.WriteString(word)
-				buf.WriteString(`</span>`)
+				span("s-func", word)
+			} else if word[0] >= 'A' && word[0] <= 'Z' {
+				span("s-type", word)
 			} else {
 				buf.WriteString(word)
 			}
-			continue
-		}
 
-		buf.WriteByte(c)
-		i++
+		default:
+			buf.WriteString(htmlEscaper.Replace(string(c)))
+			i++
+		}
 	}
 
-	return buf.String()
+	return buf.String(), state
 }
 
 func isAlphaNum(c byte) bool {
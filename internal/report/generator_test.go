@@ -0,0 +1,89 @@
+package report
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"LSPTracer/internal/model"
+	"LSPTracer/internal/textutil"
+)
+
+var lineNumRe = regexp.MustCompile(`<span class='line-num'>(\d+)</span>`)
+
+// contextRange getSmartCodeContext 显示的第一行和最后一行 (1-based)
+func contextRange(t *testing.T, html string) (int, int) {
+	t.Helper()
+	m := lineNumRe.FindAllStringSubmatch(html, -1)
+	if len(m) == 0 {
+		t.Fatalf("no lines in context: %s", html)
+	}
+	first, _ := strconv.Atoi(m[0][1])
+	last, _ := strconv.Atoi(m[len(m)-1][1])
+	return first, last
+}
+
+// 没有 LSP 范围时按函数名和花括号平衡查找函数: 字符字面量、跨行块注释、文本块和字符串中的花括号不参与计数
+func TestSmartCodeContextBoundaries(t *testing.T) {
+	file := filepath.Join("testdata", "boundaries", "Lexer.java")
+	tests := []struct {
+		fn         string
+		line       int // 目标行 (1-based)
+		start, end int
+	}{
+		{"opens(char)", 8, 5, 11},
+		{"block(String)", 20, 17, 21},
+		{"template(String)", 27, 23, 30},
+		{"template(String)", 29, 23, 30},
+		{"strings(String)", 36, 32, 37},
+		{"after()", 40, 39, 41},
+	}
+	for _, tt := range tests {
+		html := getSmartCodeContext(model.ChainStep{File: file, Line: tt.line - 1, Func: tt.fn})
+		start, end := contextRange(t, html)
+		if start != tt.start || end != tt.end {
+			t.Errorf("%s line %d: context %d-%d, want %d-%d", tt.fn, tt.line, start, end, tt.start, tt.end)
+		}
+		highlighted := regexp.MustCompile(`highlight-line'><span class='line-num'>` + strconv.Itoa(tt.line) + `<`)
+		if !highlighted.MatchString(html) {
+			t.Errorf("%s: line %d is not highlighted", tt.fn, tt.line)
+		}
+	}
+}
+
+// LSP 提供的函数范围覆盖目标行时直接使用
+func TestSmartCodeContextLSPRange(t *testing.T) {
+	file := filepath.Join("testdata", "boundaries", "Lexer.java")
+	step := model.ChainStep{File: file, Line: 35, Func: "strings(String)", FuncStartLine: 33, FuncEndLine: 36}
+	if start, end := contextRange(t, getSmartCodeContext(step)); start != 34 || end != 37 {
+		t.Errorf("context %d-%d, want the LSP range 34-37", start, end)
+	}
+}
+
+// 同名方法按参数个数和类名区分: testdata/overload/Services.java 中有两个 OrderService.save 重载和 FileService.save
+func TestFindFunctionDefLineOverloads(t *testing.T) {
+	lines, _, err := stepSource(model.ChainStep{File: filepath.Join("testdata", "overload", "Services.java")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	masked := textutil.MaskJavaSource(lines)
+	tests := []struct {
+		target int // 0 起始
+		fn     string
+		want   int
+	}{
+		{21, "save(String)", 20},
+		{21, "FileService.save(String)", 20},
+		{21, "OrderService.save(Order)", 5}, // 不会停在 FileService.save
+		{13, "save(Order, boolean)", 9},     // 参数跨行
+		{13, "save(Order)", 5},
+		{13, "save", 9}, // 没有签名时取最近的同名声明
+		{21, "save(Order, boolean, int)", -1},
+	}
+	for _, tt := range tests {
+		if got := findFunctionDefLine(masked, tt.target, tt.fn); got != tt.want {
+			t.Errorf("findFunctionDefLine(%d, %s) = %d, want %d", tt.target, tt.fn, got, tt.want)
+		}
+	}
+}
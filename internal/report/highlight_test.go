@@ -0,0 +1,86 @@
+package report
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"LSPTracer/internal/textutil"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// 逐行高亮一段有代表性的代码 (注解、泛型、跨行块注释、URL 字符串、字符字面量、数字和文本块)，与 golden 文件比较
+// 修改高亮规则后用 go test ./internal/report -run TestHighlightJavaSyntaxGolden -update 重新生成
+func TestHighlightJavaSyntaxGolden(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "highlight", "Snippet.java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	state := textutil.LexCode
+	for _, line := range strings.Split(strings.TrimRight(string(src), "\n"), "\n") {
+		var html string
+		html, state = highlightJavaSyntax(line, state)
+		out.WriteString(html + "\n")
+	}
+
+	golden := filepath.Join("testdata", "highlight", "Snippet.html")
+	if *update {
+		if err := os.WriteFile(golden, []byte(out.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(want) {
+		t.Errorf("highlighted output differs from %s:\n%s", golden, out.String())
+	}
+}
+
+// 每个 span 内只有转义后的文本，转义实体不会被 span 切开，也不会被重复转义
+func TestHighlightJavaSyntaxEscaping(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{`a < b && c > d`, `a &lt; b &amp;&amp; c &gt; d`},
+		{`"<b>&amp;"`, `<span class="s-str">"&lt;b&gt;&amp;amp;"</span>`},
+		{`List<String>`, `<span class="s-type">List</span>&lt;<span class="s-type">String</span>&gt;`},
+		{`'<'`, `<span class="s-str">'&lt;'</span>`},
+	}
+	for _, tt := range tests {
+		if got, _ := highlightJavaSyntax(tt.code, textutil.LexCode); got != tt.want {
+			t.Errorf("highlightJavaSyntax(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+// 块注释和文本块的状态传递到下一行
+func TestHighlightJavaSyntaxState(t *testing.T) {
+	tests := []struct {
+		code  string
+		in    int
+		out   int
+		class string // 行首 token 的样式
+	}{
+		{"return a; /* open", textutil.LexCode, textutil.LexBlockComment, "s-kwd"},
+		{"return x; */ b++;", textutil.LexBlockComment, textutil.LexCode, "s-com"},
+		{`String s = """`, textutil.LexCode, textutil.LexTextBlock, "s-type"},
+		{`  if (x) { \""" }`, textutil.LexTextBlock, textutil.LexTextBlock, "s-str"},
+		{`  """;`, textutil.LexTextBlock, textutil.LexCode, "s-str"},
+	}
+	for _, tt := range tests {
+		html, state := highlightJavaSyntax(tt.code, tt.in)
+		if state != tt.out {
+			t.Errorf("%q: state %d, want %d", tt.code, state, tt.out)
+		}
+		if !strings.HasPrefix(html, `<span class="`+tt.class+`">`) {
+			t.Errorf("%q: %s, want it to start with a %s span", tt.code, html, tt.class)
+		}
+	}
+}
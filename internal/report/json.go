@@ -0,0 +1,570 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// JSON 报告结构 (供 CI 和其他工具消费)
+type jsonReport struct {
+	Metadata jsonMetadata  `json:"metadata"`
+	Findings []jsonFinding `json:"findings"`
+	// 单点模式 -direction down 的调用树
+	CallTrees []jsonCallee `json:"call_trees,omitempty"`
+}
+
+type jsonMetadata struct {
+	GeneratedAt    string      `json:"generated_at"`
+	TotalChains    int         `json:"total_chains"`
+	Health         *jsonHealth `json:"health,omitempty"`
+	ServerRestarts int         `json:"server_restarts"`
+	ScanError      string      `json:"scan_error,omitempty"`
+	Stats          *jsonStats  `json:"stats,omitempty"`
+	Summary        jsonSummary `json:"summary"`
+	jsonScanInfo
+}
+
+// jsonStats 扫描过程的统计 (对应 ScanStats)
+type jsonStats struct {
+	Candidates   int         `json:"candidates"`
+	Verified     int         `json:"verified"`
+	Traced       int         `json:"traced"`
+	ZeroHitRules []string    `json:"zero_hit_rules,omitempty"`
+	Phases       []jsonPhase `json:"phases,omitempty"`
+	FanOut       []jsonCount `json:"fan_out_limited,omitempty"` // 调用者过多、只追踪了一部分的方法
+	WarmedFiles  int         `json:"warmed_files,omitempty"`
+	VerifyAvgMs  float64     `json:"verify_avg_ms,omitempty"` // 每个候选点的平均 LSP 验证耗时
+	VerifiedBy   []jsonCount `json:"verified_by,omitempty"`   // 每种确认方式确认的 Sink 数量
+}
+
+type jsonPhase struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// jsonSummary 结果汇总 (对应 Summary，render 时从链路重新计算)
+type jsonSummary struct {
+	ByType     map[string]int `json:"by_type"`
+	BySeverity map[string]int `json:"by_severity"`
+	TopFiles   []jsonCount    `json:"top_files"`
+	Suppressed int            `json:"suppressed"` // 被抑制的发现 (不计入 total_chains 和上面的分组)
+	// 被严格模式排除的链路 (不计入 total_chains 和上面的分组)
+	StrictExcluded int `json:"strict_excluded"`
+	// 所有链路 (包括被抑制和被严格模式排除的) 按结束原因的数量
+	ByTermination map[string]int `json:"by_termination,omitempty"`
+	// 按 Sink 所在模块的数量和负责的团队 (按数量降序)
+	ByModule []jsonModuleCount `json:"by_module,omitempty"`
+	// Sink 位于测试/示例代码中、没有写入报告的发现数量 (不在 findings 中)
+	ExcludedCode []jsonCount `json:"excluded_code,omitempty"`
+}
+
+type jsonModuleCount struct {
+	Module     string         `json:"module"`
+	Count      int            `json:"count"`
+	BySeverity map[string]int `json:"by_severity"`
+	Owners     []string       `json:"owners,omitempty"`
+}
+
+type jsonCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// jsonScanInfo 报告来源信息 (JSON metadata 和 SARIF run.properties 共用)
+type jsonScanInfo struct {
+	Project         string   `json:"project,omitempty"`
+	ProjectRoot     string   `json:"project_root,omitempty"`
+	GitCommit       string   `json:"git_commit,omitempty"`
+	GitBranch       string   `json:"git_branch,omitempty"`
+	RulesFile       string   `json:"rules_file"`
+	RuleCount       int      `json:"rule_count"`
+	StrictMode      bool     `json:"strict_mode"`
+	Sources         string   `json:"sources,omitempty"`
+	ShowUnverified  bool     `json:"show_unverified,omitempty"`
+	ScanMode        string   `json:"scan_mode,omitempty"`
+	Scope           []string `json:"scope,omitempty"`
+	StartedAt       string   `json:"started_at,omitempty"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	Version         string   `json:"version,omitempty"`
+	// 报告中的路径已按这些映射换成宿主机路径 (render / diff 读取时据此还原)
+	PathMap PathMap `json:"path_map,omitempty"`
+	// 扫描的产生环境 (LSPTracer / JDT.LS / Java 版本、规则哈希、命令行参数、主机平台)
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// 报告的界面语言 (render 未指定 -lang 时沿用)
+	Locale string `json:"locale,omitempty"`
+}
+
+func newScanInfo(meta Metadata) jsonScanInfo {
+	info := jsonScanInfo{
+		Project:         meta.ProjectName,
+		ProjectRoot:     meta.ProjectRoot,
+		GitCommit:       meta.GitCommit,
+		GitBranch:       meta.GitBranch,
+		RulesFile:       meta.RulesLabel(),
+		RuleCount:       meta.RuleCount,
+		StrictMode:      meta.StrictMode,
+		Sources:         meta.Sources,
+		ShowUnverified:  meta.ShowUnverified,
+		ScanMode:        meta.ScanMode,
+		Scope:           meta.Scope,
+		DurationSeconds: meta.Duration.Seconds(),
+		Version:         meta.Version,
+		PathMap:         meta.PathMap,
+		Provenance:      meta.Provenance,
+		Locale:          meta.Locale,
+	}
+	if !meta.StartedAt.IsZero() {
+		info.StartedAt = meta.StartedAt.Format(time.RFC3339)
+	}
+	return info
+}
+
+type jsonHealth struct {
+	CompileErrors   int     `json:"compile_errors"`
+	FilesWithErrors int     `json:"files_with_errors"`
+	FilesReported   int     `json:"files_reported"`
+	Score           float64 `json:"score"`
+}
+
+type jsonFinding struct {
+	ID          int    `json:"id"`          // 展示用编号 (按 Sink 位置排序后分配)，跨扫描引用请使用 fingerprint
+	Fingerprint string `json:"fingerprint"` // 不含行号的稳定指纹，diff 子命令用它匹配两次扫描的结果
+	VulnType    string `json:"vuln_type"`
+	Title       string `json:"title"`
+	Rule        string `json:"rule,omitempty"`
+	Severity    string `json:"severity,omitempty"` // 规则等级
+	// 验证状态: verified / unverified (及原因)；未验证的发现有效等级比规则等级低一级
+	Verification      string   `json:"verification"`
+	UnverifiedReason  string   `json:"unverified_reason,omitempty"`
+	EffectiveSeverity string   `json:"effective_severity,omitempty"`
+	CWE               string   `json:"cwe,omitempty"`
+	SourceKind        string   `json:"source_kind,omitempty"`  // Source 的入口类型: HTTP / MESSAGE_QUEUE / SCHEDULED / CLI / UNKNOWN
+	Routes            []string `json:"routes,omitempty"`       // Source 处理器方法的 HTTP 路由 (e.g. "POST /api/files/upload")
+	SourceInput       string   `json:"source_input,omitempty"` // Source 的输入信号: tainted / none / unknown
+	// 链路结束的原因: REACHED_ENTRY / NO_CALLERS / DEPTH_LIMIT / FANOUT_LIMIT / TIME_BUDGET / CYCLE
+	Termination string `json:"termination,omitempty"`
+	// 严格模式排除的原因: 非空的发现仍然保留 (render 可以切换严格模式)，但不计入 total_chains
+	StrictExcluded string `json:"strict_excluded,omitempty"`
+	// SSRF 发现中输入能控制的 URL 部分: FULL (协议/主机) / PATH / QUERY，PATH 和 QUERY 的有效等级会降低
+	URLControl string `json:"url_control,omitempty"`
+	// 规则 severity_overrides 中生效的条目: effective_severity 以它的等级为基础 (不再按 url_control 降级)
+	SeverityOverride *model.SeverityOverride `json:"severity_override,omitempty"`
+	// Sink 所在的模块 (相对项目根目录，根目录为 ".") 和 -owners 中负责的团队，供工单路由使用
+	Module string `json:"module,omitempty"`
+	Owner  string `json:"owner,omitempty"`
+	// Sink 所在代码的类别 (MAIN / TEST / SAMPLE)；test_only 表示链路只能从测试代码到达 (有效等级降低一级)
+	CodeKind string `json:"code_kind,omitempty"`
+	TestOnly bool   `json:"test_only,omitempty"`
+	// 可信度等级、得分和参与评分的信号，与严重等级相互独立
+	Confidence        string                 `json:"confidence,omitempty"`
+	ConfidenceScore   int                    `json:"confidence_score,omitempty"`
+	ConfidenceSignals *jsonConfidenceSignals `json:"confidence_signals,omitempty"`
+	// 白名单或 lsptracer:ignore 注释: action 为 suppress 的发现不计入 total_chains
+	Suppression *jsonSuppression `json:"suppression,omitempty"`
+	// 规则的匹配目标和描述 (render 重新生成 SARIF 时需要)
+	RuleClass      string `json:"rule_class,omitempty"`
+	RuleMethod     string `json:"rule_method,omitempty"`
+	RuleAnnotation bool   `json:"rule_annotation,omitempty"`
+	RuleDesc       string `json:"rule_desc,omitempty"`
+	RuleDescEn     string `json:"rule_desc_en,omitempty"`
+	RuleDescZh     string `json:"rule_desc_zh,omitempty"`
+	// 同一个调用上命中的所有规则: 第一条是主规则 (等级最高，与 rule 相同)，其余规则不再生成重复的发现
+	Rules []jsonRuleMatch `json:"rules"`
+	// 规则中的参考链接和修复建议原样输出
+	References  []string   `json:"references,omitempty"`
+	Remediation string     `json:"remediation,omitempty"`
+	Steps       []jsonStep `json:"steps"` // Source -> Sink
+}
+
+type jsonRuleMatch struct {
+	Name     string `json:"name"`
+	VulnType string `json:"vuln_type,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	CWE      string `json:"cwe,omitempty"`
+}
+
+type jsonConfidenceSignals struct {
+	VerifiedBy     string `json:"verified_by,omitempty"`
+	Evidence       string `json:"verification_evidence,omitempty"` // Sink 类型的确认依据
+	FrameworkEntry bool   `json:"framework_entry"`
+	TaintedInput   bool   `json:"tainted_input"`
+	DataFlow       bool   `json:"dataflow"`
+	Complete       bool   `json:"complete"`
+	Sanitizer      string `json:"sanitizer,omitempty"` // 经过的净化函数，可信度因此降低
+}
+
+type jsonSuppression struct {
+	Action string `json:"action"` // suppress / downgrade / expired (过期的注释不再生效)
+	Source string `json:"source"` // allowlist / inline
+	Reason string `json:"reason"`
+	Until  string `json:"until,omitempty"`
+}
+
+type jsonStep struct {
+	Type     string   `json:"type"` // SOURCE / STEP / SINK
+	File     string   `json:"file"`
+	Line     int      `json:"line"` // 1-based
+	Func     string   `json:"func"`
+	Code     string   `json:"code,omitempty"`
+	Analysis []string `json:"analysis,omitempty"`
+	// 所在的类 (嵌套类用 "." 连接)，标题和指纹使用 class.func
+	Class string `json:"class,omitempty"`
+	// 所在函数的范围 (1-based) 和扫描时保存的源码片段，render 子命令用它们重新生成报告
+	FuncStartLine int          `json:"func_start_line,omitempty"`
+	FuncEndLine   int          `json:"func_end_line,omitempty"`
+	Snippet       *jsonSnippet `json:"snippet,omitempty"`
+	// 步骤所在代码的类别 (MAIN / TEST / SAMPLE)
+	CodeKind string `json:"code_kind,omitempty"`
+}
+
+type jsonSnippet struct {
+	StartLine int      `json:"start_line"` // 1-based
+	Lines     []string `json:"lines"`
+}
+
+// GenerateJSON 生成 JSON 报告，发现逐条从 chains 读回并写出
+func GenerateJSON(chains *Chains, projectRoot string, meta Metadata) {
+	summary, err := chains.Summarize(projectRoot)
+	if err != nil {
+		color.Red("[-] Failed to read findings for the JSON report: %v", err)
+		return
+	}
+	// findings 为 nil，写出时由 encodeStreamed 替换为逐条生成的数组
+	out := jsonReport{
+		Metadata: jsonMetadata{
+			GeneratedAt:    time.Now().Format(time.RFC3339),
+			TotalChains:    chains.Len(),
+			ServerRestarts: meta.ServerRestarts,
+			ScanError:      meta.ScanError,
+			jsonScanInfo:   newScanInfo(meta),
+		},
+	}
+	for _, tree := range meta.CallTrees {
+		out.CallTrees = append(out.CallTrees, newJSONCallee(tree, projectRoot))
+	}
+	out.Metadata.TotalChains -= summary.Suppressed + summary.Excluded
+	out.Metadata.Summary = jsonSummary{
+		ByType:     make(map[string]int),
+		BySeverity: make(map[string]int),
+		TopFiles:   make([]jsonCount, 0, len(summary.TopFiles)),
+		Suppressed: summary.Suppressed,
+
+		StrictExcluded: summary.Excluded,
+	}
+	for _, c := range summary.ByType {
+		out.Metadata.Summary.ByType[c.Name] = c.Count
+	}
+	for _, c := range summary.BySeverity {
+		out.Metadata.Summary.BySeverity[c.Name] = c.Count
+	}
+	for _, c := range summary.TopFiles {
+		out.Metadata.Summary.TopFiles = append(out.Metadata.Summary.TopFiles, jsonCount{Name: c.Name, Count: c.Count})
+	}
+	for _, m := range summary.ByModule {
+		jm := jsonModuleCount{Module: m.Name, Count: m.Count, BySeverity: make(map[string]int), Owners: m.Owners}
+		for _, c := range m.BySeverity {
+			jm.BySeverity[c.Name] = c.Count
+		}
+		out.Metadata.Summary.ByModule = append(out.Metadata.Summary.ByModule, jm)
+	}
+	for _, c := range meta.ExcludedCode {
+		out.Metadata.Summary.ExcludedCode = append(out.Metadata.Summary.ExcludedCode, jsonCount{Name: c.Name, Count: c.Count})
+	}
+	if len(summary.ByTermination) > 0 {
+		out.Metadata.Summary.ByTermination = make(map[string]int)
+		for _, c := range summary.ByTermination {
+			out.Metadata.Summary.ByTermination[c.Name] = c.Count
+		}
+	}
+	if s := meta.Stats; s != nil {
+		stats := &jsonStats{Candidates: s.Candidates, Verified: s.Verified, Traced: s.Traced, ZeroHitRules: s.ZeroHitRules,
+			WarmedFiles: s.WarmedFiles, VerifyAvgMs: float64(s.VerifyAvg.Microseconds()) / 1000}
+		for _, p := range s.Phases {
+			stats.Phases = append(stats.Phases, jsonPhase{Name: p.Name, Seconds: p.Duration.Seconds()})
+		}
+		for _, c := range s.FanOut {
+			stats.FanOut = append(stats.FanOut, jsonCount{Name: c.Name, Count: c.Count})
+		}
+		for _, c := range s.VerifiedBy {
+			stats.VerifiedBy = append(stats.VerifiedBy, jsonCount{Name: c.Name, Count: c.Count})
+		}
+		out.Metadata.Stats = stats
+	}
+	if meta.Health != nil {
+		out.Metadata.Health = &jsonHealth{
+			CompileErrors:   meta.Health.Errors,
+			FilesWithErrors: meta.Health.FilesWithErrors,
+			FilesReported:   meta.Health.FilesReported,
+			Score:           meta.Health.Health(),
+		}
+	}
+
+	f, absPath, err := createOutputFile("json")
+	if err != nil {
+		color.Red("[-] Failed to create output file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	err = encodeStreamed(f, out, "findings", "  ", func(emit func(elem interface{}) error) error {
+		return chains.Each(func(id int, stack []model.ChainStep) error {
+			return emit(newJSONFinding(id, stack, projectRoot))
+		})
+	})
+	if err != nil {
+		color.Red("[-] Failed to write JSON report: %v", err)
+		return
+	}
+	color.Green("[+] JSON report generated: %s", absPath)
+}
+
+// newJSONFinding 编号为 id 的链路在 JSON 报告中的发现，步骤按 Source -> Sink 的顺序
+func newJSONFinding(id int, stack []model.ChainStep, projectRoot string) jsonFinding {
+	finding := jsonFinding{
+		ID:          id,
+		Fingerprint: Fingerprint(stack, projectRoot),
+		VulnType:    chainVulnType(stack),
+
+		Verification:      "verified",
+		UnverifiedReason:  chainUnverified(stack),
+		EffectiveSeverity: chainSeverity(stack),
+	}
+	if finding.UnverifiedReason != "" {
+		finding.Verification = "unverified"
+	}
+	if len(stack) > 0 {
+		finding.Title = stack[0].QualifiedFunc()
+		finding.SourceKind = stack[len(stack)-1].SourceKind
+		finding.Routes = chainRoutes(stack)
+		finding.SourceInput = stack[len(stack)-1].SourceInput
+		finding.Termination = chainTermination(stack)
+		finding.StrictExcluded = chainStrictExcluded(stack)
+		finding.URLControl = chainURLControl(stack)
+		finding.SeverityOverride = chainSeverityOverride(stack)
+		finding.Module, finding.Owner = chainModule(stack), chainOwner(stack)
+		finding.CodeKind, finding.TestOnly = stack[0].CodeKind, stack[0].TestOnly
+	}
+	if c := chainConfidence(stack); c != nil {
+		finding.Confidence = c.Level()
+		finding.ConfidenceScore = c.Score()
+		finding.ConfidenceSignals = &jsonConfidenceSignals{
+			VerifiedBy:     c.Verification.Method,
+			Evidence:       c.Verification.Evidence,
+			FrameworkEntry: c.FrameworkEntry,
+			TaintedInput:   c.TaintedInput,
+			DataFlow:       c.DataFlow,
+			Complete:       c.Complete,
+			Sanitizer:      c.Sanitizer,
+		}
+	}
+	if s := chainSuppression(stack); s != nil {
+		finding.Suppression = &jsonSuppression{Action: s.Action, Source: s.Source, Reason: s.Reason, Until: s.Until}
+	}
+	if rule := chainRule(stack); rule != nil {
+		finding.Rule = rule.Name
+		finding.Severity = rule.Severity
+		finding.CWE = rule.CWE
+		finding.RuleClass = rule.ClassName
+		finding.RuleMethod = rule.MethodName
+		finding.RuleAnnotation = rule.Annotation
+		finding.RuleDesc = rule.Desc
+		finding.RuleDescEn, finding.RuleDescZh = rule.DescEn, rule.DescZh
+		finding.References = rule.References
+		finding.Remediation = rule.Remediation
+	}
+	finding.Rules = []jsonRuleMatch{}
+	if rule := chainRule(stack); rule != nil {
+		finding.Rules = append(finding.Rules, jsonRuleMatch{Name: rule.Name, VulnType: rule.VulnType, Severity: rule.Severity, CWE: rule.CWE})
+	}
+	for _, rule := range chainAlsoMatched(stack) {
+		finding.Rules = append(finding.Rules, jsonRuleMatch{Name: rule.Name, VulnType: rule.VulnType, Severity: rule.Severity, CWE: rule.CWE})
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		step := stack[i]
+		stepType := "STEP"
+		if i == len(stack)-1 {
+			stepType = "SOURCE"
+		} else if i == 0 {
+			stepType = "SINK"
+		}
+
+		displayPath := step.File
+		if rel, err := filepath.Rel(projectRoot, step.File); err == nil {
+			displayPath = rel
+		}
+
+		js := jsonStep{
+			Type:     stepType,
+			File:     filepath.ToSlash(displayPath),
+			Line:     step.Line + 1,
+			Func:     step.Func,
+			Class:    step.Class,
+			Code:     step.Code,
+			Analysis: step.Analysis,
+			CodeKind: step.CodeKind,
+		}
+		if step.FuncEndLine > 0 {
+			js.FuncStartLine, js.FuncEndLine = step.FuncStartLine+1, step.FuncEndLine+1
+		}
+		if len(step.Context) > 0 {
+			js.Snippet = &jsonSnippet{StartLine: step.ContextStart + 1, Lines: step.Context}
+		}
+		finding.Steps = append(finding.Steps, js)
+	}
+	return finding
+}
+
+// LoadJSON 读取 JSON 报告，还原为链路和元信息 (供 render 子命令重新生成其它格式的报告)
+// 步骤中的相对路径按 metadata.project_root 还原为绝对路径，再按 metadata.path_map 反向映射回扫描环境的路径
+func LoadJSON(path string) ([][]model.ChainStep, Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	var in jsonReport
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, Metadata{}, fmt.Errorf("parse %s: %v", path, err)
+	}
+
+	m := in.Metadata
+	meta := Metadata{
+		ServerRestarts: m.ServerRestarts,
+		ScanError:      m.ScanError,
+		ProjectName:    m.Project,
+		ProjectRoot:    m.ProjectRoot,
+		GitCommit:      m.GitCommit,
+		GitBranch:      m.GitBranch,
+		RuleCount:      m.RuleCount,
+		StrictMode:     m.StrictMode,
+		Sources:        m.Sources,
+		ShowUnverified: m.ShowUnverified,
+		ScanMode:       m.ScanMode,
+		Scope:          m.Scope,
+		Duration:       time.Duration(m.DurationSeconds * float64(time.Second)),
+		Version:        m.Version,
+		PathMap:        m.PathMap,
+		Provenance:     m.Provenance,
+		Locale:         m.Locale,
+	}
+	if m.RulesFile != "built-in" {
+		meta.RulesFile = m.RulesFile
+	}
+	if t, err := time.Parse(time.RFC3339, m.StartedAt); err == nil {
+		meta.StartedAt = t
+	}
+	if s := m.Stats; s != nil {
+		meta.Stats = &ScanStats{Candidates: s.Candidates, Verified: s.Verified, Traced: s.Traced, ZeroHitRules: s.ZeroHitRules,
+			WarmedFiles: s.WarmedFiles, VerifyAvg: time.Duration(s.VerifyAvgMs * float64(time.Millisecond))}
+		for _, p := range s.Phases {
+			meta.Stats.Phases = append(meta.Stats.Phases, Phase{Name: p.Name, Duration: time.Duration(p.Seconds * float64(time.Second))})
+		}
+		for _, c := range s.FanOut {
+			meta.Stats.FanOut = append(meta.Stats.FanOut, Count{Name: c.Name, Count: c.Count})
+		}
+		for _, c := range s.VerifiedBy {
+			meta.Stats.VerifiedBy = append(meta.Stats.VerifiedBy, Count{Name: c.Name, Count: c.Count})
+		}
+	}
+	if m.Health != nil {
+		meta.Health = &lsp.DiagnosticStats{
+			Errors:          m.Health.CompileErrors,
+			FilesWithErrors: m.Health.FilesWithErrors,
+			FilesReported:   m.Health.FilesReported,
+		}
+	}
+
+	for _, c := range m.Summary.ExcludedCode {
+		meta.ExcludedCode = append(meta.ExcludedCode, Count{Name: c.Name, Count: c.Count})
+	}
+	for _, tree := range in.CallTrees {
+		meta.CallTrees = append(meta.CallTrees, tree.node(meta.ProjectRoot))
+	}
+
+	chains := make([][]model.ChainStep, 0, len(in.Findings))
+	for _, f := range in.Findings {
+		// JSON 中是 Source -> Sink，链路中是 Sink -> Source
+		stack := make([]model.ChainStep, 0, len(f.Steps))
+		for i := len(f.Steps) - 1; i >= 0; i-- {
+			js := f.Steps[i]
+			file := filepath.FromSlash(js.File)
+			if !filepath.IsAbs(file) && meta.ProjectRoot != "" {
+				file = filepath.Join(meta.ProjectRoot, file)
+			}
+			step := model.ChainStep{
+				File:     file,
+				Line:     js.Line - 1,
+				Func:     js.Func,
+				Class:    js.Class,
+				Code:     js.Code,
+				Analysis: js.Analysis,
+				CodeKind: js.CodeKind,
+			}
+			if js.FuncEndLine > 0 {
+				step.FuncStartLine, step.FuncEndLine = js.FuncStartLine-1, js.FuncEndLine-1
+			}
+			if js.Snippet != nil {
+				step.Context, step.ContextStart = js.Snippet.Lines, js.Snippet.StartLine-1
+			}
+			stack = append(stack, step)
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].SourceKind = f.SourceKind
+			stack[len(stack)-1].Routes = f.Routes
+			stack[len(stack)-1].SourceInput = f.SourceInput
+			stack[len(stack)-1].Termination = f.Termination
+			stack[0].StrictExcluded = f.StrictExcluded
+			stack[0].URLControl = f.URLControl
+			stack[0].SeverityOverride = f.SeverityOverride
+			stack[0].Module, stack[0].Owner = f.Module, f.Owner
+			stack[0].TestOnly = f.TestOnly
+		}
+		if len(stack) > 0 && f.Verification == "unverified" {
+			stack[0].Unverified = f.UnverifiedReason
+		}
+		if s := f.ConfidenceSignals; len(stack) > 0 && s != nil {
+			stack[0].Confidence = &model.Confidence{
+				Verification:   model.VerificationResult{Method: s.VerifiedBy, Evidence: s.Evidence},
+				FrameworkEntry: s.FrameworkEntry,
+				TaintedInput:   s.TaintedInput,
+				DataFlow:       s.DataFlow,
+				Complete:       s.Complete,
+				Sanitizer:      s.Sanitizer,
+			}
+		}
+		if s := f.Suppression; len(stack) > 0 && s != nil {
+			stack[0].Suppression = &model.Suppression{Action: s.Action, Source: s.Source, Reason: s.Reason, Until: s.Until}
+		}
+		if len(stack) > 0 && f.Rule != "" {
+			stack[0].Rule = &model.SinkRule{
+				Name:        f.Rule,
+				VulnType:    f.VulnType,
+				Desc:        f.RuleDesc,
+				DescEn:      f.RuleDescEn,
+				DescZh:      f.RuleDescZh,
+				ClassName:   f.RuleClass,
+				MethodName:  f.RuleMethod,
+				Annotation:  f.RuleAnnotation,
+				Severity:    f.Severity,
+				CWE:         f.CWE,
+				References:  f.References,
+				Remediation: f.Remediation,
+			}
+			for _, r := range f.Rules[min(1, len(f.Rules)):] {
+				stack[0].AlsoMatched = append(stack[0].AlsoMatched, model.SinkRule{Name: r.Name, VulnType: r.VulnType, Severity: r.Severity, CWE: r.CWE})
+			}
+		}
+		chains = append(chains, stack)
+	}
+	// 报告在写出时做过 -path-map 映射: 还原为扫描环境的路径，重新生成报告时会再次映射
+	chains, meta = ScanView(chains, meta)
+	return chains, meta, nil
+}
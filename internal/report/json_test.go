@@ -0,0 +1,194 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"LSPTracer/internal/model"
+)
+
+// renderFixture 两条带 LSP 函数范围和 Source 信息的链路 (RCE 和 SQLI)，源码写在 root 下
+func renderFixture(t *testing.T, root string) [][]model.ChainStep {
+	t.Helper()
+	rules := model.GetBuiltinRules()
+	rule := func(name string) *model.SinkRule {
+		for i := range rules {
+			if rules[i].Name == name {
+				return &rules[i]
+			}
+		}
+		t.Fatalf("no built-in rule %s", name)
+		return nil
+	}
+	service := writeLargeSource(t, root, 0, 6)
+	dao := writeLargeSource(t, root, 1, 6)
+	// 与扫描时一样嵌入所在函数的源码片段
+	step := func(file string, method, offset int) model.ChainStep {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := 3 + method*20
+		return model.ChainStep{
+			File: file, Line: start + offset, Func: fmt.Sprintf("method%d(String)", method),
+			Class:         strings.TrimSuffix(filepath.Base(file), ".java"),
+			Code:          fmt.Sprintf("String value%d = helper.transform(input, \"%d-%d\");", offset-1, method, offset-1),
+			FuncStartLine: start, FuncEndLine: start + 19, CodeKind: model.CodeMain,
+			Context: strings.Split(string(data), "\n")[start : start+20], ContextStart: start,
+		}
+	}
+
+	rce := []model.ChainStep{step(service, 2, 5), step(service, 1, 3), step(service, 0, 2)}
+	rce[0].Rule, rce[0].Analysis = rule("RCE (Runtime.exec)"), []string{"🚨 Matched Rule: RCE (Runtime.exec)"}
+	rce[2].SourceKind, rce[2].Routes, rce[2].Termination = model.SourceHTTP, []string{"GET /run"}, model.TerminationReachedEntry
+
+	sqli := []model.ChainStep{step(dao, 4, 7), step(service, 3, 1)}
+	sqli[0].Rule, sqli[0].Unverified = rule("SQLI (Statement.executeQuery)"), "no call context"
+	sqli[1].SourceKind, sqli[1].Routes, sqli[1].Termination = model.SourceHTTP, []string{"POST /query"}, model.TerminationReachedEntry
+	return [][]model.ChainStep{rce, sqli}
+}
+
+// generatedAtRe HTML 中的生成时间
+var generatedAtRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`)
+
+// 扫描时写出的 JSON 经过 LoadJSON 之后生成的 HTML 与扫描时直接生成的 HTML 相同 (除生成时间外)
+// 源码删除之后依然相同: 扫描时嵌入的函数片段保存在 JSON 中
+func TestRenderFromJSONMatchesHTML(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	meta := Metadata{
+		ProjectName: "demo", ProjectRoot: root, RuleCount: 67, StrictMode: true, Sources: model.DefaultSources,
+		ScanMode: "light", StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Duration: 42 * time.Second,
+		Version: "1.2.3", Locale: "en",
+	}
+	generate := func(gen func(*Chains, string, Metadata), chains [][]model.ChainStep, meta Metadata) string {
+		withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+		c, meta, err := NewChains(SliceSource(chains), root, meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen(c, root, meta)
+		matches, _ := filepath.Glob(filepath.Join(OutputDir, "report_*"))
+		if len(matches) != 1 {
+			t.Fatalf("want one report, got %v", matches)
+		}
+		return matches[0]
+	}
+	readHTML := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return generatedAtRe.ReplaceAllString(string(data), "")
+	}
+
+	direct := readHTML(generate(GenerateHTML, chains, meta))
+	results := generate(GenerateJSON, chains, meta)
+	for _, c := range chains {
+		for _, s := range c {
+			os.Remove(s.File)
+		}
+	}
+
+	loaded, loadedMeta, err := LoadJSON(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(chains) {
+		t.Fatalf("loaded %d chains, want %d", len(loaded), len(chains))
+	}
+	rendered := readHTML(generate(GenerateHTML, loaded, loadedMeta))
+	if rendered != direct {
+		t.Errorf("HTML rendered from JSON differs from direct generation\n%s", firstDiff(direct, rendered))
+	}
+}
+
+// firstDiff 两段文本第一处不同的行
+func firstDiff(a, b string) string {
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(al) && i < len(bl); i++ {
+		if al[i] != bl[i] {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, al[i], bl[i])
+		}
+	}
+	return fmt.Sprintf("%d lines vs %d lines", len(al), len(bl))
+}
+
+// 标题、步骤和 SARIF 消息使用 类名.函数 (嵌套类为 Outer.Inner.method)；JSON 中类名单独保存，LoadJSON 读回
+func TestQualifiedFuncInReports(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	chains[0][0].Class = "Service0.Runner"
+	meta := Metadata{ProjectName: "demo", ProjectRoot: root}
+
+	dir := withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	c, meta, err := NewChains(SliceSource(chains), root, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	GenerateJSON(c, root, meta)
+	GenerateHTML(c, root, meta)
+	results, _ := filepath.Glob(filepath.Join(dir, "report_*.json"))
+	pages, _ := filepath.Glob(filepath.Join(dir, "report_*.html"))
+	if len(results) != 1 || len(pages) != 1 {
+		t.Fatalf("reports: %v %v", results, pages)
+	}
+
+	data, err := os.ReadFile(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Findings []jsonFinding `json:"findings"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, f := range report.Findings {
+		titles = append(titles, f.Title)
+		sink := f.Steps[len(f.Steps)-1]
+		if f.Title != sink.Class+"."+sink.Func {
+			t.Errorf("title %s, sink step class %q func %q", f.Title, sink.Class, sink.Func)
+		}
+	}
+	if !slices.Contains(titles, "Service0.Runner.method2(String)") || !slices.Contains(titles, "Service1.method4(String)") {
+		t.Errorf("titles = %v", titles)
+	}
+
+	page, err := os.ReadFile(pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Service0.Runner.method2(String)", "Service0.method1(String)", "Service0.method3(String)"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("HTML report does not contain %s", want)
+		}
+	}
+
+	loaded, _, err := LoadJSON(results[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stack := range loaded {
+		if stack[0].Func == "method2(String)" && stack[0].Class != "Service0.Runner" {
+			t.Errorf("loaded sink class = %q", stack[0].Class)
+		}
+	}
+
+	result := newSarifResult(chains[0], map[string]int{}, root)
+	flow := result.CodeFlows[0].ThreadFlows[0].Locations
+	if got := flow[len(flow)-1].Location.Message.Text; got != "Service0.Runner.method2(String)" {
+		t.Errorf("SARIF sink message = %s", got)
+	}
+	if _, ok := result.PartialFingerprints["lsptracerChain/v2"]; !ok {
+		t.Errorf("partial fingerprints = %v", result.PartialFingerprints)
+	}
+}
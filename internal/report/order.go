@@ -0,0 +1,30 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"LSPTracer/internal/model"
+)
+
+// chainSortKey Sink 在前、Source 次之，然后是中间步骤；行号补零以便按字符串比较
+func chainSortKey(stack []model.ChainStep, projectRoot string) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	loc := func(step model.ChainStep) string {
+		path := step.File
+		if rel, err := filepath.Rel(projectRoot, step.File); err == nil {
+			path = rel
+		}
+		return fmt.Sprintf("%s\x00%08d", filepath.ToSlash(path), step.Line)
+	}
+
+	parts := []string{loc(stack[0]), loc(stack[len(stack)-1])}
+	for i := 1; i < len(stack)-1; i++ {
+		parts = append(parts, loc(stack[i]))
+	}
+	parts = append(parts, chainVulnType(stack))
+	return strings.Join(parts, "\x01")
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// 任意追加顺序得到相同的编号: Sink 文件、Sink 行号，Sink 相同时按 Source 位置
+func TestNewChainsOrderIndependent(t *testing.T) {
+	sourceAt := func(file string, line int, source string, sourceLine int) []model.ChainStep {
+		stack := testChain(file, line, "exec(x)")
+		stack[1].File, stack[1].Line = source, sourceLine
+		return stack
+	}
+	chains := [][]model.ChainStep{
+		sourceAt("/scan/src/A.java", 7, "/scan/src/Web.java", 12),
+		sourceAt("/scan/src/A.java", 7, "/scan/src/Web.java", 40),
+		sourceAt("/scan/src/A.java", 30, "/scan/src/Api.java", 3),
+		sourceAt("/scan/src/B.java", 5, "/scan/src/Api.java", 9),
+		sourceAt("/scan/src/a/C.java", 2, "/scan/src/Api.java", 1),
+	}
+	key := func(s []model.ChainStep) string {
+		return fmt.Sprintf("%s:%d<-%s:%d", s[0].File, s[0].Line, s[1].File, s[1].Line)
+	}
+	var want []string
+	for _, s := range chains {
+		want = append(want, key(s))
+	}
+
+	for shift := range chains {
+		shuffled := append(slices.Clone(chains[shift:]), chains[:shift]...)
+		slices.Reverse(shuffled)
+		sorted, _, err := NewChains(SliceSource(shuffled), "/scan", Metadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		sorted.Each(func(id int, stack []model.ChainStep) error {
+			got = append(got, key(stack))
+			return nil
+		})
+		if !slices.Equal(got, want) {
+			t.Errorf("shift %d:\n got %v\nwant %v", shift, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/model"
+)
+
+// 扫描在容器中运行时，报告中的路径 (/src/...) 在宿主机上没有意义:
+// -path-map /src=/home/dev/app 在写报告时把扫描环境的路径换成报告读者的路径，扫描本身仍然使用容器内的路径
+
+// PathMapping 一条路径映射: 扫描环境中的 From 对应报告读者环境中的 To
+type PathMapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PathMap -path-map 参数 (可重复)，前缀按路径分隔符匹配，多条匹配时最长的前缀优先
+type PathMap []PathMapping
+
+// ParsePathMapping 解析 from=to
+func ParsePathMapping(s string) (PathMapping, error) {
+	from, to, ok := strings.Cut(s, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return PathMapping{}, fmt.Errorf("invalid path mapping %q (expected container=host, e.g. /src=/home/dev/app)", s)
+	}
+	return PathMapping{From: from, To: to}, nil
+}
+
+func (m PathMapping) String() string {
+	return m.From + "=" + m.To
+}
+
+// ToHost 扫描环境的路径 -> 报告读者的路径
+func (m PathMap) ToHost(path string) string {
+	return m.translate(path, false)
+}
+
+// ToScan 报告读者的路径 -> 扫描环境的路径 (读取其它环境生成的 JSON 结果时使用)
+func (m PathMap) ToScan(path string) string {
+	return m.translate(path, true)
+}
+
+func (m PathMap) translate(path string, reverse bool) string {
+	out, best := path, -1
+	for _, pm := range m {
+		from, to := pm.From, pm.To
+		if reverse {
+			from, to = to, from
+		}
+		prefix := trimSeparators(from)
+		if len(prefix) <= best {
+			continue
+		}
+		if mapped, ok := replacePrefix(path, prefix, to); ok {
+			out, best = mapped, len(prefix)
+		}
+	}
+	return out
+}
+
+// replacePrefix path 以 prefix 开头且前缀后面是路径分隔符 (或者正好相等) 时替换为 to
+// /src 不会匹配 /srcfoo；剩余部分换成 to 使用的分隔符 (容器中的 Linux 路径映射到 Windows 宿主机)
+func replacePrefix(path, prefix, to string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	rest := path[len(prefix):]
+	if rest != "" && !isSeparator(rest[0]) {
+		return "", false
+	}
+	if strings.Contains(to, `\`) && !strings.Contains(to, "/") {
+		rest = strings.ReplaceAll(rest, "/", `\`)
+	} else {
+		rest = strings.ReplaceAll(rest, `\`, "/")
+	}
+	if out := trimSeparators(to) + rest; out != "" {
+		return out, true
+	}
+	return to, true
+}
+
+func trimSeparators(path string) string {
+	return strings.TrimRight(path, `/\`)
+}
+
+func isSeparator(c byte) bool {
+	return c == '/' || c == '\\'
+}
+
+// HostView 把链路和元信息中的路径换成报告读者的路径 (返回副本，扫描结果本身不变)
+func HostView(chains [][]model.ChainStep, meta Metadata) ([][]model.ChainStep, Metadata) {
+	return meta.PathMap.view(chains, meta, meta.PathMap.ToHost)
+}
+
+// ScanView HostView 的逆操作: 按报告中记录的映射把路径还原为扫描环境的路径
+func ScanView(chains [][]model.ChainStep, meta Metadata) ([][]model.ChainStep, Metadata) {
+	return meta.PathMap.view(chains, meta, meta.PathMap.ToScan)
+}
+
+func (m PathMap) view(chains [][]model.ChainStep, meta Metadata, mapPath func(string) string) ([][]model.ChainStep, Metadata) {
+	if len(m) == 0 {
+		return chains, meta
+	}
+	out := make([][]model.ChainStep, len(chains))
+	for i, stack := range chains {
+		out[i] = mapChain(stack, mapPath)
+	}
+
+	meta.ProjectRoot = mapPath(meta.ProjectRoot)
+	meta.Endpoints = MapEndpoints(meta.Endpoints, mapPath)
+	trees := make([]*model.CalleeNode, len(meta.CallTrees))
+	for i, tree := range meta.CallTrees {
+		trees[i] = mapCalleeNode(tree, mapPath)
+	}
+	if meta.CallTrees != nil {
+		meta.CallTrees = trees
+	}
+	return out, meta
+}
+
+// mapChain 返回链路的副本，其中每一步的文件路径经过 mapPath 转换
+func mapChain(stack []model.ChainStep, mapPath func(string) string) []model.ChainStep {
+	out := append([]model.ChainStep(nil), stack...)
+	for i := range out {
+		out[i].File = mapPath(out[i].File)
+	}
+	return out
+}
+
+// MapEndpoints 返回端点清单的副本，其中的文件路径经过 mapPath 转换
+func MapEndpoints(endpoints []entrypoints.Endpoint, mapPath func(string) string) []entrypoints.Endpoint {
+	if endpoints == nil {
+		return nil
+	}
+	out := append([]entrypoints.Endpoint(nil), endpoints...)
+	for i := range out {
+		out[i].File = mapPath(out[i].File)
+	}
+	return out
+}
+
+func mapCalleeNode(n *model.CalleeNode, mapPath func(string) string) *model.CalleeNode {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.File = mapPath(n.File)
+	if n.Children == nil {
+		return &c // 叶子保持 nil，映射前后的调用树结构相同
+	}
+	c.Children = make([]*model.CalleeNode, len(n.Children))
+	for i, child := range n.Children {
+		c.Children[i] = mapCalleeNode(child, mapPath)
+	}
+	return &c
+}
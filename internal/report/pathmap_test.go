@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"LSPTracer/internal/entrypoints"
+	"LSPTracer/internal/model"
+)
+
+func TestParsePathMapping(t *testing.T) {
+	m, err := ParsePathMapping(" /src = /home/dev/app ")
+	if err != nil || m != (PathMapping{From: "/src", To: "/home/dev/app"}) || m.String() != "/src=/home/dev/app" {
+		t.Errorf("ParsePathMapping = %+v, %v", m, err)
+	}
+	if m, err := ParsePathMapping(`/src=C:\work\app`); err != nil || m.To != `C:\work\app` {
+		t.Errorf("Windows host path: %+v, %v", m, err)
+	}
+	for _, bad := range []string{"", "/src", "/src=", "=/home/dev/app", " = "} {
+		if _, err := ParsePathMapping(bad); err == nil {
+			t.Errorf("ParsePathMapping(%q) accepted", bad)
+		}
+	}
+}
+
+// 前缀按路径分隔符匹配，嵌套的映射中最长的前缀优先 (与顺序无关)
+func TestPathMapNested(t *testing.T) {
+	tests := []struct {
+		path, host string
+	}{
+		{"/src/main/A.java", "/home/dev/app/main/A.java"},
+		{"/src", "/home/dev/app"},
+		{"/src/", "/home/dev/app/"},
+		{"/src/vendor/lib/B.java", "/opt/vendor/lib/B.java"},
+		{"/src/vendor", "/opt/vendor"},
+		{"/src/vendored/C.java", "/home/dev/app/vendored/C.java"}, // /src/vendor 不匹配 /src/vendored
+		{"/srcfoo/D.java", "/srcfoo/D.java"},
+		{"/other/E.java", "/other/E.java"},
+		{"relative/F.java", "relative/F.java"},
+	}
+	maps := []PathMap{
+		{{From: "/src", To: "/home/dev/app"}, {From: "/src/vendor", To: "/opt/vendor"}},
+		{{From: "/src/vendor/", To: "/opt/vendor/"}, {From: "/src/", To: "/home/dev/app/"}},
+	}
+	for i, m := range maps {
+		for _, tt := range tests {
+			if got := m.ToHost(tt.path); got != tt.host {
+				t.Errorf("map %d: ToHost(%s) = %s, want %s", i, tt.path, got, tt.host)
+			}
+			// 逆向映射还原为扫描环境的路径 (末尾的分隔符除外)
+			if got := m.ToScan(tt.host); got != tt.path && got != tt.path+"/" && got+"/" != tt.path {
+				t.Errorf("map %d: ToScan(%s) = %s, want %s", i, tt.host, got, tt.path)
+			}
+		}
+	}
+}
+
+// 容器中的 Linux 路径映射到 Windows 宿主机: 剩余部分换成宿主机的分隔符，逆向映射时换回来
+func TestPathMapWindowsHost(t *testing.T) {
+	m := PathMap{{From: "/src", To: `C:\work\app`}}
+	if got := m.ToHost("/src/main/java/A.java"); got != `C:\work\app\main\java\A.java` {
+		t.Errorf("ToHost = %s", got)
+	}
+	if got := m.ToScan(`C:\work\app\main\java\A.java`); got != "/src/main/java/A.java" {
+		t.Errorf("ToScan = %s", got)
+	}
+	if got := m.ToHost("/srcs/A.java"); got != "/srcs/A.java" {
+		t.Errorf("ToHost(/srcs/A.java) = %s", got)
+	}
+}
+
+// HostView 转换链路、项目根目录、端点和调用树中的路径，ScanView 还原；原来的数据不变
+func TestHostViewRoundTrip(t *testing.T) {
+	chains := [][]model.ChainStep{
+		testChain("/src/app/A.java", 5, "exec(a)"),
+		{{File: "/src/vendor/lib/B.java", Line: 3, Func: "run"}, {File: "/src/app/C.java", Line: 9, Func: "handle"}},
+	}
+	meta := Metadata{
+		ProjectRoot: "/src/app",
+		PathMap:     PathMap{{From: "/src", To: "/home/dev"}, {From: "/src/vendor", To: "/opt/vendor"}},
+		Endpoints:   []entrypoints.Endpoint{{File: "/src/app/C.java", Line: 9}},
+		CallTrees: []*model.CalleeNode{{Func: "handle", File: "/src/app/C.java", Children: []*model.CalleeNode{
+			{Func: "run", File: "/src/vendor/lib/B.java"},
+		}}},
+	}
+	original, _ := json.Marshal(struct {
+		C [][]model.ChainStep
+		M Metadata
+	}{chains, meta})
+
+	host, hostMeta := HostView(chains, meta)
+	if host[0][0].File != "/home/dev/app/A.java" || host[1][0].File != "/opt/vendor/lib/B.java" || host[1][1].File != "/home/dev/app/C.java" {
+		t.Errorf("host chains: %s, %s, %s", host[0][0].File, host[1][0].File, host[1][1].File)
+	}
+	if hostMeta.ProjectRoot != "/home/dev/app" || hostMeta.Endpoints[0].File != "/home/dev/app/C.java" {
+		t.Errorf("host metadata: root %s, endpoint %s", hostMeta.ProjectRoot, hostMeta.Endpoints[0].File)
+	}
+	if tree := hostMeta.CallTrees[0]; tree.File != "/home/dev/app/C.java" || tree.Children[0].File != "/opt/vendor/lib/B.java" {
+		t.Errorf("host call tree: %s, %s", tree.File, tree.Children[0].File)
+	}
+
+	after, _ := json.Marshal(struct {
+		C [][]model.ChainStep
+		M Metadata
+	}{chains, meta})
+	if string(after) != string(original) {
+		t.Error("HostView modified the scan results")
+	}
+
+	scan, scanMeta := ScanView(host, hostMeta)
+	if !reflect.DeepEqual(scan, chains) || scanMeta.ProjectRoot != meta.ProjectRoot || !reflect.DeepEqual(scanMeta.CallTrees, meta.CallTrees) || !reflect.DeepEqual(scanMeta.Endpoints, meta.Endpoints) {
+		t.Error("ScanView does not restore the scan paths")
+	}
+
+	// 没有映射时原样返回
+	if same, sameMeta := HostView(chains, Metadata{ProjectRoot: "/src/app"}); &same[0] != &chains[0] || sameMeta.ProjectRoot != "/src/app" {
+		t.Error("HostView without mappings copied or changed the results")
+	}
+}
+
+// 写出的 JSON 中是宿主机路径并记录映射；LoadJSON 读取时还原为扫描环境的路径
+func TestPathMapJSONRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	meta := Metadata{ProjectName: "demo", ProjectRoot: root, PathMap: PathMap{{From: root, To: "/home/dev/app"}}}
+
+	withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	c, hostMeta, err := NewChains(SliceSource(chains), root, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	GenerateJSON(c, hostMeta.ProjectRoot, hostMeta)
+	matches, _ := filepath.Glob(filepath.Join(OutputDir, "report_*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("want one JSON report, got %v", matches)
+	}
+
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Metadata struct {
+			ProjectRoot string  `json:"project_root"`
+			PathMap     PathMap `json:"path_map"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.Metadata.ProjectRoot != "/home/dev/app" || !reflect.DeepEqual(raw.Metadata.PathMap, meta.PathMap) {
+		t.Errorf("JSON metadata: project_root %s, path_map %v", raw.Metadata.ProjectRoot, raw.Metadata.PathMap)
+	}
+
+	loaded, loadedMeta, err := LoadJSON(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedMeta.ProjectRoot != root || !reflect.DeepEqual(loadedMeta.PathMap, meta.PathMap) {
+		t.Errorf("loaded metadata: root %s, path map %v", loadedMeta.ProjectRoot, loadedMeta.PathMap)
+	}
+	for _, stack := range loaded {
+		for _, step := range stack {
+			if rel, err := filepath.Rel(root, step.File); err != nil || filepath.IsAbs(rel) || rel[0] == '.' {
+				t.Errorf("loaded step %s is not under the scan root %s", step.File, root)
+			}
+		}
+	}
+}
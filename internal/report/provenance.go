@@ -0,0 +1,27 @@
+package report
+
+import "strings"
+
+// Provenance 结果的产生环境: 发现在几周之后有争议时，用它还原扫描时使用的版本、规则和参数
+type Provenance struct {
+	Version      string   `json:"version"`                 // LSPTracer 版本
+	Revision     string   `json:"revision,omitempty"`      // LSPTracer 构建时的 git revision
+	GoVersion    string   `json:"go_version,omitempty"`    // 构建 LSPTracer 的 Go 版本
+	JdtlsVersion string   `json:"jdtls_version,omitempty"` // initialize 返回的 serverInfo，没有时取 plugins 中 jdt.ls.core 的版本
+	JavaRuntime  string   `json:"java_runtime,omitempty"`  // 运行 JDT.LS 的 Java (java -version 的第一行)
+	RulesSHA256  string   `json:"rules_sha256,omitempty"`  // 规则文件的 SHA-256，内置规则为空
+	Args         []string `json:"args"`                    // 命令行参数 (不含程序名)
+	Platform     string   `json:"platform"`                // 主机的 GOOS/GOARCH
+}
+
+// CommandLine 命令行参数的展示形式 (含空格的参数加引号)
+func (p Provenance) CommandLine() string {
+	parts := make([]string, len(p.Args))
+	for i, arg := range p.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
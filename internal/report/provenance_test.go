@@ -0,0 +1,107 @@
+package report
+
+import (
+	"encoding/json"
+	"html"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProvenanceCommandLine(t *testing.T) {
+	p := Provenance{Args: []string{"-project", "/src/my app", "-sources", "", "-report-title", "Bob's scan", "-strict"}}
+	want := `-project '/src/my app' -sources '' -report-title 'Bob'\''s scan' -strict`
+	if got := p.CommandLine(); got != want {
+		t.Errorf("CommandLine() = %s, want %s", got, want)
+	}
+}
+
+// 产生环境写入 JSON、SARIF 和 HTML 报告，LoadJSON 读回 (render 重新生成的报告保留它)
+func TestProvenanceInReports(t *testing.T) {
+	root := t.TempDir()
+	chains := renderFixture(t, root)
+	provenance := &Provenance{
+		Version:      "v1.2.3",
+		Revision:     "0123abcd-dirty",
+		GoVersion:    "go1.24.4",
+		JdtlsVersion: "Eclipse JDT Language Server 1.40.0",
+		JavaRuntime:  `openjdk version "21.0.2" 2024-01-16`,
+		RulesSHA256:  strings.Repeat("ab", 32),
+		Args:         []string{"-project", root, "-report-title", "nightly scan"},
+		Platform:     "linux/amd64",
+	}
+	meta := Metadata{ProjectName: "demo", ProjectRoot: root, Version: "v1.2.3", Provenance: provenance}
+
+	dir := withReportLimits(t, DefaultContextBudget, DefaultPageThreshold)
+	c, meta, err := NewChains(SliceSource(chains), root, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	GenerateJSON(c, root, meta)
+	GenerateSARIF(c, root, meta)
+	GenerateHTML(c, root, meta)
+	read := func(pattern string) []byte {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		if len(matches) != 1 {
+			t.Fatalf("want one %s, got %v", pattern, matches)
+		}
+		data, err := os.ReadFile(matches[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	var jsonReport struct {
+		Metadata struct {
+			Provenance *Provenance `json:"provenance"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(read("report_*.json"), &jsonReport); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(jsonReport.Metadata.Provenance, provenance) {
+		t.Errorf("JSON provenance = %+v", jsonReport.Metadata.Provenance)
+	}
+
+	var sarif struct {
+		Runs []struct {
+			Properties struct {
+				Metadata struct {
+					Provenance *Provenance `json:"provenance"`
+				} `json:"metadata"`
+			} `json:"properties"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(read("report_*.sarif"), &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if len(sarif.Runs) != 1 || !reflect.DeepEqual(sarif.Runs[0].Properties.Metadata.Provenance, provenance) {
+		t.Errorf("SARIF run properties have no provenance: %+v", sarif.Runs)
+	}
+
+	page := html.UnescapeString(string(read("report_*.html")))
+	for _, want := range []string{
+		"v1.2.3 <span class=\"muted\">(0123abcd-dirty)</span>",
+		"<th>JDT.LS</th><td>Eclipse JDT Language Server 1.40.0</td>",
+		`<th>Java</th><td>openjdk version "21.0.2" 2024-01-16</td>`,
+		"<code>" + provenance.RulesSHA256 + "</code>",
+		"<code>lsptracer -project " + root + " -report-title 'nightly scan'</code>",
+		"linux/amd64, go1.24.4",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report does not contain %q", want)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "report_*.json"))
+	_, loaded, err := LoadJSON(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Provenance, provenance) {
+		t.Errorf("loaded provenance = %+v", loaded.Provenance)
+	}
+}
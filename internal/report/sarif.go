@@ -0,0 +1,381 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/lsp"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// SARIF 2.1.0 报告 (供 GitHub Code Scanning 等平台导入)
+// 只包含这里用到的字段
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool                   `json:"tool"`
+	OriginalURIBaseID map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+	Invocations       []sarifInvocation           `json:"invocations"`
+	Results           []sarifResult               `json:"results"`
+	Properties        *sarifRunProps              `json:"properties,omitempty"`
+}
+
+type sarifRunProps struct {
+	Metadata jsonScanInfo `json:"metadata"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool                `json:"executionSuccessful"`
+	StartTimeUTC        string              `json:"startTimeUtc,omitempty"`
+	EndTimeUTC          string              `json:"endTimeUtc,omitempty"`
+	Notifications       []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name,omitempty"`
+	ShortDescription sarifMessage    `json:"shortDescription"`
+	HelpURI          string          `json:"helpUri,omitempty"`
+	Help             *sarifMessage   `json:"help,omitempty"`
+	Properties       *sarifRuleProps `json:"properties,omitempty"`
+}
+
+type sarifRuleProps struct {
+	Tags       []string `json:"tags,omitempty"`
+	References []string `json:"references,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	CodeFlows []sarifCodeFlow `json:"codeFlows,omitempty"`
+
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+	Properties          *sarifResultProps  `json:"properties,omitempty"`
+}
+
+// sarifSuppression 被白名单 (external) 或源码注释 (inSource) 抑制的结果
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifResultProps 结果的附加属性: 验证状态 (未验证的发现记录原因) 和可信度
+type sarifResultProps struct {
+	Verification     string `json:"verification"`
+	UnverifiedReason string `json:"unverifiedReason,omitempty"`
+	Confidence       string `json:"confidence,omitempty"`
+	ConfidenceScore  int    `json:"confidenceScore,omitempty"`
+	// Source 处理器方法的 HTTP 路由，方便按 URL 分组
+	Routes []string `json:"routes,omitempty"`
+	// 链路结束的原因 (REACHED_ENTRY / NO_CALLERS / ...)
+	Termination string `json:"termination,omitempty"`
+	// SSRF 发现中输入能控制的 URL 部分 (FULL / PATH / QUERY)
+	URLControl string `json:"urlControl,omitempty"`
+	// 规则等级和 severity_overrides 中生效的条件 (level 是覆盖后的有效等级)
+	BaseSeverity     string `json:"baseSeverity,omitempty"`
+	SeverityOverride string `json:"severityOverride,omitempty"`
+	// Sink 所在的模块和 -owners 中负责的团队
+	Module string `json:"module,omitempty"`
+	Owner  string `json:"owner,omitempty"`
+	// Sink 位于测试/示例代码中时的类别 (TEST / SAMPLE)，以及链路是否只能从测试代码到达
+	CodeKind string `json:"codeKind,omitempty"`
+	TestOnly bool   `json:"testOnly,omitempty"`
+	// 同一个调用上命中的其它规则 (ruleId 是等级最高的主规则)
+	AlsoMatched []string `json:"alsoMatchedRules,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLoc `json:"physicalLocation"`
+	Message          *sarifMessage    `json:"message,omitempty"`
+}
+
+type sarifPhysicalLoc struct {
+	ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+	Region           sarifRegion      `json:"region"`
+}
+
+type sarifArtifactLoc struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"` // 1-based
+}
+
+type sarifCodeFlow struct {
+	ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Locations []sarifThreadFlowLoc `json:"locations"`
+}
+
+type sarifThreadFlowLoc struct {
+	Location sarifLocation `json:"location"`
+}
+
+// GenerateSARIF 生成 SARIF 报告，每条链路对应一个 result，链路本身作为 codeFlow
+// 规则表在 results 之前输出，因此读取两遍: 第一遍收集规则，第二遍逐条写出 result
+func GenerateSARIF(chains *Chains, projectRoot string, meta Metadata) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "LSPTracer",
+			Version:        meta.Version,
+			InformationURI: "https://github.com/0xr1ngs/LSPTracer",
+			Rules:          []sarifRule{},
+		}},
+		OriginalURIBaseID: map[string]sarifArtifactLoc{
+			"SRCROOT": {URI: strings.TrimSuffix(lsp.ToUri(projectRoot), "/") + "/"},
+		},
+		Properties: &sarifRunProps{Metadata: newScanInfo(meta)},
+	}
+
+	// 扫描中断 / 语言服务器重启 / 编译错误 作为运行通知输出
+	invocation := sarifInvocation{ExecutionSuccessful: meta.ScanError == ""}
+	if !meta.StartedAt.IsZero() {
+		invocation.StartTimeUTC = meta.StartedAt.UTC().Format(time.RFC3339)
+		invocation.EndTimeUTC = meta.StartedAt.Add(meta.Duration).UTC().Format(time.RFC3339)
+	}
+	if meta.ScanError != "" {
+		invocation.Notifications = append(invocation.Notifications, sarifNotification{
+			Level: "error", Message: sarifMessage{Text: "Scan aborted: " + meta.ScanError},
+		})
+	}
+	if meta.ServerRestarts > 0 {
+		invocation.Notifications = append(invocation.Notifications, sarifNotification{
+			Level: "warning", Message: sarifMessage{Text: fmt.Sprintf("The language server was restarted %d time(s); results may be incomplete.", meta.ServerRestarts)},
+		})
+	}
+	if meta.Health != nil && meta.Health.Errors > 0 {
+		invocation.Notifications = append(invocation.Notifications, sarifNotification{
+			Level: "warning", Message: sarifMessage{Text: fmt.Sprintf("%d compile errors across %d of %d files; results may be incomplete.", meta.Health.Errors, meta.Health.FilesWithErrors, meta.Health.FilesReported)},
+		})
+	}
+	run.Invocations = []sarifInvocation{invocation}
+
+	ruleIndex := make(map[string]int)
+	err := chains.Each(func(_ int, stack []model.ChainStep) error {
+		if !sarifReported(stack, meta) {
+			return nil
+		}
+		rule := chainRule(stack)
+		id := sarifRuleID(rule, chainVulnType(stack))
+		if _, ok := ruleIndex[id]; !ok {
+			ruleIndex[id] = len(run.Tool.Driver.Rules)
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSarifRule(id, rule, meta.Locale))
+		}
+		return nil
+	})
+	if err != nil {
+		color.Red("[-] Failed to read findings for the SARIF report: %v", err)
+		return
+	}
+
+	out := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	f, absPath, err := createOutputFile("sarif")
+	if err != nil {
+		color.Red("[-] Failed to create output file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	// results 为 nil，写出时由 encodeStreamed 替换为逐条生成的数组
+	err = encodeStreamed(f, out, "results", "      ", func(emit func(elem interface{}) error) error {
+		return chains.Each(func(_ int, stack []model.ChainStep) error {
+			if !sarifReported(stack, meta) {
+				return nil
+			}
+			return emit(newSarifResult(stack, ruleIndex, projectRoot))
+		})
+	})
+	if err != nil {
+		color.Red("[-] Failed to write SARIF report: %v", err)
+		return
+	}
+	color.Green("[+] SARIF report generated: %s", absPath)
+}
+
+// sarifReported 链路是否写入 SARIF: 被严格模式排除的链路只在 -show-unverified 时以 note 等级输出
+func sarifReported(stack []model.ChainStep, meta Metadata) bool {
+	return len(stack) > 0 && (chainStrictExcluded(stack) == "" || meta.ShowUnverified)
+}
+
+// newSarifResult 链路对应的 result，ruleIndex 是规则 ID 在规则表中的下标
+func newSarifResult(stack []model.ChainStep, ruleIndex map[string]int, projectRoot string) sarifResult {
+	rule := chainRule(stack)
+	id := sarifRuleID(rule, chainVulnType(stack))
+
+	// 未验证的发现按降低后的有效等级输出
+	level := "warning"
+	if sev := chainSeverity(stack); sev != "" {
+		level = sarifLevel(sev)
+	}
+	if chainStrictExcluded(stack) != "" {
+		level = "note"
+	}
+
+	// 链路按 Source -> Sink 的顺序输出
+	var flow []sarifThreadFlowLoc
+	for i := len(stack) - 1; i >= 0; i-- {
+		loc := sarifStepLocation(stack[i], projectRoot)
+		loc.Message = &sarifMessage{Text: stack[i].QualifiedFunc()}
+		flow = append(flow, sarifThreadFlowLoc{Location: loc})
+	}
+
+	sink := stack[0]
+	result := sarifResult{
+		RuleID:    id,
+		RuleIndex: ruleIndex[id],
+		Level:     level,
+		Message:   sarifMessage{Text: sarifResultMessage(rule, stack[len(stack)-1])},
+		Locations: []sarifLocation{sarifStepLocation(sink, projectRoot)},
+		CodeFlows: []sarifCodeFlow{{ThreadFlows: []sarifThreadFlow{{Locations: flow}}}},
+
+		PartialFingerprints: map[string]string{"lsptracerChain/v2": Fingerprint(stack, projectRoot)},
+	}
+	reason := chainUnverified(stack)
+	if excluded := chainStrictExcluded(stack); excluded != "" && reason == "" {
+		reason = "strict mode: " + excluded
+	}
+	confidence := chainConfidence(stack)
+	routes := chainRoutes(stack)
+	termination := chainTermination(stack)
+	urlControl := chainURLControl(stack)
+	override := chainSeverityOverride(stack)
+	module, owner := chainModule(stack), chainOwner(stack)
+	codeKind, testOnly := chainCodeKind(stack), chainTestOnly(stack)
+	var alsoMatched []string
+	for _, rule := range chainAlsoMatched(stack) {
+		alsoMatched = append(alsoMatched, rule.Name)
+	}
+	if reason != "" || confidence != nil || len(routes) > 0 || termination != "" || urlControl != "" || override != nil || module != "" || owner != "" || codeKind != "" || testOnly || len(alsoMatched) > 0 {
+		props := &sarifResultProps{Verification: "verified", UnverifiedReason: reason, Routes: routes, Termination: termination, URLControl: urlControl,
+			Module: module, Owner: owner, CodeKind: codeKind, TestOnly: testOnly, AlsoMatched: alsoMatched}
+		if override != nil {
+			props.BaseSeverity, props.SeverityOverride = chainRule(stack).Severity, override.Label()
+		}
+		if reason != "" {
+			props.Verification = "unverified"
+		}
+		if confidence != nil {
+			props.Confidence, props.ConfidenceScore = confidence.Level(), confidence.Score()
+		}
+		result.Properties = props
+	}
+	if isSuppressed(stack) {
+		s := chainSuppression(stack)
+		kind := "external"
+		if s.Source == model.SuppressedByInline {
+			kind = "inSource"
+		}
+		result.Suppressions = []sarifSuppression{{Kind: kind, Justification: s.Reason}}
+	}
+	return result
+}
+
+// sarifRuleID 规则 ID 使用 "类型/类.方法"，比展示用的规则名更稳定
+func sarifRuleID(rule *model.SinkRule, vulnType string) string {
+	if rule == nil {
+		return vulnType
+	}
+	return rule.VulnType + "/" + rule.Target()
+}
+
+func newSarifRule(id string, rule *model.SinkRule, lang string) sarifRule {
+	if rule == nil {
+		return sarifRule{ID: id, ShortDescription: sarifMessage{Text: id}}
+	}
+
+	r := sarifRule{
+		ID:               id,
+		Name:             rule.Name,
+		ShortDescription: sarifMessage{Text: rule.Name},
+		HelpURI:          rule.CWEURL(),
+		Properties:       &sarifRuleProps{Tags: []string{"security", rule.VulnType}, References: rule.References},
+	}
+	if desc := rule.Description(lang); desc != "" {
+		r.ShortDescription.Text = desc
+	}
+	if rule.CWE != "" {
+		r.Properties.Tags = append(r.Properties.Tags, rule.CWE)
+	}
+	if rule.Remediation != "" {
+		r.Help = &sarifMessage{Text: rule.Remediation}
+	}
+	if r.HelpURI == "" && len(rule.References) > 0 {
+		r.HelpURI = rule.References[0]
+	}
+	return r
+}
+
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "low", "info":
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+func sarifResultMessage(rule *model.SinkRule, source model.ChainStep) string {
+	name := "Sink"
+	if rule != nil {
+		name = rule.Name
+	}
+	return name + " reachable from " + source.QualifiedFunc()
+}
+
+func sarifStepLocation(step model.ChainStep, projectRoot string) sarifLocation {
+	// 项目内的文件使用相对 SRCROOT 的路径，其它文件使用绝对 URI
+	uri := lsp.ToUri(step.File)
+	baseID := ""
+	if rel, err := filepath.Rel(projectRoot, step.File); err == nil && !strings.HasPrefix(rel, "..") {
+		uri = filepath.ToSlash(rel)
+		baseID = "SRCROOT"
+	}
+	return sarifLocation{PhysicalLocation: sarifPhysicalLoc{
+		ArtifactLocation: sarifArtifactLoc{URI: uri, URIBaseID: baseID},
+		Region:           sarifRegion{StartLine: step.Line + 1},
+	}}
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"LSPTracer/internal/model"
+)
+
+// ChainSource 写入报告的链路来源 (analysis.FindingSink 满足该接口)。
+// 扫描结果较多时链路保存在临时文件中，报告只在内存中保留排序键和卡片摘要，需要步骤时再按下标读回
+type ChainSource interface {
+	Len() int
+	// Each 按记录顺序读回链路，fn 返回错误时停止并返回该错误
+	Each(fn func(chain []model.ChainStep) error) error
+	// Chain 按记录下标 (0-based) 读回一条链路
+	Chain(i int) ([]model.ChainStep, error)
+}
+
+// sliceSource 内存中的链路 (render 子命令、配置检查等结果较少的场景)
+type sliceSource [][]model.ChainStep
+
+// SliceSource 把内存中的链路包装为 ChainSource
+func SliceSource(chains [][]model.ChainStep) ChainSource {
+	return sliceSource(chains)
+}
+
+func (s sliceSource) Len() int {
+	return len(s)
+}
+
+func (s sliceSource) Each(fn func(chain []model.ChainStep) error) error {
+	for _, chain := range s {
+		if err := fn(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sliceSource) Chain(i int) ([]model.ChainStep, error) {
+	if i < 0 || i >= len(s) {
+		return nil, fmt.Errorf("finding %d out of range (%d recorded)", i, len(s))
+	}
+	return s[i], nil
+}
+
+// Chains 排成与追踪顺序无关的稳定顺序的链路: Sink 文件、Sink 行号、Source 位置，最后按完整路径区分；路径已按 PathMap 换成宿主机路径。
+// 追踪是并发进行的，结果的追加顺序每次扫描都不同；报告中的编号 (#1, #2 ...) 按排序后的顺序分配，
+// 只用于展示，跨扫描引用同一个发现请使用 Fingerprint
+type Chains struct {
+	src     ChainSource
+	order   []int // 第 id-1 条链路在 src 中的下标
+	mapPath func(string) string
+}
+
+// NewChains 读取一遍 src 计算排序键，返回排序后的链路和宿主机视角的元信息；
+// projectRoot 是宿主机路径。内存中只保留每条链路的排序键
+func NewChains(src ChainSource, projectRoot string, meta Metadata) (*Chains, Metadata, error) {
+	c := &Chains{src: src}
+	if len(meta.PathMap) > 0 {
+		c.mapPath = meta.PathMap.ToHost
+		_, meta = HostView(nil, meta)
+	}
+
+	var keys []string
+	err := src.Each(func(chain []model.ChainStep) error {
+		keys = append(keys, chainSortKey(c.view(chain), projectRoot))
+		return nil
+	})
+	if err != nil {
+		return nil, meta, err
+	}
+	c.order = make([]int, len(keys))
+	for i := range c.order {
+		c.order[i] = i
+	}
+	sort.SliceStable(c.order, func(i, j int) bool { return keys[c.order[i]] < keys[c.order[j]] })
+	return c, meta, nil
+}
+
+// sliceChains 按原有顺序读取内存中的链路 (不排序、不映射路径)
+func sliceChains(chains [][]model.ChainStep) *Chains {
+	c := &Chains{src: SliceSource(chains), order: make([]int, len(chains))}
+	for i := range c.order {
+		c.order[i] = i
+	}
+	return c
+}
+
+// view 宿主机视角的链路 (没有路径映射时原样返回)
+func (c *Chains) view(chain []model.ChainStep) []model.ChainStep {
+	if c.mapPath == nil {
+		return chain
+	}
+	return mapChain(chain, c.mapPath)
+}
+
+// Len 链路数量
+func (c *Chains) Len() int {
+	return len(c.order)
+}
+
+// At 读回编号为 id 的链路
+func (c *Chains) At(id int) ([]model.ChainStep, error) {
+	if id < 1 || id > len(c.order) {
+		return nil, fmt.Errorf("chain #%d out of range (%d chains)", id, len(c.order))
+	}
+	chain, err := c.src.Chain(c.order[id-1])
+	if err != nil {
+		return nil, err
+	}
+	return c.view(chain), nil
+}
+
+// Each 按编号顺序逐条读回链路，同一时刻只有一条链路在内存中
+func (c *Chains) Each(fn func(id int, chain []model.ChainStep) error) error {
+	for id := 1; id <= len(c.order); id++ {
+		chain, err := c.At(id)
+		if err != nil {
+			return err
+		}
+		if err := fn(id, chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summarize 汇总所有链路 (同 Summarize)
+func (c *Chains) Summarize(projectRoot string) (Summary, error) {
+	s := newSummarizer(projectRoot)
+	err := c.src.Each(func(chain []model.ChainStep) error {
+		s.add(c.view(chain))
+		return nil
+	})
+	return s.summary(), err
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"LSPTracer/internal/model"
+)
+
+// testChain Sink 在 file 第 line 行、Source 在同一文件开头的两步链路
+func testChain(file string, line int, code string) []model.ChainStep {
+	rule := &model.SinkRule{Name: "Runtime.exec", VulnType: "RCE", Severity: "critical"}
+	return []model.ChainStep{
+		{File: file, Line: line, Func: "run", Code: code, Rule: rule},
+		{File: file, Line: 1, Func: "handle", Code: "String cmd = request.getParameter(\"cmd\");"},
+	}
+}
+
+func TestNewChainsSortsAndMapsPaths(t *testing.T) {
+	src := [][]model.ChainStep{
+		testChain("/scan/src/B.java", 5, "exec(b)"),
+		testChain("/scan/src/A.java", 30, "exec(a30)"),
+		testChain("/scan/src/A.java", 7, "exec(a7)"),
+	}
+	meta := Metadata{ProjectRoot: "/scan", PathMap: PathMap{{From: "/scan", To: "/host"}}}
+	chains, hostMeta, err := NewChains(SliceSource(src), "/host", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostMeta.ProjectRoot != "/host" {
+		t.Errorf("ProjectRoot = %q, want /host", hostMeta.ProjectRoot)
+	}
+
+	var got []string
+	chains.Each(func(id int, stack []model.ChainStep) error {
+		if stack[0].File[:5] != "/host" || stack[1].File[:5] != "/host" {
+			t.Errorf("#%d: steps not mapped to host paths: %s, %s", id, stack[0].File, stack[1].File)
+		}
+		got = append(got, stack[0].Code)
+		return nil
+	})
+	want := []string{"exec(a7)", "exec(a30)", "exec(b)"}
+	if len(got) != len(want) {
+		t.Fatalf("Each returned %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("#%d = %s, want %s", i+1, got[i], want[i])
+		}
+	}
+
+	// 编号与 Each 一致，来源中的链路保持扫描环境的路径
+	if stack, err := chains.At(3); err != nil || stack[0].Code != "exec(b)" {
+		t.Errorf("At(3) = %v, %v; want exec(b)", stack, err)
+	}
+	if _, err := chains.At(4); err == nil {
+		t.Error("At(4) with 3 chains: want an out of range error")
+	}
+	if src[0][0].File != "/scan/src/B.java" {
+		t.Errorf("source chain modified: %s", src[0][0].File)
+	}
+}
+
+// 追加顺序不同的同一组结果得到同样的编号
+func TestNewChainsStableAcrossInputOrder(t *testing.T) {
+	a := [][]model.ChainStep{testChain("/p/A.java", 3, "x"), testChain("/p/A.java", 1, "y"), testChain("/p/B.java", 2, "z")}
+	b := [][]model.ChainStep{a[2], a[0], a[1]}
+	ca, _, _ := NewChains(SliceSource(a), "/p", Metadata{})
+	cb, _, _ := NewChains(SliceSource(b), "/p", Metadata{})
+	for id := 1; id <= 3; id++ {
+		x, _ := ca.At(id)
+		y, _ := cb.At(id)
+		if x[0].Code != y[0].Code {
+			t.Errorf("#%d: %s vs %s", id, x[0].Code, y[0].Code)
+		}
+	}
+}
+
+func TestEncodeStreamedMatchesEncoder(t *testing.T) {
+	type item struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	type doc struct {
+		Before string `json:"before"`
+		Items  []item `json:"items"`
+		After  []int  `json:"after"`
+	}
+	for _, items := range [][]item{
+		{},
+		{{Name: "one", Tags: []string{"a"}}},
+		{{Name: "<script>&", Tags: nil}, {Name: "two", Tags: []string{"x", "y"}}, {Name: "three"}},
+	} {
+		var want bytes.Buffer
+		enc := json.NewEncoder(&want)
+		enc.SetIndent("", "  ")
+		enc.Encode(doc{Before: "b", Items: items, After: []int{1, 2}})
+
+		var got bytes.Buffer
+		err := encodeStreamed(&got, doc{Before: "b", After: []int{1, 2}}, "items", "  ", func(emit func(elem interface{}) error) error {
+			for _, it := range items {
+				if err := emit(it); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%d items:\ngot:\n%s\nwant:\n%s", len(items), got.String(), want.String())
+		}
+	}
+}
+
+func TestEncodeStreamedMissingField(t *testing.T) {
+	var buf bytes.Buffer
+	err := encodeStreamed(&buf, map[string]int{"x": 1}, "items", "  ", func(func(interface{}) error) error { return nil })
+	if err == nil {
+		t.Error("want an error when the streamed field is not in the encoded value")
+	}
+}
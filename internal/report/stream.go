@@ -0,0 +1,286 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"text/template/parse"
+	"time"
+
+	"LSPTracer/internal/i18n"
+	"LSPTracer/internal/model"
+
+	"github.com/fatih/color"
+)
+
+// 大结果集的默认限制
+const (
+	DefaultContextBudget = 64 << 20 // 单个 HTML 报告中完整上下文的总字节数
+	DefaultPageThreshold = 1000     // 超过该数量的发现时拆分为索引页和分页
+)
+
+// ContextBudget 单个 HTML 报告中 "View Full Context" 代码块的总字节上限，超过后只保留摘要行；0 表示不限制
+var ContextBudget = DefaultContextBudget
+
+// PageThreshold 发现数量 (含被抑制和被严格模式排除的) 超过该值时，HTML 报告拆分为索引页和按类型的分页；0 表示不拆分
+var PageThreshold = DefaultPageThreshold
+
+// contextBudget 记录一个报告已经生成的完整上下文大小；nil 表示不限制
+type contextBudget struct {
+	remaining int
+	omitted   int // 因预算用完而省略上下文的步骤数
+}
+
+func newContextBudget(limit int) *contextBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &contextBudget{remaining: limit}
+}
+
+// context 生成步骤的完整上下文；预算用完后 (包括本次生成的代码块超出剩余预算时) 返回省略说明
+func (b *contextBudget) context(step model.ChainStep, displayPath string) template.HTML {
+	if b == nil {
+		return template.HTML(getSmartCodeContext(step))
+	}
+	if b.remaining > 0 {
+		code := getSmartCodeContext(step)
+		if len(code) <= b.remaining {
+			b.remaining -= len(code)
+			return template.HTML(code)
+		}
+		b.remaining = 0
+	}
+	b.omitted++
+	return template.HTML(fmt.Sprintf(`<div class="context-omitted">Context omitted (report size budget reached), see source: %s:%d</div>`,
+		template.HTMLEscapeString(displayPath), step.Line+1))
+}
+
+// warn 提示有步骤因预算用完而省略了上下文
+func (b *contextBudget) warn() {
+	if b == nil || b.omitted == 0 {
+		return
+	}
+	color.Yellow("[!] Full context omitted for %d steps: the report reached its context budget of %d MB (-html-context-budget)", b.omitted, ContextBudget>>20)
+}
+
+// reportTemplate 解析报告模板 (内置模板，以及在其之后解析的 -template 自定义模板)；
+// 卡片由 card 函数逐个渲染，步骤和完整上下文在渲染时才生成，写出后即可释放。
+// 界面字符串由 tr 按 lang (为空时为英文) 翻译，vulnName / ruleDesc 是漏洞类型名称和规则描述
+func reportTemplate(budget *contextBudget, lang string) (*template.Template, error) {
+	if lang == "" {
+		lang = i18n.English
+	}
+	var t *template.Template
+	t = template.New("report").Funcs(template.FuncMap{
+		"lang": func() string { return lang },
+		"tr": func(key string, args ...interface{}) string {
+			if len(args) == 0 {
+				return i18n.T(lang, key)
+			}
+			return i18n.Tf(lang, key, args...)
+		},
+		"vulnName": func(vulnType string) string { return i18n.VulnTypeName(lang, vulnType) },
+		"ruleDesc": func(rule *model.SinkRule) string {
+			if rule == nil {
+				return ""
+			}
+			return rule.Description(lang)
+		},
+		"card": func(v Vulnerability) (template.HTML, error) {
+			if v.Steps == nil && v.chains != nil {
+				stack, err := v.chains.At(v.chainID)
+				if err != nil {
+					return "", err
+				}
+				v.Steps = buildSteps(stack, v.root, budget)
+			}
+			var buf bytes.Buffer
+			if err := t.ExecuteTemplate(&buf, "vuln-card", v); err != nil {
+				return "", err
+			}
+			return template.HTML(buf.String()), nil
+		},
+	})
+	if _, err := t.Parse(htmlTemplateStr); err != nil {
+		return nil, err
+	}
+	if customTemplate.text == "" {
+		return t, nil
+	}
+	custom, err := t.New(customTemplate.name).Parse(customTemplate.text)
+	if err != nil {
+		return nil, err
+	}
+	// 只包含 {{define}} 的自定义模板覆盖子模板，页面仍然从内置模板开始渲染
+	if custom.Tree == nil || parse.IsEmptyTree(custom.Tree.Root) {
+		return t, nil
+	}
+	return custom, nil
+}
+
+// executeHTML 把报告流式写入 w (经过缓冲，卡片渲染一个写出一个)
+func executeHTML(t *template.Template, w io.Writer, data ReportData) error {
+	data.Title, data.Logo = Title, Logo
+	bw := bufio.NewWriter(w)
+	if err := t.Execute(bw, data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writePagedHTML 把报告拆分为索引页 (概览、端点、调用树和指向分页的侧边栏) 和每个侧边栏分组一个分页
+func writePagedHTML(data ReportData) {
+	budget := newContextBudget(ContextBudget)
+	t, err := reportTemplate(budget, data.Meta.Locale)
+	if err != nil {
+		color.Red("[-] Failed to generate report template: %v", err)
+		return
+	}
+
+	base := fmt.Sprintf("report_%d", time.Now().Unix())
+	indexPage := base + ".html"
+
+	// 卡片所在的区域: 发现、被抑制、被严格模式排除
+	const (
+		sectionVulns = iota
+		sectionSuppressed
+		sectionExcluded
+	)
+	byID := make(map[int]Vulnerability)
+	section := make(map[int]int)
+	for i, list := range [][]Vulnerability{data.Vulns, data.Suppressed, data.StrictExcluded} {
+		for _, v := range list {
+			byID[v.ID] = v
+			section[v.ID] = i
+		}
+	}
+
+	// 侧边栏的链接指向分组对应的分页
+	used := make(map[string]bool)
+	pages := make([]string, len(data.NavGroups))
+	for i := range data.NavGroups {
+		g := &data.NavGroups[i]
+		pages[i] = pageFileName(base, g.Name, used)
+		items := make([]NavItem, len(g.Items))
+		for j, item := range g.Items {
+			item.Page = pages[i]
+			items[j] = item
+		}
+		g.Items = items
+	}
+
+	index := data
+	index.Vulns, index.Suppressed, index.StrictExcluded = nil, nil, nil
+	absIndex, ok := writeHTMLPage(t, indexPage, index)
+	if !ok {
+		return
+	}
+	for i, g := range data.NavGroups {
+		page := ReportData{
+			GeneratedAt: data.GeneratedAt,
+			TotalChains: data.TotalChains,
+			NavGroups:   data.NavGroups,
+			Meta:        data.Meta,
+			PageTitle:   fmt.Sprintf("%s (%d)", g.Label, g.Count),
+			IndexPage:   indexPage,
+		}
+		for _, item := range g.Items {
+			v := byID[item.ID]
+			switch section[item.ID] {
+			case sectionSuppressed:
+				page.Suppressed = append(page.Suppressed, v)
+			case sectionExcluded:
+				page.StrictExcluded = append(page.StrictExcluded, v)
+			default:
+				page.Vulns = append(page.Vulns, v)
+			}
+		}
+		if _, ok := writeHTMLPage(t, pages[i], page); !ok {
+			return
+		}
+	}
+
+	color.Green("[+] Report generated successfully: %s (%d pages by type)", absIndex, len(pages))
+	budget.warn()
+}
+
+// writeHTMLPage 渲染一个页面并返回其绝对路径，失败时输出错误并返回 false
+func writeHTMLPage(t *template.Template, fileName string, data ReportData) (string, bool) {
+	f, absPath, err := createNamedOutputFile(fileName)
+	if err != nil {
+		color.Red("[-] Failed to create output file: %v", err)
+		return "", false
+	}
+	defer f.Close()
+	if err := executeHTML(t, f, data); err != nil {
+		color.Red("[-] Failed to write report data: %v", err)
+		return "", false
+	}
+	return absPath, true
+}
+
+var pageNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// pageFileName 分组对应的分页文件名: report_<时间戳>_<分组名>.html，重名时追加序号
+func pageFileName(base, group string, used map[string]bool) string {
+	slug := strings.Trim(pageNameRe.ReplaceAllString(strings.ToLower(group), "-"), "-")
+	if slug == "" {
+		slug = "group"
+	}
+	name := base + "_" + slug
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s_%s-%d", base, slug, i)
+	}
+	used[name] = true
+	return name + ".html"
+}
+
+// encodeStreamed 按两格缩进把 v 写入 w，其中缩进为 indent 的字段 key (在 v 中为 nil) 替换为 each 逐个生成的数组元素，
+// 输出与整个数组一次编码时相同，但同一时刻只有一个元素在内存中
+func encodeStreamed(w io.Writer, v interface{}, key, indent string, each func(emit func(elem interface{}) error) error) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	marker := []byte("\n" + indent + `"` + key + `": null`)
+	at := bytes.Index(buf.Bytes(), marker)
+	if at < 0 {
+		return fmt.Errorf("field %q not found in the encoded report", key)
+	}
+	head, tail := buf.Bytes()[:at+len(marker)-len("null")], buf.Bytes()[at+len(marker):]
+
+	bw := bufio.NewWriter(w)
+	bw.Write(head)
+	bw.WriteString("[")
+	n := 0
+	err := each(func(elem interface{}) error {
+		data, err := json.MarshalIndent(elem, indent+"  ", "  ")
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n" + indent + "  ")
+		n++
+		_, err = bw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		bw.WriteString("\n" + indent)
+	}
+	bw.WriteString("]")
+	bw.Write(tail)
+	return bw.Flush()
+}
@@ -0,0 +1,263 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"LSPTracer/internal/model"
+)
+
+// writeLargeSource 写入一个有 methods 个方法、每个方法 20 行的 Java 文件，返回文件路径
+func writeLargeSource(t testing.TB, dir string, n, methods int) string {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "package com.example;\n\npublic class Service%d {\n", n)
+	for m := 0; m < methods; m++ {
+		fmt.Fprintf(&b, "    public String method%d(String input) {\n", m)
+		for l := 0; l < 18; l++ {
+			fmt.Fprintf(&b, "        String value%d = helper.transform(input, \"%d-%d\"); // padding to make the context large\n", l, m, l)
+		}
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	path := filepath.Join(dir, fmt.Sprintf("Service%d.java", n))
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// largeResults n 条四步链路，分布在 files 个大文件中，一半是 RCE、一半是 SQLI；每一步都需要读取并高亮所在方法的完整上下文
+func largeResults(t testing.TB, dir string, n, files int) [][]model.ChainStep {
+	t.Helper()
+	const methods = 100
+	paths := make([]string, files)
+	for i := range paths {
+		paths[i] = writeLargeSource(t, dir, i, methods)
+	}
+	rules := []*model.SinkRule{
+		{Name: "Runtime.exec", VulnType: "RCE", Severity: "critical"},
+		{Name: "Statement.executeQuery", VulnType: "SQLI", Severity: "high"},
+	}
+	chains := make([][]model.ChainStep, n)
+	for i := range chains {
+		chain := make([]model.ChainStep, 4)
+		for j := range chain {
+			m := (i + j*7) % methods
+			chain[j] = model.ChainStep{
+				File: paths[(i+j)%files],
+				Line: 3 + m*20 + 1 + j,
+				Func: fmt.Sprintf("method%d(String)", m),
+				Code: fmt.Sprintf("String value%d = helper.transform(input, \"%d-%d\");", j, m, j),
+			}
+		}
+		chain[0].Rule = rules[i%len(rules)]
+		chains[i] = chain
+	}
+	return chains
+}
+
+// withReportLimits 在测试期间设置 ContextBudget、PageThreshold 和输出目录
+func withReportLimits(t testing.TB, budget, threshold int) string {
+	t.Helper()
+	savedBudget, savedThreshold, savedDir := ContextBudget, PageThreshold, OutputDir
+	ContextBudget, PageThreshold, OutputDir = budget, threshold, t.TempDir()
+	t.Cleanup(func() { ContextBudget, PageThreshold, OutputDir = savedBudget, savedThreshold, savedDir })
+	return OutputDir
+}
+
+// generateTestHTML 生成 HTML 报告，返回输出目录中的文件 (文件名 -> 内容)
+func generateTestHTML(t testing.TB, chains [][]model.ChainStep, root string) map[string]string {
+	t.Helper()
+	c, meta, err := NewChains(SliceSource(chains), root, Metadata{ProjectRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	GenerateHTML(c, root, meta)
+	entries, _ := os.ReadDir(OutputDir)
+	pages := make(map[string]string)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(OutputDir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[e.Name()] = string(data)
+	}
+	return pages
+}
+
+var (
+	cardRe  = regexp.MustCompile(`id="vuln-(\d+)" class="vuln-card`)
+	indexRe = regexp.MustCompile(`^report_\d+\.html$`)
+)
+
+func TestContextBudget(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLargeSource(t, dir, 0, 3)
+	step := model.ChainStep{File: path, Line: 5, Func: "method0(String)"}
+	full := getSmartCodeContext(step)
+
+	// 预算够一个代码块: 第一次完整输出，之后只有省略说明
+	budget := newContextBudget(len(full) + len(full)/2)
+	if got := budget.context(step, "Service0.java"); string(got) != full {
+		t.Error("first block within the budget should be complete")
+	}
+	for i := 0; i < 2; i++ {
+		got := string(budget.context(step, "Service0.java"))
+		if !strings.Contains(got, "Context omitted") || !strings.Contains(got, "Service0.java:6") {
+			t.Errorf("block %d after the budget: %q", i+2, got)
+		}
+	}
+	if budget.omitted != 2 {
+		t.Errorf("omitted = %d, want 2", budget.omitted)
+	}
+
+	// 0 表示不限制
+	if unlimited := newContextBudget(0); unlimited != nil || string(unlimited.context(step, "x")) != full {
+		t.Error("a zero budget should not limit the context")
+	}
+}
+
+func TestGenerateHTMLSinglePage(t *testing.T) {
+	withReportLimits(t, 0, 0)
+	dir := t.TempDir()
+	pages := generateTestHTML(t, largeResults(t, dir, 6, 2), dir)
+	if len(pages) != 1 {
+		t.Fatalf("wrote %d files, want a single report", len(pages))
+	}
+	for _, html := range pages {
+		if n := len(cardRe.FindAllString(html, -1)); n != 6 {
+			t.Errorf("report has %d cards, want 6", n)
+		}
+	}
+}
+
+func TestGenerateHTMLPaged(t *testing.T) {
+	withReportLimits(t, DefaultContextBudget, 5)
+	dir := t.TempDir()
+	pages := generateTestHTML(t, largeResults(t, dir, 12, 3), dir)
+
+	// 索引页 + RCE 和 SQLI 各一个分页
+	if len(pages) != 3 {
+		t.Fatalf("wrote %v, want an index and two type pages", keys(pages))
+	}
+	seen := make(map[string]bool)
+	for name, html := range pages {
+		cards := cardRe.FindAllStringSubmatch(html, -1)
+		switch {
+		case indexRe.MatchString(name):
+			if len(cards) != 0 {
+				t.Errorf("index %s has %d cards, want none", name, len(cards))
+			}
+		case len(cards) != 6:
+			t.Errorf("page %s has %d cards, want 6", name, len(cards))
+		}
+		for _, c := range cards {
+			if seen[c[1]] {
+				t.Errorf("card #%s appears on more than one page", c[1])
+			}
+			seen[c[1]] = true
+		}
+	}
+	if len(seen) != 12 {
+		t.Errorf("%d distinct cards across pages, want 12", len(seen))
+	}
+}
+
+func TestPageFileName(t *testing.T) {
+	used := make(map[string]bool)
+	for _, tc := range []struct{ group, want string }{
+		{"RCE", "report_1_rce.html"},
+		{"Path Traversal", "report_1_path-traversal.html"},
+		{"path/traversal", "report_1_path-traversal-2.html"},
+		{"漏洞", "report_1_group.html"},
+	} {
+		if got := pageFileName("report_1", tc.group, used); got != tc.want {
+			t.Errorf("pageFileName(%q) = %s, want %s", tc.group, got, tc.want)
+		}
+	}
+}
+
+func keys(m map[string]string) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// BenchmarkGenerateHTML 大结果集的 HTML 报告: 不限制上下文也不分页，和默认的上下文预算 + 分页。
+// peak-MB 是生成期间堆内存的峰值 (相对开始时)，out-MB 是输出的总大小 (最大的单个文件见 page-MB)
+func BenchmarkGenerateHTML(b *testing.B) {
+	dir := b.TempDir()
+	chains := largeResults(b, dir, 800, 40)
+	for _, bc := range []struct {
+		name              string
+		budget, threshold int
+	}{
+		{"unbounded", 0, 0},
+		{"bounded", 4 << 20, 200},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var peak uint64
+			var total, largest int64
+			for i := 0; i < b.N; i++ {
+				out := withReportLimits(b, bc.budget, bc.threshold)
+				c, meta, err := NewChains(SliceSource(chains), dir, Metadata{ProjectRoot: dir})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if n := heapPeak(func() { GenerateHTML(c, dir, meta) }); n > peak {
+					peak = n
+				}
+				total, largest = 0, 0
+				entries, _ := os.ReadDir(out)
+				for _, e := range entries {
+					info, _ := e.Info()
+					total += info.Size()
+					largest = max(largest, info.Size())
+				}
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-MB")
+			b.ReportMetric(float64(total)/(1<<20), "out-MB")
+			b.ReportMetric(float64(largest)/(1<<20), "page-MB")
+		})
+	}
+}
+
+// heapPeak fn 执行期间堆内存的峰值 (相对开始时)，每毫秒采样一次
+func heapPeak(fn func()) uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	base := m.HeapAlloc
+	peak := make(chan uint64)
+	done := make(chan struct{})
+	go func() {
+		var max uint64
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				peak <- max
+				return
+			case <-ticker.C:
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				if m.HeapAlloc > base && m.HeapAlloc-base > max {
+					max = m.HeapAlloc - base
+				}
+			}
+		}
+	}()
+	fn()
+	close(done)
+	return <-peak
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"LSPTracer/internal/model"
+)
+
+// Phase 扫描的一个阶段及其耗时
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// ScanStats 扫描过程的统计，由扫描流程填写 (render 从 JSON 还原)
+type ScanStats struct {
+	Candidates   int      // 文本初筛得到的候选点
+	Verified     int      // 通过 LSP 验证 (或组合规则确认) 的 Sink
+	Traced       int      // 至少有一条链路被记录的 Sink
+	ZeroHitRules []string // 没有匹配到任何候选点的规则
+	Phases       []Phase  // 各阶段耗时 (不含生成报告本身)
+	FanOut       []Count  // 调用者数量超过 -max-callers 的方法 (按调用者数量降序)
+
+	WarmedFiles int           // 验证前预先打开的文件数量 (-warmup)
+	VerifyAvg   time.Duration // 每个候选点的平均 LSP 验证耗时，与 -warmup 0 的扫描对比可以看出预热的效果
+	VerifiedBy  []Count       // 每种确认方式 (hover / definition / import ...) 确认的 Sink 数量 (按数量降序)
+}
+
+// Count 分组计数
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Summary 按漏洞类型、有效等级和文件汇总的结果数量
+type Summary struct {
+	ByType     []Count // 按数量降序
+	BySeverity []Count // 按等级从高到低
+	TopFiles   []Count // 发现最多的 Sink 文件 (最多 summaryTopFiles 个)
+	Suppressed int     // 被白名单或行内注释抑制的发现 (不计入上面的分组)
+	Excluded   int     // 被严格模式排除的链路 (不计入上面的分组)
+	// 按结束原因 (REACHED_ENTRY / NO_CALLERS / ...) 统计所有链路 (包括被抑制和被严格模式排除的)，按数量降序
+	// 大部分片段停在 NO_CALLERS 时通常说明索引不完整
+	ByTermination []Count
+	// 按 Sink 所在模块的统计 (按数量降序)，没有记录模块的发现不计入
+	ByModule []ModuleCount
+}
+
+// ModuleCount 一个模块中的发现数量、按有效等级的分布和负责的团队
+type ModuleCount struct {
+	Name       string
+	Count      int
+	BySeverity []Count  // 按等级从高到低
+	Owners     []string // -owners 匹配到的团队 (按名称排序)
+}
+
+const summaryTopFiles = 5
+
+// Summarize 汇总链路: 漏洞类型、有效等级 (没有规则等级的记为 "unrated") 和 Sink 所在文件
+// 被抑制和被严格模式排除的链路只计数 (结束原因的统计包括它们)
+func Summarize(chains [][]model.ChainStep, projectRoot string) Summary {
+	s := newSummarizer(projectRoot)
+	for _, stack := range chains {
+		s.add(stack)
+	}
+	return s.summary()
+}
+
+// SummarizeSource 同 Summarize，逐条读取 src 中的链路
+func SummarizeSource(src ChainSource, projectRoot string) (Summary, error) {
+	s := newSummarizer(projectRoot)
+	err := src.Each(func(chain []model.ChainStep) error {
+		s.add(chain)
+		return nil
+	})
+	return s.summary(), err
+}
+
+// summarizer 逐条累加链路的计数 (Summarize 和按批读回的 Chains 共用)
+type summarizer struct {
+	projectRoot  string
+	types        map[string]int
+	severities   map[string]int
+	files        map[string]int
+	terminations map[string]int
+	modules      map[string]*moduleTally
+	suppressed   int
+	excluded     int
+}
+
+func newSummarizer(projectRoot string) *summarizer {
+	return &summarizer{
+		projectRoot:  projectRoot,
+		types:        make(map[string]int),
+		severities:   make(map[string]int),
+		files:        make(map[string]int),
+		terminations: make(map[string]int),
+		modules:      make(map[string]*moduleTally),
+	}
+}
+
+func (s *summarizer) add(stack []model.ChainStep) {
+	if len(stack) == 0 {
+		return
+	}
+	if reason := chainTermination(stack); reason != "" {
+		s.terminations[reason]++
+	}
+	if chainStrictExcluded(stack) != "" {
+		s.excluded++
+		return
+	}
+	if isSuppressed(stack) {
+		s.suppressed++
+		return
+	}
+	s.types[chainVulnType(stack)]++
+
+	severity := strings.ToLower(chainSeverity(stack))
+	if model.SeverityRank(severity) < 0 {
+		severity = "unrated"
+	}
+	s.severities[severity]++
+
+	path := stack[0].File
+	if rel, err := filepath.Rel(s.projectRoot, path); err == nil {
+		path = rel
+	}
+	s.files[filepath.ToSlash(path)]++
+
+	if module := chainModule(stack); module != "" {
+		m := s.modules[module]
+		if m == nil {
+			m = &moduleTally{severities: make(map[string]int), owners: make(map[string]bool)}
+			s.modules[module] = m
+		}
+		m.count++
+		m.severities[severity]++
+		if owner := chainOwner(stack); owner != "" {
+			m.owners[owner] = true
+		}
+	}
+}
+
+func (s *summarizer) summary() Summary {
+	out := Summary{ByType: SortedCounts(s.types), TopFiles: SortedCounts(s.files), Suppressed: s.suppressed, Excluded: s.excluded,
+		ByTermination: SortedCounts(s.terminations)}
+	if len(out.TopFiles) > summaryTopFiles {
+		out.TopFiles = out.TopFiles[:summaryTopFiles]
+	}
+	out.BySeverity = bySeverity(s.severities)
+	out.ByModule = byModule(s.modules)
+	return out
+}
+
+type moduleTally struct {
+	count      int
+	severities map[string]int
+	owners     map[string]bool
+}
+
+// bySeverity 按等级从高到低排列
+func bySeverity(severities map[string]int) []Count {
+	counts := make([]Count, 0, len(severities))
+	for name, n := range severities {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return model.SeverityRank(counts[i].Name) > model.SeverityRank(counts[j].Name)
+	})
+	return counts
+}
+
+// byModule 按发现数量降序、模块名升序排列
+func byModule(modules map[string]*moduleTally) []ModuleCount {
+	counts := make([]ModuleCount, 0, len(modules))
+	for name, m := range modules {
+		mc := ModuleCount{Name: name, Count: m.count, BySeverity: bySeverity(m.severities)}
+		for owner := range m.owners {
+			mc.Owners = append(mc.Owners, owner)
+		}
+		sort.Strings(mc.Owners)
+		counts = append(counts, mc)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// SortedCounts 按数量降序、名称升序排列
+func SortedCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
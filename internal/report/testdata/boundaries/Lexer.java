@@ -0,0 +1,42 @@
+package com.example;
+
+public class Lexer {
+
+    // 字符字面量中的花括号
+    public boolean opens(char c) {
+        if (c == '{') {
+            return true;
+        }
+        return c == '\'' || c == '}';
+    }
+
+    /*
+     * 跨行的块注释 } } }
+     * public void fake() {
+     */
+    @Deprecated
+    public String block(String s) {
+        /* { */ String t = s; /* } } */
+        return t;
+    }
+
+    // 文本块中的花括号和引号
+    public String template(String name) {
+        String json = """
+            { "name": "%s", "nested": { "x": "}" } }
+            \""" still inside {
+            """;
+        return json.formatted(name);
+    }
+
+    // 字符串和行注释中的花括号
+    public String strings(String s) {
+        String a = "}{ // not a comment";
+        String b = "escaped \" } quote"; // }
+        return a + b + s;
+    }
+
+    public void after() {
+        System.out.println("done");
+    }
+}
@@ -0,0 +1,12 @@
+    @GetMapping(value = "/items", produces = "application/json")
+    public Map<String, List<Integer>> items(@RequestParam int limit) throws IOException {
+        /* 块注释跨越多行: return new
+           if (a < b && c > d) */
+        String url = "http://example.com/a?b=1&c=2"; // 字符串中的 //
+        char sep = '<', quote = '\'';
+        long max = 0x1F + 1_000L + 1.5e3 + 2.0f;
+        String body = """
+            <item id="1"/> if return
+            """;
+        return client.fetch(url, max) != null ? parse(body) : Collections.emptyMap();
+    }
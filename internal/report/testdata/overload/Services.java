@@ -0,0 +1,25 @@
+package com.example;
+
+public class Services {
+
+    static class OrderService {
+        public void save(Order order) {
+            repo.persist(order);
+        }
+
+        public void save(Order order,
+                         boolean flush) {
+            repo.persist(order);
+            if (flush) {
+                repo.flush();
+            }
+        }
+    }
+
+    static class FileService
+    {
+        public void save(String path) {
+            Runtime.getRuntime().exec("touch " + path);
+        }
+    }
+}
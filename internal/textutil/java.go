@@ -0,0 +1,87 @@
+package textutil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 以下是按名称匹配 Java 方法时使用的文本启发式 (没有 LSP 位置信息时的兜底)
+
+var classDeclRe = regexp.MustCompile(`\b(?:class|interface|enum|record)\s+(\w+)`)
+
+// ParamCount 返回签名中第一对括号内的参数个数 ("save(Order)" -> 1, "run()" -> 0)
+// 泛型和数组参数中的逗号不计入 (Map<String, Object>)；括号没有闭合时统计到字符串末尾；没有括号时返回 -1
+func ParamCount(sig string) int {
+	open := strings.Index(sig, "(")
+	if open == -1 {
+		return -1
+	}
+	count, depth := 0, 0
+	empty := true
+scan:
+	for _, c := range sig[open+1:] {
+		switch c {
+		case '(', '<', '[':
+			depth++
+		case '>', ']':
+			depth--
+		case ')':
+			if depth == 0 {
+				break scan
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				count++
+			}
+		}
+		if c != ' ' && c != '\t' {
+			empty = false
+		}
+	}
+	if empty {
+		return 0
+	}
+	return count + 1
+}
+
+// SimpleClassName 把展示用的类名 ("com.acme.Outer.Inner", "Outer$1") 转为源码中声明的简单类名
+// 匿名类归属于外层的命名类
+func SimpleClassName(name string) string {
+	if i := strings.Index(name, "$"); i != -1 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// EnclosingClassName 按花括号层级向上查找包含 idx 行的类声明，返回类名 (找不到时为空)
+// idx 行本身 (例如方法声明的 "{") 不参与计数
+func EnclosingClassName(lines []string, idx int) string {
+	depth := 0
+	for i := idx - 1; i >= 0 && i < len(lines); i-- {
+		line := lines[i]
+		for j := len(line) - 1; j >= 0; j-- {
+			switch line[j] {
+			case '}':
+				depth++
+			case '{':
+				depth--
+			}
+		}
+		if depth < 0 {
+			// 包围 idx 的代码块从这一行开始: 类声明可能写在上一行 (花括号换行风格)
+			decl := line
+			if i > 0 && strings.TrimSpace(line) == "{" {
+				decl = lines[i-1] + " " + line
+			}
+			if m := classDeclRe.FindStringSubmatch(decl); m != nil {
+				return m[1]
+			}
+			depth = 0
+		}
+	}
+	return ""
+}
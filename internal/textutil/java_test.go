@@ -0,0 +1,55 @@
+package textutil
+
+import "testing"
+
+func TestParamCount(t *testing.T) {
+	for sig, want := range map[string]int{
+		"save(Order)":                         1,
+		"run()":                               0,
+		"run( )":                              0,
+		"save(Order, boolean)":                2,
+		"put(Map<String, Object> m, int[] a)": 2,
+		"call(Function<String, List<Integer>> f)": 1,
+		"save(Order order,":                       2,
+		"<init>":                                  -1,
+	} {
+		if got := ParamCount(sig); got != want {
+			t.Errorf("ParamCount(%q) = %d, want %d", sig, got, want)
+		}
+	}
+}
+
+func TestSimpleClassName(t *testing.T) {
+	for name, want := range map[string]string{
+		"com.acme.Outer.Inner": "Inner",
+		"Outer$1":              "Outer",
+		"Outer.Inner$2":        "Inner",
+		"Plain":                "Plain",
+	} {
+		if got := SimpleClassName(name); got != want {
+			t.Errorf("SimpleClassName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// 同一个文件中有多个类，花括号换行风格的类声明也能找到
+func TestEnclosingClassName(t *testing.T) {
+	lines := []string{
+		"class OrderService {",      // 0
+		"    void save(Order o) {",  // 1
+		"        persist(o);",       // 2
+		"    }",                     // 3
+		"}",                         // 4
+		"class FileService",         // 5
+		"{",                         // 6
+		"    void save(String p) {", // 7
+		"        exec(p);",          // 8
+		"    }",                     // 9
+		"}",                         // 10
+	}
+	for idx, want := range map[int]string{1: "OrderService", 2: "OrderService", 7: "FileService", 8: "FileService", 5: ""} {
+		if got := EnclosingClassName(lines, idx); got != want {
+			t.Errorf("EnclosingClassName(line %d) = %q, want %q", idx, got, want)
+		}
+	}
+}
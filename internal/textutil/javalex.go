@@ -0,0 +1,85 @@
+package textutil
+
+import "strings"
+
+// 词法状态 (跨行保持: 块注释和文本块可以跨越多行)，报告的语法高亮也使用
+const (
+	LexCode = iota
+	LexBlockComment
+	LexTextBlock
+)
+
+// MaskJavaSource 把注释、字符串、字符字面量和文本块 (""") 的内容替换为空格
+// 每一行长度不变，引号保留；结果只用于分析 (函数边界、引用位置是否在代码中)，不用于展示
+func MaskJavaSource(lines []string) []string {
+	masked := make([]string, len(lines))
+	state := LexCode
+
+	for n, line := range lines {
+		buf := []byte(line)
+		for i := 0; i < len(buf); i++ {
+			switch state {
+			case LexBlockComment:
+				if strings.HasPrefix(line[i:], "*/") {
+					buf[i], buf[i+1] = ' ', ' '
+					i++
+					state = LexCode
+				} else {
+					buf[i] = ' '
+				}
+
+			case LexTextBlock:
+				if line[i] == '\\' && i+1 < len(buf) {
+					buf[i], buf[i+1] = ' ', ' '
+					i++
+				} else if strings.HasPrefix(line[i:], `"""`) {
+					i += 2
+					state = LexCode
+				} else {
+					buf[i] = ' '
+				}
+
+			default:
+				switch {
+				case strings.HasPrefix(line[i:], "//"):
+					for j := i; j < len(buf); j++ {
+						buf[j] = ' '
+					}
+					i = len(buf)
+				case strings.HasPrefix(line[i:], "/*"):
+					buf[i], buf[i+1] = ' ', ' '
+					i++
+					state = LexBlockComment
+				case strings.HasPrefix(line[i:], `"""`):
+					i += 2
+					state = LexTextBlock
+				case line[i] == '"' || line[i] == '\'':
+					i = maskLiteral(buf, i)
+				}
+			}
+		}
+		masked[n] = string(buf)
+	}
+	return masked
+}
+
+// maskLiteral 屏蔽从 buf[start] (引号) 开始的单行字符串/字符字面量，返回结束引号的位置
+// 字面量没有闭合时屏蔽到行尾
+func maskLiteral(buf []byte, start int) int {
+	quote := buf[start]
+	for i := start + 1; i < len(buf); i++ {
+		switch buf[i] {
+		case '\\':
+			buf[i] = ' '
+			if i+1 < len(buf) {
+				buf[i+1] = ' '
+				i++
+			}
+		case quote:
+			return i
+		default:
+			buf[i] = ' '
+		}
+	}
+	return len(buf)
+}
@@ -0,0 +1,42 @@
+package textutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// 注释和字面量的内容替换为空格，每行长度不变，块注释和文本块的状态跨行保持
+func TestMaskJavaSource(t *testing.T) {
+	src := []string{
+		`if (c == '{') { // }`,
+		`String s = "a \" } b";`,
+		`/* { `,
+		`  } */ int x = 1;`,
+		`String t = """`,
+		`  { \""" }`,
+		`  """; call(t);`,
+		`char q = '\''; }`,
+	}
+	want := []string{
+		`if (c == ' ') {     `,
+		`String s = "        ";`,
+		`     `,
+		`       int x = 1;`,
+		`String t = """`,
+		`          `,
+		`  """; call(t);`,
+		`char q = '  '; }`,
+	}
+	got := MaskJavaSource(src)
+	for i := range src {
+		if got[i] != want[i] {
+			t.Errorf("line %d: %q, want %q", i, got[i], want[i])
+		}
+		if len(got[i]) != len(src[i]) {
+			t.Errorf("line %d: length %d, want %d", i, len(got[i]), len(src[i]))
+		}
+	}
+	if joined := strings.Join(got, "\n"); strings.Count(joined, "{") != strings.Count(joined, "}") {
+		t.Errorf("unbalanced braces after masking:\n%s", joined)
+	}
+}
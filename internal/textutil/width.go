@@ -0,0 +1,34 @@
+// Package textutil 提供终端/报告展示用的文本处理函数
+package textutil
+
+import "github.com/rivo/uniseg"
+
+// ellipsis 截断时追加的省略号
+const ellipsis = "..."
+
+// Truncate 按显示宽度截断字符串 (中日韩字符和 emoji 占 2 列)，超出时追加 "..."
+// 结果 (包括省略号) 不超过 width 列；以字素簇为单位截断，不会切开多字节字符或组合字符
+func Truncate(s string, width int) string {
+	if uniseg.StringWidth(s) <= width {
+		return s
+	}
+
+	// 为省略号预留 3 列，宽度不够时只截断不加省略号
+	budget, tail := width-len(ellipsis), ellipsis
+	if budget < 0 {
+		budget, tail = width, ""
+	}
+
+	rest := s
+	used := 0
+	state := -1
+	for rest != "" {
+		_, next, w, newState := uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > budget {
+			break
+		}
+		used += w
+		rest, state = next, newState
+	}
+	return s[:len(s)-len(rest)] + tail
+}
@@ -0,0 +1,67 @@
+package textutil
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 8, "hello..."},
+		{"", 3, ""},
+		// 中文每个字占 2 列: 7 列只放得下 2 个字 + 省略号
+		{"用户输入的命令", 7, "用户..."},
+		{"用户输入的命令", 8, "用户..."},
+		{"用户输入的命令", 9, "用户输..."},
+		{"用户输入的命令", 14, "用户输入的命令"},
+		{"exec(命令)", 8, "exec(..."},
+		// emoji 和组合字素簇不会被切开
+		{"😀😀😀😀", 7, "😀😀..."},
+		{"👨‍👩‍👧 family", 5, "👨‍👩‍👧..."},
+		{"ééééé", 4, "é..."},
+		// 宽度不够放省略号时直接截断
+		{"abcdef", 2, "ab"},
+		{"用户", 1, ""},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.in, tt.width)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+		if w := uniseg.StringWidth(got); w > tt.width {
+			t.Errorf("Truncate(%q, %d) = %q is %d columns wide", tt.in, tt.width, got, w)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d) = %q is not valid UTF-8", tt.in, tt.width, got)
+		}
+	}
+}
+
+func TestTruncateSourceLines(t *testing.T) {
+	// 含中文注释和 emoji 的源码行截断到进度行宽度
+	lines := []string{
+		`Runtime.getRuntime().exec(cmd); // 执行用户传入的命令，注意这里没有过滤`,
+		`log.info("🚀 部署开始: " + target);`,
+		`String 名称 = request.getParameter("名称");`,
+	}
+	for _, line := range lines {
+		for width := 3; width <= 60; width++ {
+			got := Truncate(line, width)
+			if w := uniseg.StringWidth(got); w > width {
+				t.Fatalf("Truncate(%q, %d) = %q is %d columns wide", line, width, got, w)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("Truncate(%q, %d) = %q is not valid UTF-8", line, width, got)
+			}
+		}
+	}
+}